@@ -13,32 +13,79 @@ import (
 	"github.com/jatolentino/tutorialspoint/api/middleware"
 	"github.com/jatolentino/tutorialspoint/api/web"
 	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/core/affiliate"
 	"github.com/jatolentino/tutorialspoint/core/auth"
+	"github.com/jatolentino/tutorialspoint/core/caption"
+	"github.com/jatolentino/tutorialspoint/core/category"
+	"github.com/jatolentino/tutorialspoint/core/bundle"
 	"github.com/jatolentino/tutorialspoint/core/cart"
+	"github.com/jatolentino/tutorialspoint/core/coupon"
 	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/enrollment"
+	"github.com/jatolentino/tutorialspoint/core/media"
 	"github.com/jatolentino/tutorialspoint/core/order"
+	"github.com/jatolentino/tutorialspoint/core/org"
+	"github.com/jatolentino/tutorialspoint/core/question"
+	"github.com/jatolentino/tutorialspoint/core/quiz"
+	"github.com/jatolentino/tutorialspoint/core/resource"
+	"github.com/jatolentino/tutorialspoint/core/sale"
+	"github.com/jatolentino/tutorialspoint/core/search"
+	"github.com/jatolentino/tutorialspoint/core/subscription"
 	"github.com/jatolentino/tutorialspoint/core/token"
+	"github.com/jatolentino/tutorialspoint/core/transcript"
+	"github.com/jatolentino/tutorialspoint/core/transfer"
 	"github.com/jatolentino/tutorialspoint/core/user"
 	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/core/wallet"
+	"github.com/jatolentino/tutorialspoint/core/webauthn"
+	"github.com/jatolentino/tutorialspoint/core/wishlist"
+	"github.com/jatolentino/tutorialspoint/rate"
+	"github.com/jatolentino/tutorialspoint/storage"
 	"github.com/sirupsen/logrus"
 	stripecl "github.com/stripe/stripe-go/v74/client"
 )
 
+// Mailer sends all the emails needed by handlers.
+type Mailer interface {
+	token.Mailer
+	order.Mailer
+	org.Mailer
+}
+
 // APIConfig contains all the mandatory dependencies required by handlers.
 type APIConfig struct {
 	CorsOrigin         string
 	Log                logrus.FieldLogger
 	DB                 *sqlx.DB
 	Session            *scs.SessionManager
-	Mailer             token.Mailer
+	Mailer             Mailer
 	TokenTimeout       time.Duration
 	Background         *background.Background
 	Paypal             *paypal.Client
 	Stripe             *stripecl.API
 	StripeCfg          config.Stripe
+	RazorpayCfg        config.Razorpay
+	CoinbaseCfg        config.Coinbase
+	MollieCfg          config.Mollie
+	TranscodingCfg     config.Transcoding
+	RefundsCfg         config.Refunds
+	UploadsCfg         config.Uploads
+	ResourcesCfg       config.Resources
+	ProgressCfg        config.Progress
+	PrerequisitesCfg   config.Prerequisites
+	AbandonedCartsCfg  config.AbandonedCarts
 	Providers          map[string]auth.Provider
 	LoginRedirectURL   string
+	OrgJoinURL         string
 	ActivationRequired bool
+	TwoFactorCfg       config.TwoFactor
+	WebauthnCfg        config.Webauthn
+	Search             search.Engine
+	Storage            storage.Storage
+
+	// VideoListeners are notified about changes of videos,
+	// besides keeping the search index up to date.
+	VideoListeners []video.Listener
 }
 
 // api represents our server api.
@@ -75,52 +122,304 @@ func APIMux(cfg APIConfig) http.Handler {
 	}
 
 	authen := auth.Authenticate(cfg.Session)
+	identify := auth.Identify(cfg.Session)
 	admin := auth.Admin(cfg.Session)
+	instructor := auth.Instructor(cfg.Session)
+
+	// Keep the search index in sync with the catalog.
+	indexer := &search.Indexer{DB: cfg.DB, Engine: cfg.Search, BG: cfg.Background}
+	videoListeners := append(video.Listeners{indexer}, cfg.VideoListeners...)
+
+	// Accept payments through all the supported providers.
+	provs := order.NewProviders(cfg.Paypal, cfg.Stripe, cfg.StripeCfg, cfg.RazorpayCfg, cfg.CoinbaseCfg, cfg.MollieCfg)
+
+	// Subscribe users to the all-access plan with stripe billing.
+	billing := subscription.NewStripe(cfg.Stripe, cfg.StripeCfg)
+
+	// Users whose stripe checkout expired can be reminded to complete it.
+	var cartURL string
+	if cfg.StripeCfg.ExpiredReminder {
+		cartURL = cfg.StripeCfg.CancelURL
+	}
+
+	// Players report the progress often, limit each user to spare the database.
+	progressLimiter := rate.NewLimiter(cfg.ProgressCfg.Burst, 10, rate.Every(cfg.ProgressCfg.Interval))
 
 	// Setup the handlers.
 	a.Handle(http.MethodPost, "/auth/signup", auth.HandleSignup(cfg.DB, cfg.Session, cfg.ActivationRequired))
-	a.Handle(http.MethodPost, "/auth/login", auth.HandleLogin(cfg.DB, cfg.Session))
+	a.Handle(http.MethodPost, "/auth/login", auth.HandleLogin(cfg.DB, cfg.Session, cfg.TwoFactorCfg))
+	a.Handle(http.MethodPost, "/auth/passkey-login/options", webauthn.HandleLoginOptions(cfg.Session, cfg.WebauthnCfg))
+	a.Handle(http.MethodPost, "/auth/passkey-login", webauthn.HandleLogin(cfg.DB, cfg.Session, cfg.WebauthnCfg, cfg.TwoFactorCfg))
+	a.Handle(http.MethodPost, "/auth/totp", auth.HandleVerifyTOTP(cfg.DB, cfg.Session))
 	a.Handle(http.MethodPost, "/auth/logout", auth.HandleLogout(cfg.Session))
 	a.Handle(http.MethodGet, "/auth/oauth-login/{provider}", auth.HandleOauthLogin(cfg.Session, cfg.Providers))
-	a.Handle(http.MethodGet, "/auth/oauth-callback/{provider}", auth.HandleOauthCallback(cfg.DB, cfg.Session, cfg.Providers, cfg.LoginRedirectURL))
+	a.Handle(http.MethodGet, "/auth/oauth-link/{provider}", auth.HandleOauthLink(cfg.Session, cfg.Providers), authen)
+	a.Handle(http.MethodGet, "/auth/oauth-callback/{provider}", auth.HandleOauthCallback(cfg.DB, cfg.Session, cfg.Providers, cfg.LoginRedirectURL, cfg.TwoFactorCfg))
+	a.Handle(http.MethodPost, "/auth/oauth-callback/{provider}", auth.HandleOauthFormPost())
 
 	a.Handle(http.MethodPost, "/tokens", token.HandleToken(cfg.DB, cfg.Mailer, cfg.TokenTimeout, cfg.Background))
 	a.Handle(http.MethodPost, "/tokens/activate", token.HandleActivation(cfg.DB, cfg.Session))
 	a.Handle(http.MethodPost, "/tokens/recover", token.HandleRecovery(cfg.DB))
+	a.Handle(http.MethodPost, "/tokens/claim", token.HandleClaim(cfg.DB, cfg.Session))
+	a.Handle(http.MethodPost, "/tokens/email", token.HandleEmailConfirm(cfg.DB, cfg.Mailer, cfg.Background))
+	a.Handle(http.MethodPost, "/tokens/login", token.HandleLogin(cfg.DB, cfg.Session, cfg.TwoFactorCfg))
 
 	a.Handle(http.MethodGet, "/users/current", user.HandleShowCurrent(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/users/current/country", user.HandleUpdateCountry(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/users/current/totp", auth.HandleEnrollTOTP(cfg.DB, cfg.Session, cfg.TwoFactorCfg))
+	a.Handle(http.MethodPost, "/users/current/totp/confirm", auth.HandleConfirmTOTP(cfg.DB, cfg.Session))
+	a.Handle(http.MethodPost, "/users/current/totp/recovery-codes", auth.HandleRecoveryCodes(cfg.DB), authen)
+	a.Handle(http.MethodDelete, "/users/current/totp", auth.HandleDisableTOTP(cfg.DB, cfg.TwoFactorCfg), authen)
+	a.Handle(http.MethodGet, "/users/current/passkeys", webauthn.HandleList(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/users/current/passkeys/options", webauthn.HandleRegisterOptions(cfg.DB, cfg.Session, cfg.WebauthnCfg), authen)
+	a.Handle(http.MethodPost, "/users/current/passkeys", webauthn.HandleRegister(cfg.DB, cfg.Session, cfg.WebauthnCfg), authen)
+	a.Handle(http.MethodDelete, "/users/current/passkeys/{id}", webauthn.HandleDelete(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/users/current/email", token.HandleEmailChange(cfg.DB, cfg.Mailer, cfg.TokenTimeout, cfg.Background), authen)
+	a.Handle(http.MethodGet, "/users/me/history", video.HandleListHistory(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/users/me/completion", course.HandleListCompletion(cfg.DB), authen)
 	a.Handle(http.MethodGet, "/users/{id}", user.HandleShow(cfg.DB), authen)
 	a.Handle(http.MethodPost, "/users", user.HandleCreate(cfg.DB), authen)
 
 	a.Handle(http.MethodGet, "/courses/owned", course.HandleListOwned(cfg.DB), authen)
-	a.Handle(http.MethodGet, "/courses/{course_id}/videos", video.HandleListByCourse(cfg.DB))
+	a.Handle(http.MethodGet, "/courses/featured", course.HandleListFeatured(cfg.DB), identify)
+	a.Handle(http.MethodPut, "/courses/featured", course.HandleSetFeatured(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/courses/instructed", course.HandleListInstructed(cfg.DB), authen, instructor)
+	a.Handle(http.MethodGet, "/courses/{course_id}/videos", video.HandleListByCourse(cfg.DB), identify)
+	a.Handle(http.MethodPut, "/courses/{course_id}/videos/order", video.HandleReorder(cfg.DB), authen, instructor)
+	a.Handle(http.MethodPost, "/courses/{course_id}/videos/copy", video.HandleCopy(cfg.DB, videoListeners), admin)
+	a.Handle(http.MethodPost, "/courses/{course_id}/sections", video.HandleCreateSection(cfg.DB), authen, instructor)
+	a.Handle(http.MethodPut, "/sections/{id}", video.HandleUpdateSection(cfg.DB), authen, instructor)
+	a.Handle(http.MethodDelete, "/sections/{id}", video.HandleDeleteSection(cfg.DB), authen, instructor)
 	a.Handle(http.MethodGet, "/courses/{course_id}/progress", video.HandleListProgressByCourse(cfg.DB), authen)
-	a.Handle(http.MethodGet, "/courses/{id}", course.HandleShow(cfg.DB))
-	a.Handle(http.MethodGet, "/courses", course.HandleList(cfg.DB))
-	a.Handle(http.MethodPost, "/courses", course.HandleCreate(cfg.DB), admin)
-	a.Handle(http.MethodPut, "/courses/{id}", course.HandleUpdate(cfg.DB), admin)
-
-	a.Handle(http.MethodGet, "/videos/{id}/full", video.HandleShowFull(cfg.DB), authen)
-	a.Handle(http.MethodGet, "/videos/{id}/free", video.HandleShowFree(cfg.DB))
-	a.Handle(http.MethodGet, "/videos/{id}", video.HandleShow(cfg.DB))
-	a.Handle(http.MethodGet, "/videos", video.HandleList(cfg.DB))
-	a.Handle(http.MethodPost, "/videos", video.HandleCreate(cfg.DB), admin)
-	a.Handle(http.MethodPut, "/videos/{id}/progress", video.HandleUpdateProgress(cfg.DB), authen)
-	a.Handle(http.MethodPut, "/videos/{id}", video.HandleUpdate(cfg.DB), admin)
-
-	a.Handle(http.MethodGet, "/cart", cart.HandleShow(cfg.DB), authen)
-	a.Handle(http.MethodDelete, "/cart", cart.HandleDelete(cfg.DB), authen)
-	a.Handle(http.MethodPut, "/cart/items", cart.HandleCreateItem(cfg.DB), authen)
-	a.Handle(http.MethodDelete, "/cart/items/{course_id}", cart.HandleDeleteItem(cfg.DB), authen)
-
-	a.Handle(http.MethodPost, "/orders/paypal", order.HandlePaypalCheckout(cfg.DB, cfg.Paypal), authen)
-	a.Handle(http.MethodPost, "/orders/paypal/{id}/capture", order.HandlePaypalCapture(cfg.DB, cfg.Paypal), authen)
-	a.Handle(http.MethodPost, "/orders/stripe", order.HandleStripeCheckout(cfg.DB, cfg.Stripe, cfg.StripeCfg), authen)
-	a.Handle(http.MethodPost, "/orders/stripe/capture", order.HandleStripeCapture(cfg.DB, cfg.StripeCfg))
+	a.Handle(http.MethodPut, "/courses/{id}/prerequisites", course.HandleSetPrerequisites(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/courses/{id}/export", transfer.HandleExport(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/courses/{id}/completion", course.HandleShowCompletion(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/courses/{id}/related", course.HandleListRelated(cfg.DB), identify)
+	a.Handle(http.MethodGet, "/courses/{id}/prices", course.HandleListPrices(cfg.DB), admin)
+	a.Handle(http.MethodPost, "/courses/{id}/faqs", course.HandleCreateFAQ(cfg.DB), admin)
+	a.Handle(http.MethodPut, "/faqs/{id}", course.HandleUpdateFAQ(cfg.DB), admin)
+	a.Handle(http.MethodDelete, "/faqs/{id}", course.HandleDeleteFAQ(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/courses/{id}/grants", course.HandleListGrants(cfg.DB), admin)
+	a.Handle(http.MethodPost, "/enrollments", enrollment.HandleEnroll(cfg.DB), authen, admin)
+	a.Handle(http.MethodPost, "/courses/{id}/grants", course.HandleGrant(cfg.DB), authen, admin)
+	a.Handle(http.MethodPost, "/courses/{id}/grants/{user_id}/revoke", course.HandleRevokeGrant(cfg.DB), authen, admin)
+	a.Handle(http.MethodGet, "/courses/{id}/revisions", course.HandleListRevisions(cfg.DB), authen, instructor)
+	a.Handle(http.MethodPost, "/courses/{id}/revisions/{revision_id}/rollback", course.HandleRollback(cfg.DB, indexer), authen, instructor)
+	a.Handle(http.MethodGet, "/courses/{id}/regions", course.HandleListRegionalPrices(cfg.DB), admin)
+	a.Handle(http.MethodPut, "/courses/{id}/regions/{region}", course.HandleSetRegionalPrice(cfg.DB), admin)
+	a.Handle(http.MethodDelete, "/courses/{id}/regions/{region}", course.HandleDeleteRegionalPrice(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/courses/{id}", course.HandleShow(cfg.DB, courseExpansions(cfg.DB)), identify)
+	a.Handle(http.MethodGet, "/courses", course.HandleList(cfg.DB), identify)
+	a.Handle(http.MethodPost, "/courses", course.HandleCreate(cfg.DB, indexer), authen, instructor)
+	a.Handle(http.MethodPost, "/courses/import", transfer.HandleImport(cfg.DB, indexer, videoListeners), authen, admin)
+	a.Handle(http.MethodPut, "/courses/{id}", course.HandleUpdate(cfg.DB, indexer), authen, instructor)
+	a.Handle(http.MethodPut, "/courses/{id}/status", course.HandleSetStatus(cfg.DB, indexer), authen, instructor)
+	a.Handle(http.MethodPost, "/courses/{id}/archive", course.HandleArchive(cfg.DB, indexer), admin)
+	a.Handle(http.MethodPost, "/courses/{id}/restore", course.HandleRestore(cfg.DB, indexer), admin)
+	a.Handle(http.MethodPut, "/courses/{id}/rating", course.HandleRate(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/courses/{course_id}/categories", category.HandleSetCourseCategories(cfg.DB), admin)
+	a.Handle(http.MethodPut, "/courses/{course_id}/tags", category.HandleSetCourseTags(cfg.DB), admin)
+
+	a.Handle(http.MethodGet, "/categories", category.HandleListCategories(cfg.DB))
+	a.Handle(http.MethodPost, "/categories", category.HandleCreateCategory(cfg.DB), admin)
+	a.Handle(http.MethodPut, "/categories/{id}", category.HandleUpdateCategory(cfg.DB), admin)
+	a.Handle(http.MethodDelete, "/categories/{id}", category.HandleDeleteCategory(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/tags", category.HandleListTags(cfg.DB))
+	a.Handle(http.MethodPost, "/tags", category.HandleCreateTag(cfg.DB), admin)
+	a.Handle(http.MethodPut, "/tags/{id}", category.HandleUpdateTag(cfg.DB), admin)
+	a.Handle(http.MethodDelete, "/tags/{id}", category.HandleDeleteTag(cfg.DB), admin)
+
+	a.Handle(http.MethodGet, "/videos/{id}/encoding", media.HandleShowJob(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/videos/{id}/full", video.HandleShowFull(cfg.DB, captionTracks(cfg.DB), cfg.PrerequisitesCfg.Enforced), authen)
+	a.Handle(http.MethodGet, "/videos/{id}/free", video.HandleShowFree(cfg.DB), identify)
+	a.Handle(http.MethodPost, "/videos/import", video.HandleImport(cfg.DB, videoListeners), admin)
+	a.Handle(http.MethodGet, "/videos/deleted", video.HandleListDeleted(cfg.DB), admin)
+	a.Handle(http.MethodPost, "/videos/{id}/restore", video.HandleRestore(cfg.DB, indexer), admin)
+	a.Handle(http.MethodGet, "/videos/{id}", video.HandleShow(cfg.DB), identify)
+	a.Handle(http.MethodGet, "/videos", video.HandleList(cfg.DB), identify)
+	a.Handle(http.MethodPost, "/videos", video.HandleCreate(cfg.DB, videoListeners), authen, instructor)
+	a.Handle(http.MethodPost, "/videos/transcoding/callback", video.HandleTranscodingCallback(cfg.DB, cfg.TranscodingCfg, videoListeners))
+	a.Handle(http.MethodPut, "/videos/{id}/progress", video.HandleUpdateProgress(cfg.DB, progressLimiter), authen)
+	a.Handle(http.MethodPost, "/videos/progress", video.HandleUpdateProgressBatch(cfg.DB, progressLimiter), authen)
+	a.Handle(http.MethodGet, "/videos/{id}/resume", video.HandleShowResume(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/videos/{id}/chapters", video.HandleReplaceChapters(cfg.DB), authen, instructor)
+	a.Handle(http.MethodGet, "/videos/{id}/preview", video.HandleShowPreview(cfg.DB), identify)
+	a.Handle(http.MethodPut, "/videos/{id}/preview", video.HandlePutPreview(cfg.DB), authen, instructor)
+	a.Handle(http.MethodDelete, "/videos/{id}/preview", video.HandleDeletePreview(cfg.DB), authen, instructor)
+	a.Handle(http.MethodPut, "/videos/{id}", video.HandleUpdate(cfg.DB, videoListeners), authen, instructor)
+	a.Handle(http.MethodDelete, "/videos/{id}", video.HandleDelete(cfg.DB, indexer), authen, instructor)
+	a.Handle(http.MethodPost, "/uploads", video.HandleCreateUpload(cfg.DB, cfg.UploadsCfg), authen, instructor)
+	a.Handle(http.MethodGet, "/uploads/{id}", video.HandleShowUpload(cfg.DB), authen, instructor)
+	a.Handle(http.MethodPatch, "/uploads/{id}", video.HandleUploadChunk(cfg.DB, cfg.UploadsCfg, cfg.Storage), authen, instructor)
+	a.Handle(http.MethodGet, "/stream/{video_id}/{path:.*}", video.HandleStream(cfg.DB, cfg.Storage), authen)
+
+	a.Handle(http.MethodGet, "/courses/{course_id}/resources", resource.HandleList(cfg.DB))
+	a.Handle(http.MethodPost, "/courses/{course_id}/resources", resource.HandleCreate(cfg.DB, cfg.ResourcesCfg, cfg.Storage), admin)
+	a.Handle(http.MethodGet, "/resources/{id}/download", resource.HandleDownload(cfg.DB, cfg.ResourcesCfg, cfg.Storage), authen)
+	a.Handle(http.MethodDelete, "/resources/{id}", resource.HandleDelete(cfg.DB, cfg.Storage), admin)
+
+	a.Handle(http.MethodGet, "/videos/{video_id}/questions", question.HandleList(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/videos/{video_id}/questions", question.HandleCreate(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/questions/{id}", question.HandleShow(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/questions/{id}", question.HandleUpdate(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/questions/{id}/replies", question.HandleListReplies(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/questions/{id}/replies", question.HandleCreateReply(cfg.DB), authen, instructor)
+	a.Handle(http.MethodPut, "/replies/{id}", question.HandleUpdateReply(cfg.DB), authen, instructor)
+
+	a.Handle(http.MethodGet, "/videos/{video_id}/quiz", quiz.HandleShow(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/videos/{video_id}/quiz", quiz.HandlePut(cfg.DB), admin)
+	a.Handle(http.MethodDelete, "/videos/{video_id}/quiz", quiz.HandleDelete(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/videos/{video_id}/quiz/attempts", quiz.HandleListAttempts(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/videos/{video_id}/quiz/attempts", quiz.HandleCreateAttempt(cfg.DB), authen)
+
+	a.Handle(http.MethodGet, "/videos/{video_id}/transcript", transcript.HandleShow(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/videos/{video_id}/transcript", transcript.HandlePut(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/courses/{course_id}/transcripts/search", transcript.HandleSearch(cfg.DB), authen)
+
+	a.Handle(http.MethodGet, "/search", search.HandleSearch(cfg.Search))
+	a.Handle(http.MethodPost, "/search/reindex", search.HandleReindex(cfg.DB, cfg.Search, cfg.Background), admin)
+
+	a.Handle(http.MethodGet, "/captions/unreviewed", caption.HandleListUnreviewed(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/videos/{video_id}/captions", caption.HandleList(cfg.DB), admin)
+	a.Handle(http.MethodPut, "/videos/{video_id}/captions/{language}", caption.HandlePut(cfg.DB), admin)
+	a.Handle(http.MethodDelete, "/videos/{video_id}/captions/{language}", caption.HandleDelete(cfg.DB), admin)
+	a.Handle(http.MethodPost, "/videos/{video_id}/captions/{language}/review", caption.HandleReview(cfg.DB), admin)
+
+	a.Handle(http.MethodGet, "/cart", cart.HandleShow(cfg.DB, cfg.Session), identify)
+	a.Handle(http.MethodDelete, "/cart", cart.HandleDelete(cfg.DB, cfg.Session), identify)
+	a.Handle(http.MethodGet, "/cart/validate", cart.HandleValidate(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/cart/summary", order.HandleSummary(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/cart/items", cart.HandleCreateItem(cfg.DB, cfg.Session), identify)
+	a.Handle(http.MethodPut, "/cart/items/bulk", cart.HandleCreateItems(cfg.DB), authen)
+	a.Handle(http.MethodDelete, "/cart/items/{course_id}", cart.HandleDeleteItem(cfg.DB, cfg.Session), identify)
+	a.Handle(http.MethodPost, "/cart/items/{course_id}/save", cart.HandleSaveItem(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/cart/saved", cart.HandleListSaved(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/cart/saved/{course_id}/move", cart.HandleMoveToCart(cfg.DB), authen)
+	a.Handle(http.MethodDelete, "/cart/saved/{course_id}", cart.HandleDeleteSaved(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/cart/reminders/unsubscribe", cart.HandleUnsubscribe(cfg.DB, cfg.AbandonedCartsCfg.Secret))
+	a.Handle(http.MethodPut, "/cart/bundles", cart.HandleCreateBundle(cfg.DB), authen)
+	a.Handle(http.MethodDelete, "/cart/bundles/{bundle_id}", cart.HandleDeleteBundle(cfg.DB), authen)
+
+	a.Handle(http.MethodGet, "/wishlist", wishlist.HandleList(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/wishlist/items", wishlist.HandleCreate(cfg.DB), authen)
+	a.Handle(http.MethodDelete, "/wishlist/items/{course_id}", wishlist.HandleDelete(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/wishlist/items/{course_id}/move", wishlist.HandleMoveToCart(cfg.DB), authen)
+
+	a.Handle(http.MethodGet, "/bundles", bundle.HandleList(cfg.DB))
+	a.Handle(http.MethodGet, "/bundles/{id}", bundle.HandleShow(cfg.DB))
+	a.Handle(http.MethodPost, "/bundles", bundle.HandleCreate(cfg.DB), admin)
+	a.Handle(http.MethodPut, "/bundles/{id}", bundle.HandleUpdate(cfg.DB), admin)
+	a.Handle(http.MethodDelete, "/bundles/{id}", bundle.HandleDelete(cfg.DB), admin)
+
+	a.Handle(http.MethodPost, "/sales", sale.HandleCreate(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/sales", sale.HandleList(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/sales/{id}", sale.HandleShow(cfg.DB), admin)
+	a.Handle(http.MethodPut, "/sales/{id}", sale.HandleUpdate(cfg.DB), admin)
+	a.Handle(http.MethodDelete, "/sales/{id}", sale.HandleDelete(cfg.DB), admin)
+
+	a.Handle(http.MethodPost, "/coupons", coupon.HandleCreate(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/coupons", coupon.HandleList(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/coupons/{id}", coupon.HandleShow(cfg.DB), admin)
+	a.Handle(http.MethodPut, "/coupons/{id}", coupon.HandleUpdate(cfg.DB), admin)
+	a.Handle(http.MethodDelete, "/coupons/{id}", coupon.HandleDelete(cfg.DB), admin)
+
+	a.Handle(http.MethodPost, "/affiliates", affiliate.HandleCreate(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/affiliates", affiliate.HandleList(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/affiliates/mine", affiliate.HandleShowMine(cfg.DB), authen)
+
+	a.Handle(http.MethodGet, "/wallet", wallet.HandleShowMine(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/users/{id}/wallet", wallet.HandleGrant(cfg.DB), admin)
+
+	a.Handle(http.MethodPost, "/gifts/{code}/redeem", order.HandleRedeemGift(cfg.DB), authen)
+
+	a.Handle(http.MethodGet, "/orders/mine", order.HandleListMine(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/orders", order.HandleSearch(cfg.DB), admin)
+	a.Handle(http.MethodPost, "/orders/free", order.HandleCheckout(cfg.DB, provs[order.ProviderFree]), authen)
+	a.Handle(http.MethodPost, "/orders/paypal", order.HandleCheckout(cfg.DB, provs[order.ProviderPaypal]), authen)
+	a.Handle(http.MethodPost, "/orders/paypal/{id}/capture", order.HandleCapture(cfg.DB, provs[order.ProviderPaypal]), authen)
+	a.Handle(http.MethodPost, "/orders/paypal/guest", order.HandleGuestCheckout(cfg.DB, provs[order.ProviderPaypal]))
+	a.Handle(http.MethodPost, "/orders/paypal/guest/{id}/capture", order.HandleCapture(cfg.DB, provs[order.ProviderPaypal]))
+	a.Handle(http.MethodPost, "/orders/stripe", order.HandleCheckout(cfg.DB, provs[order.ProviderStripe]), authen)
+	a.Handle(http.MethodPost, "/orders/stripe/guest", order.HandleGuestCheckout(cfg.DB, provs[order.ProviderStripe]))
+	a.Handle(http.MethodPost, "/orders/stripe/capture", order.HandleWebhook(cfg.DB, provs[order.ProviderStripe], cfg.Mailer, cfg.Background, cartURL))
+	a.Handle(http.MethodPost, "/orders/stripe/intent", order.HandleCheckout(cfg.DB, provs[order.ProviderStripeIntent]), authen)
+	a.Handle(http.MethodPost, "/orders/stripe/intent/webhook", order.HandleWebhook(cfg.DB, provs[order.ProviderStripeIntent], cfg.Mailer, cfg.Background, ""))
+	a.Handle(http.MethodPost, "/orders/razorpay", order.HandleCheckout(cfg.DB, provs[order.ProviderRazorpay]), authen)
+	a.Handle(http.MethodPost, "/orders/razorpay/{id}/capture", order.HandleCapture(cfg.DB, provs[order.ProviderRazorpay]), authen)
+	a.Handle(http.MethodPost, "/orders/razorpay/webhook", order.HandleWebhook(cfg.DB, provs[order.ProviderRazorpay], cfg.Mailer, cfg.Background, ""))
+	a.Handle(http.MethodPost, "/orders/coinbase", order.HandleCheckout(cfg.DB, provs[order.ProviderCoinbase]), authen)
+	a.Handle(http.MethodPost, "/orders/coinbase/webhook", order.HandleWebhook(cfg.DB, provs[order.ProviderCoinbase], cfg.Mailer, cfg.Background, ""))
+	a.Handle(http.MethodPost, "/orders/mollie", order.HandleCheckout(cfg.DB, provs[order.ProviderMollie]), authen)
+	a.Handle(http.MethodPost, "/orders/mollie/webhook", order.HandleWebhook(cfg.DB, provs[order.ProviderMollie], cfg.Mailer, cfg.Background, ""))
+	a.Handle(http.MethodGet, "/orders/{id}/invoice", order.HandleShowInvoice(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/orders/{id}/failures", order.HandleListFailures(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/orders/mismatches", order.HandleListMismatches(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/orders/{id}", order.HandleShow(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/webhooks/events", order.HandleListWebhookEvents(cfg.DB), admin)
+	a.Handle(http.MethodPost, "/webhooks/events/{id}/replay", order.HandleReplayWebhookEvent(cfg.DB, provs), admin)
+	a.Handle(http.MethodGet, "/compensations", order.HandleListCompensations(cfg.DB), admin)
+	a.Handle(http.MethodPost, "/compensations/{provider_id}/requeue", order.HandleRequeueCompensation(cfg.DB), admin)
+	a.Handle(http.MethodPost, "/orders/{id}/refund", order.HandleRefund(cfg.DB, provs), admin)
+	a.Handle(http.MethodPost, "/orders/{id}/items/{course_id}/refund", order.HandleRefundItem(cfg.DB, provs), admin)
+	a.Handle(http.MethodPost, "/orders/{id}/refund-requests", order.HandleRequestRefund(cfg.DB, cfg.RefundsCfg.Window), authen)
+	a.Handle(http.MethodGet, "/refund-requests", order.HandleListRefundRequests(cfg.DB), admin)
+	a.Handle(http.MethodPost, "/refund-requests/{id}/approve", order.HandleApproveRefund(cfg.DB, provs), admin)
+	a.Handle(http.MethodPost, "/refund-requests/{id}/deny", order.HandleDenyRefund(cfg.DB), admin)
+	a.Handle(http.MethodGet, "/reports/revenue", order.HandleRevenue(cfg.DB), admin)
+
+	a.Handle(http.MethodGet, "/subscriptions/mine", subscription.HandleShowMine(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/subscriptions/stripe", subscription.HandleCheckout(cfg.DB, billing), authen)
+	a.Handle(http.MethodPost, "/subscriptions/stripe/webhook", subscription.HandleWebhook(cfg.DB, billing))
+
+	a.Handle(http.MethodPost, "/orgs", org.HandleCreate(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/orgs/{id}", org.HandleShow(cfg.DB), authen)
+	a.Handle(http.MethodPost, "/orgs/{id}/invitations", org.HandleInvite(cfg.DB, cfg.Mailer, cfg.OrgJoinURL, cfg.Background), authen)
+	a.Handle(http.MethodPost, "/orgs/{id}/join", org.HandleJoin(cfg.DB), authen)
+	a.Handle(http.MethodGet, "/orgs/{id}/progress", org.HandleListProgress(cfg.DB), authen)
+	a.Handle(http.MethodPut, "/orgs/{id}/seats/{course_id}/assignments/{user_id}", org.HandleAssign(cfg.DB), authen)
+	a.Handle(http.MethodDelete, "/orgs/{id}/seats/{course_id}/assignments/{user_id}", org.HandleRevoke(cfg.DB), authen)
 
 	return a.Router
 }
 
+// courseExpansions returns the relations which can be expanded on a course.
+func courseExpansions(db *sqlx.DB) web.Expansions {
+	return web.Expansions{}.
+		With("videos", func(ctx context.Context, id string) (any, error) {
+			return video.FetchPublishedByCourse(ctx, db, id)
+		}).
+		With("categories", func(ctx context.Context, id string) (any, error) {
+			return category.FetchCategoriesByCourse(ctx, db, id)
+		}).
+		With("tags", func(ctx context.Context, id string) (any, error) {
+			return category.FetchTagsByCourse(ctx, db, id)
+		}).
+		With("prerequisites", func(ctx context.Context, id string) (any, error) {
+			return course.FetchPrerequisites(ctx, db, id)
+		}).
+		With("faq", func(ctx context.Context, id string) (any, error) {
+			return course.FetchFAQs(ctx, db, id)
+		})
+}
+
+// captionTracks returns the reviewed captions of a video as the tracks
+// rendered by players.
+func captionTracks(db *sqlx.DB) video.TrackFetcher {
+	return func(ctx context.Context, videoID string) ([]video.Track, error) {
+		cs, err := caption.FetchReviewedByVideo(ctx, db, videoID)
+		if err != nil {
+			return nil, err
+		}
+
+		tracks := make([]video.Track, 0, len(cs))
+		for _, c := range cs {
+			tracks = append(tracks, video.Track{Language: c.Language, VTT: c.VTT})
+		}
+
+		return tracks, nil
+	}
+}
+
 // Handle sets a handler function for a given HTTP method and path pair
 // to the application router.
 func (a *api) Handle(method string, path string, handler web.Handler, mw ...web.Middleware) {
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/affiliate"
+)
+
+// adminID is the id of the seeded administrator.
+const adminID = "ae127240-ce13-4789-aafd-d2f31e7ee487"
+
+type affiliateTest struct {
+	*TestEnv
+}
+
+func TestAffiliate(t *testing.T) {
+	env, err := NewTestEnv(t, "affiliate_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	at := &affiliateTest{env}
+	wt := &walletTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	at.createOK(t, adminID, "friends10", 10)
+
+	c := ct.createCourseOK(t)
+	rt.createItemOK(t, c.ID)
+	wt.grantOK(t, 100000)
+
+	// Unknown codes are refused, nothing is bought.
+	if code := at.buyWithCredit(t, "nobody"); code != http.StatusUnprocessableEntity {
+		t.Fatalf("buying with an unknown referral code: expected 422, got %d", code)
+	}
+
+	// Codes are case insensitive.
+	if code := at.buyWithCredit(t, "Friends10"); code != http.StatusOK {
+		t.Fatalf("can't buy with a referral code: status code %d", code)
+	}
+
+	st := at.showMineOK(t)
+	if st.Conversions != 1 || len(st.Earnings) != 1 {
+		t.Fatalf("expected a conversion, got %+v", st)
+	}
+
+	if e := st.Earnings[0]; e.Sales.Units != c.Price.Units || e.Commission.Units != c.Price.Units*10/100 {
+		t.Fatalf("unexpected earnings of %s: %+v", c.Price, e)
+	}
+}
+
+func (at *affiliateTest) createOK(t *testing.T, userID string, code string, commission int) {
+	if err := Login(at.Server, at.AdminEmail, at.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(at.Server)
+
+	body, err := json.Marshal(affiliate.AffiliateNew{UserID: userID, Code: code, Commission: commission})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, at.URL+"/affiliates", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := at.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create affiliate: status code %s", w.Status)
+	}
+}
+
+// buyWithCredit buys the cart with store credit, referred by the passed
+// code, and returns the status code.
+func (at *affiliateTest) buyWithCredit(t *testing.T, ref string) int {
+	if err := Login(at.Server, at.UserEmail, at.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(at.Server)
+
+	r, err := http.NewRequest(http.MethodPost, at.URL+"/orders/stripe?wallet=true&ref="+ref, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := at.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+// showMineOK returns the stats of the administrator as a referrer.
+func (at *affiliateTest) showMineOK(t *testing.T) affiliate.Stats {
+	if err := Login(at.Server, at.AdminEmail, at.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(at.Server)
+
+	r, err := http.NewRequest(http.MethodGet, at.URL+"/affiliates/mine", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := at.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't show affiliate stats: status code %s", w.Status)
+	}
+
+	var got affiliate.Stats
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal affiliate stats: %v", err)
+	}
+
+	return got
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/cart"
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+func TestArchive(t *testing.T) {
+	env, err := NewTestEnv(t, "archive_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &faqTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+	rt := &cartTest{env}
+	et := &freeTest{env}
+	cpt := &completionTest{env}
+	gt := &guestTest{env}
+
+	crs := ct.createCourseOK(t)
+	vt.createVideoOK(t, crs.ID, 1)
+
+	rt.createItemOK(t, crs.ID)
+	et.createFreeCouponOK(t)
+	if code := et.enroll(t, "FREE100"); code != http.StatusOK {
+		t.Fatalf("enrolling in course: expected 200, got %d", code)
+	}
+
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	ft.do(t, http.MethodPost, "/courses/"+crs.ID+"/restore", nil, nil, http.StatusConflict)
+
+	var archived course.Course
+	ft.do(t, http.MethodPost, "/courses/"+crs.ID+"/archive", nil, &archived, http.StatusOK)
+	if archived.Status != course.StatusArchived {
+		t.Fatalf("expected the course archived, got %s", archived.Status)
+	}
+	ft.do(t, http.MethodPost, "/courses/"+crs.ID+"/archive", nil, nil, http.StatusConflict)
+
+	Logout(ft.Server)
+
+	var catalog []course.Course
+	if code := cpt.get(t, "/courses", &catalog); code != http.StatusOK {
+		t.Fatalf("listing courses: expected 200, got %d", code)
+	}
+	for _, c := range catalog {
+		if c.ID == crs.ID {
+			t.Fatalf("expected the archived course hidden from the catalog")
+		}
+	}
+
+	w := gt.checkout(t, "guest@test.com", crs.ID)
+	w.Body.Close()
+	if w.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("guest checkout of an archived course: expected 422, got %s", w.Status)
+	}
+
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	ft.do(t, http.MethodPut, "/cart/items", cart.ItemNew{CourseID: crs.ID}, nil, http.StatusUnprocessableEntity)
+
+	var owned []course.Course
+	ft.do(t, http.MethodGet, "/courses/owned", nil, &owned, http.StatusOK)
+	if len(owned) != 1 || owned[0].ID != crs.ID {
+		t.Fatalf("expected the owners to keep the archived course, got %+v", owned)
+	}
+
+	Logout(ft.Server)
+
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	var restored course.Course
+	ft.do(t, http.MethodPost, "/courses/"+crs.ID+"/restore", nil, &restored, http.StatusOK)
+	if restored.Status != course.StatusPublished {
+		t.Fatalf("expected the course published again, got %s", restored.Status)
+	}
+}
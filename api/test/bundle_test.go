@@ -0,0 +1,134 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/bundle"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/validate"
+)
+
+type bundleTest struct {
+	*TestEnv
+}
+
+func TestBundle(t *testing.T) {
+	env, err := NewTestEnv(t, "bundle_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	bt := &bundleTest{env}
+	ct := &courseTest{env}
+	wt := &walletTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+
+	bt.createBundleUnknownCourse(t, c1.ID)
+	b := bt.createBundleOK(t, c1.ID, c2.ID)
+	bt.addBundleOK(t, b.ID)
+
+	// A bundle of a cent is payed with credit only and grants
+	// both its courses.
+	wt.grantOK(t, 1)
+	wt.payWithCreditOK(t)
+
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2})
+	bt.addBundleOwned(t, b.ID)
+}
+
+func (bt *bundleTest) createBundle(t *testing.T, courseIDs ...string) *http.Response {
+	if err := Login(bt.Server, bt.AdminEmail, bt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(bt.Server)
+
+	ids, err := json.Marshal(courseIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"name": "Test bundle", "price": {"units": 1, "currency": "USD"}, "courseIds": ` + string(ids) + `}`
+	r, err := http.NewRequest(http.MethodPost, bt.URL+"/bundles", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := bt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+func (bt *bundleTest) createBundleOK(t *testing.T, courseIDs ...string) bundle.Bundle {
+	w := bt.createBundle(t, courseIDs...)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create bundle: status code %s", w.Status)
+	}
+
+	var got bundle.Bundle
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal created bundle: %v", err)
+	}
+
+	if len(got.CourseIDs) != len(courseIDs) {
+		t.Fatalf("expected %d courses, got %v", len(courseIDs), got.CourseIDs)
+	}
+
+	return got
+}
+
+func (bt *bundleTest) createBundleUnknownCourse(t *testing.T, courseID string) {
+	w := bt.createBundle(t, courseID, validate.GenerateID())
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("creating a bundle of unknown courses should fail: status code %s", w.Status)
+	}
+}
+
+func (bt *bundleTest) addBundle(t *testing.T, bundleID string) *http.Response {
+	if err := Login(bt.Server, bt.UserEmail, bt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(bt.Server)
+
+	body := `{"bundleId": "` + bundleID + `"}`
+	r, err := http.NewRequest(http.MethodPut, bt.URL+"/cart/bundles", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := bt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+func (bt *bundleTest) addBundleOK(t *testing.T, bundleID string) {
+	w := bt.addBundle(t, bundleID)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't add bundle to the cart: status code %s", w.Status)
+	}
+}
+
+func (bt *bundleTest) addBundleOwned(t *testing.T, bundleID string) {
+	w := bt.addBundle(t, bundleID)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("adding a bundle already owned should fail: status code %s", w.Status)
+	}
+}
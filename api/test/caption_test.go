@@ -0,0 +1,166 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/caption"
+	"github.com/jatolentino/tutorialspoint/core/video"
+)
+
+type captionTest struct {
+	*TestEnv
+}
+
+func TestCaption(t *testing.T) {
+	env, err := NewTestEnv(t, "caption_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	capt := &captionTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+
+	crs := ct.createCourseOK(t)
+	v := vt.createVideoOK(t, crs.ID, 1)
+
+	if err := Login(capt.Server, capt.AdminEmail, capt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	srt := "1\r\n00:00:01,000 --> 00:00:02,500\r\nHello\r\n"
+	if got := capt.put(t, v.ID, "en", caption.CaptionUp{Format: caption.FormatSRT, Content: srt}); got != http.StatusOK {
+		t.Fatalf("putting srt caption: expected 200, got %d", got)
+	}
+
+	if got := capt.put(t, v.ID, "es", caption.CaptionUp{Format: caption.FormatVTT, Content: "1\n00:00:01.000 --> 00:00:02.000\nHola\n"}); got != http.StatusUnprocessableEntity {
+		t.Fatalf("putting vtt caption without header: expected 422, got %d", got)
+	}
+
+	if got := capt.put(t, v.ID, "EN_us", caption.CaptionUp{Format: caption.FormatVTT, Content: "WEBVTT\n"}); got != http.StatusBadRequest {
+		t.Fatalf("putting caption in an invalid language: expected 400, got %d", got)
+	}
+
+	cs := capt.listOK(t, v.ID)
+	exp := "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.500\nHello\n"
+	if len(cs) != 1 || cs[0].Language != "en" || cs[0].VTT != exp || !cs[0].Reviewed || cs[0].Source != caption.SourceManual {
+		t.Fatalf("expected the english caption converted to webvtt, got %+v", cs)
+	}
+
+	Logout(capt.Server)
+
+	// Users get the tracks with the video.
+	if err := Login(capt.Server, capt.UserEmail, capt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	tracks := capt.showTracksOK(t, v.ID)
+	if len(tracks) != 1 || tracks[0].Language != "en" || tracks[0].VTT != exp {
+		t.Fatalf("expected the english track with the video, got %+v", tracks)
+	}
+
+	Logout(capt.Server)
+
+	if err := Login(capt.Server, capt.AdminEmail, capt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(capt.Server)
+
+	for i := 0; i < 2; i++ {
+		if got := capt.delete(t, v.ID, "en"); got != http.StatusNoContent {
+			t.Fatalf("deleting caption: expected 204, got %d", got)
+		}
+	}
+
+	if cs := capt.listOK(t, v.ID); len(cs) != 0 {
+		t.Fatalf("expected no captions, got %+v", cs)
+	}
+}
+
+func (ct *captionTest) put(t *testing.T, videoID string, language string, cu caption.CaptionUp) int {
+	body, err := json.Marshal(cu)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, ct.URL+"/videos/"+videoID+"/captions/"+language, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ct.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (ct *captionTest) delete(t *testing.T, videoID string, language string) int {
+	r, err := http.NewRequest(http.MethodDelete, ct.URL+"/videos/"+videoID+"/captions/"+language, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ct.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (ct *captionTest) listOK(t *testing.T, videoID string) []caption.Caption {
+	r, err := http.NewRequest(http.MethodGet, ct.URL+"/videos/"+videoID+"/captions", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ct.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list captions: status code %s", w.Status)
+	}
+
+	var got []caption.Caption
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal captions: %v", err)
+	}
+
+	return got
+}
+
+func (ct *captionTest) showTracksOK(t *testing.T, videoID string) []video.Track {
+	r, err := http.NewRequest(http.MethodGet, ct.URL+"/videos/"+videoID+"/full", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ct.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't show full video: status code %s", w.Status)
+	}
+
+	var got struct {
+		Captions []video.Track `json:"captions"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal full video: %v", err)
+	}
+
+	return got.Captions
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/jatolentino/tutorialspoint/core/cart"
+	"github.com/jatolentino/tutorialspoint/core/order"
+	"github.com/jatolentino/tutorialspoint/money"
 )
 
 type cartTest struct {
@@ -36,6 +38,8 @@ func TestCart(t *testing.T) {
 	// Add two items and check if they're added.
 	item1 := ct.createItemOK(t, course1.ID)
 	item2 := ct.createItemOK(t, course2.ID)
+	item1.Price = course1.Price
+	item2.Price = course2.Price
 	ct.showCartOK(t, cart.Cart{
 		Items: []cart.Item{item1, item2},
 	})
@@ -55,6 +59,417 @@ func TestCart(t *testing.T) {
 	ct.showCartOK(t, cart.Cart{Items: []cart.Item{}})
 }
 
+func TestAnonymousCart(t *testing.T) {
+	env, err := NewTestEnv(t, "anonymous_cart_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ut := &courseTest{env}
+	course1 := ut.createCourseOK(t)
+	course2 := ut.createCourseOK(t)
+
+	ct := &cartTest{env}
+	item1 := ct.createItemOK(t, course1.ID)
+
+	// Visitors fill their cart before logging in.
+	ct.createAnonymousItemOK(t, course1.ID)
+	item2 := ct.createAnonymousItemOK(t, course2.ID)
+
+	w := ct.createAnonymousItem(t, course2.ID)
+	w.Body.Close()
+	if w.StatusCode != http.StatusConflict {
+		t.Fatalf("adding a course twice: expected 409, got %s", w.Status)
+	}
+
+	// Logging in merges the anonymous cart, without duplicates.
+	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	item1.Price = course1.Price
+	item2.Price = course2.Price
+	ct.showCartOK(t, cart.Cart{
+		Items: []cart.Item{item1, item2},
+	})
+}
+
+func TestSaveForLater(t *testing.T) {
+	env, err := NewTestEnv(t, "saved_cart_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ut := &courseTest{env}
+	course1 := ut.createCourseOK(t)
+
+	ct := &cartTest{env}
+	item1 := ct.createItemOK(t, course1.ID)
+	item1.Price = course1.Price
+
+	// Saved items leave the cart, and survive its flush.
+	ct.saveItemOK(t, course1.ID)
+	ct.showCartOK(t, cart.Cart{Items: []cart.Item{}})
+	ct.deleteCartOK(t)
+	if got := ct.listSavedOK(t); len(got) != 1 || got[0].CourseID != course1.ID || got[0].Price != course1.Price {
+		t.Fatalf("expected course[%s] saved for later, got %+v", course1.ID, got)
+	}
+
+	// Moving them back empties the list.
+	ct.moveToCartOK(t, course1.ID)
+	ct.showCartOK(t, cart.Cart{Items: []cart.Item{item1}})
+	if got := ct.listSavedOK(t); len(got) != 0 {
+		t.Fatalf("expected nothing saved for later, got %+v", got)
+	}
+
+	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ct.Server)
+
+	w := ct.do(t, http.MethodPost, "/cart/saved/"+course1.ID+"/move")
+	w.Body.Close()
+	if w.StatusCode != http.StatusNotFound {
+		t.Fatalf("moving an item not saved: expected 404, got %s", w.Status)
+	}
+}
+
+func TestValidateCart(t *testing.T) {
+	env, err := NewTestEnv(t, "validate_cart_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ut := &courseTest{env}
+	course1 := ut.createCourseOK(t)
+	course2 := ut.createCourseOK(t)
+
+	ct := &cartTest{env}
+	ct.createItemOK(t, course1.ID)
+	ct.createItemOK(t, course2.ID)
+
+	if v := ct.validateOK(t); !v.Valid || len(v.Issues) != 0 {
+		t.Fatalf("expected a valid cart, got %+v", v)
+	}
+
+	// The price changes once the course is in the cart.
+	updated := ut.updateCourseOK(t, course1)
+
+	v := ct.validateOK(t)
+	if v.Valid || len(v.Issues) != 1 {
+		t.Fatalf("expected an issue, got %+v", v)
+	}
+
+	is := v.Issues[0]
+	if is.CourseID != course1.ID || is.Reason != cart.IssuePriceChanged ||
+		*is.AddedPrice != course1.Price || *is.Price != updated.Price {
+		t.Fatalf("expected the price change of course[%s], got %+v", course1.ID, is)
+	}
+}
+
+// validateOK returns the issues of the items in the cart.
+func (ct *cartTest) validateOK(t *testing.T) cart.Validation {
+	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ct.Server)
+
+	w := ct.do(t, http.MethodGet, "/cart/validate")
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't validate cart: status code %s", w.Status)
+	}
+
+	var got cart.Validation
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal cart validation: %v", err)
+	}
+
+	return got
+}
+
+func TestUnsubscribeCartReminders(t *testing.T) {
+	env, err := NewTestEnv(t, "cart_reminders_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ct := &cartTest{env}
+
+	if code := ct.unsubscribe(t, adminID, "forged"); code != http.StatusUnauthorized {
+		t.Fatalf("unsubscribing with a forged token: expected 401, got %d", code)
+	}
+
+	// Unsubscribing is idempotent.
+	token := cart.UnsubscribeToken("random-cart-secret", adminID)
+	for i := 0; i < 2; i++ {
+		if code := ct.unsubscribe(t, adminID, token); code != http.StatusNoContent {
+			t.Fatalf("can't unsubscribe from cart reminders: status code %d", code)
+		}
+	}
+}
+
+// unsubscribe stops the cart reminders of the user, without logging
+// in, and returns the status code.
+func (ct *cartTest) unsubscribe(t *testing.T, userID string, token string) int {
+	body, err := json.Marshal(cart.OptOut{UserID: userID, Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, ct.URL+"/cart/reminders/unsubscribe", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ct.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func TestBulkCart(t *testing.T) {
+	env, err := NewTestEnv(t, "bulk_cart_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ut := &courseTest{env}
+	course1 := ut.createCourseOK(t)
+	course2 := ut.createCourseOK(t)
+
+	ct := &cartTest{env}
+	item1 := ct.createItemOK(t, course1.ID)
+
+	unknown := "6f5f9f69-3bd4-4e6c-a3f5-f1ed4c2c0b8d"
+	got := ct.createItemsOK(t, []string{course1.ID, course2.ID, course2.ID, unknown})
+
+	exp := []cart.Result{
+		{CourseID: course1.ID, Result: cart.ResultDuplicate},
+		{CourseID: course2.ID, Result: cart.ResultAdded},
+		{CourseID: course2.ID, Result: cart.ResultDuplicate},
+		{CourseID: unknown, Result: cart.ResultNotFound},
+	}
+	if diff := cmp.Diff(got, exp); diff != "" {
+		t.Fatalf("wrong results. Diff: \n%s", diff)
+	}
+
+	item1.Price = course1.Price
+	item2 := cart.Item{CourseID: course2.ID, Price: course2.Price}
+	ct.showCartOK(t, cart.Cart{Items: []cart.Item{item1, item2}})
+}
+
+// createItemsOK adds the courses to the cart at once.
+func (ct *cartTest) createItemsOK(t *testing.T, courseIDs []string) []cart.Result {
+	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ct.Server)
+
+	body, err := json.Marshal(cart.ItemsNew{CourseIDs: courseIDs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, ct.URL+"/cart/items/bulk", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ct.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't create cart items: status code %s", w.Status)
+	}
+
+	var got []cart.Result
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal results: %v", err)
+	}
+
+	return got
+}
+
+func TestCartSummary(t *testing.T) {
+	env, err := NewTestEnv(t, "cart_summary_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ut := &courseTest{env}
+	course1 := ut.updateCourseOK(t, ut.createCourseOK(t))
+	course2 := ut.updateCourseOK(t, ut.createCourseOK(t))
+
+	ct := &cartTest{env}
+	ct.createItemOK(t, course1.ID)
+	ct.createItemOK(t, course2.ID)
+
+	pt := &couponTest{env}
+	pt.createCouponOK(t)
+
+	s := ct.summaryOK(t, "")
+	if len(s.Lines) != 2 || s.Subtotal != money.New(100000, "USD") || s.Total != s.Subtotal || !s.CouponDiscount.IsZero() {
+		t.Fatalf("expected a total of 1000.00 USD, got %+v", s)
+	}
+
+	// The coupon discounts 10% of each course.
+	s = ct.summaryOK(t, "?coupon=welcome10")
+	if s.Coupon != "WELCOME10" || s.CouponDiscount != money.New(10000, "USD") || s.Total != money.New(90000, "USD") {
+		t.Fatalf("expected a total of 900.00 USD discounted by WELCOME10, got %+v", s)
+	}
+
+	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ct.Server)
+
+	w := ct.do(t, http.MethodGet, "/cart/summary?coupon=NOPE")
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("summing up with an unknown coupon should fail: status code %s", w.Status)
+	}
+}
+
+// summaryOK returns the summary of the cart, checked out with the
+// passed query.
+func (ct *cartTest) summaryOK(t *testing.T, query string) order.Summary {
+	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ct.Server)
+
+	w := ct.do(t, http.MethodGet, "/cart/summary"+query)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't sum up cart: status code %s", w.Status)
+	}
+
+	var got order.Summary
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal cart summary: %v", err)
+	}
+
+	return got
+}
+
+// do sends a request with no body to the passed path.
+func (ct *cartTest) do(t *testing.T, method string, path string) *http.Response {
+	r, err := http.NewRequest(method, ct.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ct.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+// saveItemOK moves the course out of the cart, saving it for later.
+func (ct *cartTest) saveItemOK(t *testing.T, courseID string) {
+	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ct.Server)
+
+	w := ct.do(t, http.MethodPost, "/cart/items/"+courseID+"/save")
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't save cart item: status code %s", w.Status)
+	}
+}
+
+// moveToCartOK moves the course saved for later back into the cart.
+func (ct *cartTest) moveToCartOK(t *testing.T, courseID string) {
+	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ct.Server)
+
+	w := ct.do(t, http.MethodPost, "/cart/saved/"+courseID+"/move")
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't move saved item to the cart: status code %s", w.Status)
+	}
+}
+
+// listSavedOK returns the items saved for later.
+func (ct *cartTest) listSavedOK(t *testing.T) []cart.Item {
+	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ct.Server)
+
+	w := ct.do(t, http.MethodGet, "/cart/saved")
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list saved items: status code %s", w.Status)
+	}
+
+	var got []cart.Item
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal saved items: %v", err)
+	}
+
+	return got
+}
+
+// createAnonymousItem adds the course to the cart without logging in.
+func (ct *cartTest) createAnonymousItem(t *testing.T, courseID string) *http.Response {
+	body, err := json.Marshal(cart.ItemNew{CourseID: courseID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, ct.URL+"/cart/items", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ct.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+// createAnonymousItemOK adds the course to the cart without logging in.
+func (ct *cartTest) createAnonymousItemOK(t *testing.T, courseID string) cart.Item {
+	w := ct.createAnonymousItem(t, courseID)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create anonymous cart item: status code %s", w.Status)
+	}
+
+	var got cart.Item
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal created cart item: %v", err)
+	}
+
+	if got.CourseID != courseID {
+		t.Fatalf("expected course[%s] in the cart, got %s", courseID, got.CourseID)
+	}
+
+	return got
+}
+
 func (ct *cartTest) createItemOK(t *testing.T, courseID string) cart.Item {
 	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
 		t.Fatal(err)
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+type catalogTest struct {
+	*TestEnv
+}
+
+func TestCatalog(t *testing.T) {
+	env, err := NewTestEnv(t, "catalog_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	st := &catalogTest{env}
+	rt := &cartTest{env}
+	ft := &freeTest{env}
+
+	gophers := st.createCourseOK(t, "Concurrency for gophers", 3000)
+	rust := st.createCourseOK(t, "Ownership in Rust", 1000)
+	web := st.createCourseOK(t, "Web development with Go", 2000)
+
+	rt.createItemOK(t, gophers.ID)
+	ft.createFreeCouponOK(t)
+	if w := ft.enroll(t, "FREE100"); w != http.StatusOK {
+		t.Fatalf("enrolling in course: expected 200, got %d", w)
+	}
+
+	if err := Login(st.Server, st.UserEmail, st.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	st.rate(t, gophers.ID, 5, http.StatusOK)
+	st.rate(t, rust.ID, 5, http.StatusForbidden)
+	Logout(st.Server)
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{"newest", url.Values{}, []string{web.ID, rust.ID, gophers.ID}},
+		{"text", url.Values{"q": {"gophers"}}, []string{gophers.ID}},
+		{"price range", url.Values{"min_price": {"1500"}, "max_price": {"3000"}, "sort": {"price"}}, []string{web.ID, gophers.ID}},
+		{"rating", url.Values{"min_rating": {"4"}}, []string{gophers.ID}},
+		{"popular", url.Values{"sort": {"popular"}, "limit": {"1"}}, []string{gophers.ID}},
+		{"page", url.Values{"sort": {"price"}, "limit": {"2"}, "page": {"2"}}, []string{gophers.ID}},
+	}
+
+	for _, tc := range tests {
+		code, got := st.search(t, tc.query)
+		if code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tc.name, code)
+		}
+
+		ids := make([]string, len(got))
+		for i, c := range got {
+			ids[i] = c.ID
+		}
+		if len(ids) != len(tc.want) {
+			t.Fatalf("%s: expected courses %v, got %v", tc.name, tc.want, ids)
+		}
+		for i := range ids {
+			if ids[i] != tc.want[i] {
+				t.Fatalf("%s: expected courses %v, got %v", tc.name, tc.want, ids)
+			}
+		}
+	}
+
+	if _, got := st.search(t, url.Values{"q": {"gophers"}}); got[0].Rating == nil || got[0].Rating.Count != 1 || got[0].Rating.Average != 5 {
+		t.Fatalf("expected the rating of the course, got %+v", got[0].Rating)
+	}
+
+	if code, _ := st.search(t, url.Values{"sort": {"cheapest"}}); code != http.StatusBadRequest {
+		t.Fatalf("searching with an unknown sort: expected 400, got %d", code)
+	}
+}
+
+func (st *catalogTest) createCourseOK(t *testing.T, name string, units int64) course.Course {
+	if err := Login(st.Server, st.AdminEmail, st.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(st.Server)
+
+	body, err := json.Marshal(course.CourseNew{
+		Name:        name,
+		Description: "This is a test course",
+		Price:       money.New(units, "USD"),
+		ImageURL:    "/images/test.png",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, st.URL+"/courses", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create course: status code %s", w.Status)
+	}
+
+	var got course.Course
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal created course: %v", err)
+	}
+
+	return got
+}
+
+func (st *catalogTest) rate(t *testing.T, courseID string, stars int, want int) {
+	body, err := json.Marshal(course.RatingNew{Stars: stars})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, st.URL+"/courses/"+courseID+"/rating", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != want {
+		t.Fatalf("rating course[%s]: expected %d, got %s", courseID, want, w.Status)
+	}
+}
+
+func (st *catalogTest) search(t *testing.T, query url.Values) (int, []course.Course) {
+	r, err := http.NewRequest(http.MethodGet, st.URL+"/courses?"+query.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got []course.Course
+	if w.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal courses: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
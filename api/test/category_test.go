@@ -0,0 +1,172 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/category"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/validate"
+)
+
+type categoryTest struct {
+	*TestEnv
+}
+
+func TestCategory(t *testing.T) {
+	env, err := NewTestEnv(t, "category_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	gt := &categoryTest{env}
+	ct := &courseTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+	ct.createCourseOK(t)
+
+	if err := Login(gt.Server, gt.AdminEmail, gt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	var prog category.Category
+	if code := gt.create(t, "/categories", category.TopicNew{Name: "Programming", Slug: "programming"}, &prog); code != http.StatusCreated {
+		t.Fatalf("creating category: expected 201, got %d", code)
+	}
+	if code := gt.create(t, "/categories", category.TopicNew{Name: "Dupe", Slug: "programming"}, nil); code != http.StatusConflict {
+		t.Fatalf("creating category with a taken slug: expected 409, got %d", code)
+	}
+	if code := gt.create(t, "/categories", category.TopicNew{Name: "Bad", Slug: "Not A Slug"}, nil); code != http.StatusUnprocessableEntity {
+		t.Fatalf("creating category with an invalid slug: expected 422, got %d", code)
+	}
+
+	var golang, unit category.Tag
+	gt.create(t, "/tags", category.TopicNew{Name: "Go", Slug: "golang"}, &golang)
+	gt.create(t, "/tags", category.TopicNew{Name: "Unit testing", Slug: "unit-testing"}, &unit)
+
+	gt.set(t, c1.ID, "categories", []string{prog.ID}, http.StatusOK)
+	gt.set(t, c2.ID, "categories", []string{prog.ID}, http.StatusOK)
+	gt.set(t, c1.ID, "tags", []string{golang.ID, unit.ID}, http.StatusOK)
+	gt.set(t, c2.ID, "tags", []string{golang.ID}, http.StatusOK)
+	gt.set(t, c2.ID, "tags", []string{golang.ID, validate.GenerateID()}, http.StatusUnprocessableEntity)
+
+	Logout(gt.Server)
+
+	if got := gt.list(t, url.Values{"category": {"programming"}}); len(got) != 2 {
+		t.Fatalf("filtering by category: expected 2 courses, got %+v", got)
+	}
+	if got := gt.list(t, url.Values{"category": {"programming"}, "tag": {"golang", "unit-testing"}}); len(got) != 1 || got[0].ID != c1.ID {
+		t.Fatalf("filtering by all tags: expected course[%s], got %+v", c1.ID, got)
+	}
+	if got := gt.list(t, url.Values{"tag": {"golang"}}); len(got) != 2 {
+		t.Fatalf("filtering by a tag: expected 2 courses (the tags of the second course were kept), got %+v", got)
+	}
+	if got := gt.list(t, url.Values{"category": {"cooking"}}); len(got) != 0 {
+		t.Fatalf("filtering by a missing category: expected no courses, got %+v", got)
+	}
+
+	if err := Login(gt.Server, gt.AdminEmail, gt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(gt.Server)
+
+	gt.delete(t, "/categories/"+prog.ID, http.StatusNoContent)
+	gt.delete(t, "/categories/"+prog.ID, http.StatusNotFound)
+
+	if got := gt.list(t, url.Values{"category": {"programming"}}); len(got) != 0 {
+		t.Fatalf("filtering by a deleted category: expected no courses, got %+v", got)
+	}
+}
+
+func (gt *categoryTest) create(t *testing.T, path string, tn category.TopicNew, dest any) int {
+	body, err := json.Marshal(tn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, gt.URL+path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := gt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode == http.StatusCreated && dest != nil {
+		if err := json.NewDecoder(w.Body).Decode(dest); err != nil {
+			t.Fatalf("cannot unmarshal created topic: %v", err)
+		}
+	}
+
+	return w.StatusCode
+}
+
+func (gt *categoryTest) set(t *testing.T, courseID string, kind string, ids []string, want int) {
+	body, err := json.Marshal(category.CourseTopics{IDs: ids})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, gt.URL+"/courses/"+courseID+"/"+kind, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := gt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != want {
+		t.Fatalf("setting %s of course[%s]: expected %d, got %s", kind, courseID, want, w.Status)
+	}
+}
+
+func (gt *categoryTest) delete(t *testing.T, path string, want int) {
+	r, err := http.NewRequest(http.MethodDelete, gt.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := gt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != want {
+		t.Fatalf("deleting %s: expected %d, got %s", path, want, w.Status)
+	}
+}
+
+func (gt *categoryTest) list(t *testing.T, query url.Values) []course.Course {
+	r, err := http.NewRequest(http.MethodGet, gt.URL+"/courses?"+query.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := gt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list courses: status code %s", w.Status)
+	}
+
+	var got []course.Course
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal courses: %v", err)
+	}
+
+	return got
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+type coinbaseTest struct {
+	*TestEnv
+}
+
+func TestCoinbase(t *testing.T) {
+	env, err := NewTestEnv(t, "coinbase_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	bt := &coinbaseTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+
+	// A failed charge is not fulfilled.
+	rt.createItemOK(t, c1.ID)
+	bt.Coinbase.expectedCart = []course.Course{c1}
+	chargeID := bt.checkoutOK(t)
+	bt.webhookOK(t, "charge:failed", chargeID)
+	ct.listCoursesOwnedOK(t, []course.Course{})
+
+	// A confirmed one is.
+	rt.createItemOK(t, c2.ID)
+	bt.Coinbase.expectedCart = []course.Course{c1, c2}
+	chargeID = bt.checkoutOK(t)
+	bt.webhookOK(t, "charge:confirmed", chargeID)
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2})
+}
+
+// checkoutOK starts a coinbase checkout and returns the charge id.
+func (bt *coinbaseTest) checkoutOK(t *testing.T) string {
+	if err := Login(bt.Server, bt.UserEmail, bt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(bt.Server)
+
+	r, err := http.NewRequest(http.MethodPost, bt.URL+"/orders/coinbase", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := bt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't create coinbase charge: status code %s", w.Status)
+	}
+
+	var url string
+	if err := json.NewDecoder(w.Body).Decode(&url); err != nil {
+		t.Fatalf("cannot unmarshal coinbase checkout: %v", err)
+	}
+
+	// Mocked coinbase returns the id in the URL.
+	return path.Base(url)
+}
+
+// webhook triggers a signed coinbase event of the passed type for the
+// specified charge.
+func (bt *coinbaseTest) webhook(t *testing.T, typ string, chargeID string) *http.Response {
+	evt := map[string]any{
+		"event": map[string]any{
+			"type": typ,
+			"data": map[string]any{"id": chargeID, "code": chargeID},
+		},
+	}
+
+	b, err := json.Marshal(evt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(bt.CoinbaseSecret))
+	mac.Write(b)
+
+	r, err := http.NewRequest(http.MethodPost, bt.URL+"/orders/coinbase/webhook", bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("X-CC-Webhook-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	w, err := bt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+// webhookOK triggers a signed coinbase event of the passed type
+// for the specified charge.
+func (bt *coinbaseTest) webhookOK(t *testing.T, typ string, chargeID string) {
+	w := bt.webhook(t, typ, chargeID)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Fatalf("can't trigger coinbase webhook %s: status code %s", typ, w.Status)
+	}
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/video"
+)
+
+type completionTest struct {
+	*TestEnv
+}
+
+func TestCompletion(t *testing.T) {
+	env, err := NewTestEnv(t, "completion_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	cpt := &completionTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+	rt := &cartTest{env}
+	ft := &freeTest{env}
+
+	owned := ct.createCourseOK(t)
+	other := ct.createCourseOK(t)
+	first := vt.createVideoOK(t, owned.ID, 1)
+	second := vt.createVideoOK(t, owned.ID, 2)
+
+	rt.createItemOK(t, owned.ID)
+	ft.createFreeCouponOK(t)
+	if code := ft.enroll(t, "FREE100"); code != http.StatusOK {
+		t.Fatalf("enrolling in course: expected 200, got %d", code)
+	}
+
+	if err := Login(cpt.Server, cpt.UserEmail, cpt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(cpt.Server)
+
+	vt.updateProgress(t, first, video.ProgressUp{Progress: 100})
+	vt.updateProgress(t, second, video.ProgressUp{Progress: 50})
+
+	var got course.Completion
+	if code := cpt.get(t, "/courses/"+owned.ID+"/completion", &got); code != http.StatusOK {
+		t.Fatalf("showing completion: expected 200, got %d", code)
+	}
+	if got.Videos != 2 || got.VideosCompleted != 1 || got.Percent != 75 {
+		t.Fatalf("expected 1 of 2 videos completed at 75%%, got %+v", got)
+	}
+
+	if code := cpt.get(t, "/courses/"+other.ID+"/completion", nil); code != http.StatusForbidden {
+		t.Fatalf("showing completion of a course not owned: expected 403, got %d", code)
+	}
+
+	var all []course.Completion
+	if code := cpt.get(t, "/users/me/completion", &all); code != http.StatusOK {
+		t.Fatalf("listing completion: expected 200, got %d", code)
+	}
+	if len(all) != 1 || all[0].CourseID != owned.ID || all[0].Percent != 75 {
+		t.Fatalf("expected course[%s] at 75%%, got %+v", owned.ID, all)
+	}
+}
+
+func (cpt *completionTest) get(t *testing.T, path string, dest any) int {
+	r, err := http.NewRequest(http.MethodGet, cpt.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := cpt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode == http.StatusOK && dest != nil {
+		if err := json.NewDecoder(w.Body).Decode(dest); err != nil {
+			t.Fatalf("cannot unmarshal %s: %v", path, err)
+		}
+	}
+
+	return w.StatusCode
+}
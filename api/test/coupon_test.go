@@ -0,0 +1,111 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/coupon"
+)
+
+type couponTest struct {
+	*TestEnv
+}
+
+func TestCoupon(t *testing.T) {
+	env, err := NewTestEnv(t, "coupon_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	pt := &couponTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c := ct.createCourseOK(t)
+	rt.createItemOK(t, c.ID)
+
+	cp := pt.createCouponOK(t)
+	pt.createCouponDuplicated(t, cp)
+	pt.checkoutUnknownCoupon(t)
+}
+
+func (pt *couponTest) createCouponOK(t *testing.T) coupon.Coupon {
+	if err := Login(pt.Server, pt.AdminEmail, pt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(pt.Server)
+
+	body := `{"code": "welcome10", "kind": "percent", "percent": 10}`
+	r, err := http.NewRequest(http.MethodPost, pt.URL+"/coupons", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create coupon: status code %s", w.Status)
+	}
+
+	var got coupon.Coupon
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal created coupon: %v", err)
+	}
+
+	if got.Code != "WELCOME10" {
+		t.Fatalf("expected code WELCOME10, got %s", got.Code)
+	}
+
+	return got
+}
+
+func (pt *couponTest) createCouponDuplicated(t *testing.T, cp coupon.Coupon) {
+	if err := Login(pt.Server, pt.AdminEmail, pt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(pt.Server)
+
+	body := `{"code": "` + cp.Code + `", "kind": "percent", "percent": 20}`
+	r, err := http.NewRequest(http.MethodPost, pt.URL+"/coupons", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusConflict {
+		t.Fatalf("creating a coupon with a taken code should fail: status code %s", w.Status)
+	}
+}
+
+func (pt *couponTest) checkoutUnknownCoupon(t *testing.T) {
+	if err := Login(pt.Server, pt.UserEmail, pt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(pt.Server)
+
+	r, err := http.NewRequest(http.MethodPost, pt.URL+"/orders/stripe?coupon=NOPE", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("checking out with an unknown coupon should fail: status code %s", w.Status)
+	}
+}
@@ -6,12 +6,14 @@ import (
 	"math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
 	"github.com/jatolentino/tutorialspoint/validate"
 )
 
@@ -41,6 +43,7 @@ func TestCourse(t *testing.T) {
 
 	cs := []course.Course{c1, c2}
 	ct.listCoursesOK(t, cs)
+	ct.listCoursesByIDsOK(t, []course.Course{c2, c1})
 }
 
 func (ct *courseTest) createCourseOK(t *testing.T) course.Course {
@@ -52,7 +55,7 @@ func (ct *courseTest) createCourseOK(t *testing.T) course.Course {
 	c := course.CourseNew{
 		Name:        "Test" + strconv.Itoa(rand.Intn(1000)),
 		Description: "This is a test course",
-		Price:       rand.Intn(1000),
+		Price:       money.New(int64(rand.Intn(100000)), "USD"),
 		ImageURL:    "/images/test.png",
 	}
 
@@ -103,7 +106,7 @@ func (ct *courseTest) createCourseUnauth(t *testing.T) {
 	c := course.CourseNew{
 		Name:        "Test",
 		Description: "This is a test course",
-		Price:       100,
+		Price:       money.New(10000, "USD"),
 		ImageURL:    "/images/test.png",
 	}
 
@@ -141,7 +144,7 @@ func (ct *courseTest) updateCourseOK(t *testing.T, crs course.Course) course.Cou
 	c := course.CourseUp{
 		Name:        ptr("Updated Test"),
 		Description: ptr("This is an updated test course"),
-		Price:       ptr(500),
+		Price:       ptr(money.New(50000, "USD")),
 		ImageURL:    ptr("/images/updated.png"),
 	}
 
@@ -199,7 +202,7 @@ func (ct *courseTest) updateCourseConcurrent(t *testing.T, crs course.Course) {
 		c := course.CourseUp{
 			Name:        ptr("Updated Test"),
 			Description: ptr("This is an updated test course"),
-			Price:       ptr(500),
+			Price:       ptr(money.New(50000, "USD")),
 			ImageURL:    ptr("/images/updated.png"),
 		}
 
@@ -249,7 +252,7 @@ func (ct *courseTest) updateCourseInexistent(t *testing.T, crs course.Course) {
 	c := course.CourseUp{
 		Name:        ptr("Updated Test Course Not Existent"),
 		Description: ptr("This is an updated test course - not exist"),
-		Price:       ptr(300),
+		Price:       ptr(money.New(30000, "USD")),
 		ImageURL:    ptr("/images/updated.png"),
 	}
 
@@ -283,7 +286,7 @@ func (ct *courseTest) updateCourseUnauth(t *testing.T, crs course.Course) {
 	c := course.CourseUp{
 		Name:        ptr("Updated Test Unauth"),
 		Description: ptr("This is an updated test course - unauth"),
-		Price:       ptr(300),
+		Price:       ptr(money.New(30000, "USD")),
 		ImageURL:    ptr("/images/updated.png"),
 	}
 
@@ -412,6 +415,48 @@ func (ct *courseTest) listCoursesOK(t *testing.T, crs []course.Course) {
 	}
 }
 
+func (ct *courseTest) listCoursesByIDsOK(t *testing.T, crs []course.Course) {
+	ids := make([]string, 0, len(crs)+1)
+	for _, c := range crs {
+		ids = append(ids, c.ID)
+	}
+	// Inexistent courses are ignored.
+	ids = append(ids, validate.GenerateID())
+
+	r, err := http.NewRequest(http.MethodGet, ct.URL+"/courses?ids="+strings.Join(ids, ","), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ct.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't fetch courses by ids: status code %s", w.Status)
+	}
+
+	var got []course.Course
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal fetched courses: %v", err)
+	}
+
+	// Don't care about dates, but the order matters.
+	now := time.Now()
+	nodates := cmp.Transformer("", func(in course.Course) course.Course {
+		out := in
+		out.CreatedAt = now
+		out.UpdatedAt = now
+		return out
+	})
+
+	if diff := cmp.Diff(got, crs, nodates); diff != "" {
+		t.Fatalf("wrong courses payload. Diff: \n%s", diff)
+	}
+}
+
 func (ct *courseTest) listCoursesOwnedOK(t *testing.T, crs []course.Course) {
 	if err := Login(ct.Server, ct.UserEmail, ct.UserPass); err != nil {
 		t.Fatal(err)
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEmailChange(t *testing.T) {
+	env, err := NewTestEnv(t, "email_change_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &faqTest{env}
+
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	type change struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	const newEmail = "renamed@email.com"
+	ft.do(t, http.MethodPut, "/users/current/email", change{Email: newEmail, Password: "wrong password"}, nil, http.StatusForbidden)
+	ft.do(t, http.MethodPut, "/users/current/email", change{Email: ft.AdminEmail, Password: ft.UserPass}, nil, http.StatusConflict)
+
+	before := ft.Mailer.token
+	ft.do(t, http.MethodPut, "/users/current/email", change{Email: newEmail, Password: ft.UserPass}, nil, http.StatusAccepted)
+
+	// The token is emailed in background.
+	tok := ft.Mailer.token
+	for deadline := time.Now().Add(time.Second); tok == before && time.Now().Before(deadline); tok = ft.Mailer.token {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	Logout(ft.Server)
+
+	// The email is not switched until confirmed.
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatalf("logging in with the old email before confirming: %v", err)
+	}
+	Logout(ft.Server)
+
+	confirm := struct {
+		Token string `json:"token"`
+	}{
+		Token: tok,
+	}
+	ft.do(t, http.MethodPost, "/tokens/email", confirm, nil, http.StatusNoContent)
+	ft.do(t, http.MethodPost, "/tokens/email", confirm, nil, http.StatusBadRequest)
+
+	if err := Login(ft.Server, newEmail, ft.UserPass); err != nil {
+		t.Fatalf("logging in with the new email: %v", err)
+	}
+	Logout(ft.Server)
+
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err == nil {
+		t.Fatalf("expected the old email not to log in anymore")
+	}
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/enrollment"
+)
+
+type enrollmentTest struct {
+	*TestEnv
+}
+
+func TestEnrollment(t *testing.T) {
+	env, err := NewTestEnv(t, "enrollment_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	et := &enrollmentTest{env}
+	ct := &courseTest{env}
+	cpt := &completionTest{env}
+
+	crs := ct.createCourseOK(t)
+
+	if err := Login(et.Server, et.AdminEmail, et.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	e := enrollment.Enrollment{
+		Emails:   []string{et.UserEmail, "new.member@team.com"},
+		CourseID: crs.ID,
+		Reason:   "team plan",
+	}
+
+	code, res := et.enroll(t, e)
+	if code != http.StatusOK {
+		t.Fatalf("enrolling users: expected 200, got %d", code)
+	}
+	if len(res) != 2 || res[0].Result != enrollment.ResultEnrolled || res[0].UserID != userID || res[1].Result != enrollment.ResultInvited {
+		t.Fatalf("expected the user enrolled and the new member invited, got %+v", res)
+	}
+
+	_, res = et.enroll(t, e)
+	if len(res) != 2 || res[0].Result != enrollment.ResultOwned || res[1].Result != enrollment.ResultOwned {
+		t.Fatalf("expected both users to own the course already, got %+v", res)
+	}
+
+	e.BundleID = crs.ID
+	if code, _ := et.enroll(t, e); code != http.StatusUnprocessableEntity {
+		t.Fatalf("enrolling in both a course and a bundle: expected 422, got %d", code)
+	}
+	Logout(et.Server)
+
+	if err := Login(et.Server, et.UserEmail, et.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(et.Server)
+
+	if code := cpt.get(t, "/courses/"+crs.ID+"/completion", nil); code != http.StatusOK {
+		t.Fatalf("showing completion of an enrolled course: expected 200, got %d", code)
+	}
+}
+
+func (et *enrollmentTest) enroll(t *testing.T, e enrollment.Enrollment) (int, []enrollment.Result) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, et.URL+"/enrollments", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := et.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var res []enrollment.Result
+	if w.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatalf("cannot unmarshal results: %v", err)
+		}
+	}
+
+	return w.StatusCode, res
+}
@@ -0,0 +1,88 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+type faqTest struct {
+	*TestEnv
+}
+
+func TestFAQ(t *testing.T) {
+	env, err := NewTestEnv(t, "faq_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &faqTest{env}
+	ct := &courseTest{env}
+	cpt := &completionTest{env}
+
+	crs := ct.createCourseOK(t)
+
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	var second, first, extra course.FAQ
+	path := "/courses/" + crs.ID + "/faqs"
+	ft.do(t, http.MethodPost, path, course.FAQNew{Index: 2, Question: "Is it online?", Answer: "Yes."}, &second, http.StatusCreated)
+	ft.do(t, http.MethodPost, path, course.FAQNew{Index: 1, Question: "Who is it for?", Answer: "Beginners."}, &first, http.StatusCreated)
+	ft.do(t, http.MethodPost, path, course.FAQNew{Index: 1, Question: "Dupe?", Answer: "No."}, nil, http.StatusConflict)
+	ft.do(t, http.MethodPost, path, course.FAQNew{Index: 3, Question: "Extra?", Answer: "Removed."}, &extra, http.StatusCreated)
+
+	ft.do(t, http.MethodPut, "/faqs/"+second.ID, course.FAQUp{Answer: ptr("Yes, on demand.")}, nil, http.StatusOK)
+	ft.do(t, http.MethodDelete, "/faqs/"+extra.ID, nil, nil, http.StatusNoContent)
+	ft.do(t, http.MethodDelete, "/faqs/"+extra.ID, nil, nil, http.StatusNotFound)
+
+	Logout(ft.Server)
+
+	var got struct {
+		Expand struct {
+			FAQ []course.FAQ `json:"faq"`
+		} `json:"expand"`
+	}
+	if code := cpt.get(t, "/courses/"+crs.ID+"?expand=faq", &got); code != http.StatusOK {
+		t.Fatalf("showing course with faq: expected 200, got %d", code)
+	}
+
+	faq := got.Expand.FAQ
+	if len(faq) != 2 || faq[0].ID != first.ID || faq[1].ID != second.ID || faq[1].Answer != "Yes, on demand." {
+		t.Fatalf("expected the 2 entries in order, the second updated, got %+v", faq)
+	}
+}
+
+func (ft *faqTest) do(t *testing.T, method string, path string, payload any, dest any, want int) {
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := http.NewRequest(method, ft.URL+path, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != want {
+		t.Fatalf("%s %s: expected %d, got %s", method, path, want, w.Status)
+	}
+
+	if dest != nil {
+		if err := json.NewDecoder(w.Body).Decode(dest); err != nil {
+			t.Fatalf("cannot unmarshal %s: %v", path, err)
+		}
+	}
+}
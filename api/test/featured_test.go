@@ -0,0 +1,47 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+func TestFeatured(t *testing.T) {
+	env, err := NewTestEnv(t, "featured_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &faqTest{env}
+	ct := &courseTest{env}
+	cpt := &completionTest{env}
+
+	first := ct.createCourseOK(t)
+	second := ct.createCourseOK(t)
+
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	unknown := course.FeaturedUp{IDs: []string{second.ID, userID}}
+	ft.do(t, http.MethodPut, "/courses/featured", unknown, nil, http.StatusUnprocessableEntity)
+
+	var set []course.Course
+	ft.do(t, http.MethodPut, "/courses/featured", course.FeaturedUp{IDs: []string{second.ID, first.ID}}, &set, http.StatusOK)
+	if len(set) != 2 {
+		t.Fatalf("expected 2 featured courses, got %d", len(set))
+	}
+
+	Logout(ft.Server)
+
+	var got []course.Course
+	if code := cpt.get(t, "/courses/featured", &got); code != http.StatusOK {
+		t.Fatalf("listing featured courses: expected 200, got %d", code)
+	}
+	if len(got) != 2 || got[0].ID != second.ID || got[1].ID != first.ID {
+		t.Fatalf("expected the featured courses in order, got %+v", got)
+	}
+
+	ft.do(t, http.MethodPut, "/courses/featured", course.FeaturedUp{IDs: []string{first.ID}}, nil, http.StatusUnauthorized)
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+type freeTest struct {
+	*TestEnv
+}
+
+func TestFree(t *testing.T) {
+	env, err := NewTestEnv(t, "free_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &freeTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c := ct.createCourseOK(t)
+	rt.createItemOK(t, c.ID)
+
+	// Courses with a price can't be enrolled in for free.
+	if w := ft.enroll(t, ""); w != http.StatusUnprocessableEntity {
+		t.Fatalf("enrolling in a paid course: expected 422, got %d", w)
+	}
+	ct.listCoursesOwnedOK(t, []course.Course{})
+
+	// Carts fully discounted are, without calling any provider.
+	ft.createFreeCouponOK(t)
+	if w := ft.enroll(t, "FREE100"); w != http.StatusOK {
+		t.Fatalf("enrolling in a discounted course: expected 200, got %d", w)
+	}
+	ct.listCoursesOwnedOK(t, []course.Course{c})
+}
+
+// createFreeCouponOK creates the coupon FREE100, discounting courses
+// completely.
+func (ft *freeTest) createFreeCouponOK(t *testing.T) {
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	body := `{"code": "free100", "kind": "percent", "percent": 100}`
+	r, err := http.NewRequest(http.MethodPost, ft.URL+"/coupons", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create coupon: status code %s", w.Status)
+	}
+}
+
+// enroll enrolls the user in the courses of the cart for free, applying
+// the passed coupon, and returns the status code of the response.
+func (ft *freeTest) enroll(t *testing.T, coupon string) int {
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	r, err := http.NewRequest(http.MethodPost, ft.URL+"/orders/free?coupon="+coupon, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		return w.StatusCode
+	}
+
+	var got struct {
+		Paid bool `json:"paid"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal enrollment: %v", err)
+	}
+
+	if !got.Paid {
+		t.Fatalf("expected the free order to be fulfilled right away")
+	}
+
+	return w.StatusCode
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+const userID = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+type grantTest struct {
+	*TestEnv
+}
+
+func TestGrant(t *testing.T) {
+	env, err := NewTestEnv(t, "grant_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	gt := &grantTest{env}
+	ct := &courseTest{env}
+	cpt := &completionTest{env}
+
+	crs := ct.createCourseOK(t)
+
+	if err := Login(gt.Server, gt.AdminEmail, gt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	gn := course.GrantNew{UserID: userID, Reason: "support case"}
+	if code := gt.post(t, "/courses/"+crs.ID+"/grants", gn); code != http.StatusCreated {
+		t.Fatalf("granting course: expected 201, got %d", code)
+	}
+	if code := gt.post(t, "/courses/"+crs.ID+"/grants", gn); code != http.StatusConflict {
+		t.Fatalf("granting course twice: expected 409, got %d", code)
+	}
+	Logout(gt.Server)
+
+	if err := Login(gt.Server, gt.UserEmail, gt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	if code := cpt.get(t, "/courses/"+crs.ID+"/completion", nil); code != http.StatusOK {
+		t.Fatalf("showing completion of a granted course: expected 200, got %d", code)
+	}
+	Logout(gt.Server)
+
+	if err := Login(gt.Server, gt.AdminEmail, gt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	revoke := course.GrantRevoke{Reason: "granted by mistake"}
+	if code := gt.post(t, "/courses/"+crs.ID+"/grants/"+userID+"/revoke", revoke); code != http.StatusOK {
+		t.Fatalf("revoking grant: expected 200, got %d", code)
+	}
+	if code := gt.post(t, "/courses/"+crs.ID+"/grants/"+userID+"/revoke", revoke); code != http.StatusNotFound {
+		t.Fatalf("revoking a revoked grant: expected 404, got %d", code)
+	}
+
+	var gs []course.Grant
+	if code := cpt.get(t, "/courses/"+crs.ID+"/grants", &gs); code != http.StatusOK {
+		t.Fatalf("listing grants: expected 200, got %d", code)
+	}
+	if len(gs) != 1 || gs[0].RevokedAt == nil || gs[0].RevokeReason != revoke.Reason || gs[0].Reason != gn.Reason {
+		t.Fatalf("expected the revoked grant with both reasons, got %+v", gs)
+	}
+	Logout(gt.Server)
+
+	if err := Login(gt.Server, gt.UserEmail, gt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(gt.Server)
+
+	if code := cpt.get(t, "/courses/"+crs.ID+"/completion", nil); code != http.StatusForbidden {
+		t.Fatalf("showing completion of a revoked course: expected 403, got %d", code)
+	}
+}
+
+func (gt *grantTest) post(t *testing.T, path string, payload any) int {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, gt.URL+path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := gt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
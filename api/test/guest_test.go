@@ -0,0 +1,168 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/token"
+)
+
+type guestTest struct {
+	*TestEnv
+}
+
+func TestGuest(t *testing.T) {
+	env, err := NewTestEnv(t, "guest_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	gt := &guestTest{env}
+	ct := &courseTest{env}
+	ot := &orderTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+
+	// Registered users must log in to buy.
+	w := gt.checkout(t, env.UserEmail, c1.ID)
+	defer w.Body.Close()
+	if w.StatusCode != http.StatusConflict {
+		t.Fatalf("guest checkout with a registered email: expected 409, got %s", w.Status)
+	}
+
+	// Guests can buy without an account.
+	const email = "guest@test.com"
+	ot.Stripe.expectedCart = []course.Course{c1, c2}
+	sessionID := gt.checkoutOK(t, email, c1.ID, c2.ID)
+	ot.stripeWebhook(t, "checkout.session.completed", sessionID)
+
+	// Guests can't log in until they claim their account.
+	if err := Login(gt.Server, email, "password"); err == nil {
+		t.Fatal("guests should not be able to log in")
+	}
+
+	const pass = "guestpassword"
+	gt.claimOK(t, email, pass)
+
+	// The purchase belongs to the claimed account.
+	genv := *env
+	genv.UserEmail = email
+	genv.UserPass = pass
+	gct := &courseTest{&genv}
+	gct.listCoursesOwnedOK(t, []course.Course{c1, c2})
+
+	// Claimed accounts can't be claimed twice.
+	w = gt.claimToken(t, email)
+	defer w.Body.Close()
+	if w.StatusCode != http.StatusBadRequest {
+		t.Fatalf("claiming twice: expected 400, got %s", w.Status)
+	}
+}
+
+// checkout starts a stripe guest checkout of the passed courses.
+func (gt *guestTest) checkout(t *testing.T, email string, courseIDs ...string) *http.Response {
+	body, err := json.Marshal(map[string]any{"email": email, "courseIds": courseIDs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, gt.URL+"/orders/stripe/guest", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := gt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+// checkoutOK starts a stripe guest checkout and returns its session id.
+func (gt *guestTest) checkoutOK(t *testing.T, email string, courseIDs ...string) string {
+	w := gt.checkout(t, email, courseIDs...)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't create stripe guest order: status code %s", w.Status)
+	}
+
+	urlBytes, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var url string
+	if err := json.Unmarshal(urlBytes, &url); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mocked stripe returns the id in the URL.
+	return path.Base(url)
+}
+
+// claimToken asks for a new token to claim the account of the guest.
+func (gt *guestTest) claimToken(t *testing.T, email string) *http.Response {
+	body, err := json.Marshal(map[string]string{"email": email, "scope": token.ClaimToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, gt.URL+"/tokens", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := gt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+// claimOK claims the account of the guest, setting its password.
+func (gt *guestTest) claimOK(t *testing.T, email string, pass string) {
+	w := gt.claimToken(t, email)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Fatalf("can't send claim token: status code %s", w.Status)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"token":           gt.Mailer.token,
+		"name":            "Guest",
+		"password":        pass,
+		"passwordConfirm": pass,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, gt.URL+"/tokens/claim", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = gt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Fatalf("can't claim guest account: status code %s", w.Status)
+	}
+
+	if err := Logout(gt.Server); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/video"
+)
+
+type historyTest struct {
+	*TestEnv
+}
+
+func TestHistory(t *testing.T) {
+	env, err := NewTestEnv(t, "history_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ht := &historyTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+
+	crs := ct.createCourseOK(t)
+	v1 := vt.createVideoOK(t, crs.ID, 1)
+	v2 := vt.createVideoOK(t, crs.ID, 2)
+
+	if err := Login(ht.Server, ht.UserEmail, ht.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ht.Server)
+
+	if got := ht.listOK(t); len(got) != 0 {
+		t.Fatalf("expected an empty history, got %+v", got)
+	}
+
+	ht.watch(t, v1.ID)
+	ht.watch(t, v2.ID)
+	ht.watch(t, v1.ID)
+	vt.updateProgress(t, v1, video.ProgressUp{Progress: 40, Position: 120})
+
+	got := ht.listOK(t)
+	if len(got) != 2 || got[0].VideoID != v1.ID || got[1].VideoID != v2.ID {
+		t.Fatalf("expected the videos watched, the last first, got %+v", got)
+	}
+
+	if got[0].CourseID != crs.ID || got[0].CourseName != crs.Name || got[0].Progress != 40 || got[0].Position != 120 {
+		t.Fatalf("expected the course and the progress of the video, got %+v", got[0])
+	}
+}
+
+func (ht *historyTest) watch(t *testing.T, videoID string) {
+	r, err := http.NewRequest(http.MethodGet, ht.URL+"/videos/"+videoID+"/full", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ht.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't watch video: status code %s", w.Status)
+	}
+}
+
+func (ht *historyTest) listOK(t *testing.T) []video.Watched {
+	r, err := http.NewRequest(http.MethodGet, ht.URL+"/users/me/history", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ht.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list history: status code %s", w.Status)
+	}
+
+	var got []video.Watched
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal history: %v", err)
+	}
+
+	return got
+}
@@ -0,0 +1,203 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+const (
+	instructorEmail = "instructor@test.com"
+	instructorPass  = "instructorpass"
+)
+
+type instructorTest struct {
+	*TestEnv
+}
+
+func TestInstructor(t *testing.T) {
+	env, err := NewTestEnv(t, "instructor_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	it := &instructorTest{env}
+	ct := &courseTest{env}
+
+	other := ct.createCourseOK(t)
+	it.createInstructorOK(t)
+
+	if err := Login(it.Server, instructorEmail, instructorPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(it.Server)
+
+	code, crs := it.post(t, "/courses", course.CourseNew{
+		Name:        "Taught",
+		Description: "This is a test course",
+		Price:       money.New(1000, "USD"),
+		ImageURL:    "/images/test.png",
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("creating course as instructor: expected 201, got %d", code)
+	}
+
+	var got course.Course
+	if err := json.Unmarshal(crs, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != course.StatusDraft || got.InstructorID == nil {
+		t.Fatalf("expected a draft taught by the instructor, got %+v", got)
+	}
+
+	v := video.VideoNew{CourseID: got.ID, Index: 1, Name: "Intro", Description: "Test", ImageURL: "/images/new.png"}
+	if code, _ := it.post(t, "/videos", v); code != http.StatusCreated {
+		t.Fatalf("creating video in own course: expected 201, got %d", code)
+	}
+
+	draft := video.VideoNew{CourseID: got.ID, Index: 2, Name: "Draft", Description: "Test", ImageURL: "/images/new.png", Published: ptr(false)}
+	code, body := it.post(t, "/videos", draft)
+	if code != http.StatusCreated {
+		t.Fatalf("creating draft video in own course: expected 201, got %d", code)
+	}
+
+	var dv video.Video
+	if err := json.Unmarshal(body, &dv); err != nil {
+		t.Fatal(err)
+	}
+	if code := it.get(t, "/videos/"+dv.ID); code != http.StatusOK {
+		t.Fatalf("showing draft video of own course: expected 200, got %d", code)
+	}
+
+	up := video.UploadNew{CourseID: &got.ID, Filename: "intro.mp4", ContentType: "video/mp4", Size: 100}
+	if code, _ := it.post(t, "/uploads", up); code != http.StatusCreated {
+		t.Fatalf("uploading to own course: expected 201, got %d", code)
+	}
+
+	up.CourseID = &other.ID
+	if code, _ := it.post(t, "/uploads", up); code != http.StatusForbidden {
+		t.Fatalf("uploading to the course of others: expected 403, got %d", code)
+	}
+
+	up.CourseID = nil
+	if code, _ := it.post(t, "/uploads", up); code != http.StatusForbidden {
+		t.Fatalf("uploading without course: expected 403, got %d", code)
+	}
+
+	v.CourseID = other.ID
+	if code, _ := it.post(t, "/videos", v); code != http.StatusForbidden {
+		t.Fatalf("creating video in the course of others: expected 403, got %d", code)
+	}
+
+	if code := it.put(t, "/courses/"+other.ID, course.CourseUp{Name: ptr("Stolen")}); code != http.StatusForbidden {
+		t.Fatalf("updating the course of others: expected 403, got %d", code)
+	}
+
+	if code := it.put(t, "/courses/"+got.ID+"/status", course.StatusUp{Status: course.StatusPublished}); code != http.StatusOK {
+		t.Fatalf("publishing own course: expected 200, got %d", code)
+	}
+
+	r, err := http.NewRequest(http.MethodGet, it.URL+"/courses/instructed", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := it.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var taught []course.Course
+	if err := json.NewDecoder(w.Body).Decode(&taught); err != nil {
+		t.Fatalf("cannot unmarshal courses: %v", err)
+	}
+	if len(taught) != 1 || taught[0].ID != got.ID {
+		t.Fatalf("expected only course[%s], got %+v", got.ID, taught)
+	}
+}
+
+func (it *instructorTest) createInstructorOK(t *testing.T) {
+	if err := Login(it.Server, it.AdminEmail, it.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(it.Server)
+
+	code, _ := it.post(t, "/users", user.UserNew{
+		Name:            "Instructor",
+		Email:           instructorEmail,
+		Role:            claims.RoleInstructor,
+		Password:        instructorPass,
+		PasswordConfirm: instructorPass,
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("can't create instructor: status code %d", code)
+	}
+}
+
+func (it *instructorTest) post(t *testing.T, path string, payload any) (int, []byte) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, it.URL+path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := it.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(w.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	return w.StatusCode, buf.Bytes()
+}
+
+func (it *instructorTest) get(t *testing.T, path string) int {
+	r, err := http.NewRequest(http.MethodGet, it.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := it.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (it *instructorTest) put(t *testing.T, path string, payload any) int {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, it.URL+path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := it.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
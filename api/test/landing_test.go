@@ -0,0 +1,54 @@
+package test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+func TestLanding(t *testing.T) {
+	env, err := NewTestEnv(t, "landing_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &faqTest{env}
+	cpt := &completionTest{env}
+
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	cn := course.CourseNew{
+		Name:            "Go in practice",
+		Description:     "Write production Go",
+		Price:           money.New(5000, "USD"),
+		ImageURL:        "/images/go.png",
+		Objectives:      []string{"Write idiomatic Go", "Test concurrent code"},
+		Requirements:    []string{"Basic programming"},
+		LongDescription: "# Go in practice\n\nFrom zero to *production*.",
+	}
+
+	var crs course.Course
+	ft.do(t, http.MethodPost, "/courses", cn, &crs, http.StatusCreated)
+	if crs.Audience == nil || len(crs.Audience) != 0 {
+		t.Fatalf("expected an empty audience, got %#v", crs.Audience)
+	}
+
+	ft.do(t, http.MethodPut, "/courses/"+crs.ID, course.CourseUp{Audience: []string{"Backend developers"}}, nil, http.StatusOK)
+	ft.do(t, http.MethodPut, "/courses/"+crs.ID, course.CourseUp{Objectives: []string{""}}, nil, http.StatusUnprocessableEntity)
+
+	Logout(ft.Server)
+
+	var got course.Course
+	if code := cpt.get(t, "/courses/"+crs.ID, &got); code != http.StatusOK {
+		t.Fatalf("showing course: expected 200, got %d", code)
+	}
+	if !slices.Equal(got.Objectives, cn.Objectives) || !slices.Equal(got.Requirements, cn.Requirements) ||
+		!slices.Equal(got.Audience, []string{"Backend developers"}) || got.LongDescription != cn.LongDescription {
+		t.Fatalf("expected the landing content of the course, got %+v", got)
+	}
+}
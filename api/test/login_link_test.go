@@ -0,0 +1,50 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/token"
+)
+
+func TestLoginLink(t *testing.T) {
+	env, err := NewTestEnv(t, "login_link_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &faqTest{env}
+
+	type link struct {
+		Token string `json:"token"`
+	}
+
+	ft.do(t, http.MethodPost, "/tokens/login", link{Token: "unknown"}, nil, http.StatusUnauthorized)
+
+	req := struct {
+		Email string `json:"email"`
+		Scope string `json:"scope"`
+	}{
+		Email: ft.UserEmail,
+		Scope: token.LoginToken,
+	}
+
+	before := ft.Mailer.token
+	ft.do(t, http.MethodPost, "/tokens", req, nil, http.StatusNoContent)
+
+	// The token is emailed in background.
+	tok := ft.Mailer.token
+	for deadline := time.Now().Add(time.Second); tok == before && time.Now().Before(deadline); tok = ft.Mailer.token {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ft.do(t, http.MethodGet, "/users/current", nil, nil, http.StatusUnauthorized)
+	ft.do(t, http.MethodPost, "/tokens/login", link{Token: tok}, nil, http.StatusNoContent)
+	ft.do(t, http.MethodGet, "/users/current", nil, nil, http.StatusOK)
+
+	Logout(ft.Server)
+
+	// Links work only once.
+	ft.do(t, http.MethodPost, "/tokens/login", link{Token: tok}, nil, http.StatusUnauthorized)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http/cookiejar"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,7 +22,12 @@ import (
 	"github.com/jatolentino/tutorialspoint/api"
 	"github.com/jatolentino/tutorialspoint/api/background"
 	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/core/auth"
+	"github.com/jatolentino/tutorialspoint/core/order"
+	"github.com/jatolentino/tutorialspoint/core/search"
 	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/email"
+	"github.com/jatolentino/tutorialspoint/storage"
 	"github.com/sirupsen/logrus"
 	"github.com/stripe/stripe-go/v74"
 	stripecl "github.com/stripe/stripe-go/v74/client"
@@ -114,6 +120,10 @@ func startDB(user string, pass string, dbname string) (*dockertest.Resource, fun
 
 type mockMailer struct {
 	token string
+
+	// Gift codes are sent in background by the gift notifier.
+	mu    sync.Mutex
+	gifts []string
 }
 
 func (m *mockMailer) SendActivationToken(token string, dst string) error {
@@ -126,6 +136,65 @@ func (m *mockMailer) SendRecoveryToken(token string, dst string) error {
 	return nil
 }
 
+func (m *mockMailer) SendClaimToken(token string, dst string) error {
+	m.token = token
+	return nil
+}
+
+func (m *mockMailer) SendEmailChangeToken(token string, dst string) error {
+	m.token = token
+	return nil
+}
+
+func (m *mockMailer) SendEmailChanged(email string, dst string) error {
+	return nil
+}
+
+func (m *mockMailer) SendLoginToken(token string, dst string) error {
+	m.token = token
+	return nil
+}
+
+func (m *mockMailer) SendRefundNotice(orderID string, dst string) error {
+	return nil
+}
+
+func (m *mockMailer) SendFulfillmentAlert(orderID string, reason string, dst []string) error {
+	return nil
+}
+
+func (m *mockMailer) SendAccessExpiring(course string, renewURL string, expiresAt time.Time, dst string) error {
+	return nil
+}
+
+func (m *mockMailer) SendCheckoutReminder(cartURL string, dst string) error {
+	return nil
+}
+
+func (m *mockMailer) SendGift(course string, from string, redeemURL string, dst string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.gifts = append(m.gifts, redeemURL)
+	return nil
+}
+
+// giftCodes returns the codes of the gifts sent so far.
+func (m *mockMailer) giftCodes() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string(nil), m.gifts...)
+}
+
+func (m *mockMailer) SendReceipt(r email.Receipt, dst string) error {
+	return nil
+}
+
+func (m *mockMailer) SendOrgInvitation(org string, joinURL string, dst string) error {
+	return nil
+}
+
 const seedTest = `
 INSERT INTO users (user_id, name, email, role, active, password_hash, created_at, updated_at) VALUES
 	('ae127240-ce13-4789-aafd-d2f31e7ee487', 'Admin', '{{ .AdminEmail}}', 'ADMIN', TRUE, '{{ .AdminPassHash}}', '2022-09-16 00:00:00', '2022-09-16 00:00:00'),
@@ -146,10 +215,21 @@ type TestEnv struct {
 
 	// Collect mocked dependencies here to make them
 	// available to all tests.
-	Mailer        *mockMailer
-	Paypal        *mockPaypal
-	Stripe        *mockStripe
-	WebhookSecret string
+	Mailer               *mockMailer
+	Paypal               *mockPaypal
+	Stripe               *mockStripe
+	Razorpay             *mockRazorpay
+	Coinbase             *mockCoinbase
+	Mollie               *mockMollie
+	Github               *mockGithub
+	WebhookSecret        string
+	BillingWebhookSecret string
+	IntentWebhookSecret  string
+	RazorpaySecret       string
+	CoinbaseSecret       string
+
+	// Transcoding provider secret used to sign callbacks.
+	TranscodingSecret string
 }
 
 func (te *TestEnv) parseSeed() (string, error) {
@@ -245,6 +325,12 @@ func NewTestEnv(t *testing.T, dbname string) (*TestEnv, error) {
 	// Init a background manager to safely spawn go-routines.
 	bg := background.New(log)
 
+	// Send the codes of payed gifts, which are the redeem URLs as well.
+	gifts := &order.GiftNotifier{DB: dbEnv, Mailer: mail, Log: log}
+	bg.Add(func() error {
+		return gifts.Run(context.Background(), 10*time.Millisecond)
+	})
+
 	// Setup the mock for paypal payments.
 	te.Paypal = &mockPaypal{}
 	ppserver := httptest.NewServer(te.Paypal.handle())
@@ -266,10 +352,78 @@ func NewTestEnv(t *testing.T, dbname string) (*TestEnv, error) {
 		WebhookSecret: "random-test-secret",
 		SuccessURL:    "/success.html",
 		CancelURL:     "/cart.html",
+		AutomaticTax:  true,
+
+		PlanPrice:            planPrice,
+		BillingWebhookSecret: "random-billing-secret",
+		IntentWebhookSecret:  "random-intent-secret",
 	}
 	te.WebhookSecret = strpcfg.WebhookSecret
+	te.BillingWebhookSecret = strpcfg.BillingWebhookSecret
+	te.IntentWebhookSecret = strpcfg.IntentWebhookSecret
 	strp := &stripecl.API{}
 
+	// Setup the mock for razorpay payments.
+	te.Razorpay = &mockRazorpay{}
+	rzpserver := httptest.NewServer(te.Razorpay.handle())
+
+	rzpcfg := config.Razorpay{
+		KeyID:         "rzp_test",
+		KeySecret:     "random-razorpay-key",
+		WebhookSecret: "random-razorpay-secret",
+		URL:           rzpserver.URL,
+	}
+	te.RazorpaySecret = rzpcfg.WebhookSecret
+
+	// Setup the mock for coinbase payments.
+	te.Coinbase = &mockCoinbase{}
+	cbserver := httptest.NewServer(te.Coinbase.handle())
+
+	cbcfg := config.Coinbase{
+		APIKey:        "random-coinbase-key",
+		WebhookSecret: "random-coinbase-secret",
+		URL:           cbserver.URL,
+	}
+	te.CoinbaseSecret = cbcfg.WebhookSecret
+
+	// Setup the mock for mollie payments.
+	te.Mollie = &mockMollie{statuses: map[string]string{}}
+	mlserver := httptest.NewServer(te.Mollie.handle())
+
+	mlcfg := config.Mollie{
+		APIKey:  "random-mollie-key",
+		URL:     mlserver.URL,
+		Methods: []string{"ideal"},
+	}
+
+	// Setup the mock for github logins.
+	te.Github = &mockGithub{users: map[string]githubUser{}}
+	ghserver := httptest.NewServer(te.Github.handle())
+
+	oauthProvs := map[string]auth.Provider{
+		"github": auth.NewGithub(auth.ProviderConfig{
+			Name:        "github",
+			Kind:        auth.KindGithub,
+			Client:      "random-github-client",
+			Secret:      "random-github-secret",
+			URL:         ghserver.URL,
+			APIURL:      ghserver.URL,
+			RedirectURL: "/auth/oauth-callback/github",
+		}),
+	}
+
+	trcfg := config.Transcoding{
+		WebhookSecret: "random-transcoding-secret",
+		Tolerance:     time.Minute,
+	}
+	te.TranscodingSecret = trcfg.WebhookSecret
+
+	upcfg := config.Uploads{
+		Dir:       t.TempDir(),
+		MaxSize:   1 << 20,
+		ChunkSize: 1 << 10,
+	}
+
 	// Point to the mocked stripe server.
 	strp.Init(strpcfg.APISecret, &stripe.Backends{
 		API:     stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{URL: &strpserver.URL}),
@@ -288,7 +442,22 @@ func NewTestEnv(t *testing.T, dbname string) (*TestEnv, error) {
 		Paypal:             pp,
 		Stripe:             strp,
 		StripeCfg:          strpcfg,
+		RazorpayCfg:        rzpcfg,
+		CoinbaseCfg:        cbcfg,
+		MollieCfg:          mlcfg,
+		TranscodingCfg:     trcfg,
+		RefundsCfg:         config.Refunds{Window: time.Hour},
+		UploadsCfg:         upcfg,
+		ResourcesCfg:       config.Resources{MaxSize: 1 << 20, LinkTTL: time.Minute},
+		AbandonedCartsCfg:  config.AbandonedCarts{Secret: "random-cart-secret"},
+		PrerequisitesCfg:   config.Prerequisites{Enforced: true},
 		ActivationRequired: true,
+		TwoFactorCfg:       config.TwoFactor{Issuer: "Govod"},
+		WebauthnCfg:        config.Webauthn{RPID: "localhost", RPName: "Govod", Origin: "https://localhost", Timeout: time.Minute},
+		Providers:          oauthProvs,
+		LoginRedirectURL:   "/dashboard",
+		Search:             search.NewPostgres(dbEnv),
+		Storage:            storage.NewDisk(t.TempDir(), "https://cdn.example.com"),
 	})
 
 	jar, err := cookiejar.New(nil)
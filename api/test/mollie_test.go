@@ -0,0 +1,117 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+type mollieTest struct {
+	*TestEnv
+}
+
+func TestMollie(t *testing.T) {
+	env, err := NewTestEnv(t, "mollie_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	mt := &mollieTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+
+	// Webhooks of unknown payments are refused.
+	w := mt.webhook(t, "tr_unknown")
+	w.Body.Close()
+	if w.StatusCode != http.StatusBadRequest {
+		t.Fatalf("webhook of an unknown payment: expected 400, got %s", w.Status)
+	}
+
+	// A canceled payment is not fulfilled.
+	rt.createItemOK(t, c1.ID)
+	mt.Mollie.expectedCart = []course.Course{c1}
+	paymentID := mt.checkoutOK(t)
+	mt.Mollie.setStatus(paymentID, "canceled")
+	mt.webhookOK(t, paymentID)
+	ct.listCoursesOwnedOK(t, []course.Course{})
+
+	// Webhooks are verified against mollie: the payment is still open,
+	// so nothing happens.
+	rt.createItemOK(t, c2.ID)
+	mt.Mollie.expectedCart = []course.Course{c1, c2}
+	paymentID = mt.checkoutOK(t)
+	mt.webhookOK(t, paymentID)
+	ct.listCoursesOwnedOK(t, []course.Course{})
+
+	// A paid one is fulfilled.
+	mt.Mollie.setStatus(paymentID, "paid")
+	mt.webhookOK(t, paymentID)
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2})
+}
+
+// checkoutOK starts a mollie checkout and returns the payment id.
+func (mt *mollieTest) checkoutOK(t *testing.T) string {
+	if err := Login(mt.Server, mt.UserEmail, mt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(mt.Server)
+
+	r, err := http.NewRequest(http.MethodPost, mt.URL+"/orders/mollie", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := mt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't create mollie payment: status code %s", w.Status)
+	}
+
+	var href string
+	if err := json.NewDecoder(w.Body).Decode(&href); err != nil {
+		t.Fatalf("cannot unmarshal mollie checkout: %v", err)
+	}
+
+	// Mocked mollie returns the id in the URL.
+	return path.Base(href)
+}
+
+// webhook notifies that the specified payment changed, as mollie does.
+func (mt *mollieTest) webhook(t *testing.T, paymentID string) *http.Response {
+	form := url.Values{"id": {paymentID}}
+
+	r, err := http.NewRequest(http.MethodPost, mt.URL+"/orders/mollie/webhook", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w, err := mt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+// webhookOK notifies that the specified payment changed.
+func (mt *mollieTest) webhookOK(t *testing.T, paymentID string) {
+	w := mt.webhook(t, paymentID)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Fatalf("can't trigger mollie webhook for %s: status code %s", paymentID, w.Status)
+	}
+}
@@ -0,0 +1,242 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/core/user"
+)
+
+type oauthTest struct {
+	*TestEnv
+}
+
+func TestOauth(t *testing.T) {
+	env, err := NewTestEnv(t, "oauth_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ot := &oauthTest{env}
+	ft := &faqTest{env}
+
+	ot.Github.add("new", githubUser{ID: 1, Login: "newbie", Email: "newbie@email.com", Verified: true})
+	ot.Github.add("unverified", githubUser{ID: 2, Login: "impostor", Email: ot.UserEmail})
+	ot.Github.add("user", githubUser{ID: 3, Login: "user", Name: "User", Email: ot.UserEmail, Verified: true})
+	ot.Github.add("admin", githubUser{ID: 4, Login: "admin", Email: "admin@github.com"})
+
+	// Accounts are linked to new users.
+	if code := ot.login(t, "/auth/oauth-login/github", "new"); code != http.StatusFound {
+		t.Fatalf("logging in a new user: expected 302, got %d", code)
+	}
+	if got := ot.current(t); got.Email != "newbie@email.com" || got.Name != "newbie" {
+		t.Fatalf("expected the new user named after the login, got %+v", got)
+	}
+	Logout(ot.Server)
+
+	// Accounts are linked to the users with the same email, if verified.
+	if code := ot.login(t, "/auth/oauth-login/github", "unverified"); code != http.StatusForbidden {
+		t.Fatalf("logging in with an unverified email: expected 403, got %d", code)
+	}
+	for i := 0; i < 2; i++ {
+		if code := ot.login(t, "/auth/oauth-login/github", "user"); code != http.StatusFound {
+			t.Fatalf("logging in a user with a password: expected 302, got %d", code)
+		}
+		if got := ot.current(t); got.Email != ot.UserEmail {
+			t.Fatalf("expected user %s, got %+v", ot.UserEmail, got)
+		}
+		Logout(ot.Server)
+	}
+
+	// Accounts are linked explicitly to the users authenticated.
+	if err := Login(ot.Server, ot.AdminEmail, ot.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	if code := ot.login(t, "/auth/oauth-link/github", "user"); code != http.StatusConflict {
+		t.Fatalf("linking the account of another user: expected 409, got %d", code)
+	}
+	if code := ot.login(t, "/auth/oauth-link/github", "admin"); code != http.StatusFound {
+		t.Fatalf("linking an account: expected 302, got %d", code)
+	}
+	Logout(ot.Server)
+
+	ft.do(t, http.MethodGet, "/auth/oauth-link/github", nil, nil, http.StatusUnauthorized)
+
+	if code := ot.login(t, "/auth/oauth-login/github", "admin"); code != http.StatusFound {
+		t.Fatalf("logging in with a linked account: expected 302, got %d", code)
+	}
+	if got := ot.current(t); got.Email != ot.AdminEmail {
+		t.Fatalf("expected user %s, got %+v", ot.AdminEmail, got)
+	}
+	Logout(ot.Server)
+
+	// Providers may post the authorization code, e.g. apple.
+	if code := ot.loginFormPost(t, "new"); code != http.StatusFound {
+		t.Fatalf("logging in via form post: expected 302, got %d", code)
+	}
+	if got := ot.current(t); got.Email != "newbie@email.com" {
+		t.Fatalf("expected user newbie@email.com, got %+v", got)
+	}
+	Logout(ot.Server)
+}
+
+// login goes through the oauth flow started at path, the provider
+// granting the passed authorization code, and returns the status code
+// of the callback.
+func (ot *oauthTest) login(t *testing.T, path string, code string) int {
+	q := url.Values{"state": {ot.start(t, path)}, "code": {code}}
+	w, err := ot.Client().Get(ot.URL + "/auth/oauth-callback/github?" + q.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+// loginFormPost is like login, the provider posting the authorization
+// code to the callback.
+func (ot *oauthTest) loginFormPost(t *testing.T, code string) int {
+	form := url.Values{"state": {ot.start(t, "/auth/oauth-login/github")}, "code": {code}}
+	w, err := ot.Client().PostForm(ot.URL+"/auth/oauth-callback/github", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusSeeOther {
+		t.Fatalf("posting oauth callback: status code %s", w.Status)
+	}
+
+	cb, err := ot.Client().Get(ot.URL + w.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cb.Body.Close()
+
+	return cb.StatusCode
+}
+
+// start starts the oauth flow at path, returning its state.
+func (ot *oauthTest) start(t *testing.T, path string) string {
+	w, err := ot.Client().Get(ot.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("starting oauth flow: status code %s", w.Status)
+	}
+
+	var consent string
+	if err := json.NewDecoder(w.Body).Decode(&consent); err != nil {
+		t.Fatalf("cannot unmarshal consent url: %v", err)
+	}
+
+	u, err := url.Parse(consent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u.Query().Get("state")
+}
+
+func (ot *oauthTest) current(t *testing.T) user.User {
+	w, err := ot.Client().Get(ot.URL + "/users/current")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("showing current user: status code %s", w.Status)
+	}
+
+	var got user.User
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal current user: %v", err)
+	}
+
+	return got
+}
+
+type githubUser struct {
+	ID       int64
+	Login    string
+	Name     string
+	Email    string
+	Verified bool
+}
+
+type mockGithub struct {
+	mu sync.Mutex
+
+	// users are the users who granted the authorization codes, by code.
+	users map[string]githubUser
+}
+
+// add makes the user grant the passed authorization code.
+func (m *mockGithub) add(code string, u githubUser) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[code] = u
+}
+
+func (m *mockGithub) user(code string) (githubUser, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.users[code]
+	return u, ok
+}
+
+func (m *mockGithub) handle() http.Handler {
+	// Access tokens are the authorization codes they are exchanged for.
+	authorized := func(r *http.Request) (githubUser, bool) {
+		return m.user(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		code := r.FormValue("code")
+		if _, ok := m.user(code); !ok {
+			web.Respond(context.Background(), w, map[string]string{"error": "bad_verification_code"}, 400)
+			return
+		}
+
+		web.Respond(context.Background(), w, map[string]string{"access_token": code, "token_type": "bearer"}, 200)
+	})
+
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		u, ok := authorized(r)
+		if !ok {
+			web.Respond(context.Background(), w, nil, 401)
+			return
+		}
+
+		web.Respond(context.Background(), w, map[string]any{"id": u.ID, "login": u.Login, "name": u.Name}, 200)
+	})
+
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		u, ok := authorized(r)
+		if !ok {
+			web.Respond(context.Background(), w, nil, 401)
+			return
+		}
+
+		emails := []map[string]any{
+			{"email": u.Login + "@users.noreply.github.com", "primary": false, "verified": true},
+			{"email": u.Email, "primary": true, "verified": u.Verified},
+		}
+		web.Respond(context.Background(), w, emails, 200)
+	})
+
+	return mux
+}
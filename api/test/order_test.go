@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
 	"path"
 	"testing"
 	"time"
 
 	"github.com/plutov/paypal/v4"
 	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/order"
+	"github.com/jatolentino/tutorialspoint/validate"
 	"github.com/stripe/stripe-go/v74"
 	"github.com/stripe/stripe-go/v74/webhook"
 )
@@ -61,6 +64,457 @@ func TestOrder(t *testing.T) {
 
 	// Check if the stripe payment has been correctly fulfilled.
 	ct.listCoursesOwnedOK(t, []course.Course{c1, c2, c3, c4})
+
+	// Let a stripe checkout expire.
+	c5 := ct.createCourseOK(t)
+	rt.createItemOK(t, c5.ID)
+	ot.Stripe.expectedCart = []course.Course{c5}
+	ot.testStripeExpired(t)
+
+	// Check that the expired checkout has not been fulfilled.
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2, c3, c4})
+
+	// The user can see all the orders placed, the latest first.
+	ot.testListMine(t, c5)
+	ot.testSearch(t, c5)
+
+	// Fulfilled orders come with an invoice.
+	ot.testInvoice(t)
+
+	// The details of an order include the history of its status.
+	ot.testShow(t)
+
+	// Courses bought forever can't be renewed.
+	ot.testRenewNotRented(t, c1)
+
+	// Gifts need a valid recipient and a valid code to be redeemed.
+	ot.testGiftNoRecipient(t, c1)
+	ot.testRedeemNotFound(t)
+
+	// Only existing orders can be refunded.
+	ot.testRefundNotFound(t)
+
+	// Retrying a checkout doesn't start a new purchase.
+	ot.testStripeIdempotent(t)
+
+	// Orders completed without incidents have no failures.
+	ot.testNoFailures(t)
+
+	// Nothing is reported until the payments are reconciled.
+	ot.testNoMismatches(t)
+
+	// Installments are fulfilled once payed, not when completed.
+	c6 := ct.createCourseOK(t)
+	rt.createItemOK(t, c6.ID)
+	ot.Stripe.expectedCart = []course.Course{c6}
+	sessionID := ot.stripeCheckout(t)
+	ot.stripeEvent(t, "checkout.session.completed", map[string]any{
+		"id":             sessionID,
+		"mode":           stripe.CheckoutSessionModePayment,
+		"payment_status": stripe.CheckoutSessionPaymentStatusUnpaid,
+	})
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2, c3, c4})
+
+	ot.stripeEvent(t, "checkout.session.async_payment_succeeded", map[string]any{
+		"id":             sessionID,
+		"mode":           stripe.CheckoutSessionModePayment,
+		"payment_status": stripe.CheckoutSessionPaymentStatusPaid,
+	})
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2, c3, c4, c6})
+}
+
+// testNoFailures checks that no failures are recorded for orders
+// fulfilled at the first attempt.
+func (ot *orderTest) testNoFailures(t *testing.T) {
+	if err := Login(ot.Server, ot.AdminEmail, ot.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodGet, ot.URL+"/orders/"+validate.GenerateID()+"/failures", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list failures: status code %s", w.Status)
+	}
+
+	var got []order.Failure
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal failures: %v", err)
+	}
+
+	if len(got) != 0 {
+		t.Fatalf("expected no failures, got %+v", got)
+	}
+}
+
+// testNoMismatches checks that administrators can review the mismatches
+// found by the reconciliation and that, as it never ran, there are none.
+func (ot *orderTest) testNoMismatches(t *testing.T) {
+	if err := Login(ot.Server, ot.AdminEmail, ot.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodGet, ot.URL+"/orders/mismatches?since=2023-01-01", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list mismatches: status code %s", w.Status)
+	}
+
+	var got []order.Mismatch
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal mismatches: %v", err)
+	}
+
+	if len(got) != 0 {
+		t.Fatalf("expected no mismatches, got %+v", got)
+	}
+}
+
+// testStripeIdempotent checks that checkouts with the same idempotency
+// key return the same stripe session, and that the key can't be reused
+// for a different checkout.
+func (ot *orderTest) testStripeIdempotent(t *testing.T) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	checkout := func(query string, exp int) string {
+		r, err := http.NewRequest(http.MethodPost, ot.URL+"/orders/stripe"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Header.Set(order.IdempotencyHeader, "checkout-1")
+
+		w, err := ot.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Body.Close()
+
+		if w.StatusCode != exp {
+			t.Fatalf("checking out with query %q: expected %d, got %s", query, exp, w.Status)
+		}
+
+		b, err := io.ReadAll(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if first, second := checkout("", http.StatusOK), checkout("", http.StatusOK); first != second {
+		t.Fatalf("retried checkout returned %s instead of %s", second, first)
+	}
+
+	checkout("?wallet=true", http.StatusUnprocessableEntity)
+}
+
+// testListMine checks that users can see their orders, paginated.
+func (ot *orderTest) testListMine(t *testing.T, last course.Course) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodGet, ot.URL+"/orders/mine?limit=2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list orders: status code %s", w.Status)
+	}
+
+	var got []order.Receipt
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal orders: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected a page of 2 orders, got %d", len(got))
+	}
+
+	if got[0].Status != order.Expired || len(got[0].Items) != 1 || got[0].Items[0].CourseID != last.ID {
+		t.Fatalf("expected the expired order first, got %+v", got[0])
+	}
+
+	if got[1].Status != order.Success || got[1].Provider != order.ProviderStripe || len(got[1].Items) != 2 {
+		t.Fatalf("expected the stripe order second, got %+v", got[1])
+	}
+
+	// Stripe calculates the taxes of each item.
+	for _, it := range got[1].Items {
+		if exp := it.Price.Units * 20 / 120; it.Tax.Units != exp {
+			t.Fatalf("expected a tax of %d on item[%s], got %s", exp, it.CourseID, it.Tax)
+		}
+	}
+}
+
+// testInvoice checks that the user can download the invoice of the
+// last fulfilled order.
+func (ot *orderTest) testInvoice(t *testing.T) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodGet, ot.URL+"/orders/mine?limit=2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got []order.Receipt
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal orders: %v", err)
+	}
+
+	if len(got) != 2 || got[1].Status != order.Success {
+		t.Fatalf("expected a fulfilled order, got %+v", got)
+	}
+
+	r, err = http.NewRequest(http.MethodGet, ot.URL+"/orders/"+got[1].ID+"/invoice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't download invoice: status code %s", w.Status)
+	}
+
+	if ct := w.Header.Get("Content-Type"); ct != "application/pdf" {
+		t.Fatalf("expected a pdf invoice, got %s", ct)
+	}
+
+	b, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(b, []byte("%PDF-")) {
+		t.Fatalf("invoice is not a pdf document")
+	}
+}
+
+// testShow checks that the user can see the details of the last
+// fulfilled order.
+func (ot *orderTest) testShow(t *testing.T) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodGet, ot.URL+"/orders/mine?limit=2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var mine []order.Receipt
+	if err := json.NewDecoder(w.Body).Decode(&mine); err != nil {
+		t.Fatalf("cannot unmarshal orders: %v", err)
+	}
+
+	if len(mine) != 2 || mine[1].Status != order.Success {
+		t.Fatalf("expected a fulfilled order, got %+v", mine)
+	}
+
+	r, err = http.NewRequest(http.MethodGet, ot.URL+"/orders/"+mine[1].ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't show order: status code %s", w.Status)
+	}
+
+	var got order.Detail
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal order: %v", err)
+	}
+
+	if got.ProviderID != mine[1].ProviderID || len(got.Items) != 2 || !got.Refunded.IsZero() {
+		t.Fatalf("wrong order details, got %+v", got)
+	}
+
+	if len(got.History) != 2 || got.History[0].Status != order.Pending || got.History[1].Status != order.Success {
+		t.Fatalf("expected the order to go from pending to success, got %+v", got.History)
+	}
+}
+
+// testSearch checks that admins can filter the orders.
+func (ot *orderTest) testSearch(t *testing.T, expired course.Course) {
+	if err := Login(ot.Server, ot.AdminEmail, ot.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	q := url.Values{}
+	q.Set("status", string(order.Expired))
+	q.Set("email", ot.UserEmail)
+	q.Set("provider", order.ProviderStripe)
+
+	r, err := http.NewRequest(http.MethodGet, ot.URL+"/orders?"+q.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't search orders: status code %s", w.Status)
+	}
+
+	var got []order.Receipt
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal orders: %v", err)
+	}
+
+	if len(got) != 1 || len(got[0].Items) != 1 || got[0].Items[0].CourseID != expired.ID {
+		t.Fatalf("expected only the expired order, got %+v", got)
+	}
+}
+
+// testRefundNotFound checks that refunding an inexistent order fails.
+func (ot *orderTest) testRefundNotFound(t *testing.T) {
+	if err := Login(ot.Server, ot.AdminEmail, ot.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodPost, ot.URL+"/orders/"+validate.GenerateID()+"/refund", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNotFound {
+		t.Fatalf("refunding an inexistent order should fail: status code %s", w.Status)
+	}
+}
+
+// testRenewNotRented checks that only rented courses can be renewed.
+func (ot *orderTest) testRenewNotRented(t *testing.T, c course.Course) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodPost, ot.URL+"/orders/stripe?renew="+c.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("renewing a course which is not rented should fail: status code %s", w.Status)
+	}
+}
+
+func (ot *orderTest) testGiftNoRecipient(t *testing.T, c course.Course) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodPost, ot.URL+"/orders/stripe?gift="+c.ID+"&to=nobody", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("gifting a course to an invalid email should fail: status code %s", w.Status)
+	}
+}
+
+func (ot *orderTest) testRedeemNotFound(t *testing.T) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodPost, ot.URL+"/gifts/unknown/redeem", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNotFound {
+		t.Fatalf("redeeming an unknown gift should fail: status code %s", w.Status)
+	}
 }
 
 func (ot *orderTest) testPaypal(t *testing.T) {
@@ -110,6 +564,22 @@ func (ot *orderTest) testPaypal(t *testing.T) {
 }
 
 func (ot *orderTest) testStripe(t *testing.T) {
+	sessionID := ot.stripeCheckout(t)
+
+	// Now simulate the payment by triggering a stripe webhook.
+	ot.stripeWebhook(t, "checkout.session.completed", sessionID)
+}
+
+// testStripeExpired checks that an expired checkout is not fulfilled.
+func (ot *orderTest) testStripeExpired(t *testing.T) {
+	sessionID := ot.stripeCheckout(t)
+
+	// Simulate the expiration of the checkout by triggering a stripe webhook.
+	ot.stripeWebhook(t, "checkout.session.expired", sessionID)
+}
+
+// stripeCheckout starts a stripe checkout and returns its session id.
+func (ot *orderTest) stripeCheckout(t *testing.T) string {
 	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
 		t.Fatal(err)
 	}
@@ -131,8 +601,6 @@ func (ot *orderTest) testStripe(t *testing.T) {
 		t.Fatalf("can't create stripe order: status code %s", w.Status)
 	}
 
-	// Now simulate the payment by triggering a stripe webhook.
-	//
 	// Extract the checkout session id from the location returned by the mock.
 	urlBytes, err := io.ReadAll(w.Body)
 	if err != nil {
@@ -144,15 +612,28 @@ func (ot *orderTest) testStripe(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// Mocked stripe returns the id in the URL.
+	return path.Base(url)
+}
+
+// stripeWebhook triggers a signed stripe webhook of the passed type
+// for the specified checkout session.
+func (ot *orderTest) stripeWebhook(t *testing.T, typ string, sessionID string) {
+
 	// Generate the webhook payload.
 	//
 	// Set the same checkout id previously obtained.
 	obj := map[string]any{
-		// Mocked stripe returns the id in the URL.
-		"id":   path.Base(url),
+		"id":   sessionID,
 		"mode": stripe.CheckoutSessionModePayment,
 	}
 
+	ot.stripeEvent(t, typ, obj)
+}
+
+// stripeEvent triggers the stripe webhook with an event of the passed
+// type regarding the passed checkout session.
+func (ot *orderTest) stripeEvent(t *testing.T, typ string, obj map[string]any) {
 	raw, err := json.Marshal(obj)
 	if err != nil {
 		t.Fatal(err)
@@ -162,7 +643,7 @@ func (ot *orderTest) testStripe(t *testing.T) {
 	evt := stripe.Event{
 		// Required by stripe-go 74.2.0 .
 		APIVersion: "2022-11-15",
-		Type:       "checkout.session.completed",
+		Type:       typ,
 		Data: &stripe.EventData{
 			Raw: json.RawMessage(raw),
 		},
@@ -181,19 +662,19 @@ func (ot *orderTest) testStripe(t *testing.T) {
 	})
 
 	// Finally trigger the webhook.
-	r, err = http.NewRequest(http.MethodPost, ot.URL+"/orders/stripe/capture", bytes.NewBuffer(b))
+	r, err := http.NewRequest(http.MethodPost, ot.URL+"/orders/stripe/capture", bytes.NewBuffer(b))
 	if err != nil {
 		t.Fatal(err)
 	}
 	r.Header.Set("Stripe-Signature", signed.Header)
 
-	w, err = ot.Client().Do(r)
+	w, err := ot.Client().Do(r)
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer w.Body.Close()
 
 	if w.StatusCode != http.StatusNoContent {
-		t.Fatalf("can't trigger stripe webhook: status code %s", w.Status)
+		t.Fatalf("can't trigger stripe webhook %s: status code %s", typ, w.Status)
 	}
 }
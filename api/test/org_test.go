@@ -0,0 +1,209 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/org"
+)
+
+type orgTest struct {
+	*TestEnv
+}
+
+func TestOrg(t *testing.T) {
+	env, err := NewTestEnv(t, "org_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ot := &orgTest{env}
+	ct := &courseTest{env}
+
+	c := ct.createCourseOK(t)
+	o := ot.createOrgOK(t)
+	ot.showOrgNotMember(t, o)
+	ot.inviteOK(t, o, ot.AdminEmail)
+	m := ot.joinOK(t, o)
+	ot.assignNoSeats(t, o, c, *m.UserID)
+
+	// The member puts the course in the cart meanwhile.
+	menv := *env
+	menv.UserEmail = env.AdminEmail
+	menv.UserPass = env.AdminPass
+	mrt := &cartTest{&menv}
+	mrt.createItemOK(t, c.ID)
+
+	// Courses owned can still be bought as seats for others.
+	ft := &freeTest{env}
+	rt := &cartTest{env}
+	ft.createFreeCouponOK(t)
+	rt.createItemOK(t, c.ID)
+	if code := ft.enroll(t, "FREE100&org="+o.ID+"&seats=1"); code != http.StatusOK {
+		t.Fatalf("buying seats: expected 200, got %d", code)
+	}
+	ot.assignOK(t, o, c, *m.UserID)
+
+	// Members assigned a seat can't buy the course again.
+	mft := &freeTest{&menv}
+	if code := mft.enroll(t, "FREE100"); code != http.StatusUnprocessableEntity {
+		t.Fatalf("buying a course owned already: expected 422, got %d", code)
+	}
+}
+
+func (ot *orgTest) createOrgOK(t *testing.T) org.Org {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	body := `{"name": "Acme"}`
+	r, err := http.NewRequest(http.MethodPost, ot.URL+"/orgs", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create organization: status code %s", w.Status)
+	}
+
+	var got org.Org
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal created organization: %v", err)
+	}
+
+	return got
+}
+
+func (ot *orgTest) showOrgNotMember(t *testing.T, o org.Org) {
+	if err := Login(ot.Server, ot.AdminEmail, ot.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodGet, ot.URL+"/orgs/"+o.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNotFound {
+		t.Fatalf("non members should not see the organization: status code %s", w.Status)
+	}
+}
+
+func (ot *orgTest) inviteOK(t *testing.T, o org.Org, email string) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	body := `{"email": "` + email + `"}`
+	r, err := http.NewRequest(http.MethodPost, ot.URL+"/orgs/"+o.ID+"/invitations", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't invite member: status code %s", w.Status)
+	}
+}
+
+func (ot *orgTest) joinOK(t *testing.T, o org.Org) org.Member {
+	if err := Login(ot.Server, ot.AdminEmail, ot.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	r, err := http.NewRequest(http.MethodPost, ot.URL+"/orgs/"+o.ID+"/join", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't join organization: status code %s", w.Status)
+	}
+
+	var got org.Member
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal member: %v", err)
+	}
+
+	if got.UserID == nil || got.JoinedAt == nil || got.Role != org.RoleMember {
+		t.Fatalf("unexpected member after joining: %+v", got)
+	}
+
+	return got
+}
+
+func (ot *orgTest) assignNoSeats(t *testing.T, o org.Org, c course.Course, userID string) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	url := ot.URL + "/orgs/" + o.ID + "/seats/" + c.ID + "/assignments/" + userID
+	r, err := http.NewRequest(http.MethodPut, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNotFound {
+		t.Fatalf("assigning seats never bought should fail: status code %s", w.Status)
+	}
+}
+
+func (ot *orgTest) assignOK(t *testing.T, o org.Org, c course.Course, userID string) {
+	if err := Login(ot.Server, ot.UserEmail, ot.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ot.Server)
+
+	url := ot.URL + "/orgs/" + o.ID + "/seats/" + c.ID + "/assignments/" + userID
+	r, err := http.NewRequest(http.MethodPut, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ot.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't assign seat: status code %s", w.Status)
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/webauthn"
+)
+
+func TestPasskeys(t *testing.T) {
+	env, err := NewTestEnv(t, "passkey_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &faqTest{env}
+
+	// The assertion is refused without a challenge issued.
+	var asr webauthn.Assertion
+	asr.ID = []byte("unknown")
+	asr.Response.ClientDataJSON = []byte("{}")
+	asr.Response.AuthenticatorData = []byte("data")
+	asr.Response.Signature = []byte("signature")
+	ft.do(t, http.MethodPost, "/auth/passkey-login", asr, nil, http.StatusUnauthorized)
+
+	var reqOpts webauthn.RequestOptions
+	ft.do(t, http.MethodPost, "/auth/passkey-login/options", nil, &reqOpts, http.StatusOK)
+	if reqOpts.RPID != "localhost" || len(reqOpts.Challenge) != 32 {
+		t.Fatalf("unexpected request options: %+v", reqOpts)
+	}
+
+	// Unknown credentials.
+	ft.do(t, http.MethodPost, "/auth/passkey-login", asr, nil, http.StatusUnauthorized)
+
+	ft.do(t, http.MethodPost, "/users/current/passkeys/options", nil, nil, http.StatusUnauthorized)
+
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	var opts webauthn.CreationOptions
+	ft.do(t, http.MethodPost, "/users/current/passkeys/options", nil, &opts, http.StatusOK)
+	if string(opts.User.ID) != userID || opts.User.Name != ft.UserEmail {
+		t.Fatalf("unexpected user entity: %+v", opts.User)
+	}
+
+	var att webauthn.Attestation
+	att.ID = []byte("credential")
+	att.Response.ClientDataJSON = []byte(`{"type":"webauthn.create"}`)
+	att.Response.AttestationObject = []byte{0xa0}
+	ft.do(t, http.MethodPost, "/users/current/passkeys", att, nil, http.StatusUnprocessableEntity)
+
+	// The challenge is used once.
+	ft.do(t, http.MethodPost, "/users/current/passkeys", att, nil, http.StatusBadRequest)
+
+	var creds []webauthn.Credential
+	ft.do(t, http.MethodGet, "/users/current/passkeys", nil, &creds, http.StatusOK)
+	if len(creds) != 0 {
+		t.Fatalf("expected no passkeys, got %d", len(creds))
+	}
+
+	ft.do(t, http.MethodDelete, "/users/current/passkeys/Y3JlZGVudGlhbA", nil, nil, http.StatusNotFound)
+}
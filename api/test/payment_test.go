@@ -7,11 +7,15 @@ import (
 	"math/rand"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/plutov/paypal/v4"
 	"github.com/jatolentino/tutorialspoint/api/web"
 	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
 	mock "github.com/stripe/stripe-mock/param"
 )
 
@@ -42,13 +46,13 @@ func (m *mockPaypal) handle() http.Handler {
 			return
 		}
 
-		var tot int
+		tot := money.Zero("USD")
 		for _, c := range m.expectedCart {
-			tot += c.Price
+			tot, _ = tot.Add(c.Price)
 		}
 
 		// Check the paypal amount against the total of the cart.
-		if pu.Units[0].Amount.Value != strconv.Itoa(tot) {
+		if pu.Units[0].Amount.Value != tot.Decimal() || pu.Units[0].Amount.Currency != tot.Currency {
 			web.Respond(context.Background(), w, nil, 400)
 			return
 		}
@@ -71,6 +75,9 @@ func (m *mockPaypal) handle() http.Handler {
 	return r
 }
 
+// planPrice is the stripe price of the all-access plan.
+const planPrice = "price_all_access"
+
 type mockStripe struct {
 	expectedCart []course.Course
 }
@@ -82,8 +89,30 @@ func (m *mockStripe) handle() http.Handler {
 		params, _ := mock.ParseParams(r)
 		lines := params["line_items"].(map[string]any)
 
+		// Taxes are calculated by stripe on the billing address.
+		tax, _ := params["automatic_tax"].(map[string]any)
+		if tax["enabled"] != "true" || params["billing_address_collection"] != "required" {
+			web.Respond(context.Background(), w, nil, 400)
+			return
+		}
+
+		// Subscriptions buy the plan, on behalf of the user.
+		if params["mode"] == "subscription" {
+			it := lines["0"].(map[string]any)
+			sub, _ := params["subscription_data"].(map[string]any)
+			meta, _ := sub["metadata"].(map[string]any)
+			if it["price"] != planPrice || meta["user_id"] != params["client_reference_id"] {
+				web.Respond(context.Background(), w, nil, 400)
+				return
+			}
+
+			sess := map[string]any{"id": "cs_subscription", "url": "cs_subscription"}
+			web.Respond(context.Background(), w, sess, 201)
+			return
+		}
+
 		n := 0
-		tot := 0
+		tot := int64(0)
 		for _, li := range lines {
 			it := li.(map[string]any)
 
@@ -100,8 +129,8 @@ func (m *mockStripe) handle() http.Handler {
 				return
 			}
 
-			// Stripe prices are expressed in cents.
-			tot += int(amount / 100)
+			// Stripe prices are expressed in minor units, as ours.
+			tot += amount
 			n += 1
 		}
 
@@ -111,9 +140,9 @@ func (m *mockStripe) handle() http.Handler {
 			return
 		}
 
-		exp := 0
+		exp := int64(0)
 		for _, c := range m.expectedCart {
-			exp += c.Price
+			exp += c.Price.Units
 		}
 
 		// Check the total amount against the cart.
@@ -128,7 +157,255 @@ func (m *mockStripe) handle() http.Handler {
 		web.Respond(context.Background(), w, ord, 201)
 	})
 
+	// Every course of the cart is subject to an inclusive 20% VAT.
+	lineItems := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := make([]map[string]any, 0, len(m.expectedCart))
+		for _, c := range m.expectedCart {
+			data = append(data, map[string]any{
+				"object":   "item",
+				"currency": strings.ToLower(c.Price.Currency),
+				"quantity": 1,
+				"price": map[string]any{
+					"product": map[string]any{
+						"metadata": map[string]string{"course_id": c.ID},
+					},
+				},
+				"taxes": []map[string]any{{
+					"amount": c.Price.Units * 20 / 120,
+					"rate": map[string]any{
+						"display_name": "VAT",
+						"jurisdiction": "IT",
+						"country":      "IT",
+						"percentage":   20,
+						"inclusive":    true,
+					},
+				}},
+			})
+		}
+
+		list := map[string]any{"object": "list", "data": data, "has_more": false}
+		web.Respond(context.Background(), w, list, 200)
+	})
+
+	// Subscriptions are always active for another month, on behalf
+	// of the test user.
+	subscription := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub := map[string]any{
+			"id":                 mux.Vars(r)["id"],
+			"object":             "subscription",
+			"status":             "active",
+			"current_period_end": time.Now().AddDate(0, 1, 0).Unix(),
+			"customer":           "cus_test",
+			"metadata":           map[string]string{"user_id": seedUserID},
+		}
+		web.Respond(context.Background(), w, sub, 200)
+	})
+
+	// Payment intents charge the total of the cart at once.
+	intent := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := mock.ParseParams(r)
+
+		methods, _ := params["automatic_payment_methods"].(map[string]any)
+		meta, _ := params["metadata"].(map[string]any)
+		if methods["enabled"] != "true" || meta["origin"] != "payment_element" {
+			web.Respond(context.Background(), w, nil, 400)
+			return
+		}
+
+		exp := int64(0)
+		for _, c := range m.expectedCart {
+			exp += c.Price.Units
+		}
+
+		if params["amount"] != strconv.FormatInt(exp, 10) || params["currency"] != "usd" {
+			web.Respond(context.Background(), w, nil, 400)
+			return
+		}
+
+		randID := fmt.Sprintf("pi-%d", rand.Intn(300))
+		pi := map[string]any{"id": randID, "client_secret": randID + "_secret", "status": "requires_payment_method"}
+		web.Respond(context.Background(), w, pi, 200)
+	})
+
 	r := mux.NewRouter()
 	r.Handle("/v1/checkout/sessions", checkout).Methods("POST")
+	r.Handle("/v1/payment_intents", intent).Methods("POST")
+	r.Handle("/v1/subscriptions/{id}", subscription).Methods("GET")
+	r.Handle("/v1/checkout/sessions/{id}/line_items", lineItems).Methods("GET")
+	return r
+}
+
+type mockRazorpay struct {
+	expectedCart []course.Course
+}
+
+func (m *mockRazorpay) handle() http.Handler {
+	order := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "rzp_test" || pass != "random-razorpay-key" {
+			web.Respond(context.Background(), w, nil, 401)
+			return
+		}
+
+		var in struct {
+			Amount   int64  `json:"amount"`
+			Currency string `json:"currency"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			web.Respond(context.Background(), w, err, 400)
+			return
+		}
+
+		exp := int64(0)
+		for _, c := range m.expectedCart {
+			exp += c.Price.Units
+		}
+
+		// Razorpay amounts are expressed in minor units, as ours.
+		if in.Amount != exp || in.Currency != "USD" {
+			web.Respond(context.Background(), w, nil, 400)
+			return
+		}
+
+		ord := map[string]any{"id": fmt.Sprintf("order_%d", rand.Intn(300)), "amount": in.Amount, "status": "created"}
+		web.Respond(context.Background(), w, ord, 200)
+	})
+
+	// Every order has been payed.
+	payments := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		pay := map[string]any{"id": "pay_" + id, "order_id": id, "status": "captured"}
+		web.Respond(context.Background(), w, map[string]any{"items": []any{pay}}, 200)
+	})
+
+	r := mux.NewRouter()
+	r.Handle("/v1/orders", order).Methods("POST")
+	r.Handle("/v1/orders/{id}/payments", payments).Methods("GET")
+	return r
+}
+
+type mockCoinbase struct {
+	expectedCart []course.Course
+}
+
+func (m *mockCoinbase) handle() http.Handler {
+	charge := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-CC-Api-Key") != "random-coinbase-key" {
+			web.Respond(context.Background(), w, nil, 401)
+			return
+		}
+
+		var in struct {
+			PricingType string            `json:"pricing_type"`
+			LocalPrice  map[string]string `json:"local_price"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			web.Respond(context.Background(), w, err, 400)
+			return
+		}
+
+		exp := int64(0)
+		for _, c := range m.expectedCart {
+			exp += c.Price.Units
+		}
+
+		// Coinbase prices are expressed in major units.
+		if in.PricingType != "fixed_price" || in.LocalPrice["amount"] != money.New(exp, "USD").Decimal() {
+			web.Respond(context.Background(), w, nil, 400)
+			return
+		}
+
+		id := fmt.Sprintf("charge-%d", rand.Intn(300))
+		data := map[string]any{"id": id, "code": id, "hosted_url": "https://commerce.coinbase.com/charges/" + id}
+		web.Respond(context.Background(), w, map[string]any{"data": data}, 201)
+	})
+
+	r := mux.NewRouter()
+	r.Handle("/charges", charge).Methods("POST")
+	return r
+}
+
+type mockMollie struct {
+	expectedCart []course.Course
+
+	// statuses are the statuses of the payments created, by id.
+	mu       sync.Mutex
+	statuses map[string]string
+}
+
+// setStatus changes the status of the payment, as the user would
+// by paying or leaving the checkout.
+func (m *mockMollie) setStatus(id string, status string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses[id] = status
+}
+
+func (m *mockMollie) handle() http.Handler {
+	authorized := func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer random-mollie-key"
+	}
+
+	create := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			web.Respond(context.Background(), w, nil, 401)
+			return
+		}
+
+		var in struct {
+			Amount map[string]string `json:"amount"`
+			Method []string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			web.Respond(context.Background(), w, err, 400)
+			return
+		}
+
+		exp := int64(0)
+		for _, c := range m.expectedCart {
+			exp += c.Price.Units
+		}
+
+		// Mollie amounts are expressed in major units.
+		if in.Amount["value"] != money.New(exp, "USD").Decimal() || len(in.Method) == 0 {
+			web.Respond(context.Background(), w, nil, 422)
+			return
+		}
+
+		m.mu.Lock()
+		id := fmt.Sprintf("tr_%d", len(m.statuses)+1)
+		m.statuses[id] = "open"
+		m.mu.Unlock()
+
+		p := map[string]any{
+			"id":     id,
+			"status": "open",
+			"amount": in.Amount,
+			"_links": map[string]any{"checkout": map[string]string{"href": "https://www.mollie.com/checkout/" + id}},
+		}
+		web.Respond(context.Background(), w, p, 201)
+	})
+
+	show := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			web.Respond(context.Background(), w, nil, 401)
+			return
+		}
+
+		id := mux.Vars(r)["id"]
+
+		m.mu.Lock()
+		status, ok := m.statuses[id]
+		m.mu.Unlock()
+		if !ok {
+			web.Respond(context.Background(), w, map[string]any{"status": 404, "title": "Not Found"}, 404)
+			return
+		}
+
+		web.Respond(context.Background(), w, map[string]any{"id": id, "status": status, "isCancelable": status == "open"}, 200)
+	})
+
+	r := mux.NewRouter()
+	r.Handle("/v2/payments", create).Methods("POST")
+	r.Handle("/v2/payments/{id}", show).Methods("GET")
 	return r
 }
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/video"
+)
+
+type prerequisiteTest struct {
+	*TestEnv
+}
+
+func TestPrerequisite(t *testing.T) {
+	env, err := NewTestEnv(t, "prerequisite_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	pt := &prerequisiteTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+
+	basics := ct.createCourseOK(t)
+	advanced := ct.createCourseOK(t)
+	first := vt.createVideoOK(t, basics.ID, 1)
+	next := vt.createVideoOK(t, advanced.ID, 1)
+
+	if err := Login(pt.Server, pt.AdminEmail, pt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	pt.setPrerequisites(t, advanced.ID, []string{basics.ID}, http.StatusOK)
+	pt.setPrerequisites(t, basics.ID, []string{advanced.ID}, http.StatusUnprocessableEntity)
+	pt.setPrerequisites(t, basics.ID, []string{basics.ID}, http.StatusUnprocessableEntity)
+	Logout(pt.Server)
+
+	if err := Login(pt.Server, pt.UserEmail, pt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(pt.Server)
+
+	if c := pt.show(t, advanced.ID); !c.Locked {
+		t.Fatalf("expected course[%s] locked before completing its prerequisites", advanced.ID)
+	}
+	if c := pt.show(t, basics.ID); c.Locked {
+		t.Fatalf("expected course[%s] without prerequisites unlocked", basics.ID)
+	}
+	if code := pt.showFull(t, next.ID); code != http.StatusForbidden {
+		t.Fatalf("playing a locked video: expected 403, got %d", code)
+	}
+
+	vt.updateProgress(t, first, video.ProgressUp{Progress: 100})
+
+	if c := pt.show(t, advanced.ID); c.Locked {
+		t.Fatalf("expected course[%s] unlocked after completing its prerequisites", advanced.ID)
+	}
+	if code := pt.showFull(t, next.ID); code != http.StatusOK {
+		t.Fatalf("playing an unlocked video: expected 200, got %d", code)
+	}
+}
+
+func (pt *prerequisiteTest) setPrerequisites(t *testing.T, courseID string, ids []string, want int) {
+	body, err := json.Marshal(course.PrerequisitesUp{IDs: ids})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, pt.URL+"/courses/"+courseID+"/prerequisites", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != want {
+		t.Fatalf("setting prerequisites %v of course[%s]: expected %d, got %s", ids, courseID, want, w.Status)
+	}
+}
+
+func (pt *prerequisiteTest) show(t *testing.T, courseID string) course.Course {
+	r, err := http.NewRequest(http.MethodGet, pt.URL+"/courses/"+courseID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("showing course[%s]: expected 200, got %s", courseID, w.Status)
+	}
+
+	var c course.Course
+	if err := json.NewDecoder(w.Body).Decode(&c); err != nil {
+		t.Fatalf("cannot unmarshal course: %v", err)
+	}
+
+	return c
+}
+
+func (pt *prerequisiteTest) showFull(t *testing.T, videoID string) int {
+	r, err := http.NewRequest(http.MethodGet, pt.URL+"/videos/"+videoID+"/full", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+type publishTest struct {
+	*TestEnv
+}
+
+func TestPublish(t *testing.T) {
+	env, err := NewTestEnv(t, "publish_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	pt := &publishTest{env}
+	vt := &videoTest{env}
+	gt := &guestTest{env}
+
+	if err := Login(pt.Server, pt.AdminEmail, pt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	crs := pt.createDraftOK(t, money.New(1000, "USD"))
+	if code := pt.show(t, crs.ID); code != http.StatusOK {
+		t.Fatalf("showing a draft to administrators: expected 200, got %d", code)
+	}
+	pt.setStatus(t, crs.ID, course.StatusPublished, http.StatusUnprocessableEntity)
+
+	Logout(pt.Server)
+
+	if code := pt.show(t, crs.ID); code != http.StatusNotFound {
+		t.Fatalf("showing a draft to visitors: expected 404, got %d", code)
+	}
+
+	w := gt.checkout(t, "guest@test.com", crs.ID)
+	w.Body.Close()
+	if w.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("guest checkout of a draft: expected 422, got %s", w.Status)
+	}
+
+	vt.createVideoOK(t, crs.ID, 1)
+
+	if err := Login(pt.Server, pt.AdminEmail, pt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	pt.setStatus(t, crs.ID, "deleted", http.StatusUnprocessableEntity)
+	pt.setStatus(t, crs.ID, course.StatusPublished, http.StatusOK)
+	Logout(pt.Server)
+
+	if code := pt.show(t, crs.ID); code != http.StatusOK {
+		t.Fatalf("showing a published course to visitors: expected 200, got %d", code)
+	}
+
+	// Free courses can be published too.
+	if err := Login(pt.Server, pt.AdminEmail, pt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	free := pt.createDraftOK(t, money.New(0, "USD"))
+	Logout(pt.Server)
+
+	vt.createVideoOK(t, free.ID, 1)
+
+	if err := Login(pt.Server, pt.AdminEmail, pt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	pt.setStatus(t, free.ID, course.StatusPublished, http.StatusOK)
+	Logout(pt.Server)
+}
+
+func (pt *publishTest) createDraftOK(t *testing.T, price money.Amount) course.Course {
+	body, err := json.Marshal(course.CourseNew{
+		Name:        "Draft",
+		Description: "This is a test course",
+		Price:       price,
+		ImageURL:    "/images/test.png",
+		Status:      course.StatusDraft,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, pt.URL+"/courses", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create draft: status code %s", w.Status)
+	}
+
+	var got course.Course
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal created course: %v", err)
+	}
+
+	if got.Status != course.StatusDraft {
+		t.Fatalf("expected a draft, got status %q", got.Status)
+	}
+
+	return got
+}
+
+func (pt *publishTest) setStatus(t *testing.T, courseID string, status string, want int) {
+	body := strings.NewReader(`{"status": "` + status + `"}`)
+
+	r, err := http.NewRequest(http.MethodPut, pt.URL+"/courses/"+courseID+"/status", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != want {
+		t.Fatalf("setting status %q of course[%s]: expected %d, got %s", status, courseID, want, w.Status)
+	}
+}
+
+func (pt *publishTest) show(t *testing.T, courseID string) int {
+	r, err := http.NewRequest(http.MethodGet, pt.URL+"/courses/"+courseID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
@@ -0,0 +1,232 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/question"
+)
+
+type questionTest struct {
+	*TestEnv
+}
+
+func TestQuestion(t *testing.T) {
+	env, err := NewTestEnv(t, "question_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	qt := &questionTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+	rt := &cartTest{env}
+	ft := &freeTest{env}
+
+	crs := ct.createCourseOK(t)
+	other := ct.createCourseOK(t)
+	v := vt.createVideoOK(t, crs.ID, 1)
+	ov := vt.createVideoOK(t, other.ID, 1)
+
+	// The user owns the first course only.
+	rt.createItemOK(t, crs.ID)
+	ft.createFreeCouponOK(t)
+	if w := ft.enroll(t, "FREE100"); w != http.StatusOK {
+		t.Fatalf("enrolling in course: expected 200, got %d", w)
+	}
+
+	if err := Login(qt.Server, qt.UserEmail, qt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, _ := qt.ask(t, ov.ID, question.QuestionNew{Title: "Why?", Body: "Why is it so?"}); code != http.StatusForbidden {
+		t.Fatalf("asking on a course not owned: expected 403, got %d", code)
+	}
+
+	if code, _ := qt.ask(t, v.ID, question.QuestionNew{Body: "Why is it so?"}); code != http.StatusUnprocessableEntity {
+		t.Fatalf("asking without title: expected 422, got %d", code)
+	}
+
+	code, qu := qt.ask(t, v.ID, question.QuestionNew{Title: "Why?", Body: "Why is it so?"})
+	if code != http.StatusCreated || qu.CourseID != crs.ID || qu.UserName == "" || qu.Resolved {
+		t.Fatalf("asking: expected 201, got %d with %+v", code, qu)
+	}
+
+	if qs := qt.listOK(t, v.ID); len(qs) != 1 || qs[0].ID != qu.ID {
+		t.Fatalf("expected the question asked, got %+v", qs)
+	}
+
+	if code, _ := qt.reply(t, qu.ID, "Because."); code != http.StatusUnauthorized {
+		t.Fatalf("replying as user: expected 401, got %d", code)
+	}
+
+	Logout(qt.Server)
+
+	if err := Login(qt.Server, qt.AdminEmail, qt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	code, rep := qt.reply(t, qu.ID, "Because")
+	if code != http.StatusCreated || rep.QuestionID != qu.ID {
+		t.Fatalf("replying: expected 201, got %d with %+v", code, rep)
+	}
+
+	if code := qt.editReply(t, rep.ID, "Because it is."); code != http.StatusOK {
+		t.Fatalf("editing reply: expected 200, got %d", code)
+	}
+
+	Logout(qt.Server)
+
+	if err := Login(qt.Server, qt.UserEmail, qt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(qt.Server)
+
+	if rs := qt.listRepliesOK(t, qu.ID); len(rs) != 1 || rs[0].Body != "Because it is." {
+		t.Fatalf("expected the reply edited, got %+v", rs)
+	}
+
+	if code := qt.editReply(t, rep.ID, "Because I say so."); code != http.StatusUnauthorized {
+		t.Fatalf("editing reply as user: expected 401, got %d", code)
+	}
+
+	resolved := true
+	code, qu = qt.update(t, qu.ID, question.QuestionUp{Resolved: &resolved})
+	if code != http.StatusOK || !qu.Resolved || qu.Replies != 1 {
+		t.Fatalf("resolving question: expected 200, got %d with %+v", code, qu)
+	}
+}
+
+func (qt *questionTest) ask(t *testing.T, videoID string, qn question.QuestionNew) (int, question.Question) {
+	return qt.send(t, http.MethodPost, "/videos/"+videoID+"/questions", qn, http.StatusCreated)
+}
+
+func (qt *questionTest) update(t *testing.T, id string, qup question.QuestionUp) (int, question.Question) {
+	return qt.send(t, http.MethodPut, "/questions/"+id, qup, http.StatusOK)
+}
+
+// send sends the payload to the path and returns the status code with
+// the question returned when it's the expected one.
+func (qt *questionTest) send(t *testing.T, method string, path string, payload any, exp int) (int, question.Question) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(method, qt.URL+path, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := qt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got question.Question
+	if w.StatusCode == exp {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal question: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
+
+func (qt *questionTest) listOK(t *testing.T, videoID string) []question.Question {
+	r, err := http.NewRequest(http.MethodGet, qt.URL+"/videos/"+videoID+"/questions", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := qt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list questions: status code %s", w.Status)
+	}
+
+	var got []question.Question
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal questions: %v", err)
+	}
+
+	return got
+}
+
+func (qt *questionTest) reply(t *testing.T, questionID string, body string) (int, question.Reply) {
+	b, err := json.Marshal(question.ReplyUp{Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, qt.URL+"/questions/"+questionID+"/replies", bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := qt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got question.Reply
+	if w.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal reply: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
+
+func (qt *questionTest) editReply(t *testing.T, id string, body string) int {
+	b, err := json.Marshal(question.ReplyUp{Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, qt.URL+"/replies/"+id, bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := qt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (qt *questionTest) listRepliesOK(t *testing.T, questionID string) []question.Reply {
+	r, err := http.NewRequest(http.MethodGet, qt.URL+"/questions/"+questionID+"/replies", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := qt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list replies: status code %s", w.Status)
+	}
+
+	var got []question.Reply
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal replies: %v", err)
+	}
+
+	return got
+}
@@ -0,0 +1,200 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/quiz"
+)
+
+type quizTest struct {
+	*TestEnv
+}
+
+func TestQuiz(t *testing.T) {
+	env, err := NewTestEnv(t, "quiz_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	qt := &quizTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+	rt := &cartTest{env}
+	ft := &freeTest{env}
+
+	crs := ct.createCourseOK(t)
+	v := vt.createVideoOK(t, crs.ID, 1)
+
+	rt.createItemOK(t, crs.ID)
+	ft.createFreeCouponOK(t)
+	if w := ft.enroll(t, "FREE100"); w != http.StatusOK {
+		t.Fatalf("enrolling in course: expected 200, got %d", w)
+	}
+
+	if err := Login(qt.Server, qt.AdminEmail, qt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	qup := quiz.QuizUp{
+		Title:     "Basics",
+		PassScore: 50,
+		Questions: []quiz.QuestionUp{
+			{Text: "2 + 2?", Choices: []quiz.ChoiceUp{{Text: "3"}, {Text: "4", Correct: true}}},
+			{Text: "Even numbers?", Choices: []quiz.ChoiceUp{{Text: "1"}, {Text: "2", Correct: true}, {Text: "4", Correct: true}}},
+		},
+	}
+
+	wrong := quiz.QuizUp{
+		Title:     "Wrong",
+		PassScore: 50,
+		Questions: []quiz.QuestionUp{{Text: "2 + 2?", Choices: []quiz.ChoiceUp{{Text: "3"}, {Text: "5"}}}},
+	}
+	if code, _ := qt.put(t, v.ID, wrong); code != http.StatusUnprocessableEntity {
+		t.Fatalf("putting quiz without correct choices: expected 422, got %d", code)
+	}
+
+	code, qz := qt.put(t, v.ID, qup)
+	if code != http.StatusOK || len(qz.Questions) != 2 || !qz.Questions[0].Choices[1].Correct {
+		t.Fatalf("putting quiz: expected 200, got %d with %+v", code, qz)
+	}
+
+	Logout(qt.Server)
+
+	if err := Login(qt.Server, qt.UserEmail, qt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(qt.Server)
+
+	code, qz = qt.show(t, v.ID)
+	if code != http.StatusOK || qz.Questions[0].Choices[1].Correct {
+		t.Fatalf("showing quiz: expected 200 without correct choices, got %d with %+v", code, qz)
+	}
+
+	q1, q2 := qz.Questions[0], qz.Questions[1]
+
+	// Only some of the correct choices of the second question are selected.
+	an := quiz.AttemptNew{Answers: []quiz.Answer{
+		{QuestionID: q1.ID, ChoiceIDs: []string{q1.Choices[0].ID}},
+		{QuestionID: q2.ID, ChoiceIDs: []string{q2.Choices[1].ID}},
+	}}
+	code, a := qt.attempt(t, v.ID, an)
+	if code != http.StatusCreated || a.Correct != 0 || a.Score != 0 || a.Passed {
+		t.Fatalf("failing quiz: expected 201 with score 0, got %d with %+v", code, a)
+	}
+
+	an = quiz.AttemptNew{Answers: []quiz.Answer{
+		{QuestionID: q1.ID, ChoiceIDs: []string{q2.Choices[0].ID}},
+	}}
+	if code, _ := qt.attempt(t, v.ID, an); code != http.StatusUnprocessableEntity {
+		t.Fatalf("answering with choices of another question: expected 422, got %d", code)
+	}
+
+	an = quiz.AttemptNew{Answers: []quiz.Answer{
+		{QuestionID: q1.ID, ChoiceIDs: []string{q1.Choices[1].ID}},
+		{QuestionID: q2.ID, ChoiceIDs: []string{q2.Choices[2].ID, q2.Choices[1].ID}},
+	}}
+	code, a = qt.attempt(t, v.ID, an)
+	if code != http.StatusCreated || a.Correct != 2 || a.Score != 100 || !a.Passed {
+		t.Fatalf("passing quiz: expected 201 with score 100, got %d with %+v", code, a)
+	}
+
+	if as := qt.listAttemptsOK(t, v.ID); len(as) != 2 || as[0].ID != a.ID {
+		t.Fatalf("expected the 2 attempts, most recent first, got %+v", as)
+	}
+}
+
+func (qt *quizTest) put(t *testing.T, videoID string, qup quiz.QuizUp) (int, quiz.Quiz) {
+	body, err := json.Marshal(qup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, qt.URL+"/videos/"+videoID+"/quiz", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return qt.doQuiz(t, r)
+}
+
+func (qt *quizTest) show(t *testing.T, videoID string) (int, quiz.Quiz) {
+	r, err := http.NewRequest(http.MethodGet, qt.URL+"/videos/"+videoID+"/quiz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return qt.doQuiz(t, r)
+}
+
+// doQuiz sends the request and returns the status code with the quiz
+// returned on success.
+func (qt *quizTest) doQuiz(t *testing.T, r *http.Request) (int, quiz.Quiz) {
+	w, err := qt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got quiz.Quiz
+	if w.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal quiz: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
+
+func (qt *quizTest) attempt(t *testing.T, videoID string, an quiz.AttemptNew) (int, quiz.Attempt) {
+	body, err := json.Marshal(an)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, qt.URL+"/videos/"+videoID+"/quiz/attempts", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := qt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got quiz.Attempt
+	if w.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal attempt: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
+
+func (qt *quizTest) listAttemptsOK(t *testing.T, videoID string) []quiz.Attempt {
+	r, err := http.NewRequest(http.MethodGet, qt.URL+"/videos/"+videoID+"/quiz/attempts", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := qt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list attempts: status code %s", w.Status)
+	}
+
+	var got []quiz.Attempt
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal attempts: %v", err)
+	}
+
+	return got
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+type razorpayTest struct {
+	*TestEnv
+}
+
+func TestRazorpay(t *testing.T) {
+	env, err := NewTestEnv(t, "razorpay_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	zt := &razorpayTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+
+	// Complete the first order via webhook.
+	rt.createItemOK(t, c1.ID)
+	zt.Razorpay.expectedCart = []course.Course{c1}
+	orderID := zt.checkoutOK(t)
+	zt.webhookUnsigned(t, orderID)
+	zt.webhookOK(t, orderID)
+	ct.listCoursesOwnedOK(t, []course.Course{c1})
+
+	// Capture the second one from the client.
+	rt.createItemOK(t, c2.ID)
+	zt.Razorpay.expectedCart = []course.Course{c2}
+	orderID = zt.checkoutOK(t)
+	zt.captureOK(t, orderID)
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2})
+
+	// The webhook of the order captured already, delivered even twice,
+	// doesn't fulfill it again.
+	zt.webhookOK(t, orderID)
+	zt.webhookOK(t, orderID)
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2})
+}
+
+// checkoutOK starts a razorpay checkout and returns the razorpay order id.
+func (zt *razorpayTest) checkoutOK(t *testing.T) string {
+	if err := Login(zt.Server, zt.UserEmail, zt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(zt.Server)
+
+	r, err := http.NewRequest(http.MethodPost, zt.URL+"/orders/razorpay", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := zt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't create razorpay order: status code %s", w.Status)
+	}
+
+	var got struct {
+		Key     string `json:"key"`
+		OrderID string `json:"orderId"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal razorpay checkout: %v", err)
+	}
+
+	if got.Key != "rzp_test" || got.OrderID == "" {
+		t.Fatalf("unexpected razorpay checkout: %+v", got)
+	}
+
+	return got.OrderID
+}
+
+func (zt *razorpayTest) captureOK(t *testing.T, orderID string) {
+	if err := Login(zt.Server, zt.UserEmail, zt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(zt.Server)
+
+	r, err := http.NewRequest(http.MethodPost, zt.URL+"/orders/razorpay/"+orderID+"/capture", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := zt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Fatalf("can't capture razorpay order: status code %s", w.Status)
+	}
+}
+
+// webhook triggers a razorpay order.paid event, signed with the passed secret.
+func (zt *razorpayTest) webhook(t *testing.T, orderID string, secret string) *http.Response {
+	evt := map[string]any{
+		"event": "order.paid",
+		"payload": map[string]any{
+			"payment": map[string]any{
+				"entity": map[string]any{"id": "pay_" + orderID, "order_id": orderID, "status": "captured"},
+			},
+		},
+	}
+
+	b, err := json.Marshal(evt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(b)
+
+	r, err := http.NewRequest(http.MethodPost, zt.URL+"/orders/razorpay/webhook", bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("X-Razorpay-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	w, err := zt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+func (zt *razorpayTest) webhookOK(t *testing.T, orderID string) {
+	w := zt.webhook(t, orderID, zt.RazorpaySecret)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Fatalf("can't trigger razorpay webhook: status code %s", w.Status)
+	}
+}
+
+func (zt *razorpayTest) webhookUnsigned(t *testing.T, orderID string) {
+	w := zt.webhook(t, orderID, "wrong-secret")
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusBadRequest {
+		t.Fatalf("razorpay webhooks with a wrong signature should fail: status code %s", w.Status)
+	}
+}
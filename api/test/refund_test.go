@@ -0,0 +1,402 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/order"
+)
+
+type refundTest struct {
+	*TestEnv
+}
+
+func TestRefundItem(t *testing.T) {
+	env, err := NewTestEnv(t, "refund_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &refundTest{env}
+	wt := &walletTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+	rt.createItemOK(t, c1.ID)
+	rt.createItemOK(t, c2.ID)
+
+	// Orders payed with credit are refunded to the wallet.
+	wt.grantOK(t, 100000)
+	wt.payWithCreditOK(t)
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2})
+
+	ord := ft.lastOrderOK(t)
+
+	// Only the access to the refunded course is revoked.
+	ft.refundItemOK(t, ord.ID, c1.ID)
+	ct.listCoursesOwnedOK(t, []course.Course{c2})
+	wt.showBalanceOK(t, 100000-c2.Price.Units)
+
+	if code := ft.refundItem(t, ord.ID, c1.ID); code != http.StatusConflict {
+		t.Fatalf("refunding an item twice should conflict, got status code %d", code)
+	}
+
+	if ord = ft.lastOrderOK(t); ord.Status != order.Success {
+		t.Fatalf("expected the order to be still successful, got %s", ord.Status)
+	}
+
+	// Refunding the last item refunds the order.
+	ft.refundItemOK(t, ord.ID, c2.ID)
+	ct.listCoursesOwnedOK(t, []course.Course{})
+	wt.showBalanceOK(t, 100000)
+
+	if ord = ft.lastOrderOK(t); ord.Status != order.Refunded {
+		t.Fatalf("expected the order to be refunded, got %s", ord.Status)
+	}
+
+	for _, it := range ord.Items {
+		if it.RefundedAt == nil {
+			t.Fatalf("expected item[%s] to be refunded", it.CourseID)
+		}
+	}
+}
+
+func TestRefundGift(t *testing.T) {
+	env, err := NewTestEnv(t, "refund_gift_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &refundTest{env}
+	wt := &walletTest{env}
+	ct := &courseTest{env}
+
+	wt.grantOK(t, 100000)
+
+	// Refunding a redeemed gift revokes the access to the course.
+	c1 := ct.createCourseOK(t)
+	ft.giftWithCreditOK(t, c1.ID)
+	code := ft.giftCodeOK(t, 1)
+
+	ft.redeemOK(t, code)
+	ct.listCoursesOwnedOK(t, []course.Course{c1})
+
+	ord := ft.lastOrderOK(t)
+	ft.refundItemOK(t, ord.ID, c1.ID)
+	ct.listCoursesOwnedOK(t, []course.Course{})
+
+	// Gifts refunded before being redeemed can't be redeemed anymore.
+	c2 := ct.createCourseOK(t)
+	ft.giftWithCreditOK(t, c2.ID)
+	code = ft.giftCodeOK(t, 2)
+
+	ord = ft.lastOrderOK(t)
+	ft.refundItemOK(t, ord.ID, c2.ID)
+
+	if code := ft.redeem(t, code); code != http.StatusNotFound {
+		t.Fatalf("redeeming a refunded gift should fail, got status code %d", code)
+	}
+	ct.listCoursesOwnedOK(t, []course.Course{})
+}
+
+func TestRefundRequest(t *testing.T) {
+	env, err := NewTestEnv(t, "refund_request_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &refundTest{env}
+	wt := &walletTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+
+	wt.grantOK(t, 100000)
+
+	rt.createItemOK(t, c1.ID)
+	wt.payWithCreditOK(t)
+	denied := ft.lastOrderOK(t)
+
+	// Only the buyer can ask for a refund.
+	if code := ft.requestRefund(t, ft.AdminEmail, ft.AdminPass, denied.ID); code != http.StatusUnauthorized {
+		t.Fatalf("requesting the refund of the order of another user: expected 401, got %d", code)
+	}
+
+	rr := ft.requestRefundOK(t, denied.ID)
+
+	if code := ft.requestRefund(t, ft.UserEmail, ft.UserPass, denied.ID); code != http.StatusConflict {
+		t.Fatalf("requesting a refund twice: expected 409, got %d", code)
+	}
+
+	// Denied requests keep the access to the courses.
+	ft.decideOK(t, rr.ID, "deny", "not eligible")
+	ct.listCoursesOwnedOK(t, []course.Course{c1})
+
+	if code := ft.decide(t, rr.ID, "approve", ""); code != http.StatusConflict {
+		t.Fatalf("approving a denied request: expected 409, got %d", code)
+	}
+
+	// Approved requests refund the order and revoke the access.
+	rt.createItemOK(t, c2.ID)
+	wt.payWithCreditOK(t)
+	approved := ft.lastOrderOK(t)
+
+	rr = ft.requestRefundOK(t, approved.ID)
+
+	if got := ft.listRefundRequestsOK(t); len(got) != 1 || got[0].ID != rr.ID {
+		t.Fatalf("expected only request[%s] to be pending, got %+v", rr.ID, got)
+	}
+
+	ft.decideOK(t, rr.ID, "approve", "")
+	ct.listCoursesOwnedOK(t, []course.Course{c1})
+	wt.showBalanceOK(t, 100000-c1.Price.Units)
+
+	if ord := ft.lastOrderOK(t); ord.Status != order.Refunded {
+		t.Fatalf("expected the order to be refunded, got %s", ord.Status)
+	}
+
+	if got := ft.listRefundRequestsOK(t); len(got) != 0 {
+		t.Fatalf("expected no pending requests, got %d", len(got))
+	}
+}
+
+// lastOrderOK returns the last order placed by the test user.
+func (ft *refundTest) lastOrderOK(t *testing.T) order.Receipt {
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	r, err := http.NewRequest(http.MethodGet, ft.URL+"/orders/mine?limit=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list orders: status code %s", w.Status)
+	}
+
+	var got []order.Receipt
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal orders: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected an order, got %d", len(got))
+	}
+
+	return got[0]
+}
+
+// giftWithCreditOK gifts the course to someone else paying with the
+// credit in the wallet of the test user.
+func (ft *refundTest) giftWithCreditOK(t *testing.T, courseID string) {
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	r, err := http.NewRequest(http.MethodPost, ft.URL+"/orders/stripe?wallet=true&gift="+courseID+"&to=friend@tutorialspoint.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't gift course[%s]: status code %s", courseID, w.Status)
+	}
+}
+
+// giftCodeOK waits for the n-th gift to be sent and returns its code.
+func (ft *refundTest) giftCodeOK(t *testing.T, n int) string {
+	for i := 0; i < 200; i++ {
+		if codes := ft.Mailer.giftCodes(); len(codes) >= n {
+			return codes[n-1]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("gift %d was never sent", n)
+	return ""
+}
+
+// redeem redeems the gift as the test user and returns the status code.
+func (ft *refundTest) redeem(t *testing.T, code string) int {
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	r, err := http.NewRequest(http.MethodPost, ft.URL+"/gifts/"+code+"/redeem", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (ft *refundTest) redeemOK(t *testing.T, code string) {
+	if code := ft.redeem(t, code); code != http.StatusOK {
+		t.Fatalf("can't redeem gift: status code %d", code)
+	}
+}
+
+// refundItem refunds an item of an order to the wallet as administrator
+// and returns the status code.
+func (ft *refundTest) refundItem(t *testing.T, orderID string, courseID string) int {
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	r, err := http.NewRequest(http.MethodPost, ft.URL+"/orders/"+orderID+"/items/"+courseID+"/refund?to=wallet", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (ft *refundTest) refundItemOK(t *testing.T, orderID string, courseID string) {
+	if code := ft.refundItem(t, orderID, courseID); code != http.StatusNoContent {
+		t.Fatalf("can't refund item[%s] of order[%s]: status code %d", courseID, orderID, code)
+	}
+}
+
+// requestRefund asks for the refund of the order as the passed user and
+// returns the status code.
+func (ft *refundTest) requestRefund(t *testing.T, email string, pass string, orderID string) int {
+	if err := Login(ft.Server, email, pass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	body, err := json.Marshal(order.RefundRequestNew{Reason: "changed my mind"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, ft.URL+"/orders/"+orderID+"/refund-requests", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+// requestRefundOK asks for the refund of the order as the test user and
+// returns the pending request.
+func (ft *refundTest) requestRefundOK(t *testing.T, orderID string) order.RefundRequest {
+	if code := ft.requestRefund(t, ft.UserEmail, ft.UserPass, orderID); code != http.StatusCreated {
+		t.Fatalf("can't request the refund of order[%s]: status code %d", orderID, code)
+	}
+
+	for _, rr := range ft.listRefundRequestsOK(t) {
+		if rr.OrderID == orderID {
+			return rr
+		}
+	}
+
+	t.Fatalf("refund request of order[%s] not found", orderID)
+	return order.RefundRequest{}
+}
+
+// listRefundRequestsOK returns the pending refund requests.
+func (ft *refundTest) listRefundRequestsOK(t *testing.T) []order.RefundRequest {
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	r, err := http.NewRequest(http.MethodGet, ft.URL+"/refund-requests", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list refund requests: status code %s", w.Status)
+	}
+
+	var got []order.RefundRequest
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal refund requests: %v", err)
+	}
+
+	return got
+}
+
+// decide approves or denies, depending on the action, the refund request
+// as administrator and returns the status code.
+func (ft *refundTest) decide(t *testing.T, requestID string, action string, note string) int {
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ft.Server)
+
+	body, err := json.Marshal(order.RefundDecision{Note: note})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, ft.URL+"/refund-requests/"+requestID+"/"+action, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ft.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (ft *refundTest) decideOK(t *testing.T, requestID string, action string, note string) {
+	if code := ft.decide(t, requestID, action, note); code != http.StatusOK {
+		t.Fatalf("can't %s refund request[%s]: status code %d", action, requestID, code)
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+type regionTest struct {
+	*TestEnv
+}
+
+func TestRegion(t *testing.T) {
+	env, err := NewTestEnv(t, "region_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	pt := &regionTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+	ot := &orderTest{env}
+
+	c := ct.createCourseOK(t)
+
+	// Regional prices must be in the currency of the course.
+	if code := pt.setPrice(t, c.ID, "in", money.New(100, "EUR")); code != http.StatusUnprocessableEntity {
+		t.Fatalf("setting a regional price in another currency: expected 422, got %d", code)
+	}
+	if code := pt.setPrice(t, c.ID, "in", money.New(100, "USD")); code != http.StatusOK {
+		t.Fatalf("can't set regional price: status code %d", code)
+	}
+
+	// Users of the region are charged the regional price.
+	rt.createItemOK(t, c.ID)
+	pt.setCountryOK(t, "in")
+
+	local := c
+	local.Price = money.New(100, "USD")
+	ot.Stripe.expectedCart = []course.Course{local}
+	ot.stripeCheckout(t)
+
+	// The others the price of the course.
+	pt.setCountryOK(t, "it")
+	ot.Stripe.expectedCart = []course.Course{c}
+	ot.stripeCheckout(t)
+}
+
+// setPrice sets the price of a course in a region and returns the
+// status code of the response.
+func (pt *regionTest) setPrice(t *testing.T, courseID string, region string, price money.Amount) int {
+	if err := Login(pt.Server, pt.AdminEmail, pt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(pt.Server)
+
+	body, err := json.Marshal(course.RegionalPriceNew{Price: price})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, pt.URL+"/courses/"+courseID+"/regions/"+region, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+// setCountryOK sets the country of the user.
+func (pt *regionTest) setCountryOK(t *testing.T, country string) {
+	if err := Login(pt.Server, pt.UserEmail, pt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(pt.Server)
+
+	body, err := json.Marshal(map[string]string{"country": country})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, pt.URL+"/users/current/country", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := pt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't set country: status code %s", w.Status)
+	}
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/category"
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+func TestRelated(t *testing.T) {
+	env, err := NewTestEnv(t, "related_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ct := &courseTest{env}
+	gt := &categoryTest{env}
+	cpt := &completionTest{env}
+	rt := &cartTest{env}
+	ft := &freeTest{env}
+
+	src := ct.createCourseOK(t)
+	sameCategory := ct.createCourseOK(t)
+	sameTopics := ct.createCourseOK(t)
+	bought := ct.createCourseOK(t)
+	ct.createCourseOK(t)
+
+	rt.createItemOK(t, src.ID)
+	rt.createItemOK(t, bought.ID)
+	ft.createFreeCouponOK(t)
+	if code := ft.enroll(t, "FREE100"); code != http.StatusOK {
+		t.Fatalf("enrolling in courses: expected 200, got %d", code)
+	}
+
+	if err := Login(gt.Server, gt.AdminEmail, gt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	var prog category.Category
+	gt.create(t, "/categories", category.TopicNew{Name: "Programming", Slug: "programming"}, &prog)
+	var golang, unit category.Tag
+	gt.create(t, "/tags", category.TopicNew{Name: "Go", Slug: "golang"}, &golang)
+	gt.create(t, "/tags", category.TopicNew{Name: "Unit testing", Slug: "unit-testing"}, &unit)
+
+	for _, id := range []string{src.ID, sameCategory.ID, sameTopics.ID} {
+		gt.set(t, id, "categories", []string{prog.ID}, http.StatusOK)
+	}
+	gt.set(t, src.ID, "tags", []string{golang.ID, unit.ID}, http.StatusOK)
+	gt.set(t, sameTopics.ID, "tags", []string{golang.ID, unit.ID}, http.StatusOK)
+
+	Logout(gt.Server)
+
+	// Shared topics weigh 1 each, students who bought both 2 each.
+	var got []course.Course
+	if code := cpt.get(t, "/courses/"+src.ID+"/related", &got); code != http.StatusOK {
+		t.Fatalf("listing related courses: expected 200, got %d", code)
+	}
+	if len(got) != 3 || got[0].ID != sameTopics.ID || got[1].ID != bought.ID || got[2].ID != sameCategory.ID {
+		t.Fatalf("expected courses [%s %s %s], got %+v", sameTopics.ID, bought.ID, sameCategory.ID, got)
+	}
+}
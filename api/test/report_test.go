@@ -0,0 +1,117 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/order"
+)
+
+type reportTest struct {
+	*TestEnv
+}
+
+func TestReport(t *testing.T) {
+	env, err := NewTestEnv(t, "report_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	rpt := &reportTest{env}
+	ft := &refundTest{env}
+	wt := &walletTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	// Nothing has been sold yet.
+	if got := rpt.revenueOK(t, order.ByDay); len(got) != 0 {
+		t.Fatalf("expected no revenue, got %+v", got)
+	}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+	rt.createItemOK(t, c1.ID)
+	rt.createItemOK(t, c2.ID)
+
+	wt.grantOK(t, 100000)
+	wt.payWithCreditOK(t)
+
+	ord := ft.lastOrderOK(t)
+	ft.refundItemOK(t, ord.ID, c1.ID)
+
+	// Refunds are taken off the revenue of the course refunded only.
+	byCourse := rpt.revenueOK(t, order.ByCourse)
+	if len(byCourse) != 2 {
+		t.Fatalf("expected the revenue of 2 courses, got %d", len(byCourse))
+	}
+
+	exp := map[string]struct{ gross, refunds int64 }{
+		c1.ID: {c1.Price.Units, c1.Price.Units},
+		c2.ID: {c2.Price.Units, 0},
+	}
+	for _, r := range byCourse {
+		e, ok := exp[r.Key]
+		if !ok || r.Orders != 1 || r.Gross.Units != e.gross || r.Refunds.Units != e.refunds || r.Net.Units != e.gross-e.refunds {
+			t.Fatalf("unexpected revenue of course[%s]: %+v", r.Key, r)
+		}
+	}
+
+	// Everything happened today.
+	byDay := rpt.revenueOK(t, order.ByDay)
+	today := time.Now().UTC().Format(time.DateOnly)
+	if len(byDay) != 1 || byDay[0].Key != today || byDay[0].Orders != 1 ||
+		byDay[0].Net.Units != c2.Price.Units {
+		t.Fatalf("unexpected revenue by day: %+v", byDay)
+	}
+
+	if err := Login(rpt.Server, rpt.AdminEmail, rpt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(rpt.Server)
+
+	w := rpt.revenue(t, "year")
+	defer w.Body.Close()
+	if w.StatusCode != http.StatusBadRequest {
+		t.Fatalf("grouping by an unknown criterion: expected 400, got %s", w.Status)
+	}
+}
+
+// revenue asks for the revenue grouped by the passed criterion, once
+// logged in as administrator.
+func (rpt *reportTest) revenue(t *testing.T, by string) *http.Response {
+	r, err := http.NewRequest(http.MethodGet, rpt.URL+"/reports/revenue?by="+by, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := rpt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+// revenueOK returns the revenue grouped by the passed criterion.
+func (rpt *reportTest) revenueOK(t *testing.T, by string) []order.Revenue {
+	if err := Login(rpt.Server, rpt.AdminEmail, rpt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(rpt.Server)
+
+	w := rpt.revenue(t, by)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't report revenue: status code %s", w.Status)
+	}
+
+	var got []order.Revenue
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal revenue: %v", err)
+	}
+
+	return got
+}
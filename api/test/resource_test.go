@@ -0,0 +1,195 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/resource"
+)
+
+type resourceTest struct {
+	*TestEnv
+}
+
+func TestResource(t *testing.T) {
+	env, err := NewTestEnv(t, "resource_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	rt := &resourceTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+
+	crs := ct.createCourseOK(t)
+	other := ct.createCourseOK(t)
+	v := vt.createVideoOK(t, other.ID, 1)
+
+	if err := Login(rt.Server, rt.AdminEmail, rt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, _ := rt.create(t, crs.ID, map[string]string{"name": "Slides", "videoId": v.ID}, "slides.pdf", "pdf"); code != http.StatusUnprocessableEntity {
+		t.Fatalf("attaching a resource to the video of another course: expected 422, got %d", code)
+	}
+
+	if code, _ := rt.create(t, crs.ID, map[string]string{"name": "Nothing"}, "", ""); code != http.StatusUnprocessableEntity {
+		t.Fatalf("attaching a resource without file nor url: expected 422, got %d", code)
+	}
+
+	code, file := rt.create(t, crs.ID, map[string]string{"name": "Slides"}, "slides.pdf", "pdf")
+	if code != http.StatusCreated || file.Kind != resource.KindFile || file.Filename != "slides.pdf" || file.Size != 3 {
+		t.Fatalf("attaching a file: expected 201, got %d with %+v", code, file)
+	}
+
+	code, link := rt.create(t, crs.ID, map[string]string{"name": "Docs", "url": "https://go.dev/doc"}, "", "")
+	if code != http.StatusCreated || link.Kind != resource.KindLink {
+		t.Fatalf("attaching a link: expected 201, got %d with %+v", code, link)
+	}
+
+	if rs := rt.listOK(t, crs.ID); len(rs) != 2 {
+		t.Fatalf("expected 2 resources, got %+v", rs)
+	}
+
+	code, dl := rt.download(t, file.ID)
+	if code != http.StatusOK || dl.URL != "https://cdn.example.com/resources/"+file.ID+"/slides.pdf" || dl.ExpiresAt == nil {
+		t.Fatalf("downloading file: expected 200, got %d with %+v", code, dl)
+	}
+
+	if code, dl := rt.download(t, link.ID); code != http.StatusOK || dl.URL != "https://go.dev/doc" {
+		t.Fatalf("downloading link: expected 200, got %d with %+v", code, dl)
+	}
+
+	Logout(rt.Server)
+
+	// Users need to own the course.
+	if err := Login(rt.Server, rt.UserEmail, rt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, _ := rt.download(t, file.ID); code != http.StatusForbidden {
+		t.Fatalf("downloading a resource of a course not owned: expected 403, got %d", code)
+	}
+
+	Logout(rt.Server)
+
+	if err := Login(rt.Server, rt.AdminEmail, rt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(rt.Server)
+
+	if got := rt.delete(t, file.ID); got != http.StatusNoContent {
+		t.Fatalf("deleting resource: expected 204, got %d", got)
+	}
+
+	if rs := rt.listOK(t, crs.ID); len(rs) != 1 || rs[0].ID != link.ID {
+		t.Fatalf("expected the link only, got %+v", rs)
+	}
+}
+
+func (rt *resourceTest) create(t *testing.T, courseID string, fields map[string]string, filename string, content string) (int, resource.Resource) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if filename != "" {
+		fw, err := mw.CreateFormFile("file", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, rt.URL+"/courses/"+courseID+"/resources", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	w, err := rt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got resource.Resource
+	if w.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal resource: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
+
+func (rt *resourceTest) listOK(t *testing.T, courseID string) []resource.Resource {
+	r, err := http.NewRequest(http.MethodGet, rt.URL+"/courses/"+courseID+"/resources", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := rt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list resources: status code %s", w.Status)
+	}
+
+	var got []resource.Resource
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal resources: %v", err)
+	}
+
+	return got
+}
+
+func (rt *resourceTest) download(t *testing.T, id string) (int, resource.Download) {
+	r, err := http.NewRequest(http.MethodGet, rt.URL+"/resources/"+id+"/download", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := rt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got resource.Download
+	if w.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal download: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
+
+func (rt *resourceTest) delete(t *testing.T, id string) int {
+	r, err := http.NewRequest(http.MethodDelete, rt.URL+"/resources/"+id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := rt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+)
+
+type revisionTest struct {
+	*TestEnv
+}
+
+func TestRevision(t *testing.T) {
+	env, err := NewTestEnv(t, "revision_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	rvt := &revisionTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+
+	crs := ct.createCourseOK(t)
+	v := vt.createVideoOK(t, crs.ID, 1)
+	updated := ct.updateCourseOK(t, crs)
+	vt.updateVideoOK(t, v)
+
+	if err := Login(rvt.Server, rvt.AdminEmail, rvt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(rvt.Server)
+
+	if code := rvt.rename(t, crs.ID, "Renamed Test"); code != http.StatusOK {
+		t.Fatalf("renaming course: expected 200, got %d", code)
+	}
+
+	revs := rvt.listOK(t, crs.ID)
+	if len(revs) != 3 || revs[0].VideoID != nil || revs[1].VideoID == nil || *revs[1].VideoID != v.ID || revs[2].VideoID != nil {
+		t.Fatalf("expected the rename, the video and the course edits, got %+v", revs)
+	}
+
+	var diff map[string]course.Change
+	if err := json.Unmarshal(revs[0].Diff, &diff); err != nil {
+		t.Fatalf("cannot unmarshal diff: %v", err)
+	}
+	if len(diff) != 1 || string(diff["name"].To) != `"Renamed Test"` || revs[0].EditorID == nil {
+		t.Fatalf("expected a diff of the name by the editor, got %+v", revs[0])
+	}
+
+	if code, _ := rvt.rollback(t, crs.ID, revs[1].ID); code != http.StatusUnprocessableEntity {
+		t.Fatalf("rolling back a video revision: expected 422, got %d", code)
+	}
+
+	code, got := rvt.rollback(t, crs.ID, revs[2].ID)
+	if code != http.StatusOK {
+		t.Fatalf("rolling back course: expected 200, got %d", code)
+	}
+	if got.Name != updated.Name || got.Price != updated.Price {
+		t.Fatalf("expected course %q, got %+v", updated.Name, got)
+	}
+
+	if revs := rvt.listOK(t, crs.ID); len(revs) != 4 {
+		t.Fatalf("expected the rollback to be recorded, got %d revisions", len(revs))
+	}
+}
+
+func (rvt *revisionTest) rename(t *testing.T, courseID string, name string) int {
+	body, err := json.Marshal(course.CourseUp{Name: ptr(name)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, rvt.URL+"/courses/"+courseID, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := rvt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (rvt *revisionTest) listOK(t *testing.T, courseID string) []course.Revision {
+	r, err := http.NewRequest(http.MethodGet, rvt.URL+"/courses/"+courseID+"/revisions", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := rvt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("listing revisions of course[%s]: expected 200, got %s", courseID, w.Status)
+	}
+
+	var revs []course.Revision
+	if err := json.NewDecoder(w.Body).Decode(&revs); err != nil {
+		t.Fatalf("cannot unmarshal revisions: %v", err)
+	}
+
+	return revs
+}
+
+func (rvt *revisionTest) rollback(t *testing.T, courseID string, revisionID string) (int, course.Course) {
+	path := "/courses/" + courseID + "/revisions/" + revisionID + "/rollback"
+	r, err := http.NewRequest(http.MethodPost, rvt.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := rvt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var c course.Course
+	if w.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&c); err != nil {
+			t.Fatalf("cannot unmarshal rolled back course: %v", err)
+		}
+	}
+
+	return w.StatusCode, c
+}
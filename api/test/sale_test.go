@@ -0,0 +1,94 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/sale"
+)
+
+type saleTest struct {
+	*TestEnv
+}
+
+func TestSale(t *testing.T) {
+	env, err := NewTestEnv(t, "sale_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	st := &saleTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+	ot := &orderTest{env}
+
+	c := ct.createCourseOK(t)
+
+	// Sales must end after they start.
+	now := time.Now().UTC().Truncate(time.Second)
+	ended := sale.SaleNew{
+		Name:      "Ended",
+		Percent:   50,
+		CourseIDs: []string{c.ID},
+		StartsAt:  now,
+		EndsAt:    now.Add(-time.Hour),
+	}
+	if code := st.create(t, ended); code != http.StatusUnprocessableEntity {
+		t.Fatalf("creating a sale ending before it starts: expected 422, got %d", code)
+	}
+
+	running := sale.SaleNew{
+		Name:      "Summer",
+		Percent:   50,
+		CourseIDs: []string{c.ID},
+		StartsAt:  now.Add(-time.Hour),
+		EndsAt:    now.Add(time.Hour),
+	}
+	if code := st.create(t, running); code != http.StatusCreated {
+		t.Fatalf("can't create sale: status code %d", code)
+	}
+
+	// Courses show the sale price next to the original one.
+	exp := c
+	exp.Sale = &course.Sale{Percent: 50, Price: c.Price, EndsAt: running.EndsAt}
+	exp.Sale.Price.Units -= c.Price.Units * 50 / 100
+	ct.showCourseOK(t, exp)
+
+	// Buyers are charged the sale price.
+	rt.createItemOK(t, c.ID)
+
+	discounted := c
+	discounted.Price = exp.Sale.Price
+	ot.Stripe.expectedCart = []course.Course{discounted}
+	ot.stripeCheckout(t)
+}
+
+// create creates a sale and returns the status code of the response.
+func (st *saleTest) create(t *testing.T, sn sale.SaleNew) int {
+	if err := Login(st.Server, st.AdminEmail, st.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(st.Server)
+
+	body, err := json.Marshal(sn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, st.URL+"/sales", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
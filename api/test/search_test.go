@@ -0,0 +1,120 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/search"
+	"github.com/jatolentino/tutorialspoint/core/video"
+)
+
+type searchTest struct {
+	*TestEnv
+}
+
+func TestSearch(t *testing.T) {
+	env, err := NewTestEnv(t, "search_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	st := &searchTest{env}
+	ct := &courseTest{env}
+
+	vt := &videoTest{env}
+
+	c1 := ct.createCourseOK(t)
+	st.searchOK(t, c1)
+	st.searchEmpty(t)
+	st.searchDraftVideo(t, vt.createVideoOK(t, c1.ID, 1))
+}
+
+func (st *searchTest) searchOK(t *testing.T, c course.Course) {
+	r, err := http.NewRequest(http.MethodGet, st.URL+"/search?q="+url.QueryEscape(c.Name), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't search: status code %s", w.Status)
+	}
+
+	var got []search.Result
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal search results: %v", err)
+	}
+
+	for _, res := range got {
+		if res.Kind == search.KindCourse && res.ID == c.ID {
+			return
+		}
+	}
+	t.Fatalf("course[%s] not found in results: %v", c.ID, got)
+}
+
+func (st *searchTest) searchEmpty(t *testing.T) {
+	r, err := http.NewRequest(http.MethodGet, st.URL+"/search", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusBadRequest {
+		t.Fatalf("search without query should fail: status code %s", w.Status)
+	}
+}
+
+// searchDraftVideo checks that videos are not found once unpublished.
+func (st *searchTest) searchDraftVideo(t *testing.T, v video.Video) {
+	if err := Login(st.Server, st.AdminEmail, st.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, st.URL+"/videos/"+v.ID, bytes.NewBufferString(`{"published": false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Body.Close()
+
+	Logout(st.Server)
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't unpublish video: status code %s", w.Status)
+	}
+
+	if w, err = st.Client().Get(st.URL + "/search?q=" + url.QueryEscape(v.Name)); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got []search.Result
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal search results: %v", err)
+	}
+
+	for _, res := range got {
+		if res.ID == v.ID {
+			t.Fatalf("draft video[%s] found in results: %v", v.ID, got)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/video"
+)
+
+type sectionTest struct {
+	*TestEnv
+}
+
+func TestSection(t *testing.T) {
+	env, err := NewTestEnv(t, "section_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	st := &sectionTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+
+	crs := ct.createCourseOK(t)
+	other := ct.createCourseOK(t)
+	intro := vt.createVideoOK(t, crs.ID, 1)
+	basics := vt.createVideoOK(t, crs.ID, 2)
+	extra := vt.createVideoOK(t, crs.ID, 3)
+
+	if err := Login(st.Server, st.AdminEmail, st.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(st.Server)
+
+	first := st.createSectionOK(t, crs.ID, video.SectionNew{Index: 1, Title: "Getting started"})
+	second := st.createSectionOK(t, crs.ID, video.SectionNew{Index: 2, Title: "Going further"})
+
+	if code, _ := st.createSection(t, crs.ID, video.SectionNew{Index: 2, Title: "Taken"}); code != http.StatusConflict {
+		t.Fatalf("creating a section at a taken index: expected 409, got %d", code)
+	}
+
+	st.moveVideo(t, basics.ID, first.ID, http.StatusOK)
+	st.moveVideo(t, intro.ID, first.ID, http.StatusOK)
+	st.moveVideo(t, extra.ID, second.ID, http.StatusOK)
+	st.moveVideo(t, extra.ID, "", http.StatusOK)
+
+	foreign := st.createSectionOK(t, other.ID, video.SectionNew{Index: 1, Title: "Elsewhere"})
+	st.moveVideo(t, extra.ID, foreign.ID, http.StatusUnprocessableEntity)
+
+	o := st.outlineOK(t, crs.ID)
+	if len(o.Sections) != 2 || o.Sections[0].ID != first.ID || o.Sections[1].ID != second.ID {
+		t.Fatalf("expected sections %s and %s, got %+v", first.ID, second.ID, o.Sections)
+	}
+	if vs := o.Sections[0].Videos; len(vs) != 2 || vs[0].ID != intro.ID || vs[1].ID != basics.ID {
+		t.Fatalf("expected videos %s and %s in the first section, got %+v", intro.ID, basics.ID, vs)
+	}
+	if len(o.Sections[1].Videos) != 0 {
+		t.Fatalf("expected no videos in the second section, got %+v", o.Sections[1].Videos)
+	}
+	if len(o.Videos) != 1 || o.Videos[0].ID != extra.ID {
+		t.Fatalf("expected video %s out of sections, got %+v", extra.ID, o.Videos)
+	}
+
+	r, err := http.NewRequest(http.MethodDelete, st.URL+"/sections/"+first.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Fatalf("deleting section: expected 204, got %s", w.Status)
+	}
+
+	if o := st.outlineOK(t, crs.ID); len(o.Sections) != 1 || len(o.Videos) != 3 {
+		t.Fatalf("expected the videos of the deleted section out of sections, got %+v", o)
+	}
+}
+
+func (st *sectionTest) createSectionOK(t *testing.T, courseID string, sn video.SectionNew) video.Section {
+	code, s := st.createSection(t, courseID, sn)
+	if code != http.StatusCreated {
+		t.Fatalf("can't create section: status code %d", code)
+	}
+
+	if s.CourseID != courseID || s.Index != sn.Index || s.Title != sn.Title {
+		t.Fatalf("expected section %+v of course[%s], got %+v", sn, courseID, s)
+	}
+
+	return s
+}
+
+func (st *sectionTest) createSection(t *testing.T, courseID string, sn video.SectionNew) (int, video.Section) {
+	body, err := json.Marshal(sn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, st.URL+"/courses/"+courseID+"/sections", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var s video.Section
+	if w.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+			t.Fatalf("cannot unmarshal created section: %v", err)
+		}
+	}
+
+	return w.StatusCode, s
+}
+
+func (st *sectionTest) moveVideo(t *testing.T, videoID string, sectionID string, want int) {
+	body, err := json.Marshal(video.VideoUp{SectionID: &sectionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, st.URL+"/videos/"+videoID, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != want {
+		t.Fatalf("moving video[%s] to section[%s]: expected %d, got %s", videoID, sectionID, want, w.Status)
+	}
+}
+
+func (st *sectionTest) outlineOK(t *testing.T, courseID string) video.Outline {
+	r, err := http.NewRequest(http.MethodGet, st.URL+"/courses/"+courseID+"/videos?group=section", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("listing videos by section: expected 200, got %s", w.Status)
+	}
+
+	var o video.Outline
+	if err := json.NewDecoder(w.Body).Decode(&o); err != nil {
+		t.Fatalf("cannot unmarshal outline: %v", err)
+	}
+
+	return o
+}
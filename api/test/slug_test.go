@@ -0,0 +1,64 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+func TestSlug(t *testing.T) {
+	env, err := NewTestEnv(t, "slug_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &faqTest{env}
+	cpt := &completionTest{env}
+
+	if err := Login(ft.Server, ft.AdminEmail, ft.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	cn := course.CourseNew{
+		Name:        "Go in Practice!",
+		Description: "Write production Go",
+		Price:       money.New(5000, "USD"),
+		ImageURL:    "/images/go.png",
+	}
+
+	var first, second course.Course
+	ft.do(t, http.MethodPost, "/courses", cn, &first, http.StatusCreated)
+	ft.do(t, http.MethodPost, "/courses", cn, &second, http.StatusCreated)
+	if first.Slug != "go-in-practice" || second.Slug != "go-in-practice-2" {
+		t.Fatalf("expected slugs go-in-practice and go-in-practice-2, got %s and %s", first.Slug, second.Slug)
+	}
+
+	// Slugs of static routes are never given to courses.
+	var featured course.Course
+	cn.Name = "Featured"
+	ft.do(t, http.MethodPost, "/courses", cn, &featured, http.StatusCreated)
+	if featured.Slug != "featured-2" {
+		t.Fatalf("expected slug featured-2, got %s", featured.Slug)
+	}
+
+	Logout(ft.Server)
+
+	var got course.Course
+	if code := cpt.get(t, "/courses/"+second.Slug, &got); code != http.StatusOK {
+		t.Fatalf("showing course by slug: expected 200, got %d", code)
+	}
+	if got.ID != second.ID {
+		t.Fatalf("expected course[%s], got course[%s]", second.ID, got.ID)
+	}
+
+	var related []course.Course
+	if code := cpt.get(t, "/courses/"+first.Slug+"/related", &related); code != http.StatusOK {
+		t.Fatalf("listing related courses by slug: expected 200, got %d", code)
+	}
+
+	if code := cpt.get(t, "/courses/go-in-theory", nil); code != http.StatusNotFound {
+		t.Fatalf("showing unknown slug: expected 404, got %d", code)
+	}
+}
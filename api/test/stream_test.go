@@ -0,0 +1,136 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/video"
+)
+
+type streamTest struct {
+	*TestEnv
+}
+
+func TestStream(t *testing.T) {
+	env, err := NewTestEnv(t, "stream_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	st := &streamTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+	ut := &uploadTest{env}
+
+	crs := ct.createCourseOK(t)
+	v := vt.createVideoOK(t, crs.ID, 1)
+	hosted := vt.createVideoOK(t, crs.ID, 2)
+
+	if err := Login(st.Server, st.AdminEmail, st.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	file := bytes.Repeat([]byte("video"), 100)
+	up := ut.createUploadOK(t, int64(len(file)))
+	if got := ut.sendChunk(t, up.ID, 0, file); got != http.StatusOK {
+		t.Fatalf("uploading video: expected 200, got %d", got)
+	}
+	up = ut.showUploadOK(t, up.ID)
+
+	st.updateOK(t, v.ID, video.VideoUp{URL: &up.URL})
+
+	Logout(st.Server)
+
+	if code, _ := st.stream(t, v.ID, "lesson.mp4", ""); code != http.StatusUnauthorized {
+		t.Fatalf("streaming without session: expected 401, got %d", code)
+	}
+
+	if err := Login(st.Server, st.UserEmail, st.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, body := st.stream(t, v.ID, "lesson.mp4", ""); code != http.StatusOK || body != string(file) {
+		t.Fatalf("streaming video: expected 200 with the file, got %d with %d bytes", code, len(body))
+	}
+
+	if code, body := st.stream(t, v.ID, "lesson.mp4", "bytes=2-6"); code != http.StatusPartialContent || body != "deovi" {
+		t.Fatalf("streaming a range: expected 206 with %q, got %d with %q", "deovi", code, body)
+	}
+
+	if code, _ := st.stream(t, v.ID, "missing.ts", ""); code != http.StatusNotFound {
+		t.Fatalf("streaming a missing file: expected 404, got %d", code)
+	}
+
+	if code, _ := st.stream(t, hosted.ID, "lesson.mp4", ""); code != http.StatusNotFound {
+		t.Fatalf("streaming a video not stored: expected 404, got %d", code)
+	}
+
+	Logout(st.Server)
+
+	// Ownership is checked on every request.
+	if err := Login(st.Server, st.AdminEmail, st.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	free := false
+	st.updateOK(t, v.ID, video.VideoUp{Free: &free})
+	Logout(st.Server)
+
+	if err := Login(st.Server, st.UserEmail, st.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(st.Server)
+
+	if code, _ := st.stream(t, v.ID, "lesson.mp4", "bytes=0-4"); code != http.StatusForbidden {
+		t.Fatalf("streaming a video of a course not owned: expected 403, got %d", code)
+	}
+}
+
+func (st *streamTest) updateOK(t *testing.T, id string, vup video.VideoUp) {
+	body, err := json.Marshal(&vup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, st.URL+"/videos/"+id, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't update video: status code %s", w.Status)
+	}
+}
+
+// stream requests the file of the video at path, in the range passed
+// when not empty, and returns the status code and the body.
+func (st *streamTest) stream(t *testing.T, videoID string, path string, rng string) (int, string) {
+	r, err := http.NewRequest(http.MethodGet, st.URL+"/stream/"+videoID+"/"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rng != "" {
+		r.Header.Set("Range", rng)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	b, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w.StatusCode, string(b)
+}
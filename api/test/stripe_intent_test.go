@@ -0,0 +1,141 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+type stripeIntentTest struct {
+	*TestEnv
+}
+
+func TestStripeIntent(t *testing.T) {
+	env, err := NewTestEnv(t, "stripe_intent_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	it := &stripeIntentTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+
+	// A canceled intent is not fulfilled.
+	rt.createItemOK(t, c1.ID)
+	it.Stripe.expectedCart = []course.Course{c1}
+	intentID := it.checkoutOK(t)
+	it.webhookOK(t, "payment_intent.canceled", intentID, "payment_element")
+	ct.listCoursesOwnedOK(t, []course.Course{})
+
+	// Intents of checkout sessions are left to the checkout webhook.
+	rt.createItemOK(t, c2.ID)
+	it.Stripe.expectedCart = []course.Course{c1, c2}
+	intentID = it.checkoutOK(t)
+	it.webhookOK(t, "payment_intent.succeeded", intentID, "")
+	ct.listCoursesOwnedOK(t, []course.Course{})
+
+	// A succeeded one is.
+	it.webhookOK(t, "payment_intent.succeeded", intentID, "payment_element")
+	ct.listCoursesOwnedOK(t, []course.Course{c1, c2})
+}
+
+// checkoutOK creates a payment intent and returns its id.
+func (it *stripeIntentTest) checkoutOK(t *testing.T) string {
+	if err := Login(it.Server, it.UserEmail, it.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(it.Server)
+
+	r, err := http.NewRequest(http.MethodPost, it.URL+"/orders/stripe/intent", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := it.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't create stripe payment intent: status code %s", w.Status)
+	}
+
+	var got struct {
+		ID           string `json:"id"`
+		ClientSecret string `json:"clientSecret"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal stripe payment intent: %v", err)
+	}
+
+	if got.ID == "" || got.ClientSecret == "" {
+		t.Fatalf("unexpected stripe payment intent: %+v", got)
+	}
+
+	return got.ID
+}
+
+// webhookOK triggers a signed stripe event of the passed type about the
+// payment intent created with the passed origin.
+func (it *stripeIntentTest) webhookOK(t *testing.T, typ string, intentID string, origin string) {
+	status := "succeeded"
+	if typ == "payment_intent.canceled" {
+		status = "canceled"
+	}
+
+	raw, err := json.Marshal(map[string]any{
+		"id":       intentID,
+		"object":   "payment_intent",
+		"status":   status,
+		"metadata": map[string]string{"origin": origin},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evt := stripe.Event{
+		// Required by stripe-go 74.2.0 .
+		APIVersion: "2022-11-15",
+		Type:       typ,
+		Data: &stripe.EventData{
+			Raw: json.RawMessage(raw),
+		},
+	}
+
+	b, err := json.Marshal(evt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload:   b,
+		Secret:    it.IntentWebhookSecret,
+		Timestamp: time.Now(),
+	})
+
+	r, err := http.NewRequest(http.MethodPost, it.URL+"/orders/stripe/intent/webhook", bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Stripe-Signature", signed.Header)
+
+	w, err := it.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Fatalf("can't trigger stripe webhook %s: status code %s", typ, w.Status)
+	}
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/subscription"
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+type subscriptionTest struct {
+	*TestEnv
+}
+
+func TestSubscription(t *testing.T) {
+	env, err := NewTestEnv(t, "subscription_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	st := &subscriptionTest{env}
+
+	st.checkoutOK(t)
+
+	st.billingWebhook(t, "invoice.paid", map[string]any{"id": "in_test", "subscription": "sub_test"})
+	st.showMineOK(t, subscription.StatusActive)
+	st.checkoutSubscribed(t)
+
+	st.billingWebhook(t, "customer.subscription.deleted", map[string]any{"id": "sub_test"})
+	st.showMineOK(t, subscription.StatusCanceled)
+}
+
+func (st *subscriptionTest) checkout(t *testing.T) *http.Response {
+	if err := Login(st.Server, st.UserEmail, st.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(st.Server)
+
+	r, err := http.NewRequest(http.MethodPost, st.URL+"/subscriptions/stripe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+func (st *subscriptionTest) checkoutOK(t *testing.T) {
+	w := st.checkout(t)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't subscribe: status code %s", w.Status)
+	}
+}
+
+func (st *subscriptionTest) checkoutSubscribed(t *testing.T) {
+	w := st.checkout(t)
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusConflict {
+		t.Fatalf("subscribing twice should fail: status code %s", w.Status)
+	}
+}
+
+func (st *subscriptionTest) showMineOK(t *testing.T, status string) {
+	if err := Login(st.Server, st.UserEmail, st.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(st.Server)
+
+	r, err := http.NewRequest(http.MethodGet, st.URL+"/subscriptions/mine", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't show subscriptions: status code %s", w.Status)
+	}
+
+	var got []subscription.Subscription
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal subscriptions: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Status != status {
+		t.Fatalf("expected a subscription %s, got %+v", status, got)
+	}
+}
+
+// billingWebhook triggers a signed stripe billing event of the passed
+// type about the passed object.
+func (st *subscriptionTest) billingWebhook(t *testing.T, typ string, obj map[string]any) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evt := stripe.Event{
+		// Required by stripe-go 74.2.0 .
+		APIVersion: "2022-11-15",
+		Type:       typ,
+		Data: &stripe.EventData{
+			Raw: json.RawMessage(raw),
+		},
+	}
+
+	b, err := json.Marshal(evt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload:   b,
+		Secret:    st.BillingWebhookSecret,
+		Timestamp: time.Now(),
+	})
+
+	r, err := http.NewRequest(http.MethodPost, st.URL+"/subscriptions/stripe/webhook", bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Stripe-Signature", signed.Header)
+
+	w, err := st.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Fatalf("can't trigger stripe webhook %s: status code %s", typ, w.Status)
+	}
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/auth"
+	"github.com/jatolentino/tutorialspoint/core/totp"
+)
+
+func TestTOTP(t *testing.T) {
+	env, err := NewTestEnv(t, "totp_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ft := &faqTest{env}
+
+	type codes struct {
+		Code         string `json:"code,omitempty"`
+		RecoveryCode string `json:"recoveryCode,omitempty"`
+	}
+
+	// The secret must be enrolled by authenticated users.
+	ft.do(t, http.MethodPost, "/users/current/totp", nil, nil, http.StatusUnauthorized)
+
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	var enr totp.Enrollment
+	ft.do(t, http.MethodPost, "/users/current/totp", nil, &enr, http.StatusCreated)
+	if !strings.HasPrefix(enr.URI, "otpauth://totp/Govod:") || !strings.Contains(enr.URI, "secret="+enr.Secret) {
+		t.Fatalf("unexpected provisioning uri %s", enr.URI)
+	}
+
+	code := func(step int64) string {
+		c, err := totp.Code(enr.Secret, totp.Counter(time.Now())+step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	ft.do(t, http.MethodPost, "/users/current/totp/confirm", codes{Code: "000000"}, nil, http.StatusForbidden)
+
+	used := code(0)
+
+	var rec totp.Recovery
+	ft.do(t, http.MethodPost, "/users/current/totp/confirm", codes{Code: used}, &rec, http.StatusOK)
+	if len(rec.Codes) != totp.RecoveryCodes {
+		t.Fatalf("expected %d recovery codes, got %d", totp.RecoveryCodes, len(rec.Codes))
+	}
+
+	ft.do(t, http.MethodPost, "/users/current/totp", nil, nil, http.StatusConflict)
+
+	Logout(ft.Server)
+
+	// The login is pending the second factor.
+	pending := loginPending(t, ft.TestEnv, ft.UserEmail, ft.UserPass)
+	if pending.TwoFactor != auth.StepVerify {
+		t.Fatalf("expected step %s, got %s", auth.StepVerify, pending.TwoFactor)
+	}
+
+	ft.do(t, http.MethodGet, "/users/current", nil, nil, http.StatusUnauthorized)
+
+	// Codes can't be used twice.
+	ft.do(t, http.MethodPost, "/auth/totp", codes{Code: used}, nil, http.StatusUnauthorized)
+	ft.do(t, http.MethodPost, "/auth/totp", codes{RecoveryCode: strings.ToUpper(rec.Codes[0])}, nil, http.StatusNoContent)
+	ft.do(t, http.MethodGet, "/users/current", nil, nil, http.StatusOK)
+
+	var regen totp.Recovery
+	ft.do(t, http.MethodPost, "/users/current/totp/recovery-codes", codes{Code: code(1)}, &regen, http.StatusOK)
+
+	// Recovery codes are replaced.
+	ft.do(t, http.MethodDelete, "/users/current/totp", codes{RecoveryCode: rec.Codes[1]}, nil, http.StatusForbidden)
+	ft.do(t, http.MethodDelete, "/users/current/totp", codes{RecoveryCode: regen.Codes[0]}, nil, http.StatusNoContent)
+
+	Logout(ft.Server)
+
+	if err := Login(ft.Server, ft.UserEmail, ft.UserPass); err != nil {
+		t.Fatalf("logging in once two-factor authentication is disabled: %v", err)
+	}
+}
+
+// loginPending logs in expecting the login to be pending the second
+// factor.
+func loginPending(t *testing.T, env *TestEnv, email string, pass string) auth.Pending {
+	r, err := http.NewRequest(http.MethodPost, env.URL+"/auth/login", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.SetBasicAuth(email, pass)
+
+	w, err := env.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusAccepted {
+		t.Fatalf("login: expected %d, got %s", http.StatusAccepted, w.Status)
+	}
+
+	var p auth.Pending
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
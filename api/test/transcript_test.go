@@ -0,0 +1,121 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/transcript"
+)
+
+type transcriptTest struct {
+	*TestEnv
+}
+
+func TestTranscript(t *testing.T) {
+	env, err := NewTestEnv(t, "transcript_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	tt := &transcriptTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+	rt := &cartTest{env}
+	ft := &freeTest{env}
+
+	crs := ct.createCourseOK(t)
+	other := ct.createCourseOK(t)
+	v1 := vt.createVideoOK(t, crs.ID, 1)
+	v2 := vt.createVideoOK(t, crs.ID, 2)
+
+	rt.createItemOK(t, crs.ID)
+	ft.createFreeCouponOK(t)
+	if w := ft.enroll(t, "FREE100"); w != http.StatusOK {
+		t.Fatalf("enrolling in course: expected 200, got %d", w)
+	}
+
+	if err := Login(tt.Server, tt.AdminEmail, tt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	tt.putOK(t, v1.ID, []transcript.Segment{
+		{Start: 30, End: 60, Text: "Channels connect concurrent goroutines."},
+		{Start: 0, End: 30, Text: "Welcome, today we talk about goroutines."},
+	})
+	tt.putOK(t, v2.ID, []transcript.Segment{
+		{Start: 0, End: 45, Text: "Interfaces are satisfied implicitly."},
+	})
+
+	Logout(tt.Server)
+
+	if err := Login(tt.Server, tt.UserEmail, tt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(tt.Server)
+
+	if code, _ := tt.search(t, other.ID, "goroutines"); code != http.StatusForbidden {
+		t.Fatalf("searching a course not owned: expected 403, got %d", code)
+	}
+
+	code, ms := tt.search(t, crs.ID, "goroutines")
+	if code != http.StatusOK || len(ms) != 1 || ms[0].VideoID != v1.ID || len(ms[0].Hits) != 2 {
+		t.Fatalf("searching: expected 200 with the 2 segments of the first video, got %d with %+v", code, ms)
+	}
+
+	if hits := ms[0].Hits; hits[0].Start != 0 || !strings.Contains(hits[0].Snippet, "<b>goroutines</b>") {
+		t.Fatalf("expected the segments in order with highlighted snippets, got %+v", hits)
+	}
+
+	if code, ms := tt.search(t, crs.ID, "pointers"); code != http.StatusOK || len(ms) != 0 {
+		t.Fatalf("searching missing words: expected no matches, got %d with %+v", code, ms)
+	}
+}
+
+func (tt *transcriptTest) putOK(t *testing.T, videoID string, segs []transcript.Segment) {
+	body, err := json.Marshal(transcript.TranscriptUp{Segments: segs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, tt.URL+"/videos/"+videoID+"/transcript", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := tt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't put transcript: status code %s", w.Status)
+	}
+}
+
+func (tt *transcriptTest) search(t *testing.T, courseID string, query string) (int, []transcript.Match) {
+	path := tt.URL + "/courses/" + courseID + "/transcripts/search?q=" + url.QueryEscape(query)
+	r, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := tt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got []transcript.Match
+	if w.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal matches: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/transfer"
+	"github.com/jatolentino/tutorialspoint/core/video"
+)
+
+type transferTest struct {
+	*TestEnv
+}
+
+func TestTransfer(t *testing.T) {
+	env, err := NewTestEnv(t, "transfer_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	tt := &transferTest{env}
+	ct := &courseTest{env}
+	vt := &videoTest{env}
+	st := &sectionTest{env}
+
+	crs := ct.createCourseOK(t)
+	v := vt.createVideoOK(t, crs.ID, 1)
+	vt.createVideoOK(t, crs.ID, 2)
+
+	if err := Login(tt.Server, tt.AdminEmail, tt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(tt.Server)
+
+	s := st.createSectionOK(t, crs.ID, video.SectionNew{Index: 1, Title: "Basics"})
+	st.moveVideo(t, v.ID, s.ID, http.StatusOK)
+
+	pkg := tt.exportOK(t, crs.ID)
+	if pkg.Version != transfer.FormatVersion || pkg.Course.Name != crs.Name {
+		t.Fatalf("expected package of course %q, got %+v", crs.Name, pkg)
+	}
+	if len(pkg.Sections) != 1 || len(pkg.Videos) != 2 || pkg.Videos[0].Section != 1 || pkg.Videos[1].Section != 0 {
+		t.Fatalf("expected 2 videos, the first in section 1, got %+v", pkg)
+	}
+
+	code, imported := tt.importPackage(t, pkg)
+	if code != http.StatusCreated {
+		t.Fatalf("importing package: expected 201, got %d", code)
+	}
+	if imported.ID == crs.ID || imported.Status != course.StatusDraft {
+		t.Fatalf("expected a new draft, got %+v", imported)
+	}
+
+	again := tt.exportOK(t, imported.ID)
+	if len(again.Sections) != 1 || len(again.Videos) != 2 || again.Videos[0].Section != 1 || again.Videos[0].Name != pkg.Videos[0].Name {
+		t.Fatalf("expected the imported course to match the package, got %+v", again)
+	}
+
+	pkg.Videos[1].Section = 9
+	if code, _ := tt.importPackage(t, pkg); code != http.StatusUnprocessableEntity {
+		t.Fatalf("importing a video of a missing section: expected 422, got %d", code)
+	}
+}
+
+func (tt *transferTest) exportOK(t *testing.T, courseID string) transfer.Package {
+	r, err := http.NewRequest(http.MethodGet, tt.URL+"/courses/"+courseID+"/export", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := tt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("exporting course[%s]: expected 200, got %s", courseID, w.Status)
+	}
+
+	var pkg transfer.Package
+	if err := json.NewDecoder(w.Body).Decode(&pkg); err != nil {
+		t.Fatalf("cannot unmarshal package: %v", err)
+	}
+
+	return pkg
+}
+
+func (tt *transferTest) importPackage(t *testing.T, pkg transfer.Package) (int, course.Course) {
+	body, err := json.Marshal(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, tt.URL+"/courses/import", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := tt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var c course.Course
+	if w.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(w.Body).Decode(&c); err != nil {
+			t.Fatalf("cannot unmarshal imported course: %v", err)
+		}
+	}
+
+	return w.StatusCode, c
+}
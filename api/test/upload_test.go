@@ -0,0 +1,131 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/video"
+)
+
+type uploadTest struct {
+	*TestEnv
+}
+
+func TestUpload(t *testing.T) {
+	env, err := NewTestEnv(t, "upload_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	ut := &uploadTest{env}
+
+	if err := Login(ut.Server, ut.AdminEmail, ut.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(ut.Server)
+
+	file := bytes.Repeat([]byte("video"), 300)
+	up := ut.createUploadOK(t, int64(len(file)))
+
+	// Chunks are at most 1KiB in the test env.
+	if got := ut.sendChunk(t, up.ID, 0, file); got != http.StatusRequestEntityTooLarge {
+		t.Fatalf("sending a chunk too large: expected 413, got %d", got)
+	}
+
+	if got := ut.sendChunk(t, up.ID, 0, file[:1000]); got != http.StatusOK {
+		t.Fatalf("sending the first chunk: expected 200, got %d", got)
+	}
+
+	// The interrupted upload resumes from the bytes received.
+	if up = ut.showUploadOK(t, up.ID); up.Offset != 1000 || up.CompletedAt != nil {
+		t.Fatalf("expected 1000 bytes received, got %+v", up)
+	}
+
+	if got := ut.sendChunk(t, up.ID, 0, file[:1000]); got != http.StatusConflict {
+		t.Fatalf("sending a chunk at the wrong offset: expected 409, got %d", got)
+	}
+
+	if got := ut.sendChunk(t, up.ID, 1000, file[1000:]); got != http.StatusOK {
+		t.Fatalf("sending the last chunk: expected 200, got %d", got)
+	}
+
+	up = ut.showUploadOK(t, up.ID)
+	exp := "https://cdn.example.com/uploads/" + up.ID + "/lesson.mp4"
+	if up.Offset != int64(len(file)) || up.CompletedAt == nil || up.URL != exp {
+		t.Fatalf("expected upload stored at %s, got %+v", exp, up)
+	}
+
+	if got := ut.sendChunk(t, up.ID, up.Offset, []byte("more")); got != http.StatusConflict {
+		t.Fatalf("sending a chunk to a completed upload: expected 409, got %d", got)
+	}
+}
+
+func (ut *uploadTest) createUploadOK(t *testing.T, size int64) video.Upload {
+	body := `{"filename": "lesson.mp4", "contentType": "video/mp4", "size": ` + strconv.FormatInt(size, 10) + `}`
+	r, err := http.NewRequest(http.MethodPost, ut.URL+"/uploads", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := ut.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create upload: status code %s", w.Status)
+	}
+
+	var got video.Upload
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal created upload: %v", err)
+	}
+
+	return got
+}
+
+func (ut *uploadTest) showUploadOK(t *testing.T, id string) video.Upload {
+	w, err := ut.Client().Get(ut.URL + "/uploads/" + id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't show upload: status code %s", w.Status)
+	}
+
+	var got video.Upload
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal upload: %v", err)
+	}
+
+	if h := w.Header.Get(video.OffsetHeader); h != strconv.FormatInt(got.Offset, 10) {
+		t.Fatalf("expected %s header %d, got %s", video.OffsetHeader, got.Offset, h)
+	}
+
+	return got
+}
+
+// sendChunk sends the chunk at offset and returns the status code of
+// the response.
+func (ut *uploadTest) sendChunk(t *testing.T, id string, offset int64, chunk []byte) int {
+	r, err := http.NewRequest(http.MethodPatch, ut.URL+"/uploads/"+id, bytes.NewReader(chunk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Content-Type", video.ChunkContentType)
+	r.Header.Set(video.OffsetHeader, strconv.FormatInt(offset, 10))
+
+	w, err := ut.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"net/http"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -39,8 +40,266 @@ func TestVideo(t *testing.T) {
 	v3 = vt.updateVideoOK(t, v3)
 
 	vt.showVideoOK(t, v3)
+	vt.replaceChaptersOK(t, v1)
 	vs := []video.Video{v1, v2, v3}
 	vt.listVideosOK(t, vs)
+
+	vt.updateProgressConcurrent(t, v1)
+	vt.updateProgressStale(t, v2)
+	vt.updateProgressBatchOK(t, v3)
+
+	v4 := vt.createVideoProcessing(t, c2.ID, 2, "job-1")
+	vt.transcodingCallbackUnsigned(t, "job-1")
+	vt.transcodingCallbackOK(t, v4, "job-1")
+
+	vt.createVideoWrongSource(t, c2.ID, 3)
+
+	c3 := ct.createCourseOK(t)
+	vt.deleteVideoOK(t, c3.ID)
+
+	c4 := ct.createCourseOK(t)
+	vt.reorderVideosOK(t, c4.ID)
+
+	c6 := ct.createCourseOK(t)
+	vt.importVideosOK(t, c6.ID)
+
+	c7 := ct.createCourseOK(t)
+	c8 := ct.createCourseOK(t)
+	vt.copyVideosOK(t, c7.ID, c8.ID)
+
+	vt.previewOK(t, c4.ID)
+
+	c5 := ct.createCourseOK(t)
+	vt.draftOK(t, c5.ID)
+}
+
+func (vt *videoTest) deleteVideoOK(t *testing.T, course string) {
+	v1 := vt.createVideoOK(t, course, 1)
+	v2 := vt.createVideoOK(t, course, 2)
+	v3 := vt.createVideoOK(t, course, 3)
+
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	del := func(id string) int {
+		r, err := http.NewRequest(http.MethodDelete, vt.URL+"/videos/"+id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := vt.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Body.Close()
+
+		return w.StatusCode
+	}
+
+	if got := del(v2.ID); got != http.StatusNoContent {
+		t.Fatalf("deleting video: expected 204, got %d", got)
+	}
+	if got := del(v2.ID); got != http.StatusNotFound {
+		t.Fatalf("deleting missing video: expected 404, got %d", got)
+	}
+
+	got := vt.listCourseVideosOK(t, course)
+	if len(got) != 2 || got[0].ID != v1.ID || got[0].Index != 1 || got[1].ID != v3.ID || got[1].Index != 2 {
+		t.Fatalf("expected the videos left compacted, got %+v", got)
+	}
+
+	// Deleted videos can be restored, at the end of their course.
+	deleted := vt.listDeletedOK(t)
+	if len(deleted) != 1 || deleted[0].ID != v2.ID || deleted[0].DeletedAt == nil {
+		t.Fatalf("expected the deleted video, got %+v", deleted)
+	}
+
+	if code, v := vt.restore(t, v2.ID); code != http.StatusOK || v.Index != 3 || v.DeletedAt != nil {
+		t.Fatalf("restoring video: expected 200 at index 3, got %d with %+v", code, v)
+	}
+	if code, _ := vt.restore(t, v2.ID); code != http.StatusNotFound {
+		t.Fatalf("restoring video not deleted: expected 404, got %d", code)
+	}
+
+	got = vt.listCourseVideosOK(t, course)
+	if len(got) != 3 || got[2].ID != v2.ID {
+		t.Fatalf("expected the video restored last, got %+v", got)
+	}
+}
+
+func (vt *videoTest) importVideosOK(t *testing.T, course string) {
+	vt.createVideoOK(t, course, 1)
+
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	// The second row takes the index of the existing video, the third
+	// one the index of the first row: nothing is created.
+	m := video.ImportManifest{Videos: []video.ImportRow{
+		{CourseID: course, Index: 2, Name: "Intro", Free: true},
+		{CourseID: course, Index: 1, Name: "Setup"},
+		{CourseID: course, Index: 2, Name: "Basics"},
+	}}
+	body, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, rep := vt.importVideos(t, "application/json", body)
+	if code != http.StatusUnprocessableEntity || rep.Created != 0 || len(rep.Results) != 3 {
+		t.Fatalf("importing invalid manifest: expected 422, got %d with %+v", code, rep)
+	}
+	if rep.Results[0].Error != "" || rep.Results[1].Error == "" || rep.Results[2].Error == "" {
+		t.Fatalf("expected errors on the second and third rows, got %+v", rep.Results)
+	}
+
+	csv := "courseId,index,name,free,url\n" +
+		course + ",2,Intro,true,\n" +
+		course + ",3,Basics,false,https://cdn.example.com/basics.mp4\n"
+
+	code, rep = vt.importVideos(t, "text/csv", []byte(csv))
+	if code != http.StatusCreated || rep.Created != 2 || rep.Results[1].VideoID == "" {
+		t.Fatalf("importing csv manifest: expected 201, got %d with %+v", code, rep)
+	}
+
+	got := vt.listCourseVideosOK(t, course)
+	if len(got) != 3 || got[2].Name != "Basics" || got[2].Free {
+		t.Fatalf("expected the videos imported, got %+v", got)
+	}
+}
+
+func (vt *videoTest) importVideos(t *testing.T, contentType string, body []byte) (int, video.ImportReport) {
+	r, err := http.NewRequest(http.MethodPost, vt.URL+"/videos/import", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Content-Type", contentType)
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got video.ImportReport
+	if w.StatusCode == http.StatusCreated || w.StatusCode == http.StatusUnprocessableEntity {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal import report: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
+
+func (vt *videoTest) copyVideosOK(t *testing.T, from string, to string) {
+	v1 := vt.createVideoOK(t, from, 1)
+	v2 := vt.createVideoOK(t, from, 2)
+	vt.createVideoOK(t, to, 1)
+
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	code, got := vt.copyVideos(t, to, video.VideosCopy{FromCourseID: from, VideoIDs: []string{v2.ID}})
+	if code != http.StatusCreated || len(got) != 1 || got[0].ID == v2.ID || got[0].Index != 2 || got[0].Name != v2.Name {
+		t.Fatalf("copying a video: expected 201 with the copy at index 2, got %d with %+v", code, got)
+	}
+
+	code, _ = vt.copyVideos(t, to, video.VideosCopy{FromCourseID: from, VideoIDs: []string{got[0].ID}})
+	if code != http.StatusUnprocessableEntity {
+		t.Fatalf("copying a video of another course: expected 422, got %d", code)
+	}
+
+	// Copying all the videos appends them in the order of the source.
+	code, got = vt.copyVideos(t, to, video.VideosCopy{FromCourseID: from})
+	if code != http.StatusCreated || len(got) != 2 || got[0].Name != v1.Name || got[0].Index != 3 || got[1].Index != 4 {
+		t.Fatalf("copying all videos: expected 201 with the copies at 3 and 4, got %d with %+v", code, got)
+	}
+
+	if vs := vt.listCourseVideosOK(t, to); len(vs) != 4 {
+		t.Fatalf("expected 4 videos in the target course, got %+v", vs)
+	}
+	if vs := vt.listCourseVideosOK(t, from); len(vs) != 2 {
+		t.Fatalf("expected the source course unchanged, got %+v", vs)
+	}
+}
+
+func (vt *videoTest) copyVideos(t *testing.T, course string, vc video.VideosCopy) (int, []video.Video) {
+	body, err := json.Marshal(vc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, vt.URL+"/courses/"+course+"/videos/copy", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got []video.Video
+	if w.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal videos: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
+}
+
+func (vt *videoTest) listDeletedOK(t *testing.T) []video.Video {
+	r, err := http.NewRequest(http.MethodGet, vt.URL+"/videos/deleted", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list deleted videos: status code %s", w.Status)
+	}
+
+	var got []video.Video
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal videos: %v", err)
+	}
+
+	return got
+}
+
+func (vt *videoTest) restore(t *testing.T, id string) (int, video.Video) {
+	r, err := http.NewRequest(http.MethodPost, vt.URL+"/videos/"+id+"/restore", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	var got video.Video
+	if w.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("cannot unmarshal video: %v", err)
+		}
+	}
+
+	return w.StatusCode, got
 }
 
 func (vt *videoTest) createVideoOK(t *testing.T, course string, index int) video.Video {
@@ -255,8 +514,36 @@ func (vt *videoTest) showVideoOK(t *testing.T, v video.Video) {
 	}
 }
 
-func (vt *videoTest) listVideosOK(t *testing.T, vs []video.Video) {
-	r, err := http.NewRequest(http.MethodGet, vt.URL+"/videos", nil)
+func (vt *videoTest) replaceChaptersOK(t *testing.T, v video.Video) {
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	send := func(body string) int {
+		r, err := http.NewRequest(http.MethodPut, vt.URL+"/videos/"+v.ID+"/chapters", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := vt.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Body.Close()
+
+		return w.StatusCode
+	}
+
+	if got := send(`{"chapters": [{"start": 0, "title": "Intro"}, {"start": 0, "title": "Again"}]}`); got != http.StatusUnprocessableEntity {
+		t.Fatalf("replacing chapters starting together: expected 422, got %d", got)
+	}
+
+	if got := send(`{"chapters": [{"start": 95, "title": "Setup"}, {"start": 0, "title": "Intro"}]}`); got != http.StatusOK {
+		t.Fatalf("replacing chapters: expected 200, got %d", got)
+	}
+
+	r, err := http.NewRequest(http.MethodGet, vt.URL+"/videos/"+v.ID+"/free", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -268,25 +555,630 @@ func (vt *videoTest) listVideosOK(t *testing.T, vs []video.Video) {
 	defer w.Body.Close()
 
 	if w.StatusCode != http.StatusOK {
-		t.Fatalf("can't fetch videos: status code %s", w.Status)
+		t.Fatalf("can't fetch free video: status code %s", w.Status)
 	}
 
-	var got []video.Video
+	var got struct {
+		Chapters []video.Chapter `json:"chapters"`
+	}
 	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
-		t.Fatalf("cannot unmarshal fetched videos: %v", err)
+		t.Fatalf("cannot unmarshal free video: %v", err)
 	}
 
-	// Don't care about dates.
-	now := time.Now()
-	nodates := cmp.Transformer("", func(in video.Video) video.Video {
-		out := in
-		out.CreatedAt = now
-		out.UpdatedAt = now
-		return out
-	})
+	exp := []video.Chapter{{Start: 0, Title: "Intro"}, {Start: 95, Title: "Setup"}}
+	if diff := cmp.Diff(got.Chapters, exp); diff != "" {
+		t.Fatalf("wrong chapters. Diff: \n%s", diff)
+	}
+}
 
-	less := func(a, b video.Video) bool { return a.ID < b.ID }
-	if diff := cmp.Diff(got, vs, cmpopts.SortSlices(less), nodates); diff != "" {
-		t.Fatalf("wrong videos payload. Diff: \n%s", diff)
+func (vt *videoTest) previewOK(t *testing.T, course string) {
+	v := vt.createVideoOK(t, course, 10)
+
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(method string, path string, body string) int {
+		r, err := http.NewRequest(method, vt.URL+"/videos/"+v.ID+path, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := vt.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Body.Close()
+
+		return w.StatusCode
+	}
+
+	clip := `{"url": "https://youtu.be/dQw4w9WgXcQ", "provider": "youtube", "start": 30, "end": 90}`
+	if got := send(http.MethodPut, "/preview", clip); got != http.StatusUnprocessableEntity {
+		t.Fatalf("previewing a free video: expected 422, got %d", got)
+	}
+
+	if got := send(http.MethodPut, "", `{"free": false}`); got != http.StatusOK {
+		t.Fatalf("making video paid: expected 200, got %d", got)
+	}
+
+	if got := send(http.MethodPut, "/preview", `{"url": "https://youtu.be/dQw4w9WgXcQ", "provider": "youtube", "start": 30, "end": 10}`); got != http.StatusUnprocessableEntity {
+		t.Fatalf("previewing a segment ending before its start: expected 422, got %d", got)
+	}
+
+	if got := send(http.MethodPut, "/preview", clip); got != http.StatusOK {
+		t.Fatalf("previewing video: expected 200, got %d", got)
+	}
+
+	Logout(vt.Server)
+
+	// Visitors play the preview only.
+	r, err := http.NewRequest(http.MethodGet, vt.URL+"/videos/"+v.ID+"/preview", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't fetch preview: status code %s", w.Status)
+	}
+
+	var got struct {
+		Preview  video.Preview  `json:"preview"`
+		Playback video.Playback `json:"playback"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal preview: %v", err)
+	}
+
+	if got.Preview.Start != 30 || got.Preview.End != 90 || got.Playback.URL != "https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ" {
+		t.Fatalf("unexpected preview %+v", got)
+	}
+
+	if got := send(http.MethodGet, "/free", ""); got != http.StatusForbidden {
+		t.Fatalf("fetching paid video as free: expected 403, got %d", got)
+	}
+
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	if got := send(http.MethodDelete, "/preview", ""); got != http.StatusNoContent {
+		t.Fatalf("deleting preview: expected 204, got %d", got)
+	}
+
+	if got := send(http.MethodGet, "/preview", ""); got != http.StatusNotFound {
+		t.Fatalf("fetching deleted preview: expected 404, got %d", got)
+	}
+}
+
+func (vt *videoTest) draftOK(t *testing.T, course string) {
+	v1 := vt.createVideoOK(t, course, 1)
+	v2 := vt.createVideoOK(t, course, 2)
+
+	send := func(method string, path string, body string) int {
+		r, err := http.NewRequest(method, vt.URL+path, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := vt.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Body.Close()
+
+		return w.StatusCode
+	}
+
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := send(http.MethodPut, "/videos/"+v2.ID, `{"published": false}`); got != http.StatusOK {
+		t.Fatalf("unpublishing video: expected 200, got %d", got)
+	}
+
+	// Administrators keep seeing drafts.
+	if vs := vt.listCourseVideosOK(t, course); len(vs) != 2 || vs[1].Published {
+		t.Fatalf("expected the draft listed to administrators, got %+v", vs)
+	}
+
+	Logout(vt.Server)
+
+	if err := Login(vt.Server, vt.UserEmail, vt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+
+	if vs := vt.listCourseVideosOK(t, course); len(vs) != 1 || vs[0].ID != v1.ID {
+		t.Fatalf("expected the published video only, got %+v", vs)
+	}
+
+	for _, path := range []string{"/videos/" + v2.ID, "/videos/" + v2.ID + "/full"} {
+		if got := send(http.MethodGet, path, ""); got != http.StatusNotFound {
+			t.Fatalf("fetching draft at %s: expected 404, got %d", path, got)
+		}
+	}
+
+	if got := send(http.MethodGet, "/videos/"+v1.ID+"/full", ""); got != http.StatusOK {
+		t.Fatalf("fetching published video: expected 200, got %d", got)
+	}
+
+	Logout(vt.Server)
+}
+
+func (vt *videoTest) reorderVideosOK(t *testing.T, course string) {
+	v1 := vt.createVideoOK(t, course, 1)
+	v2 := vt.createVideoOK(t, course, 2)
+	v3 := vt.createVideoOK(t, course, 3)
+
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	reorder := func(ids ...string) int {
+		body, err := json.Marshal(video.VideosOrder{VideoIDs: ids})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := http.NewRequest(http.MethodPut, vt.URL+"/courses/"+course+"/videos/order", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := vt.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Body.Close()
+
+		return w.StatusCode
+	}
+
+	if got := reorder(v3.ID, v1.ID); got != http.StatusUnprocessableEntity {
+		t.Fatalf("reordering part of the videos: expected 422, got %d", got)
+	}
+	if got := reorder(v3.ID, v1.ID, v1.ID); got != http.StatusUnprocessableEntity {
+		t.Fatalf("reordering a video twice: expected 422, got %d", got)
+	}
+
+	if got := reorder(v3.ID, v1.ID, v2.ID); got != http.StatusOK {
+		t.Fatalf("reordering videos: expected 200, got %d", got)
+	}
+
+	got := vt.listCourseVideosOK(t, course)
+	if len(got) != 3 || got[0].ID != v3.ID || got[1].ID != v1.ID || got[2].ID != v2.ID || got[2].Index != 3 {
+		t.Fatalf("expected the videos reordered, got %+v", got)
+	}
+}
+
+func (vt *videoTest) listCourseVideosOK(t *testing.T, course string) []video.Video {
+	r, err := http.NewRequest(http.MethodGet, vt.URL+"/courses/"+course+"/videos", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't fetch videos of course: status code %s", w.Status)
+	}
+
+	var got []video.Video
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal fetched videos: %v", err)
+	}
+
+	return got
+}
+
+func (vt *videoTest) listVideosOK(t *testing.T, vs []video.Video) {
+	r, err := http.NewRequest(http.MethodGet, vt.URL+"/videos", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't fetch videos: status code %s", w.Status)
+	}
+
+	var got []video.Video
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal fetched videos: %v", err)
+	}
+
+	// Don't care about dates.
+	now := time.Now()
+	nodates := cmp.Transformer("", func(in video.Video) video.Video {
+		out := in
+		out.CreatedAt = now
+		out.UpdatedAt = now
+		return out
+	})
+
+	less := func(a, b video.Video) bool { return a.ID < b.ID }
+	if diff := cmp.Diff(got, vs, cmpopts.SortSlices(less), nodates); diff != "" {
+		t.Fatalf("wrong videos payload. Diff: \n%s", diff)
+	}
+}
+
+func (vt *videoTest) updateProgress(t *testing.T, v video.Video, up video.ProgressUp) {
+	body, err := json.Marshal(&up)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	r, err := http.NewRequest(http.MethodPut, vt.URL+"/videos/"+v.ID+"/progress", bytes.NewBuffer(body))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusNoContent {
+		t.Errorf("can't update progress: status code %s", w.Status)
+	}
+}
+
+func (vt *videoTest) fetchProgress(t *testing.T, v video.Video) video.Progress {
+	r, err := http.NewRequest(http.MethodGet, vt.URL+"/courses/"+v.CourseID+"/progress", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't fetch progress: status code %s", w.Status)
+	}
+
+	var got []video.Progress
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal fetched progress: %v", err)
+	}
+
+	for _, p := range got {
+		if p.VideoID == v.ID {
+			return p
+		}
+	}
+
+	t.Fatalf("progress of video[%s] not found", v.ID)
+	return video.Progress{}
+}
+
+func (vt *videoTest) updateProgressConcurrent(t *testing.T, v video.Video) {
+	if err := Login(vt.Server, vt.UserEmail, vt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	var wg sync.WaitGroup
+	for i := 0; i <= 100; i += 10 {
+		wg.Add(1)
+		go func(value int) {
+			defer wg.Done()
+			vt.updateProgress(t, v, video.ProgressUp{Progress: value, Position: value})
+		}(i)
+	}
+	wg.Wait()
+
+	if got := vt.fetchProgress(t, v); got.Progress != 100 {
+		t.Fatalf("expected progress to be 100, got %d", got.Progress)
+	}
+}
+
+func (vt *videoTest) updateProgressStale(t *testing.T, v video.Video) {
+	if err := Login(vt.Server, vt.UserEmail, vt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	now := time.Now().UTC()
+	older := now.Add(-time.Minute)
+
+	vt.updateProgress(t, v, video.ProgressUp{Progress: 80, Position: 40, Watched: 300, ReportedAt: &now})
+	// A stale tab neither lowers the completion nor moves the position.
+	vt.updateProgress(t, v, video.ProgressUp{Progress: 20, Position: 10, Watched: 100, ReportedAt: &older})
+
+	got := vt.fetchProgress(t, v)
+	if got.Progress != 80 || got.Position != 40 || got.Watched != 300 {
+		t.Fatalf("expected progress 80 at 40 after 300s, got %d at %d after %ds", got.Progress, got.Position, got.Watched)
+	}
+
+	// A newer update moves the position back but keeps the completion.
+	vt.updateProgress(t, v, video.ProgressUp{Progress: 30, Position: 5})
+
+	got = vt.fetchProgress(t, v)
+	if got.Progress != 80 || got.Position != 5 {
+		t.Fatalf("expected progress 80 at 5, got %d at %d", got.Progress, got.Position)
+	}
+
+	vt.showResumeOK(t, v, video.Resume{VideoID: v.ID, Position: 5, Watched: 300, Progress: 80})
+}
+
+func (vt *videoTest) updateProgressBatchOK(t *testing.T, v video.Video) {
+	if err := Login(vt.Server, vt.UserEmail, vt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	if code := vt.updateProgressBatch(t, video.ProgressBatch{}); code != http.StatusUnprocessableEntity {
+		t.Fatalf("sending empty batch: expected 422, got %d", code)
+	}
+
+	now := time.Now().UTC()
+	older := now.Add(-time.Minute)
+	oldest := now.Add(-2 * time.Minute)
+
+	// The reports of the batch are merged whatever their order.
+	batch := video.ProgressBatch{
+		Reports: []video.ProgressReport{
+			{VideoID: v.ID, ProgressUp: video.ProgressUp{Progress: 50, Position: 60, Watched: 60, ReportedAt: &older}},
+			{VideoID: v.ID, ProgressUp: video.ProgressUp{Progress: 40, Position: 30, Watched: 90, ReportedAt: &now}},
+			{VideoID: v.ID, ProgressUp: video.ProgressUp{Progress: 10, Position: 10, Watched: 10, ReportedAt: &oldest}},
+		},
+	}
+	if code := vt.updateProgressBatch(t, batch); code != http.StatusNoContent {
+		t.Fatalf("sending batch: expected 204, got %d", code)
+	}
+
+	got := vt.fetchProgress(t, v)
+	if got.Progress != 50 || got.Position != 30 || got.Watched != 90 {
+		t.Fatalf("expected progress 50 at 30 after 90s, got %d at %d after %ds", got.Progress, got.Position, got.Watched)
+	}
+}
+
+func (vt *videoTest) updateProgressBatch(t *testing.T, batch video.ProgressBatch) int {
+	body, err := json.Marshal(&batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, vt.URL+"/videos/progress", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (vt *videoTest) showResumeOK(t *testing.T, v video.Video, exp video.Resume) {
+	r, err := http.NewRequest(http.MethodGet, vt.URL+"/videos/"+v.ID+"/resume", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't fetch resume point: status code %s", w.Status)
+	}
+
+	var got video.Resume
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal resume point: %v", err)
+	}
+
+	if diff := cmp.Diff(got, exp); diff != "" {
+		t.Fatalf("wrong resume point. Diff: \n%s", diff)
+	}
+}
+
+func (vt *videoTest) createVideoProcessing(t *testing.T, course string, index int, job string) video.Video {
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	v := video.VideoNew{
+		CourseID:    course,
+		Index:       index,
+		Name:        "Video Test" + strconv.Itoa(rand.Intn(1000)),
+		Description: "This is a test video",
+		Free:        true,
+		ImageURL:    "/images/new.png",
+		JobID:       job,
+	}
+
+	body, err := json.Marshal(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, vt.URL+"/videos", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't create video: status code %s", w.Status)
+	}
+
+	var got video.Video
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal created video: %v", err)
+	}
+
+	if got.Status != video.StatusProcessing {
+		t.Fatalf("expected video to be processing, got %s", got.Status)
+	}
+
+	// Not playable until transcoded.
+	r, err = http.NewRequest(http.MethodGet, vt.URL+"/videos/"+got.ID+"/free", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusConflict {
+		t.Fatalf("processing video should not be playable: status code %s", w.Status)
+	}
+
+	return got
+}
+
+func (vt *videoTest) transcodingCallback(t *testing.T, ev video.TranscodingEvent, sign bool) int {
+	body, err := json.Marshal(&ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, vt.URL+"/videos/transcoding/callback", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig := video.Sign(body, "wrong-secret", time.Now())
+	if sign {
+		sig = video.Sign(body, vt.TranscodingSecret, time.Now())
+	}
+	r.Header.Set(video.SignatureHeader, sig)
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (vt *videoTest) transcodingCallbackUnsigned(t *testing.T, job string) {
+	ev := video.TranscodingEvent{JobID: job, Status: video.StatusReady}
+	if code := vt.transcodingCallback(t, ev, false); code != http.StatusBadRequest {
+		t.Fatalf("callbacks with a wrong signature must be rejected: status code %d", code)
+	}
+}
+
+func (vt *videoTest) transcodingCallbackOK(t *testing.T, v video.Video, job string) {
+	ev := video.TranscodingEvent{
+		JobID:  job,
+		Status: video.StatusReady,
+		Renditions: []video.Rendition{
+			{Name: "720p", URL: "https://cdn.example.com/v/720.m3u8", Width: 1280, Height: 720, Bitrate: 3000},
+			{Name: "360p", URL: "https://cdn.example.com/v/360.m3u8", Width: 640, Height: 360, Bitrate: 800},
+		},
+	}
+	if code := vt.transcodingCallback(t, ev, true); code != http.StatusNoContent {
+		t.Fatalf("can't process transcoding callback: status code %d", code)
+	}
+
+	r, err := http.NewRequest(http.MethodGet, vt.URL+"/videos/"+v.ID+"/free", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't fetch transcoded video: status code %s", w.Status)
+	}
+
+	var got struct {
+		Video      video.Video       `json:"video"`
+		Renditions []video.Rendition `json:"renditions"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal fetched video: %v", err)
+	}
+
+	if got.Video.Status != video.StatusReady {
+		t.Fatalf("expected video to be ready, got %s", got.Video.Status)
+	}
+	if len(got.Renditions) != 2 || got.Renditions[0].Name != "360p" {
+		t.Fatalf("wrong renditions: %+v", got.Renditions)
+	}
+}
+
+func (vt *videoTest) createVideoWrongSource(t *testing.T, course string, index int) {
+	if err := Login(vt.Server, vt.AdminEmail, vt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(vt.Server)
+
+	v := video.VideoNew{
+		CourseID:    course,
+		Index:       index,
+		Name:        "Video Test" + strconv.Itoa(rand.Intn(1000)),
+		Description: "This is a test video",
+		Free:        true,
+		URL:         "https://vimeo.com/76979871",
+		Provider:    video.ProviderYoutube,
+		ImageURL:    "/images/new.png",
+	}
+
+	body, err := json.Marshal(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, vt.URL+"/videos", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := vt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("video with a url not matching its provider should be rejected: status code %s", w.Status)
 	}
 }
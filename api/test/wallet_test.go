@@ -0,0 +1,131 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/wallet"
+)
+
+// seedUserID is the id of the test user in the seed.
+const seedUserID = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+type walletTest struct {
+	*TestEnv
+}
+
+func TestWallet(t *testing.T) {
+	env, err := NewTestEnv(t, "wallet_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	wt := &walletTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c := ct.createCourseOK(t)
+	rt.createItemOK(t, c.ID)
+
+	// The credit is enough to buy any test course.
+	wt.grantOK(t, 100000)
+	wt.payWithCreditOK(t)
+
+	ct.listCoursesOwnedOK(t, []course.Course{c})
+	wt.showBalanceOK(t, 100000-c.Price.Units)
+}
+
+func (wt *walletTest) grantOK(t *testing.T, units int64) {
+	if err := Login(wt.Server, wt.AdminEmail, wt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(wt.Server)
+
+	body := `{"amount": {"units": ` + strconv.FormatInt(units, 10) + `, "currency": "USD"}, "reason": "promo"}`
+	r, err := http.NewRequest(http.MethodPost, wt.URL+"/users/"+seedUserID+"/wallet", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusCreated {
+		t.Fatalf("can't grant credit: status code %s", w.Status)
+	}
+}
+
+func (wt *walletTest) payWithCreditOK(t *testing.T) {
+	if err := Login(wt.Server, wt.UserEmail, wt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(wt.Server)
+
+	r, err := http.NewRequest(http.MethodPost, wt.URL+"/orders/stripe?wallet=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't pay with credit: status code %s", w.Status)
+	}
+
+	var got struct {
+		Paid bool `json:"paid"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal checkout: %v", err)
+	}
+
+	if !got.Paid {
+		t.Fatalf("expected the order to be payed with credit only")
+	}
+}
+
+func (wt *walletTest) showBalanceOK(t *testing.T, exp int64) {
+	if err := Login(wt.Server, wt.UserEmail, wt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(wt.Server)
+
+	r, err := http.NewRequest(http.MethodGet, wt.URL+"/wallet", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't show wallet: status code %s", w.Status)
+	}
+
+	var got wallet.Wallet
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal wallet: %v", err)
+	}
+
+	if len(got.Balances) != 1 || got.Balances[0].Units != exp {
+		t.Fatalf("expected a balance of %d, got %+v", exp, got.Balances)
+	}
+
+	if len(got.Entries) != 2 {
+		t.Fatalf("expected the grant and the purchase, got %+v", got.Entries)
+	}
+}
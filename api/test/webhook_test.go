@@ -0,0 +1,138 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/order"
+)
+
+type webhookTest struct {
+	*TestEnv
+}
+
+func TestWebhookEvents(t *testing.T) {
+	env, err := NewTestEnv(t, "webhook_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	wt := &webhookTest{env}
+	bt := &coinbaseTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c := ct.createCourseOK(t)
+
+	// Events of unknown charges can't be processed.
+	w := bt.webhook(t, "charge:failed", "charge-unknown")
+	w.Body.Close()
+	if w.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected the webhook of an unknown charge to fail, got status code %s", w.Status)
+	}
+
+	bt.webhookOK(t, "charge:pending", "charge-unknown")
+
+	rt.createItemOK(t, c.ID)
+	bt.Coinbase.expectedCart = []course.Course{c}
+	bt.webhookOK(t, "charge:confirmed", bt.checkoutOK(t))
+	ct.listCoursesOwnedOK(t, []course.Course{c})
+
+	failed := wt.listOK(t, order.EventFailed)
+	if len(failed) != 1 || failed[0].Type != "charge:failed" || failed[0].ProviderID != "charge-unknown" || failed[0].Error == "" {
+		t.Fatalf("expected the event of the unknown charge to be failed, got %+v", failed)
+	}
+
+	ignored := wt.listOK(t, order.EventIgnored)
+	if len(ignored) != 1 || ignored[0].Type != "charge:pending" {
+		t.Fatalf("expected the pending event to be ignored, got %+v", ignored)
+	}
+
+	processed := wt.listOK(t, order.EventProcessed)
+	if len(processed) != 1 || processed[0].Type != "charge:confirmed" {
+		t.Fatalf("expected the confirmed event to be processed, got %+v", processed)
+	}
+
+	// The charge is still unknown, so the replay fails as well.
+	ev := wt.replayOK(t, failed[0].ID)
+	if ev.Result != order.EventFailed || ev.Attempts != 2 {
+		t.Fatalf("expected the replay to fail again, got %+v", ev)
+	}
+
+	if code := wt.replay(t, processed[0].ID); code != http.StatusConflict {
+		t.Fatalf("expected replaying a processed event to conflict, got status code %d", code)
+	}
+
+	if code := wt.replay(t, ignored[0].ID); code != http.StatusConflict {
+		t.Fatalf("expected replaying an ignored event to conflict, got status code %d", code)
+	}
+}
+
+// listOK returns the webhook events with the passed result.
+func (wt *webhookTest) listOK(t *testing.T, result order.EventResult) []order.WebhookEvent {
+	if err := Login(wt.Server, wt.AdminEmail, wt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(wt.Server)
+
+	w, err := wt.Client().Get(wt.URL + "/webhooks/events?result=" + string(result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list webhook events: status code %s", w.Status)
+	}
+
+	var got []order.WebhookEvent
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal webhook events: %v", err)
+	}
+
+	return got
+}
+
+// replay replays the webhook event as administrator and returns the
+// status code.
+func (wt *webhookTest) replay(t *testing.T, eventID string) int {
+	if err := Login(wt.Server, wt.AdminEmail, wt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(wt.Server)
+
+	w, err := wt.Client().Post(wt.URL+"/webhooks/events/"+eventID+"/replay", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+// replayOK replays the webhook event and returns it.
+func (wt *webhookTest) replayOK(t *testing.T, eventID string) order.WebhookEvent {
+	if err := Login(wt.Server, wt.AdminEmail, wt.AdminPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(wt.Server)
+
+	w, err := wt.Client().Post(wt.URL+"/webhooks/events/"+eventID+"/replay", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't replay webhook event: status code %s", w.Status)
+	}
+
+	var got order.WebhookEvent
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal webhook event: %v", err)
+	}
+
+	return got
+}
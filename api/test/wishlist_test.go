@@ -0,0 +1,151 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jatolentino/tutorialspoint/core/cart"
+	"github.com/jatolentino/tutorialspoint/core/wishlist"
+)
+
+type wishlistTest struct {
+	*TestEnv
+}
+
+func TestWishlist(t *testing.T) {
+	env, err := NewTestEnv(t, "wishlist_test")
+	if err != nil {
+		t.Fatalf("initializing test env: %v", err)
+	}
+
+	wt := &wishlistTest{env}
+	ct := &courseTest{env}
+	rt := &cartTest{env}
+
+	c1 := ct.createCourseOK(t)
+	c2 := ct.createCourseOK(t)
+
+	wt.createOK(t, c1.ID)
+	wt.createOK(t, c2.ID)
+
+	if code := wt.create(t, c1.ID); code != http.StatusConflict {
+		t.Fatalf("wishing for a course twice: expected 409, got %d", code)
+	}
+
+	got := wt.listOK(t)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 courses in the wishlist, got %+v", got)
+	}
+	for _, it := range got {
+		if (it.CourseID == c1.ID && it.Price != c1.Price) || (it.CourseID == c2.ID && it.Price != c2.Price) {
+			t.Fatalf("unexpected price of course[%s]: %s", it.CourseID, it.Price)
+		}
+	}
+
+	// Moving a course leaves it in the cart only.
+	wt.moveOK(t, c1.ID)
+	wt.deleteOK(t, c2.ID)
+	if got := wt.listOK(t); len(got) != 0 {
+		t.Fatalf("expected an empty wishlist, got %+v", got)
+	}
+
+	c1item := cart.Item{CourseID: c1.ID, Price: c1.Price}
+	rt.showCartOK(t, cart.Cart{Items: []cart.Item{c1item}})
+}
+
+// create adds the course to the wishlist and returns the status code.
+func (wt *wishlistTest) create(t *testing.T, courseID string) int {
+	if err := Login(wt.Server, wt.UserEmail, wt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(wt.Server)
+
+	body, err := json.Marshal(wishlist.ItemNew{CourseID: courseID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := http.NewRequest(http.MethodPut, wt.URL+"/wishlist/items", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (wt *wishlistTest) createOK(t *testing.T, courseID string) {
+	if code := wt.create(t, courseID); code != http.StatusCreated {
+		t.Fatalf("can't add course[%s] to the wishlist: status code %d", courseID, code)
+	}
+}
+
+// do sends a request with no body to the passed path, as the user,
+// and returns the status code.
+func (wt *wishlistTest) do(t *testing.T, method string, path string) int {
+	if err := Login(wt.Server, wt.UserEmail, wt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(wt.Server)
+
+	r, err := http.NewRequest(method, wt.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	return w.StatusCode
+}
+
+func (wt *wishlistTest) moveOK(t *testing.T, courseID string) {
+	if code := wt.do(t, http.MethodPost, "/wishlist/items/"+courseID+"/move"); code != http.StatusOK {
+		t.Fatalf("can't move course[%s] to the cart: status code %d", courseID, code)
+	}
+}
+
+func (wt *wishlistTest) deleteOK(t *testing.T, courseID string) {
+	if code := wt.do(t, http.MethodDelete, "/wishlist/items/"+courseID); code != http.StatusNoContent {
+		t.Fatalf("can't delete course[%s] from the wishlist: status code %d", courseID, code)
+	}
+}
+
+func (wt *wishlistTest) listOK(t *testing.T) []wishlist.Item {
+	if err := Login(wt.Server, wt.UserEmail, wt.UserPass); err != nil {
+		t.Fatal(err)
+	}
+	defer Logout(wt.Server)
+
+	r, err := http.NewRequest(http.MethodGet, wt.URL+"/wishlist", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wt.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Body.Close()
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("can't list wishlist: status code %s", w.Status)
+	}
+
+	var got []wishlist.Item
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot unmarshal wishlist: %v", err)
+	}
+
+	return got
+}
@@ -0,0 +1,65 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrUnknownExpansion is returned when a client asks to expand
+// a relation which is not registered on the resource.
+var ErrUnknownExpansion = errors.New("unknown expansion")
+
+// Expander loads a resource related to the one with the passed id.
+type Expander func(ctx context.Context, id string) (any, error)
+
+// Expansions is a registry of the relations which can be expanded
+// on a resource through the expand query parameter, e.g.
+// ?expand=videos,reviews_summary.
+//
+// Handlers don't know how relations are loaded: registries are composed
+// where the routes are defined, so packages don't depend on each other.
+type Expansions map[string]Expander
+
+// With returns a new registry containing both the relations of e and
+// the passed one. The original registry is left untouched.
+func (e Expansions) With(name string, exp Expander) Expansions {
+	out := make(Expansions, len(e)+1)
+	for k, v := range e {
+		out[k] = v
+	}
+	out[name] = exp
+	return out
+}
+
+// Expand loads all the relations requested by r for the resource with
+// the passed id. It returns nil if no expansion is requested.
+func (e Expansions) Expand(ctx context.Context, r *http.Request, id string) (map[string]any, error) {
+	param := r.URL.Query().Get("expand")
+	if param == "" {
+		return nil, nil
+	}
+
+	out := make(map[string]any)
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := out[name]; ok {
+			continue
+		}
+
+		exp, ok := e[name]
+		if !ok {
+			return nil, fmt.Errorf("expanding %q: %w", name, ErrUnknownExpansion)
+		}
+
+		v, err := exp(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("expanding %q: %w", name, err)
+		}
+		out[name] = v
+	}
+
+	return out, nil
+}
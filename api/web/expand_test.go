@@ -0,0 +1,43 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	calls := 0
+	exps := Expansions{}.
+		With("videos", func(ctx context.Context, id string) (any, error) {
+			calls++
+			return []string{id + "-v1", id + "-v2"}, nil
+		}).
+		With("summary", func(ctx context.Context, id string) (any, error) {
+			return 4, nil
+		})
+
+	r := httptest.NewRequest("GET", "/courses/c1?expand=videos,summary,videos", nil)
+	got, err := exps.Expand(context.Background(), r, "c1")
+	if err != nil {
+		t.Fatalf("expanding: %v", err)
+	}
+
+	if len(got) != 2 || got["summary"] != 4 {
+		t.Fatalf("wrong expansions: %v", got)
+	}
+	if calls != 1 {
+		t.Fatalf("expected videos to be loaded once, got %d", calls)
+	}
+
+	r = httptest.NewRequest("GET", "/courses/c1", nil)
+	if got, err := exps.Expand(context.Background(), r, "c1"); err != nil || got != nil {
+		t.Fatalf("expected no expansions, got %v, %v", got, err)
+	}
+
+	r = httptest.NewRequest("GET", "/courses/c1?expand=teacher", nil)
+	if _, err := exps.Expand(context.Background(), r, "c1"); !errors.Is(err, ErrUnknownExpansion) {
+		t.Fatalf("expected unknown expansion, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ErrInvalidPage is returned when the pagination parameters are not valid.
+var ErrInvalidPage = errors.New("invalid page")
+
+// Page models the page of a list requested by a client.
+// Pages are numbered from 1.
+type Page struct {
+	Number int `json:"page"`
+	Limit  int `json:"limit"`
+}
+
+// Offset returns how many elements precede the page.
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Limit
+}
+
+// ParsePage extracts the page from the page and limit query parameters,
+// e.g. ?page=2&limit=20. Missing parameters default to the first page
+// of defLimit elements, limit can't exceed maxLimit.
+func ParsePage(r *http.Request, defLimit int, maxLimit int) (Page, error) {
+	p := Page{Number: 1, Limit: defLimit}
+
+	if v := r.URL.Query().Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Page{}, fmt.Errorf("%w: page must be a positive number", ErrInvalidPage)
+		}
+		p.Number = n
+	}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLimit {
+			return Page{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidPage, maxLimit)
+		}
+		p.Limit = n
+	}
+
+	return p, nil
+}
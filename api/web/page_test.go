@@ -0,0 +1,33 @@
+package web
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	r := httptest.NewRequest("GET", "/orders", nil)
+	p, err := ParsePage(r, 20, 100)
+	if err != nil {
+		t.Fatalf("parsing default page: %v", err)
+	}
+	if p != (Page{Number: 1, Limit: 20}) || p.Offset() != 0 {
+		t.Fatalf("wrong default page: %+v", p)
+	}
+
+	r = httptest.NewRequest("GET", "/orders?page=3&limit=10", nil)
+	if p, err = ParsePage(r, 20, 100); err != nil {
+		t.Fatalf("parsing page: %v", err)
+	}
+	if p.Offset() != 20 {
+		t.Fatalf("wrong offset of page %+v: %d", p, p.Offset())
+	}
+
+	for _, q := range []string{"page=0", "page=x", "limit=0", "limit=101"} {
+		r = httptest.NewRequest("GET", "/orders?"+q, nil)
+		if _, err := ParsePage(r, 20, 100); !errors.Is(err, ErrInvalidPage) {
+			t.Fatalf("expected %q to be invalid, got %v", q, err)
+		}
+	}
+}
@@ -7,14 +7,40 @@ import (
 // Config contains all the config parameters useful
 // to setup the whole server components.
 type Config struct {
-	Cors   Cors
-	Web    Web
-	DB     DB
-	Email  Email
-	Paypal Paypal
-	Stripe Stripe
-	Oauth  Oauth
-	Auth   Auth
+	Cors           Cors
+	Web            Web
+	DB             DB
+	Email          Email
+	Paypal         Paypal
+	Stripe         Stripe
+	Razorpay       Razorpay
+	Coinbase       Coinbase
+	Mollie         Mollie
+	Oauth          Oauth
+	Auth           Auth
+	TwoFactor      TwoFactor
+	Webauthn       Webauthn
+	Compensation   Compensation
+	Search         Search
+	Transcoding    Transcoding
+	Captions       Captions
+	Rental         Rental
+	Org            Org
+	StaleOrders    StaleOrders
+	Gifts          Gifts
+	Guests         Guests
+	Reconciliation Reconciliation
+	Outbox         Outbox
+	Alerts         Alerts
+	Refunds        Refunds
+	AbandonedCarts AbandonedCarts
+	Wishlist       Wishlist
+	Media          Media
+	Uploads        Uploads
+	Storage        Storage
+	Resources      Resources
+	Progress       Progress
+	Prerequisites  Prerequisites
 }
 
 // Cors includes parameters for CORS setup.
@@ -45,13 +71,17 @@ type DB struct {
 // Email includes both SMTP information and more business related
 // details which regards the sending of emails.
 type Email struct {
-	Host          string
-	Port          string
-	Address       string
-	Password      string
-	RecoveryURL   string        `conf:"default:http://localhost:3000/password/confirm?token="`
-	ActivationURL string        `conf:"default:http://localhost:3000/activate/confirm?token="`
-	TokenTimeout  time.Duration `conf:"default:10s"`
+	Host           string
+	Port           string
+	Address        string
+	Password       string
+	RecoveryURL    string        `conf:"default:http://localhost:3000/password/confirm?token="`
+	ActivationURL  string        `conf:"default:http://localhost:3000/activate/confirm?token="`
+	ClaimURL       string        `conf:"default:http://localhost:3000/claim/confirm?token="`
+	CourseURL      string        `conf:"default:http://localhost:3000/courses/"`
+	EmailChangeURL string        `conf:"default:http://localhost:3000/email/confirm?token="`
+	LoginURL       string        `conf:"default:http://localhost:3000/login/confirm?token="`
+	TokenTimeout   time.Duration `conf:"default:10s"`
 }
 
 // Stripe contains parameters to setup the Stripe dependency.
@@ -60,6 +90,30 @@ type Stripe struct {
 	WebhookSecret string
 	SuccessURL    string `conf:"default:http://localhost:3000/dashboard"`
 	CancelURL     string `conf:"default:http://localhost:3000/cart"`
+
+	// AutomaticTax enables the calculation of taxes by stripe,
+	// which collects the billing address of the customers.
+	AutomaticTax bool `conf:"default:true"`
+
+	// Installments enables buy now pay later methods, e.g. Klarna and
+	// Afterpay, on the checkout sessions. Their payments are notified
+	// by the async payment events, which the webhook must receive.
+	Installments bool `conf:"default:false"`
+
+	// ExpiredReminder enables emailing users whose checkout expired,
+	// inviting them to complete the purchase.
+	ExpiredReminder bool `conf:"default:false"`
+
+	// PlanPrice is the id of the recurring stripe price of the all-access
+	// plan. Subscriptions are disabled when it is empty.
+	PlanPrice string
+
+	// BillingWebhookSecret signs the events of the subscriptions webhook.
+	BillingWebhookSecret string
+
+	// IntentWebhookSecret signs the events of the payment intents webhook,
+	// used by the payment element.
+	IntentWebhookSecret string
 }
 
 // Paypal contains parameters to setup the Paypal dependency.
@@ -69,7 +123,36 @@ type Paypal struct {
 	URL      string `conf:"default:https://api.sandbox.paypal.com"`
 }
 
+// Razorpay contains parameters to setup the Razorpay dependency.
+type Razorpay struct {
+	KeyID         string
+	KeySecret     string
+	WebhookSecret string
+	URL           string `conf:"default:https://api.razorpay.com"`
+}
+
+// Coinbase contains parameters to setup the Coinbase Commerce dependency.
+type Coinbase struct {
+	APIKey        string
+	WebhookSecret string
+	URL           string `conf:"default:https://api.commerce.coinbase.com"`
+	RedirectURL   string `conf:"default:http://localhost:3000/dashboard"`
+	CancelURL     string `conf:"default:http://localhost:3000/cart"`
+}
+
+// Mollie contains parameters to setup the Mollie dependency.
+// Methods restricts the payment methods offered at checkout, mollie
+// offers all the enabled ones when empty.
+type Mollie struct {
+	APIKey      string
+	URL         string   `conf:"default:https://api.mollie.com"`
+	RedirectURL string   `conf:"default:http://localhost:3000/dashboard"`
+	WebhookURL  string   `conf:"default:http://localhost:8000/orders/mollie/webhook"`
+	Methods     []string `conf:"default:ideal;banktransfer;bancontact"`
+}
+
 // Oauth includes all details needed to setup Oauth authentication.
+// Providers are enabled when their client is set.
 type Oauth struct {
 	DiscoveryTimeout time.Duration `conf:"default:30s"`
 	LoginRedirectURL string        `conf:"default:http://localhost:3000/dashboard"`
@@ -79,9 +162,233 @@ type Oauth struct {
 		URL         string `conf:"default:https://accounts.google.com"`
 		RedirectURL string `conf:"default:http://localhost:8000/auth/oauth-callback/google"`
 	}
+	Github struct {
+		Client      string
+		Secret      string
+		URL         string `conf:"default:https://github.com"`
+		APIURL      string `conf:"default:https://api.github.com"`
+		RedirectURL string `conf:"default:http://localhost:8000/auth/oauth-callback/github"`
+	}
+	// Apple signs in with the services ID as client, the private key
+	// of the team in PEM format as key.
+	Apple struct {
+		Client      string
+		TeamID      string
+		KeyID       string
+		Key         string `conf:"mask"`
+		URL         string `conf:"default:https://appleid.apple.com"`
+		RedirectURL string `conf:"default:http://localhost:8000/auth/oauth-callback/apple"`
+	}
 }
 
 // Auth configures authentication options.
 type Auth struct {
 	ActivationRequired bool `conf:"default:false"`
 }
+
+// TwoFactor configures the two-factor authentication via authenticator
+// apps, shown as Issuer in them. Users having one of the Required roles,
+// e.g. admin, must enable it to login.
+type TwoFactor struct {
+	Issuer   string `conf:"default:Govod"`
+	Required []string
+}
+
+// Webauthn configures the login with passkeys. RPID is the domain the
+// passkeys are scoped to, Origin the one of the site using the API.
+type Webauthn struct {
+	RPID    string        `conf:"default:localhost"`
+	RPName  string        `conf:"default:Govod"`
+	Origin  string        `conf:"default:http://localhost:3000"`
+	Timeout time.Duration `conf:"default:5m"`
+}
+
+// Prerequisites configures whether the videos of a course can be played
+// before completing its prerequisites.
+type Prerequisites struct {
+	Enforced bool `conf:"default:false"`
+}
+
+// Compensation configures the recovery of orders which have been
+// payed but whose fulfillment failed. Backoff doubles at each attempt,
+// up to MaxBackoff.
+type Compensation struct {
+	MaxAttempts int           `conf:"default:5"`
+	Backoff     time.Duration `conf:"default:1m"`
+	MaxBackoff  time.Duration `conf:"default:1h"`
+	Interval    time.Duration `conf:"default:30s"`
+}
+
+// Search configures the engine serving the search endpoints.
+// Engine is either postgres or opensearch, the other parameters
+// are only used by opensearch.
+type Search struct {
+	Engine   string `conf:"default:postgres"`
+	URL      string `conf:"default:http://localhost:9200"`
+	Index    string `conf:"default:tutorialspoint"`
+	Username string
+	Password string `conf:"mask"`
+}
+
+// Transcoding configures the callbacks of the transcoding provider.
+// Callbacks older than Tolerance are rejected to prevent replays.
+type Transcoding struct {
+	WebhookSecret string        `conf:"mask"`
+	Tolerance     time.Duration `conf:"default:5m"`
+}
+
+// Captions configures the automatic generation of captions through
+// a speech-to-text service exposing the Whisper API.
+type Captions struct {
+	Enabled     bool          `conf:"default:false"`
+	URL         string        `conf:"default:https://api.openai.com/v1/audio/transcriptions"`
+	APIKey      string        `conf:"mask"`
+	Model       string        `conf:"default:whisper-1"`
+	Language    string        `conf:"default:en"`
+	MaxAttempts int           `conf:"default:3"`
+	Backoff     time.Duration `conf:"default:5m"`
+	Interval    time.Duration `conf:"default:1m"`
+}
+
+// Rental configures the notices sent to users whose access to
+// rented courses is about to expire.
+type Rental struct {
+	NoticeBefore time.Duration `conf:"default:72h"`
+	Interval     time.Duration `conf:"default:1h"`
+	RenewURL     string        `conf:"default:http://localhost:3000/renew/"`
+}
+
+// Org configures organizations.
+// JoinURL is followed by the organization id in the invitations.
+type Org struct {
+	JoinURL string `conf:"default:http://localhost:3000/orgs/join/"`
+}
+
+// Gifts configures the notification of gifts to their recipients.
+// RedeemURL is followed by the code of the gift in the emails.
+type Gifts struct {
+	Interval  time.Duration `conf:"default:1m"`
+	RedeemURL string        `conf:"default:http://localhost:3000/gifts/redeem/"`
+}
+
+// Guests configures the links sent to the guests who bought without
+// an account, to claim it. Links expire after ClaimTTL.
+type Guests struct {
+	Interval time.Duration `conf:"default:1m"`
+	ClaimTTL time.Duration `conf:"default:72h"`
+}
+
+// StaleOrders configures the expiration of the orders which have
+// been pending for longer than TTL.
+type StaleOrders struct {
+	TTL      time.Duration `conf:"default:6h"`
+	Interval time.Duration `conf:"default:10m"`
+}
+
+// Reconciliation configures the comparison of the payments received
+// by the providers with the orders. Orders created in the last Window
+// are compared, except the ones created in the last Grace.
+type Reconciliation struct {
+	Window   time.Duration `conf:"default:48h"`
+	Grace    time.Duration `conf:"default:1h"`
+	Interval time.Duration `conf:"default:24h"`
+}
+
+// Outbox configures the relay of the events of the outbox to their
+// consumers. Events are posted to WebhookURL as well, if set.
+type Outbox struct {
+	Interval   time.Duration `conf:"default:5s"`
+	Batch      int           `conf:"default:100"`
+	WebhookURL string        `conf:"default:"`
+}
+
+// Alerts configures where the operators are alerted about critical
+// payment failures, e.g. orders payed but not fulfilled. Administrators
+// are emailed if Email is set, Slack and PagerDuty are notified when
+// their webhook URL and routing key are set.
+type Alerts struct {
+	Email        bool   `conf:"default:true"`
+	SlackURL     string `conf:"default:"`
+	PagerDutyKey string `conf:"default:"`
+}
+
+// Refunds configures the refunds asked by the buyers, who can ask for
+// them until Window after the purchase.
+type Refunds struct {
+	Window time.Duration `conf:"default:720h"`
+}
+
+// AbandonedCarts configures the reminders sent to the users whose cart
+// has not been modified for IdleAfter. The links to unsubscribe are
+// signed with Secret, no reminder is sent unless it's set.
+type AbandonedCarts struct {
+	IdleAfter      time.Duration `conf:"default:24h"`
+	Interval       time.Duration `conf:"default:10m"`
+	CartURL        string        `conf:"default:http://localhost:3000/cart"`
+	UnsubscribeURL string        `conf:"default:http://localhost:3000/cart/unsubscribe"`
+	Secret         string
+}
+
+// Wishlist configures how often the users are told about the sales of
+// the courses in their wishlist.
+type Wishlist struct {
+	Interval time.Duration `conf:"default:10m"`
+}
+
+// Media configures the encoding of the videos to HLS with ffmpeg.
+type Media struct {
+	Enabled     bool          `conf:"default:false"`
+	FFmpeg      string        `conf:"default:ffmpeg"`
+	MaxAttempts int           `conf:"default:3"`
+	Backoff     time.Duration `conf:"default:5m"`
+	Interval    time.Duration `conf:"default:1m"`
+}
+
+// Uploads configures the uploads of the videos, sent in chunks of at
+// most ChunkSize bytes. Uploads in progress are written into Dir, then
+// moved to the storage once completed. MaxSize is the largest object
+// S3 accepts in a single request.
+type Uploads struct {
+	Dir       string `conf:"default:uploads"`
+	MaxSize   int64  `conf:"default:5368709120"`
+	ChunkSize int64  `conf:"default:8388608"`
+}
+
+// Storage configures where the files served to the users are stored:
+// Driver is one of disk, s3 or gcs. Files are served at BaseURL, which
+// defaults to the bucket for s3 and gcs. Credentials is the path of
+// the JSON key of the service account used for gcs.
+type Storage struct {
+	Driver  string `conf:"default:disk"`
+	BaseURL string
+	Disk    struct {
+		Dir string `conf:"default:storage"`
+	}
+	S3 struct {
+		Endpoint  string `conf:"default:https://s3.amazonaws.com"`
+		Region    string `conf:"default:us-east-1"`
+		Bucket    string
+		AccessKey string
+		SecretKey string `conf:"mask"`
+	}
+	GCS struct {
+		Bucket      string
+		Credentials string
+	}
+}
+
+// Resources configures the files attached to courses and videos:
+// MaxSize is the largest file accepted, LinkTTL how long the links
+// to download them last.
+type Resources struct {
+	MaxSize int64         `conf:"default:104857600"`
+	LinkTTL time.Duration `conf:"default:15m"`
+}
+
+// Progress limits how often each user reports the progress on videos:
+// once every Interval, with Burst reports in a row. A zero Interval
+// disables the limit.
+type Progress struct {
+	Interval time.Duration `conf:"default:2s"`
+	Burst    int           `conf:"default:10"`
+}
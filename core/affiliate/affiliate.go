@@ -0,0 +1,53 @@
+// Package affiliate manages the referrers who earn a commission on the
+// orders placed with their referral code.
+package affiliate
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+// ErrInvalid is returned when a referral code can't be applied.
+var ErrInvalid = errors.New("invalid referral code")
+
+// Affiliate models a referrer. Commission is the percentage of what the
+// buyers pay for the orders placed with Code.
+type Affiliate struct {
+	ID         string    `json:"id" db:"affiliate_id"`
+	UserID     string    `json:"userId" db:"user_id"`
+	Code       string    `json:"code" db:"code"`
+	Commission int       `json:"commission" db:"commission"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// AffiliateNew contains the information needed to enroll a user as
+// a referrer.
+type AffiliateNew struct {
+	UserID     string `json:"userId" validate:"required,uuid4"`
+	Code       string `json:"code" validate:"required,alphanum,max=32"`
+	Commission int    `json:"commission" validate:"gte=0,lte=100"`
+}
+
+// Earning models what the orders referred in a currency earned.
+// Orders refunded, or their items refunded, earn nothing.
+type Earning struct {
+	Sales      money.Amount `json:"sales" db:"sales"`
+	Commission money.Amount `json:"commission" db:"commission"`
+}
+
+// Stats models the conversions of a referrer: the orders placed with
+// its code which have been payed, and the commission owed for them.
+type Stats struct {
+	Affiliate
+	Conversions int       `json:"conversions"`
+	Earnings    []Earning `json:"earnings"`
+}
+
+// Normalize returns the canonical form of a code, codes are case insensitive.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,101 @@
+package affiliate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleCreate allows administrators to enroll a user as a referrer,
+// with the referral code to share and the commission earned.
+func HandleCreate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var an AffiliateNew
+		if err := web.Decode(w, r, &an); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(an); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if _, err := user.Fetch(ctx, db, an.UserID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NewError(err, "user not found", http.StatusUnprocessableEntity)
+			}
+			return fmt.Errorf("fetching user[%s]: %w", an.UserID, err)
+		}
+
+		now := time.Now().UTC()
+		a := Affiliate{
+			ID:         validate.GenerateID(),
+			UserID:     an.UserID,
+			Code:       Normalize(an.Code),
+			Commission: an.Commission,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+
+		if err := Create(ctx, db, a); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "user already referrer or code already exists", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, a, http.StatusCreated)
+	}
+}
+
+// HandleList allows administrators to list all the referrers.
+func HandleList(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		as, err := FetchAll(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, as, http.StatusOK)
+	}
+}
+
+// HandleShowMine allows referrers to see how many orders they referred
+// and the commission they are owed.
+func HandleShowMine(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		a, err := FetchByUser(ctx, db, clm.UserID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(errors.New("user is not a referrer"))
+			}
+			return err
+		}
+
+		st := Stats{Affiliate: a}
+
+		if st.Conversions, err = CountConversions(ctx, db, a.ID); err != nil {
+			return err
+		}
+
+		if st.Earnings, err = FetchEarnings(ctx, db, a.ID); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, st, http.StatusOK)
+	}
+}
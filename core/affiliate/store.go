@@ -0,0 +1,166 @@
+package affiliate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Create inserts a new affiliate.
+func Create(ctx context.Context, db sqlx.ExtContext, a Affiliate) error {
+	const q = `
+	INSERT INTO affiliates
+		(affiliate_id, user_id, code, commission, created_at, updated_at)
+	VALUES
+		(:affiliate_id, :user_id, :code, :commission, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, a); err != nil {
+		return fmt.Errorf("inserting affiliate: %w", err)
+	}
+
+	return nil
+}
+
+// FetchByCode returns the affiliate with the passed referral code.
+func FetchByCode(ctx context.Context, db sqlx.ExtContext, code string) (Affiliate, error) {
+	in := struct {
+		Code string `db:"code"`
+	}{
+		Code: Normalize(code),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		affiliates
+	WHERE
+		code = :code`
+
+	var a Affiliate
+	if err := database.NamedQueryStruct(ctx, db, q, in, &a); err != nil {
+		return Affiliate{}, fmt.Errorf("selecting affiliate %s: %w", in.Code, err)
+	}
+
+	return a, nil
+}
+
+// FetchByUser returns the affiliate account of the passed user.
+func FetchByUser(ctx context.Context, db sqlx.ExtContext, userID string) (Affiliate, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		affiliates
+	WHERE
+		user_id = :user_id`
+
+	var a Affiliate
+	if err := database.NamedQueryStruct(ctx, db, q, in, &a); err != nil {
+		return Affiliate{}, fmt.Errorf("selecting affiliate of user[%s]: %w", userID, err)
+	}
+
+	return a, nil
+}
+
+// FetchAll returns all the affiliates, the most recent first.
+func FetchAll(ctx context.Context, db sqlx.ExtContext) ([]Affiliate, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		affiliates
+	ORDER BY
+		created_at DESC`
+
+	as := []Affiliate{}
+	if err := database.NamedQuerySlice(ctx, db, q, struct{}{}, &as); err != nil {
+		return nil, fmt.Errorf("selecting affiliates: %w", err)
+	}
+
+	return as, nil
+}
+
+// CountConversions returns how many orders placed with the code of the
+// affiliate have been payed and not refunded.
+func CountConversions(ctx context.Context, db sqlx.ExtContext, affiliateID string) (int, error) {
+	in := struct {
+		ID string `db:"affiliate_id"`
+	}{
+		ID: affiliateID,
+	}
+
+	// The statuses of the orders are spelled out, since the order
+	// package depends on this one.
+	const q = `
+	SELECT
+		COUNT(*) AS conversions
+	FROM
+		orders
+	WHERE
+		affiliate_id = :affiliate_id AND
+		status = 'success'`
+
+	var out struct {
+		Conversions int `db:"conversions"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return 0, fmt.Errorf("counting conversions of affiliate[%s]: %w", affiliateID, err)
+	}
+
+	return out.Conversions, nil
+}
+
+// FetchEarnings returns, for each currency, what the orders placed with
+// the code of the affiliate have been payed and the commission owed,
+// leaving out what has been refunded. The commission is rounded down
+// on each order.
+func FetchEarnings(ctx context.Context, db sqlx.ExtContext, affiliateID string) ([]Earning, error) {
+	in := struct {
+		ID string `db:"affiliate_id"`
+	}{
+		ID: affiliateID,
+	}
+
+	const q = `
+	WITH sales AS (
+		SELECT
+			(i.price).currency AS currency,
+			o.commission,
+			SUM(((i.price).units - COALESCE((i.discount).units, 0)) * i.quantity) AS units
+		FROM
+			orders AS o
+		INNER JOIN
+			order_items AS i ON i.order_id = o.order_id
+		WHERE
+			o.affiliate_id = :affiliate_id AND
+			o.status = 'success' AND
+			i.refunded_at IS NULL
+		GROUP BY
+			o.order_id, 1, 2
+	)
+	SELECT
+		CAST(ROW(CAST(SUM(units) AS BIGINT), currency) AS amount) AS sales,
+		CAST(ROW(CAST(SUM(FLOOR(units * commission / 100)) AS BIGINT), currency) AS amount) AS commission
+	FROM
+		sales
+	GROUP BY
+		currency
+	ORDER BY
+		currency`
+
+	es := []Earning{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &es); err != nil {
+		return nil, fmt.Errorf("selecting earnings of affiliate[%s]: %w", affiliateID, err)
+	}
+
+	return es, nil
+}
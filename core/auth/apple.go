@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// appleSecretTTL is how long the client secrets signed for apple last.
+const appleSecretTTL = 5 * time.Minute
+
+// Apple is the Sign in with Apple provider. It's an OpenID Connect
+// provider with some quirks:
+//   - the client secret is a JWT signed with the private key of the
+//     team, rather than a fixed string;
+//   - the authorization code is posted to the callback, see
+//     HandleOauthFormPost;
+//   - the name of the user is posted along with the code the first time
+//     only, it's not in the ID token;
+//   - users can hide their email behind a private relay address, e.g.
+//     abc123@privaterelay.appleid.com, forwarding to their own. Relay
+//     addresses are verified, so they are linked as any other email.
+type Apple struct {
+	config   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	url      string
+	teamID   string
+	keyID    string
+	key      *ecdsa.PrivateKey
+}
+
+// NewApple discovers the apple provider at the URL of cfg, e.g.
+// https://appleid.apple.com. The secret of cfg is the PEM encoded
+// private key identified by the key ID of cfg.
+func NewApple(ctx context.Context, cfg ProviderConfig) (*Apple, error) {
+	block, _ := pem.Decode([]byte(cfg.Secret))
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+
+	key, ok := k.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an ECDSA key")
+	}
+
+	p, err := oidc.NewProvider(ctx, cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := p.Endpoint()
+	endpoint.AuthStyle = oauth2.AuthStyleInParams
+
+	a := Apple{
+		config: &oauth2.Config{
+			ClientID:    cfg.Client,
+			Endpoint:    endpoint,
+			RedirectURL: cfg.RedirectURL,
+			Scopes:      []string{oidc.ScopeOpenID, "name", "email"},
+		},
+		verifier: p.Verifier(&oidc.Config{ClientID: cfg.Client}),
+		url:      cfg.URL,
+		teamID:   cfg.TeamID,
+		keyID:    cfg.KeyID,
+		key:      key,
+	}
+
+	return &a, nil
+}
+
+// AuthCodeURL implements the Provider interface. Apple requires the
+// form_post response mode when asking for the name and the email.
+func (a *Apple) AuthCodeURL(state string) string {
+	return a.config.AuthCodeURL(state, oauth2.SetAuthURLParam("response_mode", "form_post"))
+}
+
+// UserInfo implements the Provider interface.
+func (a *Apple) UserInfo(ctx context.Context, r *http.Request) (UserInfo, error) {
+	secret, err := a.clientSecret(time.Now().UTC())
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("signing client secret: %w", err)
+	}
+
+	cfg := *a.config
+	cfg.ClientSecret = secret
+
+	tok, err := cfg.Exchange(ctx, r.FormValue("code"))
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("exchanging code: %w", err)
+	}
+
+	// Apple encodes booleans as strings too.
+	var clm struct {
+		Subject       string          `json:"sub"`
+		Email         string          `json:"email"`
+		EmailVerified json.RawMessage `json:"email_verified"`
+	}
+	if err := idClaims(ctx, a.verifier, tok, &clm); err != nil {
+		return UserInfo{}, err
+	}
+
+	info := UserInfo{
+		Subject:       clm.Subject,
+		Email:         clm.Email,
+		EmailVerified: strings.Trim(string(clm.EmailVerified), `"`) == "true",
+	}
+
+	var usr struct {
+		Name struct {
+			FirstName string `json:"firstName"`
+			LastName  string `json:"lastName"`
+		} `json:"name"`
+	}
+	if v := r.FormValue("user"); v != "" {
+		if err := json.Unmarshal([]byte(v), &usr); err != nil {
+			return UserInfo{}, fmt.Errorf("unmarshalling user: %w", err)
+		}
+		info.Name = strings.TrimSpace(usr.Name.FirstName + " " + usr.Name.LastName)
+	}
+
+	return info, nil
+}
+
+// clientSecret returns the client secret to be sent to apple, a JWT
+// signed with the private key of the team via ES256.
+func (a *Apple) clientSecret(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "ES256",
+		"kid": a.keyID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]any{
+		"iss": a.teamID,
+		"iat": now.Unix(),
+		"exp": now.Add(appleSecretTTL).Unix(),
+		"aud": a.url,
+		"sub": a.config.ClientID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, a.key, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	// ES256 signatures are r and s, 32 bytes each.
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
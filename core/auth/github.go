@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/oauth2"
+)
+
+// Github is the github Oauth provider. Github doesn't support OpenID
+// Connect, so users are fetched from its API.
+type Github struct {
+	config *oauth2.Config
+	apiURL string
+}
+
+// NewGithub builds the github provider from cfg, whose URL is the one
+// of the github website, e.g. https://github.com, and APIURL the one of
+// its API, e.g. https://api.github.com.
+func NewGithub(cfg ProviderConfig) *Github {
+	g := Github{
+		config: &oauth2.Config{
+			ClientID:     cfg.Client,
+			ClientSecret: cfg.Secret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  cfg.URL + "/login/oauth/authorize",
+				TokenURL: cfg.URL + "/login/oauth/access_token",
+			},
+			RedirectURL: cfg.RedirectURL,
+			Scopes:      []string{"read:user", "user:email"},
+		},
+		apiURL: cfg.APIURL,
+	}
+
+	return &g
+}
+
+// AuthCodeURL implements the Provider interface.
+func (g *Github) AuthCodeURL(state string) string {
+	return g.config.AuthCodeURL(state)
+}
+
+// UserInfo implements the Provider interface. The email of the user is
+// the primary one, which may be unverified. Users without a name are
+// named after their login.
+func (g *Github) UserInfo(ctx context.Context, r *http.Request) (UserInfo, error) {
+	tok, err := g.config.Exchange(ctx, r.FormValue("code"))
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("exchanging code: %w", err)
+	}
+
+	client := g.config.Client(ctx, tok)
+
+	var usr struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := g.get(ctx, client, "/user", &usr); err != nil {
+		return UserInfo{}, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := g.get(ctx, client, "/user/emails", &emails); err != nil {
+		return UserInfo{}, err
+	}
+
+	info := UserInfo{
+		Subject: strconv.FormatInt(usr.ID, 10),
+		Name:    usr.Name,
+	}
+	if info.Name == "" {
+		info.Name = usr.Login
+	}
+
+	for _, e := range emails {
+		if e.Primary {
+			info.Email = e.Email
+			info.EmailVerified = e.Verified
+		}
+	}
+
+	if info.Email == "" {
+		return UserInfo{}, errors.New("primary email not found")
+	}
+
+	return info, nil
+}
+
+// get fetches the passed path of the github API into dest.
+func (g *Github) get(ctx context.Context, client *http.Client, path string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.apiURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("building request of %s: %w", path, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("requesting %s: status code %s", path, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+
+	return nil
+}
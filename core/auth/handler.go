@@ -5,26 +5,29 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
-	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/jmoiron/sqlx"
 	"github.com/jatolentino/tutorialspoint/api/web"
 	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/config"
 	"github.com/jatolentino/tutorialspoint/core/claims"
 	"github.com/jatolentino/tutorialspoint/core/user"
-	"github.com/jatolentino/tutorialspoint/database"
 	"github.com/jatolentino/tutorialspoint/random"
 	"github.com/jatolentino/tutorialspoint/validate"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const oauthKey = "oauthstate"
+const oauthLinkKey = "oauthlink"
 
 // HandleLogin makes a session for the user if the passed credentials
-// are correct.
-func HandleLogin(db *sqlx.DB, session *scs.SessionManager) web.Handler {
+// are correct. When the second factor is needed, the login is left
+// pending and the step to complete it is returned, see StartSession.
+func HandleLogin(db *sqlx.DB, session *scs.SessionManager, tf config.TwoFactor) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		email, pass, ok := r.BasicAuth()
 		if !ok {
@@ -46,8 +49,13 @@ func HandleLogin(db *sqlx.DB, session *scs.SessionManager) web.Handler {
 			return weberr.NewError(err, err.Error(), http.StatusLocked)
 		}
 
-		if err := SaveUserSession(ctx, session, u.ID, u.Role); err != nil {
-			return fmt.Errorf("store user[%s] in session: %w", u.ID, err)
+		step, err := StartSession(ctx, db, session, u, tf)
+		if err != nil {
+			return err
+		}
+
+		if step != "" {
+			return web.Respond(ctx, w, Pending{TwoFactor: step}, http.StatusAccepted)
 		}
 
 		return web.Respond(ctx, w, nil, http.StatusNoContent)
@@ -58,26 +66,56 @@ func HandleLogin(db *sqlx.DB, session *scs.SessionManager) web.Handler {
 // It returns the URL to complete the authentication on the specified external provider.
 func HandleOauthLogin(session *scs.SessionManager, provs map[string]Provider) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		p := web.Param(r, "provider")
-		prov, ok := provs[p]
-		if !ok {
-			return weberr.NotFound(fmt.Errorf("provider %s not found", p))
-		}
+		session.Remove(ctx, oauthLinkKey)
+		return startOauth(ctx, w, r, session, provs)
+	}
+}
 
-		state, err := random.StringSecure(32)
+// HandleOauthLink starts the Oauth flow to link the account of the
+// specified external provider to the authenticated user, e.g. to sign
+// in with it a user who signed up with a password and another email.
+// It returns the URL to complete the authentication on the provider.
+func HandleOauthLink(session *scs.SessionManager, provs map[string]Provider) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
 		if err != nil {
-			return fmt.Errorf("generating random secure string: %w", err)
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
 		}
 
-		url := prov.AuthCodeURL(state)
+		session.Put(ctx, oauthLinkKey, clm.UserID)
+		return startOauth(ctx, w, r, session, provs)
+	}
+}
+
+// startOauth stores a random state in the session and responds with
+// the URL to authenticate on the provider passed via the provider path
+// parameter.
+func startOauth(ctx context.Context, w http.ResponseWriter, r *http.Request, session *scs.SessionManager, provs map[string]Provider) error {
+	p := web.Param(r, "provider")
+	prov, ok := provs[p]
+	if !ok {
+		return weberr.NotFound(fmt.Errorf("provider %s not found", p))
+	}
 
-		session.Put(ctx, oauthKey, state)
-		return web.Respond(ctx, w, url, http.StatusOK)
+	state, err := random.StringSecure(32)
+	if err != nil {
+		return fmt.Errorf("generating random secure string: %w", err)
 	}
+
+	url := prov.AuthCodeURL(state)
+
+	session.Put(ctx, oauthKey, state)
+	return web.Respond(ctx, w, url, http.StatusOK)
 }
 
-// HandleOauthLogin completes the Oauth flow for the user and creates a new authenticated session.
-func HandleOauthCallback(db *sqlx.DB, session *scs.SessionManager, provs map[string]Provider, redirect string) web.Handler {
+// HandleOauthCallback completes the Oauth flow for the user, exchanging
+// the authorization code, and creates a new authenticated session, the
+// same as HandleLogin. The user is created or linked by email the first
+// time, see oauthUser. When the flow was started by HandleOauthLink,
+// the account is linked to the authenticated user instead. Logins
+// pending the second factor are redirected with the step to complete
+// them in the twoFactor query parameter.
+func HandleOauthCallback(db *sqlx.DB, session *scs.SessionManager, provs map[string]Provider, redirect string, tf config.TwoFactor) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		p := web.Param(r, "provider")
 		prov, ok := provs[p]
@@ -90,72 +128,82 @@ func HandleOauthCallback(db *sqlx.DB, session *scs.SessionManager, provs map[str
 			return weberr.NotAuthorized(fmt.Errorf("invalid state found in session: %+v", scstate))
 		}
 
-		state, code := r.FormValue("state"), r.FormValue("code")
-		if scstate != state {
+		if scstate != r.FormValue("state") {
 			return weberr.NotAuthorized(errors.New("wrong state"))
 		}
 
-		tok, err := prov.Exchange(ctx, code)
+		session.Remove(ctx, oauthKey)
+
+		info, err := prov.UserInfo(ctx, r)
 		if err != nil {
-			return weberr.NotAuthorized(err)
+			return weberr.NotAuthorized(fmt.Errorf("fetching user info from %s: %w", p, err))
 		}
 
-		rawIDTok, ok := tok.Extra("id_token").(string)
-		if err != nil {
-			return weberr.NotAuthorized(errors.New("id token not present"))
+		if info.Subject == "" || info.Email == "" {
+			return fmt.Errorf("subject or email not found in user info: %+v", info)
 		}
 
-		verifier := prov.Verifier(&oidc.Config{ClientID: prov.ClientID})
-		idTok, err := verifier.Verify(ctx, rawIDTok)
-		if err != nil {
-			return weberr.NotAuthorized(errors.New("id token not valid"))
+		// Providers may keep the name private, e.g. apple after the first
+		// login.
+		if info.Name == "" {
+			info.Name, _, _ = strings.Cut(info.Email, "@")
 		}
 
-		info := UserInfo{}
-		if err := idTok.Claims(&info); err != nil {
-			return fmt.Errorf("extracting info from oauth claims: %w", err)
+		if userID := session.PopString(ctx, oauthLinkKey); userID != "" {
+			if err := oauthLink(ctx, db, p, info, userID); err != nil {
+				return err
+			}
+
+			http.Redirect(w, r, redirect, http.StatusFound)
+			return nil
 		}
 
-		if info.Name == "" || info.Email == "" {
-			return fmt.Errorf("name or email not found in idToken claims: %+v", info)
+		u, err := oauthUser(ctx, db, p, info)
+		if err != nil {
+			return err
 		}
 
-		u, err := user.FetchByEmail(ctx, db, info.Email)
+		step, err := StartSession(ctx, db, session, u, tf)
 		if err != nil {
-			// Just fail and return on any unexpected error.
-			if !errors.Is(err, database.ErrDBNotFound) {
-				return fmt.Errorf("fetching user by email %s: %w", info.Email, err)
-			}
+			return err
+		}
 
-			// If user not found instead, create a new user with an unguessable password.
-			// The password can be recovered later on with the dedicated handler.
-			now := time.Now().UTC()
-			pass, err := random.StringSecure(16)
+		if step != "" {
+			ru, err := url.Parse(redirect)
 			if err != nil {
-				return fmt.Errorf("generating random secure string: %w", err)
+				return fmt.Errorf("parsing redirect url %s: %w", redirect, err)
 			}
 
-			u = user.User{
-				ID:           validate.GenerateID(),
-				Name:         info.Name,
-				Email:        info.Email,
-				Role:         claims.RoleUser,
-				PasswordHash: []byte(pass),
-				CreatedAt:    now,
-				UpdatedAt:    now,
-				Active:       true,
-			}
+			q := ru.Query()
+			q.Set("twoFactor", step)
+			ru.RawQuery = q.Encode()
+			redirect = ru.String()
+		}
 
-			if err := user.Create(ctx, db, u); err != nil {
-				return err
-			}
+		http.Redirect(w, r, redirect, http.StatusFound)
+		return nil
+	}
+}
+
+// HandleOauthFormPost redirects the callbacks of the providers posting
+// the authorization code, e.g. apple via the form_post response mode,
+// to HandleOauthCallback. Session cookies are not sent along with posts
+// from other sites, but they are along with the redirect, so that the
+// state of the flow can be checked.
+func HandleOauthFormPost() web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if err := r.ParseForm(); err != nil {
+			return weberr.BadRequest(fmt.Errorf("parsing form: %w", err))
 		}
 
-		if err := SaveUserSession(ctx, session, u.ID, u.Role); err != nil {
-			return fmt.Errorf("store user[%s] in session: %w", u.ID, err)
+		q := make(url.Values)
+		for _, k := range []string{"state", "code", "user", "error"} {
+			if v := r.PostForm.Get(k); v != "" {
+				q.Set(k, v)
+			}
 		}
 
-		http.Redirect(w, r, redirect, http.StatusFound)
+		http.Redirect(w, r, r.URL.Path+"?"+q.Encode(), http.StatusSeeOther)
 		return nil
 	}
 }
@@ -211,7 +259,7 @@ func HandleSignup(db *sqlx.DB, session *scs.SessionManager, activationRequired b
 		}
 
 		if !activationRequired {
-			if err := SaveUserSession(ctx, session, usr.ID, usr.Role); err != nil {
+			if err := SaveUserSession(ctx, db, session, usr.ID, usr.Role); err != nil {
 				return fmt.Errorf("store user[%s] in session: %w", usr.ID, err)
 			}
 		}
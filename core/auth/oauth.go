@@ -2,33 +2,67 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/random"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
 	"golang.org/x/oauth2"
 )
 
 // UserInfo includes the information of a user
-// to be retrieved from external providers.
+// to be retrieved from external providers. Subject identifies the user
+// within the provider, EmailVerified tells whether the provider vouches
+// for the email.
 type UserInfo struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Subject       string `json:"sub"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
+// Kinds of Oauth providers. OpenID Connect providers, e.g. google, are
+// discovered from their URL.
+const (
+	KindOIDC   = "oidc"
+	KindGithub = "github"
+	KindApple  = "apple"
+)
+
 // ProviderConfig contains the information needed
-// to setup an Oauth provider.
+// to setup an Oauth provider. APIURL is needed by the providers whose
+// users are fetched from their API, e.g. github. TeamID and KeyID are
+// needed by apple, which signs the client secrets with the private key
+// passed as Secret.
 type ProviderConfig struct {
 	Name        string
+	Kind        string
 	Client      string
 	Secret      string
 	URL         string
+	APIURL      string
+	TeamID      string
+	KeyID       string
 	RedirectURL string
 }
 
 // Provider wraps an external Oauth provider.
-type Provider struct {
-	*oauth2.Config
-	*oidc.Provider
+type Provider interface {
+	// AuthCodeURL returns the URL of the consent page of the provider,
+	// which redirects back with an authorization code and state.
+	AuthCodeURL(state string) string
+
+	// UserInfo exchanges the authorization code of the callback
+	// request and returns the information of the user who granted it.
+	UserInfo(ctx context.Context, r *http.Request) (UserInfo, error)
 }
 
 // MakeProviders builds supported Oauth providers.
@@ -36,22 +70,213 @@ func MakeProviders(ctx context.Context, cfg []ProviderConfig) (map[string]Provid
 	provs := make(map[string]Provider)
 
 	for _, c := range cfg {
-		p, err := oidc.NewProvider(ctx, c.URL)
-		if err != nil {
-			return nil, fmt.Errorf("loading provider for [%s]: %w", c.Name, err)
+		switch c.Kind {
+		case KindGithub:
+			provs[c.Name] = NewGithub(c)
+		case KindApple:
+			p, err := NewApple(ctx, c)
+			if err != nil {
+				return nil, fmt.Errorf("loading provider for [%s]: %w", c.Name, err)
+			}
+			provs[c.Name] = p
+		case KindOIDC, "":
+			p, err := NewOIDC(ctx, c)
+			if err != nil {
+				return nil, fmt.Errorf("loading provider for [%s]: %w", c.Name, err)
+			}
+			provs[c.Name] = p
+		default:
+			return nil, fmt.Errorf("kind %s of provider [%s] is not supported", c.Kind, c.Name)
+		}
+	}
+
+	return provs, nil
+}
+
+// OIDC is an OpenID Connect provider, whose users are described by the
+// ID token issued with the access token.
+type OIDC struct {
+	config   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDC discovers the OpenID Connect provider at the URL of cfg.
+func NewOIDC(ctx context.Context, cfg ProviderConfig) (*OIDC, error) {
+	p, err := oidc.NewProvider(ctx, cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	o := OIDC{
+		config: &oauth2.Config{
+			ClientID:     cfg.Client,
+			ClientSecret: cfg.Secret,
+			Endpoint:     p.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		},
+		verifier: p.Verifier(&oidc.Config{ClientID: cfg.Client}),
+	}
+
+	return &o, nil
+}
+
+// AuthCodeURL implements the Provider interface.
+func (o *OIDC) AuthCodeURL(state string) string {
+	return o.config.AuthCodeURL(state)
+}
+
+// UserInfo implements the Provider interface.
+func (o *OIDC) UserInfo(ctx context.Context, r *http.Request) (UserInfo, error) {
+	tok, err := o.config.Exchange(ctx, r.FormValue("code"))
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("exchanging code: %w", err)
+	}
+
+	var info UserInfo
+	if err := idClaims(ctx, o.verifier, tok, &info); err != nil {
+		return UserInfo{}, err
+	}
+
+	return info, nil
+}
+
+// idClaims verifies the ID token issued with tok and unmarshals its
+// claims into dest.
+func idClaims(ctx context.Context, verifier *oidc.IDTokenVerifier, tok *oauth2.Token, dest any) error {
+	rawIDTok, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return errors.New("id token not present")
+	}
+
+	idTok, err := verifier.Verify(ctx, rawIDTok)
+	if err != nil {
+		return fmt.Errorf("id token not valid: %w", err)
+	}
+
+	if err := idTok.Claims(dest); err != nil {
+		return fmt.Errorf("extracting info from oauth claims: %w", err)
+	}
+
+	return nil
+}
+
+// errUnverifiedEmail is returned when signing in with an account whose
+// email is not verified by the provider.
+var errUnverifiedEmail = errors.New("email not verified by the provider")
+
+// oauthUser returns the user signing in with the account of provider
+// described by info. Accounts are linked to users the first time they
+// sign in: to the user with the same email, who may have signed up with
+// a password, or to a new user. Since the provider vouches for the
+// email, the user is activated and guests claim their account. Only
+// verified emails are linked, so that nobody takes over the user of an
+// email they don't own.
+func oauthUser(ctx context.Context, db *sqlx.DB, provider string, info UserInfo) (user.User, error) {
+	u, err := user.FetchByIdentity(ctx, db, provider, info.Subject)
+	if err == nil {
+		return u, nil
+	}
+	if !errors.Is(err, database.ErrDBNotFound) {
+		return user.User{}, err
+	}
+
+	if !info.EmailVerified {
+		err := fmt.Errorf("%w: %s on %s", errUnverifiedEmail, info.Email, provider)
+		return user.User{}, weberr.NewError(err, errUnverifiedEmail.Error(), http.StatusForbidden)
+	}
+
+	now := time.Now().UTC()
+
+	err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+		u, err = user.FetchByEmail(ctx, tx, info.Email)
+		switch {
+		case errors.Is(err, database.ErrDBNotFound):
+			// Users signing up via oauth get an unguessable password,
+			// which can be recovered later on with the dedicated handler.
+			pass, err := random.StringSecure(16)
+			if err != nil {
+				return fmt.Errorf("generating random secure string: %w", err)
+			}
+
+			u = user.User{
+				ID:           validate.GenerateID(),
+				Name:         info.Name,
+				Email:        info.Email,
+				Role:         claims.RoleUser,
+				PasswordHash: []byte(pass),
+				CreatedAt:    now,
+				UpdatedAt:    now,
+				Active:       true,
+			}
+
+			if err := user.Create(ctx, tx, u); err != nil {
+				return fmt.Errorf("creating user[%s]: %w", info.Email, err)
+			}
+		case err != nil:
+			return fmt.Errorf("fetching user by email %s: %w", info.Email, err)
+		case u.Role == claims.RoleGuest || !u.Active:
+			if u.Role == claims.RoleGuest {
+				u.Name = info.Name
+				u.Role = claims.RoleUser
+			}
+			u.Active = true
+			u.UpdatedAt = now
+			if u, err = user.Update(ctx, tx, u); err != nil {
+				return fmt.Errorf("claiming user[%s]: %w", info.Email, err)
+			}
+		}
+
+		id := user.Identity{
+			Provider:  provider,
+			Subject:   info.Subject,
+			UserID:    u.ID,
+			Email:     info.Email,
+			CreatedAt: now,
 		}
+		return user.CreateIdentity(ctx, tx, id)
+	})
 
-		provs[c.Name] = Provider{
-			Provider: p,
-			Config: &oauth2.Config{
-				ClientID:     c.Client,
-				ClientSecret: c.Secret,
-				Endpoint:     p.Endpoint(),
-				RedirectURL:  c.RedirectURL,
-				Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
-			},
+	if err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			err := fmt.Errorf("linking %s account of %s: %w", provider, info.Email, err)
+			return user.User{}, weberr.NewError(err, "another "+provider+" account is linked to the user", http.StatusConflict)
 		}
+		return user.User{}, err
 	}
 
-	return provs, nil
+	return u, nil
+}
+
+// oauthLink links the account of provider described by info to the
+// user with the passed id. Accounts are linked to a single user, and a
+// user has a single account of each provider.
+func oauthLink(ctx context.Context, db *sqlx.DB, provider string, info UserInfo, userID string) error {
+	u, err := user.FetchByIdentity(ctx, db, provider, info.Subject)
+	switch {
+	case err == nil && u.ID == userID:
+		return nil
+	case err == nil:
+		err := fmt.Errorf("%s account of %s is linked to user[%s]", provider, info.Email, u.ID)
+		return weberr.NewError(err, "account linked to another user", http.StatusConflict)
+	case !errors.Is(err, database.ErrDBNotFound):
+		return err
+	}
+
+	id := user.Identity{
+		Provider:  provider,
+		Subject:   info.Subject,
+		UserID:    userID,
+		Email:     info.Email,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := user.CreateIdentity(ctx, db, id); err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			return weberr.NewError(err, "another "+provider+" account is linked to the user", http.StatusConflict)
+		}
+		return err
+	}
+
+	return nil
 }
@@ -13,16 +13,26 @@ import (
 	"github.com/alexedwards/scs/v2"
 	"github.com/jatolentino/tutorialspoint/api/web"
 	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/cart"
 	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jmoiron/sqlx"
 )
 
 const userKey = "userID"
 const roleKey = "role"
 
 // SaveUserSession saves the passed user in the current session.
-func SaveUserSession(ctx context.Context, session *scs.SessionManager, userID string, role string) error {
+// The anonymous cart of the session, if any, is merged into the cart
+// of the user, and the login pending the second factor, if any, is
+// completed.
+func SaveUserSession(ctx context.Context, db *sqlx.DB, session *scs.SessionManager, userID string, role string) error {
+	if err := cart.Merge(ctx, db, session, userID); err != nil {
+		return fmt.Errorf("merging anonymous cart: %w", err)
+	}
+
 	session.Put(ctx, userKey, userID)
 	session.Put(ctx, roleKey, role)
+	session.Remove(ctx, totpKey)
 	if err := session.RenewToken(ctx); err != nil {
 		return fmt.Errorf("renewing token: %w", err)
 	}
@@ -53,6 +63,25 @@ func Authenticate(s *scs.SessionManager) web.Middleware {
 	return m
 }
 
+// Identify returns a middleware intended for routes open to visitors
+// too. Claims are set when the user is authenticated, nothing is
+// refused otherwise.
+func Identify(s *scs.SessionManager) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			uid, uok := s.Get(ctx, userKey).(string)
+			role, rok := s.Get(ctx, roleKey).(string)
+			if uok && rok {
+				ctx = claims.Set(ctx, claims.Claims{UserID: uid, Role: role})
+			}
+
+			return handler(ctx, w, r)
+		}
+		return h
+	}
+	return m
+}
+
 // Authenticate returns a middleware intended to protect
 // routes which require an administrator.
 func Admin(s *scs.SessionManager) web.Middleware {
@@ -74,6 +103,28 @@ func Admin(s *scs.SessionManager) web.Middleware {
 	return m
 }
 
+// Instructor returns a middleware intended to protect routes which
+// manage the catalog, open to administrators and instructors. Handlers
+// check that instructors manage only their own courses.
+func Instructor(s *scs.SessionManager) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			role, ok := s.Get(ctx, roleKey).(string)
+			if !ok {
+				return weberr.NotAuthorized(errors.New("no user role in session"))
+			}
+
+			if role != claims.RoleAdmin && role != claims.RoleInstructor {
+				return weberr.NotAuthorized(fmt.Errorf("user role is neither admin nor instructor: %s", role))
+			}
+
+			return handler(ctx, w, r)
+		}
+		return h
+	}
+	return m
+}
+
 // LoadAndSave updates the user's session if there was
 // a change.
 func LoadAndSave(s *scs.SessionManager) web.Middleware {
@@ -90,7 +141,24 @@ func LoadAndSave(s *scs.SessionManager) web.Middleware {
 				return err
 			}
 
-			bw := &bufferedResponseWriter{ResponseWriter: w}
+			commit := func() error {
+				switch s.Status(ctx) {
+				case scs.Modified:
+					token, expiry, err := s.Commit(ctx)
+					if err != nil {
+						return err
+					}
+
+					s.WriteSessionCookie(ctx, w, token, expiry)
+				case scs.Destroyed:
+					s.WriteSessionCookie(ctx, w, "", time.Time{})
+				}
+
+				w.Header().Add("Vary", "Cookie")
+				return nil
+			}
+
+			bw := &bufferedResponseWriter{ResponseWriter: w, commit: commit}
 			if err := handler(ctx, bw, r); err != nil {
 				return err
 			}
@@ -99,19 +167,18 @@ func LoadAndSave(s *scs.SessionManager) web.Middleware {
 				r.MultipartForm.RemoveAll()
 			}
 
-			switch s.Status(ctx) {
-			case scs.Modified:
-				token, expiry, err := s.Commit(ctx)
-				if err != nil {
-					return err
-				}
+			if bw.err != nil {
+				return bw.err
+			}
 
-				s.WriteSessionCookie(ctx, w, token, expiry)
-			case scs.Destroyed:
-				s.WriteSessionCookie(ctx, w, "", time.Time{})
+			// The session was saved when the response was flushed.
+			if bw.flushed {
+				return nil
 			}
 
-			w.Header().Add("Vary", "Cookie")
+			if err := commit(); err != nil {
+				return err
+			}
 
 			if bw.code != 0 {
 				w.WriteHeader(bw.code)
@@ -125,24 +192,59 @@ func LoadAndSave(s *scs.SessionManager) web.Middleware {
 	return m
 }
 
+// bufferedResponseWriter holds the response until the session is saved,
+// since its cookie must be written before the body. Handlers streaming
+// large bodies flush it once their headers are set, which saves the
+// session and then writes straight to the client.
 type bufferedResponseWriter struct {
 	http.ResponseWriter
 	buf         bytes.Buffer
 	code        int
 	wroteHeader bool
+	commit      func() error
+	flushed     bool
+	err         error
 }
 
 func (bw *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if bw.flushed {
+		return bw.ResponseWriter.Write(b)
+	}
 	return bw.buf.Write(b)
 }
 
 func (bw *bufferedResponseWriter) WriteHeader(code int) {
+	if bw.flushed {
+		bw.ResponseWriter.WriteHeader(code)
+		return
+	}
 	if !bw.wroteHeader {
 		bw.code = code
 		bw.wroteHeader = true
 	}
 }
 
+// Flush implements http.Flusher. The response stays buffered when the
+// session can't be saved, so that the error is returned instead.
+func (bw *bufferedResponseWriter) Flush() {
+	if !bw.flushed {
+		if bw.err = bw.commit(); bw.err != nil {
+			return
+		}
+		bw.flushed = true
+
+		if bw.code != 0 {
+			bw.ResponseWriter.WriteHeader(bw.code)
+		}
+		bw.ResponseWriter.Write(bw.buf.Bytes())
+		bw.buf.Reset()
+	}
+
+	if f, ok := bw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func (bw *bufferedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	hj := bw.ResponseWriter.(http.Hijacker)
 	return hj.Hijack()
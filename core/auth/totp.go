@@ -0,0 +1,452 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/totp"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/rate"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// totpKey holds the user whose login is pending the second factor.
+const totpKey = "totpUserID"
+
+// Steps of the logins pending the second factor: users verify a code of
+// their authenticator app, or enroll one when their role requires it.
+const (
+	StepVerify = "verify"
+	StepEnroll = "enroll"
+)
+
+// Pending is the response of the logins pending the second factor.
+type Pending struct {
+	TwoFactor string `json:"twoFactor"`
+}
+
+// errWrongCode is returned when the code of the authenticator app, or
+// the recovery code, is not valid.
+var errWrongCode = errors.New("wrong code")
+
+// StartSession logs the user in, unless the second factor is needed:
+// users who enabled two-factor authentication, or whose role requires
+// it, are left pending until they verify a code or enroll their
+// authenticator app. It returns the step pending, if any.
+func StartSession(ctx context.Context, db *sqlx.DB, session *scs.SessionManager, u user.User, tf config.TwoFactor) (string, error) {
+	var step string
+
+	t, err := totp.Fetch(ctx, db, u.ID)
+	switch {
+	case err == nil && t.Confirmed():
+		step = StepVerify
+	case err != nil && !errors.Is(err, database.ErrDBNotFound):
+		return "", fmt.Errorf("fetching totp of user[%s]: %w", u.ID, err)
+	case slices.Contains(tf.Required, u.Role):
+		step = StepEnroll
+	default:
+		if err := SaveUserSession(ctx, db, session, u.ID, u.Role); err != nil {
+			return "", fmt.Errorf("store user[%s] in session: %w", u.ID, err)
+		}
+		return "", nil
+	}
+
+	session.Remove(ctx, userKey)
+	session.Remove(ctx, roleKey)
+	session.Put(ctx, totpKey, u.ID)
+	if err := session.RenewToken(ctx); err != nil {
+		return "", fmt.Errorf("renewing token: %w", err)
+	}
+
+	return step, nil
+}
+
+// totpUser returns the authenticated user, or the one whose login is
+// pending the second factor, who must be able to enroll their
+// authenticator app.
+func totpUser(ctx context.Context, session *scs.SessionManager) (string, bool, error) {
+	if id := session.GetString(ctx, userKey); id != "" {
+		return id, false, nil
+	}
+
+	if id := session.GetString(ctx, totpKey); id != "" {
+		return id, true, nil
+	}
+
+	return "", false, weberr.NotAuthorized(errors.New("user not authenticated"))
+}
+
+// newCodeLimiter returns the rate limiter of the attempts to enter a
+// code, which would be guessed otherwise.
+func newCodeLimiter() *rate.Limiter {
+	return rate.NewLimiter(5, 10, rate.Every(time.Minute))
+}
+
+// HandleVerifyTOTP completes the login pending the second factor, if
+// the passed code of the authenticator app, or recovery code, is valid.
+// This function leverages a rate limiter to avoid guessing codes.
+func HandleVerifyTOTP(db *sqlx.DB, session *scs.SessionManager) web.Handler {
+	limiter := newCodeLimiter()
+
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Code         string `json:"code" validate:"required_without=RecoveryCode"`
+			RecoveryCode string `json:"recoveryCode" validate:"required_without=Code"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		userID := session.GetString(ctx, totpKey)
+		if userID == "" {
+			return weberr.NotAuthorized(errors.New("no login pending the second factor"))
+		}
+
+		if !limiter.Check(userID) {
+			err := errors.New("too many requests")
+			return weberr.NewError(err, err.Error(), http.StatusTooManyRequests)
+		}
+
+		t, err := fetchConfirmed(ctx, db, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkCodes(ctx, db, t, in.Code, in.RecoveryCode); err != nil {
+			if errors.Is(err, errWrongCode) {
+				return weberr.NotAuthorized(err)
+			}
+			return err
+		}
+
+		u, err := user.Fetch(ctx, db, userID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", userID, err)
+		}
+
+		if err := SaveUserSession(ctx, db, session, u.ID, u.Role); err != nil {
+			return fmt.Errorf("store user[%s] in session: %w", u.ID, err)
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleEnrollTOTP generates a new secret for the authenticator app of
+// the user, to be confirmed via HandleConfirmTOTP. Users whose login is
+// pending the enrollment, required by their role, can enroll too.
+func HandleEnrollTOTP(db *sqlx.DB, session *scs.SessionManager, tf config.TwoFactor) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID, _, err := totpUser(ctx, session)
+		if err != nil {
+			return err
+		}
+
+		u, err := user.Fetch(ctx, db, userID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", userID, err)
+		}
+
+		t, err := totp.Fetch(ctx, db, userID)
+		if err != nil && !errors.Is(err, database.ErrDBNotFound) {
+			return fmt.Errorf("fetching totp of user[%s]: %w", userID, err)
+		}
+
+		if t.Confirmed() {
+			err := fmt.Errorf("user[%s] has two-factor authentication enabled", userID)
+			return weberr.NewError(err, "two-factor authentication enabled already", http.StatusConflict)
+		}
+
+		secret, err := totp.NewSecret()
+		if err != nil {
+			return fmt.Errorf("generating totp secret: %w", err)
+		}
+
+		t = totp.TOTP{
+			UserID:    userID,
+			Secret:    secret,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		if err := totp.Save(ctx, db, t); err != nil {
+			return fmt.Errorf("saving totp of user[%s]: %w", userID, err)
+		}
+
+		enr := totp.Enrollment{
+			Secret: secret,
+			URI:    totp.URI(tf.Issuer, u.Email, secret),
+		}
+
+		return web.Respond(ctx, w, enr, http.StatusCreated)
+	}
+}
+
+// HandleConfirmTOTP enables two-factor authentication for the user, if
+// the passed code of the authenticator app just enrolled is valid, and
+// responds with the recovery codes. The login pending the enrollment,
+// if any, is completed.
+// This function leverages a rate limiter to avoid guessing codes.
+func HandleConfirmTOTP(db *sqlx.DB, session *scs.SessionManager) web.Handler {
+	limiter := newCodeLimiter()
+
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Code string `json:"code" validate:"required"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		userID, pending, err := totpUser(ctx, session)
+		if err != nil {
+			return err
+		}
+
+		if !limiter.Check(userID) {
+			err := errors.New("too many requests")
+			return weberr.NewError(err, err.Error(), http.StatusTooManyRequests)
+		}
+
+		t, err := totp.Fetch(ctx, db, userID)
+		if err != nil {
+			err := fmt.Errorf("fetching totp of user[%s]: %w", userID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if t.Confirmed() {
+			err := fmt.Errorf("user[%s] has two-factor authentication enabled", userID)
+			return weberr.NewError(err, "two-factor authentication enabled already", http.StatusConflict)
+		}
+
+		codes, hashes, err := totp.NewRecoveryCodes()
+		if err != nil {
+			return fmt.Errorf("generating recovery codes: %w", err)
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := checkCode(ctx, tx, t, in.Code); err != nil {
+				return err
+			}
+
+			return totp.ReplaceRecoveryCodes(ctx, tx, userID, hashes)
+		})
+
+		if err != nil {
+			if errors.Is(err, errWrongCode) {
+				return weberr.NewError(err, err.Error(), http.StatusForbidden)
+			}
+			return err
+		}
+
+		if pending {
+			u, err := user.Fetch(ctx, db, userID)
+			if err != nil {
+				return fmt.Errorf("fetching user[%s]: %w", userID, err)
+			}
+
+			if err := SaveUserSession(ctx, db, session, u.ID, u.Role); err != nil {
+				return fmt.Errorf("store user[%s] in session: %w", u.ID, err)
+			}
+		}
+
+		return web.Respond(ctx, w, totp.Recovery{Codes: codes}, http.StatusOK)
+	}
+}
+
+// HandleRecoveryCodes replaces the recovery codes of the user, e.g.
+// once they were used, if the passed code of the authenticator app is
+// valid.
+// This function leverages a rate limiter to avoid guessing codes.
+func HandleRecoveryCodes(db *sqlx.DB) web.Handler {
+	limiter := newCodeLimiter()
+
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Code string `json:"code" validate:"required"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if !limiter.Check(clm.UserID) {
+			err := errors.New("too many requests")
+			return weberr.NewError(err, err.Error(), http.StatusTooManyRequests)
+		}
+
+		t, err := fetchConfirmed(ctx, db, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		codes, hashes, err := totp.NewRecoveryCodes()
+		if err != nil {
+			return fmt.Errorf("generating recovery codes: %w", err)
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := checkCode(ctx, tx, t, in.Code); err != nil {
+				return err
+			}
+
+			return totp.ReplaceRecoveryCodes(ctx, tx, clm.UserID, hashes)
+		})
+
+		if err != nil {
+			if errors.Is(err, errWrongCode) {
+				return weberr.NewError(err, err.Error(), http.StatusForbidden)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, totp.Recovery{Codes: codes}, http.StatusOK)
+	}
+}
+
+// HandleDisableTOTP disables two-factor authentication for the user, if
+// the passed code of the authenticator app, or recovery code, is valid.
+// It's refused to the users whose role requires it.
+// This function leverages a rate limiter to avoid guessing codes.
+func HandleDisableTOTP(db *sqlx.DB, tf config.TwoFactor) web.Handler {
+	limiter := newCodeLimiter()
+
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Code         string `json:"code" validate:"required_without=RecoveryCode"`
+			RecoveryCode string `json:"recoveryCode" validate:"required_without=Code"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if slices.Contains(tf.Required, clm.Role) {
+			err := fmt.Errorf("two-factor authentication is required for role %s", clm.Role)
+			return weberr.NewError(err, err.Error(), http.StatusForbidden)
+		}
+
+		if !limiter.Check(clm.UserID) {
+			err := errors.New("too many requests")
+			return weberr.NewError(err, err.Error(), http.StatusTooManyRequests)
+		}
+
+		t, err := fetchConfirmed(ctx, db, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := checkCodes(ctx, tx, t, in.Code, in.RecoveryCode); err != nil {
+				return err
+			}
+
+			return totp.Delete(ctx, tx, clm.UserID)
+		})
+
+		if err != nil {
+			if errors.Is(err, errWrongCode) {
+				return weberr.NewError(err, err.Error(), http.StatusForbidden)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// fetchConfirmed returns the authenticator app of the user, as long as
+// two-factor authentication is enabled.
+func fetchConfirmed(ctx context.Context, db sqlx.ExtContext, userID string) (totp.TOTP, error) {
+	t, err := totp.Fetch(ctx, db, userID)
+	if err != nil && !errors.Is(err, database.ErrDBNotFound) {
+		return totp.TOTP{}, fmt.Errorf("fetching totp of user[%s]: %w", userID, err)
+	}
+
+	if !t.Confirmed() {
+		err := fmt.Errorf("user[%s] has two-factor authentication disabled", userID)
+		return totp.TOTP{}, weberr.NewError(err, "two-factor authentication not enabled", http.StatusNotFound)
+	}
+
+	return t, nil
+}
+
+// checkCodes checks the code of the authenticator app, or the recovery
+// code if that's passed instead.
+func checkCodes(ctx context.Context, db sqlx.ExtContext, t totp.TOTP, code string, recovery string) error {
+	if code != "" {
+		return checkCode(ctx, db, t, code)
+	}
+
+	hash := totp.HashRecoveryCode(recovery)
+	if err := totp.UseRecoveryCode(ctx, db, t.UserID, hash, time.Now().UTC()); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return errWrongCode
+		}
+		return err
+	}
+
+	return nil
+}
+
+// checkCode checks the code of the authenticator app and records it,
+// confirming the authenticator app if not yet, so that it can't be
+// used again.
+func checkCode(ctx context.Context, db sqlx.ExtContext, t totp.TOTP, code string) error {
+	now := time.Now().UTC()
+
+	counter, ok := totp.Validate(t.Secret, code, now, t.LastCounter)
+	if !ok {
+		return errWrongCode
+	}
+
+	if err := totp.Use(ctx, db, t.UserID, counter, now); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return errWrongCode
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+// Package bundle manages the sets of courses sold together
+// at a bundled price.
+package bundle
+
+import (
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+// Bundle models a named set of courses sold at Price, usually lower
+// than the sum of the prices of its courses. Buying a bundle grants
+// each of its courses.
+type Bundle struct {
+	ID          string       `json:"id" db:"bundle_id"`
+	Name        string       `json:"name" db:"name"`
+	Description string       `json:"description" db:"description"`
+	Price       money.Amount `json:"price" db:"price"`
+	CourseIDs   []string     `json:"courseIds" db:"-"`
+	CreatedAt   time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updatedAt" db:"updated_at"`
+}
+
+// BundleNew contains the information needed to create a bundle.
+type BundleNew struct {
+	Name        string       `json:"name" validate:"required"`
+	Description string       `json:"description"`
+	Price       money.Amount `json:"price"`
+	CourseIDs   []string     `json:"courseIds" validate:"min=2,unique,dive,uuid4"`
+}
+
+// BundleUp contains the information of a bundle that can be updated.
+type BundleUp struct {
+	Name        *string       `json:"name"`
+	Description *string       `json:"description"`
+	Price       *money.Amount `json:"price"`
+	CourseIDs   []string      `json:"courseIds" validate:"omitempty,min=2,unique,dive,uuid4"`
+}
@@ -0,0 +1,190 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errInvalidCourses is returned when the courses of a bundle can't
+// be sold together at its price.
+var errInvalidCourses = errors.New("invalid bundle courses")
+
+// checkCourses makes sure the courses of the bundle exist and are
+// sold in the currency of the bundle.
+func checkCourses(ctx context.Context, db sqlx.ExtContext, b Bundle) error {
+	for _, id := range b.CourseIDs {
+		c, err := course.Fetch(ctx, db, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return fmt.Errorf("%w: course[%s] not found", errInvalidCourses, id)
+			}
+			return err
+		}
+
+		if c.Price.Currency != b.Price.Currency {
+			return fmt.Errorf("%w: course[%s] is sold in %s", errInvalidCourses, id, c.Price.Currency)
+		}
+	}
+	return nil
+}
+
+// HandleCreate allows administrators to create a bundle.
+func HandleCreate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var bn BundleNew
+		if err := web.Decode(w, r, &bn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(bn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		now := time.Now().UTC()
+		b := Bundle{
+			ID:          validate.GenerateID(),
+			Name:        bn.Name,
+			Description: bn.Description,
+			Price:       bn.Price,
+			CourseIDs:   bn.CourseIDs,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := checkCourses(ctx, tx, b); err != nil {
+				return err
+			}
+			return Create(ctx, tx, b)
+		})
+		if err != nil {
+			if errors.Is(err, errInvalidCourses) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, b, http.StatusCreated)
+	}
+}
+
+// HandleList returns all the bundles.
+func HandleList(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		bs, err := FetchAll(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, bs, http.StatusOK)
+	}
+}
+
+// HandleShow returns a bundle and its courses.
+func HandleShow(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := web.Param(r, "id")
+
+		if err := validate.CheckID(id); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		b, err := Fetch(ctx, db, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, b, http.StatusOK)
+	}
+}
+
+// HandleUpdate allows administrators to update a bundle. Passing the
+// courses replaces all of them. Orders already placed are not affected.
+func HandleUpdate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := web.Param(r, "id")
+
+		if err := validate.CheckID(id); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var bu BundleUp
+		if err := web.Decode(w, r, &bu); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(bu); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		b, err := Fetch(ctx, db, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if bu.Name != nil {
+			b.Name = *bu.Name
+		}
+		if bu.Description != nil {
+			b.Description = *bu.Description
+		}
+		if bu.Price != nil {
+			b.Price = *bu.Price
+		}
+		if bu.CourseIDs != nil {
+			b.CourseIDs = bu.CourseIDs
+		}
+		b.UpdatedAt = time.Now().UTC()
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := checkCourses(ctx, tx, b); err != nil {
+				return err
+			}
+			return Update(ctx, tx, b)
+		})
+		if err != nil {
+			if errors.Is(err, errInvalidCourses) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, b, http.StatusOK)
+	}
+}
+
+// HandleDelete allows administrators to delete a bundle.
+func HandleDelete(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := web.Param(r, "id")
+
+		if err := validate.CheckID(id); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := Delete(ctx, db, id); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,198 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Create inserts a new bundle together with its courses.
+func Create(ctx context.Context, db sqlx.ExtContext, b Bundle) error {
+	const q = `
+	INSERT INTO bundles
+		(bundle_id, name, description, price, created_at, updated_at)
+	VALUES
+		(:bundle_id, :name, :description, :price, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, b); err != nil {
+		return fmt.Errorf("inserting bundle: %w", err)
+	}
+
+	return SetCourses(ctx, db, b.ID, b.CourseIDs)
+}
+
+// Update updates the details of a bundle and replaces its courses.
+func Update(ctx context.Context, db sqlx.ExtContext, b Bundle) error {
+	const q = `
+	UPDATE bundles
+	SET
+		name = :name,
+		description = :description,
+		price = :price,
+		updated_at = :updated_at
+	WHERE
+		bundle_id = :bundle_id`
+
+	if err := database.NamedExecContext(ctx, db, q, b); err != nil {
+		return fmt.Errorf("updating bundle[%s]: %w", b.ID, err)
+	}
+
+	return SetCourses(ctx, db, b.ID, b.CourseIDs)
+}
+
+// SetCourses replaces the courses of a bundle.
+func SetCourses(ctx context.Context, db sqlx.ExtContext, bundleID string, courseIDs []string) error {
+	in := struct {
+		BundleID string `db:"bundle_id"`
+	}{
+		BundleID: bundleID,
+	}
+
+	const q = `
+	DELETE FROM
+		bundle_courses
+	WHERE
+		bundle_id = :bundle_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("deleting courses of bundle[%s]: %w", bundleID, err)
+	}
+
+	for _, id := range courseIDs {
+		in := struct {
+			BundleID string `db:"bundle_id"`
+			CourseID string `db:"course_id"`
+		}{
+			BundleID: bundleID,
+			CourseID: id,
+		}
+
+		const q = `
+		INSERT INTO bundle_courses
+			(bundle_id, course_id)
+		VALUES
+			(:bundle_id, :course_id)`
+
+		if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+			return fmt.Errorf("adding course[%s] to bundle[%s]: %w", id, bundleID, err)
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a bundle. Orders keep the courses bought with it.
+func Delete(ctx context.Context, db sqlx.ExtContext, id string) error {
+	in := struct {
+		ID string `db:"bundle_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	DELETE FROM
+		bundles
+	WHERE
+		bundle_id = :bundle_id
+	RETURNING bundle_id`
+
+	var out struct {
+		ID string `db:"bundle_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("deleting bundle[%s]: %w", id, err)
+	}
+
+	return nil
+}
+
+// Fetch returns the bundle with the passed id, together with its courses.
+func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Bundle, error) {
+	in := struct {
+		ID string `db:"bundle_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		bundles
+	WHERE
+		bundle_id = :bundle_id`
+
+	var b Bundle
+	if err := database.NamedQueryStruct(ctx, db, q, in, &b); err != nil {
+		return Bundle{}, fmt.Errorf("selecting bundle[%s]: %w", id, err)
+	}
+
+	ids, err := FetchCourseIDs(ctx, db, id)
+	if err != nil {
+		return Bundle{}, err
+	}
+	b.CourseIDs = ids
+
+	return b, nil
+}
+
+// FetchAll returns all the bundles, together with their courses.
+func FetchAll(ctx context.Context, db sqlx.ExtContext) ([]Bundle, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		bundles
+	ORDER BY
+		name`
+
+	bs := []Bundle{}
+	if err := database.NamedQuerySlice(ctx, db, q, struct{}{}, &bs); err != nil {
+		return nil, fmt.Errorf("selecting bundles: %w", err)
+	}
+
+	for i := range bs {
+		ids, err := FetchCourseIDs(ctx, db, bs[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		bs[i].CourseIDs = ids
+	}
+
+	return bs, nil
+}
+
+// FetchCourseIDs returns the ids of the courses of a bundle.
+func FetchCourseIDs(ctx context.Context, db sqlx.ExtContext, bundleID string) ([]string, error) {
+	in := struct {
+		ID string `db:"bundle_id"`
+	}{
+		ID: bundleID,
+	}
+
+	const q = `
+	SELECT
+		course_id
+	FROM
+		bundle_courses
+	WHERE
+		bundle_id = :bundle_id
+	ORDER BY
+		course_id`
+
+	var rows []struct {
+		ID string `db:"course_id"`
+	}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rows); err != nil {
+		return nil, fmt.Errorf("selecting courses of bundle[%s]: %w", bundleID, err)
+	}
+
+	ids := make([]string, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,59 @@
+// Package caption manages the captions of videos. Captions are either
+// written by instructors or generated by a speech-to-text service, in
+// which case they must be reviewed before being trusted.
+package caption
+
+import "time"
+
+// Sources of captions.
+const (
+	SourceManual  = "manual"
+	SourceMachine = "machine"
+)
+
+// Caption models a WebVTT track of a video in a specific language.
+type Caption struct {
+	VideoID   string    `json:"videoId" db:"video_id"`
+	Language  string    `json:"language" db:"language"`
+	VTT       string    `json:"vtt" db:"vtt"`
+	Source    string    `json:"source" db:"source"`
+	Reviewed  bool      `json:"reviewed" db:"reviewed"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// JobStatus represents the status of a captioning job.
+type JobStatus string
+
+// Statuses of captioning jobs.
+const (
+	JobPending JobStatus = "pending"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+// Job models the request of generating the captions of a video,
+// whose media is at URL.
+type Job struct {
+	VideoID   string    `db:"video_id"`
+	URL       string    `db:"url"`
+	Status    JobStatus `db:"status"`
+	Attempts  int       `db:"attempts"`
+	LastError string    `db:"last_error"`
+	NextRunAt time.Time `db:"next_run_at"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Formats of the caption tracks written by instructors. SRT tracks are
+// converted to WebVTT, the format rendered by players.
+const (
+	FormatVTT = "vtt"
+	FormatSRT = "srt"
+)
+
+// CaptionUp contains the track of a caption written by instructors.
+type CaptionUp struct {
+	Format  string `json:"format" validate:"required,oneof=vtt srt"`
+	Content string `json:"content" validate:"required,max=1048576"`
+}
@@ -0,0 +1,40 @@
+package caption
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// srtTiming matches the timestamps of SRT cues, `00:00:01,000`.
+var srtTiming = regexp.MustCompile(`(\d{2}:\d{2}:\d{2}),(\d{3})`)
+
+// toVTT returns the track in content, in the passed format, as WebVTT.
+// SRT cues are kept as they are but for the decimal separator of their
+// timestamps, their numbers being valid WebVTT cue identifiers.
+func toVTT(format string, content string) (string, error) {
+	content = strings.TrimPrefix(content, "\uFEFF")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	if format == FormatVTT {
+		if !strings.HasPrefix(content, "WEBVTT") {
+			return "", errors.New("webvtt tracks must start with WEBVTT")
+		}
+		return content, nil
+	}
+
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	cues := 0
+	for i, l := range lines {
+		if strings.Contains(l, "-->") {
+			lines[i] = srtTiming.ReplaceAllString(l, "$1.$2")
+			cues++
+		}
+	}
+
+	if cues == 0 {
+		return "", errors.New("srt tracks must contain cues")
+	}
+
+	return "WEBVTT\n\n" + strings.Join(lines, "\n") + "\n", nil
+}
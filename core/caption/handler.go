@@ -0,0 +1,149 @@
+package caption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// languageTag matches the BCP 47 tags of the languages, e.g. `en` or
+// `pt-BR`, which players expect on their tracks.
+var languageTag = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// HandleList allows administrators to fetch all the captions of a video,
+// reviewed or not.
+func HandleList(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		cs, err := FetchByVideo(ctx, db, videoID)
+		if err != nil {
+			return fmt.Errorf("fetching captions of video[%s]: %w", videoID, err)
+		}
+
+		return web.Respond(ctx, w, cs, http.StatusOK)
+	}
+}
+
+// HandlePut allows administrators to attach the WebVTT or SRT track of
+// a video in a language, replacing the current one.
+// SRT tracks are stored as WebVTT.
+func HandlePut(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+		language := web.Param(r, "language")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		if !languageTag.MatchString(language) {
+			return weberr.BadRequest(fmt.Errorf("passed language %q is not valid", language))
+		}
+
+		var cu CaptionUp
+		if err := web.Decode(w, r, &cu); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(cu); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		vtt, err := toVTT(cu.Format, cu.Content)
+		if err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if _, err := video.Fetch(ctx, db, videoID); err != nil {
+			err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		now := time.Now().UTC()
+		c, err := UpsertManual(ctx, db, Caption{
+			VideoID:   videoID,
+			Language:  language,
+			VTT:       vtt,
+			Source:    SourceManual,
+			Reviewed:  true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, c, http.StatusOK)
+	}
+}
+
+// HandleDelete allows administrators to remove the caption of a video
+// in a language. Deleting missing captions succeeds.
+func HandleDelete(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+		language := web.Param(r, "language")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		if err := Delete(ctx, db, videoID, language); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleListUnreviewed allows administrators to fetch the captions
+// which have been generated and are waiting for a review.
+func HandleListUnreviewed(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		cs, err := FetchUnreviewed(ctx, db)
+		if err != nil {
+			return fmt.Errorf("fetching unreviewed captions: %w", err)
+		}
+
+		return web.Respond(ctx, w, cs, http.StatusOK)
+	}
+}
+
+// HandleReview allows administrators to approve a caption.
+func HandleReview(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+		language := web.Param(r, "language")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := MarkReviewed(ctx, db, videoID, language, time.Now().UTC()); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
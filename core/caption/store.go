@@ -0,0 +1,259 @@
+package caption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// UpsertGenerated stores a machine generated caption, which needs to be
+// reviewed. Captions written by instructors are never overwritten.
+func UpsertGenerated(ctx context.Context, db sqlx.ExtContext, c Caption) error {
+	const q = `
+	INSERT INTO captions
+		(video_id, language, vtt, source, reviewed, created_at, updated_at)
+	VALUES
+		(:video_id, :language, :vtt, :source, :reviewed, :created_at, :updated_at)
+	ON CONFLICT
+		(video_id, language)
+	DO UPDATE SET
+		vtt = EXCLUDED.vtt,
+		reviewed = FALSE,
+		updated_at = EXCLUDED.updated_at
+	WHERE
+		captions.source = 'machine'`
+
+	if err := database.NamedExecContext(ctx, db, q, c); err != nil {
+		return fmt.Errorf("upserting caption of video[%s]: %w", c.VideoID, err)
+	}
+
+	return nil
+}
+
+// MarkReviewed flags a caption as reviewed.
+func MarkReviewed(ctx context.Context, db sqlx.ExtContext, videoID string, language string, now time.Time) error {
+	in := struct {
+		VideoID   string    `db:"video_id"`
+		Language  string    `db:"language"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}{
+		VideoID:   videoID,
+		Language:  language,
+		UpdatedAt: now,
+	}
+
+	const q = `
+	UPDATE captions
+	SET
+		reviewed = TRUE,
+		updated_at = :updated_at
+	WHERE
+		video_id = :video_id AND
+		language = :language
+	RETURNING video_id`
+
+	var out struct {
+		VideoID string `db:"video_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("reviewing caption[%s] of video[%s]: %w", language, videoID, err)
+	}
+
+	return nil
+}
+
+// FetchUnreviewed returns all the captions waiting for a review.
+func FetchUnreviewed(ctx context.Context, db sqlx.ExtContext) ([]Caption, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		captions
+	WHERE
+		reviewed = FALSE
+	ORDER BY
+		created_at`
+
+	cs := []Caption{}
+	if err := database.NamedQuerySlice(ctx, db, q, struct{}{}, &cs); err != nil {
+		return nil, fmt.Errorf("selecting unreviewed captions: %w", err)
+	}
+
+	return cs, nil
+}
+
+// CreateJob enqueues the generation of the captions of a video.
+// Jobs for the same media are enqueued only once.
+func CreateJob(ctx context.Context, db sqlx.ExtContext, job Job) error {
+	const q = `
+	INSERT INTO caption_jobs
+		(video_id, url, status, attempts, last_error, next_run_at, created_at, updated_at)
+	VALUES
+		(:video_id, :url, :status, :attempts, :last_error, :next_run_at, :created_at, :updated_at)
+	ON CONFLICT
+		(video_id, url)
+	DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, db, q, job); err != nil {
+		return fmt.Errorf("inserting caption job of video[%s]: %w", job.VideoID, err)
+	}
+
+	return nil
+}
+
+// UpdateJob updates the state of a captioning job.
+func UpdateJob(ctx context.Context, db sqlx.ExtContext, job Job) error {
+	const q = `
+	UPDATE caption_jobs
+	SET
+		status = :status,
+		attempts = :attempts,
+		last_error = :last_error,
+		next_run_at = :next_run_at,
+		updated_at = :updated_at
+	WHERE
+		video_id = :video_id AND
+		url = :url`
+
+	if err := database.NamedExecContext(ctx, db, q, job); err != nil {
+		return fmt.Errorf("updating caption job of video[%s]: %w", job.VideoID, err)
+	}
+
+	return nil
+}
+
+// FetchDueJobs returns the pending jobs which are scheduled
+// to run before the passed time.
+func FetchDueJobs(ctx context.Context, db sqlx.ExtContext, now time.Time) ([]Job, error) {
+	in := struct {
+		Status JobStatus `db:"status"`
+		Now    time.Time `db:"now"`
+	}{
+		Status: JobPending,
+		Now:    now,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		caption_jobs
+	WHERE
+		status = :status AND
+		next_run_at <= :now
+	ORDER BY
+		next_run_at`
+
+	jobs := []Job{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &jobs); err != nil {
+		return nil, fmt.Errorf("selecting due caption jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// UpsertManual stores a caption written by instructors, which needs no
+// review and replaces any caption of the video in its language.
+func UpsertManual(ctx context.Context, db sqlx.ExtContext, c Caption) (Caption, error) {
+	const q = `
+	INSERT INTO captions
+		(video_id, language, vtt, source, reviewed, created_at, updated_at)
+	VALUES
+		(:video_id, :language, :vtt, :source, :reviewed, :created_at, :updated_at)
+	ON CONFLICT
+		(video_id, language)
+	DO UPDATE SET
+		vtt = EXCLUDED.vtt,
+		source = EXCLUDED.source,
+		reviewed = EXCLUDED.reviewed,
+		updated_at = EXCLUDED.updated_at
+	RETURNING *`
+
+	var out Caption
+	if err := database.NamedQueryStruct(ctx, db, q, c, &out); err != nil {
+		return Caption{}, fmt.Errorf("upserting caption[%s] of video[%s]: %w", c.Language, c.VideoID, err)
+	}
+
+	return out, nil
+}
+
+// FetchByVideo returns all the captions of a video.
+func FetchByVideo(ctx context.Context, db sqlx.ExtContext, videoID string) ([]Caption, error) {
+	in := struct {
+		VideoID string `db:"video_id"`
+	}{
+		VideoID: videoID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		captions
+	WHERE
+		video_id = :video_id
+	ORDER BY
+		language`
+
+	cs := []Caption{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cs); err != nil {
+		return nil, fmt.Errorf("selecting captions of video[%s]: %w", videoID, err)
+	}
+
+	return cs, nil
+}
+
+// FetchReviewedByVideo returns the captions of a video which can be
+// shown to users.
+func FetchReviewedByVideo(ctx context.Context, db sqlx.ExtContext, videoID string) ([]Caption, error) {
+	in := struct {
+		VideoID string `db:"video_id"`
+	}{
+		VideoID: videoID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		captions
+	WHERE
+		video_id = :video_id AND
+		reviewed = TRUE
+	ORDER BY
+		language`
+
+	cs := []Caption{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cs); err != nil {
+		return nil, fmt.Errorf("selecting reviewed captions of video[%s]: %w", videoID, err)
+	}
+
+	return cs, nil
+}
+
+// Delete removes the caption of a video in a language.
+func Delete(ctx context.Context, db sqlx.ExtContext, videoID string, language string) error {
+	in := struct {
+		VideoID  string `db:"video_id"`
+		Language string `db:"language"`
+	}{
+		VideoID:  videoID,
+		Language: language,
+	}
+
+	const q = `
+	DELETE FROM
+		captions
+	WHERE
+		video_id = :video_id AND
+		language = :language`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("deleting caption[%s] of video[%s]: %w", language, videoID, err)
+	}
+
+	return nil
+}
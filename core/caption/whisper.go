@@ -0,0 +1,125 @@
+package caption
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"time"
+)
+
+// maxMediaBytes is the largest file accepted by the Whisper API.
+const maxMediaBytes = 25 << 20
+
+// Transcriber turns the speech of a media into a WebVTT track.
+type Transcriber interface {
+	Transcribe(ctx context.Context, mediaURL string, language string) (string, error)
+}
+
+// Whisper is a Transcriber backed by the OpenAI Whisper API, or any
+// service exposing the same transcriptions endpoint.
+type Whisper struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+// NewWhisper returns a transcriber calling the endpoint at url.
+func NewWhisper(url string, apiKey string, model string) *Whisper {
+	return &Whisper{
+		url:    url,
+		apiKey: apiKey,
+		model:  model,
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// Transcribe implements the Transcriber interface. The media is
+// downloaded and uploaded to the service as is.
+func (wh *Whisper) Transcribe(ctx context.Context, mediaURL string, language string) (string, error) {
+	media, err := wh.download(ctx, mediaURL)
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fields := map[string]string{
+		"model":           wh.model,
+		"language":        language,
+		"response_format": "vtt",
+	}
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			return "", fmt.Errorf("writing field %s: %w", k, err)
+		}
+	}
+
+	fw, err := mw.CreateFormFile("file", path.Base(mediaURL))
+	if err != nil {
+		return "", fmt.Errorf("creating file field: %w", err)
+	}
+	if _, err := fw.Write(media); err != nil {
+		return "", fmt.Errorf("writing file field: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return "", fmt.Errorf("closing multipart body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.url, &body)
+	if err != nil {
+		return "", fmt.Errorf("building transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+wh.apiKey)
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting transcription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	vtt, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading transcription: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting transcription: status %s: %s", resp.Status, vtt)
+	}
+
+	return string(vtt), nil
+}
+
+// download fetches the media to transcribe.
+func (wh *Whisper) download(ctx context.Context, mediaURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building download of %s: %w", mediaURL, err)
+	}
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", mediaURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: status %s", mediaURL, resp.Status)
+	}
+
+	media, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", mediaURL, err)
+	}
+	if len(media) > maxMediaBytes {
+		return nil, errors.New("media too large to be transcribed")
+	}
+
+	return media, nil
+}
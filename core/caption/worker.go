@@ -0,0 +1,141 @@
+package caption
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/background"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Captioner generates the captions of the videos through a Transcriber.
+// It listens to video events to enqueue jobs, which are then processed
+// in background, retrying failures up to MaxAttempts.
+type Captioner struct {
+	DB          *sqlx.DB
+	STT         Transcriber
+	BG          *background.Background
+	Log         logrus.FieldLogger
+	Language    string
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// VideoChanged implements the video.Listener interface.
+func (c *Captioner) VideoChanged(v video.Video) {
+	c.enqueue(v)
+}
+
+// VideoReady implements the video.Listener interface.
+func (c *Captioner) VideoReady(v video.Video) {
+	c.enqueue(v)
+}
+
+// enqueue schedules the captioning of the video, if its media
+// is available to be downloaded. HLS manifests are not, their
+// source is captioned before being encoded.
+func (c *Captioner) enqueue(v video.Video) {
+	if v.Provider != video.ProviderNative || v.Status != video.StatusReady || v.URL == "" {
+		return
+	}
+
+	if u, err := url.Parse(v.URL); err == nil && path.Ext(u.Path) == ".m3u8" {
+		return
+	}
+
+	c.BG.Add(func() error {
+		now := time.Now().UTC()
+		job := Job{
+			VideoID:   v.ID,
+			URL:       v.URL,
+			Status:    JobPending,
+			NextRunAt: now,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return CreateJob(context.Background(), c.DB, job)
+	})
+}
+
+// Run processes the due jobs every interval.
+// It blocks until the passed context is canceled.
+func (c *Captioner) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.runDue(ctx); err != nil {
+				c.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// runDue runs all the jobs scheduled until now.
+func (c *Captioner) runDue(ctx context.Context) error {
+	jobs, err := FetchDueJobs(ctx, c.DB, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("fetching due caption jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		if err := c.run(ctx, job); err != nil {
+			c.Log.WithField("message", err).Error("ERROR")
+		}
+	}
+
+	return nil
+}
+
+// run transcribes the media of the job and stores the result
+// as a machine generated caption, waiting for review.
+func (c *Captioner) run(ctx context.Context, job Job) error {
+	job.Attempts++
+
+	vtt, terr := c.STT.Transcribe(ctx, job.URL, c.Language)
+
+	now := time.Now().UTC()
+	job.UpdatedAt = now
+
+	if terr != nil {
+		job.LastError = terr.Error()
+		job.NextRunAt = now.Add(c.Backoff * time.Duration(job.Attempts))
+		if job.Attempts >= c.MaxAttempts {
+			job.Status = JobFailed
+		}
+		if err := UpdateJob(ctx, c.DB, job); err != nil {
+			return fmt.Errorf("rescheduling caption job of video[%s]: %w", job.VideoID, err)
+		}
+		return fmt.Errorf("transcribing video[%s]: %w", job.VideoID, terr)
+	}
+
+	cpt := Caption{
+		VideoID:   job.VideoID,
+		Language:  c.Language,
+		VTT:       vtt,
+		Source:    SourceMachine,
+		Reviewed:  false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := UpsertGenerated(ctx, c.DB, cpt); err != nil {
+		return fmt.Errorf("storing caption of video[%s]: %w", job.VideoID, err)
+	}
+
+	job.Status = JobDone
+	job.LastError = ""
+	if err := UpdateJob(ctx, c.DB, job); err != nil {
+		return fmt.Errorf("completing caption job of video[%s]: %w", job.VideoID, err)
+	}
+
+	return nil
+}
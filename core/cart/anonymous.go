@@ -0,0 +1,167 @@
+package cart
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// anonKey is the session key of the cart of visitors not logged in.
+// Anonymous carts live as long as the session, identified by its
+// cookie, and only hold courses.
+const anonKey = "cartItems"
+
+// maxAnonymousItems limits the items of anonymous carts, to keep the
+// sessions small.
+const maxAnonymousItems = 50
+
+func init() {
+	// Sessions are gob encoded.
+	gob.Register([]Item{})
+}
+
+// anonymousItems returns the items of the anonymous cart in session.
+func anonymousItems(ctx context.Context, session *scs.SessionManager) []Item {
+	items, ok := session.Get(ctx, anonKey).([]Item)
+	if !ok {
+		return []Item{}
+	}
+	return items
+}
+
+// showAnonymous responds with the anonymous cart in session.
+func showAnonymous(ctx context.Context, w http.ResponseWriter, db *sqlx.DB, session *scs.SessionManager) error {
+	items := anonymousItems(ctx, session)
+
+	if err := price(ctx, db, items); err != nil {
+		return fmt.Errorf("pricing anonymous cart items: %w", err)
+	}
+
+	return web.Respond(ctx, w, Cart{Items: items, Bundles: []Bundle{}}, http.StatusOK)
+}
+
+// createAnonymousItem adds the course to the anonymous cart in session.
+func createAnonymousItem(ctx context.Context, w http.ResponseWriter, db *sqlx.DB, session *scs.SessionManager, courseID string) error {
+	if err := checkCourse(ctx, db, courseID); err != nil {
+		return err
+	}
+
+	items := anonymousItems(ctx, session)
+	for _, it := range items {
+		if it.CourseID == courseID {
+			err := errors.New("course already in the cart")
+			return weberr.NewError(err, err.Error(), http.StatusConflict)
+		}
+	}
+
+	if len(items) >= maxAnonymousItems {
+		err := fmt.Errorf("carts hold at most %d courses before logging in", maxAnonymousItems)
+		return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	now := time.Now().UTC()
+	item := Item{
+		CourseID:  courseID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	session.Put(ctx, anonKey, append(items, item))
+
+	return web.Respond(ctx, w, item, http.StatusCreated)
+}
+
+// deleteAnonymousItem drops the course from the anonymous cart in
+// session.
+func deleteAnonymousItem(ctx context.Context, w http.ResponseWriter, session *scs.SessionManager, courseID string) error {
+	items := anonymousItems(ctx, session)
+
+	kept := make([]Item, 0, len(items))
+	for _, it := range items {
+		if it.CourseID != courseID {
+			kept = append(kept, it)
+		}
+	}
+	session.Put(ctx, anonKey, kept)
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Merge moves the anonymous cart in session into the cart of the user
+// logging in. Courses which are in the cart already, owned by the user
+// or deleted in the meantime are dropped.
+func Merge(ctx context.Context, db *sqlx.DB, session *scs.SessionManager, userID string) error {
+	items := anonymousItems(ctx, session)
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = it.CourseID
+	}
+
+	cs, err := course.FetchByIDs(ctx, db, ids)
+	if err != nil {
+		return fmt.Errorf("fetching anonymous cart courses: %w", err)
+	}
+
+	existing := make(map[string]bool, len(cs))
+	for _, c := range cs {
+		existing[c.ID] = true
+	}
+
+	owned, err := course.FetchByOwner(ctx, db, userID)
+	if err != nil {
+		return fmt.Errorf("fetching courses owned by user[%s]: %w", userID, err)
+	}
+
+	current, err := FetchItems(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+
+	skip := make(map[string]bool, len(owned)+len(current))
+	for _, c := range owned {
+		skip[c.ID] = true
+	}
+	for _, it := range current {
+		skip[it.CourseID] = true
+	}
+
+	err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+		if _, err := Upsert(ctx, tx, userID); err != nil {
+			return fmt.Errorf("upserting user[%s] cart: %w", userID, err)
+		}
+
+		for _, it := range items {
+			if !existing[it.CourseID] || skip[it.CourseID] {
+				continue
+			}
+
+			it.UserID = userID
+			if err := CreateItem(ctx, tx, it); err != nil {
+				return fmt.Errorf("creating cart item[%s] for user[%s]: %w", it.CourseID, userID, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	session.Remove(ctx, anonKey)
+
+	return nil
+}
@@ -2,25 +2,37 @@ package cart
 
 import (
 	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
 )
 
 // Cart models the users' carts.
 // Each user can have only a cart at a time.
+// UpdatedAt changes whenever the cart is modified, RemindedAt is when
+// the user was last reminded of the cart, once abandoned.
 type Cart struct {
-	UserID    string    `json:"-" db:"user_id"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
-	Version   int       `json:"-" db:"version"`
-	Items     []Item    `json:"items" db:"-"`
+	UserID     string     `json:"-" db:"user_id"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+	RemindedAt *time.Time `json:"-" db:"reminded_at"`
+	Version    int        `json:"-" db:"version"`
+	Items      []Item     `json:"items" db:"-"`
+	Bundles    []Bundle   `json:"bundles" db:"-"`
 }
 
 // Item models the item of a cart.
 // A cart can have many items.
+// Items saved for later, out of the cart, are modeled as items too.
+// Price is the original price of the course, to strike through when
+// the course is on sale.
 type Item struct {
-	UserID    string    `json:"-" db:"user_id"`
-	CourseID  string    `json:"courseId" db:"course_id"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	UserID    string       `json:"-" db:"user_id"`
+	CourseID  string       `json:"courseId" db:"course_id"`
+	Price     money.Amount `json:"price" db:"-"`
+	Sale      *course.Sale `json:"sale,omitempty" db:"-"`
+	CreatedAt time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time    `json:"updatedAt" db:"updated_at"`
 }
 
 // ItemNew models the data required to insert a
@@ -28,3 +40,76 @@ type Item struct {
 type ItemNew struct {
 	CourseID string `json:"courseId" db:"course_id"`
 }
+
+// ItemsNew models the courses to add to the user's cart at once, e.g.
+// all the courses of a learning path.
+type ItemsNew struct {
+	CourseIDs []string `json:"courseIds" validate:"min=1,max=100,dive,uuid4"`
+}
+
+// Outcomes of adding a course to the cart in bulk.
+const (
+	ResultAdded     = "added"
+	ResultDuplicate = "duplicate"
+	ResultOwned     = "owned"
+	ResultNotFound  = "not_found"
+)
+
+// Result reports whether a course has been added to the cart in bulk,
+// or why it was skipped.
+type Result struct {
+	CourseID string `json:"courseId"`
+	Result   string `json:"result"`
+}
+
+// Bundle models a bundle of courses in a cart.
+// A cart can have many bundles.
+type Bundle struct {
+	UserID    string    `json:"-" db:"user_id"`
+	BundleID  string    `json:"bundleId" db:"bundle_id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// BundleNew models the data required to insert a
+// new bundle on the user's cart.
+type BundleNew struct {
+	BundleID string `json:"bundleId" db:"bundle_id"`
+}
+
+// Reasons why an item of the cart needs the attention of the user.
+const (
+	IssuePriceChanged = "price_changed"
+	IssueUnavailable  = "unavailable"
+	IssueOwned        = "owned"
+)
+
+// Issue reports an item of the cart which changed since it was added.
+// AddedPrice is the price the course had then, Price the current one,
+// both set only when the price changed.
+type Issue struct {
+	CourseID   string        `json:"courseId"`
+	Reason     string        `json:"reason"`
+	AddedPrice *money.Amount `json:"addedPrice,omitempty"`
+	Price      *money.Amount `json:"price,omitempty"`
+}
+
+// Validation reports the issues of the items of a cart, if any.
+type Validation struct {
+	Valid  bool    `json:"valid"`
+	Issues []Issue `json:"issues"`
+}
+
+// Abandoned is a cart left untouched, with the email of its user.
+type Abandoned struct {
+	UserID    string    `db:"user_id"`
+	Email     string    `db:"email"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// OptOut models the request of a user to stop the reminders of the
+// abandoned carts, signed by Token.
+type OptOut struct {
+	UserID string `json:"userId" validate:"required"`
+	Token  string `json:"token" validate:"required"`
+}
@@ -7,29 +7,64 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/alexedwards/scs/v2"
 	"github.com/jmoiron/sqlx"
 	"github.com/jatolentino/tutorialspoint/api/web"
 	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/bundle"
 	"github.com/jatolentino/tutorialspoint/core/claims"
 	"github.com/jatolentino/tutorialspoint/core/course"
 	"github.com/jatolentino/tutorialspoint/database"
 	"github.com/jatolentino/tutorialspoint/validate"
 )
 
-// HandleShow returns the cart of the user.
+// price sets on the passed items the price of their courses and the
+// discount of the sales running on them.
+func price(ctx context.Context, db sqlx.ExtContext, items []Item) error {
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = it.CourseID
+	}
+
+	cs, err := course.FetchByIDs(ctx, db, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := course.WithSales(ctx, db, cs, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	byID := make(map[string]course.Course, len(cs))
+	for _, c := range cs {
+		byID[c.ID] = c
+	}
+
+	for i := range items {
+		c := byID[items[i].CourseID]
+		items[i].Price = c.Price
+		items[i].Sale = c.Sale
+	}
+
+	return nil
+}
+
+// HandleShow returns the cart of the user, with the prices of its
+// courses and the discount of the sales running on them.
 // Returns an empty cart if the user has no cart.
-func HandleShow(db *sqlx.DB) web.Handler {
+// Visitors not logged in are returned their anonymous cart.
+func HandleShow(db *sqlx.DB, session *scs.SessionManager) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		clm, err := claims.Get(ctx)
 		if err != nil {
-			return weberr.NotAuthorized(errors.New("user not authenticated"))
+			return showAnonymous(ctx, w, db, session)
 		}
 
 		// Return an empty cart if it doesn't exist yet.
 		cart, err := Fetch(ctx, db, clm.UserID)
 		if err != nil {
 			if errors.Is(err, database.ErrDBNotFound) {
-				return web.Respond(ctx, w, Cart{Items: []Item{}}, http.StatusOK)
+				return web.Respond(ctx, w, Cart{Items: []Item{}, Bundles: []Bundle{}}, http.StatusOK)
 			}
 			return fmt.Errorf("fetching user[%s] cart: %w", clm.UserID, err)
 		}
@@ -39,17 +74,27 @@ func HandleShow(db *sqlx.DB) web.Handler {
 			return fmt.Errorf("fetching user[%s] cart items: %w", clm.UserID, err)
 		}
 
+		if err := price(ctx, db, cart.Items); err != nil {
+			return fmt.Errorf("pricing user[%s] cart items: %w", clm.UserID, err)
+		}
+
+		cart.Bundles, err = FetchBundles(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s] cart bundles: %w", clm.UserID, err)
+		}
+
 		return web.Respond(ctx, w, cart, http.StatusOK)
 	}
 }
 
 // HandleDelete flushes the user's cart. It also drops
 // all related items in cascade.
-func HandleDelete(db *sqlx.DB) web.Handler {
+func HandleDelete(db *sqlx.DB, session *scs.SessionManager) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		clm, err := claims.Get(ctx)
 		if err != nil {
-			return weberr.NotAuthorized(errors.New("user not authenticated"))
+			session.Remove(ctx, anonKey)
+			return web.Respond(ctx, w, nil, http.StatusNoContent)
 		}
 
 		// Just delete the cart. Its items will be deleted in cascade.
@@ -62,7 +107,8 @@ func HandleDelete(db *sqlx.DB) web.Handler {
 }
 
 // HandleCreateItem adds a new item in the user's cart.
-func HandleCreateItem(db *sqlx.DB) web.Handler {
+// Visitors not logged in add it to their anonymous cart.
+func HandleCreateItem(db *sqlx.DB, session *scs.SessionManager) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		var itnew ItemNew
 		if err := web.Decode(w, r, &itnew); err != nil {
@@ -71,7 +117,11 @@ func HandleCreateItem(db *sqlx.DB) web.Handler {
 
 		clm, err := claims.Get(ctx)
 		if err != nil {
-			return weberr.NotAuthorized(errors.New("user not authenticated"))
+			return createAnonymousItem(ctx, w, db, session, itnew.CourseID)
+		}
+
+		if err := checkCourse(ctx, db, itnew.CourseID); err != nil {
+			return err
 		}
 
 		owned, err := course.FetchByOwner(ctx, db, clm.UserID)
@@ -110,8 +160,128 @@ func HandleCreateItem(db *sqlx.DB) web.Handler {
 	}
 }
 
+// checkCourse verifies that the course can be added to a cart: drafts
+// are not on sale yet, so they are reported as not found.
+func checkCourse(ctx context.Context, db sqlx.ExtContext, courseID string) error {
+	if err := validate.CheckID(courseID); err != nil {
+		return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	c, err := course.Fetch(ctx, db, courseID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return weberr.NewError(err, "course not found", http.StatusUnprocessableEntity)
+		}
+		return err
+	}
+
+	if c.Status == course.StatusDraft {
+		err := fmt.Errorf("course[%s] is a draft", courseID)
+		return weberr.NewError(err, "course not found", http.StatusUnprocessableEntity)
+	}
+
+	if c.Status == course.StatusArchived {
+		err := fmt.Errorf("course[%s] is archived", courseID)
+		return weberr.NewError(err, "course no longer sold", http.StatusUnprocessableEntity)
+	}
+
+	return nil
+}
+
+// HandleCreateItems adds many courses to the user's cart at once.
+// Courses in the cart already, owned or not found are skipped, the
+// others are added all together. The outcome is reported for each
+// course, in the order passed.
+func HandleCreateItems(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in ItemsNew
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		cs, err := course.FetchByIDs(ctx, db, in.CourseIDs)
+		if err != nil {
+			return err
+		}
+
+		found := make(map[string]bool, len(cs))
+		for _, c := range cs {
+			found[c.ID] = c.Status == course.StatusPublished
+		}
+
+		owned, err := course.FetchByOwner(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching courses owned by user[%s]: %w", clm.UserID, err)
+		}
+
+		ownedIDs := make(map[string]bool, len(owned))
+		for _, o := range owned {
+			ownedIDs[o.ID] = true
+		}
+
+		res := make([]Result, len(in.CourseIDs))
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if _, err := Upsert(ctx, tx, clm.UserID); err != nil {
+				return fmt.Errorf("upserting user[%s] cart: %w", clm.UserID, err)
+			}
+
+			items, err := FetchItems(ctx, tx, clm.UserID)
+			if err != nil {
+				return err
+			}
+
+			inCart := make(map[string]bool, len(items)+len(in.CourseIDs))
+			for _, it := range items {
+				inCart[it.CourseID] = true
+			}
+
+			now := time.Now().UTC()
+			for i, id := range in.CourseIDs {
+				res[i] = Result{CourseID: id}
+
+				switch {
+				case !found[id]:
+					res[i].Result = ResultNotFound
+				case ownedIDs[id]:
+					res[i].Result = ResultOwned
+				case inCart[id]:
+					res[i].Result = ResultDuplicate
+				default:
+					item := Item{
+						UserID:    clm.UserID,
+						CourseID:  id,
+						CreatedAt: now,
+						UpdatedAt: now,
+					}
+					if err := PutItem(ctx, tx, item); err != nil {
+						return fmt.Errorf("creating cart item[%s] for user[%s]: %w", id, clm.UserID, err)
+					}
+					inCart[id] = true
+					res[i].Result = ResultAdded
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, res, http.StatusOK)
+	}
+}
+
 // HandleDeleteItem deletes an item from the user's cart.
-func HandleDeleteItem(db *sqlx.DB) web.Handler {
+func HandleDeleteItem(db *sqlx.DB, session *scs.SessionManager) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		courseID := web.Param(r, "course_id")
 
@@ -121,7 +291,7 @@ func HandleDeleteItem(db *sqlx.DB) web.Handler {
 
 		clm, err := claims.Get(ctx)
 		if err != nil {
-			return weberr.NotAuthorized(errors.New("user not authenticated"))
+			return deleteAnonymousItem(ctx, w, session, courseID)
 		}
 
 		if _, err := Upsert(ctx, db, clm.UserID); err != nil {
@@ -135,3 +305,102 @@ func HandleDeleteItem(db *sqlx.DB) web.Handler {
 		return web.Respond(ctx, w, nil, http.StatusNoContent)
 	}
 }
+
+// HandleCreateBundle adds a bundle in the user's cart.
+// Bundles whose courses are all owned already can't be added.
+func HandleCreateBundle(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var bnew BundleNew
+		if err := web.Decode(w, r, &bnew); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.CheckID(bnew.BundleID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		b, err := bundle.Fetch(ctx, db, bnew.BundleID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NewError(err, "bundle not found", http.StatusUnprocessableEntity)
+			}
+			return fmt.Errorf("fetching bundle[%s]: %w", bnew.BundleID, err)
+		}
+
+		owned, err := course.FetchByOwner(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("checking if bundle[%s] is already owned by user[%s]: %w",
+				b.ID,
+				clm.UserID,
+				err,
+			)
+		}
+
+		ownedIDs := make(map[string]bool, len(owned))
+		for _, o := range owned {
+			ownedIDs[o.ID] = true
+		}
+
+		missing := false
+		for _, id := range b.CourseIDs {
+			if !ownedIDs[id] {
+				missing = true
+				break
+			}
+		}
+
+		if !missing {
+			err := errors.New("bundle already owned")
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if _, err := Upsert(ctx, db, clm.UserID); err != nil {
+			return fmt.Errorf("upserting user[%s] cart: %w", clm.UserID, err)
+		}
+
+		now := time.Now().UTC()
+		cb := Bundle{
+			UserID:    clm.UserID,
+			BundleID:  b.ID,
+			UpdatedAt: now,
+			CreatedAt: now,
+		}
+
+		if err := CreateBundle(ctx, db, cb); err != nil {
+			return fmt.Errorf("creating cart bundle[%s] for user[%s]: %w", cb.BundleID, clm.UserID, err)
+		}
+
+		return web.Respond(ctx, w, cb, http.StatusCreated)
+	}
+}
+
+// HandleDeleteBundle deletes a bundle from the user's cart.
+func HandleDeleteBundle(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		bundleID := web.Param(r, "bundle_id")
+
+		if err := validate.CheckID(bundleID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if _, err := Upsert(ctx, db, clm.UserID); err != nil {
+			return fmt.Errorf("upserting user[%s] cart: %w", clm.UserID, err)
+		}
+
+		if err := DeleteBundle(ctx, db, clm.UserID, bundleID); err != nil {
+			return fmt.Errorf("deleting user[%s] cart bundle: %w", clm.UserID, err)
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,121 @@
+package cart
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Mailer sends the emails needed by the cart reminders.
+type Mailer interface {
+	SendCartReminder(cartURL string, unsubscribeURL string, to string) error
+}
+
+// UnsubscribeToken returns the token allowing the user to stop the
+// reminders of the abandoned carts, signed with secret.
+func UnsubscribeToken(secret string, userID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Reminder emails the users whose cart is not empty but has not been
+// modified for IdleAfter, to invite them to complete the purchase.
+// Users are reminded once each time they leave the cart, and each
+// email carries a link to stop the reminders.
+type Reminder struct {
+	DB             *sqlx.DB
+	Mailer         Mailer
+	Log            logrus.FieldLogger
+	IdleAfter      time.Duration
+	CartURL        string
+	UnsubscribeURL string
+	Secret         string
+}
+
+// Run reminds the abandoned carts every interval.
+// It blocks until the passed context is canceled.
+func (rm *Reminder) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := rm.remind(ctx); err != nil {
+				rm.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// remind sends a reminder for each cart abandoned.
+func (rm *Reminder) remind(ctx context.Context) error {
+	now := time.Now().UTC()
+	abs, err := FetchAbandoned(ctx, rm.DB, now.Add(-rm.IdleAfter))
+	if err != nil {
+		return fmt.Errorf("fetching abandoned carts: %w", err)
+	}
+
+	for _, a := range abs {
+		q := url.Values{}
+		q.Set("user", a.UserID)
+		q.Set("token", UnsubscribeToken(rm.Secret, a.UserID))
+
+		if err := rm.Mailer.SendCartReminder(rm.CartURL, rm.UnsubscribeURL+"?"+q.Encode(), a.Email); err != nil {
+			rm.Log.WithField("message", fmt.Errorf("reminding cart of user[%s] to %s: %w", a.UserID, a.Email, err)).Error("ERROR")
+			continue
+		}
+
+		if err := MarkReminded(ctx, rm.DB, a.UserID, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HandleUnsubscribe stops the reminders of the abandoned carts of the
+// user, as requested following the link sent with the reminders.
+// It doesn't require to log in, the request is signed instead.
+func HandleUnsubscribe(db *sqlx.DB, secret string) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var oo OptOut
+		if err := web.Decode(w, r, &oo); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(oo); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := validate.CheckID(oo.UserID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		exp := UnsubscribeToken(secret, oo.UserID)
+		if secret == "" || !hmac.Equal([]byte(exp), []byte(oo.Token)) {
+			return weberr.NotAuthorized(errors.New("invalid unsubscribe token"))
+		}
+
+		if err := CreateOptOut(ctx, db, oo.UserID, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,165 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleListSaved returns the items the user saved for later, with
+// the prices of their courses and the discount of the sales running
+// on them.
+func HandleListSaved(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		items, err := FetchSaved(ctx, db, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		if err := price(ctx, db, items); err != nil {
+			return fmt.Errorf("pricing user[%s] saved items: %w", clm.UserID, err)
+		}
+
+		return web.Respond(ctx, w, items, http.StatusOK)
+	}
+}
+
+// HandleSaveItem moves an item out of the user's cart, saving it for
+// later.
+func HandleSaveItem(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		now := time.Now().UTC()
+		saved := Item{
+			UserID:    clm.UserID,
+			CourseID:  courseID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if _, err := Upsert(ctx, tx, clm.UserID); err != nil {
+				return fmt.Errorf("upserting user[%s] cart: %w", clm.UserID, err)
+			}
+
+			if _, err := TakeItem(ctx, tx, clm.UserID, courseID); err != nil {
+				return err
+			}
+
+			return PutSaved(ctx, tx, saved)
+		})
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, saved, http.StatusOK)
+	}
+}
+
+// HandleMoveToCart moves an item saved for later back into the user's
+// cart. Courses bought in the meantime can't be moved.
+func HandleMoveToCart(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		owned, err := course.FetchByOwner(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("checking if course[%s] is already owned by user[%s]: %w", courseID, clm.UserID, err)
+		}
+
+		for _, o := range owned {
+			if courseID == o.ID {
+				err := errors.New("course already owned")
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+		}
+
+		now := time.Now().UTC()
+		item := Item{
+			UserID:    clm.UserID,
+			CourseID:  courseID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if _, err := TakeSaved(ctx, tx, clm.UserID, courseID); err != nil {
+				return err
+			}
+
+			if _, err := Upsert(ctx, tx, clm.UserID); err != nil {
+				return fmt.Errorf("upserting user[%s] cart: %w", clm.UserID, err)
+			}
+
+			return PutItem(ctx, tx, item)
+		})
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, item, http.StatusOK)
+	}
+}
+
+// HandleDeleteSaved drops an item saved for later.
+func HandleDeleteSaved(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		// Deletion is idempotent.
+		if _, err := TakeSaved(ctx, db, clm.UserID, courseID); err != nil && !errors.Is(err, database.ErrDBNotFound) {
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
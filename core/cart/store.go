@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/jatolentino/tutorialspoint/core/course"
 	"github.com/jatolentino/tutorialspoint/database"
 )
 
@@ -152,6 +153,35 @@ func FetchItems(ctx context.Context, db sqlx.ExtContext, userID string) ([]Item,
 	return ci, nil
 }
 
+// FetchAddedPrices returns, for each item in the user's cart, the
+// price its course had when the item was added. Items added before the
+// prices were recorded are missing.
+func FetchAddedPrices(ctx context.Context, db sqlx.ExtContext, userID string) ([]course.PriceChange, error) {
+	in := struct {
+		ID string `db:"user_id"`
+	}{
+		ID: userID,
+	}
+
+	const q = `
+	SELECT DISTINCT ON (i.course_id)
+		p.*
+	FROM
+		cart_items AS i
+		JOIN course_prices AS p ON p.course_id = i.course_id AND p.created_at <= i.created_at
+	WHERE
+		i.user_id = :user_id
+	ORDER BY
+		i.course_id, p.created_at DESC`
+
+	pcs := []course.PriceChange{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &pcs); err != nil {
+		return nil, fmt.Errorf("selecting added prices of the cart of user[%s]: %w", userID, err)
+	}
+
+	return pcs, nil
+}
+
 // CreateItem inserts a new item in the user's cart.
 func CreateItem(ctx context.Context, db sqlx.ExtContext, item Item) error {
 	const q = `
@@ -189,3 +219,262 @@ func DeleteItem(ctx context.Context, db sqlx.ExtContext, userID string, courseID
 
 	return nil
 }
+
+// FetchBundles returns all the bundles in the user's cart.
+func FetchBundles(ctx context.Context, db sqlx.ExtContext, userID string) ([]Bundle, error) {
+	in := struct {
+		ID string `db:"user_id"`
+	}{
+		ID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		cart_bundles
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		bundle_id`
+
+	cb := []Bundle{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cb); err != nil {
+		return nil, fmt.Errorf("selecting cart bundles of user[%s]: %w", userID, err)
+	}
+
+	return cb, nil
+}
+
+// CreateBundle inserts a new bundle in the user's cart.
+func CreateBundle(ctx context.Context, db sqlx.ExtContext, b Bundle) error {
+	const q = `
+	INSERT INTO cart_bundles
+		(user_id, bundle_id, created_at, updated_at)
+	VALUES
+	(:user_id, :bundle_id, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, b); err != nil {
+		return fmt.Errorf("inserting cart bundle: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBundle drops a bundle from the user's cart.
+func DeleteBundle(ctx context.Context, db sqlx.ExtContext, userID string, bundleID string) error {
+	in := struct {
+		UserID   string `db:"user_id"`
+		BundleID string `db:"bundle_id"`
+	}{
+		UserID:   userID,
+		BundleID: bundleID,
+	}
+
+	const q = `
+	DELETE FROM
+		cart_bundles
+	WHERE
+		user_id = :user_id AND bundle_id = :bundle_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("deleting cart bundle: %w", err)
+	}
+
+	return nil
+}
+
+// TakeItem drops an item from the user's cart and returns it.
+func TakeItem(ctx context.Context, db sqlx.ExtContext, userID string, courseID string) (Item, error) {
+	in := struct {
+		UserID   string `db:"user_id"`
+		CourseID string `db:"course_id"`
+	}{
+		UserID:   userID,
+		CourseID: courseID,
+	}
+
+	const q = `
+	DELETE FROM
+		cart_items
+	WHERE
+		user_id = :user_id AND course_id = :course_id
+	RETURNING *`
+
+	var it Item
+	if err := database.NamedQueryStruct(ctx, db, q, in, &it); err != nil {
+		return Item{}, fmt.Errorf("deleting cart item[%s] of user[%s]: %w", courseID, userID, err)
+	}
+
+	return it, nil
+}
+
+// PutItem inserts an item in the user's cart, unless it's there already.
+func PutItem(ctx context.Context, db sqlx.ExtContext, item Item) error {
+	const q = `
+	INSERT INTO cart_items
+		(user_id, course_id, created_at, updated_at)
+	VALUES
+	(:user_id, :course_id, :created_at, :updated_at)
+	ON CONFLICT (user_id, course_id) DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, db, q, item); err != nil {
+		return fmt.Errorf("inserting cart item: %w", err)
+	}
+
+	return nil
+}
+
+// FetchSaved returns the items the user saved for later,
+// the latest first.
+func FetchSaved(ctx context.Context, db sqlx.ExtContext, userID string) ([]Item, error) {
+	in := struct {
+		ID string `db:"user_id"`
+	}{
+		ID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		saved_items
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		created_at DESC, course_id`
+
+	si := []Item{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &si); err != nil {
+		return nil, fmt.Errorf("selecting saved items of user[%s]: %w", userID, err)
+	}
+
+	return si, nil
+}
+
+// PutSaved saves an item for later, unless it's saved already.
+func PutSaved(ctx context.Context, db sqlx.ExtContext, item Item) error {
+	const q = `
+	INSERT INTO saved_items
+		(user_id, course_id, created_at, updated_at)
+	VALUES
+	(:user_id, :course_id, :created_at, :updated_at)
+	ON CONFLICT (user_id, course_id) DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, db, q, item); err != nil {
+		return fmt.Errorf("inserting saved item: %w", err)
+	}
+
+	return nil
+}
+
+// TakeSaved drops an item saved for later and returns it.
+func TakeSaved(ctx context.Context, db sqlx.ExtContext, userID string, courseID string) (Item, error) {
+	in := struct {
+		UserID   string `db:"user_id"`
+		CourseID string `db:"course_id"`
+	}{
+		UserID:   userID,
+		CourseID: courseID,
+	}
+
+	const q = `
+	DELETE FROM
+		saved_items
+	WHERE
+		user_id = :user_id AND course_id = :course_id
+	RETURNING *`
+
+	var it Item
+	if err := database.NamedQueryStruct(ctx, db, q, in, &it); err != nil {
+		return Item{}, fmt.Errorf("deleting saved item[%s] of user[%s]: %w", courseID, userID, err)
+	}
+
+	return it, nil
+}
+
+// FetchAbandoned returns the carts which are not empty, left untouched
+// since before and not reminded since. Users who opted out are skipped.
+func FetchAbandoned(ctx context.Context, db sqlx.ExtContext, before time.Time) ([]Abandoned, error) {
+	in := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before,
+	}
+
+	const q = `
+	SELECT
+		c.user_id,
+		u.email,
+		c.updated_at
+	FROM
+		carts AS c
+		JOIN users AS u ON u.user_id = c.user_id
+	WHERE
+		c.updated_at <= :before AND
+		(c.reminded_at IS NULL OR c.reminded_at < c.updated_at) AND
+		u.active AND
+		(
+			EXISTS (SELECT 1 FROM cart_items AS i WHERE i.user_id = c.user_id) OR
+			EXISTS (SELECT 1 FROM cart_bundles AS b WHERE b.user_id = c.user_id)
+		) AND
+		NOT EXISTS (SELECT 1 FROM cart_reminder_optouts AS o WHERE o.user_id = c.user_id)
+	ORDER BY
+		c.updated_at`
+
+	abs := []Abandoned{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &abs); err != nil {
+		return nil, fmt.Errorf("selecting abandoned carts: %w", err)
+	}
+
+	return abs, nil
+}
+
+// MarkReminded records when the user was reminded of the cart.
+// The cart is not considered modified.
+func MarkReminded(ctx context.Context, db sqlx.ExtContext, userID string, at time.Time) error {
+	in := struct {
+		UserID     string    `db:"user_id"`
+		RemindedAt time.Time `db:"reminded_at"`
+	}{
+		UserID:     userID,
+		RemindedAt: at,
+	}
+
+	const q = `
+	UPDATE carts
+	SET
+		reminded_at = :reminded_at
+	WHERE
+		user_id = :user_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("marking cart of user[%s] as reminded: %w", userID, err)
+	}
+
+	return nil
+}
+
+// CreateOptOut stops the reminders of the abandoned carts of the user.
+func CreateOptOut(ctx context.Context, db sqlx.ExtContext, userID string, at time.Time) error {
+	in := struct {
+		UserID    string    `db:"user_id"`
+		CreatedAt time.Time `db:"created_at"`
+	}{
+		UserID:    userID,
+		CreatedAt: at,
+	}
+
+	const q = `
+	INSERT INTO cart_reminder_optouts
+		(user_id, created_at)
+	VALUES
+	(:user_id, :created_at)
+	ON CONFLICT (user_id) DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("opting out user[%s] of cart reminders: %w", userID, err)
+	}
+
+	return nil
+}
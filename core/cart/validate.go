@@ -0,0 +1,91 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleValidate reports the items of the user's cart which changed
+// since they were added: courses whose price changed, which are not
+// available anymore or which the user owns already. It allows warning
+// users before they check out.
+func HandleValidate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		items, err := FetchItems(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s] cart items: %w", clm.UserID, err)
+		}
+
+		ids := make([]string, len(items))
+		for i, it := range items {
+			ids[i] = it.CourseID
+		}
+
+		cs, err := course.FetchByIDs(ctx, db, ids)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]course.Course, len(cs))
+		for _, c := range cs {
+			current[c.ID] = c
+		}
+
+		pcs, err := FetchAddedPrices(ctx, db, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		added := make(map[string]course.PriceChange, len(pcs))
+		for _, pc := range pcs {
+			added[pc.CourseID] = pc
+		}
+
+		owned, err := course.FetchByOwner(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching courses owned by user[%s]: %w", clm.UserID, err)
+		}
+
+		ownedIDs := make(map[string]bool, len(owned))
+		for _, o := range owned {
+			ownedIDs[o.ID] = true
+		}
+
+		v := Validation{Issues: []Issue{}}
+		for _, it := range items {
+			c, ok := current[it.CourseID]
+			switch {
+			case !ok:
+				v.Issues = append(v.Issues, Issue{CourseID: it.CourseID, Reason: IssueUnavailable})
+			case ownedIDs[c.ID]:
+				v.Issues = append(v.Issues, Issue{CourseID: c.ID, Reason: IssueOwned})
+			default:
+				pc, ok := added[c.ID]
+				if ok && pc.Price != c.Price {
+					v.Issues = append(v.Issues, Issue{
+						CourseID:   c.ID,
+						Reason:     IssuePriceChanged,
+						AddedPrice: &pc.Price,
+						Price:      &c.Price,
+					})
+				}
+			}
+		}
+		v.Valid = len(v.Issues) == 0
+
+		return web.Respond(ctx, w, v, http.StatusOK)
+	}
+}
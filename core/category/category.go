@@ -0,0 +1,54 @@
+// Package category manages the topics of the catalog: each course
+// belongs to some categories, e.g. programming, and it's labelled with
+// tags, e.g. golang, so that the catalog can be browsed by topic.
+package category
+
+import (
+	"regexp"
+	"time"
+)
+
+// slugRe matches the slugs of categories and tags, e.g. web-development.
+var slugRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidSlug reports whether slug can identify a category or a tag.
+func ValidSlug(slug string) bool {
+	return slugRe.MatchString(slug)
+}
+
+// Category models a category of courses.
+type Category struct {
+	ID        string    `json:"id" db:"category_id"`
+	Name      string    `json:"name" db:"name"`
+	Slug      string    `json:"slug" db:"slug"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Tag models a label of courses.
+type Tag struct {
+	ID        string    `json:"id" db:"tag_id"`
+	Name      string    `json:"name" db:"name"`
+	Slug      string    `json:"slug" db:"slug"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// TopicNew contains the information needed to create a category or a
+// tag. Slugs identify them in the filters of the catalog.
+type TopicNew struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Slug string `json:"slug" validate:"required,max=100"`
+}
+
+// TopicUp contains the information of a category or a tag which can
+// be updated.
+type TopicUp struct {
+	Name *string `json:"name" validate:"omitempty,max=100"`
+	Slug *string `json:"slug" validate:"omitempty,max=100"`
+}
+
+// CourseTopics lists all the categories, or the tags, of a course.
+type CourseTopics struct {
+	IDs []string `json:"ids" validate:"max=100,dive,required"`
+}
@@ -0,0 +1,375 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errTopicNotFound is returned when some of the categories, or tags,
+// to set on a course don't exist.
+var errTopicNotFound = errors.New("some of the passed ids don't exist")
+
+// HandleListCategories allows users to fetch all the categories.
+func HandleListCategories(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		cs, err := FetchCategories(ctx, db)
+		if err != nil {
+			return fmt.Errorf("fetching categories: %w", err)
+		}
+
+		return web.Respond(ctx, w, cs, http.StatusOK)
+	}
+}
+
+// HandleCreateCategory allows administrators to add new categories.
+func HandleCreateCategory(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var cn TopicNew
+		if err := decodeNew(w, r, &cn); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+
+		c := Category{
+			ID:        validate.GenerateID(),
+			Name:      cn.Name,
+			Slug:      cn.Slug,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		if err := CreateCategory(ctx, db, c); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "passed slug already exists", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, c, http.StatusCreated)
+	}
+}
+
+// HandleUpdateCategory allows administrators to rename categories.
+func HandleUpdateCategory(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		categoryID := web.Param(r, "id")
+
+		if err := validate.CheckID(categoryID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var cup TopicUp
+		if err := decodeUp(w, r, &cup); err != nil {
+			return err
+		}
+
+		c, err := FetchCategory(ctx, db, categoryID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if cup.Name != nil {
+			c.Name = *cup.Name
+		}
+		if cup.Slug != nil {
+			c.Slug = *cup.Slug
+		}
+		c.UpdatedAt = time.Now().UTC()
+
+		if err := UpdateCategory(ctx, db, c); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "passed slug already exists", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, c, http.StatusOK)
+	}
+}
+
+// HandleDeleteCategory allows administrators to delete categories,
+// which are removed from all their courses.
+func HandleDeleteCategory(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		categoryID := web.Param(r, "id")
+
+		if err := validate.CheckID(categoryID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := DeleteCategory(ctx, db, categoryID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleSetCourseCategories allows administrators to replace all the
+// categories of a course.
+func HandleSetCourseCategories(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID, ids, err := decodeCourseTopics(ctx, db, w, r)
+		if err != nil {
+			return err
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			n, err := SetCourseCategories(ctx, tx, courseID, ids)
+			if err != nil {
+				return err
+			}
+			if n != len(ids) {
+				return errTopicNotFound
+			}
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, errTopicNotFound) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		cs, err := FetchCategoriesByCourse(ctx, db, courseID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, cs, http.StatusOK)
+	}
+}
+
+// HandleListTags allows users to fetch all the tags.
+func HandleListTags(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		ts, err := FetchTags(ctx, db)
+		if err != nil {
+			return fmt.Errorf("fetching tags: %w", err)
+		}
+
+		return web.Respond(ctx, w, ts, http.StatusOK)
+	}
+}
+
+// HandleCreateTag allows administrators to add new tags.
+func HandleCreateTag(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var tn TopicNew
+		if err := decodeNew(w, r, &tn); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+
+		t := Tag{
+			ID:        validate.GenerateID(),
+			Name:      tn.Name,
+			Slug:      tn.Slug,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		if err := CreateTag(ctx, db, t); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "passed slug already exists", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, t, http.StatusCreated)
+	}
+}
+
+// HandleUpdateTag allows administrators to rename tags.
+func HandleUpdateTag(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		tagID := web.Param(r, "id")
+
+		if err := validate.CheckID(tagID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var tup TopicUp
+		if err := decodeUp(w, r, &tup); err != nil {
+			return err
+		}
+
+		t, err := FetchTag(ctx, db, tagID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if tup.Name != nil {
+			t.Name = *tup.Name
+		}
+		if tup.Slug != nil {
+			t.Slug = *tup.Slug
+		}
+		t.UpdatedAt = time.Now().UTC()
+
+		if err := UpdateTag(ctx, db, t); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "passed slug already exists", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, t, http.StatusOK)
+	}
+}
+
+// HandleDeleteTag allows administrators to delete tags, which are
+// removed from all their courses.
+func HandleDeleteTag(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		tagID := web.Param(r, "id")
+
+		if err := validate.CheckID(tagID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := DeleteTag(ctx, db, tagID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleSetCourseTags allows administrators to replace all the tags of
+// a course.
+func HandleSetCourseTags(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID, ids, err := decodeCourseTopics(ctx, db, w, r)
+		if err != nil {
+			return err
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			n, err := SetCourseTags(ctx, tx, courseID, ids)
+			if err != nil {
+				return err
+			}
+			if n != len(ids) {
+				return errTopicNotFound
+			}
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, errTopicNotFound) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		ts, err := FetchTagsByCourse(ctx, db, courseID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, ts, http.StatusOK)
+	}
+}
+
+// decodeNew decodes and validates a new category or tag.
+func decodeNew(w http.ResponseWriter, r *http.Request, tn *TopicNew) error {
+	if err := web.Decode(w, r, tn); err != nil {
+		return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+	}
+
+	if err := validate.Check(*tn); err != nil {
+		return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	return checkSlug(tn.Slug)
+}
+
+// decodeUp decodes and validates the update of a category or a tag.
+func decodeUp(w http.ResponseWriter, r *http.Request, tup *TopicUp) error {
+	if err := web.Decode(w, r, tup); err != nil {
+		return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+	}
+
+	if err := validate.Check(*tup); err != nil {
+		return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	if tup.Slug == nil {
+		return nil
+	}
+	return checkSlug(*tup.Slug)
+}
+
+// checkSlug checks slugs are made of lowercase words joined by dashes.
+func checkSlug(slug string) error {
+	if !ValidSlug(slug) {
+		err := fmt.Errorf("invalid slug %q: use lowercase letters, digits and dashes", slug)
+		return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+	return nil
+}
+
+// decodeCourseTopics returns the course of the request, which must
+// exist, and the distinct ids of the categories, or tags, to set on it.
+func decodeCourseTopics(ctx context.Context, db sqlx.ExtContext, w http.ResponseWriter, r *http.Request) (string, []string, error) {
+	courseID := web.Param(r, "course_id")
+
+	if err := validate.CheckID(courseID); err != nil {
+		return "", nil, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	var ct CourseTopics
+	if err := web.Decode(w, r, &ct); err != nil {
+		return "", nil, weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+	}
+
+	if err := validate.Check(ct); err != nil {
+		return "", nil, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	seen := make(map[string]bool, len(ct.IDs))
+	ids := make([]string, 0, len(ct.IDs))
+	for _, id := range ct.IDs {
+		if err := validate.CheckID(id); err != nil {
+			return "", nil, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if _, err := course.Fetch(ctx, db, courseID); err != nil {
+		err := fmt.Errorf("fetching course[%s]: %w", courseID, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return "", nil, weberr.NotFound(err)
+		}
+		return "", nil, err
+	}
+
+	return courseID, ids, nil
+}
@@ -0,0 +1,345 @@
+package category
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// CreateCategory inserts a new category.
+func CreateCategory(ctx context.Context, db sqlx.ExtContext, c Category) error {
+	const q = `
+	INSERT INTO categories
+		(category_id, name, slug, created_at, updated_at)
+	VALUES
+		(:category_id, :name, :slug, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, c); err != nil {
+		return fmt.Errorf("inserting category %q: %w", c.Slug, err)
+	}
+
+	return nil
+}
+
+// UpdateCategory modifies the name and the slug of a category.
+func UpdateCategory(ctx context.Context, db sqlx.ExtContext, c Category) error {
+	const q = `
+	UPDATE categories
+	SET
+		name = :name,
+		slug = :slug,
+		updated_at = :updated_at
+	WHERE
+		category_id = :category_id`
+
+	if err := database.NamedExecContext(ctx, db, q, c); err != nil {
+		return fmt.Errorf("updating category[%s]: %w", c.ID, err)
+	}
+
+	return nil
+}
+
+// DeleteCategory removes a category from the courses and deletes it.
+func DeleteCategory(ctx context.Context, db sqlx.ExtContext, id string) error {
+	in := struct {
+		ID string `db:"category_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	DELETE FROM
+		categories
+	WHERE
+		category_id = :category_id
+	RETURNING category_id`
+
+	if err := database.NamedQueryStruct(ctx, db, q, in, &in); err != nil {
+		return fmt.Errorf("deleting category[%s]: %w", id, err)
+	}
+
+	return nil
+}
+
+// FetchCategory returns a category given its id.
+func FetchCategory(ctx context.Context, db sqlx.ExtContext, id string) (Category, error) {
+	in := struct {
+		ID string `db:"category_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		categories
+	WHERE
+		category_id = :category_id`
+
+	var c Category
+	if err := database.NamedQueryStruct(ctx, db, q, in, &c); err != nil {
+		return Category{}, fmt.Errorf("selecting category[%s]: %w", id, err)
+	}
+
+	return c, nil
+}
+
+// FetchCategories returns all the categories, by name.
+func FetchCategories(ctx context.Context, db sqlx.ExtContext) ([]Category, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		categories
+	ORDER BY
+		name, slug`
+
+	cs := []Category{}
+	if err := database.NamedQuerySlice(ctx, db, q, struct{}{}, &cs); err != nil {
+		return nil, fmt.Errorf("selecting categories: %w", err)
+	}
+
+	return cs, nil
+}
+
+// FetchCategoriesByCourse returns the categories of a course, by name.
+func FetchCategoriesByCourse(ctx context.Context, db sqlx.ExtContext, courseID string) ([]Category, error) {
+	in := struct {
+		ID string `db:"course_id"`
+	}{
+		ID: courseID,
+	}
+
+	const q = `
+	SELECT
+		c.*
+	FROM
+		categories AS c
+	INNER JOIN
+		course_categories AS cc ON cc.category_id = c.category_id
+	WHERE
+		cc.course_id = :course_id
+	ORDER BY
+		c.name, c.slug`
+
+	cs := []Category{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cs); err != nil {
+		return nil, fmt.Errorf("selecting categories of course[%s]: %w", courseID, err)
+	}
+
+	return cs, nil
+}
+
+// SetCourseCategories replaces the categories of a course with the
+// passed ones. It returns the number of categories set, which is lower
+// than the ids passed when some don't exist.
+func SetCourseCategories(ctx context.Context, db sqlx.ExtContext, courseID string, ids []string) (int, error) {
+	in := struct {
+		CourseID string         `db:"course_id"`
+		IDs      pq.StringArray `db:"category_ids"`
+	}{
+		CourseID: courseID,
+		IDs:      ids,
+	}
+
+	const del = `
+	DELETE FROM
+		course_categories
+	WHERE
+		course_id = :course_id`
+
+	if err := database.NamedExecContext(ctx, db, del, in); err != nil {
+		return 0, fmt.Errorf("deleting categories of course[%s]: %w", courseID, err)
+	}
+
+	const ins = `
+	INSERT INTO course_categories
+		(course_id, category_id)
+	SELECT
+		:course_id, category_id
+	FROM
+		categories
+	WHERE
+		category_id = ANY(CAST(:category_ids AS UUID[]))
+	RETURNING category_id`
+
+	set := []struct {
+		ID string `db:"category_id"`
+	}{}
+	if err := database.NamedQuerySlice(ctx, db, ins, in, &set); err != nil {
+		return 0, fmt.Errorf("inserting categories of course[%s]: %w", courseID, err)
+	}
+
+	return len(set), nil
+}
+
+// CreateTag inserts a new tag.
+func CreateTag(ctx context.Context, db sqlx.ExtContext, t Tag) error {
+	const q = `
+	INSERT INTO tags
+		(tag_id, name, slug, created_at, updated_at)
+	VALUES
+		(:tag_id, :name, :slug, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, t); err != nil {
+		return fmt.Errorf("inserting tag %q: %w", t.Slug, err)
+	}
+
+	return nil
+}
+
+// UpdateTag modifies the name and the slug of a tag.
+func UpdateTag(ctx context.Context, db sqlx.ExtContext, t Tag) error {
+	const q = `
+	UPDATE tags
+	SET
+		name = :name,
+		slug = :slug,
+		updated_at = :updated_at
+	WHERE
+		tag_id = :tag_id`
+
+	if err := database.NamedExecContext(ctx, db, q, t); err != nil {
+		return fmt.Errorf("updating tag[%s]: %w", t.ID, err)
+	}
+
+	return nil
+}
+
+// DeleteTag removes a tag from the courses and deletes it.
+func DeleteTag(ctx context.Context, db sqlx.ExtContext, id string) error {
+	in := struct {
+		ID string `db:"tag_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	DELETE FROM
+		tags
+	WHERE
+		tag_id = :tag_id
+	RETURNING tag_id`
+
+	if err := database.NamedQueryStruct(ctx, db, q, in, &in); err != nil {
+		return fmt.Errorf("deleting tag[%s]: %w", id, err)
+	}
+
+	return nil
+}
+
+// FetchTag returns a tag given its id.
+func FetchTag(ctx context.Context, db sqlx.ExtContext, id string) (Tag, error) {
+	in := struct {
+		ID string `db:"tag_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tags
+	WHERE
+		tag_id = :tag_id`
+
+	var t Tag
+	if err := database.NamedQueryStruct(ctx, db, q, in, &t); err != nil {
+		return Tag{}, fmt.Errorf("selecting tag[%s]: %w", id, err)
+	}
+
+	return t, nil
+}
+
+// FetchTags returns all the tags, by name.
+func FetchTags(ctx context.Context, db sqlx.ExtContext) ([]Tag, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		tags
+	ORDER BY
+		name, slug`
+
+	ts := []Tag{}
+	if err := database.NamedQuerySlice(ctx, db, q, struct{}{}, &ts); err != nil {
+		return nil, fmt.Errorf("selecting tags: %w", err)
+	}
+
+	return ts, nil
+}
+
+// FetchTagsByCourse returns the tags of a course, by name.
+func FetchTagsByCourse(ctx context.Context, db sqlx.ExtContext, courseID string) ([]Tag, error) {
+	in := struct {
+		ID string `db:"course_id"`
+	}{
+		ID: courseID,
+	}
+
+	const q = `
+	SELECT
+		t.*
+	FROM
+		tags AS t
+	INNER JOIN
+		course_tags AS ct ON ct.tag_id = t.tag_id
+	WHERE
+		ct.course_id = :course_id
+	ORDER BY
+		t.name, t.slug`
+
+	ts := []Tag{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ts); err != nil {
+		return nil, fmt.Errorf("selecting tags of course[%s]: %w", courseID, err)
+	}
+
+	return ts, nil
+}
+
+// SetCourseTags replaces the tags of a course with the passed ones,
+// like SetCourseCategories.
+func SetCourseTags(ctx context.Context, db sqlx.ExtContext, courseID string, ids []string) (int, error) {
+	in := struct {
+		CourseID string         `db:"course_id"`
+		IDs      pq.StringArray `db:"tag_ids"`
+	}{
+		CourseID: courseID,
+		IDs:      ids,
+	}
+
+	const del = `
+	DELETE FROM
+		course_tags
+	WHERE
+		course_id = :course_id`
+
+	if err := database.NamedExecContext(ctx, db, del, in); err != nil {
+		return 0, fmt.Errorf("deleting tags of course[%s]: %w", courseID, err)
+	}
+
+	const ins = `
+	INSERT INTO course_tags
+		(course_id, tag_id)
+	SELECT
+		:course_id, tag_id
+	FROM
+		tags
+	WHERE
+		tag_id = ANY(CAST(:tag_ids AS UUID[]))
+	RETURNING tag_id`
+
+	set := []struct {
+		ID string `db:"tag_id"`
+	}{}
+	if err := database.NamedQuerySlice(ctx, db, ins, in, &set); err != nil {
+		return 0, fmt.Errorf("inserting tags of course[%s]: %w", courseID, err)
+	}
+
+	return len(set), nil
+}
@@ -9,6 +9,14 @@ import (
 const (
 	RoleAdmin = "ADMIN"
 	RoleUser  = "USER"
+
+	// RoleInstructor is the role of the users who teach courses: they
+	// manage only the courses they are the instructors of.
+	RoleInstructor = "INSTRUCTOR"
+
+	// RoleGuest is the role of the placeholder users created by guest
+	// checkouts, until their account is claimed.
+	RoleGuest = "GUEST"
 )
 
 // Claims represents the authorization claims stored in the session.
@@ -50,6 +58,17 @@ func IsAdmin(ctx context.Context) bool {
 	return c.Role == RoleAdmin
 }
 
+// IsInstructor checks if the session contained in the context,
+// if any, belongs to an instructor.
+func IsInstructor(ctx context.Context) bool {
+	c, err := Get(ctx)
+	if err != nil {
+		return false
+	}
+
+	return c.Role == RoleInstructor
+}
+
 // IsAdmin checks if the session contained in the context,
 // if any, belongs to the passed user id.
 // Returns false if no session is found or if the user is not
@@ -0,0 +1,91 @@
+// Package coupon manages the discount codes which can be applied
+// to the courses being bought.
+package coupon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+// ErrInvalid is returned when a coupon can't be applied.
+var ErrInvalid = errors.New("invalid coupon")
+
+// Kinds of coupons.
+const (
+	KindPercent = "percent"
+	KindFixed   = "fixed"
+)
+
+// Coupon models discount codes. Percent coupons take a percentage off
+// the price, fixed ones take Amount off the price of each course they
+// apply to. Coupons apply to every course, unless CourseID is set.
+type Coupon struct {
+	ID        string        `json:"id" db:"coupon_id"`
+	Code      string        `json:"code" db:"code"`
+	Kind      string        `json:"kind" db:"kind"`
+	Percent   int           `json:"percent" db:"percent"`
+	Amount    *money.Amount `json:"amount" db:"amount"`
+	CourseID  *string       `json:"courseId" db:"course_id"`
+	ExpiresAt *time.Time    `json:"expiresAt" db:"expires_at"`
+	MaxUses   *int          `json:"maxUses" db:"max_uses"`
+	Uses      int           `json:"uses" db:"uses"`
+	CreatedAt time.Time     `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time     `json:"updatedAt" db:"updated_at"`
+}
+
+// CouponNew contains the information needed to create a coupon.
+type CouponNew struct {
+	Code      string        `json:"code" validate:"required,alphanum,max=32"`
+	Kind      string        `json:"kind" validate:"required,oneof=percent fixed"`
+	Percent   int           `json:"percent" validate:"required_if=Kind percent,gte=0,lte=100"`
+	Amount    *money.Amount `json:"amount" validate:"required_if=Kind fixed"`
+	CourseID  *string       `json:"courseId" validate:"omitempty,uuid4"`
+	ExpiresAt *time.Time    `json:"expiresAt"`
+	MaxUses   *int          `json:"maxUses" validate:"omitempty,gte=1"`
+}
+
+// CouponUp contains the information of a coupon that can be updated.
+type CouponUp struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+	MaxUses   *int       `json:"maxUses" validate:"omitempty,gte=1"`
+}
+
+// Normalize returns the canonical form of a code, codes are case insensitive.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Usable checks whether the coupon can still be redeemed.
+func (c Coupon) Usable(now time.Time) error {
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return fmt.Errorf("%w: coupon %s expired", ErrInvalid, c.Code)
+	}
+	if c.MaxUses != nil && c.Uses >= *c.MaxUses {
+		return fmt.Errorf("%w: coupon %s has been used up", ErrInvalid, c.Code)
+	}
+	return nil
+}
+
+// Discount returns the discount on the price of a course.
+// It returns false if the coupon doesn't apply to the course.
+func (c Coupon) Discount(courseID string, price money.Amount) (money.Amount, bool) {
+	if c.CourseID != nil && *c.CourseID != courseID {
+		return money.Amount{}, false
+	}
+
+	switch c.Kind {
+	case KindPercent:
+		return money.New(price.Units*int64(c.Percent)/100, price.Currency), true
+	case KindFixed:
+		if c.Amount == nil || c.Amount.Currency != price.Currency {
+			return money.Amount{}, false
+		}
+		return money.New(min(c.Amount.Units, price.Units), price.Currency), true
+	}
+
+	return money.Amount{}, false
+}
@@ -0,0 +1,154 @@
+package coupon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleCreate allows administrators to create a coupon.
+func HandleCreate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var cn CouponNew
+		if err := web.Decode(w, r, &cn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(cn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		now := time.Now().UTC()
+		c := Coupon{
+			ID:        validate.GenerateID(),
+			Code:      Normalize(cn.Code),
+			Kind:      cn.Kind,
+			CourseID:  cn.CourseID,
+			ExpiresAt: cn.ExpiresAt,
+			MaxUses:   cn.MaxUses,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		switch c.Kind {
+		case KindPercent:
+			c.Percent = cn.Percent
+		case KindFixed:
+			c.Amount = cn.Amount
+		}
+
+		if err := Create(ctx, db, c); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "coupon code already exists", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, c, http.StatusCreated)
+	}
+}
+
+// HandleList allows administrators to list all the coupons.
+func HandleList(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		cs, err := FetchAll(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, cs, http.StatusOK)
+	}
+}
+
+// HandleShow allows administrators to see a coupon and its uses.
+func HandleShow(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := web.Param(r, "id")
+
+		if err := validate.CheckID(id); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		c, err := Fetch(ctx, db, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, c, http.StatusOK)
+	}
+}
+
+// HandleUpdate allows administrators to change when a coupon expires
+// and how many times it can be used. Discounts can't be changed, so
+// that coupons already handed out keep their value.
+func HandleUpdate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := web.Param(r, "id")
+
+		if err := validate.CheckID(id); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var cu CouponUp
+		if err := web.Decode(w, r, &cu); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(cu); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		c, err := Fetch(ctx, db, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if cu.ExpiresAt != nil {
+			c.ExpiresAt = cu.ExpiresAt
+		}
+		if cu.MaxUses != nil {
+			c.MaxUses = cu.MaxUses
+		}
+		c.UpdatedAt = time.Now().UTC()
+
+		if err := Update(ctx, db, c); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, c, http.StatusOK)
+	}
+}
+
+// HandleDelete allows administrators to delete a coupon.
+func HandleDelete(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := web.Param(r, "id")
+
+		if err := validate.CheckID(id); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := Delete(ctx, db, id); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
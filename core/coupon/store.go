@@ -0,0 +1,184 @@
+package coupon
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Create inserts a new coupon.
+func Create(ctx context.Context, db sqlx.ExtContext, c Coupon) error {
+	const q = `
+	INSERT INTO coupons
+		(coupon_id, code, kind, percent, amount, course_id, expires_at, max_uses, uses, created_at, updated_at)
+	VALUES
+		(:coupon_id, :code, :kind, :percent, :amount, :course_id, :expires_at, :max_uses, :uses, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, c); err != nil {
+		return fmt.Errorf("inserting coupon: %w", err)
+	}
+
+	return nil
+}
+
+// Update updates the expiration and the usage limit of a coupon.
+func Update(ctx context.Context, db sqlx.ExtContext, c Coupon) error {
+	const q = `
+	UPDATE coupons
+	SET
+		expires_at = :expires_at,
+		max_uses = :max_uses,
+		updated_at = :updated_at
+	WHERE
+		coupon_id = :coupon_id`
+
+	if err := database.NamedExecContext(ctx, db, q, c); err != nil {
+		return fmt.Errorf("updating coupon[%s]: %w", c.ID, err)
+	}
+
+	return nil
+}
+
+// Delete removes a coupon. Orders keep the codes they were bought with.
+func Delete(ctx context.Context, db sqlx.ExtContext, id string) error {
+	in := struct {
+		ID string `db:"coupon_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	DELETE FROM
+		coupons
+	WHERE
+		coupon_id = :coupon_id
+	RETURNING coupon_id`
+
+	var out struct {
+		ID string `db:"coupon_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("deleting coupon[%s]: %w", id, err)
+	}
+
+	return nil
+}
+
+// Fetch returns the coupon with the passed id.
+func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Coupon, error) {
+	in := struct {
+		ID string `db:"coupon_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		coupons
+	WHERE
+		coupon_id = :coupon_id`
+
+	var c Coupon
+	if err := database.NamedQueryStruct(ctx, db, q, in, &c); err != nil {
+		return Coupon{}, fmt.Errorf("selecting coupon[%s]: %w", id, err)
+	}
+
+	return c, nil
+}
+
+// FetchByCode returns the coupon with the passed code.
+func FetchByCode(ctx context.Context, db sqlx.ExtContext, code string) (Coupon, error) {
+	in := struct {
+		Code string `db:"code"`
+	}{
+		Code: Normalize(code),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		coupons
+	WHERE
+		code = :code`
+
+	var c Coupon
+	if err := database.NamedQueryStruct(ctx, db, q, in, &c); err != nil {
+		return Coupon{}, fmt.Errorf("selecting coupon %s: %w", in.Code, err)
+	}
+
+	return c, nil
+}
+
+// FetchAll returns all the coupons, the most recent first.
+func FetchAll(ctx context.Context, db sqlx.ExtContext) ([]Coupon, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		coupons
+	ORDER BY
+		created_at DESC`
+
+	cs := []Coupon{}
+	if err := database.NamedQuerySlice(ctx, db, q, struct{}{}, &cs); err != nil {
+		return nil, fmt.Errorf("selecting coupons: %w", err)
+	}
+
+	return cs, nil
+}
+
+// Redeem counts a use of the coupon with the passed code.
+// It returns database.ErrDBNotFound if the coupon has been used up.
+func Redeem(ctx context.Context, db sqlx.ExtContext, code string) error {
+	in := struct {
+		Code string `db:"code"`
+	}{
+		Code: Normalize(code),
+	}
+
+	const q = `
+	UPDATE coupons
+	SET
+		uses = uses + 1
+	WHERE
+		code = :code AND
+		(max_uses IS NULL OR uses < max_uses)
+	RETURNING code`
+
+	var out struct {
+		Code string `db:"code"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("redeeming coupon %s: %w", in.Code, err)
+	}
+
+	return nil
+}
+
+// Release gives back a use of the coupon with the passed code,
+// e.g. when the order it was redeemed for is abandoned.
+func Release(ctx context.Context, db sqlx.ExtContext, code string) error {
+	in := struct {
+		Code string `db:"code"`
+	}{
+		Code: Normalize(code),
+	}
+
+	const q = `
+	UPDATE coupons
+	SET
+		uses = GREATEST(uses - 1, 0)
+	WHERE
+		code = :code`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("releasing coupon %s: %w", in.Code, err)
+	}
+
+	return nil
+}
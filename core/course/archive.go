@@ -0,0 +1,79 @@
+package course
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleArchive allows administrators to archive a course: it's hidden
+// from the catalog and can't be bought anymore, while its owners keep
+// their access.
+func HandleArchive(db *sqlx.DB, l Listener) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return archive(ctx, w, r, db, l, StatusArchived)
+	}
+}
+
+// HandleRestore allows administrators to publish again an archived
+// course.
+func HandleRestore(db *sqlx.DB, l Listener) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return archive(ctx, w, r, db, l, StatusPublished)
+	}
+}
+
+// archive moves the course passed via the id path parameter in or out of
+// the archive, depending on status.
+func archive(ctx context.Context, w http.ResponseWriter, r *http.Request, db *sqlx.DB, l Listener, status string) error {
+	courseID := web.Param(r, "id")
+
+	if err := validate.CheckID(courseID); err != nil {
+		return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	course, err := Fetch(ctx, db, courseID)
+	if err != nil {
+		err := fmt.Errorf("fetching course[%s]: %w", courseID, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return weberr.NotFound(err)
+		}
+		return err
+	}
+
+	if status == StatusPublished && course.Status != StatusArchived {
+		err := fmt.Errorf("course[%s] is not archived", courseID)
+		return weberr.NewError(err, "course not archived", http.StatusConflict)
+	}
+
+	if status == StatusPublished {
+		ok, err := HasVideos(ctx, db, courseID)
+		if err != nil {
+			return err
+		}
+		if !ok || course.Price.IsZero() {
+			return weberr.NewError(errNotPublishable, errNotPublishable.Error(), http.StatusUnprocessableEntity)
+		}
+	}
+
+	if status == StatusArchived && course.Status == StatusArchived {
+		err := fmt.Errorf("course[%s] is archived already", courseID)
+		return weberr.NewError(err, "course archived already", http.StatusConflict)
+	}
+
+	if course, err = SetStatus(ctx, db, courseID, status, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	l.CourseChanged(course)
+
+	return web.Respond(ctx, w, course, http.StatusOK)
+}
@@ -0,0 +1,108 @@
+package course
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleShowCompletion allows users to fetch how far they went in a
+// course they own.
+func HandleShowCompletion(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if _, err := FetchOwned(ctx, db, courseID, clm.UserID); err != nil {
+			err := fmt.Errorf("fetching course[%s] owned by user[%s]: %w", courseID, clm.UserID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+			}
+			return err
+		}
+
+		cs, err := fetchCompletions(ctx, db, clm.UserID, []string{courseID})
+		if err != nil {
+			return err
+		}
+		if len(cs) == 0 {
+			return weberr.NotFound(fmt.Errorf("completion of course[%s]: %w", courseID, database.ErrDBNotFound))
+		}
+
+		return web.Respond(ctx, w, cs[0], http.StatusOK)
+	}
+}
+
+// HandleListCompletion allows users to fetch how far they went in every
+// course they own.
+func HandleListCompletion(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		courses, err := FetchByOwner(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching courses of user[%s]: %w", clm.UserID, err)
+		}
+
+		ids := make([]string, len(courses))
+		for i, c := range courses {
+			ids[i] = c.ID
+		}
+
+		cs, err := fetchCompletions(ctx, db, clm.UserID, ids)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, cs, http.StatusOK)
+	}
+}
+
+// fetchCompletions returns the completion of the courses by the user,
+// with their percentage.
+func fetchCompletions(ctx context.Context, db sqlx.ExtContext, userID string, ids []string) ([]Completion, error) {
+	if len(ids) == 0 {
+		return []Completion{}, nil
+	}
+
+	cs, err := FetchCompletions(ctx, db, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cs {
+		cs[i].Percent = percent(cs[i])
+	}
+
+	return cs, nil
+}
+
+// percent computes the percentage of completion of a course, where
+// every video weighs its progress and every quiz 100 once passed.
+func percent(c Completion) int {
+	total := c.Videos + c.Quizzes
+	if total == 0 {
+		return 0
+	}
+
+	return (c.Progress + 100*c.QuizzesPassed) / total
+}
@@ -1,35 +1,296 @@
 package course
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/lib/pq"
+)
 
 // Course models courses.
 // A user can own many courses and a course
 // can be owned by many users.
+// Courses with AccessDays are rented: access expires after that many
+// days and it can be renewed with a RenewalDiscount percentage.
+// Only published courses are in the catalog, drafts are seen only by
+// administrators and by the instructor of the course, if any.
+// Locked tells whether the user has not completed the prerequisites
+// of the course yet.
+// Objectives, Requirements, Audience and LongDescription, in markdown,
+// are the content of the landing page of the course.
+// Slug identifies the course in public URLs in place of its ID.
 type Course struct {
-	ID          string    `json:"id" db:"course_id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	ImageURL    string    `json:"imageUrl" db:"image_url"`
-	Price       int       `json:"price" db:"price"`
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-	Version     int       `json:"-" db:"version"`
+	ID              string         `json:"id" db:"course_id"`
+	Name            string         `json:"name" db:"name"`
+	Slug            string         `json:"slug" db:"slug"`
+	Description     string         `json:"description" db:"description"`
+	ImageURL        string         `json:"imageUrl" db:"image_url"`
+	Price           money.Amount   `json:"price" db:"price"`
+	AccessDays      int            `json:"accessDays" db:"access_days"`
+	RenewalDiscount int            `json:"renewalDiscount" db:"renewal_discount"`
+	Status          string         `json:"status" db:"status"`
+	InstructorID    *string        `json:"instructorId,omitempty" db:"instructor_id"`
+	Objectives      pq.StringArray `json:"objectives" db:"objectives"`
+	Requirements    pq.StringArray `json:"requirements" db:"requirements"`
+	Audience        pq.StringArray `json:"audience" db:"audience"`
+	LongDescription string         `json:"longDescription" db:"long_description"`
+	CreatedAt       time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updatedAt" db:"updated_at"`
+	Version         int            `json:"-" db:"version"`
+	Sale            *Sale          `json:"sale,omitempty" db:"-"`
+	Rating          *Rating        `json:"rating,omitempty" db:"-"`
+	Locked          bool           `json:"locked" db:"-"`
+}
+
+// Statuses of the lifecycle of a course.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+// Sale models the discount of a running sale on a course. Price is the
+// discounted one, the price of the course is the original.
+type Sale struct {
+	CourseID string       `json:"-" db:"course_id"`
+	Percent  int          `json:"percent" db:"percent"`
+	Price    money.Amount `json:"price" db:"-"`
+	EndsAt   time.Time    `json:"endsAt" db:"ends_at"`
+}
+
+// Rating summarizes the stars given to a course by its owners.
+type Rating struct {
+	CourseID string  `json:"-" db:"course_id"`
+	Average  float64 `json:"average" db:"average"`
+	Count    int     `json:"count" db:"count"`
+}
+
+// RatingNew contains the stars, from 1 to 5, given by a user to a
+// course.
+type RatingNew struct {
+	Stars int `json:"stars" validate:"required,min=1,max=5"`
+}
+
+// Sorts of the courses of the catalog.
+const (
+	SortRelevance = "relevance"
+	SortNewest    = "newest"
+	SortPopular   = "popular"
+	SortPrice     = "price"
+	SortRating    = "rating"
+)
+
+// Filter selects the courses of the catalog. Empty fields match every
+// course, whatever its status. Prices are in minor units of the currency of the courses.
+type Filter struct {
+	Query     string         `db:"query"`
+	Category  string         `db:"category"`
+	Tags      pq.StringArray `db:"tags"`
+	MinPrice  *int64         `db:"min_price"`
+	MaxPrice  *int64         `db:"max_price"`
+	MinRating float64        `db:"min_rating"`
+	Status    string         `db:"status"`
+	Sort      string         `db:"sort"`
+	Limit     int            `db:"limit"`
+	Offset    int            `db:"offset"`
 }
 
 // CourseNew contains the information needed to
 // create a new course.
 type CourseNew struct {
-	Name        string `json:"name" validate:"required"`
-	Description string `json:"description" validate:"required"`
-	Price       int    `json:"price" validate:"required,gte=0,lte=10000"`
-	ImageURL    string `json:"imageUrl" validate:"required"`
+	Name            string       `json:"name" validate:"required"`
+	Description     string       `json:"description" validate:"required"`
+	Price           money.Amount `json:"price"`
+	ImageURL        string       `json:"imageUrl" validate:"required"`
+	AccessDays      int          `json:"accessDays" validate:"gte=0"`
+	RenewalDiscount int          `json:"renewalDiscount" validate:"gte=0,lte=100"`
+	Status          string       `json:"status" validate:"omitempty,oneof=draft published"`
+	InstructorID    *string      `json:"instructorId"`
+	Objectives      []string     `json:"objectives" validate:"max=20,dive,required,max=300"`
+	Requirements    []string     `json:"requirements" validate:"max=20,dive,required,max=300"`
+	Audience        []string     `json:"audience" validate:"max=20,dive,required,max=300"`
+	LongDescription string       `json:"longDescription" validate:"max=20000"`
+}
+
+// PrerequisitesUp lists all the courses to complete before a course.
+// A course is completed once every published video is watched in full.
+type PrerequisitesUp struct {
+	IDs []string `json:"ids" validate:"max=20,dive,required"`
+}
+
+// FeaturedUp lists all the featured courses, in order.
+type FeaturedUp struct {
+	IDs []string `json:"ids" validate:"max=50,dive,required"`
+}
+
+// StatusUp contains the new status of a course.
+type StatusUp struct {
+	Status string `json:"status" validate:"required,oneof=draft published archived"`
 }
 
 // CourseUp contains the information of a course
-// that can be updated.
+// that can be updated. Passing a list replaces all of its entries.
 type CourseUp struct {
-	Name        *string `json:"name"`
-	Description *string `json:"description"`
-	Price       *int    `json:"price" validate:"omitempty,gte=0,lte=10000"`
-	ImageURL    *string `json:"imageUrl"`
+	Name            *string       `json:"name"`
+	Description     *string       `json:"description"`
+	Price           *money.Amount `json:"price"`
+	ImageURL        *string       `json:"imageUrl"`
+	AccessDays      *int          `json:"accessDays" validate:"omitempty,gte=0"`
+	RenewalDiscount *int          `json:"renewalDiscount" validate:"omitempty,gte=0,lte=100"`
+	Objectives      []string      `json:"objectives" validate:"omitempty,max=20,dive,required,max=300"`
+	Requirements    []string      `json:"requirements" validate:"omitempty,max=20,dive,required,max=300"`
+	Audience        []string      `json:"audience" validate:"omitempty,max=20,dive,required,max=300"`
+	LongDescription *string       `json:"longDescription" validate:"omitempty,max=20000"`
+}
+
+// Metadata contains the information of a course tracked by revisions,
+// which a rollback restores.
+type Metadata struct {
+	Name            string       `json:"name"`
+	Description     string       `json:"description"`
+	Price           money.Amount `json:"price"`
+	ImageURL        string       `json:"imageUrl"`
+	AccessDays      int          `json:"accessDays"`
+	RenewalDiscount int          `json:"renewalDiscount"`
+	Objectives      []string     `json:"objectives"`
+	Requirements    []string     `json:"requirements"`
+	Audience        []string     `json:"audience"`
+	LongDescription string       `json:"longDescription"`
+}
+
+// Revision records an edit of the metadata of a course, or of one of
+// its videos when VideoID is set, made by the editor. Snapshot is the
+// metadata after the edit, Diff maps the changed fields to a Change.
+type Revision struct {
+	ID        string          `json:"id" db:"revision_id"`
+	CourseID  string          `json:"courseId" db:"course_id"`
+	VideoID   *string         `json:"videoId,omitempty" db:"video_id"`
+	EditorID  *string         `json:"editorId" db:"editor_id"`
+	Snapshot  json.RawMessage `json:"snapshot" db:"snapshot"`
+	Diff      json.RawMessage `json:"diff" db:"diff"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// Change tells the value of a field before and after an edit.
+type Change struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// Grant models the access to a course given to a user by an
+// administrator without payment, e.g. for support cases or promotions.
+// Revoked grants are kept, so that grants form an audit trail.
+type Grant struct {
+	ID           string     `json:"id" db:"grant_id"`
+	CourseID     string     `json:"courseId" db:"course_id"`
+	UserID       string     `json:"userId" db:"user_id"`
+	Reason       string     `json:"reason" db:"reason"`
+	GrantedBy    *string    `json:"grantedBy" db:"granted_by"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	RevokedBy    *string    `json:"revokedBy,omitempty" db:"revoked_by"`
+	RevokeReason string     `json:"revokeReason,omitempty" db:"revoke_reason"`
+}
+
+// GrantNew contains the information needed to grant a course.
+type GrantNew struct {
+	UserID string `json:"userId" validate:"required"`
+	Reason string `json:"reason" validate:"required,max=2000"`
+}
+
+// GrantRevoke contains the information needed to revoke a grant.
+type GrantRevoke struct {
+	Reason string `json:"reason" validate:"required,max=2000"`
+}
+
+// FAQ models an entry of the frequently asked questions of a course,
+// listed by index.
+type FAQ struct {
+	ID        string    `json:"id" db:"faq_id"`
+	CourseID  string    `json:"courseId" db:"course_id"`
+	Index     int       `json:"index" db:"index"`
+	Question  string    `json:"question" db:"question"`
+	Answer    string    `json:"answer" db:"answer"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	Version   int       `json:"-" db:"version"`
+}
+
+// FAQNew contains the information needed to add an entry to the FAQ of
+// a course.
+type FAQNew struct {
+	Index    int    `json:"index" validate:"required,gte=1"`
+	Question string `json:"question" validate:"required,max=500"`
+	Answer   string `json:"answer" validate:"required,max=5000"`
+}
+
+// FAQUp specifies the data of the entries of a FAQ that can be updated.
+type FAQUp struct {
+	Index    *int    `json:"index" validate:"omitempty,gte=1"`
+	Question *string `json:"question" validate:"omitempty,min=1,max=500"`
+	Answer   *string `json:"answer" validate:"omitempty,min=1,max=5000"`
+}
+
+// PriceChange records a change of the price of a course,
+// together with the user who made it.
+type PriceChange struct {
+	CourseID  string       `json:"courseId" db:"course_id"`
+	Price     money.Amount `json:"price" db:"price"`
+	ChangedBy string       `json:"changedBy" db:"changed_by"`
+	CreatedAt time.Time    `json:"createdAt" db:"created_at"`
+}
+
+// RegionalPrice overrides the price of a course for the buyers of a
+// region, e.g. to adjust it to the local purchasing power.
+// Region is an ISO 3166-1 alpha-2 country code.
+type RegionalPrice struct {
+	CourseID  string       `json:"courseId" db:"course_id"`
+	Region    string       `json:"region" db:"region"`
+	Price     money.Amount `json:"price" db:"price"`
+	CreatedAt time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time    `json:"updatedAt" db:"updated_at"`
+}
+
+// RegionalPriceNew contains the information needed to set the price
+// of a course in a region.
+type RegionalPriceNew struct {
+	Price money.Amount `json:"price"`
+}
+
+// Listener is notified whenever a course is created or updated.
+type Listener interface {
+	CourseChanged(Course)
+}
+
+// Completion tells how far a user went in a course. Videos count for
+// the share of them watched and quizzes for being passed, so Percent
+// reaches 100 once every published video is watched in full and every
+// quiz of the course is passed.
+type Completion struct {
+	CourseID        string `json:"courseId" db:"course_id"`
+	CourseName      string `json:"courseName" db:"course_name"`
+	Videos          int    `json:"videos" db:"videos"`
+	VideosCompleted int    `json:"videosCompleted" db:"videos_completed"`
+	Progress        int    `json:"-" db:"progress"`
+	Quizzes         int    `json:"quizzes" db:"quizzes"`
+	QuizzesPassed   int    `json:"quizzesPassed" db:"quizzes_passed"`
+	Percent         int    `json:"percent" db:"-"`
+}
+
+// Access models the right of a user to watch a course.
+// A nil ExpiresAt means the access never expires.
+type Access struct {
+	CourseID  string     `json:"courseId" db:"course_id"`
+	UserID    string     `json:"userId" db:"user_id"`
+	ExpiresAt *time.Time `json:"expiresAt" db:"expires_at"`
+}
+
+// texts returns the passed list of texts as stored, empty rather than
+// nil, so that it's never null.
+func texts(ss []string) pq.StringArray {
+	if ss == nil {
+		return pq.StringArray{}
+	}
+	return ss
 }
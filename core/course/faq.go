@@ -0,0 +1,136 @@
+package course
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleCreateFAQ allows administrators to add an entry to the FAQ of a
+// course. The FAQ is shown expanding the course, e.g. ?expand=faq.
+func HandleCreateFAQ(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var fn FAQNew
+		if err := web.Decode(w, r, &fn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(fn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if _, err := Fetch(ctx, db, courseID); err != nil {
+			err := fmt.Errorf("fetching course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		now := time.Now().UTC()
+		f := FAQ{
+			ID:        validate.GenerateID(),
+			CourseID:  courseID,
+			Index:     fn.Index,
+			Question:  fn.Question,
+			Answer:    fn.Answer,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Version:   1,
+		}
+
+		if err := CreateFAQ(ctx, db, f); err != nil {
+			err := fmt.Errorf("creating faq of course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "index already taken", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, f, http.StatusCreated)
+	}
+}
+
+// HandleUpdateFAQ allows administrators to edit or move an entry of the
+// FAQ of a course.
+func HandleUpdateFAQ(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		faqID := web.Param(r, "id")
+
+		if err := validate.CheckID(faqID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var fup FAQUp
+		if err := web.Decode(w, r, &fup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(fup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		f, err := FetchFAQ(ctx, db, faqID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if fup.Index != nil {
+			f.Index = *fup.Index
+		}
+		if fup.Question != nil {
+			f.Question = *fup.Question
+		}
+		if fup.Answer != nil {
+			f.Answer = *fup.Answer
+		}
+		f.UpdatedAt = time.Now().UTC()
+
+		if f, err = UpdateFAQ(ctx, db, f); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "index already taken", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, f, http.StatusOK)
+	}
+}
+
+// HandleDeleteFAQ allows administrators to remove an entry of the FAQ
+// of a course.
+func HandleDeleteFAQ(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		faqID := web.Param(r, "id")
+
+		if err := validate.CheckID(faqID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := DeleteFAQ(ctx, db, faqID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
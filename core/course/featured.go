@@ -0,0 +1,96 @@
+package course
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errFeaturedNotFound is returned when a featured course doesn't exist.
+var errFeaturedNotFound = errors.New("featured course not found")
+
+// HandleListFeatured returns the courses curated for the homepage, in
+// order, together with the discount of the sales running on them and
+// their rating. Administrators see the featured drafts as well.
+func HandleListFeatured(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courses, err := FetchFeatured(ctx, db, claims.IsAdmin(ctx))
+		if err != nil {
+			return err
+		}
+
+		if err := WithSales(ctx, db, courses, time.Now().UTC()); err != nil {
+			return fmt.Errorf("fetching sales of courses: %w", err)
+		}
+
+		if err := WithRatings(ctx, db, courses); err != nil {
+			return fmt.Errorf("fetching ratings of courses: %w", err)
+		}
+
+		if err := WithLocks(ctx, db, courses); err != nil {
+			return fmt.Errorf("fetching locks of courses: %w", err)
+		}
+
+		return web.Respond(ctx, w, courses, http.StatusOK)
+	}
+}
+
+// HandleSetFeatured allows administrators to set the courses curated
+// for the homepage, in order, replacing the current ones.
+func HandleSetFeatured(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var fup FeaturedUp
+		if err := web.Decode(w, r, &fup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(fup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		seen := make(map[string]bool, len(fup.IDs))
+		ids := make([]string, 0, len(fup.IDs))
+		for _, id := range fup.IDs {
+			if err := validate.CheckID(id); err != nil {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			n, err := SetFeatured(ctx, tx, ids, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			if n != len(ids) {
+				return errFeaturedNotFound
+			}
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, errFeaturedNotFound) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		cs, err := FetchFeatured(ctx, db, true)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, cs, http.StatusOK)
+	}
+}
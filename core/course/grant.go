@@ -0,0 +1,141 @@
+package course
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleGrant allows administrators to give a user access to a course
+// without payment, recording the reason.
+func HandleGrant(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var gn GrantNew
+		if err := web.Decode(w, r, &gn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(gn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := validate.CheckID(gn.UserID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if _, err := Fetch(ctx, db, courseID); err != nil {
+			err := fmt.Errorf("fetching course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if _, err := user.Fetch(ctx, db, gn.UserID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NewError(err, "user not found", http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		g := Grant{
+			ID:        validate.GenerateID(),
+			CourseID:  courseID,
+			UserID:    gn.UserID,
+			Reason:    gn.Reason,
+			GrantedBy: &clm.UserID,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		if err := CreateGrant(ctx, db, g); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "course already granted to the user", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, g, http.StatusCreated)
+	}
+}
+
+// HandleRevokeGrant allows administrators to revoke the access to a
+// course granted to a user, recording the reason. Purchases are not
+// affected: they are revoked by refunding them.
+func HandleRevokeGrant(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+		userID := web.Param(r, "user_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := validate.CheckID(userID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var gr GrantRevoke
+		if err := web.Decode(w, r, &gr); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(gr); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		g, err := RevokeGrant(ctx, db, courseID, userID, clm.UserID, gr.Reason, time.Now().UTC())
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, g, http.StatusOK)
+	}
+}
+
+// HandleListGrants allows administrators to fetch the audit trail of the
+// grants of a course, the most recent first.
+func HandleListGrants(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		gs, err := FetchGrants(ctx, db, courseID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, gs, http.StatusOK)
+	}
+}
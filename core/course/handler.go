@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -15,8 +18,11 @@ import (
 	"github.com/jatolentino/tutorialspoint/validate"
 )
 
-// HandleCreate allows administrators to add new courses.
-func HandleCreate(db *sqlx.DB) web.Handler {
+// HandleCreate allows administrators and instructors to add new
+// courses. Courses are published right away unless created as drafts,
+// to be published later via HandleSetStatus. Instructors always create
+// drafts, which they teach; administrators can pick their instructor.
+func HandleCreate(db *sqlx.DB, l Listener) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		var c CourseNew
 		if err := web.Decode(w, r, &c); err != nil {
@@ -30,28 +36,75 @@ func HandleCreate(db *sqlx.DB) web.Handler {
 		now := time.Now().UTC()
 
 		course := Course{
-			ID:          validate.GenerateID(),
-			Name:        c.Name,
-			Description: c.Description,
-			Price:       c.Price,
-			ImageURL:    c.ImageURL,
-			CreatedAt:   now,
-			UpdatedAt:   now,
+			ID:              validate.GenerateID(),
+			Name:            c.Name,
+			Description:     c.Description,
+			Price:           c.Price,
+			ImageURL:        c.ImageURL,
+			AccessDays:      c.AccessDays,
+			RenewalDiscount: c.RenewalDiscount,
+			Status:          c.Status,
+			Objectives:      texts(c.Objectives),
+			Requirements:    texts(c.Requirements),
+			Audience:        texts(c.Audience),
+			LongDescription: c.LongDescription,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if course.Status == "" {
+			course.Status = StatusPublished
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		switch {
+		case clm.Role == claims.RoleInstructor:
+			course.InstructorID = &clm.UserID
+			course.Status = StatusDraft
+		case c.InstructorID != nil:
+			if err := checkInstructor(ctx, db, *c.InstructorID); err != nil {
+				return err
+			}
+			course.InstructorID = c.InstructorID
 		}
 
-		if err := Create(ctx, db, course); err != nil {
+		pc := PriceChange{
+			CourseID:  course.ID,
+			Price:     course.Price,
+			ChangedBy: clm.UserID,
+			CreatedAt: now,
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if course.Slug, err = NewSlug(ctx, tx, course.Name); err != nil {
+				return err
+			}
+			if err := Create(ctx, tx, course); err != nil {
+				return err
+			}
+			return CreatePriceChange(ctx, tx, pc)
+		})
+
+		if err != nil {
 			if errors.Is(err, database.ErrDBDuplicatedEntry) {
 				return weberr.NewError(err, "passed course already exists", http.StatusUnprocessableEntity)
 			}
 			return err
 		}
 
+		l.CourseChanged(course)
+
 		return web.Respond(ctx, w, course, http.StatusCreated)
 	}
 }
 
-// HandleUpdate allows administrators to update existing courses.
-func HandleUpdate(db *sqlx.DB) web.Handler {
+// HandleUpdate allows administrators, and the instructor of the
+// course, to update existing courses. Edits are recorded as revisions,
+// see HandleListRevisions.
+func HandleUpdate(db *sqlx.DB, l Listener) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		courseID := web.Param(r, "id")
 
@@ -77,6 +130,17 @@ func HandleUpdate(db *sqlx.DB) web.Handler {
 			return err
 		}
 
+		if !CanManage(ctx, course) {
+			err := fmt.Errorf("user trying to update course[%s] of another instructor", courseID)
+			return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		before := course
 		if cup.Name != nil {
 			course.Name = *cup.Name
 		}
@@ -89,28 +153,221 @@ func HandleUpdate(db *sqlx.DB) web.Handler {
 		if cup.ImageURL != nil {
 			course.ImageURL = *cup.ImageURL
 		}
+		if cup.AccessDays != nil {
+			course.AccessDays = *cup.AccessDays
+		}
+		if cup.RenewalDiscount != nil {
+			course.RenewalDiscount = *cup.RenewalDiscount
+		}
+		if cup.Objectives != nil {
+			course.Objectives = cup.Objectives
+		}
+		if cup.Requirements != nil {
+			course.Requirements = cup.Requirements
+		}
+		if cup.Audience != nil {
+			course.Audience = cup.Audience
+		}
+		if cup.LongDescription != nil {
+			course.LongDescription = *cup.LongDescription
+		}
 		course.UpdatedAt = time.Now().UTC()
 
-		if course, err = Update(ctx, db, course); err != nil {
-			return fmt.Errorf("updating course[%s]: %w", course.ID, err)
+		// Record the new price and the revision only if the course gets
+		// updated (and viceversa).
+		if course, err = update(ctx, db, before, course, clm.UserID); err != nil {
+			return err
 		}
 
+		l.CourseChanged(course)
+
 		return web.Respond(ctx, w, course, http.StatusOK)
 	}
 }
 
-// HandleList allows users to fetch all available courses.
+// HandleListPrices allows administrators to fetch the price history of a course.
+func HandleListPrices(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		prices, err := FetchPriceHistory(ctx, db, courseID)
+		if err != nil {
+			return fmt.Errorf("fetching price history of course[%s]: %w", courseID, err)
+		}
+
+		return web.Respond(ctx, w, prices, http.StatusOK)
+	}
+}
+
+// maxBatchIDs is the maximum number of courses which can be fetched at once.
+const maxBatchIDs = 100
+
+// maxFilterTags is the maximum number of tags courses can be filtered by.
+const maxFilterTags = 10
+
+// Size of the pages of the catalog.
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// HandleList allows users to search the catalog, see parseFilter,
+// together with the discount of the sales running on the courses and
+// their rating. Courses are paginated via the page and limit query
+// parameters, the newest first unless sorted otherwise; when searching
+// text the most relevant come first.
+// Courses can be browsed by topic passing the slug of a category and
+// of tags, which must all be on the returned courses.
+// When the ids query parameter is passed (e.g. ?ids=a,b,c) only
+// those courses are returned, in the same order.
 func HandleList(db *sqlx.DB) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		courses, err := FetchAll(ctx, db)
+		if r.URL.Query().Has("ids") {
+			ids := strings.Split(r.URL.Query().Get("ids"), ",")
+			if len(ids) > maxBatchIDs {
+				return weberr.BadRequest(fmt.Errorf("at most %d ids can be passed", maxBatchIDs))
+			}
+
+			for _, id := range ids {
+				if err := validate.CheckID(id); err != nil {
+					return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+				}
+			}
+
+			courses, err := FetchByIDs(ctx, db, ids)
+			if err != nil {
+				return fmt.Errorf("fetching courses by ids: %w", err)
+			}
+
+			if !claims.IsAdmin(ctx) {
+				courses = slices.DeleteFunc(courses, func(c Course) bool { return c.Status == StatusDraft })
+			}
+
+			if err := WithSales(ctx, db, courses, time.Now().UTC()); err != nil {
+				return fmt.Errorf("fetching sales of courses: %w", err)
+			}
+
+			if err := WithRatings(ctx, db, courses); err != nil {
+				return fmt.Errorf("fetching ratings of courses: %w", err)
+			}
+
+			if err := WithLocks(ctx, db, courses); err != nil {
+				return fmt.Errorf("fetching locks of courses: %w", err)
+			}
+
+			return web.Respond(ctx, w, courses, http.StatusOK)
+		}
+
+		f, err := parseFilter(r)
 		if err != nil {
-			return fmt.Errorf("fetching all courses: %w", err)
+			return weberr.BadRequest(err)
+		}
+
+		// Only administrators browse the courses outside the catalog.
+		if !claims.IsAdmin(ctx) {
+			f.Status = StatusPublished
+		}
+
+		courses, err := Search(ctx, db, f)
+		if err != nil {
+			return err
+		}
+
+		if err := WithSales(ctx, db, courses, time.Now().UTC()); err != nil {
+			return fmt.Errorf("fetching sales of courses: %w", err)
+		}
+
+		if err := WithRatings(ctx, db, courses); err != nil {
+			return fmt.Errorf("fetching ratings of courses: %w", err)
+		}
+
+		if err := WithLocks(ctx, db, courses); err != nil {
+			return fmt.Errorf("fetching locks of courses: %w", err)
 		}
 
 		return web.Respond(ctx, w, courses, http.StatusOK)
 	}
 }
 
+// parseFilter extracts the filter of the catalog from the query
+// parameters, e.g. ?q=go&category=programming&tag=golang&min_price=1000
+// &max_price=5000&min_rating=4&sort=popular&page=2&limit=20.
+// Administrators can filter by status as well, e.g. ?status=draft.
+func parseFilter(r *http.Request) (Filter, error) {
+	qs := r.URL.Query()
+
+	page, err := web.ParsePage(r, defaultPageLimit, maxPageLimit)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	f := Filter{
+		Query:    strings.TrimSpace(qs.Get("q")),
+		Category: qs.Get("category"),
+		Status:   qs.Get("status"),
+		Sort:     qs.Get("sort"),
+		Limit:    page.Limit,
+		Offset:   page.Offset(),
+	}
+
+	tags := qs["tag"]
+	if len(tags) > maxFilterTags {
+		return Filter{}, fmt.Errorf("at most %d tags can be passed", maxFilterTags)
+	}
+	slices.Sort(tags)
+	f.Tags = slices.Compact(tags)
+
+	if f.MinPrice, err = parseUnits(qs.Get("min_price")); err != nil {
+		return Filter{}, fmt.Errorf("min_price %w", err)
+	}
+	if f.MaxPrice, err = parseUnits(qs.Get("max_price")); err != nil {
+		return Filter{}, fmt.Errorf("max_price %w", err)
+	}
+
+	if v := qs.Get("min_rating"); v != "" {
+		if f.MinRating, err = strconv.ParseFloat(v, 64); err != nil || f.MinRating < 0 || f.MinRating > 5 {
+			return Filter{}, errors.New("min_rating must be between 0 and 5")
+		}
+	}
+
+	switch f.Status {
+	case "", StatusDraft, StatusPublished, StatusArchived:
+	default:
+		return Filter{}, fmt.Errorf("unknown status %q", f.Status)
+	}
+
+	switch f.Sort {
+	case "":
+		f.Sort = SortNewest
+		if f.Query != "" {
+			f.Sort = SortRelevance
+		}
+	case SortRelevance, SortNewest, SortPopular, SortPrice, SortRating:
+	default:
+		return Filter{}, fmt.Errorf("unknown sort %q", f.Sort)
+	}
+
+	return f, nil
+}
+
+// parseUnits parses an optional amount of minor units.
+func parseUnits(v string) (*int64, error) {
+	if v == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return nil, errors.New("must be a non negative number of minor units")
+	}
+
+	return &n, nil
+}
+
 // HandleList allows users to fetch courses they own.
 func HandleListOwned(db *sqlx.DB) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -124,12 +381,75 @@ func HandleListOwned(db *sqlx.DB) web.Handler {
 			return fmt.Errorf("fetching courses of user[%s]: %w", clm.UserID, err)
 		}
 
+		if err := WithLocks(ctx, db, courses); err != nil {
+			return fmt.Errorf("fetching locks of courses of user[%s]: %w", clm.UserID, err)
+		}
+
 		return web.Respond(ctx, w, courses, http.StatusOK)
 	}
 }
 
-// HandleShow allows users to fetch the information of a specific course.
-func HandleShow(db *sqlx.DB) web.Handler {
+// HandleShow allows users to fetch the information of a specific course,
+// together with the discount of the sale running on it, if any.
+// Courses are identified by either their ID or their slug.
+// Related resources registered in exps can be included via ?expand=.
+func HandleShow(db *sqlx.DB, exps web.Expansions) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		course, err := fetchByRef(ctx, db, web.Param(r, "id"))
+		if err != nil {
+			return err
+		}
+		courseID := course.ID
+
+		if course.Status == StatusDraft && !CanManage(ctx, course) {
+			return weberr.NotFound(fmt.Errorf("course[%s] is a draft", courseID))
+		}
+
+		cs := []Course{course}
+		if err := WithSales(ctx, db, cs, time.Now().UTC()); err != nil {
+			return fmt.Errorf("fetching sales of course[%s]: %w", courseID, err)
+		}
+		if err := WithRatings(ctx, db, cs); err != nil {
+			return fmt.Errorf("fetching rating of course[%s]: %w", courseID, err)
+		}
+		if err := WithLocks(ctx, db, cs); err != nil {
+			return fmt.Errorf("fetching lock of course[%s]: %w", courseID, err)
+		}
+		course = cs[0]
+
+		expanded, err := exps.Expand(ctx, r, courseID)
+		if err != nil {
+			if errors.Is(err, web.ErrUnknownExpansion) {
+				return weberr.NewError(err, err.Error(), http.StatusBadRequest)
+			}
+			return fmt.Errorf("expanding course[%s]: %w", courseID, err)
+		}
+
+		if expanded == nil {
+			return web.Respond(ctx, w, course, http.StatusOK)
+		}
+
+		resp := struct {
+			Course
+			Expand map[string]any `json:"expand"`
+		}{
+			Course: course,
+			Expand: expanded,
+		}
+
+		return web.Respond(ctx, w, resp, http.StatusOK)
+	}
+}
+
+// errNotPublishable is returned when publishing a course which is not
+// ready to be sold.
+var errNotPublishable = errors.New("courses need a valid price and at least one video to be published")
+
+// HandleSetStatus allows administrators, and the instructor of the
+// course, to move it through its lifecycle. Courses are published only
+// when they have a valid price, zero for free courses, and at least one
+// video.
+func HandleSetStatus(db *sqlx.DB, l Listener) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		courseID := web.Param(r, "id")
 
@@ -137,6 +457,15 @@ func HandleShow(db *sqlx.DB) web.Handler {
 			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
 		}
 
+		var sup StatusUp
+		if err := web.Decode(w, r, &sup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(sup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
 		course, err := Fetch(ctx, db, courseID)
 		if err != nil {
 			err := fmt.Errorf("fetching course[%s]: %w", courseID, err)
@@ -146,6 +475,27 @@ func HandleShow(db *sqlx.DB) web.Handler {
 			return err
 		}
 
+		if !CanManage(ctx, course) {
+			err := fmt.Errorf("user trying to change the status of course[%s] of another instructor", courseID)
+			return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+		}
+
+		if sup.Status == StatusPublished && course.Status != StatusPublished {
+			ok, err := HasVideos(ctx, db, courseID)
+			if err != nil {
+				return err
+			}
+			if !ok || course.Price.Currency == "" || course.Price.Units < 0 {
+				return weberr.NewError(errNotPublishable, errNotPublishable.Error(), http.StatusUnprocessableEntity)
+			}
+		}
+
+		if course, err = SetStatus(ctx, db, courseID, sup.Status, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		l.CourseChanged(course)
+
 		return web.Respond(ctx, w, course, http.StatusOK)
 	}
 }
@@ -0,0 +1,132 @@
+package course
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// errPrerequisiteNotFound is returned when a prerequisite doesn't exist.
+	errPrerequisiteNotFound = errors.New("prerequisite not found")
+
+	// errPrerequisiteCycle is returned when a course would require itself.
+	errPrerequisiteCycle = errors.New("a course can't require itself, even through other courses")
+)
+
+// WithLocks sets whether the passed courses are locked for the user of
+// the session. The courses managed by the user are never locked.
+func WithLocks(ctx context.Context, db sqlx.ExtContext, cs []Course) error {
+	var userID string
+	if clm, err := claims.Get(ctx); err == nil {
+		userID = clm.UserID
+	}
+
+	ids := make([]string, 0, len(cs))
+	for i, c := range cs {
+		cs[i].Locked = false
+		if !CanManage(ctx, c) {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	locked, err := FetchLocked(ctx, db, ids, userID)
+	if err != nil {
+		return err
+	}
+
+	lm := make(map[string]bool, len(locked))
+	for _, id := range locked {
+		lm[id] = true
+	}
+	for i := range cs {
+		cs[i].Locked = lm[cs[i].ID]
+	}
+
+	return nil
+}
+
+// HandleSetPrerequisites allows administrators to set the courses to
+// complete before a course, replacing the current ones.
+func HandleSetPrerequisites(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var pup PrerequisitesUp
+		if err := web.Decode(w, r, &pup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(pup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		seen := make(map[string]bool, len(pup.IDs))
+		ids := make([]string, 0, len(pup.IDs))
+		for _, id := range pup.IDs {
+			if err := validate.CheckID(id); err != nil {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+
+		if _, err := Fetch(ctx, db, courseID); err != nil {
+			err := fmt.Errorf("fetching course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			cycle, err := Requires(ctx, tx, ids, courseID)
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return errPrerequisiteCycle
+			}
+
+			n, err := SetPrerequisites(ctx, tx, courseID, ids, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			if n != len(ids) {
+				return errPrerequisiteNotFound
+			}
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, errPrerequisiteCycle) || errors.Is(err, errPrerequisiteNotFound) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		cs, err := FetchPrerequisites(ctx, db, courseID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, cs, http.StatusOK)
+	}
+}
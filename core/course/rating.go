@@ -0,0 +1,96 @@
+package course
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// WithRatings sets the rating of the passed courses, nil for those
+// not rated yet.
+func WithRatings(ctx context.Context, db sqlx.ExtContext, cs []Course) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(cs))
+	for i, c := range cs {
+		ids[i] = c.ID
+	}
+
+	rs, err := FetchRatings(ctx, db, ids)
+	if err != nil {
+		return err
+	}
+
+	ratings := make(map[string]Rating, len(rs))
+	for _, r := range rs {
+		ratings[r.CourseID] = r
+	}
+
+	for i := range cs {
+		r, ok := ratings[cs[i].ID]
+		if !ok {
+			cs[i].Rating = nil
+			continue
+		}
+		cs[i].Rating = &r
+	}
+
+	return nil
+}
+
+// HandleRate allows users to rate the courses they own, from 1 to 5
+// stars. Rating a course again replaces the previous stars.
+func HandleRate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var rn RatingNew
+		if err := web.Decode(w, r, &rn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(rn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		c, err := FetchOwned(ctx, db, courseID, clm.UserID)
+		if err != nil {
+			err := fmt.Errorf("fetching course[%s] owned by user[%s]: %w", courseID, clm.UserID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NewError(err, "only owners can rate a course", http.StatusForbidden)
+			}
+			return err
+		}
+
+		if err := SetRating(ctx, db, courseID, clm.UserID, rn.Stars, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		cs := []Course{c}
+		if err := WithRatings(ctx, db, cs); err != nil {
+			return fmt.Errorf("fetching rating of course[%s]: %w", courseID, err)
+		}
+
+		return web.Respond(ctx, w, cs[0].Rating, http.StatusOK)
+	}
+}
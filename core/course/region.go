@@ -0,0 +1,163 @@
+package course
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errRegionCurrency is returned when setting the price of a course in
+// a region with a currency different from the one of the course.
+var errRegionCurrency = errors.New("regional prices must be in the currency of the course")
+
+// Localize returns the course priced for the buyers of the passed
+// region, if it has a price there. Otherwise, or when the region is
+// unknown, the course is returned as is.
+func Localize(ctx context.Context, db sqlx.ExtContext, c Course, region string) (Course, error) {
+	if region == "" {
+		return c, nil
+	}
+
+	rp, err := FetchRegionalPrice(ctx, db, c.ID, region)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return c, nil
+		}
+		return Course{}, err
+	}
+
+	// Prices set before a change of currency of the course are stale.
+	if rp.Price.Currency != c.Price.Currency {
+		return c, nil
+	}
+
+	c.Price = rp.Price
+	return c, nil
+}
+
+// regionParam returns the region passed in the path, checking that it
+// is an ISO 3166-1 alpha-2 country code.
+func regionParam(r *http.Request) (string, error) {
+	in := struct {
+		Region string `validate:"iso3166_1_alpha2"`
+	}{
+		Region: strings.ToUpper(web.Param(r, "region")),
+	}
+
+	if err := validate.Check(in); err != nil {
+		return "", err
+	}
+
+	return in.Region, nil
+}
+
+// HandleListRegionalPrices allows administrators to fetch the prices of
+// a course in the regions which override it.
+func HandleListRegionalPrices(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		rps, err := FetchRegionalPrices(ctx, db, courseID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, rps, http.StatusOK)
+	}
+}
+
+// HandleSetRegionalPrice allows administrators to set the price of a
+// course in the region passed in the path. Regional prices must be in
+// the currency of the course, so that carts never mix currencies.
+func HandleSetRegionalPrice(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		region, err := regionParam(r)
+		if err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var rpn RegionalPriceNew
+		if err := web.Decode(w, r, &rpn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(rpn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		c, err := Fetch(ctx, db, courseID)
+		if err != nil {
+			err := fmt.Errorf("fetching passed course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if rpn.Price.Currency != c.Price.Currency {
+			err := fmt.Errorf("%w: %s", errRegionCurrency, c.Price.Currency)
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		now := time.Now().UTC()
+		rp := RegionalPrice{
+			CourseID:  courseID,
+			Region:    region,
+			Price:     rpn.Price,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		if err := SetRegionalPrice(ctx, db, rp); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, rp, http.StatusOK)
+	}
+}
+
+// HandleDeleteRegionalPrice allows administrators to drop the price of
+// a course in the region passed in the path: its buyers are charged the
+// price of the course again.
+func HandleDeleteRegionalPrice(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		region, err := regionParam(r)
+		if err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := DeleteRegionalPrice(ctx, db, courseID, region); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,61 @@
+package course
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jmoiron/sqlx"
+)
+
+// Size of the pages of the related courses.
+const (
+	defaultRelatedLimit = 10
+	maxRelatedLimit     = 50
+)
+
+// HandleListRelated returns the courses related to a course by their
+// categories, their tags and by the students who bought both, see
+// FetchRelated, e.g. to show what students also bought. Courses are
+// paginated via the page and limit query parameters. The course is
+// identified by either its ID or its slug.
+func HandleListRelated(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		page, err := web.ParsePage(r, defaultRelatedLimit, maxRelatedLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		course, err := fetchByRef(ctx, db, web.Param(r, "id"))
+		if err != nil {
+			return err
+		}
+		courseID := course.ID
+
+		if course.Status == StatusDraft && !CanManage(ctx, course) {
+			return weberr.NotFound(fmt.Errorf("course[%s] is a draft", courseID))
+		}
+
+		courses, err := FetchRelated(ctx, db, courseID, page.Limit, page.Offset())
+		if err != nil {
+			return err
+		}
+
+		if err := WithSales(ctx, db, courses, time.Now().UTC()); err != nil {
+			return fmt.Errorf("fetching sales of courses: %w", err)
+		}
+
+		if err := WithRatings(ctx, db, courses); err != nil {
+			return fmt.Errorf("fetching ratings of courses: %w", err)
+		}
+
+		if err := WithLocks(ctx, db, courses); err != nil {
+			return fmt.Errorf("fetching locks of courses: %w", err)
+		}
+
+		return web.Respond(ctx, w, courses, http.StatusOK)
+	}
+}
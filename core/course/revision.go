@@ -0,0 +1,243 @@
+package course
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// Size of the pages of the history of a course.
+const (
+	defaultRevisionLimit = 50
+	maxRevisionLimit     = 200
+)
+
+// NewRevision returns the revision of an edit of a course, or of the
+// video when videoID is not nil, from the metadata before and after the
+// edit. It returns false when no field changed.
+func NewRevision(courseID string, videoID *string, editorID string, before, after any, now time.Time) (Revision, bool, error) {
+	snapshot, err := json.Marshal(after)
+	if err != nil {
+		return Revision{}, false, fmt.Errorf("marshalling snapshot: %w", err)
+	}
+
+	var from, to map[string]json.RawMessage
+	if err := unmarshalFields(before, &from); err != nil {
+		return Revision{}, false, err
+	}
+	if err := json.Unmarshal(snapshot, &to); err != nil {
+		return Revision{}, false, fmt.Errorf("unmarshalling snapshot: %w", err)
+	}
+
+	changes := make(map[string]Change)
+	for field, v := range to {
+		if string(from[field]) != string(v) {
+			changes[field] = Change{From: from[field], To: v}
+		}
+	}
+	if len(changes) == 0 {
+		return Revision{}, false, nil
+	}
+
+	diff, err := json.Marshal(changes)
+	if err != nil {
+		return Revision{}, false, fmt.Errorf("marshalling diff: %w", err)
+	}
+
+	rev := Revision{
+		ID:        validate.GenerateID(),
+		CourseID:  courseID,
+		VideoID:   videoID,
+		Snapshot:  snapshot,
+		Diff:      diff,
+		CreatedAt: now,
+	}
+	if editorID != "" {
+		rev.EditorID = &editorID
+	}
+
+	return rev, true, nil
+}
+
+// unmarshalFields unmarshals the JSON fields of v into fields.
+func unmarshalFields(v any, fields *map[string]json.RawMessage) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshalling fields: %w", err)
+	}
+	if err := json.Unmarshal(data, fields); err != nil {
+		return fmt.Errorf("unmarshalling fields: %w", err)
+	}
+	return nil
+}
+
+// metadata returns the metadata of a course tracked by revisions.
+func metadata(c Course) Metadata {
+	return Metadata{
+		Name:            c.Name,
+		Description:     c.Description,
+		Price:           c.Price,
+		ImageURL:        c.ImageURL,
+		AccessDays:      c.AccessDays,
+		RenewalDiscount: c.RenewalDiscount,
+		Objectives:      texts(c.Objectives),
+		Requirements:    texts(c.Requirements),
+		Audience:        texts(c.Audience),
+		LongDescription: c.LongDescription,
+	}
+}
+
+// update stores the edit of a course from before to after, recording
+// its new price and a revision of its metadata, if changed, within the
+// same transaction.
+func update(ctx context.Context, db *sqlx.DB, before Course, after Course, editorID string) (Course, error) {
+	rev, changed, err := NewRevision(after.ID, nil, editorID, metadata(before), metadata(after), after.UpdatedAt)
+	if err != nil {
+		return Course{}, err
+	}
+
+	err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+		if after, err = Update(ctx, tx, after); err != nil {
+			return err
+		}
+
+		if after.Price != before.Price {
+			pc := PriceChange{
+				CourseID:  after.ID,
+				Price:     after.Price,
+				ChangedBy: editorID,
+				CreatedAt: after.UpdatedAt,
+			}
+			if err := CreatePriceChange(ctx, tx, pc); err != nil {
+				return err
+			}
+		}
+
+		if !changed {
+			return nil
+		}
+		return CreateRevision(ctx, tx, rev)
+	})
+
+	if err != nil {
+		return Course{}, fmt.Errorf("updating course[%s]: %w", after.ID, err)
+	}
+
+	return after, nil
+}
+
+// HandleListRevisions allows the managers of a course to fetch the
+// history of the edits of the course and of its videos, the most
+// recent first, paginated via the page and limit query parameters.
+func HandleListRevisions(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		page, err := web.ParsePage(r, defaultRevisionLimit, maxRevisionLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		if err := CheckManager(ctx, db, courseID); err != nil {
+			return err
+		}
+
+		revs, err := FetchRevisions(ctx, db, courseID, page.Limit, page.Offset())
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, revs, http.StatusOK)
+	}
+}
+
+// HandleRollback allows the managers of a course to restore its
+// metadata as it was after the passed revision. The rollback is an edit
+// itself, so it's recorded as a new revision. Revisions of videos can't
+// be rolled back from here.
+func HandleRollback(db *sqlx.DB, l Listener) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+		revisionID := web.Param(r, "revision_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+		if err := validate.CheckID(revisionID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		course, err := Fetch(ctx, db, courseID)
+		if err != nil {
+			err := fmt.Errorf("fetching passed course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if !CanManage(ctx, course) {
+			err := fmt.Errorf("user trying to roll back course[%s] of another instructor", courseID)
+			return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+		}
+
+		rev, err := FetchRevision(ctx, db, courseID, revisionID)
+		if err != nil {
+			err := fmt.Errorf("fetching revision[%s]: %w", revisionID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if rev.VideoID != nil {
+			err := fmt.Errorf("revision[%s] is of video[%s]", revisionID, *rev.VideoID)
+			return weberr.NewError(err, "only revisions of courses can be rolled back", http.StatusUnprocessableEntity)
+		}
+
+		var m Metadata
+		if err := json.Unmarshal(rev.Snapshot, &m); err != nil {
+			return fmt.Errorf("unmarshalling snapshot of revision[%s]: %w", revisionID, err)
+		}
+
+		after := course
+		after.Name = m.Name
+		after.Description = m.Description
+		after.Price = m.Price
+		after.ImageURL = m.ImageURL
+		after.AccessDays = m.AccessDays
+		after.RenewalDiscount = m.RenewalDiscount
+		after.Objectives = texts(m.Objectives)
+		after.Requirements = texts(m.Requirements)
+		after.Audience = texts(m.Audience)
+		after.LongDescription = m.LongDescription
+		after.UpdatedAt = time.Now().UTC()
+
+		if course, err = update(ctx, db, course, after, clm.UserID); err != nil {
+			return err
+		}
+
+		l.CourseChanged(course)
+
+		return web.Respond(ctx, w, course, http.StatusOK)
+	}
+}
@@ -0,0 +1,47 @@
+package course
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WithSales sets on the passed courses the discount of the best sale
+// running on each of them at the passed time. Sales discount the price
+// of the courses as passed, so they stack on regional prices.
+func WithSales(ctx context.Context, db sqlx.ExtContext, cs []Course, now time.Time) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(cs))
+	for i, c := range cs {
+		ids[i] = c.ID
+	}
+
+	ss, err := FetchSales(ctx, db, ids, now)
+	if err != nil {
+		return err
+	}
+
+	sales := make(map[string]Sale, len(ss))
+	for _, s := range ss {
+		sales[s.CourseID] = s
+	}
+
+	for i := range cs {
+		s, ok := sales[cs[i].ID]
+		if !ok {
+			cs[i].Sale = nil
+			continue
+		}
+
+		off := cs[i].Price.Units * int64(s.Percent) / 100
+		s.Price = cs[i].Price
+		s.Price.Units -= off
+		cs[i].Sale = &s
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package course
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxSlugLength is the maximum length of the slug generated from the
+// name of a course, before any suffix.
+const maxSlugLength = 80
+
+// reservedSlugs are the slugs shadowed by the static routes under
+// /courses, so they are never given to a course.
+var reservedSlugs = []string{"featured", "instructed", "owned"}
+
+// slugRe matches the slugs of courses, e.g. go-in-practice-2.
+var slugRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Slugify returns the slug of a course name: the lowercase letters and
+// digits of the name, separated by dashes, e.g. "Go in Practice!"
+// becomes go-in-practice.
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			dash = b.Len() > 0
+			continue
+		}
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+		b.WriteRune(r)
+	}
+
+	slug := strings.TrimRight(b.String(), "-")
+	if slug == "" {
+		return "course"
+	}
+	return slug
+}
+
+// NewSlug returns a slug for a course with the passed name not taken by
+// other courses. Taken and reserved slugs are suffixed by the first free
+// number, e.g. go-in-practice-2.
+func NewSlug(ctx context.Context, db sqlx.ExtContext, name string) (string, error) {
+	base := Slugify(name)
+
+	taken, err := FetchSlugs(ctx, db, base)
+	if err != nil {
+		return "", err
+	}
+	taken = append(taken, reservedSlugs...)
+
+	slug := base
+	for n := 2; slices.Contains(taken, slug); n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+
+	return slug, nil
+}
+
+// fetchByRef returns the course identified by ref, either its ID or its
+// slug.
+func fetchByRef(ctx context.Context, db sqlx.ExtContext, ref string) (Course, error) {
+	var (
+		course Course
+		err    error
+	)
+
+	switch {
+	case validate.CheckID(ref) == nil:
+		course, err = Fetch(ctx, db, ref)
+	case slugRe.MatchString(ref):
+		course, err = FetchBySlug(ctx, db, ref)
+	default:
+		err := fmt.Errorf("invalid course reference %q", ref)
+		return Course{}, weberr.NewError(err, "invalid course id or slug", http.StatusUnprocessableEntity)
+	}
+
+	if err != nil {
+		err := fmt.Errorf("fetching course %q: %w", ref, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Course{}, weberr.NotFound(err)
+		}
+		return Course{}, err
+	}
+
+	return course, nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/jatolentino/tutorialspoint/database"
 )
 
@@ -13,9 +15,11 @@ import (
 func Create(ctx context.Context, db sqlx.ExtContext, course Course) error {
 	const q = `
 	INSERT INTO courses
-		(course_id, name, description, price, image_url, created_at, updated_at)
+		(course_id, name, slug, description, price, image_url, access_days, renewal_discount, status, instructor_id,
+		objectives, requirements, audience, long_description, created_at, updated_at)
 	VALUES
-	(:course_id, :name, :description, :price, :image_url, :created_at, :updated_at)`
+	(:course_id, :name, :slug, :description, :price, :image_url, :access_days, :renewal_discount, :status, :instructor_id,
+		:objectives, :requirements, :audience, :long_description, :created_at, :updated_at)`
 
 	if err := database.NamedExecContext(ctx, db, q, course); err != nil {
 		return fmt.Errorf("inserting course: %w", err)
@@ -34,6 +38,12 @@ func Update(ctx context.Context, db sqlx.ExtContext, course Course) (Course, err
 		description = :description,
 		price = :price,
 		image_url = :image_url,
+		access_days = :access_days,
+		renewal_discount = :renewal_discount,
+		objectives = :objectives,
+		requirements = :requirements,
+		audience = :audience,
+		long_description = :long_description,
 		updated_at = :updated_at,
 		version = version + 1
 	WHERE
@@ -81,6 +91,63 @@ func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Course, error) {
 	return course, nil
 }
 
+// FetchBySlug returns the course identified by the passed slug.
+func FetchBySlug(ctx context.Context, db sqlx.ExtContext, slug string) (Course, error) {
+	in := struct {
+		Slug string `db:"slug"`
+	}{
+		Slug: slug,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		courses
+	WHERE
+		slug = :slug`
+
+	var course Course
+	if err := database.NamedQueryStruct(ctx, db, q, in, &course); err != nil {
+		return Course{}, fmt.Errorf("selecting course %q: %w", slug, err)
+	}
+
+	return course, nil
+}
+
+// FetchSlugs returns the slugs starting with the passed one, either
+// equal to it or followed by a dash and a suffix.
+func FetchSlugs(ctx context.Context, db sqlx.ExtContext, slug string) ([]string, error) {
+	in := struct {
+		Slug string `db:"slug"`
+	}{
+		Slug: slug,
+	}
+
+	const q = `
+	SELECT
+		slug
+	FROM
+		courses
+	WHERE
+		slug = :slug OR
+		STARTS_WITH(slug, :slug || '-')`
+
+	var rows []struct {
+		Slug string `db:"slug"`
+	}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rows); err != nil {
+		return nil, fmt.Errorf("selecting slugs like %q: %w", slug, err)
+	}
+
+	slugs := make([]string, 0, len(rows))
+	for _, r := range rows {
+		slugs = append(slugs, r.Slug)
+	}
+
+	return slugs, nil
+}
+
 // FetchAll returns all courses.
 func FetchAll(ctx context.Context, db sqlx.ExtContext) ([]Course, error) {
 	const q = `
@@ -99,7 +166,150 @@ func FetchAll(ctx context.Context, db sqlx.ExtContext) ([]Course, error) {
 	return cs, nil
 }
 
-// FetchByOwner returns all the courses owned by the passed user.
+// FetchByIDs returns the courses with the passed ids, in the same order.
+// Ids not matching any course are ignored.
+func FetchByIDs(ctx context.Context, db sqlx.ExtContext, ids []string) ([]Course, error) {
+	in := struct {
+		IDs pq.StringArray `db:"course_ids"`
+	}{
+		IDs: ids,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		courses
+	WHERE
+		course_id = ANY(CAST(:course_ids AS UUID[]))
+	ORDER BY
+		array_position(CAST(:course_ids AS UUID[]), course_id)`
+
+	cs := []Course{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cs); err != nil {
+		return nil, fmt.Errorf("selecting courses by ids: %w", err)
+	}
+
+	return cs, nil
+}
+
+// Search returns the page of the courses of the catalog matching the
+// filter, sorted as requested. Courses are sold when bought with a
+// successful order and not refunded.
+func Search(ctx context.Context, db sqlx.ExtContext, f Filter) ([]Course, error) {
+	in := struct {
+		Filter
+		Paid string `db:"paid"`
+	}{
+		Filter: f,
+		Paid:   "success",
+	}
+
+	// The expression of the text search must match the one of the index
+	// to use it. Each sort has its own expression, so that their types
+	// don't need to match.
+	const q = `
+	SELECT
+		c.*
+	FROM
+		courses AS c
+	LEFT JOIN (
+		SELECT
+			course_id,
+			AVG(stars) AS average
+		FROM
+			course_ratings
+		GROUP BY
+			course_id
+	) AS r ON r.course_id = c.course_id
+	LEFT JOIN (
+		SELECT
+			i.course_id,
+			COUNT(*) AS sold
+		FROM
+			orders AS o
+		INNER JOIN
+			order_items AS i ON i.order_id = o.order_id
+		WHERE
+			o.status = :paid AND
+			i.refunded_at IS NULL
+		GROUP BY
+			i.course_id
+	) AS p ON p.course_id = c.course_id
+	WHERE
+		(:status = '' OR c.status = :status) AND
+		(
+			:query = '' OR
+			to_tsvector('english', c.name || ' ' || c.description) @@ plainto_tsquery('english', :query)
+		) AND
+		(
+			:category = '' OR
+			EXISTS (
+				SELECT 1
+				FROM course_categories AS cc
+				INNER JOIN categories AS ca ON ca.category_id = cc.category_id
+				WHERE cc.course_id = c.course_id AND ca.slug = :category
+			)
+		) AND
+		(
+			SELECT COUNT(DISTINCT t.slug)
+			FROM course_tags AS ct
+			INNER JOIN tags AS t ON t.tag_id = ct.tag_id
+			WHERE ct.course_id = c.course_id AND t.slug = ANY(CAST(:tags AS TEXT[]))
+		) = COALESCE(cardinality(CAST(:tags AS TEXT[])), 0) AND
+		(CAST(:min_price AS BIGINT) IS NULL OR (c.price).units >= :min_price) AND
+		(CAST(:max_price AS BIGINT) IS NULL OR (c.price).units <= :max_price) AND
+		COALESCE(r.average, 0) >= :min_rating
+	ORDER BY
+		CASE WHEN :sort = 'relevance' THEN ts_rank(to_tsvector('english', c.name || ' ' || c.description), plainto_tsquery('english', :query)) END DESC,
+		CASE WHEN :sort = 'newest' THEN c.created_at END DESC,
+		CASE WHEN :sort = 'popular' THEN COALESCE(p.sold, 0) END DESC,
+		CASE WHEN :sort = 'price' THEN (c.price).units END,
+		CASE WHEN :sort = 'rating' THEN COALESCE(r.average, 0) END DESC,
+		c.course_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	cs := []Course{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cs); err != nil {
+		return nil, fmt.Errorf("searching courses: %w", err)
+	}
+
+	return cs, nil
+}
+
+// FetchByInstructor returns all the courses taught by the passed
+// instructor, the newest first.
+func FetchByInstructor(ctx context.Context, db sqlx.ExtContext, userID string) ([]Course, error) {
+	in := struct {
+		ID string `db:"instructor_id"`
+	}{
+		ID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		courses
+	WHERE
+		instructor_id = :instructor_id
+	ORDER BY
+		created_at DESC, course_id`
+
+	cs := []Course{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cs); err != nil {
+		return nil, fmt.Errorf("selecting courses of instructor[%s]: %w", userID, err)
+	}
+
+	return cs, nil
+}
+
+// FetchByOwner returns all the courses owned by the passed user,
+// leaving out those whose access has expired. Courses whose seat has
+// been assigned to the user by an organization, gifts redeemed by the
+// user, unless refunded, and courses granted by administrators are
+// owned as well.
 func FetchByOwner(ctx context.Context, db sqlx.ExtContext, userID string) ([]Course, error) {
 	in := struct {
 		ID     string `db:"user_id"`
@@ -113,18 +323,59 @@ func FetchByOwner(ctx context.Context, db sqlx.ExtContext, userID string) ([]Cou
 		Status: "success",
 	}
 
+	// Renewed courses have many items, so select each course once.
 	const q = `
 	SELECT
 		c.*
 	FROM
-		orders AS o
-	INNER JOIN
-		order_items AS i ON i.order_id = o.order_id
-	INNER JOIN
-		courses AS c ON i.course_id = c.course_id
+		courses AS c
 	WHERE
-		o.status = :status AND
-		o.user_id = :user_id
+		c.course_id IN (
+			SELECT
+				i.course_id
+			FROM
+				orders AS o
+			INNER JOIN
+				order_items AS i ON i.order_id = o.order_id
+			WHERE
+				o.status = :status AND
+				o.user_id = :user_id AND
+				o.org_id IS NULL AND
+				NOT o.gift AND
+				i.refunded_at IS NULL AND
+				(i.expires_at IS NULL OR i.expires_at > NOW())
+		) OR
+		c.course_id IN (
+			SELECT
+				sa.course_id
+			FROM
+				seat_assignments AS sa
+			WHERE
+				sa.user_id = :user_id
+		) OR
+		c.course_id IN (
+			SELECT
+				g.course_id
+			FROM
+				gifts AS g
+			INNER JOIN
+				orders AS o ON o.order_id = g.order_id
+			INNER JOIN
+				order_items AS i ON i.order_id = g.order_id AND i.course_id = g.course_id
+			WHERE
+				o.status = :status AND
+				i.refunded_at IS NULL AND
+				g.redeemed_by = :user_id
+		) OR
+		c.course_id IN (
+			SELECT
+				cg.course_id
+			FROM
+				course_grants AS cg
+			WHERE
+				cg.user_id = :user_id AND
+				cg.revoked_at IS NULL
+		)
 	ORDER BY
 		c.course_id`
 
@@ -136,35 +387,1124 @@ func FetchByOwner(ctx context.Context, db sqlx.ExtContext, userID string) ([]Cou
 	return cs, nil
 }
 
-// FetchOwned returns the specified course if the passed user owns it.
+// FetchOwned returns the specified course if the passed user owns it
+// and the access has not expired yet, has been assigned a seat of it,
+// has redeemed it as a gift, has been granted it or is entitled to every course by an active
+// subscription to the all-access plan.
 func FetchOwned(ctx context.Context, db sqlx.ExtContext, courseID string, userID string) (Course, error) {
 	in := struct {
 		UserID   string `db:"user_id"`
 		CourseID string `db:"course_id"`
 		Status   string `db:"status"`
+		Active   string `db:"active"`
 	}{
 		UserID:   userID,
 		CourseID: courseID,
 		Status:   "success",
+		Active:   "active",
 	}
 
 	const q = `
 	SELECT
 		c.*
+	FROM
+		courses AS c
+	WHERE
+		c.course_id = :course_id AND
+		EXISTS (
+			SELECT
+				1
+			FROM
+				orders AS o
+			INNER JOIN
+				order_items AS i ON i.order_id = o.order_id
+			WHERE
+				o.status = :status AND
+				o.user_id = :user_id AND
+				o.org_id IS NULL AND
+				NOT o.gift AND
+				i.course_id = :course_id AND
+				i.refunded_at IS NULL AND
+				(i.expires_at IS NULL OR i.expires_at > NOW())
+		) OR
+		c.course_id = :course_id AND
+		EXISTS (
+			SELECT
+				1
+			FROM
+				seat_assignments AS sa
+			WHERE
+				sa.user_id = :user_id AND
+				sa.course_id = :course_id
+		) OR
+		c.course_id = :course_id AND
+		EXISTS (
+			SELECT
+				1
+			FROM
+				gifts AS g
+			INNER JOIN
+				orders AS o ON o.order_id = g.order_id
+			INNER JOIN
+				order_items AS i ON i.order_id = g.order_id AND i.course_id = g.course_id
+			WHERE
+				o.status = :status AND
+				i.refunded_at IS NULL AND
+				g.redeemed_by = :user_id AND
+				g.course_id = :course_id
+		) OR
+		c.course_id = :course_id AND
+		EXISTS (
+			SELECT
+				1
+			FROM
+				course_grants AS cg
+			WHERE
+				cg.user_id = :user_id AND
+				cg.course_id = :course_id AND
+				cg.revoked_at IS NULL
+		) OR
+		c.course_id = :course_id AND
+		EXISTS (
+			SELECT
+				1
+			FROM
+				subscriptions AS s
+			WHERE
+				s.user_id = :user_id AND
+				s.status = :active AND
+				s.period_end > NOW()
+		)`
+
+	var cs Course
+	if err := database.NamedQueryStruct(ctx, db, q, in, &cs); err != nil {
+		return Course{}, fmt.Errorf("selecting owned course: %w", err)
+	}
+
+	return cs, nil
+}
+
+// FetchAccess returns the access of a user to a course, even if expired.
+// Purchases and renewals are merged, so the latest expiration wins.
+func FetchAccess(ctx context.Context, db sqlx.ExtContext, courseID string, userID string) (Access, error) {
+	in := struct {
+		UserID   string `db:"user_id"`
+		CourseID string `db:"course_id"`
+		Status   string `db:"status"`
+	}{
+		UserID:   userID,
+		CourseID: courseID,
+		Status:   "success",
+	}
+
+	const q = `
+	SELECT
+		i.course_id,
+		o.user_id,
+		CASE
+			WHEN bool_or(i.expires_at IS NULL) THEN NULL
+			ELSE MAX(i.expires_at)
+		END AS expires_at
 	FROM
 		orders AS o
 	INNER JOIN
 		order_items AS i ON i.order_id = o.order_id
-	INNER JOIN
-		courses AS c ON i.course_id = c.course_id
 	WHERE
 		o.status = :status AND
 		o.user_id = :user_id AND
-		c.course_id = :course_id`
+		o.org_id IS NULL AND
+		NOT o.gift AND
+		i.course_id = :course_id AND
+		i.refunded_at IS NULL
+	GROUP BY
+		i.course_id, o.user_id`
 
-	var cs Course
-	if err := database.NamedQueryStruct(ctx, db, q, in, &cs); err != nil {
-		return Course{}, fmt.Errorf("selecting owned course: %w", err)
+	var acc Access
+	if err := database.NamedQueryStruct(ctx, db, q, in, &acc); err != nil {
+		return Access{}, fmt.Errorf("selecting access of user[%s] to course[%s]: %w", userID, courseID, err)
+	}
+
+	return acc, nil
+}
+
+// CreatePriceChange records a new price of a course.
+func CreatePriceChange(ctx context.Context, db sqlx.ExtContext, pc PriceChange) error {
+	const q = `
+	INSERT INTO course_prices
+		(course_id, price, changed_by, created_at)
+	VALUES
+		(:course_id, :price, :changed_by, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, pc); err != nil {
+		return fmt.Errorf("inserting price change: %w", err)
+	}
+
+	return nil
+}
+
+// FetchPriceHistory returns all the prices of a course, from the oldest.
+func FetchPriceHistory(ctx context.Context, db sqlx.ExtContext, courseID string) ([]PriceChange, error) {
+	in := struct {
+		ID string `db:"course_id"`
+	}{
+		ID: courseID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		course_prices
+	WHERE
+		course_id = :course_id
+	ORDER BY
+		created_at`
+
+	pcs := []PriceChange{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &pcs); err != nil {
+		return nil, fmt.Errorf("selecting price history of course[%s]: %w", courseID, err)
+	}
+
+	return pcs, nil
+}
+
+// SetRegionalPrice sets the price of a course in a region,
+// replacing the previous one, if any.
+func SetRegionalPrice(ctx context.Context, db sqlx.ExtContext, rp RegionalPrice) error {
+	const q = `
+	INSERT INTO regional_prices
+		(course_id, region, price, created_at, updated_at)
+	VALUES
+		(:course_id, :region, :price, :created_at, :updated_at)
+	ON CONFLICT (course_id, region) DO UPDATE
+	SET
+		price = EXCLUDED.price,
+		updated_at = EXCLUDED.updated_at`
+
+	if err := database.NamedExecContext(ctx, db, q, rp); err != nil {
+		return fmt.Errorf("upserting price of course[%s] in region %s: %w", rp.CourseID, rp.Region, err)
+	}
+
+	return nil
+}
+
+// DeleteRegionalPrice drops the price of a course in a region.
+func DeleteRegionalPrice(ctx context.Context, db sqlx.ExtContext, courseID string, region string) error {
+	in := struct {
+		CourseID string `db:"course_id"`
+		Region   string `db:"region"`
+	}{
+		CourseID: courseID,
+		Region:   region,
+	}
+
+	const q = `
+	DELETE FROM
+		regional_prices
+	WHERE
+		course_id = :course_id AND
+		region = :region
+	RETURNING course_id`
+
+	var out struct {
+		CourseID string `db:"course_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("deleting price of course[%s] in region %s: %w", courseID, region, err)
+	}
+
+	return nil
+}
+
+// FetchRegionalPrice returns the price of a course in a region.
+func FetchRegionalPrice(ctx context.Context, db sqlx.ExtContext, courseID string, region string) (RegionalPrice, error) {
+	in := struct {
+		CourseID string `db:"course_id"`
+		Region   string `db:"region"`
+	}{
+		CourseID: courseID,
+		Region:   region,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		regional_prices
+	WHERE
+		course_id = :course_id AND
+		region = :region`
+
+	var rp RegionalPrice
+	if err := database.NamedQueryStruct(ctx, db, q, in, &rp); err != nil {
+		return RegionalPrice{}, fmt.Errorf("selecting price of course[%s] in region %s: %w", courseID, region, err)
+	}
+
+	return rp, nil
+}
+
+// FetchRegionalPrices returns the prices of a course in all the
+// regions which have one, sorted by region.
+func FetchRegionalPrices(ctx context.Context, db sqlx.ExtContext, courseID string) ([]RegionalPrice, error) {
+	in := struct {
+		CourseID string `db:"course_id"`
+	}{
+		CourseID: courseID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		regional_prices
+	WHERE
+		course_id = :course_id
+	ORDER BY
+		region`
+
+	rps := []RegionalPrice{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rps); err != nil {
+		return nil, fmt.Errorf("selecting regional prices of course[%s]: %w", courseID, err)
+	}
+
+	return rps, nil
+}
+
+// FetchSales returns the best discount of the sales running at the
+// passed time on each of the passed courses. Courses not on sale are
+// left out.
+func FetchSales(ctx context.Context, db sqlx.ExtContext, ids []string, now time.Time) ([]Sale, error) {
+	in := struct {
+		IDs pq.StringArray `db:"course_ids"`
+		Now time.Time      `db:"now"`
+	}{
+		IDs: ids,
+		Now: now,
+	}
+
+	const q = `
+	SELECT DISTINCT ON (sc.course_id)
+		sc.course_id,
+		s.percent,
+		s.ends_at
+	FROM
+		sale_courses AS sc
+	JOIN
+		sales AS s ON s.sale_id = sc.sale_id
+	WHERE
+		sc.course_id = ANY(CAST(:course_ids AS UUID[])) AND
+		s.starts_at <= :now AND
+		s.ends_at > :now
+	ORDER BY
+		sc.course_id, s.percent DESC, s.ends_at`
+
+	ss := []Sale{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ss); err != nil {
+		return nil, fmt.Errorf("selecting sales of courses: %w", err)
+	}
+
+	return ss, nil
+}
+
+// SetRating records the stars given by a user to a course, replacing
+// the ones given before.
+func SetRating(ctx context.Context, db sqlx.ExtContext, courseID string, userID string, stars int, now time.Time) error {
+	in := struct {
+		CourseID string    `db:"course_id"`
+		UserID   string    `db:"user_id"`
+		Stars    int       `db:"stars"`
+		Now      time.Time `db:"now"`
+	}{
+		CourseID: courseID,
+		UserID:   userID,
+		Stars:    stars,
+		Now:      now,
+	}
+
+	const q = `
+	INSERT INTO course_ratings
+		(course_id, user_id, stars, created_at, updated_at)
+	VALUES
+		(:course_id, :user_id, :stars, :now, :now)
+	ON CONFLICT (course_id, user_id) DO UPDATE SET
+		stars = EXCLUDED.stars,
+		updated_at = EXCLUDED.updated_at`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("rating course[%s] by user[%s]: %w", courseID, userID, err)
+	}
+
+	return nil
+}
+
+// FetchRatings returns the ratings of the passed courses. Courses not
+// rated yet are left out.
+func FetchRatings(ctx context.Context, db sqlx.ExtContext, ids []string) ([]Rating, error) {
+	in := struct {
+		IDs pq.StringArray `db:"course_ids"`
+	}{
+		IDs: ids,
+	}
+
+	const q = `
+	SELECT
+		course_id,
+		CAST(ROUND(AVG(stars), 2) AS FLOAT8) AS average,
+		COUNT(*) AS count
+	FROM
+		course_ratings
+	WHERE
+		course_id = ANY(CAST(:course_ids AS UUID[]))
+	GROUP BY
+		course_id`
+
+	rs := []Rating{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rs); err != nil {
+		return nil, fmt.Errorf("selecting ratings of courses: %w", err)
+	}
+
+	return rs, nil
+}
+
+// SetStatus moves a course to the passed status of its lifecycle.
+func SetStatus(ctx context.Context, db sqlx.ExtContext, id string, status string, now time.Time) (Course, error) {
+	in := struct {
+		ID     string    `db:"course_id"`
+		Status string    `db:"status"`
+		Now    time.Time `db:"now"`
+	}{
+		ID:     id,
+		Status: status,
+		Now:    now,
+	}
+
+	const q = `
+	UPDATE courses
+	SET
+		status = :status,
+		updated_at = :now,
+		version = version + 1
+	WHERE
+		course_id = :course_id
+	RETURNING *`
+
+	var c Course
+	if err := database.NamedQueryStruct(ctx, db, q, in, &c); err != nil {
+		return Course{}, fmt.Errorf("setting status of course[%s] to %s: %w", id, status, err)
+	}
+
+	return c, nil
+}
+
+// HasVideos reports whether the course has any video, leaving out the
+// deleted ones.
+func HasVideos(ctx context.Context, db sqlx.ExtContext, id string) (bool, error) {
+	in := struct {
+		ID string `db:"course_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT EXISTS (
+		SELECT
+			1
+		FROM
+			videos
+		WHERE
+			course_id = :course_id AND
+			deleted_at IS NULL
+	) AS found`
+
+	out := struct {
+		Found bool `db:"found"`
+	}{}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return false, fmt.Errorf("checking videos of course[%s]: %w", id, err)
+	}
+
+	return out.Found, nil
+}
+
+// SetPrerequisites replaces the prerequisites of a course with the
+// passed courses, returning how many of them exist.
+func SetPrerequisites(ctx context.Context, db sqlx.ExtContext, courseID string, ids []string, now time.Time) (int, error) {
+	in := struct {
+		CourseID  string         `db:"course_id"`
+		IDs       pq.StringArray `db:"prerequisite_ids"`
+		CreatedAt time.Time      `db:"created_at"`
+	}{
+		CourseID:  courseID,
+		IDs:       ids,
+		CreatedAt: now,
+	}
+
+	const del = `
+	DELETE FROM
+		course_prerequisites
+	WHERE
+		course_id = :course_id`
+
+	if err := database.NamedExecContext(ctx, db, del, in); err != nil {
+		return 0, fmt.Errorf("deleting prerequisites of course[%s]: %w", courseID, err)
+	}
+
+	const ins = `
+	INSERT INTO course_prerequisites
+		(course_id, prerequisite_id, created_at)
+	SELECT
+		:course_id, course_id, :created_at
+	FROM
+		courses
+	WHERE
+		course_id = ANY(CAST(:prerequisite_ids AS UUID[]))
+	RETURNING prerequisite_id`
+
+	set := []struct {
+		ID string `db:"prerequisite_id"`
+	}{}
+	if err := database.NamedQuerySlice(ctx, db, ins, in, &set); err != nil {
+		return 0, fmt.Errorf("inserting prerequisites of course[%s]: %w", courseID, err)
+	}
+
+	return len(set), nil
+}
+
+// FetchPrerequisites returns the courses to complete before a course.
+func FetchPrerequisites(ctx context.Context, db sqlx.ExtContext, courseID string) ([]Course, error) {
+	in := struct {
+		ID string `db:"course_id"`
+	}{
+		ID: courseID,
+	}
+
+	const q = `
+	SELECT
+		c.*
+	FROM
+		courses c
+		JOIN course_prerequisites p ON p.prerequisite_id = c.course_id
+	WHERE
+		p.course_id = :course_id
+	ORDER BY
+		c.name`
+
+	courses := []Course{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &courses); err != nil {
+		return nil, fmt.Errorf("selecting prerequisites of course[%s]: %w", courseID, err)
+	}
+
+	return courses, nil
+}
+
+// Requires reports whether any of the passed courses requires, directly
+// or through its own prerequisites, the course, or is the course.
+func Requires(ctx context.Context, db sqlx.ExtContext, ids []string, courseID string) (bool, error) {
+	in := struct {
+		CourseID string         `db:"course_id"`
+		IDs      pq.StringArray `db:"ids"`
+	}{
+		CourseID: courseID,
+		IDs:      ids,
+	}
+
+	const q = `
+	WITH RECURSIVE required AS (
+		SELECT
+			UNNEST(CAST(:ids AS UUID[])) AS course_id
+		UNION
+		SELECT
+			p.prerequisite_id
+		FROM
+			course_prerequisites p
+			JOIN required r ON r.course_id = p.course_id
+	)
+	SELECT EXISTS (
+		SELECT
+			1
+		FROM
+			required
+		WHERE
+			course_id = :course_id
+	) AS found`
+
+	out := struct {
+		Found bool `db:"found"`
+	}{}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return false, fmt.Errorf("checking courses requiring course[%s]: %w", courseID, err)
+	}
+
+	return out.Found, nil
+}
+
+// FetchLocked returns the ids, among the passed ones, of the courses
+// whose prerequisites have not been completed by the user yet, i.e.
+// with a published video of a prerequisite not watched in full.
+// Anonymous users, with an empty userID, completed no course.
+func FetchLocked(ctx context.Context, db sqlx.ExtContext, ids []string, userID string) ([]string, error) {
+	in := struct {
+		IDs    pq.StringArray `db:"ids"`
+		UserID string         `db:"user_id"`
+	}{
+		IDs:    ids,
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT DISTINCT
+		p.course_id
+	FROM
+		course_prerequisites p
+	WHERE
+		p.course_id = ANY(CAST(:ids AS UUID[])) AND
+		EXISTS (
+			SELECT
+				1
+			FROM
+				videos v
+			WHERE
+				v.course_id = p.prerequisite_id AND
+				v.published AND
+				v.deleted_at IS NULL AND
+				NOT EXISTS (
+					SELECT
+						1
+					FROM
+						videos_progress vp
+					WHERE
+						vp.video_id = v.video_id AND
+						vp.user_id = CAST(NULLIF(:user_id, '') AS UUID) AND
+						vp.progress = 100
+				)
+		)`
+
+	locked := []struct {
+		ID string `db:"course_id"`
+	}{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &locked); err != nil {
+		return nil, fmt.Errorf("selecting locked courses of user[%s]: %w", userID, err)
+	}
+
+	out := make([]string, len(locked))
+	for i, l := range locked {
+		out[i] = l.ID
+	}
+
+	return out, nil
+}
+
+// FetchCompletions returns how far the user went in the passed courses,
+// counting their published videos and the quizzes of those videos.
+// Progress is the sum of the progress on the videos. Percent is left
+// to the caller.
+func FetchCompletions(ctx context.Context, db sqlx.ExtContext, userID string, ids []string) ([]Completion, error) {
+	in := struct {
+		UserID string         `db:"user_id"`
+		IDs    pq.StringArray `db:"ids"`
+	}{
+		UserID: userID,
+		IDs:    ids,
+	}
+
+	const q = `
+	SELECT
+		c.course_id,
+		c.name AS course_name,
+		COUNT(v.video_id) AS videos,
+		COUNT(v.video_id) FILTER (WHERE vp.progress = 100) AS videos_completed,
+		COALESCE(SUM(vp.progress), 0) AS progress,
+		COUNT(q.quiz_id) AS quizzes,
+		COUNT(q.quiz_id) FILTER (
+			WHERE EXISTS (
+				SELECT
+					1
+				FROM
+					quiz_attempts a
+				WHERE
+					a.quiz_id = q.quiz_id AND
+					a.user_id = :user_id AND
+					a.passed
+			)
+		) AS quizzes_passed
+	FROM
+		courses c
+		LEFT JOIN videos v ON v.course_id = c.course_id AND v.published AND v.deleted_at IS NULL
+		LEFT JOIN videos_progress vp ON vp.video_id = v.video_id AND vp.user_id = :user_id
+		LEFT JOIN quizzes q ON q.video_id = v.video_id
+	WHERE
+		c.course_id = ANY(CAST(:ids AS UUID[]))
+	GROUP BY
+		c.course_id
+	ORDER BY
+		c.course_id`
+
+	cs := []Completion{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cs); err != nil {
+		return nil, fmt.Errorf("selecting completion of courses by user[%s]: %w", userID, err)
+	}
+
+	return cs, nil
+}
+
+// CreateRevision inserts a new revision.
+func CreateRevision(ctx context.Context, db sqlx.ExtContext, rev Revision) error {
+	in := struct {
+		ID        string    `db:"revision_id"`
+		CourseID  string    `db:"course_id"`
+		VideoID   *string   `db:"video_id"`
+		EditorID  *string   `db:"editor_id"`
+		Snapshot  string    `db:"snapshot"`
+		Diff      string    `db:"diff"`
+		CreatedAt time.Time `db:"created_at"`
+	}{
+		ID:        rev.ID,
+		CourseID:  rev.CourseID,
+		VideoID:   rev.VideoID,
+		EditorID:  rev.EditorID,
+		Snapshot:  string(rev.Snapshot),
+		Diff:      string(rev.Diff),
+		CreatedAt: rev.CreatedAt,
+	}
+
+	const q = `
+	INSERT INTO course_revisions
+		(revision_id, course_id, video_id, editor_id, snapshot, diff, created_at)
+	VALUES
+		(:revision_id, :course_id, :video_id, :editor_id, CAST(:snapshot AS JSONB), CAST(:diff AS JSONB), :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("inserting revision of course[%s]: %w", rev.CourseID, err)
+	}
+
+	return nil
+}
+
+// FetchRevision returns a revision of a course.
+func FetchRevision(ctx context.Context, db sqlx.ExtContext, courseID string, id string) (Revision, error) {
+	in := struct {
+		CourseID string `db:"course_id"`
+		ID       string `db:"revision_id"`
+	}{
+		CourseID: courseID,
+		ID:       id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		course_revisions
+	WHERE
+		revision_id = :revision_id AND
+		course_id = :course_id`
+
+	var rev Revision
+	if err := database.NamedQueryStruct(ctx, db, q, in, &rev); err != nil {
+		return Revision{}, fmt.Errorf("selecting revision[%s] of course[%s]: %w", id, courseID, err)
+	}
+
+	return rev, nil
+}
+
+// FetchRevisions returns the revisions of a course and of its videos,
+// the most recent first.
+func FetchRevisions(ctx context.Context, db sqlx.ExtContext, courseID string, limit int, offset int) ([]Revision, error) {
+	in := struct {
+		CourseID string `db:"course_id"`
+		Limit    int    `db:"limit"`
+		Offset   int    `db:"offset"`
+	}{
+		CourseID: courseID,
+		Limit:    limit,
+		Offset:   offset,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		course_revisions
+	WHERE
+		course_id = :course_id
+	ORDER BY
+		created_at DESC, revision_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	revs := []Revision{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &revs); err != nil {
+		return nil, fmt.Errorf("selecting revisions of course[%s]: %w", courseID, err)
+	}
+
+	return revs, nil
+}
+
+// CreateGrant inserts a new grant. It returns ErrDBDuplicatedEntry when
+// the user has already been granted the course.
+func CreateGrant(ctx context.Context, db sqlx.ExtContext, g Grant) error {
+	const q = `
+	INSERT INTO course_grants
+		(grant_id, course_id, user_id, reason, granted_by, created_at)
+	VALUES
+		(:grant_id, :course_id, :user_id, :reason, :granted_by, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, g); err != nil {
+		return fmt.Errorf("inserting grant of course[%s] to user[%s]: %w", g.CourseID, g.UserID, err)
+	}
+
+	return nil
+}
+
+// RevokeGrant revokes the active grant of a course to a user, returning
+// it. It returns ErrDBNotFound when there is none.
+func RevokeGrant(ctx context.Context, db sqlx.ExtContext, courseID string, userID string, revokedBy string, reason string, now time.Time) (Grant, error) {
+	in := struct {
+		CourseID  string    `db:"course_id"`
+		UserID    string    `db:"user_id"`
+		RevokedBy string    `db:"revoked_by"`
+		Reason    string    `db:"revoke_reason"`
+		RevokedAt time.Time `db:"revoked_at"`
+	}{
+		CourseID:  courseID,
+		UserID:    userID,
+		RevokedBy: revokedBy,
+		Reason:    reason,
+		RevokedAt: now,
+	}
+
+	const q = `
+	UPDATE course_grants
+	SET
+		revoked_at = :revoked_at,
+		revoked_by = :revoked_by,
+		revoke_reason = :revoke_reason
+	WHERE
+		course_id = :course_id AND
+		user_id = :user_id AND
+		revoked_at IS NULL
+	RETURNING *`
+
+	var g Grant
+	if err := database.NamedQueryStruct(ctx, db, q, in, &g); err != nil {
+		return Grant{}, fmt.Errorf("revoking grant of course[%s] to user[%s]: %w", courseID, userID, err)
+	}
+
+	return g, nil
+}
+
+// FetchGrants returns the grants of a course, revoked ones included,
+// the most recent first.
+func FetchGrants(ctx context.Context, db sqlx.ExtContext, courseID string) ([]Grant, error) {
+	in := struct {
+		CourseID string `db:"course_id"`
+	}{
+		CourseID: courseID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		course_grants
+	WHERE
+		course_id = :course_id
+	ORDER BY
+		created_at DESC, grant_id`
+
+	gs := []Grant{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &gs); err != nil {
+		return nil, fmt.Errorf("selecting grants of course[%s]: %w", courseID, err)
+	}
+
+	return gs, nil
+}
+
+// FetchRelated returns the published courses related to the passed one,
+// the most related first. Each student who bought both courses weighs
+// as much as two shared categories or tags, so that what students
+// actually buy together comes first.
+func FetchRelated(ctx context.Context, db sqlx.ExtContext, courseID string, limit int, offset int) ([]Course, error) {
+	in := struct {
+		CourseID  string `db:"course_id"`
+		Published string `db:"published"`
+		Paid      string `db:"paid"`
+		Limit     int    `db:"limit"`
+		Offset    int    `db:"offset"`
+	}{
+		CourseID:  courseID,
+		Published: StatusPublished,
+		Paid:      "success",
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	const q = `
+	WITH buyers AS (
+		SELECT
+			i.course_id,
+			o.user_id
+		FROM
+			orders AS o
+		INNER JOIN
+			order_items AS i ON i.order_id = o.order_id
+		WHERE
+			o.status = :paid AND
+			i.refunded_at IS NULL
+	), scores AS (
+		SELECT
+			cc.course_id,
+			COUNT(*) AS score
+		FROM
+			course_categories AS cc
+		INNER JOIN
+			course_categories AS src ON src.category_id = cc.category_id
+		WHERE
+			src.course_id = :course_id
+		GROUP BY
+			cc.course_id
+		UNION ALL
+		SELECT
+			ct.course_id,
+			COUNT(*) AS score
+		FROM
+			course_tags AS ct
+		INNER JOIN
+			course_tags AS src ON src.tag_id = ct.tag_id
+		WHERE
+			src.course_id = :course_id
+		GROUP BY
+			ct.course_id
+		UNION ALL
+		SELECT
+			b.course_id,
+			2 * COUNT(DISTINCT b.user_id) AS score
+		FROM
+			buyers AS b
+		INNER JOIN
+			buyers AS src ON src.user_id = b.user_id
+		WHERE
+			src.course_id = :course_id
+		GROUP BY
+			b.course_id
+	)
+	SELECT
+		c.*
+	FROM
+		courses AS c
+	INNER JOIN (
+		SELECT
+			course_id,
+			SUM(score) AS score
+		FROM
+			scores
+		GROUP BY
+			course_id
+	) AS s ON s.course_id = c.course_id
+	WHERE
+		c.course_id <> :course_id AND
+		c.status = :published
+	ORDER BY
+		s.score DESC, c.course_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	cs := []Course{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cs); err != nil {
+		return nil, fmt.Errorf("selecting courses related to course[%s]: %w", courseID, err)
+	}
+
+	return cs, nil
+}
+
+// CreateFAQ inserts a new entry of the FAQ of a course.
+func CreateFAQ(ctx context.Context, db sqlx.ExtContext, f FAQ) error {
+	const q = `
+	INSERT INTO course_faqs
+		(faq_id, course_id, index, question, answer, created_at, updated_at)
+	VALUES
+		(:faq_id, :course_id, :index, :question, :answer, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, f); err != nil {
+		return fmt.Errorf("inserting faq: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateFAQ updates an entry of a FAQ with the passed information.
+// It relies on optimistic lock to deal with data races.
+func UpdateFAQ(ctx context.Context, db sqlx.ExtContext, f FAQ) (FAQ, error) {
+	const q = `
+	UPDATE course_faqs
+	SET
+		index = :index,
+		question = :question,
+		answer = :answer,
+		updated_at = :updated_at,
+		version = version + 1
+	WHERE
+		faq_id = :faq_id AND
+		version = :version
+	RETURNING version`
+
+	v := struct {
+		Version int `db:"version"`
+	}{}
+
+	if err := database.NamedQueryStruct(ctx, db, q, f, &v); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return FAQ{}, fmt.Errorf("updating faq[%s]: version conflict", f.ID)
+		}
+		return FAQ{}, fmt.Errorf("updating faq[%s]: %w", f.ID, err)
+	}
+
+	f.Version = v.Version
+
+	return f, nil
+}
+
+// DeleteFAQ removes an entry of a FAQ.
+func DeleteFAQ(ctx context.Context, db sqlx.ExtContext, id string) error {
+	in := struct {
+		ID string `db:"faq_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	DELETE FROM
+		course_faqs
+	WHERE
+		faq_id = :faq_id
+	RETURNING faq_id`
+
+	var out struct {
+		ID string `db:"faq_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("deleting faq[%s]: %w", id, err)
+	}
+
+	return nil
+}
+
+// FetchFAQ returns the entry of a FAQ with the passed id.
+func FetchFAQ(ctx context.Context, db sqlx.ExtContext, id string) (FAQ, error) {
+	in := struct {
+		ID string `db:"faq_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		course_faqs
+	WHERE
+		faq_id = :faq_id`
+
+	var f FAQ
+	if err := database.NamedQueryStruct(ctx, db, q, in, &f); err != nil {
+		return FAQ{}, fmt.Errorf("selecting faq[%s]: %w", id, err)
+	}
+
+	return f, nil
+}
+
+// FetchFAQs returns the FAQ of a course, in order.
+func FetchFAQs(ctx context.Context, db sqlx.ExtContext, courseID string) ([]FAQ, error) {
+	in := struct {
+		ID string `db:"course_id"`
+	}{
+		ID: courseID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		course_faqs
+	WHERE
+		course_id = :course_id
+	ORDER BY
+		index`
+
+	fs := []FAQ{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &fs); err != nil {
+		return nil, fmt.Errorf("selecting faqs of course[%s]: %w", courseID, err)
+	}
+
+	return fs, nil
+}
+
+// SetFeatured replaces the featured courses with the passed ones, in
+// order, returning how many of them exist.
+func SetFeatured(ctx context.Context, db sqlx.ExtContext, ids []string, now time.Time) (int, error) {
+	in := struct {
+		IDs       pq.StringArray `db:"course_ids"`
+		CreatedAt time.Time      `db:"created_at"`
+	}{
+		IDs:       ids,
+		CreatedAt: now,
+	}
+
+	const del = `
+	DELETE FROM
+		featured_courses`
+
+	if err := database.NamedExecContext(ctx, db, del, in); err != nil {
+		return 0, fmt.Errorf("deleting featured courses: %w", err)
+	}
+
+	const ins = `
+	INSERT INTO featured_courses
+		(course_id, position, created_at)
+	SELECT
+		c.course_id, f.position, :created_at
+	FROM
+		unnest(CAST(:course_ids AS UUID[])) WITH ORDINALITY AS f(course_id, position)
+	INNER JOIN
+		courses AS c ON c.course_id = f.course_id
+	RETURNING course_id`
+
+	set := []struct {
+		ID string `db:"course_id"`
+	}{}
+	if err := database.NamedQuerySlice(ctx, db, ins, in, &set); err != nil {
+		return 0, fmt.Errorf("inserting featured courses: %w", err)
+	}
+
+	return len(set), nil
+}
+
+// FetchFeatured returns the featured courses, in order. Unless all is
+// set, only the published ones are returned.
+func FetchFeatured(ctx context.Context, db sqlx.ExtContext, all bool) ([]Course, error) {
+	in := struct {
+		All       bool   `db:"all"`
+		Published string `db:"published"`
+	}{
+		All:       all,
+		Published: StatusPublished,
+	}
+
+	const q = `
+	SELECT
+		c.*
+	FROM
+		courses AS c
+	INNER JOIN
+		featured_courses AS f ON f.course_id = c.course_id
+	WHERE
+		:all OR c.status = :published
+	ORDER BY
+		f.position`
+
+	cs := []Course{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &cs); err != nil {
+		return nil, fmt.Errorf("selecting featured courses: %w", err)
 	}
 
 	return cs, nil
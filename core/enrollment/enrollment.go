@@ -0,0 +1,30 @@
+// Package enrollment enrolls many users in a course, or in all the
+// courses of a bundle, at once, e.g. the members of a team whose access
+// has been sold to their company.
+package enrollment
+
+// Enrollment contains the information needed to enroll users in bulk.
+// Either CourseID or BundleID must be passed. Reason is recorded in the
+// grants of the courses, see course.Grant.
+type Enrollment struct {
+	Emails   []string `json:"emails" validate:"min=1,max=500,unique,dive,email"`
+	CourseID string   `json:"courseId" validate:"omitempty,uuid4"`
+	BundleID string   `json:"bundleId" validate:"omitempty,uuid4"`
+	Reason   string   `json:"reason" validate:"required,max=2000"`
+}
+
+// Outcomes of enrolling a user in bulk.
+const (
+	ResultEnrolled = "enrolled"
+	ResultInvited  = "invited"
+	ResultOwned    = "owned"
+)
+
+// Result reports whether a user has been enrolled, invited because the
+// email was not registered yet, or skipped because the user already
+// owned all the courses.
+type Result struct {
+	Email  string `json:"email"`
+	UserID string `json:"userId"`
+	Result string `json:"result"`
+}
@@ -0,0 +1,191 @@
+package enrollment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/bundle"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/random"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errRegistered is returned when an email gets registered while the
+// users are being enrolled.
+var errRegistered = errors.New("email registered concurrently, retry")
+
+// HandleEnroll allows administrators to grant a course, or all the
+// courses of a bundle, to a list of users by email.
+// Unknown emails get an invited account: a guest who is sent the link
+// to claim it, as guests who check out are. Enrollments are all or
+// nothing: a failure leaves no user enrolled.
+func HandleEnroll(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var e Enrollment
+		if err := web.Decode(w, r, &e); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(e); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if (e.CourseID == "") == (e.BundleID == "") {
+			err := errors.New("either a course or a bundle must be passed")
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		courseIDs, err := fetchCourseIDs(ctx, db, e)
+		if err != nil {
+			return err
+		}
+
+		res := make([]Result, len(e.Emails))
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			now := time.Now().UTC()
+			for i, email := range e.Emails {
+				usr, invited, err := member(ctx, tx, email, now)
+				if err != nil {
+					return err
+				}
+
+				granted, err := grant(ctx, tx, usr.ID, courseIDs, clm.UserID, e.Reason, now)
+				if err != nil {
+					return err
+				}
+
+				res[i] = Result{Email: email, UserID: usr.ID, Result: ResultEnrolled}
+				switch {
+				case invited:
+					res[i].Result = ResultInvited
+				case granted == 0:
+					res[i].Result = ResultOwned
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, errRegistered) {
+				return weberr.NewError(err, err.Error(), http.StatusConflict)
+			}
+			return fmt.Errorf("enrolling users: %w", err)
+		}
+
+		return web.Respond(ctx, w, res, http.StatusOK)
+	}
+}
+
+// fetchCourseIDs returns the ids of the courses of an enrollment.
+func fetchCourseIDs(ctx context.Context, db sqlx.ExtContext, e Enrollment) ([]string, error) {
+	if e.CourseID != "" {
+		if _, err := course.Fetch(ctx, db, e.CourseID); err != nil {
+			err := fmt.Errorf("fetching course[%s]: %w", e.CourseID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return nil, weberr.NotFound(err)
+			}
+			return nil, err
+		}
+		return []string{e.CourseID}, nil
+	}
+
+	b, err := bundle.Fetch(ctx, db, e.BundleID)
+	if err != nil {
+		err := fmt.Errorf("fetching bundle[%s]: %w", e.BundleID, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil, weberr.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return b.CourseIDs, nil
+}
+
+// member returns the user with the passed email, creating a guest when
+// the email is not registered. It reports whether the guest was created.
+// Guests can't log in: they are inactive and their password is
+// unguessable until they claim their account.
+func member(ctx context.Context, db sqlx.ExtContext, email string, now time.Time) (user.User, bool, error) {
+	usr, err := user.FetchByEmail(ctx, db, email)
+	if err == nil {
+		return usr, false, nil
+	}
+	if !errors.Is(err, database.ErrDBNotFound) {
+		return user.User{}, false, fmt.Errorf("fetching user by email %s: %w", email, err)
+	}
+
+	pass, err := random.StringSecure(16)
+	if err != nil {
+		return user.User{}, false, fmt.Errorf("generating random secure string: %w", err)
+	}
+
+	usr = user.User{
+		ID:           validate.GenerateID(),
+		Name:         email,
+		Email:        email,
+		Role:         claims.RoleGuest,
+		PasswordHash: []byte(pass),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Active:       false,
+	}
+
+	if err := user.Create(ctx, db, usr); err != nil {
+		if errors.Is(err, user.ErrUniqueEmail) {
+			return user.User{}, false, fmt.Errorf("%w: %s", errRegistered, email)
+		}
+		return user.User{}, false, fmt.Errorf("creating guest[%s]: %w", email, err)
+	}
+
+	return usr, true, nil
+}
+
+// grant grants the passed courses to a user, skipping those the user
+// owns already. It returns the number of courses granted.
+func grant(ctx context.Context, db sqlx.ExtContext, userID string, courseIDs []string, grantedBy string, reason string, now time.Time) (int, error) {
+	owned, err := course.FetchByOwner(ctx, db, userID)
+	if err != nil {
+		return 0, fmt.Errorf("fetching courses owned by user[%s]: %w", userID, err)
+	}
+
+	ownedIDs := make(map[string]bool, len(owned))
+	for _, o := range owned {
+		ownedIDs[o.ID] = true
+	}
+
+	var granted int
+	for _, id := range courseIDs {
+		if ownedIDs[id] {
+			continue
+		}
+
+		g := course.Grant{
+			ID:        validate.GenerateID(),
+			CourseID:  id,
+			UserID:    userID,
+			Reason:    reason,
+			GrantedBy: &grantedBy,
+			CreatedAt: now,
+		}
+		if err := course.CreateGrant(ctx, db, g); err != nil {
+			return 0, err
+		}
+		granted++
+	}
+
+	return granted, nil
+}
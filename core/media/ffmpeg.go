@@ -0,0 +1,253 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/storage"
+)
+
+// masterPlaylist is the name of the HLS playlist listing the variants.
+const masterPlaylist = "master.m3u8"
+
+// Variant is a quality of the videos encoded to HLS.
+// Bitrate is the bitrate of the video stream, in bits per second.
+type Variant struct {
+	Name    string
+	Height  int
+	Bitrate int
+}
+
+// Ladder is the set of qualities the videos are encoded to.
+var Ladder = []Variant{
+	{Name: "1080p", Height: 1080, Bitrate: 5_000_000},
+	{Name: "720p", Height: 720, Bitrate: 2_800_000},
+	{Name: "480p", Height: 480, Bitrate: 1_400_000},
+	{Name: "360p", Height: 360, Bitrate: 800_000},
+}
+
+// Output is the result of the encoding of a video: the master playlist
+// and the renditions it lists.
+type Output struct {
+	ManifestURL string
+	Renditions  []video.Rendition
+}
+
+// Encoder encodes the media at sourceURL to HLS.
+type Encoder interface {
+	Encode(ctx context.Context, videoID string, sourceURL string) (Output, error)
+}
+
+// FFmpeg is an Encoder running ffmpeg on the local machine. The HLS
+// playlists and segments of each video are stored under
+// `media/{video id}`.
+type FFmpeg struct {
+	bin    string
+	st     storage.Storage
+	ladder []Variant
+}
+
+// NewFFmpeg returns an encoder running the ffmpeg binary at bin.
+func NewFFmpeg(bin string, st storage.Storage) *FFmpeg {
+	return &FFmpeg{
+		bin:    bin,
+		st:     st,
+		ladder: Ladder,
+	}
+}
+
+// Encode implements the Encoder interface. The video is encoded into a
+// temporary directory, then stored.
+func (ff *FFmpeg) Encode(ctx context.Context, videoID string, sourceURL string) (Output, error) {
+	tmp, err := os.MkdirTemp("", "media-"+videoID+"-")
+	if err != nil {
+		return Output{}, fmt.Errorf("creating encoding directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ff.bin, ff.args(sourceURL, tmp)...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return Output{}, fmt.Errorf("running ffmpeg: %w: %s", err, tail(stderr.String(), 512))
+	}
+
+	prefix := "media/" + videoID + "/"
+	if err := ff.store(ctx, tmp, prefix); err != nil {
+		return Output{}, err
+	}
+
+	now := time.Now().UTC()
+	rends := make([]video.Rendition, 0, len(ff.ladder))
+	for _, v := range ff.ladder {
+		// The width follows the aspect ratio of the source.
+		rends = append(rends, video.Rendition{
+			VideoID:   videoID,
+			Name:      v.Name,
+			URL:       ff.st.URL(prefix + v.Name + "/index.m3u8"),
+			Height:    v.Height,
+			Bitrate:   v.Bitrate,
+			CreatedAt: now,
+		})
+	}
+
+	return Output{ManifestURL: ff.st.URL(prefix + masterPlaylist), Renditions: rends}, nil
+}
+
+// Frame implements the Framer interface. ffmpeg picks the most
+// representative of the first frames, scaled to the width of 1280p.
+func (ff *FFmpeg) Frame(ctx context.Context, videoID string, sourceURL string) (string, error) {
+	tmp, err := os.MkdirTemp("", "thumbnail-"+videoID+"-")
+	if err != nil {
+		return "", fmt.Errorf("creating thumbnail directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	out := filepath.Join(tmp, "thumbnail.jpg")
+	args := []string{"-y", "-i", sourceURL, "-vf", "thumbnail,scale=1280:-2", "-frames:v", "1", "-q:v", "3", out}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ff.bin, args...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running ffmpeg: %w: %s", err, tail(stderr.String(), 512))
+	}
+
+	key := thumbnailKey(videoID, sourceURL)
+	if err := ff.put(ctx, out, key); err != nil {
+		return "", err
+	}
+
+	return ff.st.URL(key), nil
+}
+
+// store stores the files of the encoding in dir under prefix. The
+// segments are stored before the playlists listing them, and the
+// master playlist last, so that players never load a partial encoding.
+func (ff *FFmpeg) store(ctx context.Context, dir string, prefix string) error {
+	var segments, playlists []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+		if filepath.Ext(rel) == ".m3u8" {
+			playlists = append(playlists, rel)
+		} else {
+			segments = append(segments, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing encoding: %w", err)
+	}
+
+	// The master playlist is at the root, the others in the
+	// directories of the variants, so it sorts last.
+	sort.Slice(playlists, func(i, j int) bool {
+		return strings.Count(playlists[i], "/") > strings.Count(playlists[j], "/")
+	})
+
+	for _, rel := range append(segments, playlists...) {
+		if err := ff.put(ctx, filepath.Join(dir, filepath.FromSlash(rel)), prefix+rel); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// put stores the file at path under key.
+func (ff *FFmpeg) put(ctx context.Context, path string, key string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", key, err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading size of %s: %w", key, err)
+	}
+
+	ct := "video/mp2t"
+	switch filepath.Ext(path) {
+	case ".m3u8":
+		ct = "application/vnd.apple.mpegurl"
+	case ".jpg":
+		ct = "image/jpeg"
+	}
+
+	if err := ff.st.Put(ctx, key, f, fi.Size(), ct); err != nil {
+		return fmt.Errorf("storing %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// args returns the arguments of ffmpeg encoding the source into a
+// variant stream for each quality of the ladder, written into dir.
+func (ff *FFmpeg) args(sourceURL string, dir string) []string {
+	n := len(ff.ladder)
+
+	var filter strings.Builder
+	filter.WriteString("[0:v]split=" + strconv.Itoa(n))
+	for i := range ff.ladder {
+		fmt.Fprintf(&filter, "[s%d]", i)
+	}
+	for i, v := range ff.ladder {
+		fmt.Fprintf(&filter, ";[s%d]scale=-2:%d[v%d]", i, v.Height, i)
+	}
+
+	args := []string{"-y", "-i", sourceURL, "-filter_complex", filter.String()}
+
+	streams := make([]string, 0, n)
+	for i, v := range ff.ladder {
+		idx := strconv.Itoa(i)
+		rate := strconv.Itoa(v.Bitrate)
+		args = append(args,
+			"-map", "[v"+idx+"]", "-map", "0:a:0",
+			"-c:v:"+idx, "libx264", "-b:v:"+idx, rate, "-maxrate:v:"+idx, rate, "-bufsize:v:"+idx, strconv.Itoa(2*v.Bitrate),
+		)
+		streams = append(streams, "v:"+idx+",a:"+idx+",name:"+v.Name)
+	}
+
+	return append(args,
+		"-preset", "veryfast", "-g", "48", "-sc_threshold", "0",
+		"-c:a", "aac", "-b:a", "128k", "-ac", "2",
+		"-f", "hls",
+		"-hls_time", "6",
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(dir, "%v", "segment%03d.ts"),
+		"-master_pl_name", masterPlaylist,
+		"-var_stream_map", strings.Join(streams, " "),
+		filepath.Join(dir, "%v", "index.m3u8"),
+	)
+}
+
+// tail returns the last n bytes of s, where ffmpeg reports the errors.
+func tail(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
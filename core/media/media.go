@@ -0,0 +1,32 @@
+// Package media transcodes the videos uploaded as a single file into
+// multi-bitrate HLS, so that players can adapt the quality to the
+// bandwidth of the users. Videos keep playing their source until their
+// HLS manifest is ready, then they play the manifest instead.
+package media
+
+import "time"
+
+// JobStatus represents the status of an encoding job.
+type JobStatus string
+
+// Statuses of encoding jobs.
+const (
+	JobPending JobStatus = "pending"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+// Job models the encoding of the source of a video, whose media is at
+// SourceURL. Each video has a single job, encoding its latest source.
+// ManifestURL is the master playlist of the video once the job is done.
+type Job struct {
+	VideoID     string    `json:"videoId" db:"video_id"`
+	SourceURL   string    `json:"sourceUrl" db:"source_url"`
+	Status      JobStatus `json:"status" db:"status"`
+	Attempts    int       `json:"attempts" db:"attempts"`
+	LastError   string    `json:"lastError" db:"last_error"`
+	ManifestURL string    `json:"manifestUrl" db:"manifest_url"`
+	NextRunAt   time.Time `json:"nextRunAt" db:"next_run_at"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
@@ -0,0 +1,116 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// UpsertJob enqueues the encoding of the source of a video.
+// A new source restarts the job of the video, while the same source is
+// enqueued only once.
+func UpsertJob(ctx context.Context, db sqlx.ExtContext, job Job) error {
+	const q = `
+	INSERT INTO media_jobs
+		(video_id, source_url, status, attempts, last_error, manifest_url, next_run_at, created_at, updated_at)
+	VALUES
+		(:video_id, :source_url, :status, :attempts, :last_error, :manifest_url, :next_run_at, :created_at, :updated_at)
+	ON CONFLICT
+		(video_id)
+	DO UPDATE SET
+		source_url = EXCLUDED.source_url,
+		status = EXCLUDED.status,
+		attempts = EXCLUDED.attempts,
+		last_error = EXCLUDED.last_error,
+		manifest_url = EXCLUDED.manifest_url,
+		next_run_at = EXCLUDED.next_run_at,
+		updated_at = EXCLUDED.updated_at
+	WHERE
+		media_jobs.source_url <> EXCLUDED.source_url`
+
+	if err := database.NamedExecContext(ctx, db, q, job); err != nil {
+		return fmt.Errorf("upserting media job of video[%s]: %w", job.VideoID, err)
+	}
+
+	return nil
+}
+
+// UpdateJob updates the state of an encoding job, unless its video has
+// been given another source meanwhile.
+func UpdateJob(ctx context.Context, db sqlx.ExtContext, job Job) error {
+	const q = `
+	UPDATE media_jobs
+	SET
+		status = :status,
+		attempts = :attempts,
+		last_error = :last_error,
+		manifest_url = :manifest_url,
+		next_run_at = :next_run_at,
+		updated_at = :updated_at
+	WHERE
+		video_id = :video_id AND
+		source_url = :source_url`
+
+	if err := database.NamedExecContext(ctx, db, q, job); err != nil {
+		return fmt.Errorf("updating media job of video[%s]: %w", job.VideoID, err)
+	}
+
+	return nil
+}
+
+// FetchJob returns the encoding job of a video.
+func FetchJob(ctx context.Context, db sqlx.ExtContext, videoID string) (Job, error) {
+	in := struct {
+		VideoID string `db:"video_id"`
+	}{
+		VideoID: videoID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		media_jobs
+	WHERE
+		video_id = :video_id`
+
+	var job Job
+	if err := database.NamedQueryStruct(ctx, db, q, in, &job); err != nil {
+		return Job{}, fmt.Errorf("selecting media job of video[%s]: %w", videoID, err)
+	}
+
+	return job, nil
+}
+
+// FetchDueJobs returns the pending jobs which are scheduled
+// to run before the passed time.
+func FetchDueJobs(ctx context.Context, db sqlx.ExtContext, now time.Time) ([]Job, error) {
+	in := struct {
+		Status JobStatus `db:"status"`
+		Now    time.Time `db:"now"`
+	}{
+		Status: JobPending,
+		Now:    now,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		media_jobs
+	WHERE
+		status = :status AND
+		next_run_at <= :now
+	ORDER BY
+		next_run_at`
+
+	jobs := []Job{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &jobs); err != nil {
+		return nil, fmt.Errorf("selecting due media jobs: %w", err)
+	}
+
+	return jobs, nil
+}
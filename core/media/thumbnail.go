@@ -0,0 +1,123 @@
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/background"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+// Framer extracts a frame of the media at sourceURL to be used as the
+// thumbnail of a video, returning where it's served.
+type Framer interface {
+	Frame(ctx context.Context, videoID string, sourceURL string) (string, error)
+}
+
+// thumbnailPrefix is the prefix of the keys of the thumbnails extracted
+// from the videos.
+func thumbnailPrefix(videoID string) string {
+	return "media/" + videoID + "/thumbnail-"
+}
+
+// thumbnailKey returns where the thumbnail extracted from the media at
+// sourceURL is stored. The key changes with the media, so that caches
+// never serve the thumbnail of a previous one.
+func thumbnailKey(videoID string, sourceURL string) string {
+	h := sha256.Sum256([]byte(sourceURL))
+	return thumbnailPrefix(videoID) + hex.EncodeToString(h[:8]) + ".jpg"
+}
+
+// Thumbnailer fills the image of the videos whose administrators didn't
+// provide one. Videos of mux and youtube get the thumbnail served by
+// the provider, native videos a frame extracted by Framer, if set.
+// Thumbnails set this way follow the changes of the URL of the video,
+// those set by administrators are never replaced.
+type Thumbnailer struct {
+	DB      *sqlx.DB
+	Framer  Framer
+	Storage storage.Storage
+	BG      *background.Background
+}
+
+// VideoChanged implements the video.Listener interface.
+func (t *Thumbnailer) VideoChanged(v video.Video) {
+	t.enqueue(v)
+}
+
+// VideoReady implements the video.Listener interface.
+func (t *Thumbnailer) VideoReady(v video.Video) {
+	t.enqueue(v)
+}
+
+// generated reports whether the image of the video was set by the
+// thumbnailer rather than by administrators.
+func (t *Thumbnailer) generated(v video.Video) bool {
+	return video.IsProviderThumbnail(v.ImageURL) ||
+		strings.HasPrefix(v.ImageURL, t.Storage.URL(thumbnailPrefix(v.ID)))
+}
+
+// enqueue schedules the generation of the thumbnail of the video, when
+// its image is missing or was generated for another URL.
+func (t *Thumbnailer) enqueue(v video.Video) {
+	if v.URL == "" || (v.ImageURL != "" && !t.generated(v)) {
+		return
+	}
+
+	if thumb := video.Thumbnail(v.Provider, v.URL); thumb != "" {
+		if thumb != v.ImageURL {
+			t.BG.Add(func() error {
+				return t.set(context.Background(), v, thumb)
+			})
+		}
+		return
+	}
+
+	if v.Provider != video.ProviderNative || t.Framer == nil {
+		return
+	}
+	if v.ImageURL == t.Storage.URL(thumbnailKey(v.ID, v.URL)) {
+		return
+	}
+
+	t.BG.Add(func() error {
+		ctx := context.Background()
+		thumb, err := t.Framer.Frame(ctx, v.ID, v.URL)
+		if err != nil {
+			return fmt.Errorf("extracting thumbnail of video[%s]: %w", v.ID, err)
+		}
+		return t.set(ctx, v, thumb)
+	})
+}
+
+// set makes thumb the image of the video, unless the video changed in
+// the meantime. Listeners are not notified: only the image changes.
+func (t *Thumbnailer) set(ctx context.Context, v video.Video, thumb string) error {
+	err := database.Transaction(t.DB, func(tx sqlx.ExtContext) error {
+		cur, err := video.Fetch(ctx, tx, v.ID)
+		if err != nil {
+			return err
+		}
+
+		if cur.URL != v.URL || cur.ImageURL != v.ImageURL {
+			return nil
+		}
+
+		cur.ImageURL = thumb
+		cur.UpdatedAt = time.Now().UTC()
+		_, err = video.Update(ctx, tx, cur)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("setting thumbnail of video[%s]: %w", v.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/background"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Transcoder encodes the sources of the videos to HLS through an
+// Encoder. It listens to video events to enqueue jobs, which are then
+// processed in background, retrying failures up to MaxAttempts.
+type Transcoder struct {
+	DB          *sqlx.DB
+	Encoder     Encoder
+	BG          *background.Background
+	Log         logrus.FieldLogger
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// VideoChanged implements the video.Listener interface.
+func (t *Transcoder) VideoChanged(v video.Video) {
+	t.enqueue(v)
+}
+
+// VideoReady implements the video.Listener interface.
+func (t *Transcoder) VideoReady(v video.Video) {
+	t.enqueue(v)
+}
+
+// isManifest reports whether raw is an HLS playlist, already streamed
+// at multiple bitrates.
+func isManifest(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return path.Ext(u.Path) == ".m3u8"
+}
+
+// enqueue schedules the encoding of the source of the video, if it is
+// a single file hosted by ourselves. Videos sent to the transcoding
+// provider are encoded by the provider itself.
+func (t *Transcoder) enqueue(v video.Video) {
+	if v.Provider != video.ProviderNative || v.JobID != "" || v.URL == "" || isManifest(v.URL) {
+		return
+	}
+
+	t.BG.Add(func() error {
+		now := time.Now().UTC()
+		job := Job{
+			VideoID:   v.ID,
+			SourceURL: v.URL,
+			Status:    JobPending,
+			NextRunAt: now,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return UpsertJob(context.Background(), t.DB, job)
+	})
+}
+
+// Run processes the due jobs every interval.
+// It blocks until the passed context is canceled.
+func (t *Transcoder) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := t.runDue(ctx); err != nil {
+				t.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// runDue runs all the jobs scheduled until now.
+func (t *Transcoder) runDue(ctx context.Context) error {
+	jobs, err := FetchDueJobs(ctx, t.DB, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("fetching due media jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		if err := t.run(ctx, job); err != nil {
+			t.Log.WithField("message", err).Error("ERROR")
+		}
+	}
+
+	return nil
+}
+
+// run encodes the source of the job, then makes the video play the
+// HLS manifest and its renditions instead of the source.
+func (t *Transcoder) run(ctx context.Context, job Job) error {
+	job.Attempts++
+
+	out, eerr := t.Encoder.Encode(ctx, job.VideoID, job.SourceURL)
+
+	now := time.Now().UTC()
+	job.UpdatedAt = now
+
+	if eerr != nil {
+		job.LastError = eerr.Error()
+		job.NextRunAt = now.Add(t.Backoff * time.Duration(job.Attempts))
+		if job.Attempts >= t.MaxAttempts {
+			job.Status = JobFailed
+		}
+		if err := UpdateJob(ctx, t.DB, job); err != nil {
+			return fmt.Errorf("rescheduling media job of video[%s]: %w", job.VideoID, err)
+		}
+		return fmt.Errorf("encoding video[%s]: %w", job.VideoID, eerr)
+	}
+
+	job.Status = JobDone
+	job.LastError = ""
+	job.ManifestURL = out.ManifestURL
+
+	err := database.Transaction(t.DB, func(tx sqlx.ExtContext) error {
+		v, err := video.Fetch(ctx, tx, job.VideoID)
+		if err != nil {
+			return err
+		}
+
+		// The source changed while encoding, its own job takes over.
+		if v.URL != job.SourceURL {
+			return nil
+		}
+
+		// Listeners are not notified: the media is the same.
+		v.URL = out.ManifestURL
+		v.UpdatedAt = now
+		if _, err := video.Update(ctx, tx, v); err != nil {
+			return err
+		}
+
+		if err := video.ReplaceRenditions(ctx, tx, v.ID, out.Renditions); err != nil {
+			return err
+		}
+
+		return UpdateJob(ctx, tx, job)
+	})
+	if err != nil {
+		return fmt.Errorf("completing media job of video[%s]: %w", job.VideoID, err)
+	}
+
+	return nil
+}
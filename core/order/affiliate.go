@@ -0,0 +1,34 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/core/affiliate"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// referral returns the affiliate referring the user buying with the
+// passed code, nil if no code is passed. Referrers can't earn a
+// commission on their own orders.
+func referral(ctx context.Context, db *sqlx.DB, code string, userID string) (*affiliate.Affiliate, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	a, err := affiliate.FetchByCode(ctx, db, code)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil, fmt.Errorf("%w: %s not found", affiliate.ErrInvalid, affiliate.Normalize(code))
+		}
+		return nil, err
+	}
+
+	if a.UserID == userID {
+		return nil, fmt.Errorf("%w: %s is your own", affiliate.ErrInvalid, a.Code)
+	}
+
+	return &a, nil
+}
@@ -0,0 +1,161 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jmoiron/sqlx"
+)
+
+// Alert describes a payed order whose fulfillment needs the attention
+// of the operators.
+type Alert struct {
+	OrderID    string
+	ProviderID string
+	Reason     string
+}
+
+// String implements the fmt.Stringer interface.
+func (a Alert) String() string {
+	return fmt.Sprintf("order[%s] bound to payment[%s]: %s", a.OrderID, a.ProviderID, a.Reason)
+}
+
+// Alerter notifies the operators about critical payment failures.
+type Alerter interface {
+	Alert(ctx context.Context, a Alert) error
+}
+
+// Alerters notifies all of its alerters.
+type Alerters []Alerter
+
+// Alert implements the Alerter interface. All the alerters are
+// notified even when some of them fail.
+func (as Alerters) Alert(ctx context.Context, a Alert) error {
+	var errs []error
+	for _, al := range as {
+		if err := al.Alert(ctx, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MailAlerter is an Alerter emailing all the administrators.
+type MailAlerter struct {
+	DB     *sqlx.DB
+	Mailer Mailer
+}
+
+// Alert implements the Alerter interface.
+func (m *MailAlerter) Alert(ctx context.Context, a Alert) error {
+	admins, err := user.FetchAllByRole(ctx, m.DB, claims.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("fetching admins to alert: %w", err)
+	}
+
+	to := make([]string, 0, len(admins))
+	for _, ad := range admins {
+		to = append(to, ad.Email)
+	}
+
+	if err := m.Mailer.SendFulfillmentAlert(a.OrderID, a.Reason, to); err != nil {
+		return fmt.Errorf("emailing admins: %w", err)
+	}
+
+	return nil
+}
+
+// Slack is an Alerter posting to a Slack incoming webhook.
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlack returns an alerter posting to the incoming webhook at url.
+func NewSlack(url string) *Slack {
+	return &Slack{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Alert implements the Alerter interface.
+func (s *Slack) Alert(ctx context.Context, a Alert) error {
+	body := map[string]string{
+		"text": ":rotating_light: " + a.String(),
+	}
+
+	return postJSON(ctx, s.client, s.url, nil, body)
+}
+
+// pagerDutyURL is the endpoint of the PagerDuty Events API v2.
+const pagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty is an Alerter triggering PagerDuty incidents.
+// Alerts about the same payment are grouped in the same incident.
+type PagerDuty struct {
+	routingKey string
+	client     *http.Client
+}
+
+// NewPagerDuty returns an alerter triggering incidents on the service
+// integration with the passed routing key.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{
+		routingKey: routingKey,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Alert implements the Alerter interface.
+func (pd *PagerDuty) Alert(ctx context.Context, a Alert) error {
+	body := map[string]any{
+		"routing_key":  pd.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    "payment-" + a.ProviderID,
+		"payload": map[string]string{
+			"summary":  a.String(),
+			"source":   "tutorialspoint",
+			"severity": "critical",
+		},
+	}
+
+	return postJSON(ctx, pd.client, pagerDutyURL, nil, body)
+}
+
+// postJSON posts the passed body encoded as JSON to url, together with
+// the passed headers, failing on any non 2xx response.
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("posting to %s: status %s", url, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/core/bundle"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jmoiron/sqlx"
+)
+
+// errNotBundlable is returned when a bundle in the cart can't be bought.
+var errNotBundlable = errors.New("bundle can't be bought")
+
+// bundleLines returns the lines buying each course of a bundle.
+// The price of the bundle is spread over its courses proportionally to
+// their own prices, and taken off each of them as a discount, so that
+// the courses are granted as usual once the order is fulfilled.
+// Bundles costing more than their courses are sold at the price of the
+// courses.
+func bundleLines(ctx context.Context, db *sqlx.DB, bundleID string) ([]line, error) {
+	b, err := bundle.Fetch(ctx, db, bundleID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching bundle[%s]: %w", bundleID, err)
+	}
+
+	if len(b.CourseIDs) == 0 {
+		return nil, fmt.Errorf("%w: bundle[%s] has no courses", errNotBundlable, b.ID)
+	}
+
+	lines := make([]line, 0, len(b.CourseIDs))
+	prices := make([]money.Amount, 0, len(b.CourseIDs))
+	for _, id := range b.CourseIDs {
+		c, err := course.Fetch(ctx, db, id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching course[%s]: %w", id, err)
+		}
+
+		if err := forSale(c); err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, line{course: c, discount: money.Zero(c.Price.Currency), quantity: 1, bundle: b.ID})
+		prices = append(prices, c.Price)
+	}
+
+	sum, err := money.Sum(b.Price.Currency, prices...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bundle[%s] mixes currencies: %v", errNotBundlable, b.ID, err)
+	}
+
+	if b.Price.Units >= sum.Units {
+		return lines, nil
+	}
+
+	shares, err := b.Price.Allocate(prices...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bundle[%s]: %v", errNotBundlable, b.ID, err)
+	}
+
+	for i := range lines {
+		if lines[i].discount, err = prices[i].Sub(shares[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return lines, nil
+}
+
+// bundleID returns the id of the bundle of the line, nil if it is
+// not bought with a bundle.
+func (l line) bundleID() *string {
+	if l.bundle == "" {
+		return nil
+	}
+	id := l.bundle
+	return &id
+}
+
+// withBundles adds to the lines of the cart those of its bundles.
+// Courses in a bundle are bought with it, instead of on their own,
+// while the same course can't be bought with two bundles.
+func withBundles(lines []line, bundled []line) ([]line, error) {
+	in := make(map[string]bool, len(bundled))
+	for _, l := range bundled {
+		if in[l.course.ID] {
+			return nil, fmt.Errorf("%w: course[%s] is in many bundles", errNotBundlable, l.course.ID)
+		}
+		in[l.course.ID] = true
+	}
+
+	merged := make([]line, 0, len(lines)+len(bundled))
+	for _, l := range lines {
+		if !in[l.course.ID] {
+			merged = append(merged, l)
+		}
+	}
+
+	return append(merged, bundled...), nil
+}
@@ -0,0 +1,222 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+// coinbaseVersion is the version of the coinbase commerce API in use.
+const coinbaseVersion = "2018-03-22"
+
+// Coinbase accepts cryptocurrency payments (e.g. BTC, ETH or USDC)
+// through coinbase commerce charges. Users pay the charge on the page
+// hosted by coinbase, and orders are completed by coinbase webhooks
+// once the payment is confirmed on the blockchain.
+type Coinbase struct {
+	client *http.Client
+	cfg    config.Coinbase
+}
+
+// NewCoinbase returns the coinbase provider.
+func NewCoinbase(cfg config.Coinbase) *Coinbase {
+	return &Coinbase{client: &http.Client{Timeout: 30 * time.Second}, cfg: cfg}
+}
+
+// coinbaseCharge is a coinbase commerce charge.
+// Its timeline lists the statuses it went through, the latest last.
+type coinbaseCharge struct {
+	ID        string `json:"id"`
+	Code      string `json:"code"`
+	HostedURL string `json:"hosted_url"`
+	Timeline  []struct {
+		Status string `json:"status"`
+	} `json:"timeline"`
+}
+
+// Name implements the PaymentProvider interface.
+func (cb *Coinbase) Name() string {
+	return ProviderCoinbase
+}
+
+// CreateCheckout creates a charge of the total amount, priced in the
+// currency of the courses, and returns the URL of its hosted page.
+func (cb *Coinbase) CreateCheckout(ctx context.Context, lines []line, tot money.Amount) (Checkout, error) {
+	names := make([]string, 0, len(lines))
+	for _, l := range lines {
+		names = append(names, l.course.Name)
+	}
+
+	in := map[string]any{
+		"name":         "Tutorialspoint courses",
+		"description":  truncate(strings.Join(names, ", "), 200),
+		"pricing_type": "fixed_price",
+		"local_price": map[string]string{
+			"amount":   tot.Decimal(),
+			"currency": tot.Currency,
+		},
+		"redirect_url": cb.cfg.RedirectURL,
+		"cancel_url":   cb.cfg.CancelURL,
+	}
+
+	var out struct {
+		Data coinbaseCharge `json:"data"`
+	}
+	if err := cb.do(ctx, http.MethodPost, "/charges", in, &out); err != nil {
+		return Checkout{}, fmt.Errorf("creating coinbase charge: %w", err)
+	}
+
+	return Checkout{ID: out.Data.ID, Resp: out.Data.HostedURL}, nil
+}
+
+// Capture checks whether the charge has been payed.
+// Coinbase completes charges by itself, so nothing else is done.
+func (cb *Coinbase) Capture(ctx context.Context, providerID string) (Payment, error) {
+	var out struct {
+		Data coinbaseCharge `json:"data"`
+	}
+	if err := cb.do(ctx, http.MethodGet, "/charges/"+providerID, nil, &out); err != nil {
+		return Payment{}, fmt.Errorf("fetching coinbase charge[%s]: %w", providerID, err)
+	}
+
+	pay := Payment{ProviderID: providerID, Status: Pending}
+	if len(out.Data.Timeline) == 0 {
+		return pay, nil
+	}
+
+	switch out.Data.Timeline[len(out.Data.Timeline)-1].Status {
+	case "COMPLETED", "RESOLVED":
+		pay.PaymentID = providerID
+		pay.Status = Success
+	case "EXPIRED", "CANCELED":
+		pay.Status = Expired
+	}
+
+	return pay, nil
+}
+
+// Refund always fails: coinbase commerce payments are made from wallets
+// we know nothing about, so they must be refunded by hand.
+func (cb *Coinbase) Refund(ctx context.Context, paymentID string) error {
+	return fmt.Errorf("coinbase charge[%s] must be refunded manually", paymentID)
+}
+
+// RefundPart always fails, as Refund does.
+func (cb *Coinbase) RefundPart(ctx context.Context, paymentID string, amount money.Amount) error {
+	return fmt.Errorf("%s of coinbase charge[%s] must be refunded manually", amount, paymentID)
+}
+
+// Taxes returns no taxes: prices are charged as they are on coinbase.
+func (cb *Coinbase) Taxes(ctx context.Context, providerID string) ([]Tax, error) {
+	return nil, nil
+}
+
+// Cancel cancels the charge. Coinbase only cancels charges which have
+// not been payed yet.
+func (cb *Coinbase) Cancel(ctx context.Context, providerID string) error {
+	if err := cb.do(ctx, http.MethodPost, "/charges/"+providerID+"/cancel", nil, nil); err != nil {
+		return fmt.Errorf("canceling coinbase charge[%s]: %w", providerID, err)
+	}
+
+	return nil
+}
+
+// VerifyWebhook checks the signature of a coinbase event and returns the
+// payment of the charge it regards. Charges are payed once confirmed,
+// while they fail when expired or not payed in full.
+func (cb *Coinbase) VerifyWebhook(r *http.Request) (Payment, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Payment{}, fmt.Errorf("cannot read the request body: %w", err)
+	}
+
+	sig := r.Header.Get("X-CC-Webhook-Signature")
+	if sig == "" {
+		return Payment{}, errors.New("received coinbase event is not signed")
+	}
+
+	if !hmacSigned(b, sig, cb.cfg.WebhookSecret) {
+		return Payment{}, errors.New("coinbase event signature mismatch")
+	}
+
+	var body struct {
+		Event struct {
+			Type string         `json:"type"`
+			Data coinbaseCharge `json:"data"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return Payment{}, fmt.Errorf("unable to decode coinbase event: %w", err)
+	}
+
+	charge, typ := body.Event.Data, body.Event.Type
+	switch typ {
+	case "charge:confirmed":
+		return Payment{ProviderID: charge.ID, PaymentID: charge.ID, Status: Success, Event: typ}, nil
+	case "charge:failed":
+		return Payment{ProviderID: charge.ID, Status: Failed, Event: typ}, nil
+	}
+
+	return Payment{Event: typ}, errEventIgnored
+}
+
+// do calls the coinbase commerce API, sending in and decoding the
+// response in out, when not nil.
+func (cb *Coinbase) do(ctx context.Context, method string, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, cb.cfg.URL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-CC-Api-Key", cb.cfg.APIKey)
+	req.Header.Set("X-CC-Version", coinbaseVersion)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := cb.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var e struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+			return fmt.Errorf("coinbase responded %s", resp.Status)
+		}
+		return fmt.Errorf("coinbase responded %s: %s: %s", resp.Status, e.Error.Type, e.Error.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding coinbase response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,316 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// compensate schedules the compensation of the order bound to providerID
+// and records the failure. It must be called when a payment has been
+// completed but the fulfillment of the corresponding order failed.
+// Payments already scheduled are not scheduled again, only the failure
+// is recorded.
+func compensate(ctx context.Context, db *sqlx.DB, provider string, providerID string, paymentID string, cause error) error {
+	now := time.Now().UTC()
+	comp := Compensation{
+		ProviderID: providerID,
+		Provider:   provider,
+		PaymentID:  paymentID,
+		Status:     CompensationPending,
+		LastError:  cause.Error(),
+		NextRunAt:  now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	f := Failure{
+		ID:         validate.GenerateID(),
+		ProviderID: providerID,
+		Stage:      StageFulfillment,
+		Error:      cause.Error(),
+		CreatedAt:  now,
+	}
+
+	err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+		if err := CreateCompensation(ctx, tx, comp); err != nil {
+			return err
+		}
+		return CreateFailure(ctx, tx, f)
+	})
+	if err != nil {
+		return fmt.Errorf("scheduling compensation of payment[%s]: %w", providerID, err)
+	}
+
+	return nil
+}
+
+// Compensator retries the fulfillment of payed orders whose fulfillment
+// failed. The operators are alerted as soon as a failure is found.
+// Attempts are spaced by Backoff, doubled at each attempt up to
+// MaxBackoff. When all the attempts fail, the payment is refunded and
+// both the user and the operators are notified. Compensations whose
+// refund fails too are dead-lettered as failed, until the operators
+// requeue them.
+type Compensator struct {
+	DB          *sqlx.DB
+	Providers   Providers
+	Mailer      Mailer
+	Alerter     Alerter
+	Log         logrus.FieldLogger
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Run processes the due compensations every interval.
+// It blocks until the passed context is canceled.
+func (c *Compensator) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.runDue(ctx); err != nil {
+				c.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// runDue runs all the compensations scheduled until now.
+func (c *Compensator) runDue(ctx context.Context) error {
+	comps, err := FetchDueCompensations(ctx, c.DB, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("fetching due compensations: %w", err)
+	}
+
+	for _, comp := range comps {
+		if err := c.run(ctx, comp); err != nil {
+			c.Log.WithField("message", err).Error("ERROR")
+		}
+	}
+
+	return nil
+}
+
+// run tries to fulfill the order once more. If the attempts are
+// exhausted then it refunds the payment.
+func (c *Compensator) run(ctx context.Context, comp Compensation) error {
+	if comp.Attempts == 0 {
+		c.alert(ctx, comp.ProviderID, "the order was payed but its fulfillment failed: retrying: "+comp.LastError)
+	}
+
+	now := time.Now().UTC()
+	comp.Attempts++
+	comp.UpdatedAt = now
+
+	ferr := c.fulfill(ctx, comp)
+	if ferr == nil {
+		comp.Status = CompensationFulfilled
+		comp.LastError = ""
+		if err := UpdateCompensation(ctx, c.DB, comp); err != nil {
+			return fmt.Errorf("order bound to payment[%s] fulfilled but: %w", comp.ProviderID, err)
+		}
+		return nil
+	}
+	comp.LastError = ferr.Error()
+	c.record(ctx, comp.ProviderID, StageRetry, ferr)
+
+	// Retry later, waiting twice as long at each attempt. Orders closed
+	// without being payed can't be fulfilled anymore, so they are
+	// refunded right away.
+	if comp.Attempts < c.MaxAttempts && !errors.Is(ferr, errOrderClosed) {
+		comp.NextRunAt = now.Add(c.backoff(comp.Attempts))
+		if err := UpdateCompensation(ctx, c.DB, comp); err != nil {
+			return fmt.Errorf("rescheduling compensation of payment[%s]: %w", comp.ProviderID, err)
+		}
+		return nil
+	}
+
+	// Payments may not be bound to any order, their money is given back
+	// all the same.
+	ord, err := FetchByProviderID(ctx, c.DB, comp.ProviderID)
+	if err != nil && !errors.Is(err, database.ErrDBNotFound) {
+		return fmt.Errorf("fetching the order bound to payment[%s]: %w", comp.ProviderID, err)
+	}
+	found := err == nil
+
+	// All attempts failed, so give the money back to the user.
+	if err := c.refund(ctx, comp); err != nil {
+		c.record(ctx, comp.ProviderID, StageRefund, err)
+		comp.Status = CompensationFailed
+		comp.LastError = fmt.Sprintf("refunding after %d attempts: %v", comp.Attempts, err)
+		if err := UpdateCompensation(ctx, c.DB, comp); err != nil {
+			return fmt.Errorf("marking compensation of payment[%s] as failed: %w", comp.ProviderID, err)
+		}
+
+		c.alert(ctx, comp.ProviderID, "the order was payed, its fulfillment and the refund failed: manual recovery is needed: "+comp.LastError)
+		return fmt.Errorf("refunding payment[%s]: %w", comp.ProviderID, err)
+	}
+
+	err = database.Transaction(c.DB, func(tx sqlx.ExtContext) error {
+		comp.Status = CompensationRefunded
+		if err := UpdateCompensation(ctx, tx, comp); err != nil {
+			return err
+		}
+
+		if !found {
+			return nil
+		}
+
+		up := StatusUp{
+			ID:        ord.ID,
+			Status:    Refunded,
+			UpdatedAt: now,
+		}
+
+		return UpdateStatus(ctx, tx, up)
+	})
+	if err != nil {
+		c.alert(ctx, comp.ProviderID, "the order was refunded but its state could not be updated: "+err.Error())
+		return fmt.Errorf("marking payment[%s] as refunded: %w", comp.ProviderID, err)
+	}
+
+	if !found {
+		c.alert(ctx, comp.ProviderID, "the payment was not bound to any order: it has been refunded: "+ferr.Error())
+		return nil
+	}
+
+	usr, err := user.Fetch(ctx, c.DB, ord.UserID)
+	if err != nil {
+		return fmt.Errorf("fetching user[%s] to notify the refund: %w", ord.UserID, err)
+	}
+
+	if err := c.Mailer.SendRefundNotice(ord.ID, usr.Email); err != nil {
+		c.Log.WithField("message", fmt.Errorf("notifying refund of order[%s]: %w", ord.ID, err)).Error("ERROR")
+	}
+
+	c.alert(ctx, comp.ProviderID, "the order was payed but its fulfillment failed: the payment has been refunded: "+ferr.Error())
+	return nil
+}
+
+// backoff returns how long to wait after the passed number of failed
+// attempts.
+func (c *Compensator) backoff(attempts int) time.Duration {
+	d := c.Backoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if c.MaxBackoff > 0 && d >= c.MaxBackoff {
+			return c.MaxBackoff
+		}
+	}
+	return d
+}
+
+// fulfill completes the order of the compensated payment.
+func (c *Compensator) fulfill(ctx context.Context, comp Compensation) error {
+	prov, err := c.Providers.Get(comp.Provider)
+	if err != nil {
+		return err
+	}
+	return fulfill(ctx, c.DB, prov, comp.ProviderID, comp.PaymentID)
+}
+
+// refund gives back the money of the compensated payment.
+func (c *Compensator) refund(ctx context.Context, comp Compensation) error {
+	prov, err := c.Providers.Get(comp.Provider)
+	if err != nil {
+		return err
+	}
+	return prov.Refund(ctx, comp.PaymentID)
+}
+
+// record stores a failure happened while compensating a payment.
+// Failures are only logged since the compensation goes on anyway.
+func (c *Compensator) record(ctx context.Context, providerID string, stage string, cause error) {
+	f := Failure{
+		ID:         validate.GenerateID(),
+		ProviderID: providerID,
+		Stage:      stage,
+		Error:      cause.Error(),
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := CreateFailure(ctx, c.DB, f); err != nil {
+		c.Log.WithField("message", err).Error("ERROR")
+	}
+}
+
+// alert notifies the operators about the order bound to providerID.
+// Failures are only logged since there is nothing more to do.
+func (c *Compensator) alert(ctx context.Context, providerID string, reason string) {
+	a := Alert{
+		ProviderID: providerID,
+		Reason:     reason,
+	}
+
+	if ord, err := FetchByProviderID(ctx, c.DB, providerID); err == nil {
+		a.OrderID = ord.ID
+	}
+
+	if err := c.Alerter.Alert(ctx, a); err != nil {
+		c.Log.WithField("message", fmt.Errorf("alerting about payment[%s]: %w", providerID, err)).Error("ERROR")
+	}
+}
+
+// HandleListCompensations allows administrators to browse the
+// compensations with the status passed via the status query parameter,
+// failed by default, i.e. the dead-lettered ones. The oldest come first.
+// Compensations are paginated via the page and limit query parameters.
+func HandleListCompensations(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		page, err := web.ParsePage(r, defaultPageLimit, maxPageLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		status := CompensationStatus(r.URL.Query().Get("status"))
+		switch status {
+		case "":
+			status = CompensationFailed
+		case CompensationPending, CompensationFulfilled, CompensationRefunded, CompensationFailed:
+		default:
+			return weberr.BadRequest(fmt.Errorf("unknown status %q", status))
+		}
+
+		comps, err := FetchCompensations(ctx, db, status, page.Limit, page.Offset())
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, comps, http.StatusOK)
+	}
+}
+
+// HandleRequeueCompensation allows administrators to requeue a failed
+// compensation, once the cause of the failure has been fixed. It is
+// retried right away with a fresh set of attempts.
+func HandleRequeueCompensation(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		providerID := web.Param(r, "provider_id")
+
+		comp, err := RequeueCompensation(ctx, db, providerID, time.Now().UTC())
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(fmt.Errorf("failed compensation of payment[%s] not found", providerID))
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, comp, http.StatusOK)
+	}
+}
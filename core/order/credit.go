@@ -0,0 +1,131 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jatolentino/tutorialspoint/core/wallet"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// useCredit pays as much as possible of the lines with the store credit
+// of the user. Credit is spent in whole minor units per unit bought, so
+// a few units may be left in the wallet when buying many seats.
+func useCredit(ctx context.Context, db sqlx.ExtContext, userID string, lines []line) error {
+	cur := lines[0].course.Price.Currency
+
+	bal, err := wallet.FetchBalance(ctx, db, userID, cur)
+	if err != nil {
+		return err
+	}
+
+	left := bal.Units
+	for i := range lines {
+		q := int64(lines[i].quantity)
+		per := min(lines[i].amount().Units, left/q)
+		lines[i].credit = money.New(per, cur)
+		left -= per * q
+	}
+
+	return nil
+}
+
+// spendCredit debits the user the store credit spent on the lines.
+func spendCredit(ctx context.Context, db sqlx.ExtContext, userID string, orderID string, lines []line) error {
+	var units int64
+	for _, l := range lines {
+		units += l.credit.Units * int64(l.quantity)
+	}
+
+	if units == 0 {
+		return nil
+	}
+
+	return wallet.Debit(ctx, db, userID, money.New(units, lines[0].course.Price.Currency), wallet.ReasonPurchase, &orderID)
+}
+
+// returnCredit gives back to the user the store credit spent on the
+// items of an order.
+func returnCredit(ctx context.Context, db sqlx.ExtContext, ord Order, items []Item, reason string) error {
+	var units int64
+	for _, it := range items {
+		units += it.Credit.Units * int64(it.Quantity)
+	}
+
+	if units == 0 {
+		return nil
+	}
+
+	return wallet.Credit(ctx, db, ord.UserID, money.New(units, items[0].Price.Currency), reason, &ord.ID, "")
+}
+
+// charged returns what the provider charged for a single unit of an item.
+func charged(it Item) (money.Amount, error) {
+	a, err := it.Price.Sub(it.Discount)
+	if err != nil {
+		return money.Amount{}, err
+	}
+	return a.Sub(it.Credit)
+}
+
+// Credit accepts payments made with store credit only. The credit is
+// spent when the order is created, so nothing is left to charge.
+type Credit struct{}
+
+// NewCredit returns the store credit provider.
+func NewCredit() *Credit {
+	return &Credit{}
+}
+
+// Name implements the PaymentProvider interface.
+func (c *Credit) Name() string {
+	return ProviderCredit
+}
+
+// CreateCheckout returns a purchase which is payed already.
+func (c *Credit) CreateCheckout(ctx context.Context, lines []line, tot money.Amount) (Checkout, error) {
+	id := "credit_" + validate.GenerateID()
+	resp := struct {
+		ID   string `json:"id"`
+		Paid bool   `json:"paid"`
+	}{
+		ID:   id,
+		Paid: true,
+	}
+
+	return Checkout{ID: id, Resp: resp}, nil
+}
+
+// Capture reports that the purchase is payed.
+func (c *Credit) Capture(ctx context.Context, providerID string) (Payment, error) {
+	return Payment{ProviderID: providerID, PaymentID: providerID, Status: Success}, nil
+}
+
+// Taxes returns no taxes: there is no provider calculating them.
+func (c *Credit) Taxes(ctx context.Context, providerID string) ([]Tax, error) {
+	return nil, nil
+}
+
+// Refund does nothing: the credit of the refunded orders is given
+// back to the wallet along with the refund.
+func (c *Credit) Refund(ctx context.Context, paymentID string) error {
+	return nil
+}
+
+// RefundPart does nothing, as Refund does.
+func (c *Credit) RefundPart(ctx context.Context, paymentID string, amount money.Amount) error {
+	return nil
+}
+
+// Cancel does nothing: these purchases are never pending.
+func (c *Credit) Cancel(ctx context.Context, providerID string) error {
+	return nil
+}
+
+// VerifyWebhook is not supported: there is nobody to notify payments.
+func (c *Credit) VerifyWebhook(r *http.Request) (Payment, error) {
+	return Payment{}, errors.New("credit webhooks are not supported")
+}
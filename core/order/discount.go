@@ -0,0 +1,101 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/coupon"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// applyCoupon discounts the lines the coupon with the passed code
+// applies to. It fails if the coupon doesn't apply to any line.
+// Courses bought with a bundle are already discounted.
+func applyCoupon(ctx context.Context, db *sqlx.DB, code string, lines []line) error {
+	c, err := coupon.FetchByCode(ctx, db, code)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return fmt.Errorf("%w: coupon %s not found", coupon.ErrInvalid, coupon.Normalize(code))
+		}
+		return fmt.Errorf("fetching coupon: %w", err)
+	}
+
+	if err := c.Usable(time.Now().UTC()); err != nil {
+		return err
+	}
+
+	applied := false
+	for i := range lines {
+		if lines[i].bundle != "" {
+			continue
+		}
+
+		// Coupons stack on sales, discounting the sale price.
+		base, err := lines[i].course.Price.Sub(lines[i].discount)
+		if err != nil {
+			return err
+		}
+
+		d, ok := c.Discount(lines[i].course.ID, base)
+		if !ok {
+			continue
+		}
+		if lines[i].discount, err = lines[i].discount.Add(d); err != nil {
+			return err
+		}
+		lines[i].coupon = c.Code
+		applied = true
+	}
+
+	if !applied {
+		return fmt.Errorf("%w: coupon %s doesn't apply to the courses being bought", coupon.ErrInvalid, c.Code)
+	}
+
+	return nil
+}
+
+// redeemCoupons counts a use of the coupons applied to the lines.
+func redeemCoupons(ctx context.Context, db sqlx.ExtContext, lines []line) error {
+	for _, code := range couponCodes(lines) {
+		if err := coupon.Redeem(ctx, db, code); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return fmt.Errorf("%w: coupon %s has been used up", coupon.ErrInvalid, code)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseCoupons gives back the uses of the coupons applied to the items.
+func releaseCoupons(ctx context.Context, db sqlx.ExtContext, items []Item) error {
+	seen := make(map[string]bool)
+	for _, it := range items {
+		if it.Coupon == "" || seen[it.Coupon] {
+			continue
+		}
+		seen[it.Coupon] = true
+
+		if err := coupon.Release(ctx, db, it.Coupon); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// couponCodes returns the distinct codes applied to the lines.
+func couponCodes(lines []line) []string {
+	var codes []string
+	seen := make(map[string]bool)
+	for _, l := range lines {
+		if l.coupon == "" || seen[l.coupon] {
+			continue
+		}
+		seen[l.coupon] = true
+		codes = append(codes, l.coupon)
+	}
+	return codes
+}
@@ -0,0 +1,76 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// staleBatch is the maximum number of stale orders expired at each run.
+const staleBatch = 100
+
+// Expirer closes the orders which have been pending for too long,
+// canceling the corresponding purchases on the providers.
+type Expirer struct {
+	DB        *sqlx.DB
+	Providers Providers
+	Log       logrus.FieldLogger
+	TTL       time.Duration
+}
+
+// Run expires the stale orders every interval.
+// It blocks until the passed context is canceled.
+func (e *Expirer) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := e.expire(ctx); err != nil {
+				e.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// expire closes a batch of stale orders.
+func (e *Expirer) expire(ctx context.Context) error {
+	ords, err := FetchStale(ctx, e.DB, time.Now().UTC().Add(-e.TTL), staleBatch)
+	if err != nil {
+		return fmt.Errorf("fetching stale orders: %w", err)
+	}
+
+	for _, ord := range ords {
+		if err := e.cancel(ctx, ord); err != nil {
+			e.Log.WithField("message", err).Error("ERROR")
+		}
+	}
+
+	return nil
+}
+
+// cancel closes the purchase of the order on its provider, then the
+// order itself. If the purchase can't be closed, e.g. because it has been
+// payed in the meanwhile, the order is left to the provider callbacks.
+func (e *Expirer) cancel(ctx context.Context, ord Order) error {
+	prov, err := e.Providers.Get(ord.Provider)
+	if err != nil {
+		return fmt.Errorf("expiring order[%s]: %w", ord.ID, err)
+	}
+
+	if err := prov.Cancel(ctx, ord.ProviderID); err != nil {
+		return fmt.Errorf("expiring order[%s]: %w", ord.ID, err)
+	}
+
+	if _, err := abandon(ctx, e.DB, ord.ProviderID, Expired); err != nil {
+		return fmt.Errorf("expiring order[%s]: %w", ord.ID, err)
+	}
+
+	return nil
+}
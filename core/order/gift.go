@@ -0,0 +1,193 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/random"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// giftCodeLength is the length of the codes redeeming gifts.
+const giftCodeLength = 24
+
+var (
+	// errNotGiftable is returned when gifting a course which can't be gifted.
+	errNotGiftable = errors.New("course can't be gifted")
+
+	// errGiftRedeemed is returned when redeeming a gift twice.
+	errGiftRedeemed = errors.New("gift already redeemed")
+)
+
+// gift returns the line buying a course as a gift to the passed email,
+// priced for the passed region and discounted by the running sales.
+// Rented courses can't be gifted, since their access starts with the
+// purchase.
+func gift(ctx context.Context, db *sqlx.DB, courseID string, email string, region string) (line, error) {
+	to := struct {
+		Email string `validate:"required,email"`
+	}{
+		Email: email,
+	}
+
+	if err := validate.Check(to); err != nil {
+		return line{}, fmt.Errorf("%w: invalid recipient: %v", errNotGiftable, err)
+	}
+
+	c, err := course.Fetch(ctx, db, courseID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return line{}, fmt.Errorf("%w: course[%s] not found", errNotGiftable, courseID)
+		}
+		return line{}, fmt.Errorf("fetching course[%s]: %w", courseID, err)
+	}
+
+	if c.AccessDays > 0 {
+		return line{}, fmt.Errorf("%w: course[%s] is rented", errNotGiftable, courseID)
+	}
+
+	l, err := priced(ctx, db, c, region)
+	if err != nil {
+		return line{}, err
+	}
+
+	l.gift = email
+	return l, nil
+}
+
+// isGift reports whether the lines are bought as gifts.
+func isGift(lines []line) bool {
+	for _, l := range lines {
+		if l.gift != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// createGift adds to an order the gift of a course to the passed email,
+// generating the code to redeem it.
+func createGift(ctx context.Context, db sqlx.ExtContext, orderID string, courseID string, email string, now time.Time) error {
+	code, err := random.StringSecure(giftCodeLength)
+	if err != nil {
+		return fmt.Errorf("generating gift code: %w", err)
+	}
+
+	g := Gift{
+		ID:        validate.GenerateID(),
+		OrderID:   orderID,
+		CourseID:  courseID,
+		Email:     email,
+		Code:      code,
+		CreatedAt: now,
+	}
+
+	return CreateGift(ctx, db, g)
+}
+
+// GiftNotifier emails their codes to the recipients of payed gifts.
+type GiftNotifier struct {
+	DB        *sqlx.DB
+	Mailer    Mailer
+	Log       logrus.FieldLogger
+	RedeemURL string
+}
+
+// Run notifies the recipients of the gifts payed every interval.
+// It blocks until the passed context is canceled.
+func (n *GiftNotifier) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := n.notify(ctx); err != nil {
+				n.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// notify sends the code of each payed gift to its recipient.
+func (n *GiftNotifier) notify(ctx context.Context) error {
+	as, err := FetchUnannounced(ctx, n.DB)
+	if err != nil {
+		return fmt.Errorf("fetching unannounced gifts: %w", err)
+	}
+
+	for _, a := range as {
+		if err := n.Mailer.SendGift(a.CourseName, a.From, n.RedeemURL+a.Code, a.Email); err != nil {
+			n.Log.WithField("message", fmt.Errorf("notifying gift[%s] to %s: %w", a.GiftID, a.Email, err)).Error("ERROR")
+			continue
+		}
+
+		if err := MarkGiftNotified(ctx, n.DB, a.GiftID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// redeem gives the gift with the passed code to the user.
+func redeem(ctx context.Context, db *sqlx.DB, code string, userID string) (Gift, error) {
+	g, err := FetchGiftByCode(ctx, db, code)
+	if err != nil {
+		return Gift{}, err
+	}
+
+	if g.RedeemedBy != nil {
+		return Gift{}, fmt.Errorf("%w: gift[%s]", errGiftRedeemed, g.ID)
+	}
+
+	// Someone else may have redeemed the gift in the meantime.
+	g, err = RedeemGift(ctx, db, g.ID, userID, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Gift{}, fmt.Errorf("%w: %v", errGiftRedeemed, err)
+		}
+		return Gift{}, err
+	}
+
+	return g, nil
+}
+
+// HandleRedeemGift grants the authenticated user the access to the
+// course of the gift whose code is passed in the path. Gifts can be
+// redeemed once, by any user knowing their code.
+func HandleRedeemGift(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		code := web.Param(r, "code")
+
+		g, err := redeem(ctx, db, code, clm.UserID)
+		if err != nil {
+			switch {
+			case errors.Is(err, database.ErrDBNotFound):
+				return weberr.NotFound(errors.New("gift not found"))
+			case errors.Is(err, errGiftRedeemed):
+				return weberr.NewError(err, "gift already redeemed", http.StatusConflict)
+			}
+			return fmt.Errorf("redeeming gift: %w", err)
+		}
+
+		return web.Respond(ctx, w, g, http.StatusOK)
+	}
+}
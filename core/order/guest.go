@@ -0,0 +1,237 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/coupon"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/token"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/random"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// errRegistered is returned when checking out as a guest with the
+// email of a registered user.
+var errRegistered = errors.New("email already registered")
+
+// guest returns the placeholder user of the guest buying with the
+// passed email, creating it on the first purchase.
+// Guests can't log in: they are inactive and their password is
+// unguessable until they claim their account.
+func guest(ctx context.Context, db *sqlx.DB, email string) (user.User, error) {
+	usr, err := user.FetchByEmail(ctx, db, email)
+	if err == nil {
+		if usr.Role != claims.RoleGuest {
+			return user.User{}, fmt.Errorf("%w: %s", errRegistered, email)
+		}
+		return usr, nil
+	}
+	if !errors.Is(err, database.ErrDBNotFound) {
+		return user.User{}, fmt.Errorf("fetching user by email %s: %w", email, err)
+	}
+
+	pass, err := random.StringSecure(16)
+	if err != nil {
+		return user.User{}, fmt.Errorf("generating random secure string: %w", err)
+	}
+
+	now := time.Now().UTC()
+	usr = user.User{
+		ID:           validate.GenerateID(),
+		Name:         email,
+		Email:        email,
+		Role:         claims.RoleGuest,
+		PasswordHash: []byte(pass),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Active:       false,
+	}
+
+	if err := user.Create(ctx, db, usr); err != nil {
+		// The same guest may be checking out concurrently.
+		if errors.Is(err, user.ErrUniqueEmail) {
+			return guest(ctx, db, email)
+		}
+		return user.User{}, fmt.Errorf("creating guest[%s]: %w", email, err)
+	}
+
+	return usr, nil
+}
+
+// guestLines returns the lines buying the passed courses, priced for
+// the passed region and discounted by the running sales and by the
+// coupon with the passed code, if any.
+func guestLines(ctx context.Context, db *sqlx.DB, courseIDs []string, region string, code string) ([]line, error) {
+	lines := make([]line, 0, len(courseIDs))
+	for _, id := range courseIDs {
+		c, err := course.Fetch(ctx, db, id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching course[%s]: %w", id, err)
+		}
+
+		l, err := priced(ctx, db, c, region)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, l)
+	}
+
+	if code != "" {
+		if err := applyCoupon(ctx, db, code, lines); err != nil {
+			return nil, err
+		}
+	}
+
+	return lines, nil
+}
+
+// HandleGuestCheckout starts the purchase of the passed courses without
+// an authenticated session. The order is placed on behalf of a guest
+// identified by the email only: once the order is payed, the guest is
+// sent the link to claim the account holding the courses.
+// Registered users must log in to buy instead.
+// Courses are priced for the region of the IP address of the guest and
+// can be discounted by the coupon passed via the coupon query parameter.
+// The referral code of an affiliate can be passed via the ref one.
+func HandleGuestCheckout(db *sqlx.DB, prov PaymentProvider) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Email     string   `json:"email" validate:"required,email"`
+			CourseIDs []string `json:"courseIds" validate:"min=1,unique,dive,uuid4"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		reg, err := region(ctx, db, r, "")
+		if err != nil {
+			return err
+		}
+
+		lines, err := guestLines(ctx, db, in.CourseIDs, reg, r.URL.Query().Get("coupon"))
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NewError(err, "course not found", http.StatusUnprocessableEntity)
+			}
+			return buyError(err)
+		}
+
+		// Providers can't mix currencies.
+		tot, err := total(lines)
+		if err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		// Providers can't charge zero amounts, free orders don't need them.
+		if tot.IsZero() {
+			prov = NewFree()
+		}
+
+		usr, err := guest(ctx, db, in.Email)
+		if err != nil {
+			if errors.Is(err, errRegistered) {
+				return weberr.NewError(err, "email already registered, please log in to buy", http.StatusConflict)
+			}
+			return err
+		}
+
+		ref, err := referral(ctx, db, r.URL.Query().Get("ref"), usr.ID)
+		if err != nil {
+			return buyError(err)
+		}
+
+		co, err := prov.CreateCheckout(ctx, lines, tot)
+		if err != nil {
+			return fmt.Errorf("creating %s checkout: %w", prov.Name(), err)
+		}
+
+		if err := prepare(ctx, db, usr.ID, nil, ref, prov.Name(), co.ID, lines); err != nil {
+			if errors.Is(err, coupon.ErrInvalid) {
+				return buyError(err)
+			}
+			return fmt.Errorf("creating the order on the database: %w", err)
+		}
+
+		if err := fulfillLocal(ctx, db, prov, co.ID); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, co.Resp, http.StatusOK)
+	}
+}
+
+// ClaimNotifier sends the guests who payed an order, or have been
+// enrolled by administrators, the link to claim their account. Links expire after TTL, guests can ask for a new one
+// with the claim scope.
+type ClaimNotifier struct {
+	DB     *sqlx.DB
+	Mailer Mailer
+	Log    logrus.FieldLogger
+	TTL    time.Duration
+}
+
+// Run notifies the guests who payed every interval.
+// It blocks until the passed context is canceled.
+func (n *ClaimNotifier) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := n.notify(ctx); err != nil {
+				n.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// notify sends a claim token to each guest who payed an order or has
+// been granted a course.
+// Tokens whose email can't be sent are dropped, to retry later.
+func (n *ClaimNotifier) notify(ctx context.Context) error {
+	us, err := FetchUnclaimed(ctx, n.DB)
+	if err != nil {
+		return fmt.Errorf("fetching unclaimed guests: %w", err)
+	}
+
+	for _, u := range us {
+		text, tok, err := token.GenToken(u.ID, n.TTL, token.ClaimToken)
+		if err != nil {
+			return fmt.Errorf("generating random token: %w", err)
+		}
+
+		if err := token.Create(ctx, n.DB, tok); err != nil {
+			return fmt.Errorf("creating claim token for guest[%s]: %w", u.ID, err)
+		}
+
+		if err := n.Mailer.SendClaimToken(text, u.Email); err != nil {
+			n.Log.WithField("message", fmt.Errorf("sending claim token to %s: %w", u.Email, err)).Error("ERROR")
+
+			if err := token.DeleteByUser(ctx, n.DB, u.ID, token.ClaimToken); err != nil {
+				return fmt.Errorf("deleting token by user[%s]: %w", u.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
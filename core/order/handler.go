@@ -1,80 +1,343 @@
 package order
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/jatolentino/tutorialspoint/api/background"
 	"github.com/jatolentino/tutorialspoint/api/web"
 	"github.com/jatolentino/tutorialspoint/api/weberr"
-	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/core/affiliate"
 	"github.com/jatolentino/tutorialspoint/core/cart"
 	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/coupon"
 	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/org"
+	"github.com/jatolentino/tutorialspoint/core/wallet"
+	"github.com/jatolentino/tutorialspoint/core/user"
 	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/email"
+	"github.com/jatolentino/tutorialspoint/money"
 	"github.com/jatolentino/tutorialspoint/validate"
-	"github.com/stripe/stripe-go/v74"
-	stripecl "github.com/stripe/stripe-go/v74/client"
-	"github.com/stripe/stripe-go/v74/webhook"
-
-	"github.com/plutov/paypal/v4"
 )
 
-// checkout retrieves the latest details of the courses in the cart.
-func checkout(ctx context.Context, db *sqlx.DB, userID string) ([]course.Course, error) {
+// Mailer should be able to notify users and administrators
+// about the lifecycle of their orders.
+type Mailer interface {
+	SendRefundNotice(orderID string, to string) error
+	SendFulfillmentAlert(orderID string, reason string, to []string) error
+	SendCheckoutReminder(cartURL string, to string) error
+	SendAccessExpiring(course string, renewURL string, expiresAt time.Time, to string) error
+	SendGift(course string, from string, redeemURL string, to string) error
+	SendClaimToken(token string, to string) error
+	SendReceipt(r email.Receipt, to string) error
+}
+
+// errOwned is returned when buying for oneself courses owned already.
+var errOwned = errors.New("courses already owned")
+
+// errOrderClosed is returned when fulfilling an order closed without
+// being payed, e.g. expired.
+var errOrderClosed = errors.New("order closed")
+
+// line is a course being bought, together with the discount applied
+// and the code of the coupon granting it, if any.
+// Quantity is the number of seats bought by organizations, one otherwise.
+// Gift is the email of the recipient of courses bought as gifts.
+// Credit is the store credit spent on each unit.
+// Bundle is the id of the bundle the course is bought with, if any.
+type line struct {
+	course   course.Course
+	discount money.Amount
+	credit   money.Amount
+	coupon   string
+	renewal  bool
+	quantity int
+	gift     string
+	bundle   string
+}
+
+// amount returns the price to pay for a single unit of the line.
+func (l line) amount() money.Amount {
+	a, err := l.course.Price.Sub(l.discount)
+	if err != nil {
+		return l.course.Price
+	}
+
+	if l.credit.IsZero() {
+		return a
+	}
+
+	c, err := a.Sub(l.credit)
+	if err != nil {
+		return a
+	}
+	return c
+}
+
+// checkout retrieves the latest details of the courses in the cart,
+// priced for the passed region and discounted by the running sales and
+// by the coupon with the passed code, if any.
+// Bundles are expanded into their courses, charged the bundle price.
+func checkout(ctx context.Context, db *sqlx.DB, userID string, region string, code string) ([]line, error) {
 	items, err := cart.FetchItems(ctx, db, userID)
 	if err != nil {
 		return nil, fmt.Errorf("fetching cart items: %w", err)
 	}
 
-	courses := make([]course.Course, 0, len(items))
+	lines := make([]line, 0, len(items))
 	for _, it := range items {
 		c, err := course.Fetch(ctx, db, it.CourseID)
 		if err != nil {
 			return nil, fmt.Errorf("fetching course[%s]: %w", it.CourseID, err)
 		}
 
-		courses = append(courses, c)
+		l, err := priced(ctx, db, c, region)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, l)
+	}
+
+	bundles, err := cart.FetchBundles(ctx, db, userID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching cart bundles: %w", err)
+	}
+
+	var bundled []line
+	for _, b := range bundles {
+		bl, err := bundleLines(ctx, db, b.BundleID)
+		if err != nil {
+			return nil, err
+		}
+		bundled = append(bundled, bl...)
+	}
+
+	if lines, err = withBundles(lines, bundled); err != nil {
+		return nil, err
+	}
+
+	if code != "" && len(lines) > 0 {
+		if err := applyCoupon(ctx, db, code, lines); err != nil {
+			return nil, err
+		}
+	}
+
+	return lines, nil
+}
+
+// toBuy returns what the user is buying: either the renewal of the
+// course passed via the renew query parameter, the course passed via
+// the gift query parameter as a gift to the email passed via to, or
+// the cart.
+// Gifts and the cart can be discounted by the coupon passed via the
+// coupon query parameter. The cart can be bought as seats on behalf of
+// an organization, whose id is returned as well.
+// Courses are priced for the region of the user.
+func toBuy(ctx context.Context, db *sqlx.DB, r *http.Request, userID string) ([]line, *string, error) {
+	code := r.URL.Query().Get("coupon")
+
+	reg, err := region(ctx, db, r, userID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return courses, nil
+	if courseID := r.URL.Query().Get("renew"); courseID != "" {
+		if r.URL.Query().Get("org") != "" {
+			return nil, nil, fmt.Errorf("%w: renewals are personal", errNotSeatable)
+		}
+		if code != "" {
+			return nil, nil, fmt.Errorf("%w: renewals are already discounted", coupon.ErrInvalid)
+		}
+
+		l, err := renewal(ctx, db, userID, courseID, reg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []line{l}, nil, nil
+	}
+
+	if courseID := r.URL.Query().Get("gift"); courseID != "" {
+		if r.URL.Query().Get("org") != "" {
+			return nil, nil, fmt.Errorf("%w: gifts are personal", errNotSeatable)
+		}
+
+		l, err := gift(ctx, db, courseID, r.URL.Query().Get("to"), reg)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		lines := []line{l}
+		if code != "" {
+			if err := applyCoupon(ctx, db, code, lines); err != nil {
+				return nil, nil, err
+			}
+		}
+		return lines, nil, nil
+	}
+
+	return fromCart(ctx, db, r, userID, reg, code)
+}
+
+// fromCart returns the lines buying the cart of the user, priced for
+// the passed region and discounted by the coupon with the passed code,
+// if any. The cart can be bought as seats on behalf of the organization
+// passed via the org query parameter, whose id is returned as well.
+func fromCart(ctx context.Context, db *sqlx.DB, r *http.Request, userID string, region string, code string) ([]line, *string, error) {
+	lines, err := checkout(ctx, db, userID, region, code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orgID, err := seats(ctx, db, r, userID, lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Seats are assigned to others, so they can be bought anyway.
+	if orgID == nil {
+		if err := notOwned(ctx, db, userID, lines); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return lines, orgID, nil
+}
+
+// notOwned checks that the user doesn't own any of the courses bought
+// on their own, e.g. bought meanwhile with another cart or redeemed as
+// a gift. Bundles are bought as a whole, even when some of their
+// courses are owned already.
+func notOwned(ctx context.Context, db *sqlx.DB, userID string, lines []line) error {
+	owned, err := course.FetchByOwner(ctx, db, userID)
+	if err != nil {
+		return fmt.Errorf("fetching courses owned by user[%s]: %w", userID, err)
+	}
+
+	ownedIDs := make(map[string]bool, len(owned))
+	for _, o := range owned {
+		ownedIDs[o.ID] = true
+	}
+
+	var dups []string
+	for _, l := range lines {
+		if l.bundle == "" && ownedIDs[l.course.ID] {
+			dups = append(dups, l.course.ID)
+		}
+	}
+
+	if len(dups) > 0 {
+		return fmt.Errorf("%w: %s", errOwned, strings.Join(dups, ", "))
+	}
+
+	return nil
+}
+
+// buyError turns the errors due to what the user is trying to buy
+// into client errors.
+func buyError(err error) error {
+	switch {
+	case errors.Is(err, errNotRenewable), errors.Is(err, errNotSeatable), errors.Is(err, errNotGiftable),
+		errors.Is(err, errNotBundlable), errors.Is(err, errNotForSale), errors.Is(err, errOwned), errors.Is(err, coupon.ErrInvalid),
+		errors.Is(err, affiliate.ErrInvalid):
+		return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, errNotOrgAdmin):
+		return weberr.NewError(err, err.Error(), http.StatusForbidden)
+	}
+	return fmt.Errorf("fetching details of the items to buy: %w", err)
+}
+
+// total returns the sum of the amounts of the passed lines.
+// It fails if the courses are not sold in the same currency.
+func total(lines []line) (money.Amount, error) {
+	if len(lines) == 0 {
+		return money.Amount{}, errors.New("no items to sum up")
+	}
+
+	amounts := make([]money.Amount, 0, len(lines))
+	for _, l := range lines {
+		amounts = append(amounts, l.amount().Mul(int64(l.quantity)))
+	}
+
+	tot, err := money.Sum(lines[0].course.Price.Currency, amounts...)
+	if err != nil {
+		return money.Amount{}, fmt.Errorf("courses must be bought in the same currency: %w", err)
+	}
+
+	return tot, nil
 }
 
 // prepare creates the order and its items in the database,
-// binding the order to the passed providerID.
-func prepare(ctx context.Context, db *sqlx.DB, userID string, providerID string, courses []course.Course) error {
+// binding the order to the passed providerID and to the referrer, if any.
+// Orders of organizations buy seats instead of courses, gift orders
+// buy the gifts of their lines.
+func prepare(ctx context.Context, db *sqlx.DB, userID string, orgID *string, ref *affiliate.Affiliate, provider string, providerID string, lines []line) error {
 	err := database.Transaction(db, func(tx sqlx.ExtContext) error {
 		now := time.Now().UTC()
 		ord := Order{
 			ID:         validate.GenerateID(),
 			UserID:     userID,
+			OrgID:      orgID,
+			Gift:       isGift(lines),
+			Provider:   provider,
 			ProviderID: providerID,
 			Status:     Pending,
 			CreatedAt:  now,
 			UpdatedAt:  now,
 		}
 
+		if ref != nil {
+			ord.AffiliateID = &ref.ID
+			ord.Commission = ref.Commission
+		}
+
 		if err := Create(ctx, tx, ord); err != nil {
 			return fmt.Errorf("creating order: %w", err)
 		}
 
-		for _, c := range courses {
+		if err := redeemCoupons(ctx, tx, lines); err != nil {
+			return fmt.Errorf("redeeming coupons: %w", err)
+		}
+
+		if err := spendCredit(ctx, tx, userID, ord.ID, lines); err != nil {
+			return fmt.Errorf("spending credit: %w", err)
+		}
+
+		for _, l := range lines {
+			c := l.course
 			it := Item{
 				OrderID:   ord.ID,
 				CourseID:  c.ID,
+				BundleID:  l.bundleID(),
+				Name:      c.Name,
 				Price:     c.Price,
+				Quantity:  l.quantity,
+				Coupon:    l.coupon,
+				Discount:  l.discount,
+				Credit:    money.New(l.credit.Units, c.Price.Currency),
+				Tax:       money.Zero(c.Price.Currency),
+				Renewal:   l.renewal,
 				CreatedAt: now,
 			}
 
 			if err := CreateItem(ctx, tx, it); err != nil {
 				return fmt.Errorf("creating item: %w", err)
 			}
+
+			if l.gift != "" {
+				if err := createGift(ctx, tx, ord.ID, c.ID, l.gift, now); err != nil {
+					return fmt.Errorf("creating gift: %w", err)
+				}
+			}
 		}
 
 		return nil
@@ -86,23 +349,106 @@ func prepare(ctx context.Context, db *sqlx.DB, userID string, providerID string,
 	return nil
 }
 
-func fulfill(ctx context.Context, db *sqlx.DB, providerID string) error {
+// fulfill completes the order bound to providerID, payed by paymentID,
+// recording the taxes calculated by the provider and issuing its invoice.
+// The order.fulfilled event is written to the outbox in the same
+// transaction, for the Relay to publish.
+//
+// Providers notify payments at least once, and the same payment can be
+// notified both by the client capture and by a webhook: orders fulfilled
+// already, or refunded since, are left untouched. Orders closed without
+// being payed, e.g. expired, gave back their coupons and credit, so they
+// are refused with errOrderClosed.
+func fulfill(ctx context.Context, db *sqlx.DB, prov PaymentProvider, providerID string, paymentID string) error {
 	ord, err := FetchByProviderID(ctx, db, providerID)
 	if err != nil {
 		return fmt.Errorf("fetching the order bound to payment[%s]: %w", providerID, err)
 	}
 
+	if ord.Status == Success || ord.Status == Refunded {
+		return nil
+	}
+
+	taxes, err := prov.Taxes(ctx, providerID)
+	if err != nil {
+		return fmt.Errorf("fetching taxes of the order[%s]: %w", ord.ID, err)
+	}
+
 	err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+		// The order is locked, so that concurrent notifications of the
+		// same payment fulfill it once.
+		if ord, err = FetchForUpdate(ctx, tx, ord.ID); err != nil {
+			return fmt.Errorf("locking order: %w", err)
+		}
+
+		switch ord.Status {
+		case Pending:
+		case Success, Refunded:
+			return nil
+		default:
+			return fmt.Errorf("%w: order is %s", errOrderClosed, ord.Status)
+		}
+
+		now := time.Now().UTC()
 		up := StatusUp{
 			ID:        ord.ID,
 			Status:    Success,
-			UpdatedAt: time.Now().UTC(),
+			UpdatedAt: now,
 		}
 
 		if err = UpdateStatus(ctx, tx, up); err != nil {
 			return fmt.Errorf("updating status: %w", err)
 		}
 
+		if err = SetPaymentID(ctx, tx, ord.ID, paymentID); err != nil {
+			return fmt.Errorf("setting payment: %w", err)
+		}
+
+		items, err := FetchItems(ctx, tx, ord.ID)
+		if err != nil {
+			return fmt.Errorf("fetching items: %w", err)
+		}
+
+		if err = recordTaxes(ctx, tx, ord.ID, items, taxes, now); err != nil {
+			return fmt.Errorf("recording taxes: %w", err)
+		}
+
+		// Organizations get seats to assign to their members.
+		if ord.OrgID != nil {
+			for _, it := range items {
+				if err = org.AddSeats(ctx, tx, *ord.OrgID, it.CourseID, it.Quantity, now); err != nil {
+					return fmt.Errorf("adding seats: %w", err)
+				}
+			}
+		} else if !ord.Gift {
+			// Rented courses start counting from now, or from the end
+			// of the current access when renewed in advance.
+			if err = SetExpiry(ctx, tx, ord.ID, ord.UserID, now); err != nil {
+				return fmt.Errorf("setting access expiry: %w", err)
+			}
+		}
+
+		recorded, err := FetchTaxes(ctx, tx, ord.ID)
+		if err != nil {
+			return fmt.Errorf("fetching taxes: %w", err)
+		}
+
+		ord.Status = Success
+		ord.PaymentID = paymentID
+		if err = issueInvoice(ctx, tx, ord, items, recorded, now); err != nil {
+			return fmt.Errorf("issuing invoice: %w", err)
+		}
+
+		// Consumers are notified only once the order is committed.
+		if err = writeFulfilled(ctx, tx, ord, now); err != nil {
+			return fmt.Errorf("writing fulfilled event: %w", err)
+		}
+
+		// Renewals and gifts are not bought from the cart, so leave it untouched.
+		if ord.Gift || len(items) == 1 && items[0].Renewal {
+			return nil
+		}
+
 		// Finally flush the cart as a last step.
 		if err = cart.Delete(ctx, tx, ord.UserID); err != nil {
 			return fmt.Errorf("flushing cart: %w", err)
@@ -117,214 +463,339 @@ func fulfill(ctx context.Context, db *sqlx.DB, providerID string) error {
 	return nil
 }
 
-// HandlePaypalCheckout starts the purchase flow with paypal.
-func HandlePaypalCheckout(db *sqlx.DB, pp *paypal.Client) web.Handler {
-	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		clm, err := claims.Get(ctx)
-		if err != nil {
-			return weberr.NotAuthorized(errors.New("user not authenticated"))
+// abandon closes the pending order bound to providerID with the passed
+// status. Orders which are not pending anymore are left untouched. The
+// order is locked the same as by fulfill, so that an order is either
+// fulfilled or abandoned.
+func abandon(ctx context.Context, db *sqlx.DB, providerID string, status Status) (Order, error) {
+	ord, err := FetchByProviderID(ctx, db, providerID)
+	if err != nil {
+		return Order{}, fmt.Errorf("fetching the order bound to payment[%s]: %w", providerID, err)
+	}
+
+	if ord.Status != Pending {
+		return ord, nil
+	}
+
+	up := StatusUp{
+		ID:        ord.ID,
+		Status:    status,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	// The order won't be payed, so the coupons and the credit it used
+	// are given back.
+	var closed bool
+	err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+		if ord, err = FetchForUpdate(ctx, tx, ord.ID); err != nil {
+			return err
+		}
+
+		if ord.Status != Pending {
+			closed = true
+			return nil
 		}
 
-		courses, err := checkout(ctx, db, clm.UserID)
+		if err := UpdateStatus(ctx, tx, up); err != nil {
+			return err
+		}
+
+		items, err := FetchItems(ctx, tx, ord.ID)
 		if err != nil {
-			return fmt.Errorf("fetching details of cart items: %w", err)
+			return err
 		}
 
-		if len(courses) == 0 {
-			err := errors.New("no items to checkout")
-			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		if err := releaseCoupons(ctx, tx, items); err != nil {
+			return err
 		}
 
-		var tot int
-		items := make([]paypal.Item, 0, len(courses))
-		for _, c := range courses {
-			items = append(items, paypal.Item{
-				Quantity:    "1",
-				Name:        c.Name,
-				Description: c.Description,
+		return returnCredit(ctx, tx, ord, items, wallet.ReasonRelease)
+	})
+	if err != nil {
+		return Order{}, fmt.Errorf("closing order[%s] as %s: %w", ord.ID, status, err)
+	}
+
+	if closed {
+		return ord, nil
+	}
 
-				UnitAmount: &paypal.Money{
-					Currency: "USD",
-					Value:    strconv.Itoa(c.Price),
-				},
-			})
+	ord.Status = status
+	ord.UpdatedAt = up.UpdatedAt
+	return ord, nil
+}
 
-			tot += c.Price
+// HandleCheckout starts the purchase flow with the passed provider.
+// Courses in the cart are bought, unless a course is passed to be renewed.
+// Organization admins can buy seats of the courses in the cart by passing
+// the org and seats query parameters.
+// Passing wallet=true spends the store credit of the user first: the
+// provider charges the rest, if any. Orders with nothing to pay are
+// fulfilled right away, without the provider.
+// Passing the referral code of an affiliate via the ref query parameter
+// binds the order to the affiliate.
+//
+// Clients can pass an Idempotency-Key header, so that retrying the same
+// checkout returns the response of the first attempt instead of starting
+// a new purchase. Keys can't be reused for a different checkout.
+func HandleCheckout(db *sqlx.DB, prov PaymentProvider) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
 		}
 
-		units := []paypal.PurchaseUnitRequest{{
-			Items: items,
-
-			Amount: &paypal.PurchaseUnitAmount{
-				Currency: "USD",
-				Value:    strconv.Itoa(tot),
+		key := r.Header.Get(IdempotencyHeader)
+		if key == "" {
+			resp, err := startCheckout(ctx, db, prov, r, clm.UserID)
+			if err != nil {
+				return err
+			}
+			return web.Respond(ctx, w, resp, http.StatusOK)
+		}
 
-				Breakdown: &paypal.PurchaseUnitAmountBreakdown{ItemTotal: &paypal.Money{
-					Currency: "USD",
-					Value:    strconv.Itoa(tot),
-				}},
-			},
-		}}
+		prev, err := claimKey(ctx, db, clm.UserID, key, prov.Name(), r.URL.Query())
+		if err != nil {
+			return keyError(err)
+		}
 
-		// TODO: Extract these params from the configuration.
-		app := &paypal.ApplicationContext{
-			// ReturnURL: "/success.html",
-			// CancelURL: "/canceled.html",
+		if prev != nil {
+			return web.Respond(ctx, w, json.RawMessage(prev), http.StatusOK)
 		}
 
-		ord, err := pp.CreateOrder(ctx, "CAPTURE", units, nil, app)
+		resp, err := startCheckout(ctx, db, prov, r, clm.UserID)
 		if err != nil {
-			return fmt.Errorf("creating paypal order: %w", err)
+			// Nothing was bought, so the client can retry with the same key.
+			if rerr := DeleteIdempotencyKey(ctx, db, clm.UserID, key); rerr != nil {
+				return fmt.Errorf("%w: releasing idempotency key: %v", err, rerr)
+			}
+			return err
 		}
 
-		if err := prepare(ctx, db, clm.UserID, ord.ID, courses); err != nil {
-			return fmt.Errorf("creating the order on the database: %w", err)
+		if err := saveKey(ctx, db, clm.UserID, key, resp); err != nil {
+			// Left claimed, the key would be in progress until it's stale.
+			if rerr := DeleteIdempotencyKey(ctx, db, clm.UserID, key); rerr != nil {
+				return fmt.Errorf("%w: releasing idempotency key: %v", err, rerr)
+			}
+			return err
 		}
 
-		return web.Respond(ctx, w, ord, http.StatusOK)
+		return web.Respond(ctx, w, resp, http.StatusOK)
 	}
 }
 
-// HandlePaypalCapture checks if the user's purchase has been
-// successfully completed. After the capture, the money of the user
-// will be transferred to our paypal account.
-func HandlePaypalCapture(db *sqlx.DB, pp *paypal.Client) web.Handler {
-	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		providerID := web.Param(r, "id")
+// startCheckout starts the purchase of what the user is buying and
+// returns the response for the client.
+func startCheckout(ctx context.Context, db *sqlx.DB, prov PaymentProvider, r *http.Request, userID string) (any, error) {
+	lines, orgID, err := toBuy(ctx, db, r, userID)
+	if err != nil {
+		return nil, buyError(err)
+	}
 
-		resp, err := pp.CaptureOrder(ctx, providerID, paypal.CaptureOrderRequest{})
-		if err != nil {
-			return fmt.Errorf("capturing paypal order[%s]: %w", providerID, err)
-		}
+	ref, err := referral(ctx, db, r.URL.Query().Get("ref"), userID)
+	if err != nil {
+		return nil, buyError(err)
+	}
 
-		if resp.Status != "COMPLETED" {
-			return fmt.Errorf("captured order[%s] with status[%s] different from 'COMPLETED'", providerID, resp.Status)
-		}
+	if len(lines) == 0 {
+		err := errors.New("no items to checkout")
+		return nil, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
 
-		// WARNING: This is a critical error. The user has payed but he won't have the bought courses.
-		// Take this issue in mind, manual recovery is needed at the moment.
-		//
-		// Putting an alarm here is a good compromise; so we don't need to use an external service
-		// like redis or google pub-sub to enqueue the fulfillment request.
-		// Then if this issue happens regularly we're going to solve it in a proper way.
-		if err := fulfill(ctx, db, providerID); err != nil {
-			// Try to refund the capture.
-			// pp.RefundCapture(ctx, providerID, paypal.RefundCaptureRequest{})
+	// Providers can't mix currencies.
+	tot, err := total(lines)
+	if err != nil {
+		return nil, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
 
-			// Alternative: Use webhooks even for paypal capture.
-			// https://developer.paypal.com/api/rest/webhooks/
-			// PAYMENT.CAPTURE.COMPLETED https://developer.paypal.com/beta/apm-beta/additional-information/subscribe-to-webhooks/
-			// However, this is in BETA...
-			//
-			// We could also try order webhooks but it says: `Orders webhooks are for use by Partners only`
+	// Providers can't charge zero amounts, free orders don't need them.
+	switch {
+	case tot.IsZero():
+		prov = NewFree()
+	case prov.Name() == ProviderFree:
+		err := fmt.Errorf("%w: it costs %s", errNotFree, tot)
+		return nil, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
 
-			return fmt.Errorf("the order was payed but its fulfillment failed: %w", err)
+	// Store credit pays first, the provider charges what is left.
+	if r.URL.Query().Get("wallet") == "true" && !tot.IsZero() {
+		if err := useCredit(ctx, db, userID, lines); err != nil {
+			return nil, err
 		}
 
-		return web.Respond(ctx, w, nil, http.StatusNoContent)
-	}
-}
-
-// HandleStripeCheckout starts the purchase flow with stripe.
-func HandleStripeCheckout(db *sqlx.DB, strp *stripecl.API, cfg config.Stripe) web.Handler {
-	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		clm, err := claims.Get(ctx)
-		if err != nil {
-			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		if tot, err = total(lines); err != nil {
+			return nil, err
 		}
 
-		courses, err := checkout(ctx, db, clm.UserID)
-		if err != nil {
-			return fmt.Errorf("fetching details of cart items: %w", err)
+		if tot.IsZero() {
+			prov = NewCredit()
 		}
+	}
+
+	co, err := prov.CreateCheckout(ctx, lines, tot)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s checkout: %w", prov.Name(), err)
+	}
 
-		if len(courses) == 0 {
-			err := errors.New("no items to checkout")
-			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	if err := prepare(ctx, db, userID, orgID, ref, prov.Name(), co.ID, lines); err != nil {
+		switch {
+		case errors.Is(err, coupon.ErrInvalid):
+			return nil, buyError(err)
+		case errors.Is(err, wallet.ErrInsufficientFunds):
+			return nil, weberr.NewError(err, "the credit in the wallet has changed, please retry", http.StatusConflict)
 		}
+		return nil, fmt.Errorf("creating the order on the database: %w", err)
+	}
 
-		li := make([]*stripe.CheckoutSessionLineItemParams, 0, len(courses))
-		for _, c := range courses {
-			li = append(li, &stripe.CheckoutSessionLineItemParams{
-				Quantity: stripe.Int64(1),
+	if err := fulfillLocal(ctx, db, prov, co.ID); err != nil {
+		return nil, err
+	}
 
-				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-					Currency:    stripe.String("usd"),
-					TaxBehavior: stripe.String("inclusive"),
-					UnitAmount:  stripe.Int64(int64(c.Price) * 100),
+	return co.Resp, nil
+}
 
-					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-						Name:        stripe.String(c.Name),
-						Description: stripe.String(c.Description),
-					},
-				},
-			})
-		}
+// fulfillLocal fulfills right away the orders no payment provider has
+// to charge, payed with store credit only or free.
+func fulfillLocal(ctx context.Context, db *sqlx.DB, prov PaymentProvider, providerID string) error {
+	if prov.Name() != ProviderCredit && prov.Name() != ProviderFree {
+		return nil
+	}
 
-		params := &stripe.CheckoutSessionParams{
-			SuccessURL: stripe.String(cfg.SuccessURL),
-			CancelURL:  stripe.String(cfg.CancelURL),
-			Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
-			LineItems:  li,
+	if err := fulfill(ctx, db, prov, providerID, providerID); err != nil {
+		if _, aerr := abandon(ctx, db, providerID, Failed); aerr != nil {
+			return fmt.Errorf("fulfilling %s order: %w: %v", prov.Name(), err, aerr)
 		}
+		return fmt.Errorf("fulfilling %s order: %w", prov.Name(), err)
+	}
+
+	return nil
+}
+
+// HandleCapture completes the user's purchase with the passed provider,
+// once the user has approved it.
+func HandleCapture(db *sqlx.DB, prov PaymentProvider) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		providerID := web.Param(r, "id")
 
-		// Create a new stripe checkout with the courses to be bought.
-		s, err := strp.CheckoutSessions.New(params)
+		pay, err := prov.Capture(ctx, providerID)
 		if err != nil {
-			return fmt.Errorf("creating stripe session: %w", err)
+			return err
 		}
 
-		if err := prepare(ctx, db, clm.UserID, s.ID, courses); err != nil {
-			return fmt.Errorf("creating the order on the database: %w", err)
+		if pay.Status != Success {
+			return fmt.Errorf("%s purchase[%s] is %s", prov.Name(), providerID, pay.Status)
 		}
 
-		return web.Respond(ctx, w, s.URL, http.StatusOK)
+		return complete(ctx, w, db, prov, pay)
 	}
 }
 
-// HandleStripeCapture completes the user's purchase.
-// Stripe webhooks must be configured to call this endpoint when a
-// checkout is completed.
+// HandleWebhook handles the webhooks of the passed provider, which
+// notify when a purchase is completed, expired or its payment failed.
+// Orders of expired or failed purchases are closed, so they can't be
+// fulfilled anymore. When cartURL is set, users whose purchase expired
+// are reminded that the courses are still in their cart.
 //
-// TODO: Remember to disable async payments.
-// https://stripe.com/docs/payments/checkout/fulfill-orders#delayed-notification .
-// TODO: rename in HandleStripeWebhooks.
-func HandleStripeCapture(db *sqlx.DB, cfg config.Stripe) web.Handler {
+// Authentic events are recorded together with the outcome of their
+// processing, so that the failed ones can be replayed.
+func HandleWebhook(db *sqlx.DB, prov PaymentProvider, mailer Mailer, bg *background.Background, cartURL string) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		b, err := ioutil.ReadAll(r.Body)
+		b, err := io.ReadAll(r.Body)
 		if err != nil {
 			return weberr.BadRequest(fmt.Errorf("cannot read the request body: %w", err))
 		}
+		r.Body = io.NopCloser(bytes.NewReader(b))
 
-		sig := r.Header.Get("Stripe-Signature")
-		if sig == "" {
-			return weberr.BadRequest(errors.New("received stripe event is not signed"))
+		pay, err := prov.VerifyWebhook(r)
+		if err != nil && !errors.Is(err, errEventIgnored) {
+			return weberr.BadRequest(err)
 		}
 
-		event, err := webhook.ConstructEvent(b, sig, cfg.WebhookSecret)
-		if err != nil {
-			return weberr.BadRequest(fmt.Errorf("cannot construct stripe event: %w", err))
+		ev, rerr := receive(ctx, db, prov.Name(), b, pay, err)
+		if rerr != nil {
+			return rerr
 		}
 
-		// Filter all the events but the checkout completion one.
-		if event.Type != "checkout.session.completed" {
+		if ev.Result == EventIgnored {
 			return web.Respond(ctx, w, nil, http.StatusNoContent)
 		}
 
-		var session stripe.CheckoutSession
-		if err = json.Unmarshal(event.Data.Raw, &session); err != nil {
-			return weberr.BadRequest(fmt.Errorf("unable to decode stripe event: %w", err))
+		err = settle(ctx, db, prov, mailer, bg, cartURL, pay)
+		if _, rerr := processed(ctx, db, ev, err); rerr != nil {
+			if err != nil {
+				return fmt.Errorf("%w: %v", err, rerr)
+			}
+			return rerr
+		}
+		if err != nil {
+			return err
 		}
 
-		// Filter out checkouts that are not for one-time payments.
-		if session.Mode != stripe.CheckoutSessionModePayment {
-			return web.Respond(ctx, w, nil, http.StatusNoContent)
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// settle applies the payment notified by a webhook to its order.
+func settle(ctx context.Context, db *sqlx.DB, prov PaymentProvider, mailer Mailer, bg *background.Background, cartURL string, pay Payment) error {
+	switch pay.Status {
+	case Expired:
+		ord, err := abandon(ctx, db, pay.ProviderID, Expired)
+		if err != nil {
+			return fmt.Errorf("expiring the order bound to payment[%s]: %w", pay.ProviderID, err)
 		}
 
-		if err := fulfill(ctx, db, session.ID); err != nil {
-			return fmt.Errorf("the order was payed but its fulfillment failed: %w", err)
+		// Remind the user that the courses are still waiting in the cart.
+		if cartURL != "" && ord.Status == Expired {
+			bg.Add(func() error {
+				usr, err := user.Fetch(context.Background(), db, ord.UserID)
+				if err != nil {
+					return fmt.Errorf("fetching user[%s] to remind the checkout: %w", ord.UserID, err)
+				}
+				// Guests have no cart to go back to.
+				if usr.Role == claims.RoleGuest {
+					return nil
+				}
+				if err := mailer.SendCheckoutReminder(cartURL, usr.Email); err != nil {
+					return fmt.Errorf("reminding checkout of order[%s] to %s: %w", ord.ID, usr.Email, err)
+				}
+				return nil
+			})
 		}
 
-		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	case Failed:
+		if _, err := abandon(ctx, db, pay.ProviderID, Failed); err != nil {
+			return fmt.Errorf("failing the order bound to payment[%s]: %w", pay.ProviderID, err)
+		}
+
+	case Success:
+		return paid(ctx, db, prov, pay)
 	}
+
+	return nil
+}
+
+// complete fulfills the order of a payed purchase.
+func complete(ctx context.Context, w http.ResponseWriter, db *sqlx.DB, prov PaymentProvider, pay Payment) error {
+	if err := paid(ctx, db, prov, pay); err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// paid fulfills the order of a payed purchase.
+// The user has payed, so a failed fulfillment must not be lost:
+// a compensation is scheduled to retry it or to refund the user.
+func paid(ctx context.Context, db *sqlx.DB, prov PaymentProvider, pay Payment) error {
+	if err := fulfill(ctx, db, prov, pay.ProviderID, pay.PaymentID); err != nil {
+
+		// Let the provider retry the webhook if the compensation can't be scheduled.
+		if cerr := compensate(ctx, db, prov.Name(), pay.ProviderID, pay.PaymentID, err); cerr != nil {
+			return fmt.Errorf("the order was payed but its fulfillment failed: %w: %v", err, cerr)
+		}
+
+		err := fmt.Errorf("%w: %v", errCompensated, err)
+		return weberr.NewError(err, "payment received, the order will be completed shortly", http.StatusAccepted)
+	}
+
+	return nil
 }
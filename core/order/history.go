@@ -0,0 +1,259 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// Limits of the pages of orders.
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// receipts attaches to each order its items and what was payed for them.
+func receipts(ctx context.Context, db sqlx.ExtContext, ords []Order) ([]Receipt, error) {
+	ids := make([]string, 0, len(ords))
+	for _, o := range ords {
+		ids = append(ids, o.ID)
+	}
+
+	items, err := FetchItemsByOrders(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byOrder := make(map[string][]ReceiptItem, len(ords))
+	for _, it := range items {
+		paid, err := it.Price.Sub(it.Discount)
+		if err != nil {
+			return nil, fmt.Errorf("computing the price payed for item[%s] of order[%s]: %w", it.CourseID, it.OrderID, err)
+		}
+		byOrder[it.OrderID] = append(byOrder[it.OrderID], ReceiptItem{Item: it, Paid: paid.Mul(int64(it.Quantity))})
+	}
+
+	rs := make([]Receipt, 0, len(ords))
+	for _, o := range ords {
+		its := byOrder[o.ID]
+		if its == nil {
+			its = []ReceiptItem{}
+		}
+		rs = append(rs, Receipt{Order: o, Items: its})
+	}
+
+	return rs, nil
+}
+
+// HandleListMine returns the orders placed by the authenticated user,
+// the most recent first. Orders are paginated via the page and limit
+// query parameters.
+func HandleListMine(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		page, err := web.ParsePage(r, defaultPageLimit, maxPageLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		ords, err := FetchByUser(ctx, db, clm.UserID, page.Limit, page.Offset())
+		if err != nil {
+			return err
+		}
+
+		rs, err := receipts(ctx, db, ords)
+		if err != nil {
+			return fmt.Errorf("fetching items of the orders of user[%s]: %w", clm.UserID, err)
+		}
+
+		return web.Respond(ctx, w, rs, http.StatusOK)
+	}
+}
+
+// HandleShow returns the full details of an order: its items as they
+// were bought, what was payed and refunded, the references of the
+// payment and the history of its status.
+// Orders can be seen by the user who placed them and by administrators.
+func HandleShow(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orderID := web.Param(r, "id")
+
+		if err := validate.CheckID(orderID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		ord, err := Fetch(ctx, db, orderID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if !claims.IsUser(ctx, ord.UserID) && !claims.IsAdmin(ctx) {
+			return weberr.NotAuthorized(errors.New("user trying to fetch the order of another user"))
+		}
+
+		rs, err := receipts(ctx, db, []Order{ord})
+		if err != nil {
+			return fmt.Errorf("fetching items of order[%s]: %w", orderID, err)
+		}
+
+		d := Detail{Receipt: rs[0]}
+		if len(d.Items) > 0 {
+			d.Refunded = money.Zero(d.Items[0].Paid.Currency)
+		}
+		for _, it := range d.Items {
+			if it.RefundedAt == nil {
+				continue
+			}
+			if d.Refunded, err = d.Refunded.Add(it.Paid); err != nil {
+				return fmt.Errorf("summing refunds of order[%s]: %w", orderID, err)
+			}
+		}
+
+		if d.RefundRequests, err = FetchRefundRequestsByOrder(ctx, db, orderID); err != nil {
+			return err
+		}
+
+		if d.History, err = FetchHistory(ctx, db, orderID); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, d, http.StatusOK)
+	}
+}
+
+// HandleSearch allows administrators to search orders by status, email
+// of the user, provider, creation date and total amount, e.g.
+// ?status=success&email=a@b.c&provider=stripe&from=2023-01-01&to=2023-02-01&min=1000&max=5000&currency=USD.
+// Dates are either RFC 3339 timestamps or days, amounts are in minor
+// units. Orders are paginated via the page and limit query parameters.
+func HandleSearch(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		page, err := web.ParsePage(r, defaultPageLimit, maxPageLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		f, err := parseFilter(r.URL.Query())
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+		f.Limit = page.Limit
+		f.Offset = page.Offset()
+
+		ords, err := Search(ctx, db, f)
+		if err != nil {
+			return err
+		}
+
+		rs, err := receipts(ctx, db, ords)
+		if err != nil {
+			return fmt.Errorf("fetching items of the orders found: %w", err)
+		}
+
+		return web.Respond(ctx, w, rs, http.StatusOK)
+	}
+}
+
+// parseFilter extracts the search criteria from the query parameters.
+func parseFilter(v url.Values) (Filter, error) {
+	f := Filter{
+		Status:   Status(v.Get("status")),
+		Email:    v.Get("email"),
+		Provider: v.Get("provider"),
+		Currency: strings.ToUpper(v.Get("currency")),
+	}
+
+	switch f.Status {
+	case "", Pending, Success, Expired, Refunded, Failed:
+	default:
+		return Filter{}, fmt.Errorf("unknown status %q", f.Status)
+	}
+
+	var err error
+	if f.From, err = parseTime(v.Get("from")); err != nil {
+		return Filter{}, fmt.Errorf("invalid from: %w", err)
+	}
+	if f.To, err = parseTime(v.Get("to")); err != nil {
+		return Filter{}, fmt.Errorf("invalid to: %w", err)
+	}
+	if f.MinAmount, err = parseUnits(v.Get("min")); err != nil {
+		return Filter{}, fmt.Errorf("invalid min: %w", err)
+	}
+	if f.MaxAmount, err = parseUnits(v.Get("max")); err != nil {
+		return Filter{}, fmt.Errorf("invalid max: %w", err)
+	}
+
+	return f, nil
+}
+
+// parseTime parses either a RFC 3339 timestamp or a day.
+// It returns nil if s is empty.
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, s); err != nil {
+			return nil, err
+		}
+	}
+
+	t = t.UTC()
+	return &t, nil
+}
+
+// parseUnits parses an amount in minor units.
+// It returns nil if s is empty.
+func parseUnits(s string) (*int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// HandleListFailures allows administrators to investigate the incidents
+// happened while completing an order.
+func HandleListFailures(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orderID := web.Param(r, "id")
+
+		if err := validate.CheckID(orderID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		fs, err := FetchFailures(ctx, db, orderID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, fs, http.StatusOK)
+	}
+}
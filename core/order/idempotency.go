@@ -0,0 +1,115 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyHeader is the header carrying the idempotency key of a checkout.
+const IdempotencyHeader = "Idempotency-Key"
+
+const (
+	// idempotencyTTL is how long idempotency keys are remembered.
+	idempotencyTTL = 24 * time.Hour
+
+	// claimTimeout is how long a checkout can take before its key is
+	// considered abandoned, e.g. after a crash, and can be claimed again.
+	claimTimeout = time.Minute
+
+	// maxKeyLen is the maximum length of idempotency keys.
+	maxKeyLen = 255
+)
+
+var (
+	// errKeyInvalid is returned when an idempotency key is too long.
+	errKeyInvalid = fmt.Errorf("idempotency key can't be longer than %d characters", maxKeyLen)
+
+	// errKeyInProgress is returned when the first request with the same
+	// idempotency key is still being processed.
+	errKeyInProgress = errors.New("a checkout with the same idempotency key is in progress")
+
+	// errKeyReused is returned when an idempotency key is reused
+	// for a different request.
+	errKeyReused = errors.New("idempotency key already used with another request")
+)
+
+// requestHash identifies a checkout by its provider and query. The cart
+// is left out, since it's emptied once the order is fulfilled.
+func requestHash(provider string, query url.Values) []byte {
+	h := sha256.Sum256([]byte(provider + "?" + query.Encode()))
+	return h[:]
+}
+
+// claimKey reserves an idempotency key for a checkout. If the key has
+// already been used, the response of the previous checkout is returned.
+func claimKey(ctx context.Context, db *sqlx.DB, userID string, key string, provider string, query url.Values) ([]byte, error) {
+	if len(key) > maxKeyLen {
+		return nil, errKeyInvalid
+	}
+
+	now := time.Now().UTC()
+	k := IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Provider:    provider,
+		RequestHash: requestHash(provider, query),
+		CreatedAt:   now,
+	}
+
+	err := ClaimIdempotencyKey(ctx, db, k, now.Add(-idempotencyTTL), now.Add(-claimTimeout))
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, database.ErrDBNotFound) {
+		return nil, err
+	}
+
+	// The key is already taken.
+	prev, err := FetchIdempotencyKey(ctx, db, userID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case prev.Provider != provider, !bytes.Equal(prev.RequestHash, k.RequestHash):
+		return nil, errKeyReused
+	case prev.Response == nil:
+		return nil, errKeyInProgress
+	}
+
+	return prev.Response, nil
+}
+
+// saveKey stores the response of the checkout started with the key.
+func saveKey(ctx context.Context, db *sqlx.DB, userID string, key string, resp any) error {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encoding the response of idempotency key %q: %w", key, err)
+	}
+
+	return SaveIdempotentResponse(ctx, db, userID, key, b)
+}
+
+// keyError turns the errors due to idempotency keys into client errors.
+func keyError(err error) error {
+	switch {
+	case errors.Is(err, errKeyInvalid):
+		return weberr.BadRequest(err)
+	case errors.Is(err, errKeyInProgress):
+		return weberr.NewError(err, err.Error(), http.StatusConflict)
+	case errors.Is(err, errKeyReused):
+		return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+	return fmt.Errorf("claiming idempotency key: %w", err)
+}
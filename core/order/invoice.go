@@ -0,0 +1,251 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/org"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jatolentino/tutorialspoint/pdf"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// invoiceNumber formats the number of an invoice as printed on it.
+func invoiceNumber(n int64) string {
+	return fmt.Sprintf("INV-%06d", n)
+}
+
+// issueInvoice numbers, renders and stores the invoice of a fulfilled
+// order, billed to the organization which placed it or to the user.
+func issueInvoice(ctx context.Context, db sqlx.ExtContext, ord Order, items []Item, taxes []Tax, now time.Time) error {
+	billedTo := ""
+	if ord.OrgID != nil {
+		o, err := org.Fetch(ctx, db, *ord.OrgID)
+		if err != nil {
+			return err
+		}
+		billedTo = o.Name
+	} else {
+		usr, err := user.Fetch(ctx, db, ord.UserID)
+		if err != nil {
+			return err
+		}
+		billedTo = fmt.Sprintf("%s <%s>", usr.Name, usr.Email)
+	}
+
+	n, err := NextInvoiceNumber(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	doc, err := renderInvoice(n, ord, billedTo, items, taxes, now)
+	if err != nil {
+		return err
+	}
+
+	inv := Invoice{
+		OrderID:   ord.ID,
+		Number:    n,
+		PDF:       doc,
+		CreatedAt: now,
+	}
+
+	return CreateInvoice(ctx, db, inv)
+}
+
+// Columns of the items of an invoice, in points from the left margin.
+const (
+	colQuantity = 260
+	colPrice    = 295
+	colDiscount = 365
+	colTotal    = 435
+)
+
+// renderInvoice writes the invoice of an order as a PDF document.
+// Taxes included in the prices are listed for information only,
+// the others are added to the total.
+func renderInvoice(n int64, ord Order, billedTo string, items []Item, taxes []Tax, now time.Time) ([]byte, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("order[%s] has no items", ord.ID)
+	}
+	currency := items[0].Price.Currency
+
+	d := pdf.New()
+	d.Bold(0, 20, "Invoice "+invoiceNumber(n))
+	d.Ln(36)
+
+	ref := ord.PaymentID
+	if ref == "" {
+		ref = ord.ProviderID
+	}
+
+	for _, f := range [][2]string{
+		{"Date", now.Format(time.DateOnly)},
+		{"Order", ord.ID},
+		{"Billed to", billedTo},
+		{"Payment", ord.Provider + " " + ref},
+	} {
+		d.Bold(0, 10, f[0])
+		d.Text(80, 10, f[1])
+		d.Ln(16)
+	}
+	d.Ln(16)
+
+	d.Bold(0, 10, "Course")
+	d.Bold(colQuantity, 10, "Qty")
+	d.Bold(colPrice, 10, "Price")
+	d.Bold(colDiscount, 10, "Discount")
+	d.Bold(colTotal, 10, "Amount")
+	d.Ln(18)
+
+	amounts := make([]money.Amount, 0, len(items))
+	credits := make([]money.Amount, 0, len(items))
+	for _, it := range items {
+		paid, err := it.Price.Sub(it.Discount)
+		if err != nil {
+			return nil, fmt.Errorf("computing the price payed for item[%s]: %w", it.CourseID, err)
+		}
+		paid = paid.Mul(int64(it.Quantity))
+		amounts = append(amounts, paid)
+		credits = append(credits, it.Credit.Mul(int64(it.Quantity)))
+
+		d.Text(0, 10, truncate(it.Name, 48))
+		d.Text(colQuantity, 10, strconv.Itoa(it.Quantity))
+		d.Text(colPrice, 10, it.Price.String())
+		d.Text(colDiscount, 10, it.Discount.String())
+		d.Text(colTotal, 10, paid.String())
+		d.Ln(16)
+	}
+	d.Ln(16)
+
+	names := make(map[string]string, len(items))
+	for _, it := range items {
+		names[it.CourseID] = it.Name
+	}
+
+	var taxed []money.Amount
+	if len(taxes) > 0 {
+		d.Bold(0, 10, "Taxes")
+		d.Ln(18)
+	}
+	for _, t := range taxes {
+		kind := "added"
+		if t.Inclusive {
+			kind = "included"
+		} else {
+			amounts = append(amounts, t.Amount)
+		}
+		taxed = append(taxed, t.Amount)
+
+		where := t.Country
+		if t.State != "" {
+			where += "-" + t.State
+		}
+
+		d.Text(0, 10, truncate(fmt.Sprintf("%s %g%% %s, %s", t.Name, t.Percentage, where, names[t.CourseID]), 60))
+		d.Text(colDiscount, 10, kind)
+		d.Text(colTotal, 10, t.Amount.String())
+		d.Ln(16)
+	}
+	d.Ln(16)
+
+	tax, err := money.Sum(currency, taxed...)
+	if err != nil {
+		return nil, fmt.Errorf("summing the taxes: %w", err)
+	}
+
+	tot, err := money.Sum(currency, amounts...)
+	if err != nil {
+		return nil, fmt.Errorf("summing the amounts: %w", err)
+	}
+
+	credit, err := money.Sum(currency, credits...)
+	if err != nil {
+		return nil, fmt.Errorf("summing the store credit: %w", err)
+	}
+
+	d.Text(colDiscount, 10, "Tax")
+	d.Text(colTotal, 10, tax.String())
+	d.Ln(16)
+	d.Bold(colDiscount, 12, "Total")
+	d.Bold(colTotal, 12, tot.String())
+
+	// Store credit is a payment as well, the provider charged the rest.
+	if !credit.IsZero() {
+		rest, err := tot.Sub(credit)
+		if err != nil {
+			return nil, err
+		}
+
+		d.Ln(18)
+		d.Text(colDiscount, 10, "Store credit")
+		d.Text(colTotal, 10, credit.String())
+		d.Ln(16)
+		d.Text(colDiscount, 10, "Charged")
+		d.Text(colTotal, 10, rest.String())
+	}
+
+	return d.Bytes(), nil
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
+
+// HandleShowInvoice sends the PDF invoice of a fulfilled order.
+// Invoices can be downloaded by the user who placed the order and by
+// administrators.
+func HandleShowInvoice(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orderID := web.Param(r, "id")
+
+		if err := validate.CheckID(orderID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		ord, err := Fetch(ctx, db, orderID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if !claims.IsUser(ctx, ord.UserID) && !claims.IsAdmin(ctx) {
+			return weberr.NotAuthorized(errors.New("user trying to fetch the invoice of another user"))
+		}
+
+		inv, err := FetchInvoice(ctx, db, orderID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, invoiceNumber(inv.Number)))
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := w.Write(inv.PDF); err != nil {
+			return fmt.Errorf("sending invoice of order[%s]: %w", orderID, err)
+		}
+
+		return nil
+	}
+}
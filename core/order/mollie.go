@@ -0,0 +1,252 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+// Mollie accepts the payment methods preferred by european customers,
+// e.g. iDEAL, SEPA bank transfers or Bancontact, through mollie
+// payments. Users pay on the checkout page hosted by mollie, and orders
+// are completed by mollie webhooks once the payment status changes.
+type Mollie struct {
+	client *http.Client
+	cfg    config.Mollie
+}
+
+// NewMollie returns the mollie provider.
+func NewMollie(cfg config.Mollie) *Mollie {
+	return &Mollie{client: &http.Client{Timeout: 30 * time.Second}, cfg: cfg}
+}
+
+// mollieAmount is an amount as expressed by mollie, in major units.
+type mollieAmount struct {
+	Currency string `json:"currency"`
+	Value    string `json:"value"`
+}
+
+// molliePayment is a mollie payment.
+type molliePayment struct {
+	ID           string       `json:"id"`
+	Status       string       `json:"status"`
+	Amount       mollieAmount `json:"amount"`
+	IsCancelable bool         `json:"isCancelable"`
+	Links        struct {
+		Checkout struct {
+			Href string `json:"href"`
+		} `json:"checkout"`
+	} `json:"_links"`
+}
+
+// Name implements the PaymentProvider interface.
+func (ml *Mollie) Name() string {
+	return ProviderMollie
+}
+
+// CreateCheckout creates a payment of the total amount, payable with
+// the configured methods, and returns the URL of its checkout page.
+func (ml *Mollie) CreateCheckout(ctx context.Context, lines []line, tot money.Amount) (Checkout, error) {
+	names := make([]string, 0, len(lines))
+	for _, l := range lines {
+		names = append(names, l.course.Name)
+	}
+
+	in := map[string]any{
+		"amount": mollieAmount{
+			Currency: tot.Currency,
+			Value:    tot.Decimal(),
+		},
+		"description": truncate(strings.Join(names, ", "), 255),
+		"redirectUrl": ml.cfg.RedirectURL,
+		"webhookUrl":  ml.cfg.WebhookURL,
+	}
+	if len(ml.cfg.Methods) > 0 {
+		in["method"] = ml.cfg.Methods
+	}
+
+	var out molliePayment
+	if err := ml.do(ctx, http.MethodPost, "/v2/payments", in, &out); err != nil {
+		return Checkout{}, fmt.Errorf("creating mollie payment: %w", err)
+	}
+
+	return Checkout{ID: out.ID, Resp: out.Links.Checkout.Href}, nil
+}
+
+// Capture checks whether the payment has been payed.
+// Mollie completes payments by itself, so nothing else is done.
+func (ml *Mollie) Capture(ctx context.Context, providerID string) (Payment, error) {
+	p, err := ml.fetch(ctx, providerID)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	return p.payment(), nil
+}
+
+// Refund gives back the whole amount of the payment.
+func (ml *Mollie) Refund(ctx context.Context, paymentID string) error {
+	p, err := ml.fetch(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+
+	return ml.refund(ctx, paymentID, p.Amount)
+}
+
+// RefundPart gives back amount of the payment.
+func (ml *Mollie) RefundPart(ctx context.Context, paymentID string, amount money.Amount) error {
+	return ml.refund(ctx, paymentID, mollieAmount{Currency: amount.Currency, Value: amount.Decimal()})
+}
+
+// refund gives back amount of the payment.
+func (ml *Mollie) refund(ctx context.Context, paymentID string, amount mollieAmount) error {
+	in := map[string]any{"amount": amount}
+	if err := ml.do(ctx, http.MethodPost, "/v2/payments/"+paymentID+"/refunds", in, nil); err != nil {
+		return fmt.Errorf("refunding %s %s of mollie payment[%s]: %w", amount.Value, amount.Currency, paymentID, err)
+	}
+
+	return nil
+}
+
+// Taxes returns no taxes: prices are charged as they are on mollie.
+func (ml *Mollie) Taxes(ctx context.Context, providerID string) ([]Tax, error) {
+	return nil, nil
+}
+
+// Cancel cancels the payment. Mollie only cancels some of the payments
+// not payed yet, e.g. bank transfers, the others just expire.
+func (ml *Mollie) Cancel(ctx context.Context, providerID string) error {
+	p, err := ml.fetch(ctx, providerID)
+	if err != nil {
+		return err
+	}
+
+	if p.Status == "paid" {
+		return fmt.Errorf("mollie payment[%s] has been payed already", providerID)
+	}
+
+	if !p.IsCancelable {
+		return nil
+	}
+
+	if err := ml.do(ctx, http.MethodDelete, "/v2/payments/"+providerID, nil, nil); err != nil {
+		return fmt.Errorf("canceling mollie payment[%s]: %w", providerID, err)
+	}
+
+	return nil
+}
+
+// VerifyWebhook returns the payment notified by a mollie webhook.
+// Mollie doesn't sign its webhooks, which only carry the id of the
+// payment: its status is fetched from the mollie API instead, so that
+// forged calls can't complete orders.
+func (ml *Mollie) VerifyWebhook(r *http.Request) (Payment, error) {
+	if err := r.ParseForm(); err != nil {
+		return Payment{}, fmt.Errorf("unable to decode mollie webhook: %w", err)
+	}
+
+	id := r.PostForm.Get("id")
+	if id == "" {
+		return Payment{}, errors.New("received mollie webhook without payment id")
+	}
+
+	p, err := ml.fetch(r.Context(), id)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	pay := p.payment()
+	pay.Event = "payment." + p.Status
+	if pay.Status == Pending {
+		return Payment{Event: pay.Event}, errEventIgnored
+	}
+
+	return pay, nil
+}
+
+// fetch returns the mollie payment with the passed id.
+func (ml *Mollie) fetch(ctx context.Context, id string) (molliePayment, error) {
+	var p molliePayment
+	if err := ml.do(ctx, http.MethodGet, "/v2/payments/"+id, nil, &p); err != nil {
+		return molliePayment{}, fmt.Errorf("fetching mollie payment[%s]: %w", id, err)
+	}
+
+	return p, nil
+}
+
+// payment returns the outcome of the mollie payment.
+// Payments which are neither payed nor over are pending.
+func (p molliePayment) payment() Payment {
+	pay := Payment{ProviderID: p.ID, Status: Pending}
+
+	switch p.Status {
+	case "paid":
+		pay.PaymentID = p.ID
+		pay.Status = Success
+	case "expired":
+		pay.Status = Expired
+	case "failed", "canceled":
+		pay.Status = Failed
+	}
+
+	return pay
+}
+
+// do calls the mollie API, sending in and decoding the response in
+// out, when not nil.
+func (ml *Mollie) do(ctx context.Context, method string, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, ml.cfg.URL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+ml.cfg.APIKey)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := ml.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var e struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+			return fmt.Errorf("mollie responded %s", resp.Status)
+		}
+		return fmt.Errorf("mollie responded %s: %s: %s", resp.Status, e.Title, e.Detail)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding mollie response: %w", err)
+	}
+
+	return nil
+}
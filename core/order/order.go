@@ -1,25 +1,57 @@
 package order
 
-import "time"
+import (
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/money"
+)
 
 // Status models the possible states of an order.
 type Status string
 
 const (
-	Pending Status = "pending"
-	Success Status = "success"
-	Expired Status = "expired"
+	Pending  Status = "pending"
+	Success  Status = "success"
+	Expired  Status = "expired"
+	Refunded Status = "refunded"
+	Failed   Status = "failed"
+)
+
+// Known payment providers.
+const (
+	ProviderPaypal       = "paypal"
+	ProviderStripe       = "stripe"
+	ProviderStripeIntent = "stripe_intent"
+	ProviderCredit       = "credit"
+	ProviderFree         = "free"
+	ProviderRazorpay     = "razorpay"
+	ProviderCoinbase     = "coinbase"
+	ProviderMollie       = "mollie"
 )
 
 // Order models orders.
 // Orders have a one-to-many relationship with items.
+// Orders placed on behalf of an organization buy seats instead of
+// granting access to the user who placed them.
+// Gift orders grant access to the recipients of their gifts instead.
+// PaymentID identifies the payment to refund: the capture for paypal,
+// the payment intent for stripe and stripe_intent, the payment for razorpay, the charge
+// for coinbase. It is set once the order is fulfilled.
+// Orders placed with a referral code are bound to the affiliate, who
+// is owed Commission percent of what is payed.
 type Order struct {
-	ID         string    `json:"id" db:"order_id"`
-	UserID     string    `json:"userId" db:"user_id"`
-	ProviderID string    `json:"providerId" db:"provider_id"`
-	Status     Status    `json:"status" db:"status"`
-	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+	ID          string    `json:"id" db:"order_id"`
+	UserID      string    `json:"userId" db:"user_id"`
+	OrgID       *string   `json:"orgId" db:"org_id"`
+	Gift        bool      `json:"gift" db:"gift"`
+	Provider    string    `json:"provider" db:"provider"`
+	ProviderID  string    `json:"providerId" db:"provider_id"`
+	PaymentID   string    `json:"paymentId" db:"payment_id"`
+	Status      Status    `json:"status" db:"status"`
+	AffiliateID *string   `json:"affiliateId" db:"affiliate_id"`
+	Commission  int       `json:"-" db:"commission"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
 }
 
 // StatusUp contains the information needed to update an order.
@@ -32,9 +64,347 @@ type StatusUp struct {
 // Item models the item of an order.
 // An item can only belong to one order.
 // An order can have many items.
+// Items snapshot the course as it was bought, so later changes
+// to the course don't alter past orders.
+// Items of rented courses grant access until ExpiresAt.
+// Quantity is greater than one only for seats bought by organizations.
+// Discount and Credit are taken off the price of each unit: Credit is
+// the store credit spent on it.
+// BundleID is the bundle the course was bought with, if any: its price
+// is spread over the discounts of its items.
+// Refunded items don't grant access to their course anymore.
 type Item struct {
-	OrderID   string    `json:"orderId" db:"order_id"`
-	CourseID  string    `json:"courseId" db:"course_id"`
-	Price     int       `json:"price" db:"price"`
+	OrderID    string       `json:"orderId" db:"order_id"`
+	CourseID   string       `json:"courseId" db:"course_id"`
+	BundleID   *string      `json:"bundleId" db:"bundle_id"`
+	Name       string       `json:"name" db:"name"`
+	Price      money.Amount `json:"price" db:"price"`
+	Quantity   int          `json:"quantity" db:"quantity"`
+	Coupon     string       `json:"coupon" db:"coupon"`
+	Discount   money.Amount `json:"discount" db:"discount"`
+	Credit     money.Amount `json:"credit" db:"credit"`
+	Tax        money.Amount `json:"tax" db:"tax"`
+	Renewal    bool         `json:"renewal" db:"renewal"`
+	ExpiresAt  *time.Time   `json:"expiresAt" db:"expires_at"`
+	Notified   bool         `json:"-" db:"expiry_notified"`
+	RefundedAt *time.Time   `json:"refundedAt" db:"refunded_at"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+}
+
+// Receipt models an order as seen by the user who placed it.
+type Receipt struct {
+	Order
+	Items []ReceiptItem `json:"items"`
+}
+
+// Detail models an order with all of its details: the items with what
+// was payed for them, the amount refunded, the refunds asked by the
+// buyer and the history of its status.
+type Detail struct {
+	Receipt
+	Refunded       money.Amount    `json:"refunded"`
+	RefundRequests []RefundRequest `json:"refundRequests"`
+	History        []StatusChange  `json:"history"`
+}
+
+// StatusChange models a status an order went through.
+type StatusChange struct {
+	Status    Status    `json:"status" db:"status"`
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 }
+
+// ReceiptItem models an item of a receipt, together with what the
+// user actually payed for it.
+type ReceiptItem struct {
+	Item
+	Paid money.Amount `json:"paid"`
+}
+
+// Tax models a tax applied to an order item, as calculated by the
+// payment provider from the address of the customer. Items can be
+// subject to more than one tax, e.g. state and county sales taxes.
+// Amount is the tax applied to the whole quantity of the item.
+type Tax struct {
+	ID           string       `json:"id" db:"tax_id"`
+	OrderID      string       `json:"orderId" db:"order_id"`
+	CourseID     string       `json:"courseId" db:"course_id"`
+	Name         string       `json:"name" db:"name"`
+	Jurisdiction string       `json:"jurisdiction" db:"jurisdiction"`
+	Country      string       `json:"country" db:"country"`
+	State        string       `json:"state" db:"state"`
+	Percentage   float64      `json:"percentage" db:"percentage"`
+	Inclusive    bool         `json:"inclusive" db:"inclusive"`
+	Amount       money.Amount `json:"amount" db:"amount"`
+	CreatedAt    time.Time    `json:"createdAt" db:"created_at"`
+}
+
+// Gift models a course bought for someone else, who redeems it with
+// the code emailed once the order is payed.
+type Gift struct {
+	ID         string     `json:"id" db:"gift_id"`
+	OrderID    string     `json:"orderId" db:"order_id"`
+	CourseID   string     `json:"courseId" db:"course_id"`
+	Email      string     `json:"email" db:"email"`
+	Code       string     `json:"-" db:"code"`
+	Notified   bool       `json:"notified" db:"notified"`
+	RedeemedBy *string    `json:"redeemedBy" db:"redeemed_by"`
+	RedeemedAt *time.Time `json:"redeemedAt" db:"redeemed_at"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// Invoice is the document issued for a fulfilled order.
+// Numbers are progressive across all the invoices.
+type Invoice struct {
+	OrderID   string    `db:"order_id"`
+	Number    int64     `db:"number"`
+	PDF       []byte    `db:"pdf"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Filter contains the criteria to search orders. Empty criteria are
+// ignored. Amounts are the total payed for an order, in minor units.
+type Filter struct {
+	Status    Status     `db:"status"`
+	Email     string     `db:"email"`
+	Provider  string     `db:"provider"`
+	From      *time.Time `db:"from"`
+	To        *time.Time `db:"to"`
+	MinAmount *int64     `db:"min_amount"`
+	MaxAmount *int64     `db:"max_amount"`
+	Currency  string     `db:"currency"`
+	Limit     int        `db:"limit"`
+	Offset    int        `db:"offset"`
+}
+
+// Groupings of the revenue reports.
+const (
+	ByDay      = "day"
+	ByWeek     = "week"
+	ByMonth    = "month"
+	ByCourse   = "course"
+	ByProvider = "provider"
+)
+
+// RevenueFilter contains the criteria of a revenue report: how to group
+// the fulfilled orders and, optionally, the period to cover.
+type RevenueFilter struct {
+	By   string     `db:"by"`
+	From *time.Time `db:"from"`
+	To   *time.Time `db:"to"`
+}
+
+// Revenue models what fulfilled orders earned, grouped by Key: either a
+// period, the id of a course or a provider. Payments are accounted when
+// orders are fulfilled, refunds when they are made, so refunds may fall
+// in another period than the payments they give back.
+type Revenue struct {
+	Key      string       `json:"key" db:"key"`
+	Currency string       `json:"currency" db:"currency"`
+	Orders   int          `json:"orders" db:"orders"`
+	Gross    money.Amount `json:"gross" db:"gross"`
+	Refunds  money.Amount `json:"refunds" db:"refunds"`
+	Net      money.Amount `json:"net" db:"net"`
+}
+
+// Stages of the completion of an order in which failures happen.
+const (
+	StageFulfillment = "fulfillment"
+	StageRetry       = "retry"
+	StageRefund      = "refund"
+)
+
+// Failure records an incident happened while completing a payed order.
+// OrderID is nil when the order bound to the payment was not found.
+type Failure struct {
+	ID         string    `json:"id" db:"failure_id"`
+	ProviderID string    `json:"providerId" db:"provider_id"`
+	OrderID    *string   `json:"orderId" db:"order_id"`
+	Stage      string    `json:"stage" db:"stage"`
+	Error      string    `json:"error" db:"error"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// IdempotencyKey records a checkout started with an idempotency key.
+// RequestHash identifies the request the key was claimed for. Response
+// is nil until the checkout has been started.
+type IdempotencyKey struct {
+	UserID      string    `db:"user_id"`
+	Key         string    `db:"idem_key"`
+	Provider    string    `db:"provider"`
+	RequestHash []byte    `db:"request_hash"`
+	Response    []byte    `db:"response"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// RefundRequestStatus models the possible states of a refund request.
+type RefundRequestStatus string
+
+// Possible states of a refund request.
+const (
+	RefundRequested RefundRequestStatus = "pending"
+	RefundApproved  RefundRequestStatus = "approved"
+	RefundDenied    RefundRequestStatus = "denied"
+)
+
+// RefundRequest models the refund of an order asked by its buyer.
+// Administrators approve it, refunding the order, or deny it giving
+// the reason in Note.
+type RefundRequest struct {
+	ID        string              `json:"id" db:"request_id"`
+	OrderID   string              `json:"orderId" db:"order_id"`
+	UserID    string              `json:"userId" db:"user_id"`
+	Reason    string              `json:"reason" db:"reason"`
+	Status    RefundRequestStatus `json:"status" db:"status"`
+	Note      string              `json:"note" db:"note"`
+	CreatedAt time.Time           `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time           `json:"updatedAt" db:"updated_at"`
+}
+
+// RefundRequestNew contains the information needed to ask for a refund.
+type RefundRequestNew struct {
+	Reason string `json:"reason" validate:"required,max=2000"`
+}
+
+// RefundDecision contains the information of the decision on a refund
+// request. Note is required to deny it.
+type RefundDecision struct {
+	Note string `json:"note" validate:"max=2000"`
+}
+
+// CompensationStatus models the possible states of a compensation.
+// Failed compensations are dead-lettered: they are not retried until
+// the operators requeue them.
+type CompensationStatus string
+
+const (
+	CompensationPending   CompensationStatus = "pending"
+	CompensationFulfilled CompensationStatus = "fulfilled"
+	CompensationRefunded  CompensationStatus = "refunded"
+	CompensationFailed    CompensationStatus = "failed"
+)
+
+// Compensation tracks an order which has been payed but whose fulfillment
+// failed. The fulfillment is retried until it succeeds or the attempts
+// are exhausted, in which case the payment is refunded.
+type Compensation struct {
+	ProviderID string             `json:"providerId" db:"provider_id"`
+	Provider   string             `json:"provider" db:"provider"`
+	PaymentID  string             `json:"paymentId" db:"payment_id"`
+	Status     CompensationStatus `json:"status" db:"status"`
+	Attempts   int                `json:"attempts" db:"attempts"`
+	LastError  string             `json:"lastError" db:"last_error"`
+	NextRunAt  time.Time          `json:"nextRunAt" db:"next_run_at"`
+	CreatedAt  time.Time          `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time          `json:"updatedAt" db:"updated_at"`
+}
+
+// EventResult models the outcome of the processing of a webhook event.
+type EventResult string
+
+const (
+	EventReceived    EventResult = "received"
+	EventProcessed   EventResult = "processed"
+	EventIgnored     EventResult = "ignored"
+	EventCompensated EventResult = "compensated"
+	EventFailed      EventResult = "failed"
+)
+
+// WebhookEvent records a webhook call of a payment provider, together with
+// the payment it notified and the outcome of its processing.
+// Events are Received until processed, Compensated when the payment was
+// received but the fulfillment of the order has been scheduled again.
+// Failed events can be replayed by administrators.
+type WebhookEvent struct {
+	ID            string      `json:"id" db:"event_id"`
+	Provider      string      `json:"provider" db:"provider"`
+	Type          string      `json:"type" db:"type"`
+	Payload       string      `json:"payload" db:"payload"`
+	ProviderID    string      `json:"providerId" db:"provider_id"`
+	PaymentID     string      `json:"paymentId" db:"payment_id"`
+	PaymentStatus Status      `json:"paymentStatus" db:"payment_status"`
+	Result        EventResult `json:"result" db:"result"`
+	Error         string      `json:"error" db:"error"`
+	Attempts      int         `json:"attempts" db:"attempts"`
+	CreatedAt     time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time   `json:"updatedAt" db:"updated_at"`
+}
+
+// Kinds of mismatches between payments and orders.
+const (
+	MismatchPaidNotFulfilled = "paid_not_fulfilled"
+	MismatchFulfilledNotPaid = "fulfilled_not_paid"
+)
+
+// Mismatch records a payment which doesn't match the local orders:
+// either the payment was received but its order is not fulfilled,
+// or the order was fulfilled but the provider has no payment for it.
+// OrderID is nil when no order is bound to the payment.
+type Mismatch struct {
+	ID         string    `json:"id" db:"mismatch_id"`
+	Provider   string    `json:"provider" db:"provider"`
+	ProviderID string    `json:"providerId" db:"provider_id"`
+	PaymentID  string    `json:"paymentId" db:"payment_id"`
+	OrderID    *string   `json:"orderId" db:"order_id"`
+	Kind       string    `json:"kind" db:"kind"`
+	DetectedAt time.Time `json:"detectedAt" db:"detected_at"`
+}
+
+// Topics of the events written to the outbox.
+const (
+	TopicFulfilled = "order.fulfilled"
+)
+
+// Event models an event written to the outbox in the same transaction
+// as the change it notifies, so that it's never lost nor sent for
+// changes rolled back. PublishedAt is nil until every consumer
+// received it.
+type Event struct {
+	ID          string     `json:"id" db:"event_id"`
+	Topic       string     `json:"topic" db:"topic"`
+	Payload     string     `json:"payload" db:"payload"`
+	Attempts    int        `json:"attempts" db:"attempts"`
+	LastError   string     `json:"lastError" db:"last_error"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	PublishedAt *time.Time `json:"publishedAt" db:"published_at"`
+}
+
+// Fulfilled is the payload of the order.fulfilled events.
+// EventID lets consumers drop the events they already received.
+type Fulfilled struct {
+	EventID string `json:"eventId"`
+	Order   Order  `json:"order"`
+	Items   []Item `json:"items"`
+}
+
+// Summary models the amounts to be charged by checking out, as priced
+// by the checkout itself. Discounts and Credit are summed over the
+// whole quantity of the lines. Prices include taxes, which the payment
+// provider breaks down from the address of the customer without
+// changing the Total.
+type Summary struct {
+	Lines          []SummaryLine `json:"lines"`
+	Subtotal       money.Amount  `json:"subtotal"`
+	SaleDiscount   money.Amount  `json:"saleDiscount"`
+	BundleDiscount money.Amount  `json:"bundleDiscount"`
+	Coupon         string        `json:"coupon"`
+	CouponDiscount money.Amount  `json:"couponDiscount"`
+	Credit         money.Amount  `json:"credit"`
+	TaxInclusive   bool          `json:"taxInclusive"`
+	Total          money.Amount  `json:"total"`
+}
+
+// SummaryLine models a course of a Summary, with the discounts taken
+// off each of its units. Amount is what is charged for all of them.
+type SummaryLine struct {
+	CourseID       string       `json:"courseId"`
+	BundleID       *string      `json:"bundleId"`
+	Name           string       `json:"name"`
+	Price          money.Amount `json:"price"`
+	Quantity       int          `json:"quantity"`
+	SaleDiscount   money.Amount `json:"saleDiscount"`
+	BundleDiscount money.Amount `json:"bundleDiscount"`
+	Coupon         string       `json:"coupon"`
+	CouponDiscount money.Amount `json:"couponDiscount"`
+	Credit         money.Amount `json:"credit"`
+	Amount         money.Amount `json:"amount"`
+}
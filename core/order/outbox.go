@@ -0,0 +1,168 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Consumer is notified of the orders fulfilled, e.g. to email the
+// buyer or to feed analytics. Events are delivered at least once:
+// consumers must drop the ones already received, by EventID.
+type Consumer interface {
+	OrderFulfilled(ctx context.Context, f Fulfilled) error
+}
+
+// Consumers notifies all of its consumers.
+type Consumers []Consumer
+
+// OrderFulfilled implements the Consumer interface. All the consumers
+// are notified even when some of them fail.
+func (cs Consumers) OrderFulfilled(ctx context.Context, f Fulfilled) error {
+	var errs []error
+	for _, c := range cs {
+		if err := c.OrderFulfilled(ctx, f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeFulfilled writes to the outbox the event notifying that the
+// order has been fulfilled. It must be called within the transaction
+// fulfilling the order, once its items are final.
+func writeFulfilled(ctx context.Context, tx sqlx.ExtContext, ord Order, now time.Time) error {
+	items, err := FetchItems(ctx, tx, ord.ID)
+	if err != nil {
+		return fmt.Errorf("fetching items: %w", err)
+	}
+
+	f := Fulfilled{
+		EventID: validate.GenerateID(),
+		Order:   ord,
+		Items:   items,
+	}
+
+	payload, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("encoding event of order[%s]: %w", ord.ID, err)
+	}
+
+	e := Event{
+		ID:        f.EventID,
+		Topic:     TopicFulfilled,
+		Payload:   string(payload),
+		CreatedAt: now,
+	}
+
+	return CreateEvent(ctx, tx, e)
+}
+
+// Relay publishes the events of the outbox to the consumers.
+// Events are retried every interval until all the consumers receive
+// them, at most Batch at a time.
+type Relay struct {
+	DB        *sqlx.DB
+	Consumers Consumers
+	Log       logrus.FieldLogger
+	Batch     int
+}
+
+// Run publishes the pending events every interval.
+// It blocks until the passed context is canceled.
+func (rl *Relay) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := rl.relay(ctx); err != nil {
+				rl.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// relay publishes the pending events, recording the failures on the
+// events themselves so that they don't hold back the others.
+func (rl *Relay) relay(ctx context.Context) error {
+	es, err := FetchUnpublished(ctx, rl.DB, rl.Batch)
+	if err != nil {
+		return fmt.Errorf("fetching unpublished events: %w", err)
+	}
+
+	for _, e := range es {
+		e.Attempts++
+		e.LastError = ""
+
+		if perr := rl.publish(ctx, e); perr != nil {
+			rl.Log.WithField("message", fmt.Errorf("publishing event[%s]: %w", e.ID, perr)).Error("ERROR")
+			e.LastError = perr.Error()
+		} else {
+			now := time.Now().UTC()
+			e.PublishedAt = &now
+		}
+
+		if err := UpdateEvent(ctx, rl.DB, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publish notifies the consumers of the passed event.
+func (rl *Relay) publish(ctx context.Context, e Event) error {
+	switch e.Topic {
+	case TopicFulfilled:
+		var f Fulfilled
+		if err := json.Unmarshal([]byte(e.Payload), &f); err != nil {
+			return fmt.Errorf("decoding payload: %w", err)
+		}
+		return rl.Consumers.OrderFulfilled(ctx, f)
+	default:
+		return fmt.Errorf("unknown topic %s", e.Topic)
+	}
+}
+
+// Webhook is a Consumer posting the events to an HTTP endpoint, e.g.
+// the collector of an analytics service. The id of the event is sent
+// as the Idempotency-Key header.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook returns a consumer posting the events to url.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// OrderFulfilled implements the Consumer interface.
+func (wh *Webhook) OrderFulfilled(ctx context.Context, f Fulfilled) error {
+	body := struct {
+		Topic string    `json:"topic"`
+		Data  Fulfilled `json:"data"`
+	}{
+		Topic: TopicFulfilled,
+		Data:  f,
+	}
+
+	header := http.Header{}
+	header.Set("Idempotency-Key", f.EventID)
+
+	return postJSON(ctx, wh.client, wh.url, header, body)
+}
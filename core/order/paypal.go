@@ -0,0 +1,180 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/plutov/paypal/v4"
+)
+
+// Paypal accepts payments through paypal orders.
+// Orders are captured by the client once the user approves them.
+type Paypal struct {
+	client *paypal.Client
+}
+
+// NewPaypal returns the paypal provider.
+func NewPaypal(client *paypal.Client) *Paypal {
+	return &Paypal{client: client}
+}
+
+// Name implements the PaymentProvider interface.
+func (p *Paypal) Name() string {
+	return ProviderPaypal
+}
+
+// CreateCheckout creates a paypal order, which is sent to the client.
+func (p *Paypal) CreateCheckout(ctx context.Context, lines []line, tot money.Amount) (Checkout, error) {
+	items := make([]paypal.Item, 0, len(lines))
+	for _, l := range lines {
+		amount := l.amount()
+		items = append(items, paypal.Item{
+			Quantity:    strconv.Itoa(l.quantity),
+			Name:        l.course.Name,
+			Description: l.course.Description,
+
+			UnitAmount: &paypal.Money{
+				Currency: amount.Currency,
+				Value:    amount.Decimal(),
+			},
+		})
+	}
+
+	units := []paypal.PurchaseUnitRequest{{
+		Items: items,
+
+		Amount: &paypal.PurchaseUnitAmount{
+			Currency: tot.Currency,
+			Value:    tot.Decimal(),
+
+			Breakdown: &paypal.PurchaseUnitAmountBreakdown{ItemTotal: &paypal.Money{
+				Currency: tot.Currency,
+				Value:    tot.Decimal(),
+			}},
+		},
+	}}
+
+	// TODO: Extract these params from the configuration.
+	app := &paypal.ApplicationContext{
+		// ReturnURL: "/success.html",
+		// CancelURL: "/canceled.html",
+	}
+
+	ord, err := p.client.CreateOrder(ctx, "CAPTURE", units, nil, app)
+	if err != nil {
+		return Checkout{}, fmt.Errorf("creating paypal order: %w", err)
+	}
+
+	return Checkout{ID: ord.ID, Resp: ord}, nil
+}
+
+// Capture captures the paypal order, transferring the money of the
+// user to our paypal account.
+func (p *Paypal) Capture(ctx context.Context, providerID string) (Payment, error) {
+	resp, err := p.client.CaptureOrder(ctx, providerID, paypal.CaptureOrderRequest{})
+	if err != nil {
+		return Payment{}, fmt.Errorf("capturing paypal order[%s]: %w", providerID, err)
+	}
+
+	if resp.Status != "COMPLETED" {
+		return Payment{}, fmt.Errorf("captured order[%s] with status[%s] different from 'COMPLETED'", providerID, resp.Status)
+	}
+
+	return Payment{ProviderID: providerID, PaymentID: captureID(resp), Status: Success}, nil
+}
+
+// Refund refunds a paypal capture.
+func (p *Paypal) Refund(ctx context.Context, paymentID string) error {
+	if _, err := p.client.RefundCapture(ctx, paymentID, paypal.RefundCaptureRequest{}); err != nil {
+		return fmt.Errorf("refunding paypal capture[%s]: %w", paymentID, err)
+	}
+
+	return nil
+}
+
+// RefundPart refunds part of a paypal capture.
+func (p *Paypal) RefundPart(ctx context.Context, paymentID string, amount money.Amount) error {
+	req := paypal.RefundCaptureRequest{
+		Amount: &paypal.Money{Currency: amount.Currency, Value: amount.Decimal()},
+	}
+
+	if _, err := p.client.RefundCapture(ctx, paymentID, req); err != nil {
+		return fmt.Errorf("refunding %s of paypal capture[%s]: %w", amount, paymentID, err)
+	}
+
+	return nil
+}
+
+// Taxes returns no taxes: prices are charged as they are on paypal.
+func (p *Paypal) Taxes(ctx context.Context, providerID string) ([]Tax, error) {
+	return nil, nil
+}
+
+// Cancel does nothing: paypal orders can't be voided until approved,
+// and those never approved expire by themselves.
+func (p *Paypal) Cancel(ctx context.Context, providerID string) error {
+	return nil
+}
+
+// VerifyWebhook is not supported: paypal orders are captured by the
+// client, so there is no need of webhooks.
+func (p *Paypal) VerifyWebhook(r *http.Request) (Payment, error) {
+	return Payment{}, errors.New("paypal webhooks are not supported")
+}
+
+// Payments returns the paypal payments completed since the passed time.
+// Captures are listed by the reporting API, which doesn't report the
+// order they captured: payments are bound to the orders by the capture.
+func (p *Paypal) Payments(ctx context.Context, since time.Time) ([]Payment, error) {
+	// The largest page allowed by paypal.
+	size := 500
+	req := &paypal.TransactionSearchRequest{
+		StartDate: since,
+		EndDate:   time.Now().UTC(),
+		PageSize:  &size,
+	}
+
+	var pays []Payment
+	for page := 1; ; page++ {
+		req.Page = &page
+
+		resp, err := p.client.ListTransactions(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("listing paypal transactions: %w", err)
+		}
+
+		for _, t := range resp.TransactionDetails {
+			info := t.TransactionInfo
+
+			// T00 codes are payments received, S stands for success.
+			if !strings.HasPrefix(info.TransactionEventCode, "T00") || info.TransactionStatus != "S" {
+				continue
+			}
+			pays = append(pays, Payment{PaymentID: info.TransactionID, Status: Success})
+		}
+
+		if page >= resp.TotalPages {
+			return pays, nil
+		}
+	}
+}
+
+// captureID returns the id of the first capture of a paypal order.
+// Such id is needed to refund the capture later on.
+func captureID(resp *paypal.CaptureOrderResponse) string {
+	for _, pu := range resp.PurchaseUnits {
+		if pu.Payments == nil {
+			continue
+		}
+		for _, c := range pu.Payments.Captures {
+			return c.ID
+		}
+	}
+	return ""
+}
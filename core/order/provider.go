@@ -0,0 +1,112 @@
+package order
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/plutov/paypal/v4"
+	stripecl "github.com/stripe/stripe-go/v74/client"
+)
+
+// errEventIgnored is returned by providers when a webhook event is
+// authentic but doesn't concern the lifecycle of an order.
+var errEventIgnored = errors.New("event ignored")
+
+// Checkout is a purchase started with a payment provider.
+type Checkout struct {
+	// ID identifies the purchase on the provider, orders are bound to it.
+	ID string
+
+	// Resp is sent to the client to complete the payment.
+	Resp any
+}
+
+// Payment is the outcome of a purchase, as reported by its provider.
+// Status is Success when the purchase has been payed, Pending while
+// waiting for the user, Expired or Failed otherwise.
+// Event is the type of the webhook event notifying the payment, if any.
+type Payment struct {
+	ProviderID string
+	PaymentID  string
+	Status     Status
+	Event      string
+}
+
+// PaymentProvider is implemented by the services accepting payments.
+type PaymentProvider interface {
+	// Name returns the name of the provider, e.g. ProviderStripe.
+	Name() string
+
+	// CreateCheckout starts the purchase of the passed lines,
+	// whose sum is tot.
+	CreateCheckout(ctx context.Context, lines []line, tot money.Amount) (Checkout, error)
+
+	// Capture completes the purchase bound to providerID, once the
+	// user has approved it.
+	Capture(ctx context.Context, providerID string) (Payment, error)
+
+	// Refund gives back the whole amount of a payment.
+	Refund(ctx context.Context, paymentID string) error
+
+	// RefundPart gives back part of a payment, e.g. what was charged
+	// for one of the items of an order.
+	RefundPart(ctx context.Context, paymentID string, amount money.Amount) error
+
+	// Taxes returns the taxes applied to the items of the purchase
+	// bound to providerID, once payed. Providers which don't calculate
+	// taxes return none.
+	Taxes(ctx context.Context, providerID string) ([]Tax, error)
+
+	// Cancel closes the purchase bound to providerID, so that it can't
+	// be payed anymore. It fails if the purchase has been payed.
+	Cancel(ctx context.Context, providerID string) error
+
+	// VerifyWebhook authenticates a webhook call of the provider and
+	// returns the payment it notifies. It returns errEventIgnored for
+	// events which don't concern orders, together with a payment
+	// reporting just the type of the event.
+	VerifyWebhook(r *http.Request) (Payment, error)
+}
+
+// hmacSigned reports whether sig is the hex encoded HMAC-SHA256 of the
+// payload with the passed secret, as webhooks are signed by razorpay
+// and coinbase.
+func hmacSigned(payload []byte, sig string, secret string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	exp := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(exp), []byte(sig))
+}
+
+// Providers maps the payment providers by name.
+type Providers map[string]PaymentProvider
+
+// NewProviders returns all the supported providers.
+func NewProviders(pp *paypal.Client, strp *stripecl.API, strpCfg config.Stripe, rzpCfg config.Razorpay, cbCfg config.Coinbase, mlCfg config.Mollie) Providers {
+	return Providers{
+		ProviderPaypal:       NewPaypal(pp),
+		ProviderStripe:       NewStripe(strp, strpCfg),
+		ProviderStripeIntent: NewStripeIntent(strp, strpCfg),
+		ProviderCredit:       NewCredit(),
+		ProviderFree:         NewFree(),
+		ProviderRazorpay:     NewRazorpay(rzpCfg),
+		ProviderCoinbase:     NewCoinbase(cbCfg),
+		ProviderMollie:       NewMollie(mlCfg),
+	}
+}
+
+// Get returns the provider with the passed name.
+func (ps Providers) Get(name string) (PaymentProvider, error) {
+	p, ok := ps[name]
+	if !ok {
+		return nil, fmt.Errorf("provider %s is not supported", name)
+	}
+	return p, nil
+}
@@ -0,0 +1,239 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+// Razorpay accepts payments through razorpay orders, which the client
+// pays with razorpay checkout. Orders are completed by razorpay webhooks,
+// or captured by the client once payed.
+type Razorpay struct {
+	client *http.Client
+	cfg    config.Razorpay
+}
+
+// NewRazorpay returns the razorpay provider.
+func NewRazorpay(cfg config.Razorpay) *Razorpay {
+	return &Razorpay{client: &http.Client{Timeout: 30 * time.Second}, cfg: cfg}
+}
+
+// razorpayCheckout is sent to the client to open razorpay checkout.
+type razorpayCheckout struct {
+	Key      string `json:"key"`
+	OrderID  string `json:"orderId"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// razorpayPayment is a payment attempt of a razorpay order.
+type razorpayPayment struct {
+	ID      string `json:"id"`
+	OrderID string `json:"order_id"`
+	Status  string `json:"status"`
+}
+
+// Name implements the PaymentProvider interface.
+func (rp *Razorpay) Name() string {
+	return ProviderRazorpay
+}
+
+// CreateCheckout creates a razorpay order of the total amount.
+// Razorpay orders have no items, so the courses are listed in the notes.
+func (rp *Razorpay) CreateCheckout(ctx context.Context, lines []line, tot money.Amount) (Checkout, error) {
+	names := make([]string, 0, len(lines))
+	for _, l := range lines {
+		names = append(names, l.course.Name)
+	}
+
+	in := map[string]any{
+		"amount":   tot.Units,
+		"currency": tot.Currency,
+		"notes":    map[string]string{"courses": truncate(strings.Join(names, ", "), 256)},
+	}
+
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := rp.do(ctx, http.MethodPost, "/v1/orders", in, &out); err != nil {
+		return Checkout{}, fmt.Errorf("creating razorpay order: %w", err)
+	}
+
+	resp := razorpayCheckout{
+		Key:      rp.cfg.KeyID,
+		OrderID:  out.ID,
+		Amount:   tot.Units,
+		Currency: tot.Currency,
+	}
+
+	return Checkout{ID: out.ID, Resp: resp}, nil
+}
+
+// Capture checks whether the razorpay order has been payed. Payments
+// are captured by razorpay as soon as they are authorized.
+func (rp *Razorpay) Capture(ctx context.Context, providerID string) (Payment, error) {
+	pays, err := rp.payments(ctx, providerID)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	for _, p := range pays {
+		if p.Status == "captured" {
+			return Payment{ProviderID: providerID, PaymentID: p.ID, Status: Success}, nil
+		}
+	}
+
+	return Payment{ProviderID: providerID, Status: Pending}, nil
+}
+
+// Refund refunds the whole amount of a razorpay payment.
+func (rp *Razorpay) Refund(ctx context.Context, paymentID string) error {
+	if err := rp.do(ctx, http.MethodPost, "/v1/payments/"+paymentID+"/refund", map[string]any{}, nil); err != nil {
+		return fmt.Errorf("refunding razorpay payment[%s]: %w", paymentID, err)
+	}
+
+	return nil
+}
+
+// RefundPart refunds part of a razorpay payment.
+func (rp *Razorpay) RefundPart(ctx context.Context, paymentID string, amount money.Amount) error {
+	in := map[string]any{"amount": amount.Units}
+	if err := rp.do(ctx, http.MethodPost, "/v1/payments/"+paymentID+"/refund", in, nil); err != nil {
+		return fmt.Errorf("refunding %s of razorpay payment[%s]: %w", amount, paymentID, err)
+	}
+
+	return nil
+}
+
+// Taxes returns no taxes: prices are charged as they are on razorpay.
+func (rp *Razorpay) Taxes(ctx context.Context, providerID string) ([]Tax, error) {
+	return nil, nil
+}
+
+// Cancel only makes sure the razorpay order has not been payed:
+// razorpay orders can't be canceled, the unpaid ones expire by
+// themselves.
+func (rp *Razorpay) Cancel(ctx context.Context, providerID string) error {
+	pay, err := rp.Capture(ctx, providerID)
+	if err != nil {
+		return err
+	}
+
+	if pay.Status == Success {
+		return fmt.Errorf("razorpay order[%s] has been payed by payment[%s]", providerID, pay.PaymentID)
+	}
+
+	return nil
+}
+
+// VerifyWebhook checks the signature of a razorpay event and returns
+// the payment of the order it regards.
+// Failed payments are ignored, since the user can try again to pay the
+// same order: unpaid orders are expired by the Expirer.
+func (rp *Razorpay) VerifyWebhook(r *http.Request) (Payment, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Payment{}, fmt.Errorf("cannot read the request body: %w", err)
+	}
+
+	sig := r.Header.Get("X-Razorpay-Signature")
+	if sig == "" {
+		return Payment{}, errors.New("received razorpay event is not signed")
+	}
+
+	if !hmacSigned(b, sig, rp.cfg.WebhookSecret) {
+		return Payment{}, errors.New("razorpay event signature mismatch")
+	}
+
+	var evt struct {
+		Event   string `json:"event"`
+		Payload struct {
+			Payment struct {
+				Entity razorpayPayment `json:"entity"`
+			} `json:"payment"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(b, &evt); err != nil {
+		return Payment{}, fmt.Errorf("unable to decode razorpay event: %w", err)
+	}
+
+	if evt.Event != "order.paid" {
+		return Payment{Event: evt.Event}, errEventIgnored
+	}
+
+	pay := evt.Payload.Payment.Entity
+	return Payment{ProviderID: pay.OrderID, PaymentID: pay.ID, Status: Success, Event: evt.Event}, nil
+}
+
+// payments returns the payment attempts of a razorpay order.
+func (rp *Razorpay) payments(ctx context.Context, orderID string) ([]razorpayPayment, error) {
+	var out struct {
+		Items []razorpayPayment `json:"items"`
+	}
+	if err := rp.do(ctx, http.MethodGet, "/v1/orders/"+orderID+"/payments", nil, &out); err != nil {
+		return nil, fmt.Errorf("fetching payments of razorpay order[%s]: %w", orderID, err)
+	}
+
+	return out.Items, nil
+}
+
+// do calls the razorpay API, sending in and decoding the response in out,
+// when not nil.
+func (rp *Razorpay) do(ctx context.Context, method string, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rp.cfg.URL+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(rp.cfg.KeyID, rp.cfg.KeySecret)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := rp.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var e struct {
+			Error struct {
+				Code        string `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+			return fmt.Errorf("razorpay responded %s", resp.Status)
+		}
+		return fmt.Errorf("razorpay responded %s: %s: %s", resp.Status, e.Error.Code, e.Error.Description)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding razorpay response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/email"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jmoiron/sqlx"
+)
+
+// receiptConsumer names the ReceiptMailer among the consumers of the
+// outbox.
+const receiptConsumer = "receipt"
+
+// ReceiptMailer is a Consumer sending the buyers the receipt of their
+// fulfilled orders.
+type ReceiptMailer struct {
+	DB     *sqlx.DB
+	Mailer Mailer
+}
+
+// OrderFulfilled implements the Consumer interface.
+// Receipts are sent once, even when the event is delivered again.
+func (rm *ReceiptMailer) OrderFulfilled(ctx context.Context, f Fulfilled) error {
+	done, err := IsConsumed(ctx, rm.DB, f.EventID, receiptConsumer)
+	if err != nil {
+		return err
+	}
+	if done {
+		return nil
+	}
+
+	usr, err := user.Fetch(ctx, rm.DB, f.Order.UserID)
+	if err != nil {
+		return fmt.Errorf("fetching buyer[%s] of order[%s]: %w", f.Order.UserID, f.Order.ID, err)
+	}
+
+	r, err := receipt(f)
+	if err != nil {
+		return err
+	}
+
+	if err := rm.Mailer.SendReceipt(r, usr.Email); err != nil {
+		return fmt.Errorf("sending receipt of order[%s] to %s: %w", f.Order.ID, usr.Email, err)
+	}
+
+	return MarkConsumed(ctx, rm.DB, f.EventID, receiptConsumer, time.Now().UTC())
+}
+
+// receipt lists what has been payed for each item of the fulfilled
+// order. Buyers own the courses unless bought for an organization or
+// as a gift.
+func receipt(f Fulfilled) (email.Receipt, error) {
+	r := email.Receipt{
+		OrderID:   f.Order.ID,
+		Provider:  f.Order.Provider,
+		Reference: f.Order.PaymentID,
+	}
+	if r.Reference == "" {
+		r.Reference = f.Order.ProviderID
+	}
+
+	owned := f.Order.OrgID == nil && !f.Order.Gift
+
+	var tot money.Amount
+	for i, it := range f.Items {
+		paid, err := it.Price.Sub(it.Discount)
+		if err != nil {
+			return email.Receipt{}, fmt.Errorf("computing the price payed for item[%s] of order[%s]: %w", it.CourseID, it.OrderID, err)
+		}
+		paid = paid.Mul(int64(it.Quantity))
+
+		if i == 0 {
+			tot = money.Zero(paid.Currency)
+		}
+		if tot, err = tot.Add(paid); err != nil {
+			return email.Receipt{}, err
+		}
+
+		r.Items = append(r.Items, email.ReceiptItem{
+			CourseID: it.CourseID,
+			Course:   it.Name,
+			Quantity: it.Quantity,
+			Paid:     paid.String(),
+			Owned:    owned,
+		})
+	}
+	r.Total = tot.String()
+
+	return r, nil
+}
@@ -0,0 +1,169 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Reconcilable is implemented by the providers which can list the
+// payments they received, so that they can be compared with the orders.
+type Reconcilable interface {
+	// Payments returns the payments received since the passed time.
+	// ProviderID is empty when the provider doesn't know the purchase
+	// the payment completed.
+	Payments(ctx context.Context, since time.Time) ([]Payment, error)
+}
+
+// Reconciler compares the payments received by the providers with the
+// orders, recording the mismatches: payments whose order has not been
+// fulfilled and fulfilled orders which have not been payed.
+// Orders created in the last Window are compared, except the ones
+// created in the last Grace, whose payments may be still in flight.
+type Reconciler struct {
+	DB        *sqlx.DB
+	Providers Providers
+	Log       logrus.FieldLogger
+	Window    time.Duration
+	Grace     time.Duration
+}
+
+// Run reconciles the payments every interval.
+// It blocks until the passed context is canceled.
+func (rc *Reconciler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			rc.reconcile(ctx)
+		}
+	}
+}
+
+// reconcile compares the payments of every provider which can list them.
+func (rc *Reconciler) reconcile(ctx context.Context) {
+	now := time.Now().UTC()
+	for name, prov := range rc.Providers {
+		r, ok := prov.(Reconcilable)
+		if !ok {
+			continue
+		}
+
+		if err := rc.compare(ctx, name, r, now); err != nil {
+			rc.Log.WithField("message", err).Error("ERROR")
+		}
+	}
+}
+
+// compare records the mismatches between the payments received by the
+// provider and its orders.
+// Purchases start before their orders are created, so payments are
+// listed since a bit earlier than the orders.
+func (rc *Reconciler) compare(ctx context.Context, provider string, r Reconcilable, now time.Time) error {
+	from := now.Add(-rc.Window)
+
+	pays, err := r.Payments(ctx, from.Add(-rc.Grace))
+	if err != nil {
+		return fmt.Errorf("listing %s payments: %w", provider, err)
+	}
+
+	byProvider := make(map[string]bool, len(pays))
+	byPayment := make(map[string]bool, len(pays))
+	for _, pay := range pays {
+		if pay.ProviderID != "" {
+			byProvider[pay.ProviderID] = true
+		}
+		if pay.PaymentID != "" {
+			byPayment[pay.PaymentID] = true
+		}
+
+		ord, err := rc.order(ctx, pay)
+		if err != nil && !errors.Is(err, database.ErrDBNotFound) {
+			return err
+		}
+
+		if err == nil && (ord.Status == Success || ord.Status == Refunded) {
+			continue
+		}
+
+		m := Mismatch{Provider: provider, ProviderID: pay.ProviderID, PaymentID: pay.PaymentID, Kind: MismatchPaidNotFulfilled}
+		if err == nil {
+			m.OrderID = &ord.ID
+		}
+
+		if err := rc.record(ctx, m, now); err != nil {
+			return err
+		}
+	}
+
+	ords, err := FetchFulfilled(ctx, rc.DB, provider, from, now.Add(-rc.Grace))
+	if err != nil {
+		return err
+	}
+
+	for _, ord := range ords {
+		if byProvider[ord.ProviderID] || byPayment[ord.PaymentID] {
+			continue
+		}
+
+		m := Mismatch{Provider: provider, ProviderID: ord.ProviderID, PaymentID: ord.PaymentID, OrderID: &ord.ID, Kind: MismatchFulfilledNotPaid}
+		if err := rc.record(ctx, m, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// order returns the order completed by the payment.
+func (rc *Reconciler) order(ctx context.Context, pay Payment) (Order, error) {
+	if pay.ProviderID != "" {
+		return FetchByProviderID(ctx, rc.DB, pay.ProviderID)
+	}
+	return FetchByPaymentID(ctx, rc.DB, pay.PaymentID)
+}
+
+// record stores a mismatch and logs it, so that it gets noticed.
+func (rc *Reconciler) record(ctx context.Context, m Mismatch, now time.Time) error {
+	m.ID = validate.GenerateID()
+	m.DetectedAt = now
+
+	if err := CreateMismatch(ctx, rc.DB, m); err != nil {
+		return err
+	}
+
+	rc.Log.WithField("message", fmt.Sprintf("%s payment[%s] of purchase[%s]: %s", m.Provider, m.PaymentID, m.ProviderID, m.Kind)).Warn("MISMATCH")
+	return nil
+}
+
+// HandleListMismatches allows administrators to review the mismatches
+// between payments and orders found by the reconciliation, the latest
+// first. Passing since returns the ones detected from then on.
+func HandleListMismatches(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		since, err := parseTime(r.URL.Query().Get("since"))
+		if err != nil {
+			return weberr.BadRequest(fmt.Errorf("invalid since: %w", err))
+		}
+
+		ms, err := FetchMismatches(ctx, db, since)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, ms, http.StatusOK)
+	}
+}
@@ -0,0 +1,264 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/org"
+	"github.com/jatolentino/tutorialspoint/core/wallet"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errNotRefundable is returned when refunding an order which can't be refunded.
+var errNotRefundable = errors.New("order can't be refunded")
+
+// refundable checks that the order has been fulfilled and that its
+// payment is known.
+func refundable(ord Order) error {
+	if ord.Status != Success {
+		return fmt.Errorf("%w: order[%s] is %s", errNotRefundable, ord.ID, ord.Status)
+	}
+
+	if ord.PaymentID == "" {
+		return fmt.Errorf("%w: the payment of order[%s] is unknown", errNotRefundable, ord.ID)
+	}
+
+	return nil
+}
+
+// refund gives back the money of a fulfilled order and revokes what
+// it granted: the access of the user or the seats of the organization.
+// The store credit spent on the order goes back to the wallet of the
+// user, as does what the provider charged when toWallet is set.
+// Items refunded already are left out.
+// The order is locked while the provider refunds it, so it can't be
+// refunded twice.
+func refund(ctx context.Context, db *sqlx.DB, provs Providers, orderID string, toWallet bool) error {
+	return database.Transaction(db, func(tx sqlx.ExtContext) error {
+		ord, err := FetchForUpdate(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if err := refundable(ord); err != nil {
+			return err
+		}
+
+		// Access is granted by successful orders only, so changing the
+		// status revokes it.
+		up := StatusUp{
+			ID:        ord.ID,
+			Status:    Refunded,
+			UpdatedAt: time.Now().UTC(),
+		}
+
+		if err := UpdateStatus(ctx, tx, up); err != nil {
+			return err
+		}
+
+		all, err := FetchItems(ctx, tx, ord.ID)
+		if err != nil {
+			return err
+		}
+
+		items := make([]Item, 0, len(all))
+		for _, it := range all {
+			if it.RefundedAt == nil {
+				items = append(items, it)
+			}
+		}
+
+		return refundItems(ctx, tx, provs, ord, items, len(items) < len(all), toWallet, up.UpdatedAt)
+	})
+}
+
+// refundItem gives back what was charged for an item of a fulfilled
+// order and revokes what it granted, as refund does for whole orders.
+// Refunding the last item left refunds the order.
+func refundItem(ctx context.Context, db *sqlx.DB, provs Providers, orderID string, courseID string, toWallet bool) error {
+	return database.Transaction(db, func(tx sqlx.ExtContext) error {
+		ord, err := FetchForUpdate(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if err := refundable(ord); err != nil {
+			return err
+		}
+
+		items, err := FetchItems(ctx, tx, ord.ID)
+		if err != nil {
+			return err
+		}
+
+		var it *Item
+		left := 0
+		for i := range items {
+			switch {
+			case items[i].CourseID == courseID:
+				it = &items[i]
+			case items[i].RefundedAt == nil:
+				left++
+			}
+		}
+
+		if it == nil {
+			return fmt.Errorf("item[%s] of order[%s]: %w", courseID, ord.ID, database.ErrDBNotFound)
+		}
+
+		if it.RefundedAt != nil {
+			return fmt.Errorf("%w: item[%s] of order[%s] is refunded already", errNotRefundable, courseID, ord.ID)
+		}
+
+		now := time.Now().UTC()
+		if left == 0 {
+			up := StatusUp{
+				ID:        ord.ID,
+				Status:    Refunded,
+				UpdatedAt: now,
+			}
+
+			if err := UpdateStatus(ctx, tx, up); err != nil {
+				return err
+			}
+		}
+
+		return refundItems(ctx, tx, provs, ord, []Item{*it}, true, toWallet, now)
+	})
+}
+
+// refundItems marks the items as refunded, revokes the seats they granted
+// and gives back the money spent on them. Gifts of refunded items can't
+// be redeemed, and no longer grant access, once their item is marked.
+// Part of the payment is refunded when partial is set, the whole payment
+// otherwise.
+func refundItems(ctx context.Context, tx sqlx.ExtContext, provs Providers, ord Order, items []Item, partial bool, toWallet bool, now time.Time) error {
+	for _, it := range items {
+		if err := SetItemRefunded(ctx, tx, ord.ID, it.CourseID, now); err != nil {
+			return err
+		}
+
+		if ord.OrgID != nil {
+			if err := org.RemoveSeats(ctx, tx, *ord.OrgID, it.CourseID, it.Quantity, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := returnCredit(ctx, tx, ord, items, wallet.ReasonRefund); err != nil {
+		return err
+	}
+
+	amount, err := chargedFor(items)
+	if err != nil {
+		return err
+	}
+
+	if toWallet {
+		if amount.IsZero() {
+			return nil
+		}
+		return wallet.Credit(ctx, tx, ord.UserID, amount, wallet.ReasonRefund, &ord.ID, "")
+	}
+
+	prov, err := provs.Get(ord.Provider)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errNotRefundable, err)
+	}
+
+	// Refund as last step, so any failure above rolls back everything.
+	if !partial {
+		return prov.Refund(ctx, ord.PaymentID)
+	}
+
+	if amount.IsZero() {
+		return nil
+	}
+	return prov.RefundPart(ctx, ord.PaymentID, amount)
+}
+
+// chargedFor returns what the provider charged for the passed items.
+func chargedFor(items []Item) (money.Amount, error) {
+	if len(items) == 0 {
+		return money.Amount{}, errors.New("no items charged")
+	}
+
+	var units int64
+	for _, it := range items {
+		c, err := charged(it)
+		if err != nil {
+			return money.Amount{}, err
+		}
+		units += c.Units * int64(it.Quantity)
+	}
+
+	return money.New(units, items[0].Price.Currency), nil
+}
+
+// HandleRefund allows administrators to refund the whole amount of
+// a fulfilled order, revoking the access to the courses bought.
+// Passing to=wallet refunds the user with store credit instead.
+func HandleRefund(db *sqlx.DB, provs Providers) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orderID := web.Param(r, "id")
+
+		if err := validate.CheckID(orderID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		toWallet := r.URL.Query().Get("to") == "wallet"
+
+		if err := refund(ctx, db, provs, orderID, toWallet); err != nil {
+			switch {
+			case errors.Is(err, database.ErrDBNotFound):
+				return weberr.NotFound(err)
+			case errors.Is(err, errNotRefundable):
+				return weberr.NewError(err, err.Error(), http.StatusConflict)
+			}
+			return fmt.Errorf("refunding order[%s]: %w", orderID, err)
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleRefundItem allows administrators to refund a single item of a
+// fulfilled order, revoking the access to its course only. The provider
+// refunds what was charged for the item.
+// Passing to=wallet refunds the user with store credit instead.
+func HandleRefundItem(db *sqlx.DB, provs Providers) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orderID := web.Param(r, "id")
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(orderID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		toWallet := r.URL.Query().Get("to") == "wallet"
+
+		if err := refundItem(ctx, db, provs, orderID, courseID, toWallet); err != nil {
+			switch {
+			case errors.Is(err, database.ErrDBNotFound):
+				return weberr.NotFound(err)
+			case errors.Is(err, errNotRefundable):
+				return weberr.NewError(err, err.Error(), http.StatusConflict)
+			}
+			return fmt.Errorf("refunding item[%s] of order[%s]: %w", courseID, orderID, err)
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
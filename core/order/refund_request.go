@@ -0,0 +1,207 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errRefundWindow is returned when asking for the refund of an order
+// fulfilled too long ago.
+var errRefundWindow = errors.New("the refund window has passed")
+
+// HandleRequestRefund allows buyers to ask for the refund of one of
+// their fulfilled orders, until window after its fulfillment.
+// Orders can have only one request waiting for a decision.
+func HandleRequestRefund(db *sqlx.DB, window time.Duration) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		orderID := web.Param(r, "id")
+
+		if err := validate.CheckID(orderID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var rn RefundRequestNew
+		if err := web.Decode(w, r, &rn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(rn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		ord, err := Fetch(ctx, db, orderID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if ord.UserID != clm.UserID {
+			return weberr.NotAuthorized(errors.New("user trying to refund the order of another user"))
+		}
+
+		if err := refundable(ord); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusConflict)
+		}
+
+		// Fulfilled orders are not updated anymore until refunded.
+		now := time.Now().UTC()
+		if now.Sub(ord.UpdatedAt) > window {
+			err := fmt.Errorf("%w: order[%s] was fulfilled on %s", errRefundWindow, ord.ID, ord.UpdatedAt.Format(time.DateOnly))
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		rr := RefundRequest{
+			ID:        validate.GenerateID(),
+			OrderID:   ord.ID,
+			UserID:    clm.UserID,
+			Reason:    rn.Reason,
+			Status:    RefundRequested,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		if err := CreateRefundRequest(ctx, db, rr); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "a refund has been requested already", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, rr, http.StatusCreated)
+	}
+}
+
+// HandleListRefundRequests allows administrators to review the refund
+// requests, the oldest first. Only the pending ones are returned,
+// unless another status is passed via the status query parameter.
+// Requests are paginated via the page and limit query parameters.
+func HandleListRefundRequests(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		page, err := web.ParsePage(r, defaultPageLimit, maxPageLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		status := RefundRequestStatus(r.URL.Query().Get("status"))
+		switch status {
+		case "":
+			status = RefundRequested
+		case RefundRequested, RefundApproved, RefundDenied:
+		default:
+			return weberr.BadRequest(fmt.Errorf("unknown status %q", status))
+		}
+
+		rrs, err := FetchRefundRequests(ctx, db, status, page.Limit, page.Offset())
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, rrs, http.StatusOK)
+	}
+}
+
+// pendingRequest returns the pending refund request passed in the path.
+func pendingRequest(ctx context.Context, db *sqlx.DB, r *http.Request) (RefundRequest, error) {
+	id := web.Param(r, "id")
+
+	if err := validate.CheckID(id); err != nil {
+		return RefundRequest{}, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	rr, err := FetchRefundRequest(ctx, db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return RefundRequest{}, weberr.NotFound(err)
+		}
+		return RefundRequest{}, err
+	}
+
+	if rr.Status != RefundRequested {
+		err := fmt.Errorf("refund request[%s] is %s already", rr.ID, rr.Status)
+		return RefundRequest{}, weberr.NewError(err, err.Error(), http.StatusConflict)
+	}
+
+	return rr, nil
+}
+
+// HandleApproveRefund allows administrators to approve a pending refund
+// request. The provider refunds the whole order, revoking the access to
+// the courses bought, and the request is marked as approved.
+func HandleApproveRefund(db *sqlx.DB, provs Providers) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		rr, err := pendingRequest(ctx, db, r)
+		if err != nil {
+			return err
+		}
+
+		if err := refund(ctx, db, provs, rr.OrderID, false); err != nil {
+			if errors.Is(err, errNotRefundable) {
+				return weberr.NewError(err, err.Error(), http.StatusConflict)
+			}
+			return fmt.Errorf("refunding order[%s]: %w", rr.OrderID, err)
+		}
+
+		rr.Status = RefundApproved
+		rr.UpdatedAt = time.Now().UTC()
+		if err := DecideRefundRequest(ctx, db, rr); err != nil {
+			return fmt.Errorf("order[%s] refunded but: %w", rr.OrderID, err)
+		}
+
+		return web.Respond(ctx, w, rr, http.StatusOK)
+	}
+}
+
+// HandleDenyRefund allows administrators to deny a pending refund
+// request, explaining why in the note.
+func HandleDenyRefund(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var d RefundDecision
+		if err := web.Decode(w, r, &d); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(d); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if d.Note == "" {
+			err := errors.New("a note is required to deny a refund")
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		rr, err := pendingRequest(ctx, db, r)
+		if err != nil {
+			return err
+		}
+
+		rr.Status = RefundDenied
+		rr.Note = d.Note
+		rr.UpdatedAt = time.Now().UTC()
+		if err := DecideRefundRequest(ctx, db, rr); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				err := fmt.Errorf("refund request[%s] has been decided already", rr.ID)
+				return weberr.NewError(err, err.Error(), http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, rr, http.StatusOK)
+	}
+}
@@ -0,0 +1,33 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jmoiron/sqlx"
+)
+
+// RegionHeader carries the country of the client, as resolved from
+// its IP address by the CDN in front of the API.
+const RegionHeader = "CF-IPCountry"
+
+// region returns the region whose prices the buyer is charged: the
+// country of the user, if set, or the one of the IP address of the
+// request otherwise. Guests are passed with no userID.
+func region(ctx context.Context, db sqlx.ExtContext, r *http.Request, userID string) (string, error) {
+	if userID != "" {
+		usr, err := user.Fetch(ctx, db, userID)
+		if err != nil {
+			return "", fmt.Errorf("fetching user[%s]: %w", userID, err)
+		}
+
+		if usr.Country != "" {
+			return usr.Country, nil
+		}
+	}
+
+	return strings.ToUpper(r.Header.Get(RegionHeader)), nil
+}
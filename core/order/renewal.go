@@ -0,0 +1,103 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// errNotRenewable is returned when renewing a course which can't be renewed.
+var errNotRenewable = errors.New("course can't be renewed")
+
+// renewal returns the line renewing the access of a user to a rented
+// course, priced for the passed region and discounted by the renewal
+// discount of the course.
+func renewal(ctx context.Context, db *sqlx.DB, userID string, courseID string, region string) (line, error) {
+	c, err := course.Fetch(ctx, db, courseID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return line{}, fmt.Errorf("%w: course[%s] not found", errNotRenewable, courseID)
+		}
+		return line{}, fmt.Errorf("fetching course[%s]: %w", courseID, err)
+	}
+
+	if c.AccessDays == 0 {
+		return line{}, fmt.Errorf("%w: course[%s] is not rented", errNotRenewable, courseID)
+	}
+
+	acc, err := course.FetchAccess(ctx, db, courseID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return line{}, fmt.Errorf("%w: course[%s] was never bought", errNotRenewable, courseID)
+		}
+		return line{}, fmt.Errorf("fetching access to course[%s]: %w", courseID, err)
+	}
+
+	if acc.ExpiresAt == nil {
+		return line{}, fmt.Errorf("%w: access to course[%s] never expires", errNotRenewable, courseID)
+	}
+
+	if c, err = course.Localize(ctx, db, c, region); err != nil {
+		return line{}, err
+	}
+
+	discount := money.New(c.Price.Units*int64(c.RenewalDiscount)/100, c.Price.Currency)
+	return line{course: c, discount: discount, renewal: true, quantity: 1}, nil
+}
+
+// ExpiryNotifier warns users whose access to rented courses is about
+// to expire, inviting them to renew it.
+type ExpiryNotifier struct {
+	DB       *sqlx.DB
+	Mailer   Mailer
+	Log      logrus.FieldLogger
+	Before   time.Duration
+	RenewURL string
+}
+
+// Run notifies the expiring accesses every interval.
+// It blocks until the passed context is canceled.
+func (n *ExpiryNotifier) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := n.notify(ctx); err != nil {
+				n.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// notify sends a notice for each access expiring soon.
+func (n *ExpiryNotifier) notify(ctx context.Context) error {
+	now := time.Now().UTC()
+	exps, err := FetchExpiring(ctx, n.DB, now, now.Add(n.Before))
+	if err != nil {
+		return fmt.Errorf("fetching expiring accesses: %w", err)
+	}
+
+	for _, e := range exps {
+		if err := n.Mailer.SendAccessExpiring(e.CourseName, n.RenewURL+e.CourseID, e.ExpiresAt, e.Email); err != nil {
+			n.Log.WithField("message", fmt.Errorf("notifying expiry of course[%s] to %s: %w", e.CourseID, e.Email, err)).Error("ERROR")
+			continue
+		}
+
+		if err := MarkExpiryNotified(ctx, n.DB, e.OrderID, e.CourseID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleRevenue allows administrators to report the gross, refunded and
+// net revenue of the fulfilled orders grouped by day, week, month,
+// course or provider, as passed via the by query parameter, e.g.
+// ?by=month&from=2023-01-01&to=2024-01-01.
+// Dates are either RFC 3339 timestamps or days. Revenue is reported for
+// each currency, amounts are never converted.
+func HandleRevenue(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		v := r.URL.Query()
+
+		f := RevenueFilter{
+			By: v.Get("by"),
+		}
+
+		switch f.By {
+		case "":
+			f.By = ByDay
+		case ByDay, ByWeek, ByMonth, ByCourse, ByProvider:
+		default:
+			return weberr.BadRequest(fmt.Errorf("unknown grouping %q", f.By))
+		}
+
+		var err error
+		if f.From, err = parseTime(v.Get("from")); err != nil {
+			return weberr.BadRequest(fmt.Errorf("invalid from: %w", err))
+		}
+		if f.To, err = parseTime(v.Get("to")); err != nil {
+			return weberr.BadRequest(fmt.Errorf("invalid to: %w", err))
+		}
+
+		rs, err := FetchRevenue(ctx, db, f)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, rs, http.StatusOK)
+	}
+}
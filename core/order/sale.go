@@ -0,0 +1,57 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jmoiron/sqlx"
+)
+
+// errNotForSale is returned when buying a course which is not sold.
+var errNotForSale = errors.New("course not for sale")
+
+// forSale verifies that the course can be bought, on every purchase
+// path: drafts are not sold until published, and archived courses are
+// no longer sold.
+func forSale(c course.Course) error {
+	switch c.Status {
+	case course.StatusDraft:
+		return fmt.Errorf("%w: course[%s] is a draft", errNotForSale, c.ID)
+	case course.StatusArchived:
+		return fmt.Errorf("%w: course[%s] is archived", errNotForSale, c.ID)
+	}
+	return nil
+}
+
+// priced returns the line buying a unit of the passed course, priced
+// for the passed region and discounted by the best sale running on it.
+// Orders keep the original price, the sale is recorded as a discount.
+func priced(ctx context.Context, db *sqlx.DB, c course.Course, region string) (line, error) {
+	if err := forSale(c); err != nil {
+		return line{}, err
+	}
+
+	c, err := course.Localize(ctx, db, c, region)
+	if err != nil {
+		return line{}, err
+	}
+
+	cs := []course.Course{c}
+	if err := course.WithSales(ctx, db, cs, time.Now().UTC()); err != nil {
+		return line{}, err
+	}
+	c = cs[0]
+
+	l := line{course: c, discount: money.Zero(c.Price.Currency), quantity: 1}
+	if c.Sale != nil {
+		if l.discount, err = c.Price.Sub(c.Sale.Price); err != nil {
+			return line{}, err
+		}
+	}
+
+	return l, nil
+}
@@ -0,0 +1,62 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/jatolentino/tutorialspoint/core/org"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxSeats is the maximum number of seats of a course bought at once.
+const maxSeats = 1000
+
+var (
+	// errNotSeatable is returned when the seats being bought are not valid.
+	errNotSeatable = errors.New("seats can't be bought")
+
+	// errNotOrgAdmin is returned when buying seats for an organization
+	// the user doesn't administer.
+	errNotOrgAdmin = errors.New("only organization admins can buy seats")
+)
+
+// seats turns the passed lines into seats bought on behalf of the
+// organization passed via the org query parameter, as many as the
+// seats query parameter. It returns nil if no organization is passed.
+func seats(ctx context.Context, db *sqlx.DB, r *http.Request, userID string, lines []line) (*string, error) {
+	orgID := r.URL.Query().Get("org")
+	if orgID == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(r.URL.Query().Get("seats"))
+	if err != nil || n < 1 || n > maxSeats {
+		return nil, fmt.Errorf("%w: seats must be between 1 and %d", errNotSeatable, maxSeats)
+	}
+
+	m, err := org.FetchMember(ctx, db, orgID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil, fmt.Errorf("%w: organization[%s]", errNotOrgAdmin, orgID)
+		}
+		return nil, fmt.Errorf("fetching membership of organization[%s]: %w", orgID, err)
+	}
+
+	if m.Role != org.RoleAdmin {
+		return nil, fmt.Errorf("%w: organization[%s]", errNotOrgAdmin, orgID)
+	}
+
+	for i := range lines {
+		// Seats are assigned for good, so they can't expire.
+		if lines[i].course.AccessDays > 0 {
+			return nil, fmt.Errorf("%w: course[%s] is rented", errNotSeatable, lines[i].course.ID)
+		}
+		lines[i].quantity = n
+	}
+
+	return &orgID, nil
+}
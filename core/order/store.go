@@ -3,18 +3,34 @@ package order
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/token"
+	"github.com/jatolentino/tutorialspoint/core/user"
 	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/lib/pq"
 )
 
-// Create inserts a new order with the passed information.
+// Create inserts a new order with the passed information, starting the
+// history of its status.
 func Create(ctx context.Context, db sqlx.ExtContext, order Order) error {
 	const q = `
-	INSERT INTO orders
-		(order_id, user_id, provider_id, status, created_at, updated_at)
-	VALUES
-		(:order_id, :user_id, :provider_id, :status, :created_at, :updated_at)`
+	WITH o AS (
+		INSERT INTO orders
+			(order_id, user_id, org_id, gift, provider, provider_id, status, affiliate_id, commission, created_at, updated_at)
+		VALUES
+			(:order_id, :user_id, :org_id, :gift, :provider, :provider_id, :status, :affiliate_id, :commission, :created_at, :updated_at)
+		RETURNING order_id, status, created_at
+	)
+	INSERT INTO order_statuses
+		(order_id, status, created_at)
+	SELECT
+		order_id, status, created_at
+	FROM
+		o`
 
 	if err := database.NamedExecContext(ctx, db, q, order); err != nil {
 		return fmt.Errorf("inserting order: %w", err)
@@ -23,23 +39,107 @@ func Create(ctx context.Context, db sqlx.ExtContext, order Order) error {
 	return nil
 }
 
-// UpdateStatus updates only the status and the date of an order.
+// UpdateStatus updates only the status and the date of an order,
+// recording the change in its history.
 func UpdateStatus(ctx context.Context, db sqlx.ExtContext, up StatusUp) error {
+	const q = `
+	WITH o AS (
+		UPDATE orders
+		SET
+			status = :status,
+			updated_at = :updated_at
+		WHERE
+			order_id = :order_id
+		RETURNING order_id
+	)
+	INSERT INTO order_statuses
+		(order_id, status, created_at)
+	SELECT
+		order_id, :status, :updated_at
+	FROM
+		o`
+
+	if err := database.NamedExecContext(ctx, db, q, up); err != nil {
+		return fmt.Errorf("updating state of order[%s]: %w", up.ID, err)
+	}
+
+	return nil
+}
+
+// SetPaymentID binds an order to the payment which payed it.
+func SetPaymentID(ctx context.Context, db sqlx.ExtContext, orderID string, paymentID string) error {
+	in := struct {
+		ID        string `db:"order_id"`
+		PaymentID string `db:"payment_id"`
+	}{
+		ID:        orderID,
+		PaymentID: paymentID,
+	}
+
 	const q = `
 	UPDATE orders
 	SET
-		status = :status,
-		updated_at = :updated_at
+		payment_id = :payment_id
 	WHERE
 		order_id = :order_id`
 
-	if err := database.NamedExecContext(ctx, db, q, up); err != nil {
-		return fmt.Errorf("updating state of order[%s]: %w", up.ID, err)
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("setting payment of order[%s]: %w", orderID, err)
 	}
 
 	return nil
 }
 
+// Fetch retrieves the order with the specified id.
+func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Order, error) {
+	in := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		order_id = :order_id`
+
+	var order Order
+	if err := database.NamedQueryStruct(ctx, db, q, in, &order); err != nil {
+		return Order{}, fmt.Errorf("selecting order[%s]: %w", id, err)
+	}
+
+	return order, nil
+}
+
+// FetchForUpdate retrieves the order with the specified id, locking it
+// until the end of the transaction.
+func FetchForUpdate(ctx context.Context, db sqlx.ExtContext, id string) (Order, error) {
+	in := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		order_id = :order_id
+	FOR UPDATE`
+
+	var order Order
+	if err := database.NamedQueryStruct(ctx, db, q, in, &order); err != nil {
+		return Order{}, fmt.Errorf("selecting order[%s]: %w", id, err)
+	}
+
+	return order, nil
+}
+
 // FetchByProviderID retrieves the order with the specified provider id, if any.
 func FetchByProviderID(ctx context.Context, db sqlx.ExtContext, provID string) (Order, error) {
 	in := struct {
@@ -64,13 +164,37 @@ func FetchByProviderID(ctx context.Context, db sqlx.ExtContext, provID string) (
 	return order, nil
 }
 
+// FetchByPaymentID returns the order payed by the passed payment.
+func FetchByPaymentID(ctx context.Context, db sqlx.ExtContext, paymentID string) (Order, error) {
+	in := struct {
+		PaymentID string `db:"payment_id"`
+	}{
+		PaymentID: paymentID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		payment_id = :payment_id`
+
+	var order Order
+	if err := database.NamedQueryStruct(ctx, db, q, in, &order); err != nil {
+		return Order{}, fmt.Errorf("selecting order by payment_id[%s]: %w", paymentID, err)
+	}
+
+	return order, nil
+}
+
 // CreateItem adds a new item in an order.
 func CreateItem(ctx context.Context, db sqlx.ExtContext, item Item) error {
 	const q = `
 	INSERT INTO order_items
-		(order_id, course_id, price, created_at)
+		(order_id, course_id, bundle_id, name, price, quantity, coupon, discount, credit, tax, renewal, created_at)
 	VALUES
-	(:order_id, :course_id, :price, :created_at)`
+	(:order_id, :course_id, :bundle_id, :name, :price, :quantity, :coupon, :discount, :credit, :tax, :renewal, :created_at)`
 
 	if err := database.NamedExecContext(ctx, db, q, item); err != nil {
 		return fmt.Errorf("inserting order item: %w", err)
@@ -78,3 +202,1518 @@ func CreateItem(ctx context.Context, db sqlx.ExtContext, item Item) error {
 
 	return nil
 }
+
+// CreateTax records a tax applied to an order item.
+func CreateTax(ctx context.Context, db sqlx.ExtContext, tax Tax) error {
+	const q = `
+	INSERT INTO order_item_taxes
+		(tax_id, order_id, course_id, name, jurisdiction, country, state, percentage, inclusive, amount, created_at)
+	VALUES
+		(:tax_id, :order_id, :course_id, :name, :jurisdiction, :country, :state, :percentage, :inclusive, :amount, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, tax); err != nil {
+		return fmt.Errorf("inserting tax of order[%s] item[%s]: %w", tax.OrderID, tax.CourseID, err)
+	}
+
+	return nil
+}
+
+// SetItemTax sets the total tax applied to an order item.
+func SetItemTax(ctx context.Context, db sqlx.ExtContext, orderID string, courseID string, tax money.Amount) error {
+	in := struct {
+		OrderID  string       `db:"order_id"`
+		CourseID string       `db:"course_id"`
+		Tax      money.Amount `db:"tax"`
+	}{
+		OrderID:  orderID,
+		CourseID: courseID,
+		Tax:      tax,
+	}
+
+	const q = `
+	UPDATE
+		order_items
+	SET
+		tax = :tax
+	WHERE
+		order_id = :order_id AND
+		course_id = :course_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("updating tax of order[%s] item[%s]: %w", orderID, courseID, err)
+	}
+
+	return nil
+}
+
+// FetchTaxes returns the breakdown of the taxes applied to the items
+// of an order.
+func FetchTaxes(ctx context.Context, db sqlx.ExtContext, orderID string) ([]Tax, error) {
+	in := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: orderID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		order_item_taxes
+	WHERE
+		order_id = :order_id
+	ORDER BY
+		course_id, created_at`
+
+	taxes := []Tax{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &taxes); err != nil {
+		return nil, fmt.Errorf("selecting taxes of order[%s]: %w", orderID, err)
+	}
+
+	return taxes, nil
+}
+
+// CreateGift adds a gift to an order.
+func CreateGift(ctx context.Context, db sqlx.ExtContext, g Gift) error {
+	const q = `
+	INSERT INTO gifts
+		(gift_id, order_id, course_id, email, code, created_at)
+	VALUES
+		(:gift_id, :order_id, :course_id, :email, :code, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, g); err != nil {
+		return fmt.Errorf("inserting gift of order[%s]: %w", g.OrderID, err)
+	}
+
+	return nil
+}
+
+// FetchGiftByCode returns the payed gift with the passed code, unless
+// its course has been refunded.
+func FetchGiftByCode(ctx context.Context, db sqlx.ExtContext, code string) (Gift, error) {
+	in := struct {
+		Code   string `db:"code"`
+		Status Status `db:"status"`
+	}{
+		Code:   code,
+		Status: Success,
+	}
+
+	const q = `
+	SELECT
+		g.*
+	FROM
+		gifts AS g
+	INNER JOIN
+		orders AS o ON o.order_id = g.order_id
+	INNER JOIN
+		order_items AS i ON i.order_id = g.order_id AND i.course_id = g.course_id
+	WHERE
+		g.code = :code AND
+		o.status = :status AND
+		i.refunded_at IS NULL`
+
+	var g Gift
+	if err := database.NamedQueryStruct(ctx, db, q, in, &g); err != nil {
+		return Gift{}, fmt.Errorf("selecting gift by code: %w", err)
+	}
+
+	return g, nil
+}
+
+// RedeemGift gives the gift to the passed user, unless already redeemed
+// or refunded.
+func RedeemGift(ctx context.Context, db sqlx.ExtContext, giftID string, userID string, now time.Time) (Gift, error) {
+	in := struct {
+		ID     string    `db:"gift_id"`
+		UserID string    `db:"user_id"`
+		Now    time.Time `db:"now"`
+	}{
+		ID:     giftID,
+		UserID: userID,
+		Now:    now,
+	}
+
+	const q = `
+	UPDATE
+		gifts
+	SET
+		redeemed_by = :user_id,
+		redeemed_at = :now
+	WHERE
+		gift_id = :gift_id AND
+		redeemed_by IS NULL AND
+		redeemed_at IS NULL AND
+		NOT EXISTS (
+			SELECT
+				1
+			FROM
+				order_items AS i
+			WHERE
+				i.order_id = gifts.order_id AND
+				i.course_id = gifts.course_id AND
+				i.refunded_at IS NOT NULL
+		)
+	RETURNING
+		*`
+
+	var g Gift
+	if err := database.NamedQueryStruct(ctx, db, q, in, &g); err != nil {
+		return Gift{}, fmt.Errorf("redeeming gift[%s]: %w", giftID, err)
+	}
+
+	return g, nil
+}
+
+// Announcement models a payed gift whose recipient has not been
+// notified yet.
+type Announcement struct {
+	GiftID     string `db:"gift_id"`
+	Code       string `db:"code"`
+	Email      string `db:"email"`
+	CourseName string `db:"course_name"`
+	From       string `db:"from_name"`
+}
+
+// FetchUnannounced returns the payed gifts, not refunded, whose
+// recipients have not been notified yet.
+func FetchUnannounced(ctx context.Context, db sqlx.ExtContext) ([]Announcement, error) {
+	in := struct {
+		Status Status `db:"status"`
+	}{
+		Status: Success,
+	}
+
+	const q = `
+	SELECT
+		g.gift_id,
+		g.code,
+		g.email,
+		c.name AS course_name,
+		u.name AS from_name
+	FROM
+		gifts AS g
+	INNER JOIN
+		orders AS o ON o.order_id = g.order_id
+	INNER JOIN
+		courses AS c ON c.course_id = g.course_id
+	INNER JOIN
+		users AS u ON u.user_id = o.user_id
+	INNER JOIN
+		order_items AS i ON i.order_id = g.order_id AND i.course_id = g.course_id
+	WHERE
+		o.status = :status AND
+		i.refunded_at IS NULL AND
+		g.notified = FALSE
+	ORDER BY
+		g.created_at`
+
+	as := []Announcement{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &as); err != nil {
+		return nil, fmt.Errorf("selecting unannounced gifts: %w", err)
+	}
+
+	return as, nil
+}
+
+// MarkGiftNotified records that the recipient of a gift has been notified.
+func MarkGiftNotified(ctx context.Context, db sqlx.ExtContext, giftID string) error {
+	in := struct {
+		ID string `db:"gift_id"`
+	}{
+		ID: giftID,
+	}
+
+	const q = `
+	UPDATE
+		gifts
+	SET
+		notified = TRUE
+	WHERE
+		gift_id = :gift_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("marking gift[%s] as notified: %w", giftID, err)
+	}
+
+	return nil
+}
+
+// NextInvoiceNumber reserves the number of a new invoice.
+func NextInvoiceNumber(ctx context.Context, db sqlx.ExtContext) (int64, error) {
+	const q = `
+	SELECT
+		nextval('invoice_numbers') AS number`
+
+	var out struct {
+		Number int64 `db:"number"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, struct{}{}, &out); err != nil {
+		return 0, fmt.Errorf("reserving invoice number: %w", err)
+	}
+
+	return out.Number, nil
+}
+
+// CreateInvoice stores the invoice of an order.
+func CreateInvoice(ctx context.Context, db sqlx.ExtContext, inv Invoice) error {
+	const q = `
+	INSERT INTO invoices
+		(order_id, number, pdf, created_at)
+	VALUES
+		(:order_id, :number, :pdf, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, inv); err != nil {
+		return fmt.Errorf("inserting invoice of order[%s]: %w", inv.OrderID, err)
+	}
+
+	return nil
+}
+
+// FetchInvoice returns the invoice of an order.
+func FetchInvoice(ctx context.Context, db sqlx.ExtContext, orderID string) (Invoice, error) {
+	in := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: orderID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		invoices
+	WHERE
+		order_id = :order_id`
+
+	var inv Invoice
+	if err := database.NamedQueryStruct(ctx, db, q, in, &inv); err != nil {
+		return Invoice{}, fmt.Errorf("selecting invoice of order[%s]: %w", orderID, err)
+	}
+
+	return inv, nil
+}
+
+// FetchByUser returns a page of the orders placed by a user,
+// the most recent first.
+func FetchByUser(ctx context.Context, db sqlx.ExtContext, userID string, limit int, offset int) ([]Order, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+		Limit  int    `db:"limit"`
+		Offset int    `db:"offset"`
+	}{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		created_at DESC, order_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	ords := []Order{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ords); err != nil {
+		return nil, fmt.Errorf("selecting orders of user[%s]: %w", userID, err)
+	}
+
+	return ords, nil
+}
+
+// Search returns a page of the orders matching the filter,
+// the most recent first.
+func Search(ctx context.Context, db sqlx.ExtContext, f Filter) ([]Order, error) {
+	const q = `
+	SELECT
+		o.*
+	FROM
+		orders AS o
+	INNER JOIN
+		users AS u ON u.user_id = o.user_id
+	LEFT JOIN (
+		SELECT
+			order_id,
+			SUM(((price).units - COALESCE((discount).units, 0)) * quantity) AS units,
+			MIN((price).currency) AS currency
+		FROM
+			order_items
+		GROUP BY
+			order_id
+	) AS t ON t.order_id = o.order_id
+	WHERE
+		(:status = '' OR o.status = :status) AND
+		(:email = '' OR LOWER(u.email) = LOWER(:email)) AND
+		(:provider = '' OR o.provider = :provider) AND
+		(CAST(:from AS TIMESTAMP) IS NULL OR o.created_at >= :from) AND
+		(CAST(:to AS TIMESTAMP) IS NULL OR o.created_at < :to) AND
+		(CAST(:min_amount AS BIGINT) IS NULL OR t.units >= :min_amount) AND
+		(CAST(:max_amount AS BIGINT) IS NULL OR t.units <= :max_amount) AND
+		(:currency = '' OR t.currency = :currency)
+	ORDER BY
+		o.created_at DESC, o.order_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	ords := []Order{}
+	if err := database.NamedQuerySlice(ctx, db, q, f, &ords); err != nil {
+		return nil, fmt.Errorf("searching orders: %w", err)
+	}
+
+	return ords, nil
+}
+
+// FetchRevenue aggregates what the fulfilled orders earned according
+// to the passed filter, in each currency. Periods are keyed by their
+// first day, months by year and month. Orders refunded before their
+// items tracked refunds are accounted as refunded when last updated.
+func FetchRevenue(ctx context.Context, db sqlx.ExtContext, f RevenueFilter) ([]Revenue, error) {
+	const q = `
+	WITH fulfilled AS (
+		SELECT
+			o.order_id,
+			o.provider,
+			o.status,
+			o.updated_at,
+			COALESCE((
+				SELECT
+					MIN(s.created_at)
+				FROM
+					order_statuses AS s
+				WHERE
+					s.order_id = o.order_id AND
+					s.status IN ('success', 'refunded')
+			), o.created_at) AS fulfilled_at
+		FROM
+			orders AS o
+		WHERE
+			o.status IN ('success', 'refunded')
+	),
+	entries AS (
+		SELECT
+			f.order_id,
+			f.provider,
+			i.course_id,
+			f.fulfilled_at AS booked_at,
+			TRUE AS payment,
+			(i.price).currency AS currency,
+			((i.price).units - COALESCE((i.discount).units, 0)) * i.quantity AS gross,
+			0 AS refunds
+		FROM
+			fulfilled AS f
+		INNER JOIN
+			order_items AS i ON i.order_id = f.order_id
+		UNION ALL
+		SELECT
+			f.order_id,
+			f.provider,
+			i.course_id,
+			COALESCE(i.refunded_at, f.updated_at) AS booked_at,
+			FALSE AS payment,
+			(i.price).currency AS currency,
+			0 AS gross,
+			((i.price).units - COALESCE((i.discount).units, 0)) * i.quantity AS refunds
+		FROM
+			fulfilled AS f
+		INNER JOIN
+			order_items AS i ON i.order_id = f.order_id
+		WHERE
+			i.refunded_at IS NOT NULL OR
+			f.status = 'refunded'
+	),
+	grouped AS (
+		SELECT
+			CASE :by
+				WHEN 'day' THEN TO_CHAR(DATE_TRUNC('day', booked_at), 'YYYY-MM-DD')
+				WHEN 'week' THEN TO_CHAR(DATE_TRUNC('week', booked_at), 'YYYY-MM-DD')
+				WHEN 'month' THEN TO_CHAR(DATE_TRUNC('month', booked_at), 'YYYY-MM')
+				WHEN 'course' THEN CAST(course_id AS TEXT)
+				ELSE provider
+			END AS key,
+			currency,
+			COUNT(DISTINCT order_id) FILTER (WHERE payment) AS orders,
+			CAST(SUM(gross) AS BIGINT) AS gross,
+			CAST(SUM(refunds) AS BIGINT) AS refunds
+		FROM
+			entries
+		WHERE
+			(CAST(:from AS TIMESTAMP) IS NULL OR booked_at >= :from) AND
+			(CAST(:to AS TIMESTAMP) IS NULL OR booked_at < :to)
+		GROUP BY
+			1, 2
+	)
+	SELECT
+		key,
+		currency,
+		orders,
+		CAST(ROW(gross, currency) AS amount) AS gross,
+		CAST(ROW(refunds, currency) AS amount) AS refunds,
+		CAST(ROW(gross - refunds, currency) AS amount) AS net
+	FROM
+		grouped
+	ORDER BY
+		key, currency`
+
+	rs := []Revenue{}
+	if err := database.NamedQuerySlice(ctx, db, q, f, &rs); err != nil {
+		return nil, fmt.Errorf("aggregating revenue by %s: %w", f.By, err)
+	}
+
+	return rs, nil
+}
+
+// FetchStale returns at most limit orders which are pending
+// since before the passed time, the oldest first.
+func FetchStale(ctx context.Context, db sqlx.ExtContext, before time.Time, limit int) ([]Order, error) {
+	in := struct {
+		Status Status    `db:"status"`
+		Before time.Time `db:"before"`
+		Limit  int       `db:"limit"`
+	}{
+		Status: Pending,
+		Before: before,
+		Limit:  limit,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		status = :status AND
+		created_at < :before
+	ORDER BY
+		created_at
+	LIMIT :limit`
+
+	ords := []Order{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ords); err != nil {
+		return nil, fmt.Errorf("selecting stale orders: %w", err)
+	}
+
+	return ords, nil
+}
+
+// FetchItemsByOrders returns all the items of the passed orders.
+func FetchItemsByOrders(ctx context.Context, db sqlx.ExtContext, orderIDs []string) ([]Item, error) {
+	in := struct {
+		IDs pq.StringArray `db:"order_ids"`
+	}{
+		IDs: orderIDs,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		order_items
+	WHERE
+		order_id = ANY(CAST(:order_ids AS UUID[]))
+	ORDER BY
+		order_id, course_id`
+
+	items := []Item{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &items); err != nil {
+		return nil, fmt.Errorf("selecting items of orders: %w", err)
+	}
+
+	return items, nil
+}
+
+// FetchItems returns all the items of an order.
+func FetchItems(ctx context.Context, db sqlx.ExtContext, orderID string) ([]Item, error) {
+	in := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: orderID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		order_items
+	WHERE
+		order_id = :order_id
+	ORDER BY
+		course_id`
+
+	items := []Item{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &items); err != nil {
+		return nil, fmt.Errorf("selecting items of order[%s]: %w", orderID, err)
+	}
+
+	return items, nil
+}
+
+// SetExpiry sets when the access granted by the items of rented courses
+// of an order expires. The access of renewed courses is extended from
+// the end of the previous one, if still running.
+func SetExpiry(ctx context.Context, db sqlx.ExtContext, orderID string, userID string, now time.Time) error {
+	in := struct {
+		OrderID string    `db:"order_id"`
+		UserID  string    `db:"user_id"`
+		Status  Status    `db:"status"`
+		Now     time.Time `db:"now"`
+	}{
+		OrderID: orderID,
+		UserID:  userID,
+		Status:  Success,
+		Now:     now,
+	}
+
+	const q = `
+	UPDATE order_items AS i
+	SET
+		expires_at = GREATEST(
+			CAST(:now AS TIMESTAMP),
+			COALESCE((
+				SELECT
+					MAX(pi.expires_at)
+				FROM
+					order_items AS pi
+				INNER JOIN
+					orders AS po ON po.order_id = pi.order_id
+				WHERE
+					po.user_id = :user_id AND
+					po.org_id IS NULL AND
+					po.status = :status AND
+					pi.refunded_at IS NULL AND
+					pi.course_id = i.course_id AND
+					pi.order_id <> i.order_id
+			), CAST(:now AS TIMESTAMP))
+		) + make_interval(days => c.access_days)
+	FROM
+		courses AS c
+	WHERE
+		i.order_id = :order_id AND
+		c.course_id = i.course_id AND
+		c.access_days > 0`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("setting expiry of items of order[%s]: %w", orderID, err)
+	}
+
+	return nil
+}
+
+// SetItemRefunded records that an item has been refunded.
+func SetItemRefunded(ctx context.Context, db sqlx.ExtContext, orderID string, courseID string, now time.Time) error {
+	in := struct {
+		OrderID  string    `db:"order_id"`
+		CourseID string    `db:"course_id"`
+		Now      time.Time `db:"now"`
+	}{
+		OrderID:  orderID,
+		CourseID: courseID,
+		Now:      now,
+	}
+
+	const q = `
+	UPDATE order_items
+	SET
+		refunded_at = :now
+	WHERE
+		order_id = :order_id AND
+		course_id = :course_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("marking item[%s] of order[%s] as refunded: %w", courseID, orderID, err)
+	}
+
+	return nil
+}
+
+// Expiring models an access to a rented course which is about to expire.
+type Expiring struct {
+	OrderID    string    `db:"order_id"`
+	CourseID   string    `db:"course_id"`
+	CourseName string    `db:"name"`
+	Email      string    `db:"email"`
+	ExpiresAt  time.Time `db:"expires_at"`
+}
+
+// FetchExpiring returns the accesses expiring before the passed time
+// whose owners have not been notified yet. Accesses already extended
+// by a renewal are left out.
+func FetchExpiring(ctx context.Context, db sqlx.ExtContext, now time.Time, before time.Time) ([]Expiring, error) {
+	in := struct {
+		Status Status    `db:"status"`
+		Now    time.Time `db:"now"`
+		Before time.Time `db:"before"`
+	}{
+		Status: Success,
+		Now:    now,
+		Before: before,
+	}
+
+	const q = `
+	SELECT
+		i.order_id,
+		i.course_id,
+		i.name,
+		u.email,
+		i.expires_at
+	FROM
+		order_items AS i
+	INNER JOIN
+		orders AS o ON o.order_id = i.order_id
+	INNER JOIN
+		users AS u ON u.user_id = o.user_id
+	WHERE
+		o.status = :status AND
+		o.org_id IS NULL AND
+		i.expiry_notified = FALSE AND
+		i.refunded_at IS NULL AND
+		i.expires_at > :now AND
+		i.expires_at <= :before AND
+		NOT EXISTS (
+			SELECT
+				1
+			FROM
+				order_items AS ri
+			INNER JOIN
+				orders AS ro ON ro.order_id = ri.order_id
+			WHERE
+				ro.user_id = o.user_id AND
+				ro.org_id IS NULL AND
+				ro.status = :status AND
+				ri.refunded_at IS NULL AND
+				ri.course_id = i.course_id AND
+				(ri.expires_at IS NULL OR ri.expires_at > i.expires_at)
+		)
+	ORDER BY
+		i.expires_at`
+
+	exps := []Expiring{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &exps); err != nil {
+		return nil, fmt.Errorf("selecting expiring items: %w", err)
+	}
+
+	return exps, nil
+}
+
+// MarkExpiryNotified records that the owner of the item has been
+// notified about the expiration of the access.
+func MarkExpiryNotified(ctx context.Context, db sqlx.ExtContext, orderID string, courseID string) error {
+	in := struct {
+		OrderID  string `db:"order_id"`
+		CourseID string `db:"course_id"`
+	}{
+		OrderID:  orderID,
+		CourseID: courseID,
+	}
+
+	const q = `
+	UPDATE order_items
+	SET
+		expiry_notified = TRUE
+	WHERE
+		order_id = :order_id AND
+		course_id = :course_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("marking item[%s] of order[%s] as notified: %w", courseID, orderID, err)
+	}
+
+	return nil
+}
+
+// ClaimIdempotencyKey reserves an idempotency key. Keys created before
+// expired can be reclaimed, as well as the ones still in progress created
+// before stale. It returns database.ErrDBNotFound if the key is already
+// taken.
+func ClaimIdempotencyKey(ctx context.Context, db sqlx.ExtContext, k IdempotencyKey, expired time.Time, stale time.Time) error {
+	in := struct {
+		IdempotencyKey
+		Expired time.Time `db:"expired"`
+		Stale   time.Time `db:"stale"`
+	}{
+		IdempotencyKey: k,
+		Expired:        expired,
+		Stale:          stale,
+	}
+
+	const q = `
+	INSERT INTO idempotency_keys
+		(user_id, idem_key, provider, request_hash, response, created_at)
+	VALUES
+		(:user_id, :idem_key, :provider, :request_hash, NULL, :created_at)
+	ON CONFLICT
+		(user_id, idem_key)
+	DO UPDATE SET
+		provider = EXCLUDED.provider,
+		request_hash = EXCLUDED.request_hash,
+		response = NULL,
+		created_at = EXCLUDED.created_at
+	WHERE
+		idempotency_keys.created_at < :expired OR
+		(idempotency_keys.response IS NULL AND idempotency_keys.created_at < :stale)
+	RETURNING user_id`
+
+	var out struct {
+		UserID string `db:"user_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("claiming idempotency key %q: %w", k.Key, err)
+	}
+
+	return nil
+}
+
+// FetchIdempotencyKey returns an idempotency key of a user.
+func FetchIdempotencyKey(ctx context.Context, db sqlx.ExtContext, userID string, key string) (IdempotencyKey, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+		Key    string `db:"idem_key"`
+	}{
+		UserID: userID,
+		Key:    key,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		idempotency_keys
+	WHERE
+		user_id = :user_id AND
+		idem_key = :idem_key`
+
+	var k IdempotencyKey
+	if err := database.NamedQueryStruct(ctx, db, q, in, &k); err != nil {
+		return IdempotencyKey{}, fmt.Errorf("selecting idempotency key %q: %w", key, err)
+	}
+
+	return k, nil
+}
+
+// SaveIdempotentResponse stores the response to replay for a key.
+func SaveIdempotentResponse(ctx context.Context, db sqlx.ExtContext, userID string, key string, resp []byte) error {
+	in := struct {
+		UserID   string `db:"user_id"`
+		Key      string `db:"idem_key"`
+		Response string `db:"response"`
+	}{
+		UserID:   userID,
+		Key:      key,
+		Response: string(resp),
+	}
+
+	const q = `
+	UPDATE idempotency_keys
+	SET
+		response = CAST(:response AS JSONB)
+	WHERE
+		user_id = :user_id AND
+		idem_key = :idem_key`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("saving response of idempotency key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// DeleteIdempotencyKey releases an idempotency key.
+func DeleteIdempotencyKey(ctx context.Context, db sqlx.ExtContext, userID string, key string) error {
+	in := struct {
+		UserID string `db:"user_id"`
+		Key    string `db:"idem_key"`
+	}{
+		UserID: userID,
+		Key:    key,
+	}
+
+	const q = `
+	DELETE FROM
+		idempotency_keys
+	WHERE
+		user_id = :user_id AND
+		idem_key = :idem_key`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("deleting idempotency key %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// CreateFailure records an incident of an order. The order is looked up
+// by the provider id of the failure.
+func CreateFailure(ctx context.Context, db sqlx.ExtContext, f Failure) error {
+	const q = `
+	INSERT INTO order_failures
+		(failure_id, provider_id, order_id, stage, error, created_at)
+	VALUES
+		(:failure_id, :provider_id, (SELECT order_id FROM orders WHERE provider_id = :provider_id), :stage, :error, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, f); err != nil {
+		return fmt.Errorf("inserting failure of payment[%s]: %w", f.ProviderID, err)
+	}
+
+	return nil
+}
+
+// FetchFailures returns the incidents of an order, the oldest first.
+func FetchFailures(ctx context.Context, db sqlx.ExtContext, orderID string) ([]Failure, error) {
+	in := struct {
+		OrderID string `db:"order_id"`
+	}{
+		OrderID: orderID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		order_failures
+	WHERE
+		order_id = :order_id
+	ORDER BY
+		created_at`
+
+	fs := []Failure{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &fs); err != nil {
+		return nil, fmt.Errorf("selecting failures of order[%s]: %w", orderID, err)
+	}
+
+	return fs, nil
+}
+
+// CreateCompensation schedules the compensation of a payed order
+// whose fulfillment failed. Payments already scheduled are left as they
+// are, since providers may deliver the same webhook more than once.
+func CreateCompensation(ctx context.Context, db sqlx.ExtContext, comp Compensation) error {
+	const q = `
+	INSERT INTO compensations
+		(provider_id, provider, payment_id, status, attempts, last_error, next_run_at, created_at, updated_at)
+	VALUES
+		(:provider_id, :provider, :payment_id, :status, :attempts, :last_error, :next_run_at, :created_at, :updated_at)
+	ON CONFLICT
+		(provider_id)
+	DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, db, q, comp); err != nil {
+		return fmt.Errorf("inserting compensation: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateCompensation updates the state of a compensation.
+func UpdateCompensation(ctx context.Context, db sqlx.ExtContext, comp Compensation) error {
+	const q = `
+	UPDATE compensations
+	SET
+		status = :status,
+		attempts = :attempts,
+		last_error = :last_error,
+		next_run_at = :next_run_at,
+		updated_at = :updated_at
+	WHERE
+		provider_id = :provider_id`
+
+	if err := database.NamedExecContext(ctx, db, q, comp); err != nil {
+		return fmt.Errorf("updating compensation[%s]: %w", comp.ProviderID, err)
+	}
+
+	return nil
+}
+
+// FetchDueCompensations returns the pending compensations which are
+// scheduled to run before the passed time.
+func FetchDueCompensations(ctx context.Context, db sqlx.ExtContext, now time.Time) ([]Compensation, error) {
+	in := struct {
+		Status CompensationStatus `db:"status"`
+		Now    time.Time          `db:"now"`
+	}{
+		Status: CompensationPending,
+		Now:    now,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		compensations
+	WHERE
+		status = :status AND
+		next_run_at <= :now
+	ORDER BY
+		next_run_at`
+
+	comps := []Compensation{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &comps); err != nil {
+		return nil, fmt.Errorf("selecting due compensations: %w", err)
+	}
+
+	return comps, nil
+}
+
+// FetchCompensations returns the compensations with the passed status,
+// the oldest first.
+func FetchCompensations(ctx context.Context, db sqlx.ExtContext, status CompensationStatus, limit int, offset int) ([]Compensation, error) {
+	in := struct {
+		Status CompensationStatus `db:"status"`
+		Limit  int                `db:"limit"`
+		Offset int                `db:"offset"`
+	}{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		compensations
+	WHERE
+		status = :status
+	ORDER BY
+		created_at,
+		provider_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	comps := []Compensation{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &comps); err != nil {
+		return nil, fmt.Errorf("selecting %s compensations: %w", status, err)
+	}
+
+	return comps, nil
+}
+
+// RequeueCompensation schedules again at the passed time the failed
+// compensation of the payment, resetting its attempts.
+// It returns database.ErrDBNotFound if the compensation is not failed.
+func RequeueCompensation(ctx context.Context, db sqlx.ExtContext, providerID string, now time.Time) (Compensation, error) {
+	in := struct {
+		ProviderID string             `db:"provider_id"`
+		Failed     CompensationStatus `db:"failed"`
+		Pending    CompensationStatus `db:"pending"`
+		Now        time.Time          `db:"now"`
+	}{
+		ProviderID: providerID,
+		Failed:     CompensationFailed,
+		Pending:    CompensationPending,
+		Now:        now,
+	}
+
+	const q = `
+	UPDATE compensations
+	SET
+		status = :pending,
+		attempts = 0,
+		next_run_at = :now,
+		updated_at = :now
+	WHERE
+		provider_id = :provider_id AND
+		status = :failed
+	RETURNING
+		*`
+
+	var comp Compensation
+	if err := database.NamedQueryStruct(ctx, db, q, in, &comp); err != nil {
+		return Compensation{}, fmt.Errorf("requeueing compensation[%s]: %w", providerID, err)
+	}
+
+	return comp, nil
+}
+
+// CreateWebhookEvent records a webhook event.
+func CreateWebhookEvent(ctx context.Context, db sqlx.ExtContext, ev WebhookEvent) error {
+	const q = `
+	INSERT INTO webhook_events
+		(event_id, provider, type, payload, provider_id, payment_id, payment_status, result, error, attempts, created_at, updated_at)
+	VALUES
+		(:event_id, :provider, :type, :payload, :provider_id, :payment_id, :payment_status, :result, :error, :attempts, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, ev); err != nil {
+		return fmt.Errorf("inserting webhook event: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateWebhookEvent records the outcome of the processing of an event.
+func UpdateWebhookEvent(ctx context.Context, db sqlx.ExtContext, ev WebhookEvent) error {
+	const q = `
+	UPDATE webhook_events
+	SET
+		result = :result,
+		error = :error,
+		attempts = :attempts,
+		updated_at = :updated_at
+	WHERE
+		event_id = :event_id`
+
+	if err := database.NamedExecContext(ctx, db, q, ev); err != nil {
+		return fmt.Errorf("updating webhook event[%s]: %w", ev.ID, err)
+	}
+
+	return nil
+}
+
+// FetchWebhookEvent returns the webhook event with the passed id.
+func FetchWebhookEvent(ctx context.Context, db sqlx.ExtContext, id string) (WebhookEvent, error) {
+	in := struct {
+		ID string `db:"event_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhook_events
+	WHERE
+		event_id = :event_id`
+
+	var ev WebhookEvent
+	if err := database.NamedQueryStruct(ctx, db, q, in, &ev); err != nil {
+		return WebhookEvent{}, fmt.Errorf("selecting webhook event[%s]: %w", id, err)
+	}
+
+	return ev, nil
+}
+
+// FetchWebhookEvents returns the webhook events with the passed result,
+// or all of them when empty, the latest first.
+func FetchWebhookEvents(ctx context.Context, db sqlx.ExtContext, result EventResult) ([]WebhookEvent, error) {
+	in := struct {
+		Result EventResult `db:"result"`
+	}{
+		Result: result,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhook_events
+	WHERE
+		:result = '' OR result = :result
+	ORDER BY
+		created_at DESC`
+
+	evs := []WebhookEvent{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &evs); err != nil {
+		return nil, fmt.Errorf("selecting webhook events: %w", err)
+	}
+
+	return evs, nil
+}
+
+// FetchFulfilled returns the fulfilled orders of a provider created
+// in the passed period.
+func FetchFulfilled(ctx context.Context, db sqlx.ExtContext, provider string, from time.Time, to time.Time) ([]Order, error) {
+	in := struct {
+		Provider string    `db:"provider"`
+		Status   Status    `db:"status"`
+		From     time.Time `db:"from"`
+		To       time.Time `db:"to"`
+	}{
+		Provider: provider,
+		Status:   Success,
+		From:     from,
+		To:       to,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		provider = :provider AND
+		status = :status AND
+		created_at >= :from AND
+		created_at < :to
+	ORDER BY
+		created_at`
+
+	ords := []Order{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ords); err != nil {
+		return nil, fmt.Errorf("selecting fulfilled %s orders: %w", provider, err)
+	}
+
+	return ords, nil
+}
+
+// CreateMismatch records a mismatch, unless already detected.
+func CreateMismatch(ctx context.Context, db sqlx.ExtContext, m Mismatch) error {
+	const q = `
+	INSERT INTO payment_mismatches
+		(mismatch_id, provider, provider_id, payment_id, order_id, kind, detected_at)
+	VALUES
+		(:mismatch_id, :provider, :provider_id, :payment_id, :order_id, :kind, :detected_at)
+	ON CONFLICT
+		(provider, kind, provider_id, payment_id)
+	DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, db, q, m); err != nil {
+		return fmt.Errorf("inserting %s mismatch of a %s payment: %w", m.Kind, m.Provider, err)
+	}
+
+	return nil
+}
+
+// FetchMismatches returns the mismatches detected since the passed
+// time, or all of them when nil, the latest first.
+func FetchMismatches(ctx context.Context, db sqlx.ExtContext, since *time.Time) ([]Mismatch, error) {
+	in := struct {
+		Since *time.Time `db:"since"`
+	}{
+		Since: since,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		payment_mismatches
+	WHERE
+		CAST(:since AS TIMESTAMP) IS NULL OR detected_at >= :since
+	ORDER BY
+		detected_at DESC`
+
+	ms := []Mismatch{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ms); err != nil {
+		return nil, fmt.Errorf("selecting mismatches: %w", err)
+	}
+
+	return ms, nil
+}
+
+// FetchUnclaimed returns the guests who payed an order, or have been
+// granted a course, and haven't been sent the link to claim their
+// account yet.
+func FetchUnclaimed(ctx context.Context, db sqlx.ExtContext) ([]user.User, error) {
+	in := struct {
+		Role   string `db:"role"`
+		Status Status `db:"status"`
+		Scope  string `db:"scope"`
+	}{
+		Role:   claims.RoleGuest,
+		Status: Success,
+		Scope:  token.ClaimToken,
+	}
+
+	const q = `
+	SELECT
+		u.*
+	FROM
+		users AS u
+	WHERE
+		u.role = :role AND
+		(
+			EXISTS (
+				SELECT 1 FROM orders AS o
+				WHERE o.user_id = u.user_id AND o.status = :status
+			) OR
+			EXISTS (
+				SELECT 1 FROM course_grants AS cg
+				WHERE cg.user_id = u.user_id AND cg.revoked_at IS NULL
+			)
+		) AND
+		NOT EXISTS (
+			SELECT 1 FROM tokens AS t
+			WHERE t.user_id = u.user_id AND t.scope = :scope
+		)
+	ORDER BY
+		u.created_at`
+
+	us := []user.User{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &us); err != nil {
+		return nil, fmt.Errorf("selecting unclaimed guests: %w", err)
+	}
+
+	return us, nil
+}
+
+// CreateEvent writes an event to the outbox.
+func CreateEvent(ctx context.Context, db sqlx.ExtContext, e Event) error {
+	const q = `
+	INSERT INTO outbox
+		(event_id, topic, payload, attempts, last_error, created_at)
+	VALUES
+		(:event_id, :topic, :payload, :attempts, :last_error, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, e); err != nil {
+		return fmt.Errorf("inserting event[%s]: %w", e.Topic, err)
+	}
+
+	return nil
+}
+
+// UpdateEvent records the outcome of the last attempt to publish an
+// event of the outbox.
+func UpdateEvent(ctx context.Context, db sqlx.ExtContext, e Event) error {
+	const q = `
+	UPDATE outbox
+	SET
+		attempts = :attempts,
+		last_error = :last_error,
+		published_at = :published_at
+	WHERE
+		event_id = :event_id`
+
+	if err := database.NamedExecContext(ctx, db, q, e); err != nil {
+		return fmt.Errorf("updating event[%s]: %w", e.ID, err)
+	}
+
+	return nil
+}
+
+// FetchUnpublished returns at most limit events of the outbox not
+// published yet, the oldest first.
+func FetchUnpublished(ctx context.Context, db sqlx.ExtContext, limit int) ([]Event, error) {
+	in := struct {
+		Limit int `db:"limit"`
+	}{
+		Limit: limit,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		outbox
+	WHERE
+		published_at IS NULL
+	ORDER BY
+		created_at
+	LIMIT :limit`
+
+	es := []Event{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &es); err != nil {
+		return nil, fmt.Errorf("selecting unpublished events: %w", err)
+	}
+
+	return es, nil
+}
+
+// IsConsumed reports whether the passed consumer already handled the
+// event with the passed id.
+func IsConsumed(ctx context.Context, db sqlx.ExtContext, eventID string, consumer string) (bool, error) {
+	in := struct {
+		EventID  string `db:"event_id"`
+		Consumer string `db:"consumer"`
+	}{
+		EventID:  eventID,
+		Consumer: consumer,
+	}
+
+	const q = `
+	SELECT
+		EXISTS (
+			SELECT 1 FROM consumed_events
+			WHERE event_id = :event_id AND consumer = :consumer
+		) AS consumed`
+
+	var out struct {
+		Consumed bool `db:"consumed"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return false, fmt.Errorf("selecting event[%s] consumed by %s: %w", eventID, consumer, err)
+	}
+
+	return out.Consumed, nil
+}
+
+// MarkConsumed records that the passed consumer handled the event with
+// the passed id.
+func MarkConsumed(ctx context.Context, db sqlx.ExtContext, eventID string, consumer string, now time.Time) error {
+	in := struct {
+		EventID    string    `db:"event_id"`
+		Consumer   string    `db:"consumer"`
+		ConsumedAt time.Time `db:"consumed_at"`
+	}{
+		EventID:    eventID,
+		Consumer:   consumer,
+		ConsumedAt: now,
+	}
+
+	const q = `
+	INSERT INTO consumed_events
+		(event_id, consumer, consumed_at)
+	VALUES
+		(:event_id, :consumer, :consumed_at)
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("marking event[%s] consumed by %s: %w", eventID, consumer, err)
+	}
+
+	return nil
+}
+
+// FetchHistory returns the statuses the passed order went through,
+// the oldest first.
+func FetchHistory(ctx context.Context, db sqlx.ExtContext, orderID string) ([]StatusChange, error) {
+	in := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: orderID,
+	}
+
+	const q = `
+	SELECT
+		status,
+		created_at
+	FROM
+		order_statuses
+	WHERE
+		order_id = :order_id
+	ORDER BY
+		created_at`
+
+	h := []StatusChange{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &h); err != nil {
+		return nil, fmt.Errorf("selecting history of order[%s]: %w", orderID, err)
+	}
+
+	return h, nil
+}
+
+// CreateRefundRequest inserts a new refund request. It fails with
+// database.ErrDBDuplicatedEntry if the order has a pending request.
+func CreateRefundRequest(ctx context.Context, db sqlx.ExtContext, rr RefundRequest) error {
+	const q = `
+	INSERT INTO refund_requests
+		(request_id, order_id, user_id, reason, status, note, created_at, updated_at)
+	VALUES
+		(:request_id, :order_id, :user_id, :reason, :status, :note, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, rr); err != nil {
+		return fmt.Errorf("inserting refund request of order[%s]: %w", rr.OrderID, err)
+	}
+
+	return nil
+}
+
+// DecideRefundRequest records the decision on a pending refund request.
+// It fails with database.ErrDBNotFound if the request is not pending.
+func DecideRefundRequest(ctx context.Context, db sqlx.ExtContext, rr RefundRequest) error {
+	in := struct {
+		RefundRequest
+		Pending RefundRequestStatus `db:"pending"`
+	}{
+		RefundRequest: rr,
+		Pending:       RefundRequested,
+	}
+
+	const q = `
+	UPDATE refund_requests
+	SET
+		status = :status,
+		note = :note,
+		updated_at = :updated_at
+	WHERE
+		request_id = :request_id AND
+		status = :pending
+	RETURNING request_id`
+
+	var out struct {
+		ID string `db:"request_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("deciding refund request[%s]: %w", rr.ID, err)
+	}
+
+	return nil
+}
+
+// FetchRefundRequest returns the refund request with the passed id.
+func FetchRefundRequest(ctx context.Context, db sqlx.ExtContext, id string) (RefundRequest, error) {
+	in := struct {
+		ID string `db:"request_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		refund_requests
+	WHERE
+		request_id = :request_id`
+
+	var rr RefundRequest
+	if err := database.NamedQueryStruct(ctx, db, q, in, &rr); err != nil {
+		return RefundRequest{}, fmt.Errorf("selecting refund request[%s]: %w", id, err)
+	}
+
+	return rr, nil
+}
+
+// FetchRefundRequests returns a page of the refund requests in the
+// passed status, the oldest first.
+func FetchRefundRequests(ctx context.Context, db sqlx.ExtContext, status RefundRequestStatus, limit int, offset int) ([]RefundRequest, error) {
+	in := struct {
+		Status RefundRequestStatus `db:"status"`
+		Limit  int                 `db:"limit"`
+		Offset int                 `db:"offset"`
+	}{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		refund_requests
+	WHERE
+		status = :status
+	ORDER BY
+		created_at
+	LIMIT :limit
+	OFFSET :offset`
+
+	rrs := []RefundRequest{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rrs); err != nil {
+		return nil, fmt.Errorf("selecting %s refund requests: %w", status, err)
+	}
+
+	return rrs, nil
+}
+
+// FetchRefundRequestsByOrder returns the refund requests of the passed
+// order, the oldest first.
+func FetchRefundRequestsByOrder(ctx context.Context, db sqlx.ExtContext, orderID string) ([]RefundRequest, error) {
+	in := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: orderID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		refund_requests
+	WHERE
+		order_id = :order_id
+	ORDER BY
+		created_at`
+
+	rrs := []RefundRequest{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rrs); err != nil {
+		return nil, fmt.Errorf("selecting refund requests of order[%s]: %w", orderID, err)
+	}
+
+	return rrs, nil
+}
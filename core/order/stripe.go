@@ -0,0 +1,288 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/stripe/stripe-go/v74"
+	stripecl "github.com/stripe/stripe-go/v74/client"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+// installmentMethods are the payment methods offered by the checkout
+// sessions when installments are enabled. Buy now pay later methods
+// are only offered for the currencies and countries they support.
+var installmentMethods = []string{"card", "klarna", "afterpay_clearpay"}
+
+// Stripe accepts payments through stripe checkout sessions.
+// Sessions are completed by stripe webhooks.
+type Stripe struct {
+	client *stripecl.API
+	cfg    config.Stripe
+}
+
+// NewStripe returns the stripe provider.
+func NewStripe(client *stripecl.API, cfg config.Stripe) *Stripe {
+	return &Stripe{client: client, cfg: cfg}
+}
+
+// Name implements the PaymentProvider interface.
+func (s *Stripe) Name() string {
+	return ProviderStripe
+}
+
+// CreateCheckout creates a checkout session whose URL is sent to the client.
+func (s *Stripe) CreateCheckout(ctx context.Context, lines []line, tot money.Amount) (Checkout, error) {
+	li := make([]*stripe.CheckoutSessionLineItemParams, 0, len(lines))
+	for _, l := range lines {
+		amount := l.amount()
+		li = append(li, &stripe.CheckoutSessionLineItemParams{
+			Quantity: stripe.Int64(int64(l.quantity)),
+
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency:    stripe.String(strings.ToLower(amount.Currency)),
+				TaxBehavior: stripe.String("inclusive"),
+				UnitAmount:  stripe.Int64(amount.Units),
+
+				// The course is needed to break down the taxes per item.
+				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+					Name:        stripe.String(l.course.Name),
+					Description: stripe.String(l.course.Description),
+					Metadata:    map[string]string{"course_id": l.course.ID},
+				},
+			},
+		})
+	}
+
+	params := &stripe.CheckoutSessionParams{
+		SuccessURL: stripe.String(s.cfg.SuccessURL),
+		CancelURL:  stripe.String(s.cfg.CancelURL),
+		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
+		LineItems:  li,
+	}
+	params.Context = ctx
+
+	// Installments are payed later, notified by the async payment events.
+	if s.cfg.Installments {
+		params.PaymentMethodTypes = stripe.StringSlice(installmentMethods)
+	}
+
+	// Taxes depend on where the customer lives, so the address is needed.
+	if s.cfg.AutomaticTax {
+		params.AutomaticTax = &stripe.CheckoutSessionAutomaticTaxParams{Enabled: stripe.Bool(true)}
+		params.BillingAddressCollection = stripe.String(string(stripe.CheckoutSessionBillingAddressCollectionRequired))
+	}
+
+	sess, err := s.client.CheckoutSessions.New(params)
+	if err != nil {
+		return Checkout{}, fmt.Errorf("creating stripe session: %w", err)
+	}
+
+	return Checkout{ID: sess.ID, Resp: sess.URL}, nil
+}
+
+// Capture checks whether the checkout session has been payed.
+// Stripe captures the payment by itself, so nothing else is done.
+func (s *Stripe) Capture(ctx context.Context, providerID string) (Payment, error) {
+	params := &stripe.CheckoutSessionParams{}
+	params.Context = ctx
+
+	sess, err := s.client.CheckoutSessions.Get(providerID, params)
+	if err != nil {
+		return Payment{}, fmt.Errorf("fetching stripe session[%s]: %w", providerID, err)
+	}
+
+	return sessionPayment(sess), nil
+}
+
+// Taxes returns the taxes calculated by stripe for the line items
+// of the checkout session.
+func (s *Stripe) Taxes(ctx context.Context, providerID string) ([]Tax, error) {
+	params := &stripe.CheckoutSessionListLineItemsParams{Session: stripe.String(providerID)}
+	params.Context = ctx
+	params.AddExpand("data.price.product")
+	params.AddExpand("data.taxes")
+
+	var taxes []Tax
+	it := s.client.CheckoutSessions.ListLineItems(params)
+	for it.Next() {
+		li := it.LineItem()
+		if li.Price == nil || li.Price.Product == nil {
+			continue
+		}
+
+		courseID := li.Price.Product.Metadata["course_id"]
+		for _, t := range li.Taxes {
+			if t.Rate == nil {
+				continue
+			}
+
+			taxes = append(taxes, Tax{
+				CourseID:     courseID,
+				Name:         t.Rate.DisplayName,
+				Jurisdiction: t.Rate.Jurisdiction,
+				Country:      t.Rate.Country,
+				State:        t.Rate.State,
+				Percentage:   t.Rate.Percentage,
+				Inclusive:    t.Rate.Inclusive,
+				Amount:       money.New(t.Amount, string(li.Currency)),
+			})
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("listing line items of stripe session[%s]: %w", providerID, err)
+	}
+
+	return taxes, nil
+}
+
+// Refund refunds a payment intent.
+func (s *Stripe) Refund(ctx context.Context, paymentID string) error {
+	params := &stripe.RefundParams{PaymentIntent: stripe.String(paymentID)}
+	params.Context = ctx
+
+	if _, err := s.client.Refunds.New(params); err != nil {
+		return fmt.Errorf("refunding stripe payment intent[%s]: %w", paymentID, err)
+	}
+
+	return nil
+}
+
+// RefundPart refunds part of a payment intent.
+func (s *Stripe) RefundPart(ctx context.Context, paymentID string, amount money.Amount) error {
+	params := &stripe.RefundParams{PaymentIntent: stripe.String(paymentID), Amount: stripe.Int64(amount.Units)}
+	params.Context = ctx
+
+	if _, err := s.client.Refunds.New(params); err != nil {
+		return fmt.Errorf("refunding %s of stripe payment intent[%s]: %w", amount, paymentID, err)
+	}
+
+	return nil
+}
+
+// Cancel expires the checkout session.
+func (s *Stripe) Cancel(ctx context.Context, providerID string) error {
+	params := &stripe.CheckoutSessionExpireParams{}
+	params.Context = ctx
+
+	if _, err := s.client.CheckoutSessions.Expire(providerID, params); err != nil {
+		return fmt.Errorf("expiring stripe session[%s]: %w", providerID, err)
+	}
+
+	return nil
+}
+
+// VerifyWebhook checks the signature of a stripe event and returns the
+// payment of the checkout session it regards.
+// Sessions payed with delayed methods, e.g. installments, complete
+// before being payed: their payment is notified later by the async
+// payment events.
+// https://stripe.com/docs/payments/checkout/fulfill-orders#delayed-notification .
+func (s *Stripe) VerifyWebhook(r *http.Request) (Payment, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Payment{}, fmt.Errorf("cannot read the request body: %w", err)
+	}
+
+	sig := r.Header.Get("Stripe-Signature")
+	if sig == "" {
+		return Payment{}, errors.New("received stripe event is not signed")
+	}
+
+	event, err := webhook.ConstructEvent(b, sig, s.cfg.WebhookSecret)
+	if err != nil {
+		return Payment{}, fmt.Errorf("cannot construct stripe event: %w", err)
+	}
+
+	// Filter all the events but the checkout ones.
+	var status Status
+	switch event.Type {
+	case "checkout.session.completed", "checkout.session.async_payment_succeeded":
+		status = Success
+	case "checkout.session.expired":
+		status = Expired
+	case "checkout.session.async_payment_failed":
+		status = Failed
+	default:
+		return Payment{Event: event.Type}, errEventIgnored
+	}
+
+	var sess stripe.CheckoutSession
+	if err = json.Unmarshal(event.Data.Raw, &sess); err != nil {
+		return Payment{}, fmt.Errorf("unable to decode stripe event: %w", err)
+	}
+
+	// Filter out checkouts that are not for one-time payments.
+	if sess.Mode != stripe.CheckoutSessionModePayment {
+		return Payment{Event: event.Type}, errEventIgnored
+	}
+
+	// Wait for the async payment of the sessions completed unpaid.
+	if status == Success && sess.PaymentStatus == stripe.CheckoutSessionPaymentStatusUnpaid {
+		status = Pending
+	}
+
+	pay := sessionPayment(&sess)
+	pay.Status = status
+	pay.Event = event.Type
+	return pay, nil
+}
+
+// Payments returns the checkout sessions payed since the passed time.
+func (s *Stripe) Payments(ctx context.Context, since time.Time) ([]Payment, error) {
+	params := &stripe.CheckoutSessionListParams{}
+	params.Context = ctx
+	params.Filters.AddFilter("created", "gte", strconv.FormatInt(since.Unix(), 10))
+
+	var pays []Payment
+	it := s.client.CheckoutSessions.List(params)
+	for it.Next() {
+		sess := it.CheckoutSession()
+		if sess.Mode != stripe.CheckoutSessionModePayment {
+			continue
+		}
+
+		if pay := sessionPayment(sess); pay.Status == Success {
+			pays = append(pays, pay)
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("listing stripe sessions: %w", err)
+	}
+
+	return pays, nil
+}
+
+// sessionPayment returns the payment of a checkout session.
+func sessionPayment(sess *stripe.CheckoutSession) Payment {
+	pay := Payment{ProviderID: sess.ID, Status: Failed}
+
+	if sess.PaymentIntent != nil {
+		pay.PaymentID = sess.PaymentIntent.ID
+	}
+
+	switch {
+	case sess.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid:
+		pay.Status = Success
+	case sess.Status == stripe.CheckoutSessionStatusExpired:
+		pay.Status = Expired
+	case sess.Status == stripe.CheckoutSessionStatusOpen:
+		pay.Status = Pending
+	case sess.Status == stripe.CheckoutSessionStatusComplete && sess.PaymentStatus == stripe.CheckoutSessionPaymentStatusUnpaid:
+		// Delayed payment methods are still processing.
+		pay.Status = Pending
+	}
+
+	return pay
+}
@@ -0,0 +1,193 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/stripe/stripe-go/v74"
+	stripecl "github.com/stripe/stripe-go/v74/client"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+// intentOrigin marks the payment intents created for the payment element,
+// as opposed to the ones created by checkout sessions.
+const intentOrigin = "payment_element"
+
+// StripeIntent accepts payments through stripe payment intents, confirmed
+// by the client with the payment element, which renders the wallet
+// buttons of Apple Pay and Google Pay as well.
+// Intents are completed by stripe webhooks.
+type StripeIntent struct {
+	client *stripecl.API
+	cfg    config.Stripe
+}
+
+// stripeIntentCheckout is sent to the client to render the payment element.
+type stripeIntentCheckout struct {
+	ID           string `json:"id"`
+	ClientSecret string `json:"clientSecret"`
+}
+
+// NewStripeIntent returns the stripe payment intents provider.
+func NewStripeIntent(client *stripecl.API, cfg config.Stripe) *StripeIntent {
+	return &StripeIntent{client: client, cfg: cfg}
+}
+
+// Name implements the PaymentProvider interface.
+func (s *StripeIntent) Name() string {
+	return ProviderStripeIntent
+}
+
+// CreateCheckout creates a payment intent of the total, whose client
+// secret is sent to the client. The payment methods are the ones enabled
+// on the stripe dashboard.
+func (s *StripeIntent) CreateCheckout(ctx context.Context, lines []line, tot money.Amount) (Checkout, error) {
+	names := make([]string, 0, len(lines))
+	for _, l := range lines {
+		names = append(names, l.course.Name)
+	}
+
+	params := &stripe.PaymentIntentParams{
+		Amount:      stripe.Int64(tot.Units),
+		Currency:    stripe.String(strings.ToLower(tot.Currency)),
+		Description: stripe.String(strings.Join(names, ", ")),
+
+		AutomaticPaymentMethods: &stripe.PaymentIntentAutomaticPaymentMethodsParams{
+			Enabled: stripe.Bool(true),
+		},
+	}
+	params.Context = ctx
+	params.AddMetadata("origin", intentOrigin)
+
+	pi, err := s.client.PaymentIntents.New(params)
+	if err != nil {
+		return Checkout{}, fmt.Errorf("creating stripe payment intent: %w", err)
+	}
+
+	return Checkout{ID: pi.ID, Resp: stripeIntentCheckout{ID: pi.ID, ClientSecret: pi.ClientSecret}}, nil
+}
+
+// Capture checks whether the payment intent has succeeded.
+// Stripe captures the payment by itself, so nothing else is done.
+func (s *StripeIntent) Capture(ctx context.Context, providerID string) (Payment, error) {
+	params := &stripe.PaymentIntentParams{}
+	params.Context = ctx
+
+	pi, err := s.client.PaymentIntents.Get(providerID, params)
+	if err != nil {
+		return Payment{}, fmt.Errorf("fetching stripe payment intent[%s]: %w", providerID, err)
+	}
+
+	return intentPayment(pi), nil
+}
+
+// Taxes implements the PaymentProvider interface.
+// Payment intents are not taxed by stripe.
+func (s *StripeIntent) Taxes(ctx context.Context, providerID string) ([]Tax, error) {
+	return nil, nil
+}
+
+// Refund refunds a payment intent.
+func (s *StripeIntent) Refund(ctx context.Context, paymentID string) error {
+	params := &stripe.RefundParams{PaymentIntent: stripe.String(paymentID)}
+	params.Context = ctx
+
+	if _, err := s.client.Refunds.New(params); err != nil {
+		return fmt.Errorf("refunding stripe payment intent[%s]: %w", paymentID, err)
+	}
+
+	return nil
+}
+
+// RefundPart refunds part of a payment intent.
+func (s *StripeIntent) RefundPart(ctx context.Context, paymentID string, amount money.Amount) error {
+	params := &stripe.RefundParams{PaymentIntent: stripe.String(paymentID), Amount: stripe.Int64(amount.Units)}
+	params.Context = ctx
+
+	if _, err := s.client.Refunds.New(params); err != nil {
+		return fmt.Errorf("refunding %s of stripe payment intent[%s]: %w", amount, paymentID, err)
+	}
+
+	return nil
+}
+
+// Cancel cancels the payment intent. Stripe refuses to cancel the
+// intents which have succeeded.
+func (s *StripeIntent) Cancel(ctx context.Context, providerID string) error {
+	params := &stripe.PaymentIntentCancelParams{}
+	params.Context = ctx
+
+	if _, err := s.client.PaymentIntents.Cancel(providerID, params); err != nil {
+		return fmt.Errorf("canceling stripe payment intent[%s]: %w", providerID, err)
+	}
+
+	return nil
+}
+
+// VerifyWebhook checks the signature of a stripe event and returns the
+// payment of the payment intent it regards.
+// Failed payments are ignored, since the user can retry with another
+// payment method until the intent is canceled.
+func (s *StripeIntent) VerifyWebhook(r *http.Request) (Payment, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Payment{}, fmt.Errorf("cannot read the request body: %w", err)
+	}
+
+	sig := r.Header.Get("Stripe-Signature")
+	if sig == "" {
+		return Payment{}, errors.New("received stripe event is not signed")
+	}
+
+	event, err := webhook.ConstructEvent(b, sig, s.cfg.IntentWebhookSecret)
+	if err != nil {
+		return Payment{}, fmt.Errorf("cannot construct stripe event: %w", err)
+	}
+
+	var status Status
+	switch event.Type {
+	case "payment_intent.succeeded":
+		status = Success
+	case "payment_intent.canceled":
+		status = Expired
+	default:
+		return Payment{Event: event.Type}, errEventIgnored
+	}
+
+	var pi stripe.PaymentIntent
+	if err = json.Unmarshal(event.Data.Raw, &pi); err != nil {
+		return Payment{}, fmt.Errorf("unable to decode stripe event: %w", err)
+	}
+
+	// Filter out the intents of checkout sessions, they are fulfilled
+	// by the checkout session events.
+	if pi.Metadata["origin"] != intentOrigin {
+		return Payment{Event: event.Type}, errEventIgnored
+	}
+
+	pay := intentPayment(&pi)
+	pay.Status = status
+	pay.Event = event.Type
+	return pay, nil
+}
+
+// intentPayment returns the payment of a payment intent.
+func intentPayment(pi *stripe.PaymentIntent) Payment {
+	pay := Payment{ProviderID: pi.ID, PaymentID: pi.ID, Status: Pending}
+
+	switch pi.Status {
+	case stripe.PaymentIntentStatusSucceeded:
+		pay.Status = Success
+	case stripe.PaymentIntentStatusCanceled:
+		pay.Status = Expired
+	}
+
+	return pay
+}
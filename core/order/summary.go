@@ -0,0 +1,151 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jmoiron/sqlx"
+)
+
+// summaryLine breaks down the discount of the line into the sale, the
+// bundle and the coupon granting it.
+// Bundled courses are not discounted by sales nor coupons, while
+// coupons stack on sales.
+func summaryLine(l line) (SummaryLine, error) {
+	cur := l.course.Price.Currency
+	sl := SummaryLine{
+		CourseID:       l.course.ID,
+		BundleID:       l.bundleID(),
+		Name:           l.course.Name,
+		Price:          l.course.Price,
+		Quantity:       l.quantity,
+		SaleDiscount:   money.Zero(cur),
+		BundleDiscount: money.Zero(cur),
+		Coupon:         l.coupon,
+		CouponDiscount: money.Zero(cur),
+		Credit:         l.credit,
+		Amount:         l.amount().Mul(int64(l.quantity)),
+	}
+
+	if l.bundle != "" {
+		sl.BundleDiscount = l.discount
+		return sl, nil
+	}
+
+	var err error
+	if l.course.Sale != nil {
+		if sl.SaleDiscount, err = l.course.Price.Sub(l.course.Sale.Price); err != nil {
+			return SummaryLine{}, err
+		}
+	}
+
+	if sl.CouponDiscount, err = l.discount.Sub(sl.SaleDiscount); err != nil {
+		return SummaryLine{}, err
+	}
+
+	return sl, nil
+}
+
+// summarize sums up the lines into the summary of their checkout.
+func summarize(lines []line) (Summary, error) {
+	tot, err := total(lines)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	cur := tot.Currency
+	s := Summary{
+		Lines:          make([]SummaryLine, 0, len(lines)),
+		Subtotal:       money.Zero(cur),
+		SaleDiscount:   money.Zero(cur),
+		BundleDiscount: money.Zero(cur),
+		CouponDiscount: money.Zero(cur),
+		Credit:         money.Zero(cur),
+		TaxInclusive:   true,
+		Total:          tot,
+	}
+
+	for _, l := range lines {
+		sl, err := summaryLine(l)
+		if err != nil {
+			return Summary{}, err
+		}
+		s.Lines = append(s.Lines, sl)
+
+		if sl.Coupon != "" {
+			s.Coupon = sl.Coupon
+		}
+
+		q := int64(sl.Quantity)
+		sums := []struct {
+			to *money.Amount
+			a  money.Amount
+		}{
+			{&s.Subtotal, sl.Price},
+			{&s.SaleDiscount, sl.SaleDiscount},
+			{&s.BundleDiscount, sl.BundleDiscount},
+			{&s.CouponDiscount, sl.CouponDiscount},
+			{&s.Credit, sl.Credit},
+		}
+		for _, sum := range sums {
+			if *sum.to, err = sum.to.Add(sum.a.Mul(q)); err != nil {
+				return Summary{}, err
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// HandleSummary returns the subtotal of the user's cart, the discounts
+// of the sales, bundles and coupon applied to it, the store credit
+// spent and the total to pay. It takes the same query parameters as
+// the checkout of the cart and prices it the same way, so that the
+// total shown is the one charged by the payment provider.
+// Nothing is reserved: coupons and credit may change until checkout.
+func HandleSummary(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		reg, err := region(ctx, db, r, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		lines, _, err := fromCart(ctx, db, r, clm.UserID, reg, r.URL.Query().Get("coupon"))
+		if err != nil {
+			return buyError(err)
+		}
+
+		if len(lines) == 0 {
+			err := errors.New("no items to checkout")
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		tot, err := total(lines)
+		if err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if r.URL.Query().Get("wallet") == "true" && !tot.IsZero() {
+			if err := useCredit(ctx, db, clm.UserID, lines); err != nil {
+				return err
+			}
+		}
+
+		s, err := summarize(lines)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, s, http.StatusOK)
+	}
+}
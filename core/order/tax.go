@@ -0,0 +1,47 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// recordTaxes stores the breakdown of the taxes applied to the items
+// of an order, together with the total tax of each item.
+// Taxes of courses which are not in the order are ignored.
+func recordTaxes(ctx context.Context, db sqlx.ExtContext, orderID string, items []Item, taxes []Tax, now time.Time) error {
+	for _, it := range items {
+		var amounts []money.Amount
+		for _, t := range taxes {
+			if t.CourseID != it.CourseID {
+				continue
+			}
+
+			t.ID = validate.GenerateID()
+			t.OrderID = orderID
+			t.CreatedAt = now
+			if err := CreateTax(ctx, db, t); err != nil {
+				return err
+			}
+			amounts = append(amounts, t.Amount)
+		}
+
+		if len(amounts) == 0 {
+			continue
+		}
+
+		tot, err := money.Sum(it.Price.Currency, amounts...)
+		if err != nil {
+			return err
+		}
+
+		if err := SetItemTax(ctx, db, orderID, it.CourseID, tot); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errCompensated is returned when a purchase has been payed but the
+// fulfillment of its order failed and has been scheduled again.
+var errCompensated = errors.New("the order was payed but its fulfillment failed, compensation scheduled")
+
+// receive records an authentic webhook event of the provider, carrying
+// the passed payload. Events ignored by the provider are recorded as such.
+func receive(ctx context.Context, db sqlx.ExtContext, provider string, payload []byte, pay Payment, verr error) (WebhookEvent, error) {
+	now := time.Now().UTC()
+	ev := WebhookEvent{
+		ID:            validate.GenerateID(),
+		Provider:      provider,
+		Type:          pay.Event,
+		Payload:       string(payload),
+		ProviderID:    pay.ProviderID,
+		PaymentID:     pay.PaymentID,
+		PaymentStatus: pay.Status,
+		Result:        EventReceived,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if errors.Is(verr, errEventIgnored) {
+		ev.Result = EventIgnored
+	}
+
+	if err := CreateWebhookEvent(ctx, db, ev); err != nil {
+		return WebhookEvent{}, fmt.Errorf("recording %s event %q: %w", provider, pay.Event, err)
+	}
+
+	return ev, nil
+}
+
+// processed records the outcome of an attempt to process the event,
+// which failed when err is not nil.
+func processed(ctx context.Context, db sqlx.ExtContext, ev WebhookEvent, err error) (WebhookEvent, error) {
+	ev.Attempts++
+	ev.UpdatedAt = time.Now().UTC()
+	ev.Error = ""
+
+	switch {
+	case err == nil:
+		ev.Result = EventProcessed
+	case errors.Is(err, errCompensated):
+		ev.Result = EventCompensated
+		ev.Error = err.Error()
+	default:
+		ev.Result = EventFailed
+		ev.Error = err.Error()
+	}
+
+	if err := UpdateWebhookEvent(ctx, db, ev); err != nil {
+		return WebhookEvent{}, err
+	}
+
+	return ev, nil
+}
+
+// replay settles once more the payment notified by an event.
+// Users are not reminded of their cart, since the reminder would be
+// outdated by then. Orders which are not pending anymore, e.g. because
+// the user captured the payment in the meantime, are handled the same
+// as by a live delivery, see fulfill and abandon.
+func replay(ctx context.Context, db *sqlx.DB, prov PaymentProvider, ev WebhookEvent) error {
+	pay := Payment{
+		ProviderID: ev.ProviderID,
+		PaymentID:  ev.PaymentID,
+		Status:     ev.PaymentStatus,
+		Event:      ev.Type,
+	}
+
+	return settle(ctx, db, prov, nil, nil, "", pay)
+}
+
+// HandleListWebhookEvents allows administrators to browse the webhook
+// events received, the latest first. They can be filtered by the result
+// of their processing via the result query parameter.
+func HandleListWebhookEvents(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		result := EventResult(r.URL.Query().Get("result"))
+
+		evs, err := FetchWebhookEvents(ctx, db, result)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, evs, http.StatusOK)
+	}
+}
+
+// HandleReplayWebhookEvent allows administrators to process once more a
+// webhook event whose processing failed, e.g. because of a transient
+// database error. It responds with the event, reporting the outcome of
+// the replay.
+func HandleReplayWebhookEvent(db *sqlx.DB, provs Providers) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		eventID := web.Param(r, "id")
+
+		if err := validate.CheckID(eventID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		ev, err := FetchWebhookEvent(ctx, db, eventID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if ev.Result != EventFailed {
+			err := fmt.Errorf("webhook event[%s] is %s", ev.ID, ev.Result)
+			return weberr.NewError(err, "only failed events can be replayed", http.StatusConflict)
+		}
+
+		prov, err := provs.Get(ev.Provider)
+		if err != nil {
+			return err
+		}
+
+		ev, err = processed(ctx, db, ev, replay(ctx, db, prov, ev))
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, ev, http.StatusOK)
+	}
+}
@@ -0,0 +1,356 @@
+package org
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/background"
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// Mailer should be able to invite users in organizations.
+type Mailer interface {
+	SendOrgInvitation(org string, joinURL string, to string) error
+}
+
+var (
+	// errNoSeatsLeft is returned when all the seats of a course are assigned.
+	errNoSeatsLeft = errors.New("no seats left")
+
+	// errNoSeats is returned when the organization has no seats of a course.
+	errNoSeats = errors.New("no seats of the course")
+)
+
+// member returns the membership of the authenticated user in the
+// organization, failing if the user is not a member with the passed role.
+// Any role is accepted when role is empty.
+func member(ctx context.Context, db *sqlx.DB, orgID string, role string) (Member, error) {
+	if err := validate.CheckID(orgID); err != nil {
+		return Member{}, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	clm, err := claims.Get(ctx)
+	if err != nil {
+		return Member{}, weberr.NotAuthorized(errors.New("user not authenticated"))
+	}
+
+	m, err := FetchMember(ctx, db, orgID, clm.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Member{}, weberr.NotFound(err)
+		}
+		return Member{}, err
+	}
+
+	if role != "" && m.Role != role {
+		err := fmt.Errorf("user[%s] is not %s of organization[%s]", clm.UserID, role, orgID)
+		return Member{}, weberr.NewError(err, "forbidden", http.StatusForbidden)
+	}
+
+	return m, nil
+}
+
+// HandleCreate allows users to create an organization,
+// which they administer.
+func HandleCreate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var o OrgNew
+		if err := web.Decode(w, r, &o); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(o); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		usr, err := user.Fetch(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", clm.UserID, err)
+		}
+
+		now := time.Now().UTC()
+		org := Org{
+			ID:        validate.GenerateID(),
+			Name:      o.Name,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		m := Member{
+			OrgID:     org.ID,
+			Email:     strings.ToLower(usr.Email),
+			UserID:    &usr.ID,
+			Role:      RoleAdmin,
+			InvitedAt: now,
+			JoinedAt:  &now,
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := Create(ctx, tx, org); err != nil {
+				return err
+			}
+			return CreateMember(ctx, tx, m)
+		})
+		if err != nil {
+			return fmt.Errorf("creating organization: %w", err)
+		}
+
+		return web.Respond(ctx, w, org, http.StatusCreated)
+	}
+}
+
+// HandleShow returns an organization with its members and seats.
+// Only members can see it.
+func HandleShow(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orgID := web.Param(r, "id")
+
+		if _, err := member(ctx, db, orgID, ""); err != nil {
+			return err
+		}
+
+		org, err := Fetch(ctx, db, orgID)
+		if err != nil {
+			return fmt.Errorf("fetching organization[%s]: %w", orgID, err)
+		}
+
+		ms, err := FetchMembers(ctx, db, orgID)
+		if err != nil {
+			return err
+		}
+
+		seats, err := FetchSeats(ctx, db, orgID)
+		if err != nil {
+			return err
+		}
+
+		resp := struct {
+			Org
+			Members []Member `json:"members"`
+			Seats   []Seats  `json:"seats"`
+		}{
+			Org:     org,
+			Members: ms,
+			Seats:   seats,
+		}
+
+		return web.Respond(ctx, w, resp, http.StatusOK)
+	}
+}
+
+// HandleInvite allows the admins of an organization to invite
+// a new member by email.
+func HandleInvite(db *sqlx.DB, mailer Mailer, joinURL string, bg *background.Background) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orgID := web.Param(r, "id")
+
+		if _, err := member(ctx, db, orgID, RoleAdmin); err != nil {
+			return err
+		}
+
+		var inv Invitation
+		if err := web.Decode(w, r, &inv); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(inv); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if inv.Role == "" {
+			inv.Role = RoleMember
+		}
+
+		org, err := Fetch(ctx, db, orgID)
+		if err != nil {
+			return fmt.Errorf("fetching organization[%s]: %w", orgID, err)
+		}
+
+		m := Member{
+			OrgID:     orgID,
+			Email:     strings.ToLower(inv.Email),
+			Role:      inv.Role,
+			InvitedAt: time.Now().UTC(),
+		}
+
+		if err := CreateMember(ctx, db, m); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "user already invited", http.StatusConflict)
+			}
+			return err
+		}
+
+		bg.Add(func() error {
+			if err := mailer.SendOrgInvitation(org.Name, joinURL+orgID, m.Email); err != nil {
+				return fmt.Errorf("inviting %s in organization[%s]: %w", m.Email, orgID, err)
+			}
+			return nil
+		})
+
+		return web.Respond(ctx, w, m, http.StatusCreated)
+	}
+}
+
+// HandleJoin accepts the invitation in an organization sent to
+// the email of the authenticated user.
+func HandleJoin(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orgID := web.Param(r, "id")
+
+		if err := validate.CheckID(orgID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		usr, err := user.Fetch(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", clm.UserID, err)
+		}
+
+		m, err := Join(ctx, db, orgID, strings.ToLower(usr.Email), usr.ID, time.Now().UTC())
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, m, http.StatusOK)
+	}
+}
+
+// HandleAssign allows the admins of an organization to assign
+// a seat of a course to a member who joined.
+func HandleAssign(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orgID := web.Param(r, "id")
+		courseID := web.Param(r, "course_id")
+		userID := web.Param(r, "user_id")
+
+		if _, err := member(ctx, db, orgID, RoleAdmin); err != nil {
+			return err
+		}
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := validate.CheckID(userID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if _, err := FetchMember(ctx, db, orgID, userID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NewError(err, "user is not a member of the organization", http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		a := Assignment{
+			OrgID:      orgID,
+			CourseID:   courseID,
+			UserID:     userID,
+			AssignedAt: time.Now().UTC(),
+		}
+
+		// Lock the seats so concurrent assignments can't exceed them.
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			s, err := LockSeats(ctx, tx, orgID, courseID)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					return errNoSeats
+				}
+				return err
+			}
+
+			if s.Assigned >= s.Quantity {
+				return errNoSeatsLeft
+			}
+
+			return Assign(ctx, tx, a)
+		})
+
+		if err != nil {
+			switch {
+			case errors.Is(err, errNoSeats):
+				return weberr.NotFound(err)
+			case errors.Is(err, errNoSeatsLeft):
+				return weberr.NewError(err, err.Error(), http.StatusConflict)
+			case errors.Is(err, database.ErrDBDuplicatedEntry):
+				return weberr.NewError(err, "seat already assigned", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, a, http.StatusOK)
+	}
+}
+
+// HandleRevoke allows the admins of an organization to free the seat
+// of a course assigned to a member.
+func HandleRevoke(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orgID := web.Param(r, "id")
+		courseID := web.Param(r, "course_id")
+		userID := web.Param(r, "user_id")
+
+		if _, err := member(ctx, db, orgID, RoleAdmin); err != nil {
+			return err
+		}
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := validate.CheckID(userID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := Revoke(ctx, db, orgID, courseID, userID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleListProgress allows the admins of an organization to follow
+// the progress of the members on the courses assigned to them.
+func HandleListProgress(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		orgID := web.Param(r, "id")
+
+		if _, err := member(ctx, db, orgID, RoleAdmin); err != nil {
+			return err
+		}
+
+		ps, err := FetchProgress(ctx, db, orgID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, ps, http.StatusOK)
+	}
+}
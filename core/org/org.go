@@ -0,0 +1,67 @@
+// Package org manages organizations, which buy seats of courses
+// for their members.
+package org
+
+import "time"
+
+// Roles of the members of an organization.
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Org models organizations.
+type Org struct {
+	ID        string    `json:"id" db:"org_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// OrgNew contains the information needed to create an organization.
+type OrgNew struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// Member models a member of an organization. Members are invited by
+// email, UserID and JoinedAt are set once they accept the invitation.
+type Member struct {
+	OrgID     string     `json:"orgId" db:"org_id"`
+	Email     string     `json:"email" db:"email"`
+	UserID    *string    `json:"userId" db:"user_id"`
+	Role      string     `json:"role" db:"role"`
+	InvitedAt time.Time  `json:"invitedAt" db:"invited_at"`
+	JoinedAt  *time.Time `json:"joinedAt" db:"joined_at"`
+}
+
+// Invitation contains the information needed to invite a member.
+type Invitation struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"omitempty,oneof=admin member"`
+}
+
+// Seats models the seats of a course bought by an organization.
+type Seats struct {
+	OrgID     string    `json:"orgId" db:"org_id"`
+	CourseID  string    `json:"courseId" db:"course_id"`
+	Quantity  int       `json:"quantity" db:"quantity"`
+	Assigned  int       `json:"assigned" db:"assigned"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Assignment models a seat of a course assigned to a member.
+type Assignment struct {
+	OrgID      string    `json:"orgId" db:"org_id"`
+	CourseID   string    `json:"courseId" db:"course_id"`
+	UserID     string    `json:"userId" db:"user_id"`
+	AssignedAt time.Time `json:"assignedAt" db:"assigned_at"`
+}
+
+// Progress models the progress of a member on a course.
+type Progress struct {
+	UserID   string  `json:"userId" db:"user_id"`
+	Email    string  `json:"email" db:"email"`
+	CourseID string  `json:"courseId" db:"course_id"`
+	Progress float64 `json:"progress" db:"progress"`
+}
@@ -0,0 +1,411 @@
+package org
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Create inserts a new organization.
+func Create(ctx context.Context, db sqlx.ExtContext, org Org) error {
+	const q = `
+	INSERT INTO organizations
+		(org_id, name, created_at, updated_at)
+	VALUES
+		(:org_id, :name, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, org); err != nil {
+		return fmt.Errorf("inserting organization: %w", err)
+	}
+
+	return nil
+}
+
+// Fetch returns an organization given its id.
+func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Org, error) {
+	in := struct {
+		ID string `db:"org_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		organizations
+	WHERE
+		org_id = :org_id`
+
+	var org Org
+	if err := database.NamedQueryStruct(ctx, db, q, in, &org); err != nil {
+		return Org{}, fmt.Errorf("fetching organization[%s]: %w", id, err)
+	}
+
+	return org, nil
+}
+
+// CreateMember invites a member in an organization.
+func CreateMember(ctx context.Context, db sqlx.ExtContext, m Member) error {
+	const q = `
+	INSERT INTO org_members
+		(org_id, email, user_id, role, invited_at, joined_at)
+	VALUES
+		(:org_id, :email, :user_id, :role, :invited_at, :joined_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, m); err != nil {
+		return fmt.Errorf("inserting member %s of organization[%s]: %w", m.Email, m.OrgID, err)
+	}
+
+	return nil
+}
+
+// FetchMember returns the member of an organization who is the passed user.
+// Members who have not joined yet are not returned.
+func FetchMember(ctx context.Context, db sqlx.ExtContext, orgID string, userID string) (Member, error) {
+	in := struct {
+		OrgID  string `db:"org_id"`
+		UserID string `db:"user_id"`
+	}{
+		OrgID:  orgID,
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		org_members
+	WHERE
+		org_id = :org_id AND
+		user_id = :user_id`
+
+	var m Member
+	if err := database.NamedQueryStruct(ctx, db, q, in, &m); err != nil {
+		return Member{}, fmt.Errorf("fetching user[%s] in organization[%s]: %w", userID, orgID, err)
+	}
+
+	return m, nil
+}
+
+// FetchMembers returns all the members of an organization,
+// including the invited ones.
+func FetchMembers(ctx context.Context, db sqlx.ExtContext, orgID string) ([]Member, error) {
+	in := struct {
+		OrgID string `db:"org_id"`
+	}{
+		OrgID: orgID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		org_members
+	WHERE
+		org_id = :org_id
+	ORDER BY
+		email`
+
+	ms := []Member{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ms); err != nil {
+		return nil, fmt.Errorf("selecting members of organization[%s]: %w", orgID, err)
+	}
+
+	return ms, nil
+}
+
+// Join accepts the invitation sent to email on behalf of the passed user.
+func Join(ctx context.Context, db sqlx.ExtContext, orgID string, email string, userID string, now time.Time) (Member, error) {
+	in := struct {
+		OrgID    string    `db:"org_id"`
+		Email    string    `db:"email"`
+		UserID   string    `db:"user_id"`
+		JoinedAt time.Time `db:"joined_at"`
+	}{
+		OrgID:    orgID,
+		Email:    email,
+		UserID:   userID,
+		JoinedAt: now,
+	}
+
+	const q = `
+	UPDATE org_members
+	SET
+		user_id = :user_id,
+		joined_at = :joined_at
+	WHERE
+		org_id = :org_id AND
+		email = :email AND
+		user_id IS NULL
+	RETURNING *`
+
+	var m Member
+	if err := database.NamedQueryStruct(ctx, db, q, in, &m); err != nil {
+		return Member{}, fmt.Errorf("joining organization[%s] as %s: %w", orgID, email, err)
+	}
+
+	return m, nil
+}
+
+// AddSeats adds seats of a course to the ones owned by an organization.
+func AddSeats(ctx context.Context, db sqlx.ExtContext, orgID string, courseID string, quantity int, now time.Time) error {
+	in := struct {
+		OrgID    string    `db:"org_id"`
+		CourseID string    `db:"course_id"`
+		Quantity int       `db:"quantity"`
+		Now      time.Time `db:"now"`
+	}{
+		OrgID:    orgID,
+		CourseID: courseID,
+		Quantity: quantity,
+		Now:      now,
+	}
+
+	const q = `
+	INSERT INTO org_seats
+		(org_id, course_id, quantity, created_at, updated_at)
+	VALUES
+		(:org_id, :course_id, :quantity, :now, :now)
+	ON CONFLICT
+		(org_id, course_id)
+	DO UPDATE SET
+		quantity = org_seats.quantity + EXCLUDED.quantity,
+		updated_at = EXCLUDED.updated_at`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("adding %d seats of course[%s] to organization[%s]: %w", quantity, courseID, orgID, err)
+	}
+
+	return nil
+}
+
+// RemoveSeats takes back seats of a course from an organization.
+// If the seats left are not enough, the latest assignments are revoked.
+func RemoveSeats(ctx context.Context, db sqlx.ExtContext, orgID string, courseID string, quantity int, now time.Time) error {
+	in := struct {
+		OrgID    string    `db:"org_id"`
+		CourseID string    `db:"course_id"`
+		Quantity int       `db:"quantity"`
+		Now      time.Time `db:"now"`
+	}{
+		OrgID:    orgID,
+		CourseID: courseID,
+		Quantity: quantity,
+		Now:      now,
+	}
+
+	const q = `
+	UPDATE org_seats
+	SET
+		quantity = GREATEST(quantity - :quantity, 0),
+		updated_at = :now
+	WHERE
+		org_id = :org_id AND
+		course_id = :course_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("removing %d seats of course[%s] from organization[%s]: %w", quantity, courseID, orgID, err)
+	}
+
+	const r = `
+	DELETE FROM
+		seat_assignments
+	WHERE
+		(org_id, course_id, user_id) IN (
+			SELECT
+				a.org_id, a.course_id, a.user_id
+			FROM
+				seat_assignments AS a
+			WHERE
+				a.org_id = :org_id AND
+				a.course_id = :course_id
+			ORDER BY
+				a.assigned_at
+			OFFSET (
+				SELECT
+					s.quantity
+				FROM
+					org_seats AS s
+				WHERE
+					s.org_id = :org_id AND
+					s.course_id = :course_id
+			)
+		)`
+
+	if err := database.NamedExecContext(ctx, db, r, in); err != nil {
+		return fmt.Errorf("revoking exceeding seats of course[%s] of organization[%s]: %w", courseID, orgID, err)
+	}
+
+	return nil
+}
+
+// FetchSeats returns all the seats bought by an organization,
+// together with the number of assigned ones.
+func FetchSeats(ctx context.Context, db sqlx.ExtContext, orgID string) ([]Seats, error) {
+	in := struct {
+		OrgID string `db:"org_id"`
+	}{
+		OrgID: orgID,
+	}
+
+	const q = `
+	SELECT
+		s.*,
+		(
+			SELECT
+				COUNT(*)
+			FROM
+				seat_assignments AS a
+			WHERE
+				a.org_id = s.org_id AND
+				a.course_id = s.course_id
+		) AS assigned
+	FROM
+		org_seats AS s
+	WHERE
+		s.org_id = :org_id
+	ORDER BY
+		s.course_id`
+
+	seats := []Seats{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &seats); err != nil {
+		return nil, fmt.Errorf("selecting seats of organization[%s]: %w", orgID, err)
+	}
+
+	return seats, nil
+}
+
+// LockSeats returns the seats of a course bought by an organization,
+// locking them until the end of the transaction.
+func LockSeats(ctx context.Context, db sqlx.ExtContext, orgID string, courseID string) (Seats, error) {
+	in := struct {
+		OrgID    string `db:"org_id"`
+		CourseID string `db:"course_id"`
+	}{
+		OrgID:    orgID,
+		CourseID: courseID,
+	}
+
+	const q = `
+	SELECT
+		s.*,
+		0 AS assigned
+	FROM
+		org_seats AS s
+	WHERE
+		s.org_id = :org_id AND
+		s.course_id = :course_id
+	FOR UPDATE`
+
+	var seats Seats
+	if err := database.NamedQueryStruct(ctx, db, q, in, &seats); err != nil {
+		return Seats{}, fmt.Errorf("locking seats of course[%s] of organization[%s]: %w", courseID, orgID, err)
+	}
+
+	const c = `
+	SELECT
+		COUNT(*) AS assigned
+	FROM
+		seat_assignments
+	WHERE
+		org_id = :org_id AND
+		course_id = :course_id`
+
+	var out struct {
+		Assigned int `db:"assigned"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, c, in, &out); err != nil {
+		return Seats{}, fmt.Errorf("counting assigned seats of course[%s] of organization[%s]: %w", courseID, orgID, err)
+	}
+	seats.Assigned = out.Assigned
+
+	return seats, nil
+}
+
+// Assign assigns a seat to a member.
+func Assign(ctx context.Context, db sqlx.ExtContext, a Assignment) error {
+	const q = `
+	INSERT INTO seat_assignments
+		(org_id, course_id, user_id, assigned_at)
+	VALUES
+		(:org_id, :course_id, :user_id, :assigned_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, a); err != nil {
+		return fmt.Errorf("assigning seat of course[%s] to user[%s]: %w", a.CourseID, a.UserID, err)
+	}
+
+	return nil
+}
+
+// Revoke frees the seat assigned to a member.
+func Revoke(ctx context.Context, db sqlx.ExtContext, orgID string, courseID string, userID string) error {
+	in := struct {
+		OrgID    string `db:"org_id"`
+		CourseID string `db:"course_id"`
+		UserID   string `db:"user_id"`
+	}{
+		OrgID:    orgID,
+		CourseID: courseID,
+		UserID:   userID,
+	}
+
+	const q = `
+	DELETE FROM
+		seat_assignments
+	WHERE
+		org_id = :org_id AND
+		course_id = :course_id AND
+		user_id = :user_id
+	RETURNING user_id`
+
+	var out struct {
+		UserID string `db:"user_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("revoking seat of course[%s] from user[%s]: %w", courseID, userID, err)
+	}
+
+	return nil
+}
+
+// FetchProgress returns the progress of the members on the courses
+// whose seats have been assigned to them.
+func FetchProgress(ctx context.Context, db sqlx.ExtContext, orgID string) ([]Progress, error) {
+	in := struct {
+		OrgID string `db:"org_id"`
+	}{
+		OrgID: orgID,
+	}
+
+	const q = `
+	SELECT
+		a.user_id,
+		u.email,
+		a.course_id,
+		COALESCE(AVG(COALESCE(p.progress, 0)), 0) AS progress
+	FROM
+		seat_assignments AS a
+	INNER JOIN
+		users AS u ON u.user_id = a.user_id
+	LEFT JOIN
+		videos AS v ON v.course_id = a.course_id AND v.deleted_at IS NULL
+	LEFT JOIN
+		videos_progress AS p ON p.video_id = v.video_id AND p.user_id = a.user_id
+	WHERE
+		a.org_id = :org_id
+	GROUP BY
+		a.user_id, u.email, a.course_id
+	ORDER BY
+		u.email, a.course_id`
+
+	ps := []Progress{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ps); err != nil {
+		return nil, fmt.Errorf("selecting progress of organization[%s]: %w", orgID, err)
+	}
+
+	return ps, nil
+}
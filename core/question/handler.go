@@ -0,0 +1,379 @@
+package question
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// Limits of the pages of questions and replies.
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// HandleList returns the questions asked on a video, the most recent
+// first. Questions are paginated via the page and limit query
+// parameters.
+func HandleList(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		page, err := web.ParsePage(r, defaultLimit, maxLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		v, err := fetchVideo(ctx, db, videoID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkAccess(ctx, db, v.CourseID, clm); err != nil {
+			return err
+		}
+
+		qs, err := FetchByVideo(ctx, db, videoID, page.Limit, page.Offset())
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, qs, http.StatusOK)
+	}
+}
+
+// HandleCreate allows the owners of a course to ask a question on one
+// of its videos.
+func HandleCreate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var qn QuestionNew
+		if err := web.Decode(w, r, &qn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(qn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		v, err := fetchVideo(ctx, db, videoID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkAccess(ctx, db, v.CourseID, clm); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		qu := Question{
+			ID:        validate.GenerateID(),
+			VideoID:   videoID,
+			UserID:    clm.UserID,
+			Title:     qn.Title,
+			Body:      qn.Body,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		if err := Create(ctx, db, qu); err != nil {
+			return err
+		}
+
+		if qu, err = Fetch(ctx, db, qu.ID); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, qu, http.StatusCreated)
+	}
+}
+
+// HandleShow returns a question given its id.
+func HandleShow(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		qu, err := fetchQuestion(ctx, db, web.Param(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, qu, http.StatusOK)
+	}
+}
+
+// HandleUpdate allows the author of a question, or an administrator,
+// to edit it and to flag it as resolved.
+func HandleUpdate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		var qup QuestionUp
+		if err := web.Decode(w, r, &qup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(qup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		qu, err := fetchQuestion(ctx, db, web.Param(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		if !claims.IsUser(ctx, qu.UserID) && !claims.IsAdmin(ctx) {
+			err := fmt.Errorf("user[%s] is not the author of question[%s]", clm.UserID, qu.ID)
+			return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+		}
+
+		if qup.Title != nil {
+			qu.Title = *qup.Title
+		}
+		if qup.Body != nil {
+			qu.Body = *qup.Body
+		}
+		if qup.Resolved != nil {
+			qu.Resolved = *qup.Resolved
+		}
+		qu.UpdatedAt = time.Now().UTC()
+
+		// Titles and bodies can be edited but not emptied.
+		if qu.Title == "" || qu.Body == "" {
+			err := errors.New("title and body are required")
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := Update(ctx, db, qu); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, qu, http.StatusOK)
+	}
+}
+
+// HandleListReplies returns the replies to a question, in the order
+// they were posted. Replies are paginated via the page and limit query
+// parameters.
+func HandleListReplies(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		page, err := web.ParsePage(r, defaultLimit, maxLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		qu, err := fetchQuestion(ctx, db, web.Param(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		rs, err := FetchReplies(ctx, db, qu.ID, page.Limit, page.Offset())
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, rs, http.StatusOK)
+	}
+}
+
+// HandleCreateReply allows administrators, and the instructor of the
+// course, to reply to a question.
+func HandleCreateReply(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		var ru ReplyUp
+		if err := web.Decode(w, r, &ru); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(ru); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		qu, err := fetchQuestion(ctx, db, web.Param(r, "id"))
+		if err != nil {
+			return err
+		}
+
+		if err := course.CheckManager(ctx, db, qu.CourseID); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		rep := Reply{
+			ID:         validate.GenerateID(),
+			QuestionID: qu.ID,
+			UserID:     clm.UserID,
+			Body:       ru.Body,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+
+		if err := CreateReply(ctx, db, rep); err != nil {
+			return err
+		}
+
+		if rep, err = FetchReply(ctx, db, rep.ID); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, rep, http.StatusCreated)
+	}
+}
+
+// HandleUpdateReply allows administrators, and the instructor of the
+// course, to edit a reply.
+func HandleUpdateReply(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		repID := web.Param(r, "id")
+
+		if err := validate.CheckID(repID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var ru ReplyUp
+		if err := web.Decode(w, r, &ru); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(ru); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		rep, err := FetchReply(ctx, db, repID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		qu, err := fetchQuestion(ctx, db, rep.QuestionID)
+		if err != nil {
+			return err
+		}
+
+		if err := course.CheckManager(ctx, db, qu.CourseID); err != nil {
+			return err
+		}
+
+		rep.Body = ru.Body
+		rep.UpdatedAt = time.Now().UTC()
+
+		if err := UpdateReply(ctx, db, rep); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, rep, http.StatusOK)
+	}
+}
+
+// fetchVideo returns the video questions are asked on, drafts being
+// found by administrators only.
+func fetchVideo(ctx context.Context, db sqlx.ExtContext, videoID string) (video.Video, error) {
+	v, err := video.Fetch(ctx, db, videoID)
+	if err != nil {
+		err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return video.Video{}, weberr.NotFound(err)
+		}
+		return video.Video{}, err
+	}
+
+	if !v.Published && !claims.IsAdmin(ctx) {
+		return video.Video{}, weberr.NotFound(fmt.Errorf("video[%s] is a draft", v.ID))
+	}
+
+	return v, nil
+}
+
+// fetchQuestion returns the question of the passed id when the user
+// can take part in the discussions of its course.
+func fetchQuestion(ctx context.Context, db sqlx.ExtContext, id string) (Question, error) {
+	clm, err := claims.Get(ctx)
+	if err != nil {
+		return Question{}, weberr.NotAuthorized(errors.New("user not authenticated"))
+	}
+
+	if err := validate.CheckID(id); err != nil {
+		return Question{}, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	qu, err := Fetch(ctx, db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Question{}, weberr.NotFound(err)
+		}
+		return Question{}, err
+	}
+
+	if err := checkAccess(ctx, db, qu.CourseID, clm); err != nil {
+		return Question{}, err
+	}
+
+	return qu, nil
+}
+
+// checkAccess verifies that the user owns the course, administrators
+// take part in the discussions of every course and instructors in the
+// ones of the courses they teach.
+func checkAccess(ctx context.Context, db sqlx.ExtContext, courseID string, clm claims.Claims) error {
+	if claims.IsAdmin(ctx) {
+		return nil
+	}
+
+	if clm.Role == claims.RoleInstructor {
+		c, err := course.Fetch(ctx, db, courseID)
+		if err != nil {
+			return fmt.Errorf("fetching course[%s]: %w", courseID, err)
+		}
+		if course.CanManage(ctx, c) {
+			return nil
+		}
+	}
+
+	if _, err := course.FetchOwned(ctx, db, courseID, clm.UserID); err != nil {
+		err := fmt.Errorf("fetching course[%s] owned by user[%s]: %w", courseID, clm.UserID, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+		}
+		return err
+	}
+
+	return nil
+}
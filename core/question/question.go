@@ -0,0 +1,50 @@
+// Package question manages the discussions on the videos: owners of
+// the course ask questions which administrators reply to.
+package question
+
+import "time"
+
+// Question models a question asked on a video. It's resolved once the
+// author, or an administrator, is satisfied with the replies.
+type Question struct {
+	ID        string    `json:"id" db:"question_id"`
+	VideoID   string    `json:"videoId" db:"video_id"`
+	CourseID  string    `json:"courseId" db:"course_id"`
+	UserID    string    `json:"userId" db:"user_id"`
+	UserName  string    `json:"userName" db:"user_name"`
+	Title     string    `json:"title" db:"title"`
+	Body      string    `json:"body" db:"body"`
+	Resolved  bool      `json:"resolved" db:"resolved"`
+	Replies   int       `json:"replies" db:"replies"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// QuestionNew contains the information needed to ask a question.
+type QuestionNew struct {
+	Title string `json:"title" validate:"required,max=200"`
+	Body  string `json:"body" validate:"required,max=10000"`
+}
+
+// QuestionUp defines what a question update can edit.
+type QuestionUp struct {
+	Title    *string `json:"title" validate:"omitempty,max=200"`
+	Body     *string `json:"body" validate:"omitempty,max=10000"`
+	Resolved *bool   `json:"resolved"`
+}
+
+// Reply models the reply to a question.
+type Reply struct {
+	ID         string    `json:"id" db:"reply_id"`
+	QuestionID string    `json:"questionId" db:"question_id"`
+	UserID     string    `json:"userId" db:"user_id"`
+	UserName   string    `json:"userName" db:"user_name"`
+	Body       string    `json:"body" db:"body"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// ReplyUp contains the body of a new or edited reply.
+type ReplyUp struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
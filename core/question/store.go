@@ -0,0 +1,212 @@
+package question
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Create inserts a new question.
+func Create(ctx context.Context, db sqlx.ExtContext, qu Question) error {
+	const q = `
+	INSERT INTO questions
+		(question_id, video_id, user_id, title, body, resolved, created_at, updated_at)
+	VALUES
+		(:question_id, :video_id, :user_id, :title, :body, :resolved, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, qu); err != nil {
+		return fmt.Errorf("inserting question on video[%s]: %w", qu.VideoID, err)
+	}
+
+	return nil
+}
+
+// Update modifies the title, the body and the resolved flag of a
+// question.
+func Update(ctx context.Context, db sqlx.ExtContext, qu Question) error {
+	const q = `
+	UPDATE questions
+	SET
+		title = :title,
+		body = :body,
+		resolved = :resolved,
+		updated_at = :updated_at
+	WHERE
+		question_id = :question_id`
+
+	if err := database.NamedExecContext(ctx, db, q, qu); err != nil {
+		return fmt.Errorf("updating question[%s]: %w", qu.ID, err)
+	}
+
+	return nil
+}
+
+// Fetch returns a question given its id, with the course of its video,
+// the name of its author and its number of replies.
+func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Question, error) {
+	in := struct {
+		ID string `db:"question_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		q.*,
+		v.course_id,
+		u.name AS user_name,
+		(SELECT COUNT(*) FROM question_replies AS r WHERE r.question_id = q.question_id) AS replies
+	FROM
+		questions AS q
+	INNER JOIN
+		videos AS v ON v.video_id = q.video_id
+	INNER JOIN
+		users AS u ON u.user_id = q.user_id
+	WHERE
+		q.question_id = :question_id AND
+		v.deleted_at IS NULL`
+
+	var qu Question
+	if err := database.NamedQueryStruct(ctx, db, q, in, &qu); err != nil {
+		return Question{}, fmt.Errorf("selecting question[%s]: %w", id, err)
+	}
+
+	return qu, nil
+}
+
+// FetchByVideo returns the questions asked on a video, the most recent
+// first.
+func FetchByVideo(ctx context.Context, db sqlx.ExtContext, videoID string, limit int, offset int) ([]Question, error) {
+	in := struct {
+		VideoID string `db:"video_id"`
+		Limit   int    `db:"limit"`
+		Offset  int    `db:"offset"`
+	}{
+		VideoID: videoID,
+		Limit:   limit,
+		Offset:  offset,
+	}
+
+	const q = `
+	SELECT
+		q.*,
+		v.course_id,
+		u.name AS user_name,
+		(SELECT COUNT(*) FROM question_replies AS r WHERE r.question_id = q.question_id) AS replies
+	FROM
+		questions AS q
+	INNER JOIN
+		videos AS v ON v.video_id = q.video_id
+	INNER JOIN
+		users AS u ON u.user_id = q.user_id
+	WHERE
+		q.video_id = :video_id
+	ORDER BY
+		q.created_at DESC, q.question_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	qs := []Question{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &qs); err != nil {
+		return nil, fmt.Errorf("selecting questions on video[%s]: %w", videoID, err)
+	}
+
+	return qs, nil
+}
+
+// CreateReply inserts a new reply.
+func CreateReply(ctx context.Context, db sqlx.ExtContext, rep Reply) error {
+	const q = `
+	INSERT INTO question_replies
+		(reply_id, question_id, user_id, body, created_at, updated_at)
+	VALUES
+		(:reply_id, :question_id, :user_id, :body, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, rep); err != nil {
+		return fmt.Errorf("inserting reply to question[%s]: %w", rep.QuestionID, err)
+	}
+
+	return nil
+}
+
+// UpdateReply modifies the body of a reply.
+func UpdateReply(ctx context.Context, db sqlx.ExtContext, rep Reply) error {
+	const q = `
+	UPDATE question_replies
+	SET
+		body = :body,
+		updated_at = :updated_at
+	WHERE
+		reply_id = :reply_id`
+
+	if err := database.NamedExecContext(ctx, db, q, rep); err != nil {
+		return fmt.Errorf("updating reply[%s]: %w", rep.ID, err)
+	}
+
+	return nil
+}
+
+// FetchReply returns a reply given its id, with the name of its author.
+func FetchReply(ctx context.Context, db sqlx.ExtContext, id string) (Reply, error) {
+	in := struct {
+		ID string `db:"reply_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		r.*,
+		u.name AS user_name
+	FROM
+		question_replies AS r
+	INNER JOIN
+		users AS u ON u.user_id = r.user_id
+	WHERE
+		r.reply_id = :reply_id`
+
+	var rep Reply
+	if err := database.NamedQueryStruct(ctx, db, q, in, &rep); err != nil {
+		return Reply{}, fmt.Errorf("selecting reply[%s]: %w", id, err)
+	}
+
+	return rep, nil
+}
+
+// FetchReplies returns the replies to a question, in the order they
+// were posted.
+func FetchReplies(ctx context.Context, db sqlx.ExtContext, questionID string, limit int, offset int) ([]Reply, error) {
+	in := struct {
+		QuestionID string `db:"question_id"`
+		Limit      int    `db:"limit"`
+		Offset     int    `db:"offset"`
+	}{
+		QuestionID: questionID,
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	const q = `
+	SELECT
+		r.*,
+		u.name AS user_name
+	FROM
+		question_replies AS r
+	INNER JOIN
+		users AS u ON u.user_id = r.user_id
+	WHERE
+		r.question_id = :question_id
+	ORDER BY
+		r.created_at, r.reply_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	rs := []Reply{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rs); err != nil {
+		return nil, fmt.Errorf("selecting replies to question[%s]: %w", questionID, err)
+	}
+
+	return rs, nil
+}
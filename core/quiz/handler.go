@@ -0,0 +1,321 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandlePut allows administrators to attach a quiz to a video, or to
+// replace its content. The choices of the previous questions are lost,
+// while the scores of the previous attempts are kept.
+func HandlePut(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var qup QuizUp
+		if err := web.Decode(w, r, &qup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(qup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if _, err := video.Fetch(ctx, db, videoID); err != nil {
+			err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		now := time.Now().UTC()
+		qz := Quiz{
+			ID:        validate.GenerateID(),
+			VideoID:   videoID,
+			Title:     qup.Title,
+			PassScore: qup.PassScore,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		qs := make([]Question, len(qup.Questions))
+		for i, qu := range qup.Questions {
+			qs[i] = Question{
+				ID:       validate.GenerateID(),
+				Position: i + 1,
+				Text:     qu.Text,
+				Choices:  make([]Choice, len(qu.Choices)),
+			}
+
+			correct := false
+			for j, ch := range qu.Choices {
+				qs[i].Choices[j] = Choice{
+					ID:         validate.GenerateID(),
+					QuestionID: qs[i].ID,
+					Position:   j + 1,
+					Text:       ch.Text,
+					Correct:    ch.Correct,
+				}
+				correct = correct || ch.Correct
+			}
+
+			if !correct {
+				err := fmt.Errorf("question %d has no correct choice", i+1)
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+		}
+
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			var err error
+			if qz, err = Upsert(ctx, tx, qz); err != nil {
+				return err
+			}
+
+			for i := range qs {
+				qs[i].QuizID = qz.ID
+			}
+
+			return ReplaceQuestions(ctx, tx, qz.ID, qs)
+		})
+		if err != nil {
+			return err
+		}
+		qz.Questions = qs
+
+		return web.Respond(ctx, w, qz, http.StatusOK)
+	}
+}
+
+// HandleDelete allows administrators to remove the quiz of a video,
+// with all its attempts. Deleting a missing quiz succeeds.
+func HandleDelete(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := DeleteByVideo(ctx, db, videoID); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleShow returns the quiz of a video to the owners of its course.
+// The correct choices are revealed to administrators only.
+func HandleShow(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		qz, err := fetchQuiz(ctx, db, web.Param(r, "video_id"))
+		if err != nil {
+			return err
+		}
+
+		if !claims.IsAdmin(ctx) {
+			hideCorrect(qz.Questions)
+		}
+
+		return web.Respond(ctx, w, qz, http.StatusOK)
+	}
+}
+
+// HandleCreateAttempt grades the answers of the user to the quiz of a
+// video and records the attempt. Users can attempt a quiz many times.
+func HandleCreateAttempt(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		var an AttemptNew
+		if err := web.Decode(w, r, &an); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(an); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		qz, err := fetchQuiz(ctx, db, web.Param(r, "video_id"))
+		if err != nil {
+			return err
+		}
+
+		results, choiceIDs, err := grade(qz.Questions, an.Answers)
+		if err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		a := Attempt{
+			ID:        validate.GenerateID(),
+			QuizID:    qz.ID,
+			UserID:    clm.UserID,
+			Total:     len(results),
+			Results:   results,
+			CreatedAt: time.Now().UTC(),
+		}
+		for _, res := range results {
+			if res.Correct {
+				a.Correct++
+			}
+		}
+		if a.Total > 0 {
+			a.Score = a.Correct * 100 / a.Total
+		}
+		a.Passed = a.Score >= qz.PassScore
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			return CreateAttempt(ctx, tx, a, choiceIDs)
+		})
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, a, http.StatusCreated)
+	}
+}
+
+// HandleListAttempts returns the attempts of the user on the quiz of a
+// video, the most recent first.
+func HandleListAttempts(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		qz, err := fetchQuiz(ctx, db, web.Param(r, "video_id"))
+		if err != nil {
+			return err
+		}
+
+		as, err := FetchAttempts(ctx, db, qz.ID, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, as, http.StatusOK)
+	}
+}
+
+// grade checks the answers against the questions of a quiz. A question
+// is answered correctly when all and only its correct choices are
+// selected. It returns the result of each question, in order, and the
+// choices selected.
+func grade(qs []Question, answers []Answer) ([]Result, []string, error) {
+	selected := make(map[string]map[string]bool, len(answers))
+	for _, an := range answers {
+		if _, ok := selected[an.QuestionID]; ok {
+			return nil, nil, fmt.Errorf("question[%s] answered twice", an.QuestionID)
+		}
+		selected[an.QuestionID] = make(map[string]bool, len(an.ChoiceIDs))
+		for _, id := range an.ChoiceIDs {
+			selected[an.QuestionID][id] = true
+		}
+	}
+
+	results := make([]Result, len(qs))
+	var choiceIDs []string
+	for i, qu := range qs {
+		sel := selected[qu.ID]
+		delete(selected, qu.ID)
+
+		correct, matched := true, 0
+		for _, ch := range qu.Choices {
+			if sel[ch.ID] {
+				choiceIDs = append(choiceIDs, ch.ID)
+				matched++
+			}
+			if sel[ch.ID] != ch.Correct {
+				correct = false
+			}
+		}
+
+		if matched != len(sel) {
+			return nil, nil, fmt.Errorf("choices of question[%s] not found", qu.ID)
+		}
+
+		results[i] = Result{QuestionID: qu.ID, Correct: correct}
+	}
+
+	if len(selected) > 0 {
+		return nil, nil, fmt.Errorf("%d answered questions not found", len(selected))
+	}
+
+	return results, choiceIDs, nil
+}
+
+// hideCorrect clears the correct choices of the questions.
+func hideCorrect(qs []Question) {
+	for i := range qs {
+		for j := range qs[i].Choices {
+			qs[i].Choices[j].Correct = false
+		}
+	}
+}
+
+// fetchQuiz returns the quiz of the video of the passed id when the
+// user owns its course. Drafts are found by administrators only.
+func fetchQuiz(ctx context.Context, db sqlx.ExtContext, videoID string) (Quiz, error) {
+	clm, err := claims.Get(ctx)
+	if err != nil {
+		return Quiz{}, weberr.NotAuthorized(errors.New("user not authenticated"))
+	}
+
+	if err := validate.CheckID(videoID); err != nil {
+		return Quiz{}, weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	v, err := video.Fetch(ctx, db, videoID)
+	if err != nil {
+		err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Quiz{}, weberr.NotFound(err)
+		}
+		return Quiz{}, err
+	}
+
+	if !v.Published && !claims.IsAdmin(ctx) {
+		return Quiz{}, weberr.NotFound(fmt.Errorf("video[%s] is a draft", v.ID))
+	}
+
+	if !claims.IsAdmin(ctx) {
+		if _, err := course.FetchOwned(ctx, db, v.CourseID, clm.UserID); err != nil {
+			err := fmt.Errorf("fetching course[%s] owned by user[%s]: %w", v.CourseID, clm.UserID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return Quiz{}, weberr.NewError(err, "access forbidden", http.StatusForbidden)
+			}
+			return Quiz{}, err
+		}
+	}
+
+	qz, err := FetchByVideo(ctx, db, videoID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Quiz{}, weberr.NotFound(err)
+		}
+		return Quiz{}, err
+	}
+
+	return qz, nil
+}
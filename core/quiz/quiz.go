@@ -0,0 +1,90 @@
+// Package quiz manages the quizzes attached to videos: multiple-choice
+// questions which the owners of the course answer to check what they
+// learnt. Attempts are graded by the server.
+package quiz
+
+import "time"
+
+// Quiz models the quiz of a video. It's passed by answering correctly
+// at least PassScore percent of its questions.
+type Quiz struct {
+	ID        string     `json:"id" db:"quiz_id"`
+	VideoID   string     `json:"videoId" db:"video_id"`
+	Title     string     `json:"title" db:"title"`
+	PassScore int        `json:"passScore" db:"pass_score"`
+	Questions []Question `json:"questions" db:"-"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Question models a question of a quiz. It's answered correctly by
+// selecting all and only its correct choices.
+type Question struct {
+	ID       string   `json:"id" db:"question_id"`
+	QuizID   string   `json:"-" db:"quiz_id"`
+	Position int      `json:"position" db:"position"`
+	Text     string   `json:"text" db:"text"`
+	Choices  []Choice `json:"choices" db:"-"`
+}
+
+// Choice models a possible answer to a question.
+// Correct is hidden to those taking the quiz.
+type Choice struct {
+	ID         string `json:"id" db:"choice_id"`
+	QuestionID string `json:"-" db:"question_id"`
+	Position   int    `json:"position" db:"position"`
+	Text       string `json:"text" db:"text"`
+	Correct    bool   `json:"correct,omitempty" db:"correct"`
+}
+
+// QuizUp contains the whole content of a quiz, which replaces the
+// previous one.
+type QuizUp struct {
+	Title     string       `json:"title" validate:"required,max=200"`
+	PassScore int          `json:"passScore" validate:"gte=1,lte=100"`
+	Questions []QuestionUp `json:"questions" validate:"required,min=1,max=100,dive"`
+}
+
+// QuestionUp contains a question of a quiz, with its choices in order.
+type QuestionUp struct {
+	Text    string     `json:"text" validate:"required,max=1000"`
+	Choices []ChoiceUp `json:"choices" validate:"required,min=2,max=10,dive"`
+}
+
+// ChoiceUp contains a possible answer to a question.
+type ChoiceUp struct {
+	Text    string `json:"text" validate:"required,max=500"`
+	Correct bool   `json:"correct"`
+}
+
+// Attempt models a user taking a quiz. Score is the percentage of
+// questions answered correctly.
+type Attempt struct {
+	ID        string    `json:"id" db:"attempt_id"`
+	QuizID    string    `json:"quizId" db:"quiz_id"`
+	UserID    string    `json:"userId" db:"user_id"`
+	Correct   int       `json:"correct" db:"correct"`
+	Total     int       `json:"total" db:"total"`
+	Score     int       `json:"score" db:"score"`
+	Passed    bool      `json:"passed" db:"passed"`
+	Results   []Result  `json:"results,omitempty" db:"-"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Result tells whether a question was answered correctly in an attempt.
+type Result struct {
+	QuestionID string `json:"questionId"`
+	Correct    bool   `json:"correct"`
+}
+
+// Answer contains the choices selected for a question.
+type Answer struct {
+	QuestionID string   `json:"questionId" validate:"required"`
+	ChoiceIDs  []string `json:"choiceIds" validate:"max=10,dive,required"`
+}
+
+// AttemptNew contains the answers of an attempt. Questions left
+// unanswered are wrong.
+type AttemptNew struct {
+	Answers []Answer `json:"answers" validate:"max=100,dive"`
+}
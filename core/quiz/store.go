@@ -0,0 +1,249 @@
+package quiz
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Upsert inserts the quiz of a video, or updates its title and pass
+// score when the video has one already. It returns the stored quiz,
+// without its questions.
+func Upsert(ctx context.Context, db sqlx.ExtContext, qz Quiz) (Quiz, error) {
+	const q = `
+	INSERT INTO quizzes
+		(quiz_id, video_id, title, pass_score, created_at, updated_at)
+	VALUES
+		(:quiz_id, :video_id, :title, :pass_score, :created_at, :updated_at)
+	ON CONFLICT
+		(video_id)
+	DO UPDATE SET
+		title = EXCLUDED.title,
+		pass_score = EXCLUDED.pass_score,
+		updated_at = EXCLUDED.updated_at
+	RETURNING *`
+
+	var out Quiz
+	if err := database.NamedQueryStruct(ctx, db, q, qz, &out); err != nil {
+		return Quiz{}, fmt.Errorf("upserting quiz of video[%s]: %w", qz.VideoID, err)
+	}
+
+	return out, nil
+}
+
+// ReplaceQuestions replaces all the questions of a quiz, with their
+// choices, with the passed ones.
+func ReplaceQuestions(ctx context.Context, db sqlx.ExtContext, quizID string, qs []Question) error {
+	in := struct {
+		ID string `db:"quiz_id"`
+	}{
+		ID: quizID,
+	}
+
+	const del = `
+	DELETE FROM
+		quiz_questions
+	WHERE
+		quiz_id = :quiz_id`
+
+	if err := database.NamedExecContext(ctx, db, del, in); err != nil {
+		return fmt.Errorf("deleting questions of quiz[%s]: %w", quizID, err)
+	}
+
+	const insQuestion = `
+	INSERT INTO quiz_questions
+		(question_id, quiz_id, position, text)
+	VALUES
+		(:question_id, :quiz_id, :position, :text)`
+
+	const insChoice = `
+	INSERT INTO quiz_choices
+		(choice_id, question_id, position, text, correct)
+	VALUES
+		(:choice_id, :question_id, :position, :text, :correct)`
+
+	for _, qu := range qs {
+		if err := database.NamedExecContext(ctx, db, insQuestion, qu); err != nil {
+			return fmt.Errorf("inserting question %d of quiz[%s]: %w", qu.Position, quizID, err)
+		}
+		for _, ch := range qu.Choices {
+			if err := database.NamedExecContext(ctx, db, insChoice, ch); err != nil {
+				return fmt.Errorf("inserting choice %d of question[%s]: %w", ch.Position, qu.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// FetchByVideo returns the quiz of a video, with its questions and
+// their choices in order.
+func FetchByVideo(ctx context.Context, db sqlx.ExtContext, videoID string) (Quiz, error) {
+	in := struct {
+		VideoID string `db:"video_id"`
+	}{
+		VideoID: videoID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		quizzes
+	WHERE
+		video_id = :video_id`
+
+	var qz Quiz
+	if err := database.NamedQueryStruct(ctx, db, q, in, &qz); err != nil {
+		return Quiz{}, fmt.Errorf("selecting quiz of video[%s]: %w", videoID, err)
+	}
+
+	qs, err := fetchQuestions(ctx, db, qz.ID)
+	if err != nil {
+		return Quiz{}, err
+	}
+	qz.Questions = qs
+
+	return qz, nil
+}
+
+// fetchQuestions returns the questions of a quiz with their choices.
+func fetchQuestions(ctx context.Context, db sqlx.ExtContext, quizID string) ([]Question, error) {
+	in := struct {
+		ID string `db:"quiz_id"`
+	}{
+		ID: quizID,
+	}
+
+	const qq = `
+	SELECT
+		*
+	FROM
+		quiz_questions
+	WHERE
+		quiz_id = :quiz_id
+	ORDER BY
+		position`
+
+	qs := []Question{}
+	if err := database.NamedQuerySlice(ctx, db, qq, in, &qs); err != nil {
+		return nil, fmt.Errorf("selecting questions of quiz[%s]: %w", quizID, err)
+	}
+
+	const qc = `
+	SELECT
+		c.*
+	FROM
+		quiz_choices AS c
+	INNER JOIN
+		quiz_questions AS q ON q.question_id = c.question_id
+	WHERE
+		q.quiz_id = :quiz_id
+	ORDER BY
+		c.position`
+
+	chs := []Choice{}
+	if err := database.NamedQuerySlice(ctx, db, qc, in, &chs); err != nil {
+		return nil, fmt.Errorf("selecting choices of quiz[%s]: %w", quizID, err)
+	}
+
+	index := make(map[string]int, len(qs))
+	for i := range qs {
+		qs[i].Choices = []Choice{}
+		index[qs[i].ID] = i
+	}
+	for _, ch := range chs {
+		i := index[ch.QuestionID]
+		qs[i].Choices = append(qs[i].Choices, ch)
+	}
+
+	return qs, nil
+}
+
+// DeleteByVideo deletes the quiz of a video with all its attempts.
+func DeleteByVideo(ctx context.Context, db sqlx.ExtContext, videoID string) error {
+	in := struct {
+		VideoID string `db:"video_id"`
+	}{
+		VideoID: videoID,
+	}
+
+	const q = `
+	DELETE FROM
+		quizzes
+	WHERE
+		video_id = :video_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("deleting quiz of video[%s]: %w", videoID, err)
+	}
+
+	return nil
+}
+
+// CreateAttempt inserts a graded attempt with the choices selected.
+func CreateAttempt(ctx context.Context, db sqlx.ExtContext, a Attempt, choiceIDs []string) error {
+	const q = `
+	INSERT INTO quiz_attempts
+		(attempt_id, quiz_id, user_id, correct, total, score, passed, created_at)
+	VALUES
+		(:attempt_id, :quiz_id, :user_id, :correct, :total, :score, :passed, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, a); err != nil {
+		return fmt.Errorf("inserting attempt of user[%s] on quiz[%s]: %w", a.UserID, a.QuizID, err)
+	}
+
+	const ins = `
+	INSERT INTO quiz_attempt_answers
+		(attempt_id, choice_id)
+	VALUES
+		(:attempt_id, :choice_id)`
+
+	for _, id := range choiceIDs {
+		in := struct {
+			AttemptID string `db:"attempt_id"`
+			ChoiceID  string `db:"choice_id"`
+		}{
+			AttemptID: a.ID,
+			ChoiceID:  id,
+		}
+
+		if err := database.NamedExecContext(ctx, db, ins, in); err != nil {
+			return fmt.Errorf("inserting choice[%s] of attempt[%s]: %w", id, a.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// FetchAttempts returns the attempts of a user on a quiz, the most
+// recent first.
+func FetchAttempts(ctx context.Context, db sqlx.ExtContext, quizID string, userID string) ([]Attempt, error) {
+	in := struct {
+		QuizID string `db:"quiz_id"`
+		UserID string `db:"user_id"`
+	}{
+		QuizID: quizID,
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		quiz_attempts
+	WHERE
+		quiz_id = :quiz_id AND
+		user_id = :user_id
+	ORDER BY
+		created_at DESC`
+
+	as := []Attempt{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &as); err != nil {
+		return nil, fmt.Errorf("selecting attempts of user[%s] on quiz[%s]: %w", userID, quizID, err)
+	}
+
+	return as, nil
+}
@@ -0,0 +1,231 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/storage"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxMemory is how much of the uploaded files is kept in memory,
+// the rest is written to temporary files.
+const maxMemory = 8 << 20
+
+// HandleCreate allows administrators to attach a resource to a course,
+// or to one of its videos. The resource is sent as a multipart form
+// with its name, the videoId and either a file or the url of a link.
+func HandleCreate(db *sqlx.DB, cfg config.Resources, st storage.Storage) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		// Leave room for the other fields of the form.
+		r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxSize+1<<20)
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				err := fmt.Errorf("files can't be larger than %d bytes", cfg.MaxSize)
+				return weberr.NewError(err, err.Error(), http.StatusRequestEntityTooLarge)
+			}
+			return weberr.BadRequest(fmt.Errorf("unable to parse form: %w", err))
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		in := ResourceNew{
+			Name: r.FormValue("name"),
+			URL:  r.FormValue("url"),
+		}
+		if v := r.FormValue("videoId"); v != "" {
+			in.VideoID = &v
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if _, err := course.Fetch(ctx, db, courseID); err != nil {
+			err := fmt.Errorf("fetching course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if in.VideoID != nil {
+			v, err := video.Fetch(ctx, db, *in.VideoID)
+			if err != nil && !errors.Is(err, database.ErrDBNotFound) {
+				return fmt.Errorf("fetching video[%s]: %w", *in.VideoID, err)
+			}
+			if err != nil || v.CourseID != courseID {
+				err := fmt.Errorf("video[%s] is not part of course[%s]", *in.VideoID, courseID)
+				return weberr.NewError(err, "video not found in the course", http.StatusUnprocessableEntity)
+			}
+		}
+
+		now := time.Now().UTC()
+		res := Resource{
+			ID:        validate.GenerateID(),
+			CourseID:  courseID,
+			VideoID:   in.VideoID,
+			Name:      in.Name,
+			Kind:      KindLink,
+			URL:       in.URL,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		f, hdr, err := r.FormFile("file")
+		switch {
+		case err == nil:
+			defer f.Close()
+
+			if in.URL != "" {
+				err := errors.New("resources are either a file or a link")
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+
+			// The file is stored under its name, so it can't be a path.
+			if hdr.Filename == "" || strings.ContainsAny(hdr.Filename, `/\`) || hdr.Filename == "." || hdr.Filename == ".." {
+				err := fmt.Errorf("invalid filename %q", hdr.Filename)
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+
+			res.Kind = KindFile
+			res.Filename = hdr.Filename
+			res.ContentType = hdr.Header.Get("Content-Type")
+			res.Size = hdr.Size
+			res.Key = "resources/" + res.ID + "/" + hdr.Filename
+			if res.ContentType == "" {
+				res.ContentType = "application/octet-stream"
+			}
+
+			if err := st.Put(ctx, res.Key, f, res.Size, res.ContentType); err != nil {
+				return fmt.Errorf("storing resource[%s]: %w", res.ID, err)
+			}
+		case errors.Is(err, http.ErrMissingFile):
+			if in.URL == "" {
+				err := errors.New("either a file or a url is required")
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+		default:
+			return weberr.BadRequest(fmt.Errorf("unable to read file: %w", err))
+		}
+
+		if err := Create(ctx, db, res); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, res, http.StatusCreated)
+	}
+}
+
+// HandleList returns the resources of a course and of its videos.
+// Where to download them isn't returned, so it can be safely exposed.
+func HandleList(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		rs, err := FetchByCourse(ctx, db, courseID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, rs, http.StatusOK)
+	}
+}
+
+// HandleDownload returns where to download a resource. Files are served
+// through signed URLs expiring after the configured time, so that only
+// the owners of the course and administrators can download them.
+func HandleDownload(db *sqlx.DB, cfg config.Resources, st storage.Storage) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		resID := web.Param(r, "id")
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if err := validate.CheckID(resID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		res, err := Fetch(ctx, db, resID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if !claims.IsAdmin(ctx) {
+			if _, err := course.FetchOwned(ctx, db, res.CourseID, clm.UserID); err != nil {
+				err := fmt.Errorf("fetching course[%s] owned by user[%s]: %w", res.CourseID, clm.UserID, err)
+				if errors.Is(err, database.ErrDBNotFound) {
+					return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+				}
+				return err
+			}
+		}
+
+		if res.Kind == KindLink {
+			return web.Respond(ctx, w, Download{URL: res.URL}, http.StatusOK)
+		}
+
+		u, err := st.SignedURL(res.Key, cfg.LinkTTL)
+		if err != nil {
+			return fmt.Errorf("signing url of resource[%s]: %w", res.ID, err)
+		}
+		exp := time.Now().UTC().Add(cfg.LinkTTL)
+
+		return web.Respond(ctx, w, Download{URL: u, ExpiresAt: &exp}, http.StatusOK)
+	}
+}
+
+// HandleDelete allows administrators to remove a resource, along with
+// its file.
+func HandleDelete(db *sqlx.DB, st storage.Storage) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		resID := web.Param(r, "id")
+
+		if err := validate.CheckID(resID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		res, err := Delete(ctx, db, resID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if res.Kind == KindFile {
+			if err := st.Delete(ctx, res.Key); err != nil {
+				return fmt.Errorf("deleting file of resource[%s]: %w", res.ID, err)
+			}
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,46 @@
+// Package resource manages the material attached to courses and videos,
+// e.g. slides, exercises or links, which only owners of the course can
+// download.
+package resource
+
+import "time"
+
+// Kinds of resources.
+const (
+	KindFile = "file"
+	KindLink = "link"
+)
+
+// Resource models a file or a link attached to a course, or to one of
+// its videos when VideoID is set. Files are stored under Key, links
+// point to URL: neither is marshalled to JSON, owners get them via
+// a Download.
+type Resource struct {
+	ID          string    `json:"id" db:"resource_id"`
+	CourseID    string    `json:"courseId" db:"course_id"`
+	VideoID     *string   `json:"videoId,omitempty" db:"video_id"`
+	Name        string    `json:"name" db:"name"`
+	Kind        string    `json:"kind" db:"kind"`
+	Filename    string    `json:"filename" db:"filename"`
+	ContentType string    `json:"contentType" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	Key         string    `json:"-" db:"storage_key"`
+	URL         string    `json:"-" db:"url"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// ResourceNew contains the information needed to attach a resource,
+// sent along with the file, or with the URL of a link.
+type ResourceNew struct {
+	Name    string  `validate:"required,max=200"`
+	VideoID *string `validate:"omitempty,uuid4"`
+	URL     string  `validate:"omitempty,url,startswith=http"`
+}
+
+// Download tells where to download a resource. The URL of files
+// expires at ExpiresAt.
+type Download struct {
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
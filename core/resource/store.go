@@ -0,0 +1,98 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Create inserts a new resource.
+func Create(ctx context.Context, db sqlx.ExtContext, res Resource) error {
+	const q = `
+	INSERT INTO resources
+		(resource_id, course_id, video_id, name, kind, filename, content_type, size, storage_key, url, created_at, updated_at)
+	VALUES
+		(:resource_id, :course_id, :video_id, :name, :kind, :filename, :content_type, :size, :storage_key, :url, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, res); err != nil {
+		return fmt.Errorf("inserting resource of course[%s]: %w", res.CourseID, err)
+	}
+
+	return nil
+}
+
+// Fetch returns a resource given its id.
+func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Resource, error) {
+	in := struct {
+		ID string `db:"resource_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		resources
+	WHERE
+		resource_id = :resource_id`
+
+	var res Resource
+	if err := database.NamedQueryStruct(ctx, db, q, in, &res); err != nil {
+		return Resource{}, fmt.Errorf("selecting resource[%s]: %w", id, err)
+	}
+
+	return res, nil
+}
+
+// FetchByCourse returns the resources of a course and of its videos,
+// the resources of the course first.
+func FetchByCourse(ctx context.Context, db sqlx.ExtContext, courseID string) ([]Resource, error) {
+	in := struct {
+		CourseID string `db:"course_id"`
+	}{
+		CourseID: courseID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		resources
+	WHERE
+		course_id = :course_id
+	ORDER BY
+		video_id NULLS FIRST, created_at`
+
+	rs := []Resource{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rs); err != nil {
+		return nil, fmt.Errorf("selecting resources of course[%s]: %w", courseID, err)
+	}
+
+	return rs, nil
+}
+
+// Delete removes a resource and returns it.
+func Delete(ctx context.Context, db sqlx.ExtContext, id string) (Resource, error) {
+	in := struct {
+		ID string `db:"resource_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	DELETE FROM
+		resources
+	WHERE
+		resource_id = :resource_id
+	RETURNING *`
+
+	var res Resource
+	if err := database.NamedQueryStruct(ctx, db, q, in, &res); err != nil {
+		return Resource{}, fmt.Errorf("deleting resource[%s]: %w", id, err)
+	}
+
+	return res, nil
+}
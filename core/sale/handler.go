@@ -0,0 +1,192 @@
+package sale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errInvalidSale is returned when a sale can't be applied as passed.
+var errInvalidSale = errors.New("invalid sale")
+
+// check makes sure the courses of the sale exist and that the sale
+// ends after it starts.
+func check(ctx context.Context, db sqlx.ExtContext, s Sale) error {
+	if !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("%w: it must end after it starts", errInvalidSale)
+	}
+
+	for _, id := range s.CourseIDs {
+		if _, err := course.Fetch(ctx, db, id); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return fmt.Errorf("%w: course[%s] not found", errInvalidSale, id)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleCreate allows administrators to create a sale.
+func HandleCreate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var sn SaleNew
+		if err := web.Decode(w, r, &sn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(sn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		now := time.Now().UTC()
+		s := Sale{
+			ID:        validate.GenerateID(),
+			Name:      sn.Name,
+			Percent:   sn.Percent,
+			CourseIDs: sn.CourseIDs,
+			StartsAt:  sn.StartsAt.UTC(),
+			EndsAt:    sn.EndsAt.UTC(),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := check(ctx, tx, s); err != nil {
+				return err
+			}
+			return Create(ctx, tx, s)
+		})
+		if err != nil {
+			if errors.Is(err, errInvalidSale) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, s, http.StatusCreated)
+	}
+}
+
+// HandleList allows administrators to fetch all the sales.
+func HandleList(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		ss, err := FetchAll(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, ss, http.StatusOK)
+	}
+}
+
+// HandleShow allows administrators to fetch a sale and its courses.
+func HandleShow(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := web.Param(r, "id")
+
+		if err := validate.CheckID(id); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		s, err := Fetch(ctx, db, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, s, http.StatusOK)
+	}
+}
+
+// HandleUpdate allows administrators to update a sale. Passing the
+// courses replaces all of them. Orders already placed are not affected.
+func HandleUpdate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := web.Param(r, "id")
+
+		if err := validate.CheckID(id); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var su SaleUp
+		if err := web.Decode(w, r, &su); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(su); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		s, err := Fetch(ctx, db, id)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if su.Name != nil {
+			s.Name = *su.Name
+		}
+		if su.Percent != nil {
+			s.Percent = *su.Percent
+		}
+		if su.CourseIDs != nil {
+			s.CourseIDs = su.CourseIDs
+		}
+		if su.StartsAt != nil {
+			s.StartsAt = su.StartsAt.UTC()
+		}
+		if su.EndsAt != nil {
+			s.EndsAt = su.EndsAt.UTC()
+		}
+		s.UpdatedAt = time.Now().UTC()
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := check(ctx, tx, s); err != nil {
+				return err
+			}
+			return Update(ctx, tx, s)
+		})
+		if err != nil {
+			if errors.Is(err, errInvalidSale) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, s, http.StatusOK)
+	}
+}
+
+// HandleDelete allows administrators to delete a sale, ending it.
+func HandleDelete(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := web.Param(r, "id")
+
+		if err := validate.CheckID(id); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := Delete(ctx, db, id); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,39 @@
+// Package sale manages the campaigns discounting courses for a
+// limited time.
+package sale
+
+import (
+	"time"
+)
+
+// Sale models a campaign discounting its courses by Percent from
+// StartsAt until EndsAt. Courses on more sales at once get the highest
+// discount.
+type Sale struct {
+	ID        string    `json:"id" db:"sale_id"`
+	Name      string    `json:"name" db:"name"`
+	Percent   int       `json:"percent" db:"percent"`
+	CourseIDs []string  `json:"courseIds" db:"-"`
+	StartsAt  time.Time `json:"startsAt" db:"starts_at"`
+	EndsAt    time.Time `json:"endsAt" db:"ends_at"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// SaleNew contains the information needed to create a sale.
+type SaleNew struct {
+	Name      string    `json:"name" validate:"required"`
+	Percent   int       `json:"percent" validate:"gte=1,lte=100"`
+	CourseIDs []string  `json:"courseIds" validate:"min=1,unique,dive,uuid4"`
+	StartsAt  time.Time `json:"startsAt" validate:"required"`
+	EndsAt    time.Time `json:"endsAt" validate:"required,gtfield=StartsAt"`
+}
+
+// SaleUp contains the information of a sale that can be updated.
+type SaleUp struct {
+	Name      *string    `json:"name"`
+	Percent   *int       `json:"percent" validate:"omitempty,gte=1,lte=100"`
+	CourseIDs []string   `json:"courseIds" validate:"omitempty,min=1,unique,dive,uuid4"`
+	StartsAt  *time.Time `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt"`
+}
@@ -0,0 +1,200 @@
+package sale
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Create inserts a new sale together with its courses.
+func Create(ctx context.Context, db sqlx.ExtContext, s Sale) error {
+	const q = `
+	INSERT INTO sales
+		(sale_id, name, percent, starts_at, ends_at, created_at, updated_at)
+	VALUES
+		(:sale_id, :name, :percent, :starts_at, :ends_at, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, s); err != nil {
+		return fmt.Errorf("inserting sale: %w", err)
+	}
+
+	return SetCourses(ctx, db, s.ID, s.CourseIDs)
+}
+
+// Update updates the details of a sale and replaces its courses.
+func Update(ctx context.Context, db sqlx.ExtContext, s Sale) error {
+	const q = `
+	UPDATE sales
+	SET
+		name = :name,
+		percent = :percent,
+		starts_at = :starts_at,
+		ends_at = :ends_at,
+		updated_at = :updated_at
+	WHERE
+		sale_id = :sale_id`
+
+	if err := database.NamedExecContext(ctx, db, q, s); err != nil {
+		return fmt.Errorf("updating sale[%s]: %w", s.ID, err)
+	}
+
+	return SetCourses(ctx, db, s.ID, s.CourseIDs)
+}
+
+// SetCourses replaces the courses of a sale.
+func SetCourses(ctx context.Context, db sqlx.ExtContext, saleID string, courseIDs []string) error {
+	in := struct {
+		SaleID string `db:"sale_id"`
+	}{
+		SaleID: saleID,
+	}
+
+	const q = `
+	DELETE FROM
+		sale_courses
+	WHERE
+		sale_id = :sale_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("deleting courses of sale[%s]: %w", saleID, err)
+	}
+
+	for _, id := range courseIDs {
+		in := struct {
+			SaleID   string `db:"sale_id"`
+			CourseID string `db:"course_id"`
+		}{
+			SaleID:   saleID,
+			CourseID: id,
+		}
+
+		const q = `
+		INSERT INTO sale_courses
+			(sale_id, course_id)
+		VALUES
+			(:sale_id, :course_id)`
+
+		if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+			return fmt.Errorf("adding course[%s] to sale[%s]: %w", id, saleID, err)
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a sale. Orders keep the prices they were placed at.
+func Delete(ctx context.Context, db sqlx.ExtContext, id string) error {
+	in := struct {
+		ID string `db:"sale_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	DELETE FROM
+		sales
+	WHERE
+		sale_id = :sale_id
+	RETURNING sale_id`
+
+	var out struct {
+		ID string `db:"sale_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("deleting sale[%s]: %w", id, err)
+	}
+
+	return nil
+}
+
+// Fetch returns the sale with the passed id, together with its courses.
+func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Sale, error) {
+	in := struct {
+		ID string `db:"sale_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sales
+	WHERE
+		sale_id = :sale_id`
+
+	var s Sale
+	if err := database.NamedQueryStruct(ctx, db, q, in, &s); err != nil {
+		return Sale{}, fmt.Errorf("selecting sale[%s]: %w", id, err)
+	}
+
+	ids, err := FetchCourseIDs(ctx, db, id)
+	if err != nil {
+		return Sale{}, err
+	}
+	s.CourseIDs = ids
+
+	return s, nil
+}
+
+// FetchAll returns all the sales, together with their courses,
+// the latest starting first.
+func FetchAll(ctx context.Context, db sqlx.ExtContext) ([]Sale, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		sales
+	ORDER BY
+		starts_at DESC`
+
+	ss := []Sale{}
+	if err := database.NamedQuerySlice(ctx, db, q, struct{}{}, &ss); err != nil {
+		return nil, fmt.Errorf("selecting sales: %w", err)
+	}
+
+	for i := range ss {
+		ids, err := FetchCourseIDs(ctx, db, ss[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		ss[i].CourseIDs = ids
+	}
+
+	return ss, nil
+}
+
+// FetchCourseIDs returns the ids of the courses of a sale.
+func FetchCourseIDs(ctx context.Context, db sqlx.ExtContext, saleID string) ([]string, error) {
+	in := struct {
+		ID string `db:"sale_id"`
+	}{
+		ID: saleID,
+	}
+
+	const q = `
+	SELECT
+		course_id
+	FROM
+		sale_courses
+	WHERE
+		sale_id = :sale_id
+	ORDER BY
+		course_id`
+
+	var rows []struct {
+		ID string `db:"course_id"`
+	}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rows); err != nil {
+		return nil, fmt.Errorf("selecting courses of sale[%s]: %w", saleID, err)
+	}
+
+	ids := make([]string, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+
+	return ids, nil
+}
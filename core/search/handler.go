@@ -0,0 +1,61 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/jatolentino/tutorialspoint/api/background"
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jmoiron/sqlx"
+)
+
+// Bounds of the number of results of a search.
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// HandleSearch allows users to search courses and videos.
+// The query is passed via the q parameter, e.g. ?q=golang&limit=10.
+func HandleSearch(engine Engine) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			return weberr.BadRequest(errors.New("missing search query"))
+		}
+
+		limit := defaultLimit
+		if l := r.URL.Query().Get("limit"); l != "" {
+			var err error
+			if limit, err = strconv.Atoi(l); err != nil || limit <= 0 || limit > maxLimit {
+				return weberr.BadRequest(fmt.Errorf("limit must be between 1 and %d", maxLimit))
+			}
+		}
+
+		res, err := engine.Search(ctx, query, limit)
+		if err != nil {
+			return fmt.Errorf("searching %q: %w", query, err)
+		}
+
+		return web.Respond(ctx, w, res, http.StatusOK)
+	}
+}
+
+// HandleReindex allows administrators to rebuild the whole index,
+// e.g. after switching engine. Reindexing happens in background.
+func HandleReindex(db *sqlx.DB, engine Engine, bg *background.Background) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		bg.Add(func() error {
+			if err := Reindex(context.Background(), db, engine); err != nil {
+				return fmt.Errorf("reindexing catalog: %w", err)
+			}
+			return nil
+		})
+
+		return web.Respond(ctx, w, nil, http.StatusAccepted)
+	}
+}
@@ -0,0 +1,174 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Opensearch is the engine based on an OpenSearch (or Elasticsearch)
+// cluster, to be used when the catalog grows too much for PostgreSQL.
+type Opensearch struct {
+	url      string
+	index    string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewOpensearch returns an engine using the passed index of the
+// cluster at url. Credentials are optional.
+func NewOpensearch(url string, index string, username string, password string) *Opensearch {
+	return &Opensearch{
+		url:      strings.TrimSuffix(url, "/"),
+		index:    index,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Index implements the Engine interface, upserting documents
+// through the bulk API.
+func (o *Opensearch) Index(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, d := range docs {
+		action := map[string]any{
+			"index": map[string]string{"_index": o.index, "_id": d.Kind + ":" + d.ID},
+		}
+		if err := enc.Encode(action); err != nil {
+			return fmt.Errorf("encoding action of %s[%s]: %w", d.Kind, d.ID, err)
+		}
+		if err := enc.Encode(d); err != nil {
+			return fmt.Errorf("encoding %s[%s]: %w", d.Kind, d.ID, err)
+		}
+	}
+
+	var resp struct {
+		Errors bool `json:"errors"`
+	}
+	if err := o.do(ctx, "/_bulk", "application/x-ndjson", &body, &resp); err != nil {
+		return err
+	}
+	if resp.Errors {
+		return fmt.Errorf("some of the %d documents have not been indexed", len(docs))
+	}
+
+	return nil
+}
+
+// Delete implements the Engine interface, removing documents through
+// the bulk API. Ids are unique across kinds, so the documents of any
+// kind with the passed ids are removed; missing ones are ignored.
+func (o *Opensearch) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, id := range ids {
+		for _, kind := range []string{KindCourse, KindVideo} {
+			action := map[string]any{
+				"delete": map[string]string{"_index": o.index, "_id": kind + ":" + id},
+			}
+			if err := enc.Encode(action); err != nil {
+				return fmt.Errorf("encoding deletion of %s[%s]: %w", kind, id, err)
+			}
+		}
+	}
+
+	var resp struct {
+		Errors bool `json:"errors"`
+	}
+	if err := o.do(ctx, "/_bulk", "application/x-ndjson", &body, &resp); err != nil {
+		return err
+	}
+	if resp.Errors {
+		return fmt.Errorf("some of the %d documents have not been removed", len(ids))
+	}
+
+	return nil
+}
+
+// Search implements the Engine interface.
+func (o *Opensearch) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	req := map[string]any{
+		"size": limit,
+		"query": map[string]any{
+			"multi_match": map[string]any{
+				"query":  query,
+				"fields": []string{"title^2", "description"},
+			},
+		},
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encoding search %q: %w", query, err)
+	}
+
+	var resp struct {
+		Hits struct {
+			Hits []struct {
+				Score  float64  `json:"_score"`
+				Source Document `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := o.do(ctx, "/"+o.index+"/_search", "application/json", bytes.NewReader(body), &resp); err != nil {
+		return nil, fmt.Errorf("searching %q: %w", query, err)
+	}
+
+	res := make([]Result, 0, len(resp.Hits.Hits))
+	for _, h := range resp.Hits.Hits {
+		res = append(res, Result{
+			ID:       h.Source.ID,
+			Kind:     h.Source.Kind,
+			CourseID: h.Source.CourseID,
+			Title:    h.Source.Title,
+			Score:    h.Score,
+		})
+	}
+
+	return res, nil
+}
+
+// do posts body to the passed path and decodes the response in dst.
+func (o *Opensearch) do(ctx context.Context, path string, contentType string, body io.Reader, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url+path, body)
+	if err != nil {
+		return fmt.Errorf("building request to %s: %w", path, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if o.username != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("requesting %s: status %s: %s", path, resp.Status, msg)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("decoding response of %s: %w", path, err)
+	}
+
+	return nil
+}
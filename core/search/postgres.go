@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Postgres is the engine based on PostgreSQL full text search.
+// It searches directly the catalog tables, so there is nothing to index.
+type Postgres struct {
+	db *sqlx.DB
+}
+
+// NewPostgres returns an engine searching the passed database.
+func NewPostgres(db *sqlx.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Index implements the Engine interface.
+// Documents are always up to date, thanks to the indexes of the tables.
+func (p *Postgres) Index(ctx context.Context, docs ...Document) error {
+	return nil
+}
+
+// Delete implements the Engine interface.
+// Searches skip by themselves what is not published.
+func (p *Postgres) Delete(ctx context.Context, ids ...string) error {
+	return nil
+}
+
+// Search implements the Engine interface.
+func (p *Postgres) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	in := struct {
+		Query string `db:"query"`
+		Limit int    `db:"limit"`
+	}{
+		Query: query,
+		Limit: limit,
+	}
+
+	// Expressions must match the ones of the indexes to use them.
+	const q = `
+	SELECT
+		'course' AS kind,
+		course_id AS id,
+		course_id,
+		name AS title,
+		ts_rank(to_tsvector('english', name || ' ' || description), plainto_tsquery('english', :query)) AS score
+	FROM
+		courses
+	WHERE
+		status = 'published' AND
+		to_tsvector('english', name || ' ' || description) @@ plainto_tsquery('english', :query)
+	UNION ALL
+	SELECT
+		'video' AS kind,
+		video_id AS id,
+		course_id,
+		name AS title,
+		ts_rank(to_tsvector('english', name || ' ' || description), plainto_tsquery('english', :query)) AS score
+	FROM
+		videos
+	WHERE
+		published AND
+		deleted_at IS NULL AND
+		course_id IN (SELECT course_id FROM courses WHERE status = 'published') AND
+		to_tsvector('english', name || ' ' || description) @@ plainto_tsquery('english', :query)
+	ORDER BY
+		score DESC
+	LIMIT :limit`
+
+	res := []Result{}
+	if err := database.NamedQuerySlice(ctx, p.db, q, in, &res); err != nil {
+		return nil, fmt.Errorf("searching %q: %w", query, err)
+	}
+
+	return res, nil
+}
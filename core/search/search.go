@@ -0,0 +1,212 @@
+// Package search provides the full text search over the catalog.
+// Searches are served by an Engine, which is either PostgreSQL full
+// text search or an external OpenSearch cluster, depending on config.
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/api/background"
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jmoiron/sqlx"
+)
+
+// Kinds of indexed documents.
+const (
+	KindCourse = "course"
+	KindVideo  = "video"
+)
+
+// Supported engines.
+const (
+	EnginePostgres   = "postgres"
+	EngineOpensearch = "opensearch"
+)
+
+// Document models an entry of the search index.
+type Document struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"`
+	CourseID    string `json:"courseId"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// Result models a document matching a search.
+type Result struct {
+	ID       string  `json:"id" db:"id"`
+	Kind     string  `json:"kind" db:"kind"`
+	CourseID string  `json:"courseId" db:"course_id"`
+	Title    string  `json:"title" db:"title"`
+	Score    float64 `json:"score" db:"score"`
+}
+
+// Engine indexes and searches documents.
+type Engine interface {
+	Index(ctx context.Context, docs ...Document) error
+	Delete(ctx context.Context, ids ...string) error
+	Search(ctx context.Context, query string, limit int) ([]Result, error)
+}
+
+// NewEngine returns the engine specified by cfg.
+func NewEngine(cfg config.Search, db *sqlx.DB) (Engine, error) {
+	switch cfg.Engine {
+	case EnginePostgres:
+		return NewPostgres(db), nil
+	case EngineOpensearch:
+		return NewOpensearch(cfg.URL, cfg.Index, cfg.Username, cfg.Password), nil
+	default:
+		return nil, fmt.Errorf("search engine %s is not supported", cfg.Engine)
+	}
+}
+
+// FromCourse returns the document indexing a course.
+func FromCourse(c course.Course) Document {
+	return Document{
+		ID:          c.ID,
+		Kind:        KindCourse,
+		CourseID:    c.ID,
+		Title:       c.Name,
+		Description: c.Description,
+	}
+}
+
+// FromVideo returns the document indexing a video.
+func FromVideo(v video.Video) Document {
+	return Document{
+		ID:          v.ID,
+		Kind:        KindVideo,
+		CourseID:    v.CourseID,
+		Title:       v.Name,
+		Description: v.Description,
+	}
+}
+
+// Indexer keeps the index in sync with the catalog, listening
+// to changes of courses and videos. Documents are indexed in
+// background, so that handlers don't wait for the engine.
+type Indexer struct {
+	DB     *sqlx.DB
+	Engine Engine
+	BG     *background.Background
+}
+
+// CourseChanged implements the course.Listener interface.
+// Drafts are not indexed until published, nor archived courses: the
+// course and its videos leave the index once it's not published.
+func (i *Indexer) CourseChanged(c course.Course) {
+	i.BG.Add(func() error {
+		ctx := context.Background()
+
+		if c.Status != course.StatusPublished {
+			videos, err := video.FetchAllByCourse(ctx, i.DB, c.ID)
+			if err != nil {
+				return fmt.Errorf("fetching videos of course[%s]: %w", c.ID, err)
+			}
+
+			ids := []string{c.ID}
+			for _, v := range videos {
+				ids = append(ids, v.ID)
+			}
+			if err := i.Engine.Delete(ctx, ids...); err != nil {
+				return fmt.Errorf("removing course[%s] from the index: %w", c.ID, err)
+			}
+			return nil
+		}
+
+		videos, err := video.FetchPublishedByCourse(ctx, i.DB, c.ID)
+		if err != nil {
+			return fmt.Errorf("fetching videos of course[%s]: %w", c.ID, err)
+		}
+
+		docs := []Document{FromCourse(c)}
+		for _, v := range videos {
+			docs = append(docs, FromVideo(v))
+		}
+		if err := i.Engine.Index(ctx, docs...); err != nil {
+			return fmt.Errorf("indexing %s[%s]: %w", KindCourse, c.ID, err)
+		}
+		return nil
+	})
+}
+
+// VideoChanged implements the video.Listener interface.
+// Drafts are not indexed, nor the videos of courses not published:
+// videos leave the index once unpublished or deleted.
+func (i *Indexer) VideoChanged(v video.Video) {
+	i.indexVideo(v)
+}
+
+// VideoReady implements the video.Listener interface.
+func (i *Indexer) VideoReady(v video.Video) {
+	i.indexVideo(v)
+}
+
+func (i *Indexer) indexVideo(v video.Video) {
+	i.BG.Add(func() error {
+		ctx := context.Background()
+
+		if !v.Published || v.DeletedAt != nil {
+			if err := i.Engine.Delete(ctx, v.ID); err != nil {
+				return fmt.Errorf("removing video[%s] from the index: %w", v.ID, err)
+			}
+			return nil
+		}
+
+		c, err := course.Fetch(ctx, i.DB, v.CourseID)
+		if err != nil {
+			return fmt.Errorf("fetching course[%s] of video[%s]: %w", v.CourseID, v.ID, err)
+		}
+		if c.Status != course.StatusPublished {
+			return nil
+		}
+
+		if err := i.Engine.Index(ctx, FromVideo(v)); err != nil {
+			return fmt.Errorf("indexing %s[%s]: %w", KindVideo, v.ID, err)
+		}
+		return nil
+	})
+}
+
+// Reindex indexes again the whole catalog.
+func Reindex(ctx context.Context, db sqlx.ExtContext, engine Engine) error {
+	courses, err := course.FetchAll(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching courses: %w", err)
+	}
+
+	videos, err := video.FetchAllPublished(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetching videos: %w", err)
+	}
+
+	docs := make([]Document, 0, len(courses)+len(videos))
+	published := make(map[string]bool, len(courses))
+	for _, c := range courses {
+		if c.Status != course.StatusPublished {
+			continue
+		}
+		published[c.ID] = true
+		docs = append(docs, FromCourse(c))
+	}
+	for _, v := range videos {
+		if !published[v.CourseID] {
+			continue
+		}
+		docs = append(docs, FromVideo(v))
+	}
+
+	// Send documents in batches to keep requests small.
+	const batch = 500
+	for start := 0; start < len(docs); start += batch {
+		end := min(start+batch, len(docs))
+		if err := engine.Index(ctx, docs[start:end]...); err != nil {
+			return fmt.Errorf("indexing documents %d-%d: %w", start, end, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleCheckout starts the subscription of the user to the plan and
+// returns the URL of the stripe checkout. Users already subscribed
+// can't subscribe again.
+func HandleCheckout(db *sqlx.DB, strp *Stripe) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if !strp.Enabled() {
+			return weberr.NotFound(errors.New("no plan available"))
+		}
+
+		ss, err := FetchByUser(ctx, db, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, s := range ss {
+			if s.Entitles(now) {
+				err := fmt.Errorf("user[%s] already subscribed", clm.UserID)
+				return weberr.NewError(err, "already subscribed", http.StatusConflict)
+			}
+		}
+
+		usr, err := user.Fetch(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", clm.UserID, err)
+		}
+
+		url, err := strp.CreateCheckout(ctx, clm.UserID, usr.Email)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, url, http.StatusOK)
+	}
+}
+
+// HandleShowMine returns the subscriptions of the user, the most recent first.
+func HandleShowMine(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		ss, err := FetchByUser(ctx, db, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, ss, http.StatusOK)
+	}
+}
+
+// HandleWebhook handles the billing events of stripe. Paid invoices
+// extend the subscription to the end of the period they pay, deleted
+// subscriptions are canceled right away.
+func HandleWebhook(db *sqlx.DB, strp *Stripe) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		evt, err := strp.VerifyWebhook(r)
+		if err != nil {
+			if errors.Is(err, errEventIgnored) {
+				return web.Respond(ctx, w, nil, http.StatusNoContent)
+			}
+			return weberr.BadRequest(err)
+		}
+
+		now := time.Now().UTC()
+
+		switch evt.Type {
+		case EventInvoicePaid:
+			s, err := strp.Fetch(ctx, evt.ProviderID)
+			if err != nil {
+				return err
+			}
+
+			// Subscriptions not started by users are none of our business.
+			if s.UserID == "" {
+				return web.Respond(ctx, w, nil, http.StatusNoContent)
+			}
+
+			// The period has been payed, even if stripe didn't
+			// activate the subscription yet.
+			if s.Status != StatusCanceled {
+				s.Status = StatusActive
+			}
+			s.ID = validate.GenerateID()
+			s.CreatedAt = now
+			s.UpdatedAt = now
+
+			if err := Upsert(ctx, db, s); err != nil {
+				return err
+			}
+
+		case EventSubscriptionDeleted:
+			if err := Cancel(ctx, db, evt.ProviderID, now); err != nil {
+				if !errors.Is(err, database.ErrDBNotFound) {
+					return err
+				}
+			}
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
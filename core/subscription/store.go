@@ -0,0 +1,90 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Upsert records a subscription, or updates the status and the period
+// of the one with the same ProviderID.
+func Upsert(ctx context.Context, db sqlx.ExtContext, s Subscription) error {
+	const q = `
+	INSERT INTO subscriptions
+		(subscription_id, user_id, provider_id, customer_id, status, period_end, created_at, updated_at)
+	VALUES
+		(:subscription_id, :user_id, :provider_id, :customer_id, :status, :period_end, :created_at, :updated_at)
+	ON CONFLICT (provider_id) DO UPDATE
+	SET
+		status = EXCLUDED.status,
+		period_end = GREATEST(subscriptions.period_end, EXCLUDED.period_end),
+		updated_at = EXCLUDED.updated_at`
+
+	if err := database.NamedExecContext(ctx, db, q, s); err != nil {
+		return fmt.Errorf("upserting subscription[%s]: %w", s.ProviderID, err)
+	}
+
+	return nil
+}
+
+// Cancel ends the subscription with the passed provider id.
+// It returns database.ErrDBNotFound if the subscription is unknown.
+func Cancel(ctx context.Context, db sqlx.ExtContext, providerID string, now time.Time) error {
+	in := struct {
+		ProviderID string    `db:"provider_id"`
+		Status     string    `db:"status"`
+		Now        time.Time `db:"now"`
+	}{
+		ProviderID: providerID,
+		Status:     StatusCanceled,
+		Now:        now,
+	}
+
+	const q = `
+	UPDATE subscriptions
+	SET
+		status = :status,
+		canceled_at = :now,
+		updated_at = :now
+	WHERE
+		provider_id = :provider_id
+	RETURNING subscription_id`
+
+	var out struct {
+		ID string `db:"subscription_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("canceling subscription[%s]: %w", providerID, err)
+	}
+
+	return nil
+}
+
+// FetchByUser returns all the subscriptions of a user, the most recent first.
+func FetchByUser(ctx context.Context, db sqlx.ExtContext, userID string) ([]Subscription, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		subscriptions
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		created_at DESC`
+
+	ss := []Subscription{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ss); err != nil {
+		return nil, fmt.Errorf("selecting subscriptions of user[%s]: %w", userID, err)
+	}
+
+	return ss, nil
+}
@@ -0,0 +1,159 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/stripe/stripe-go/v74"
+	stripecl "github.com/stripe/stripe-go/v74/client"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+// errEventIgnored is returned when a billing event is authentic but
+// doesn't concern the subscriptions to the plan.
+var errEventIgnored = errors.New("event ignored")
+
+// Stripe subscribes users to the plan with stripe billing.
+// Subscriptions are started with checkout sessions and kept up to date
+// by the billing webhooks.
+type Stripe struct {
+	client *stripecl.API
+	cfg    config.Stripe
+}
+
+// NewStripe returns the stripe billing of the plan.
+func NewStripe(client *stripecl.API, cfg config.Stripe) *Stripe {
+	return &Stripe{client: client, cfg: cfg}
+}
+
+// Enabled reports whether a plan has been configured.
+func (s *Stripe) Enabled() bool {
+	return s.cfg.PlanPrice != ""
+}
+
+// CreateCheckout creates a checkout session subscribing the user to the
+// plan, and returns its URL. The user is recorded on the subscription,
+// so that its invoices can be bound to them.
+func (s *Stripe) CreateCheckout(ctx context.Context, userID string, email string) (string, error) {
+	params := &stripe.CheckoutSessionParams{
+		SuccessURL:        stripe.String(s.cfg.SuccessURL),
+		CancelURL:         stripe.String(s.cfg.CancelURL),
+		Mode:              stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		ClientReferenceID: stripe.String(userID),
+		CustomerEmail:     stripe.String(email),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{{
+			Price:    stripe.String(s.cfg.PlanPrice),
+			Quantity: stripe.Int64(1),
+		}},
+		SubscriptionData: &stripe.CheckoutSessionSubscriptionDataParams{
+			Metadata: map[string]string{"user_id": userID},
+		},
+	}
+	params.Context = ctx
+
+	if s.cfg.AutomaticTax {
+		params.AutomaticTax = &stripe.CheckoutSessionAutomaticTaxParams{Enabled: stripe.Bool(true)}
+		params.BillingAddressCollection = stripe.String(string(stripe.CheckoutSessionBillingAddressCollectionRequired))
+	}
+
+	sess, err := s.client.CheckoutSessions.New(params)
+	if err != nil {
+		return "", fmt.Errorf("creating stripe subscription session: %w", err)
+	}
+
+	return sess.URL, nil
+}
+
+// Fetch returns the subscription with the passed provider id, as
+// currently known by stripe. The user is empty for subscriptions
+// which were not started by CreateCheckout.
+func (s *Stripe) Fetch(ctx context.Context, providerID string) (Subscription, error) {
+	params := &stripe.SubscriptionParams{}
+	params.Context = ctx
+
+	sub, err := s.client.Subscriptions.Get(providerID, params)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("fetching stripe subscription[%s]: %w", providerID, err)
+	}
+
+	ss := Subscription{
+		UserID:     sub.Metadata["user_id"],
+		ProviderID: sub.ID,
+		Status:     StatusPastDue,
+		PeriodEnd:  time.Unix(sub.CurrentPeriodEnd, 0).UTC(),
+	}
+
+	if sub.Customer != nil {
+		ss.CustomerID = sub.Customer.ID
+	}
+
+	switch sub.Status {
+	case stripe.SubscriptionStatusActive, stripe.SubscriptionStatusTrialing:
+		ss.Status = StatusActive
+	case stripe.SubscriptionStatusCanceled, stripe.SubscriptionStatusIncompleteExpired:
+		ss.Status = StatusCanceled
+	}
+
+	return ss, nil
+}
+
+// Event is a billing event regarding a subscription.
+type Event struct {
+	Type       string
+	ProviderID string
+}
+
+// Billing events handled by the webhook.
+const (
+	EventInvoicePaid         = "invoice.paid"
+	EventSubscriptionDeleted = "customer.subscription.deleted"
+)
+
+// VerifyWebhook checks the signature of a billing event and returns the
+// subscription it regards. It returns errEventIgnored for the events
+// which are not handled.
+func (s *Stripe) VerifyWebhook(r *http.Request) (Event, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Event{}, fmt.Errorf("cannot read the request body: %w", err)
+	}
+
+	sig := r.Header.Get("Stripe-Signature")
+	if sig == "" {
+		return Event{}, errors.New("received stripe event is not signed")
+	}
+
+	event, err := webhook.ConstructEvent(b, sig, s.cfg.BillingWebhookSecret)
+	if err != nil {
+		return Event{}, fmt.Errorf("cannot construct stripe event: %w", err)
+	}
+
+	switch event.Type {
+	case EventInvoicePaid:
+		var inv stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &inv); err != nil {
+			return Event{}, fmt.Errorf("unable to decode stripe invoice: %w", err)
+		}
+
+		// Invoices of one-time payments are not about subscriptions.
+		if inv.Subscription == nil {
+			return Event{}, errEventIgnored
+		}
+		return Event{Type: event.Type, ProviderID: inv.Subscription.ID}, nil
+
+	case EventSubscriptionDeleted:
+		var sub stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &sub); err != nil {
+			return Event{}, fmt.Errorf("unable to decode stripe subscription: %w", err)
+		}
+		return Event{Type: event.Type, ProviderID: sub.ID}, nil
+	}
+
+	return Event{}, errEventIgnored
+}
@@ -0,0 +1,37 @@
+// Package subscription manages the all-access plan: users subscribed
+// to it can access every course while their subscription is active.
+package subscription
+
+import (
+	"time"
+)
+
+// Statuses of a subscription.
+const (
+	StatusActive   = "active"
+	StatusPastDue  = "past_due"
+	StatusCanceled = "canceled"
+)
+
+// Subscription models the subscription of a user to the all-access plan.
+// ProviderID and CustomerID identify the subscription and the customer
+// on stripe. PeriodEnd is the end of the last period payed: active
+// subscriptions grant access until then, and are extended every time
+// a new period is payed.
+type Subscription struct {
+	ID         string     `json:"id" db:"subscription_id"`
+	UserID     string     `json:"userId" db:"user_id"`
+	ProviderID string     `json:"-" db:"provider_id"`
+	CustomerID string     `json:"-" db:"customer_id"`
+	Status     string     `json:"status" db:"status"`
+	PeriodEnd  time.Time  `json:"periodEnd" db:"period_end"`
+	CanceledAt *time.Time `json:"canceledAt" db:"canceled_at"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Entitles reports whether the subscription grants access to all the
+// courses at the passed time.
+func (s Subscription) Entitles(now time.Time) bool {
+	return s.Status == StatusActive && now.Before(s.PeriodEnd)
+}
@@ -0,0 +1,198 @@
+package token
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/background"
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/rate"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// errEmailTaken is returned when changing the email of a user to the
+// one of another user.
+var errEmailTaken = errors.New("email already registered")
+
+// HandleEmailChange allows users to change their email, confirming
+// their password. A token is sent to the new email, which is switched
+// to only once confirmed via HandleEmailConfirm.
+// This function leverages a rate limiter to avoid too many emails.
+func HandleEmailChange(db *sqlx.DB, mailer Mailer, timeout time.Duration, bg *background.Background) web.Handler {
+	rps := rate.Every(timeout)
+	limiter := rate.NewLimiter(1, 10, float64(rps))
+
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Email    string `json:"email" validate:"required,email"`
+			Password string `json:"password" validate:"required"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if !limiter.Check(clm.UserID) {
+			err := errors.New("too many requests")
+			return weberr.NewError(err, err.Error(), http.StatusTooManyRequests)
+		}
+
+		usr, err := user.Fetch(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", clm.UserID, err)
+		}
+
+		if err := bcrypt.CompareHashAndPassword(usr.PasswordHash, []byte(in.Password)); err != nil {
+			return weberr.NewError(err, "wrong password", http.StatusForbidden)
+		}
+
+		if in.Email == usr.Email {
+			err := fmt.Errorf("user[%s] already uses %s", usr.ID, in.Email)
+			return weberr.NewError(err, "email not changed", http.StatusUnprocessableEntity)
+		}
+
+		if err := checkEmail(ctx, db, in.Email); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusConflict)
+		}
+
+		text, token, err := GenToken(usr.ID, 6*time.Hour, EmailChangeToken)
+		if err != nil {
+			return fmt.Errorf("generating random token: %w", err)
+		}
+		token.Email = &in.Email
+
+		// Only the latest change requested can be confirmed.
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := DeleteByUser(ctx, tx, usr.ID, EmailChangeToken); err != nil {
+				return fmt.Errorf("deleting token by user[%s]: %w", usr.ID, err)
+			}
+
+			if err := Create(ctx, tx, token); err != nil {
+				return fmt.Errorf("creating new token for user[%s]: %w", usr.ID, err)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		bg.Add(func() error {
+			if err := mailer.SendEmailChangeToken(text, in.Email); err != nil {
+				return fmt.Errorf("failed to send email change token to %s: %w", in.Email, err)
+			}
+			return nil
+		})
+
+		return web.Respond(ctx, w, nil, http.StatusAccepted)
+	}
+}
+
+// HandleEmailConfirm validates the passed token and, if correct,
+// switches the email of the user to the one it was sent to. The old
+// email is notified of the change.
+func HandleEmailConfirm(db *sqlx.DB, mailer Mailer, bg *background.Background) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Token string `json:"token" validate:"required"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		tokh := sha256.Sum256([]byte(in.Token))
+		now := time.Now().UTC()
+
+		token, err := Fetch(ctx, db, tokh[:], EmailChangeToken, now)
+		if err != nil {
+			err := fmt.Errorf("fetching token: %w", err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.BadRequest(err)
+			}
+			return err
+		}
+
+		if token.Email == nil {
+			return fmt.Errorf("email change token of user[%s] without email", token.UserID)
+		}
+
+		usr, err := user.Fetch(ctx, db, token.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", token.UserID, err)
+		}
+		old := usr.Email
+
+		// Delete the token only if the user gets updated correctly (and viceversa).
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := checkEmail(ctx, tx, *token.Email); err != nil {
+				return err
+			}
+
+			if err := DeleteByUser(ctx, tx, usr.ID, EmailChangeToken); err != nil {
+				return fmt.Errorf("deleting token by user[%s]: %w", usr.ID, err)
+			}
+
+			usr.Email = *token.Email
+			usr.UpdatedAt = now
+			if _, err := user.Update(ctx, tx, usr); err != nil {
+				return fmt.Errorf("changing email of user[%s]: %w", usr.ID, err)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			if errors.Is(err, errEmailTaken) {
+				return weberr.NewError(err, err.Error(), http.StatusConflict)
+			}
+			return err
+		}
+
+		bg.Add(func() error {
+			if err := mailer.SendEmailChanged(usr.Email, old); err != nil {
+				return fmt.Errorf("failed to notify email change to %s: %w", old, err)
+			}
+			return nil
+		})
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// checkEmail returns errEmailTaken if the passed email is registered.
+func checkEmail(ctx context.Context, db sqlx.ExtContext, email string) error {
+	_, err := user.FetchByEmail(ctx, db, email)
+	switch {
+	case err == nil:
+		return errEmailTaken
+	case errors.Is(err, database.ErrDBNotFound):
+		return nil
+	default:
+		return fmt.Errorf("fetching user by email %s: %w", email, err)
+	}
+}
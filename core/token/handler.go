@@ -14,6 +14,7 @@ import (
 	"github.com/jatolentino/tutorialspoint/api/web"
 	"github.com/jatolentino/tutorialspoint/api/weberr"
 	"github.com/jatolentino/tutorialspoint/core/auth"
+	"github.com/jatolentino/tutorialspoint/core/claims"
 	"github.com/jatolentino/tutorialspoint/core/user"
 	"github.com/jatolentino/tutorialspoint/database"
 	"github.com/jatolentino/tutorialspoint/rate"
@@ -22,10 +23,16 @@ import (
 )
 
 // Mailer should be able to send emails to users
-// for handling their activation and their password recovery.
+// for handling their activation, their password recovery and the
+// change of their email and their login via link, and to guests for
+// claiming their account.
 type Mailer interface {
 	SendActivationToken(token string, to string) error
 	SendRecoveryToken(token string, to string) error
+	SendClaimToken(token string, to string) error
+	SendEmailChangeToken(token string, to string) error
+	SendEmailChanged(email string, to string) error
+	SendLoginToken(token string, to string) error
 }
 
 // HandleToken is used to send specific tokens to users via email.
@@ -65,18 +72,31 @@ func HandleToken(db *sqlx.DB, mailer Mailer, timeout time.Duration, bg *backgrou
 			return err
 		}
 
+		// Guests have no password to recover, they must claim their account.
 		scope := in.Scope
+		if usr.Role == claims.RoleGuest && scope != ClaimToken {
+			return weberr.BadRequest(fmt.Errorf("user %s must claim the account", usr.Email))
+		}
+
+		ttl := 6 * time.Hour
+
 		switch scope {
 		case ActivationToken:
 			if usr.Active {
 				return weberr.BadRequest(fmt.Errorf("user %s is already active", usr.Email))
 			}
 		case RecoveryToken:
+		case ClaimToken:
+			if usr.Role != claims.RoleGuest {
+				return weberr.BadRequest(fmt.Errorf("user %s is already claimed", usr.Email))
+			}
+		case LoginToken:
+			ttl = loginTTL
 		default:
 			return weberr.BadRequest(fmt.Errorf("scope %s is not supported", scope))
 		}
 
-		text, token, err := GenToken(usr.ID, 6*time.Hour, scope)
+		text, token, err := GenToken(usr.ID, ttl, scope)
 		if err != nil {
 			return fmt.Errorf("generating random token: %w", err)
 		}
@@ -109,6 +129,14 @@ func HandleToken(db *sqlx.DB, mailer Mailer, timeout time.Duration, bg *backgrou
 				if err := mailer.SendRecoveryToken(text, usr.Email); err != nil {
 					return fmt.Errorf("failed to send recovery token %s to %s: %w", scope, usr.Email, err)
 				}
+			case ClaimToken:
+				if err := mailer.SendClaimToken(text, usr.Email); err != nil {
+					return fmt.Errorf("failed to send claim token %s to %s: %w", scope, usr.Email, err)
+				}
+			case LoginToken:
+				if err := mailer.SendLoginToken(text, usr.Email); err != nil {
+					return fmt.Errorf("failed to send login token %s to %s: %w", scope, usr.Email, err)
+				}
 			default:
 				return fmt.Errorf("scope %s is not supported", scope)
 			}
@@ -165,7 +193,7 @@ func HandleActivation(db *sqlx.DB, session *scs.SessionManager) web.Handler {
 			return err
 		}
 
-		if err := auth.SaveUserSession(ctx, session, usr.ID, usr.Role); err != nil {
+		if err := auth.SaveUserSession(ctx, db, session, usr.ID, usr.Role); err != nil {
 			return fmt.Errorf("store user[%s] in session: %w", usr.ID, err)
 		}
 
@@ -229,3 +257,75 @@ func HandleRecovery(db *sqlx.DB) web.Handler {
 		return nil
 	}
 }
+
+// HandleClaim validates the passed token and, if correct, turns the
+// guest it was sent to into an active user with the name and password
+// provided. The purchases made as a guest belong to the user already,
+// who is logged in right away.
+func HandleClaim(db *sqlx.DB, session *scs.SessionManager) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Token           string `json:"token" validate:"required"`
+			Name            string `json:"name" validate:"required"`
+			Password        string `json:"password" validate:"required,gte=8,lte=50"`
+			PasswordConfirm string `json:"passwordConfirm" validate:"eqfield=Password"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		tokh := sha256.Sum256([]byte(in.Token))
+
+		usr, err := user.FetchByToken(ctx, db, tokh[:], ClaimToken)
+		if err != nil {
+			err := fmt.Errorf("fetch user by token: %w", err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.BadRequest(err)
+			}
+			return err
+		}
+
+		if usr.Role != claims.RoleGuest {
+			err := fmt.Errorf("user %s is already claimed", usr.Email)
+			return weberr.NewError(err, err.Error(), http.StatusConflict)
+		}
+
+		passh, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("generating password hash: %w", err)
+		}
+
+		// Delete the token only if the user gets updated correctly (and viceversa).
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if err := DeleteByUser(ctx, tx, usr.ID, ClaimToken); err != nil {
+				return fmt.Errorf("deleting token by user[%s]: %w", usr.ID, err)
+			}
+
+			usr.Name = in.Name
+			usr.PasswordHash = passh
+			usr.Role = claims.RoleUser
+			usr.Active = true
+			usr.UpdatedAt = time.Now().UTC()
+			if _, err := user.Update(ctx, tx, usr); err != nil {
+				return fmt.Errorf("claiming user[%s]: %w", usr.ID, err)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err := auth.SaveUserSession(ctx, db, session, usr.ID, usr.Role); err != nil {
+			return fmt.Errorf("store user[%s] in session: %w", usr.ID, err)
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,95 @@
+package token
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/core/auth"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// loginTTL is how long the links to login without password are valid,
+// since they grant access to the account as they are.
+const loginTTL = 15 * time.Minute
+
+// HandleLogin validates the passed token, sent via HandleToken as a
+// link, and, if correct, makes a session for the user, the same as a
+// login with password. Tokens can be used only once. Since the link was
+// received via email, the user gets activated.
+func HandleLogin(db *sqlx.DB, session *scs.SessionManager, tf config.TwoFactor) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Token string `json:"token" validate:"required"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		tokh := sha256.Sum256([]byte(in.Token))
+
+		// Consuming the token makes sure that concurrent requests with the
+		// same link don't both log in. The user is activated along with it.
+		var usr user.User
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			tok, err := Consume(ctx, tx, tokh[:], LoginToken, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+
+			if err := DeleteByUser(ctx, tx, tok.UserID, LoginToken); err != nil {
+				return fmt.Errorf("deleting token by user[%s]: %w", tok.UserID, err)
+			}
+
+			if usr, err = user.Fetch(ctx, tx, tok.UserID); err != nil {
+				return fmt.Errorf("fetching user[%s]: %w", tok.UserID, err)
+			}
+
+			if usr.Active {
+				return nil
+			}
+
+			usr.Active = true
+			usr.UpdatedAt = time.Now().UTC()
+			if _, err := user.Update(ctx, tx, usr); err != nil {
+				return fmt.Errorf("activating user[%s]: %w", usr.ID, err)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			err := fmt.Errorf("logging in by token: %w", err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotAuthorized(err)
+			}
+			return err
+		}
+
+		step, err := auth.StartSession(ctx, db, session, usr, tf)
+		if err != nil {
+			return err
+		}
+
+		if step != "" {
+			return web.Respond(ctx, w, auth.Pending{TwoFactor: step}, http.StatusAccepted)
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
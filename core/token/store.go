@@ -3,6 +3,7 @@ package token
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/jatolentino/tutorialspoint/database"
@@ -12,9 +13,9 @@ import (
 func Create(ctx context.Context, db sqlx.ExtContext, token Token) error {
 	const q = `
 	INSERT INTO tokens
-		(hash, user_id, expiry, scope)
+		(hash, user_id, expiry, scope, email)
 	VALUES
-		(:hash, :user_id, :expiry, :scope)`
+		(:hash, :user_id, :expiry, :scope, :email)`
 
 	if err := database.NamedExecContext(ctx, db, q, token); err != nil {
 		return fmt.Errorf("inserting token: %w", err)
@@ -44,3 +45,64 @@ func DeleteByUser(ctx context.Context, db sqlx.ExtContext, userID string, scope
 
 	return nil
 }
+
+// Fetch returns the token with the passed hash and scope, unless expired.
+func Fetch(ctx context.Context, db sqlx.ExtContext, hash []byte, scope string, now time.Time) (Token, error) {
+	in := struct {
+		Hash  []byte    `db:"hash"`
+		Scope string    `db:"scope"`
+		Now   time.Time `db:"now"`
+	}{
+		Hash:  hash,
+		Scope: scope,
+		Now:   now,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tokens
+	WHERE
+		hash = :hash AND
+		scope = :scope AND
+		expiry > :now`
+
+	var token Token
+	if err := database.NamedQueryStruct(ctx, db, q, in, &token); err != nil {
+		return Token{}, fmt.Errorf("selecting token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Consume deletes the token with the passed hash and scope, unless
+// expired, and returns it. Tokens can be consumed only once: it returns
+// database.ErrDBNotFound if a concurrent request consumed it already.
+func Consume(ctx context.Context, db sqlx.ExtContext, hash []byte, scope string, now time.Time) (Token, error) {
+	in := struct {
+		Hash  []byte    `db:"hash"`
+		Scope string    `db:"scope"`
+		Now   time.Time `db:"now"`
+	}{
+		Hash:  hash,
+		Scope: scope,
+		Now:   now,
+	}
+
+	const q = `
+	DELETE FROM tokens
+	WHERE
+		hash = :hash AND
+		scope = :scope AND
+		expiry > :now
+	RETURNING
+		*`
+
+	var token Token
+	if err := database.NamedQueryStruct(ctx, db, q, in, &token); err != nil {
+		return Token{}, fmt.Errorf("deleting token: %w", err)
+	}
+
+	return token, nil
+}
@@ -8,17 +8,23 @@ import (
 )
 
 const (
-	ActivationToken = "activation"
-	RecoveryToken   = "recovery"
+	ActivationToken  = "activation"
+	RecoveryToken    = "recovery"
+	ClaimToken       = "claim"
+	EmailChangeToken = "email_change"
+	LoginToken       = "login"
 )
 
 // Token models tokens to be sent to users for
 // activation and recovery purposes.
+// Email change tokens hold the new Email of the user, sent to it to be
+// confirmed.
 type Token struct {
 	Hash   []byte    `json:"-" db:"hash"`
 	UserID string    `json:"userId" db:"user_id"`
 	Expiry time.Time `json:"expiry" db:"expiry"`
 	Scope  string    `json:"scope" db:"scope"`
+	Email  *string   `json:"-" db:"email"`
 }
 
 // GenToken generates a new random token for a user.
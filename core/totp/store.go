@@ -0,0 +1,184 @@
+package totp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Save stores the authenticator app of a user, replacing the one not
+// confirmed yet, if any.
+func Save(ctx context.Context, db sqlx.ExtContext, t TOTP) error {
+	const q = `
+	INSERT INTO user_totps
+		(user_id, secret, confirmed_at, last_counter, created_at)
+	VALUES
+		(:user_id, :secret, :confirmed_at, :last_counter, :created_at)
+	ON CONFLICT (user_id) DO UPDATE SET
+		secret = EXCLUDED.secret,
+		confirmed_at = EXCLUDED.confirmed_at,
+		last_counter = EXCLUDED.last_counter,
+		created_at = EXCLUDED.created_at
+	WHERE
+		user_totps.confirmed_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, db, q, t); err != nil {
+		return fmt.Errorf("inserting totp: %w", err)
+	}
+
+	return nil
+}
+
+// Fetch returns the authenticator app of the passed user.
+func Fetch(ctx context.Context, db sqlx.ExtContext, userID string) (TOTP, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		user_totps
+	WHERE
+		user_id = :user_id`
+
+	var t TOTP
+	if err := database.NamedQueryStruct(ctx, db, q, in, &t); err != nil {
+		return TOTP{}, fmt.Errorf("selecting totp: %w", err)
+	}
+
+	return t, nil
+}
+
+// Use records that the code of the time step counter was accepted, and
+// confirms the authenticator app if not yet. It returns
+// database.ErrDBNotFound when a code of the same or a later step was
+// accepted already, so that concurrent logins don't share a code.
+func Use(ctx context.Context, db sqlx.ExtContext, userID string, counter int64, now time.Time) error {
+	in := struct {
+		UserID  string    `db:"user_id"`
+		Counter int64     `db:"counter"`
+		Now     time.Time `db:"now"`
+	}{
+		UserID:  userID,
+		Counter: counter,
+		Now:     now,
+	}
+
+	const q = `
+	UPDATE user_totps SET
+		last_counter = :counter,
+		confirmed_at = COALESCE(confirmed_at, :now)
+	WHERE
+		user_id = :user_id AND
+		last_counter < :counter
+	RETURNING
+		*`
+
+	var t TOTP
+	if err := database.NamedQueryStruct(ctx, db, q, in, &t); err != nil {
+		return fmt.Errorf("updating totp: %w", err)
+	}
+
+	return nil
+}
+
+// Delete drops the authenticator app of the passed user, along with
+// the recovery codes.
+func Delete(ctx context.Context, db sqlx.ExtContext, userID string) error {
+	in := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	DELETE FROM user_totps
+	WHERE user_id = :user_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("deleting totp: %w", err)
+	}
+
+	return ReplaceRecoveryCodes(ctx, db, userID, nil)
+}
+
+// ReplaceRecoveryCodes stores the passed hashes as the recovery codes
+// of a user, dropping the previous ones.
+func ReplaceRecoveryCodes(ctx context.Context, db sqlx.ExtContext, userID string, hashes [][]byte) error {
+	in := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const qd = `
+	DELETE FROM totp_recovery_codes
+	WHERE user_id = :user_id`
+
+	if err := database.NamedExecContext(ctx, db, qd, in); err != nil {
+		return fmt.Errorf("deleting recovery codes: %w", err)
+	}
+
+	const q = `
+	INSERT INTO totp_recovery_codes
+		(user_id, hash)
+	VALUES
+		(:user_id, :hash)`
+
+	for _, h := range hashes {
+		code := struct {
+			UserID string `db:"user_id"`
+			Hash   []byte `db:"hash"`
+		}{
+			UserID: userID,
+			Hash:   h,
+		}
+
+		if err := database.NamedExecContext(ctx, db, q, code); err != nil {
+			return fmt.Errorf("inserting recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks as used the recovery code of the user with the
+// passed hash. It returns database.ErrDBNotFound if there's no such code
+// or it was used already.
+func UseRecoveryCode(ctx context.Context, db sqlx.ExtContext, userID string, hash []byte, now time.Time) error {
+	in := struct {
+		UserID string    `db:"user_id"`
+		Hash   []byte    `db:"hash"`
+		Now    time.Time `db:"now"`
+	}{
+		UserID: userID,
+		Hash:   hash,
+		Now:    now,
+	}
+
+	const q = `
+	UPDATE totp_recovery_codes SET
+		used_at = :now
+	WHERE
+		user_id = :user_id AND
+		hash = :hash AND
+		used_at IS NULL
+	RETURNING
+		user_id`
+
+	var out struct {
+		UserID string `db:"user_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, db, q, in, &out); err != nil {
+		return fmt.Errorf("updating recovery code: %w", err)
+	}
+
+	return nil
+}
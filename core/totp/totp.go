@@ -0,0 +1,167 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Codes are generated as described by RFC 6238, with the defaults
+// supported by every authenticator app.
+const (
+	digits = 6
+	period = 30
+
+	// skew is the number of time steps accepted before and after the
+	// current one, to make up for clock drift.
+	skew = 1
+
+	secretSize = 20
+
+	// RecoveryCodes is the number of recovery codes a user gets.
+	RecoveryCodes = 10
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTP models the authenticator app of a user. Two-factor authentication
+// is enabled once the first code is confirmed. LastCounter is the time
+// step of the last code accepted, so that codes can't be replayed.
+type TOTP struct {
+	UserID      string     `json:"-" db:"user_id"`
+	Secret      string     `json:"-" db:"secret"`
+	ConfirmedAt *time.Time `json:"confirmedAt" db:"confirmed_at"`
+	LastCounter int64      `json:"-" db:"last_counter"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// Confirmed tells whether two-factor authentication is enabled.
+func (t TOTP) Confirmed() bool {
+	return t.ConfirmedAt != nil
+}
+
+// Enrollment is what users need to add the secret to their
+// authenticator app, either typed or via a QR code of URI.
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// Recovery lists the recovery codes of a user, shown only once.
+type Recovery struct {
+	Codes []string `json:"recoveryCodes"`
+}
+
+// NewSecret generates a new random secret, base32 encoded.
+func NewSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the provisioning URI of secret for the account of issuer,
+// as understood by authenticator apps.
+func URI(issuer, account, secret string) string {
+	q := make(url.Values)
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(digits))
+	q.Set("period", fmt.Sprint(period))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// Counter returns the time step of t.
+func Counter(t time.Time) int64 {
+	return t.Unix() / period
+}
+
+// Code returns the code of secret for the time step counter.
+func Code(secret string, counter int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("decoding secret: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, see RFC 4226.
+	off := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[off:]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, bin%1_000_000), nil
+}
+
+// Validate checks code against secret at now and returns the time step
+// it belongs to. Codes of steps up to last are refused, since they were
+// used already.
+func Validate(secret, code string, now time.Time, last int64) (int64, bool) {
+	if len(code) != digits {
+		return 0, false
+	}
+
+	cur := Counter(now)
+	for c := cur - skew; c <= cur+skew; c++ {
+		if c <= last {
+			continue
+		}
+
+		want, err := Code(secret, c)
+		if err != nil {
+			return 0, false
+		}
+
+		if hmac.Equal([]byte(want), []byte(code)) {
+			return c, true
+		}
+	}
+
+	return 0, false
+}
+
+// NewRecoveryCodes generates a set of random recovery codes, formatted
+// as xxxxx-xxxxx, and returns them along with their hashes.
+func NewRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, RecoveryCodes)
+	hashes := make([][]byte, RecoveryCodes)
+
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		s := strings.ToLower(encoding.EncodeToString(b))
+		codes[i] = s[:5] + "-" + s[5:]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns the hash of code as stored, ignoring case,
+// dashes and spaces.
+func HashRecoveryCode(code string) []byte {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+
+	hash := sha256.Sum256([]byte(code))
+	return hash[:]
+}
@@ -0,0 +1,70 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// secret is the key of the test vectors of RFC 6238.
+var secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		exp  string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(secret, Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.exp {
+			t.Errorf("code at %d: got %q, expected %q", tt.unix, got, tt.exp)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	cur := Counter(now)
+
+	prev, err := Code(secret, cur-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c, ok := Validate(secret, prev, now, 0); !ok || c != cur-1 {
+		t.Fatalf("code of the previous step should be valid, got %d %t", c, ok)
+	}
+
+	if _, ok := Validate(secret, prev, now, cur-1); ok {
+		t.Fatalf("code used already should not be valid")
+	}
+
+	if _, ok := Validate(secret, "005924", now.Add(2*period*time.Second), 0); ok {
+		t.Fatalf("code expired should not be valid")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codes) != RecoveryCodes || len(hashes) != RecoveryCodes {
+		t.Fatalf("expected %d recovery codes, got %d", RecoveryCodes, len(codes))
+	}
+
+	if string(HashRecoveryCode(" "+codes[0][:5]+codes[0][6:])) != string(hashes[0]) {
+		t.Fatalf("recovery codes should be hashed ignoring dashes and spaces")
+	}
+}
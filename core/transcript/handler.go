@@ -0,0 +1,205 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// Bounds of the number of segments returned by a search.
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// HandlePut allows administrators to set the transcript of a video,
+// replacing the previous one.
+func HandlePut(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var tup TranscriptUp
+		if err := web.Decode(w, r, &tup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(tup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		segs := tup.Segments
+		sort.Slice(segs, func(i, j int) bool { return segs[i].Start < segs[j].Start })
+
+		now := time.Now().UTC()
+		for i := range segs {
+			if i > 0 && segs[i].Start == segs[i-1].Start {
+				err := fmt.Errorf("two segments start at %ds", segs[i].Start)
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			segs[i].VideoID = videoID
+			segs[i].CreatedAt = now
+		}
+
+		if _, err := video.Fetch(ctx, db, videoID); err != nil {
+			err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			return Replace(ctx, tx, videoID, segs)
+		})
+		if err != nil {
+			return err
+		}
+
+		if segs == nil {
+			segs = []Segment{}
+		}
+
+		return web.Respond(ctx, w, segs, http.StatusOK)
+	}
+}
+
+// HandleShow returns the transcript of a video to the owners of its
+// course.
+func HandleShow(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		v, err := video.Fetch(ctx, db, videoID)
+		if err != nil {
+			err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if !v.Published && !claims.IsAdmin(ctx) {
+			return weberr.NotFound(fmt.Errorf("video[%s] is a draft", v.ID))
+		}
+
+		if err := checkAccess(ctx, db, v.CourseID); err != nil {
+			return err
+		}
+
+		segs, err := FetchByVideo(ctx, db, videoID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, segs, http.StatusOK)
+	}
+}
+
+// HandleSearch allows the owners of a course to search the transcripts
+// of its videos. The query is passed via the q parameter, e.g.
+// ?q=goroutines&limit=10. Matching videos are returned the most
+// relevant first, each with its matching segments in order.
+func HandleSearch(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			return weberr.BadRequest(errors.New("missing search query"))
+		}
+
+		limit := defaultLimit
+		if l := r.URL.Query().Get("limit"); l != "" {
+			var err error
+			if limit, err = strconv.Atoi(l); err != nil || limit <= 0 || limit > maxLimit {
+				return weberr.BadRequest(fmt.Errorf("limit must be between 1 and %d", maxLimit))
+			}
+		}
+
+		if err := checkAccess(ctx, db, courseID); err != nil {
+			return err
+		}
+
+		hits, err := Search(ctx, db, courseID, query, claims.IsAdmin(ctx), limit)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, groupHits(hits), http.StatusOK)
+	}
+}
+
+// groupHits groups the hits by video, keeping the videos in the order
+// of their first hit and sorting the hits of each video by time.
+func groupHits(hits []Hit) []Match {
+	ms := []Match{}
+	index := make(map[string]int)
+
+	for _, h := range hits {
+		i, ok := index[h.VideoID]
+		if !ok {
+			i = len(ms)
+			index[h.VideoID] = i
+			ms = append(ms, Match{
+				VideoID:    h.VideoID,
+				VideoName:  h.VideoName,
+				VideoIndex: h.VideoIndex,
+			})
+		}
+		ms[i].Hits = append(ms[i].Hits, h)
+	}
+
+	for _, m := range ms {
+		sort.Slice(m.Hits, func(i, j int) bool { return m.Hits[i].Start < m.Hits[j].Start })
+	}
+
+	return ms
+}
+
+// checkAccess verifies that the user owns the course, administrators
+// access the transcripts of every course.
+func checkAccess(ctx context.Context, db sqlx.ExtContext, courseID string) error {
+	if claims.IsAdmin(ctx) {
+		return nil
+	}
+
+	clm, err := claims.Get(ctx)
+	if err != nil {
+		return weberr.NotAuthorized(errors.New("user not authenticated"))
+	}
+
+	if _, err := course.FetchOwned(ctx, db, courseID, clm.UserID); err != nil {
+		err := fmt.Errorf("fetching course[%s] owned by user[%s]: %w", courseID, clm.UserID, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+		}
+		return err
+	}
+
+	return nil
+}
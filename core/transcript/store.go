@@ -0,0 +1,116 @@
+package transcript
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Replace replaces the whole transcript of a video with the passed
+// segments.
+func Replace(ctx context.Context, db sqlx.ExtContext, videoID string, segs []Segment) error {
+	in := struct {
+		ID string `db:"video_id"`
+	}{
+		ID: videoID,
+	}
+
+	const del = `
+	DELETE FROM
+		transcript_segments
+	WHERE
+		video_id = :video_id`
+
+	if err := database.NamedExecContext(ctx, db, del, in); err != nil {
+		return fmt.Errorf("deleting transcript of video[%s]: %w", videoID, err)
+	}
+
+	const ins = `
+	INSERT INTO transcript_segments
+		(video_id, start_seconds, end_seconds, text, created_at)
+	VALUES
+		(:video_id, :start_seconds, :end_seconds, :text, :created_at)`
+
+	for _, s := range segs {
+		if err := database.NamedExecContext(ctx, db, ins, s); err != nil {
+			return fmt.Errorf("inserting segment at %ds of video[%s]: %w", s.Start, videoID, err)
+		}
+	}
+
+	return nil
+}
+
+// FetchByVideo returns the transcript of a video, in order.
+func FetchByVideo(ctx context.Context, db sqlx.ExtContext, videoID string) ([]Segment, error) {
+	in := struct {
+		ID string `db:"video_id"`
+	}{
+		ID: videoID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		transcript_segments
+	WHERE
+		video_id = :video_id
+	ORDER BY
+		start_seconds`
+
+	segs := []Segment{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &segs); err != nil {
+		return nil, fmt.Errorf("selecting transcript of video[%s]: %w", videoID, err)
+	}
+
+	return segs, nil
+}
+
+// Search returns the segments of the transcripts of a course matching
+// the query, the most relevant first. Drafts are searched only when
+// drafts is true.
+func Search(ctx context.Context, db sqlx.ExtContext, courseID string, query string, drafts bool, limit int) ([]Hit, error) {
+	in := struct {
+		CourseID string `db:"course_id"`
+		Query    string `db:"query"`
+		Drafts   bool   `db:"drafts"`
+		Limit    int    `db:"limit"`
+	}{
+		CourseID: courseID,
+		Query:    query,
+		Drafts:   drafts,
+		Limit:    limit,
+	}
+
+	// Expressions must match the one of the index to use it.
+	const q = `
+	SELECT
+		s.video_id,
+		v.name AS video_name,
+		v.index AS video_index,
+		s.start_seconds,
+		s.end_seconds,
+		ts_headline('english', s.text, plainto_tsquery('english', :query)) AS snippet,
+		ts_rank(to_tsvector('english', s.text), plainto_tsquery('english', :query)) AS score
+	FROM
+		transcript_segments AS s
+	INNER JOIN
+		videos AS v ON v.video_id = s.video_id
+	WHERE
+		v.course_id = :course_id AND
+		(v.published OR :drafts) AND
+		v.deleted_at IS NULL AND
+		to_tsvector('english', s.text) @@ plainto_tsquery('english', :query)
+	ORDER BY
+		score DESC, v.index, s.start_seconds
+	LIMIT :limit`
+
+	hits := []Hit{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &hits); err != nil {
+		return nil, fmt.Errorf("searching %q in transcripts of course[%s]: %w", query, courseID, err)
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,41 @@
+// Package transcript manages the transcripts of videos, which owners of
+// the courses search to find where a topic is explained.
+package transcript
+
+import "time"
+
+// Segment models a piece of the transcript of a video, spoken between
+// Start and End seconds.
+type Segment struct {
+	VideoID   string    `json:"-" db:"video_id"`
+	Start     int       `json:"start" db:"start_seconds" validate:"gte=0"`
+	End       int       `json:"end" db:"end_seconds" validate:"gtfield=Start"`
+	Text      string    `json:"text" db:"text" validate:"required,max=5000"`
+	CreatedAt time.Time `json:"-" db:"created_at"`
+}
+
+// TranscriptUp contains the whole transcript of a video.
+type TranscriptUp struct {
+	Segments []Segment `json:"segments" validate:"max=10000,dive"`
+}
+
+// Hit models a segment matching a search, Snippet being its text with
+// the matching words highlighted.
+type Hit struct {
+	VideoID    string  `json:"-" db:"video_id"`
+	VideoName  string  `json:"-" db:"video_name"`
+	VideoIndex int     `json:"-" db:"video_index"`
+	Start      int     `json:"start" db:"start_seconds"`
+	End        int     `json:"end" db:"end_seconds"`
+	Snippet    string  `json:"snippet" db:"snippet"`
+	Score      float64 `json:"score" db:"score"`
+}
+
+// Match models a video of a course matching a search, with its
+// matching segments in order.
+type Match struct {
+	VideoID    string `json:"videoId"`
+	VideoName  string `json:"videoName"`
+	VideoIndex int    `json:"videoIndex"`
+	Hits       []Hit  `json:"hits"`
+}
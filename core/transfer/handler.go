@@ -0,0 +1,427 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/category"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/core/resource"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// maxPackageSize is the maximum size of an imported package.
+const maxPackageSize = 16 << 20
+
+// errInvalidPackage is returned when the parts of a package don't fit
+// together, e.g. a video refers to a missing section.
+var errInvalidPackage = errors.New("invalid package")
+
+// HandleExport allows administrators to download a course, with its
+// videos, sections and resources, as a package. Deleted videos are
+// left out.
+func HandleExport(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		crs, err := course.Fetch(ctx, db, courseID)
+		if err != nil {
+			err := fmt.Errorf("fetching course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		pkg, err := export(ctx, db, crs)
+		if err != nil {
+			return fmt.Errorf("exporting course[%s]: %w", courseID, err)
+		}
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="course-%s.json"`, courseID))
+
+		return web.Respond(ctx, w, pkg, http.StatusOK)
+	}
+}
+
+// HandleImport allows administrators to create a course from a package.
+// The course is created as a draft, to be reviewed before publishing.
+// Either the whole package is imported or nothing is.
+func HandleImport(db *sqlx.DB, cl course.Listener, vl video.Listener) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxPackageSize)
+
+		var pkg Package
+		if err := web.Decode(w, r, &pkg); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(pkg); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var (
+			crs    course.Course
+			videos []video.Video
+		)
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			var err error
+			crs, videos, err = importPackage(ctx, tx, pkg, clm.UserID, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, errInvalidPackage) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "package has duplicated entries", http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		cl.CourseChanged(crs)
+		for _, v := range videos {
+			vl.VideoChanged(v)
+		}
+
+		return web.Respond(ctx, w, crs, http.StatusCreated)
+	}
+}
+
+// export builds the package of a course.
+func export(ctx context.Context, db sqlx.ExtContext, crs course.Course) (Package, error) {
+	pkg := Package{
+		Version:    FormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Course: Course{
+			Name:            crs.Name,
+			Description:     crs.Description,
+			Price:           crs.Price,
+			ImageURL:        crs.ImageURL,
+			AccessDays:      crs.AccessDays,
+			RenewalDiscount: crs.RenewalDiscount,
+			Objectives:      crs.Objectives,
+			Requirements:    crs.Requirements,
+			Audience:        crs.Audience,
+			LongDescription: crs.LongDescription,
+		},
+		Categories: []category.TopicNew{},
+		Tags:       []category.TopicNew{},
+		Sections:   []video.SectionNew{},
+		Videos:     []Video{},
+		Resources:  []Resource{},
+	}
+
+	cats, err := category.FetchCategoriesByCourse(ctx, db, crs.ID)
+	if err != nil {
+		return Package{}, err
+	}
+	for _, c := range cats {
+		pkg.Categories = append(pkg.Categories, category.TopicNew{Name: c.Name, Slug: c.Slug})
+	}
+
+	tags, err := category.FetchTagsByCourse(ctx, db, crs.ID)
+	if err != nil {
+		return Package{}, err
+	}
+	for _, t := range tags {
+		pkg.Tags = append(pkg.Tags, category.TopicNew{Name: t.Name, Slug: t.Slug})
+	}
+
+	sections, err := video.FetchSectionsByCourse(ctx, db, crs.ID)
+	if err != nil {
+		return Package{}, err
+	}
+	sectionIndexes := make(map[string]int, len(sections))
+	for _, s := range sections {
+		pkg.Sections = append(pkg.Sections, video.SectionNew{Index: s.Index, Title: s.Title})
+		sectionIndexes[s.ID] = s.Index
+	}
+
+	videos, err := video.FetchAllByCourse(ctx, db, crs.ID)
+	if err != nil {
+		return Package{}, err
+	}
+	videoIndexes := make(map[string]int, len(videos))
+	for _, v := range videos {
+		chs, err := video.FetchChapters(ctx, db, v.ID)
+		if err != nil {
+			return Package{}, err
+		}
+
+		pv := Video{
+			Index:       v.Index,
+			Name:        v.Name,
+			Description: v.Description,
+			Free:        v.Free,
+			URL:         v.URL,
+			Provider:    v.Provider,
+			ImageURL:    v.ImageURL,
+			Published:   v.Published,
+			Chapters:    chs,
+		}
+		if v.SectionID != nil {
+			pv.Section = sectionIndexes[*v.SectionID]
+		}
+
+		pkg.Videos = append(pkg.Videos, pv)
+		videoIndexes[v.ID] = v.Index
+	}
+
+	rs, err := resource.FetchByCourse(ctx, db, crs.ID)
+	if err != nil {
+		return Package{}, err
+	}
+	for _, res := range rs {
+		pr := Resource{
+			Name:        res.Name,
+			Kind:        res.Kind,
+			Filename:    res.Filename,
+			ContentType: res.ContentType,
+			Size:        res.Size,
+			Key:         res.Key,
+			URL:         res.URL,
+		}
+
+		// Resources of deleted videos are left out with their videos.
+		if res.VideoID != nil {
+			idx, ok := videoIndexes[*res.VideoID]
+			if !ok {
+				continue
+			}
+			pr.Video = idx
+		}
+
+		pkg.Resources = append(pkg.Resources, pr)
+	}
+
+	return pkg, nil
+}
+
+// importPackage creates the course of a package, with its topics,
+// sections, videos and resources, returning the course and its videos.
+func importPackage(ctx context.Context, db sqlx.ExtContext, pkg Package, userID string, now time.Time) (course.Course, []video.Video, error) {
+	crs := course.Course{
+		ID:              validate.GenerateID(),
+		Name:            pkg.Course.Name,
+		Description:     pkg.Course.Description,
+		Price:           pkg.Course.Price,
+		ImageURL:        pkg.Course.ImageURL,
+		AccessDays:      pkg.Course.AccessDays,
+		RenewalDiscount: pkg.Course.RenewalDiscount,
+		Objectives:      append(pq.StringArray{}, pkg.Course.Objectives...),
+		Requirements:    append(pq.StringArray{}, pkg.Course.Requirements...),
+		Audience:        append(pq.StringArray{}, pkg.Course.Audience...),
+		LongDescription: pkg.Course.LongDescription,
+		Status:          course.StatusDraft,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Version:         1,
+	}
+
+	slug, err := course.NewSlug(ctx, db, crs.Name)
+	if err != nil {
+		return course.Course{}, nil, err
+	}
+	crs.Slug = slug
+
+	if err := course.Create(ctx, db, crs); err != nil {
+		return course.Course{}, nil, err
+	}
+
+	pc := course.PriceChange{
+		CourseID:  crs.ID,
+		Price:     crs.Price,
+		ChangedBy: userID,
+		CreatedAt: now,
+	}
+	if err := course.CreatePriceChange(ctx, db, pc); err != nil {
+		return course.Course{}, nil, err
+	}
+
+	if err := importTopics(ctx, db, crs.ID, pkg, now); err != nil {
+		return course.Course{}, nil, err
+	}
+
+	sectionIDs := make(map[int]string, len(pkg.Sections))
+	for _, ps := range pkg.Sections {
+		s := video.Section{
+			ID:        validate.GenerateID(),
+			CourseID:  crs.ID,
+			Index:     ps.Index,
+			Title:     ps.Title,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Version:   1,
+		}
+		if err := video.CreateSection(ctx, db, s); err != nil {
+			return course.Course{}, nil, err
+		}
+		sectionIDs[s.Index] = s.ID
+	}
+
+	videos := make([]video.Video, 0, len(pkg.Videos))
+	videoIDs := make(map[int]string, len(pkg.Videos))
+	for _, pv := range pkg.Videos {
+		v := video.Video{
+			ID:          validate.GenerateID(),
+			CourseID:    crs.ID,
+			Index:       pv.Index,
+			Name:        pv.Name,
+			Description: pv.Description,
+			Free:        pv.Free,
+			URL:         pv.URL,
+			Provider:    pv.Provider,
+			ImageURL:    pv.ImageURL,
+			Status:      video.StatusReady,
+			Published:   pv.Published,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Version:     1,
+		}
+		if v.Provider == "" {
+			v.Provider = video.ProviderNative
+		}
+
+		if pv.Section != 0 {
+			id, ok := sectionIDs[pv.Section]
+			if !ok {
+				return course.Course{}, nil, fmt.Errorf("%w: video %d refers to missing section %d", errInvalidPackage, pv.Index, pv.Section)
+			}
+			v.SectionID = &id
+		}
+
+		if err := video.Create(ctx, db, v); err != nil {
+			return course.Course{}, nil, err
+		}
+
+		chs := pv.Chapters
+		for i := range chs {
+			chs[i].VideoID = v.ID
+			chs[i].CreatedAt = now
+		}
+		if err := video.ReplaceChapters(ctx, db, v.ID, chs); err != nil {
+			return course.Course{}, nil, err
+		}
+
+		videos = append(videos, v)
+		videoIDs[v.Index] = v.ID
+	}
+
+	for _, pr := range pkg.Resources {
+		res := resource.Resource{
+			ID:          validate.GenerateID(),
+			CourseID:    crs.ID,
+			Name:        pr.Name,
+			Kind:        pr.Kind,
+			Filename:    pr.Filename,
+			ContentType: pr.ContentType,
+			Size:        pr.Size,
+			Key:         pr.Key,
+			URL:         pr.URL,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		if pr.Video != 0 {
+			id, ok := videoIDs[pr.Video]
+			if !ok {
+				return course.Course{}, nil, fmt.Errorf("%w: resource %q refers to missing video %d", errInvalidPackage, pr.Name, pr.Video)
+			}
+			res.VideoID = &id
+		}
+
+		if err := resource.Create(ctx, db, res); err != nil {
+			return course.Course{}, nil, err
+		}
+	}
+
+	return crs, videos, nil
+}
+
+// importTopics sets the categories and the tags of the package on the
+// course, creating the missing ones.
+func importTopics(ctx context.Context, db sqlx.ExtContext, courseID string, pkg Package, now time.Time) error {
+	for _, t := range append(pkg.Categories, pkg.Tags...) {
+		if !category.ValidSlug(t.Slug) {
+			return fmt.Errorf("%w: invalid slug %q", errInvalidPackage, t.Slug)
+		}
+	}
+
+	cats, err := category.FetchCategories(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	catIDs := make(map[string]string, len(cats))
+	for _, c := range cats {
+		catIDs[c.Slug] = c.ID
+	}
+
+	ids := make([]string, 0, len(pkg.Categories))
+	for _, pc := range pkg.Categories {
+		id, ok := catIDs[pc.Slug]
+		if !ok {
+			c := category.Category{ID: validate.GenerateID(), Name: pc.Name, Slug: pc.Slug, CreatedAt: now, UpdatedAt: now}
+			if err := category.CreateCategory(ctx, db, c); err != nil {
+				return err
+			}
+			id = c.ID
+			catIDs[c.Slug] = id
+		}
+		ids = append(ids, id)
+	}
+
+	if _, err := category.SetCourseCategories(ctx, db, courseID, ids); err != nil {
+		return err
+	}
+
+	tags, err := category.FetchTags(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	tagIDs := make(map[string]string, len(tags))
+	for _, t := range tags {
+		tagIDs[t.Slug] = t.ID
+	}
+
+	ids = make([]string, 0, len(pkg.Tags))
+	for _, pt := range pkg.Tags {
+		id, ok := tagIDs[pt.Slug]
+		if !ok {
+			t := category.Tag{ID: validate.GenerateID(), Name: pt.Name, Slug: pt.Slug, CreatedAt: now, UpdatedAt: now}
+			if err := category.CreateTag(ctx, db, t); err != nil {
+				return err
+			}
+			id = t.ID
+			tagIDs[t.Slug] = id
+		}
+		ids = append(ids, id)
+	}
+
+	if _, err := category.SetCourseTags(ctx, db, courseID, ids); err != nil {
+		return err
+	}
+
+	return nil
+}
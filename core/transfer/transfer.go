@@ -0,0 +1,74 @@
+// Package transfer exports courses as portable JSON packages, and
+// imports them, e.g. to promote the content prepared on staging to
+// production. Packages refer to everything by content rather than by
+// id, since ids differ between environments.
+package transfer
+
+import (
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/category"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+// FormatVersion is the version of the format of the packages, bumped
+// whenever a package of the previous version can't be imported anymore.
+const FormatVersion = 1
+
+// Package contains a whole course. Categories and tags are matched by
+// slug on import, and created when missing. Videos refer to their
+// section by its index, 0 for none, and resources refer to their video
+// by its index, 0 for the course.
+type Package struct {
+	Version    int                 `json:"version" validate:"eq=1"`
+	ExportedAt time.Time           `json:"exportedAt"`
+	Course     Course              `json:"course"`
+	Categories []category.TopicNew `json:"categories" validate:"max=100,dive"`
+	Tags       []category.TopicNew `json:"tags" validate:"max=100,dive"`
+	Sections   []video.SectionNew  `json:"sections" validate:"max=1000,dive"`
+	Videos     []Video             `json:"videos" validate:"max=1000,dive"`
+	Resources  []Resource          `json:"resources" validate:"max=1000,dive"`
+}
+
+// Course contains the information of the course of a package.
+type Course struct {
+	Name            string       `json:"name" validate:"required"`
+	Description     string       `json:"description" validate:"required"`
+	Price           money.Amount `json:"price"`
+	ImageURL        string       `json:"imageUrl" validate:"required"`
+	AccessDays      int          `json:"accessDays" validate:"gte=0"`
+	RenewalDiscount int          `json:"renewalDiscount" validate:"gte=0,lte=100"`
+	Objectives      []string     `json:"objectives" validate:"max=20,dive,required,max=300"`
+	Requirements    []string     `json:"requirements" validate:"max=20,dive,required,max=300"`
+	Audience        []string     `json:"audience" validate:"max=20,dive,required,max=300"`
+	LongDescription string       `json:"longDescription" validate:"max=20000"`
+}
+
+// Video contains a video of a package, with its chapters.
+type Video struct {
+	Index       int             `json:"index" validate:"gte=1"`
+	Section     int             `json:"section" validate:"gte=0"`
+	Name        string          `json:"name" validate:"required"`
+	Description string          `json:"description"`
+	Free        bool            `json:"free"`
+	URL         string          `json:"url" validate:"omitempty,url"`
+	Provider    string          `json:"provider" validate:"omitempty,oneof=native mux vimeo youtube"`
+	ImageURL    string          `json:"imageUrl"`
+	Published   bool            `json:"published"`
+	Chapters    []video.Chapter `json:"chapters" validate:"max=100,dive"`
+}
+
+// Resource references a resource of a package. The files themselves
+// are not included: they are referred to by their storage key, so they
+// must be copied to the storage of the target environment apart.
+type Resource struct {
+	Video       int    `json:"video" validate:"gte=0"`
+	Name        string `json:"name" validate:"required,max=200"`
+	Kind        string `json:"kind" validate:"oneof=file link"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size" validate:"gte=0"`
+	Key         string `json:"key" validate:"required_if=Kind file"`
+	URL         string `json:"url" validate:"required_if=Kind link"`
+}
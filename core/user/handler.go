@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -99,3 +100,37 @@ func HandleShowCurrent(db *sqlx.DB) web.Handler {
 		return web.Respond(ctx, w, user, http.StatusOK)
 	}
 }
+
+// HandleUpdateCountry sets the country of the current user, which
+// prices the courses bought by the user.
+func HandleUpdateCountry(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		var cup CountryUp
+		if err := web.Decode(w, r, &cup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+		cup.Country = strings.ToUpper(cup.Country)
+
+		if err := validate.Check(cup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		user, err := Fetch(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", clm.UserID, err)
+		}
+
+		user.Country = cup.Country
+		user.UpdatedAt = time.Now().UTC()
+		if user, err = Update(ctx, db, user); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, user, http.StatusOK)
+	}
+}
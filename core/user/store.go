@@ -42,6 +42,7 @@ func Update(ctx context.Context, db sqlx.ExtContext, user User) (User, error) {
 		email = :email,
 		role = :role,
 		active = :active,
+		country = :country,
 		password_hash = :password_hash,
 		updated_at = :updated_at,
 		version = version + 1
@@ -114,6 +115,32 @@ func FetchByEmail(ctx context.Context, db sqlx.ExtContext, email string) (User,
 	return user, nil
 }
 
+// FetchAllByRole returns all the users with the passed role.
+func FetchAllByRole(ctx context.Context, db sqlx.ExtContext, role string) ([]User, error) {
+	in := struct {
+		Role string `db:"role"`
+	}{
+		Role: role,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		users
+	WHERE
+		role = :role
+	ORDER BY
+		user_id`
+
+	users := []User{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &users); err != nil {
+		return nil, fmt.Errorf("selecting users by role[%s]: %w", role, err)
+	}
+
+	return users, nil
+}
+
 // FetchByToken retrieves the user corresponding to the passed token.
 func FetchByToken(ctx context.Context, db sqlx.ExtContext, tokenHash []byte, tokenScope string) (User, error) {
 	in := struct {
@@ -143,3 +170,50 @@ func FetchByToken(ctx context.Context, db sqlx.ExtContext, tokenHash []byte, tok
 
 	return user, nil
 }
+
+// CreateIdentity links a user to the account of an external provider.
+// It returns database.ErrDBDuplicatedEntry if the account, or another
+// account of the same provider, is linked already.
+func CreateIdentity(ctx context.Context, db sqlx.ExtContext, id Identity) error {
+	const q = `
+	INSERT INTO user_identities
+		(provider, subject, user_id, email, created_at)
+	VALUES
+		(:provider, :subject, :user_id, :email, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, id); err != nil {
+		return fmt.Errorf("inserting identity of user[%s] on %s: %w", id.UserID, id.Provider, err)
+	}
+
+	return nil
+}
+
+// FetchByIdentity retrieves the user linked to the account of an
+// external provider.
+func FetchByIdentity(ctx context.Context, db sqlx.ExtContext, provider string, subject string) (User, error) {
+	in := struct {
+		Provider string `db:"provider"`
+		Subject  string `db:"subject"`
+	}{
+		Provider: provider,
+		Subject:  subject,
+	}
+
+	const q = `
+	SELECT
+		u.*
+	FROM
+		users AS u
+	JOIN
+		user_identities AS i ON i.user_id = u.user_id
+	WHERE
+		i.provider = :provider AND
+		i.subject = :subject`
+
+	var user User
+	if err := database.NamedQueryStruct(ctx, db, q, in, &user); err != nil {
+		return User{}, fmt.Errorf("selecting user by identity on %s: %w", provider, err)
+	}
+
+	return user, nil
+}
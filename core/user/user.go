@@ -5,12 +5,15 @@ import (
 )
 
 // User models users. Email address is a unique field.
+// Country is the ISO 3166-1 alpha-2 code of the country of the user,
+// if known, which sets the regional prices charged.
 type User struct {
 	ID           string    `json:"id" db:"user_id"`
 	Name         string    `json:"name" db:"name"`
 	Email        string    `json:"email" db:"email"`
 	Role         string    `json:"role" db:"role"`
 	Active       bool      `json:"active" db:"active"`
+	Country      string    `json:"country" db:"country"`
 	PasswordHash []byte    `json:"-" db:"password_hash"`
 	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
@@ -43,3 +46,19 @@ type UserUp struct {
 	Password        *string `json:"password"`
 	PasswordConfirm *string `json:"passwordConfirm" validate:"omitempty,eqfield=Password"`
 }
+
+// CountryUp sets the country of a user, empty to clear it.
+type CountryUp struct {
+	Country string `json:"country" validate:"omitempty,iso3166_1_alpha2"`
+}
+
+// Identity links a user to the account of an external provider, e.g.
+// google, identified by the Subject assigned by the provider. Email is
+// the one of the account when linked.
+type Identity struct {
+	Provider  string    `json:"provider" db:"provider"`
+	Subject   string    `json:"-" db:"subject"`
+	UserID    string    `json:"userId" db:"user_id"`
+	Email     string    `json:"email" db:"email"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
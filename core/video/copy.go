@@ -0,0 +1,186 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// errCopyNotFound is returned when copying videos which are not of
+	// the source course.
+	errCopyNotFound = errors.New("videos to copy not found in the course")
+
+	// errCopyProcessing is returned when copying videos which are still
+	// processed by the transcoding provider.
+	errCopyProcessing = errors.New("videos to copy are still processing")
+)
+
+// HandleCopy allows administrators to copy videos of a course into
+// another one, e.g. when building a variant of the course. The copies
+// get new ids and follow the videos of the course, in the order of the
+// source course; their renditions and chapters are copied too.
+// The copies are returned.
+func HandleCopy(db *sqlx.DB, l Listener) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var vc VideosCopy
+		if err := web.Decode(w, r, &vc); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(vc); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := validate.CheckID(vc.FromCourseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		for _, id := range []string{vc.FromCourseID, courseID} {
+			if _, err := course.Fetch(ctx, db, id); err != nil {
+				err := fmt.Errorf("fetching course[%s]: %w", id, err)
+				if errors.Is(err, database.ErrDBNotFound) {
+					return weberr.NotFound(err)
+				}
+				return err
+			}
+		}
+
+		var copies []Video
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			source, err := FetchAllByCourse(ctx, tx, vc.FromCourseID)
+			if err != nil {
+				return err
+			}
+
+			source, err = pickCopied(source, vc.VideoIDs)
+			if err != nil {
+				return err
+			}
+
+			current, err := FetchAllByCourseForUpdate(ctx, tx, courseID)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now().UTC()
+			for i, v := range source {
+				cp, err := copyVideo(ctx, tx, v, courseID, len(current)+i+1, now)
+				if err != nil {
+					return err
+				}
+				copies = append(copies, cp)
+			}
+
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, errCopyNotFound) || errors.Is(err, errCopyProcessing) {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return fmt.Errorf("copying videos of course[%s] into course[%s]: %w", vc.FromCourseID, courseID, err)
+		}
+
+		for _, cp := range copies {
+			l.VideoChanged(cp)
+		}
+
+		if copies == nil {
+			copies = []Video{}
+		}
+
+		return web.Respond(ctx, w, copies, http.StatusCreated)
+	}
+}
+
+// pickCopied returns the videos of the passed ids, keeping their order,
+// or all the videos when ids is empty.
+func pickCopied(videos []Video, ids []string) ([]Video, error) {
+	if len(ids) == 0 {
+		return videos, nil
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var picked []Video
+	for _, v := range videos {
+		if wanted[v.ID] {
+			picked = append(picked, v)
+			delete(wanted, v.ID)
+		}
+	}
+
+	if len(wanted) > 0 {
+		return nil, errCopyNotFound
+	}
+
+	return picked, nil
+}
+
+// copyVideo inserts a copy of the video, with its renditions and its
+// chapters, into the course at index.
+func copyVideo(ctx context.Context, db sqlx.ExtContext, v Video, courseID string, index int, now time.Time) (Video, error) {
+	if v.Status == StatusProcessing {
+		return Video{}, fmt.Errorf("video[%s]: %w", v.ID, errCopyProcessing)
+	}
+
+	rends, err := FetchRenditions(ctx, db, v.ID)
+	if err != nil {
+		return Video{}, err
+	}
+
+	chs, err := FetchChapters(ctx, db, v.ID)
+	if err != nil {
+		return Video{}, err
+	}
+
+	cp := v
+	cp.ID = validate.GenerateID()
+	cp.CourseID = courseID
+	cp.SectionID = nil
+	cp.Index = index
+	cp.JobID = ""
+	cp.CreatedAt = now
+	cp.UpdatedAt = now
+	cp.Version = 1
+
+	if err := Create(ctx, db, cp); err != nil {
+		return Video{}, fmt.Errorf("creating copy of video[%s]: %w", v.ID, err)
+	}
+
+	for i := range rends {
+		rends[i].VideoID = cp.ID
+		rends[i].CreatedAt = now
+	}
+	if err := ReplaceRenditions(ctx, db, cp.ID, rends); err != nil {
+		return Video{}, err
+	}
+
+	for i := range chs {
+		chs[i].VideoID = cp.ID
+		chs[i].CreatedAt = now
+	}
+	if err := ReplaceChapters(ctx, db, cp.ID, chs); err != nil {
+		return Video{}, err
+	}
+
+	return cp, nil
+}
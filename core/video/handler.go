@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,11 +14,13 @@ import (
 	"github.com/jatolentino/tutorialspoint/core/claims"
 	"github.com/jatolentino/tutorialspoint/core/course"
 	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/rate"
 	"github.com/jatolentino/tutorialspoint/validate"
 )
 
-// HandleCreate allows administrators to insert a new video in a course.
-func HandleCreate(db *sqlx.DB) web.Handler {
+// HandleCreate allows administrators, and the instructor of the course,
+// to insert a new video in a course.
+func HandleCreate(db *sqlx.DB, l Listener) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		var v VideoNew
 		if err := web.Decode(w, r, &v); err != nil {
@@ -28,6 +31,16 @@ func HandleCreate(db *sqlx.DB) web.Handler {
 			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
 		}
 
+		if err := course.CheckManager(ctx, db, v.CourseID); err != nil {
+			return err
+		}
+
+		if v.SectionID != "" {
+			if err := checkSection(ctx, db, v.SectionID, v.CourseID); err != nil {
+				return err
+			}
+		}
+
 		now := time.Now().UTC()
 
 		video := Video{
@@ -38,11 +51,35 @@ func HandleCreate(db *sqlx.DB) web.Handler {
 			Description: v.Description,
 			Free:        v.Free,
 			URL:         v.URL,
+			Provider:    v.Provider,
 			ImageURL:    v.ImageURL,
+			Status:      StatusReady,
+			JobID:       v.JobID,
+			Published:   true,
 			CreatedAt:   now,
 			UpdatedAt:   now,
 		}
 
+		if v.SectionID != "" {
+			video.SectionID = &v.SectionID
+		}
+		if v.Published != nil {
+			video.Published = *v.Published
+		}
+
+		if video.Provider == "" {
+			video.Provider = ProviderNative
+		}
+
+		if err := checkSource(video.Provider, video.URL); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		// Videos sent to the transcoding provider can't be played until it calls back.
+		if video.JobID != "" {
+			video.Status = StatusProcessing
+		}
+
 		if err := Create(ctx, db, video); err != nil {
 			err := fmt.Errorf("creating video: %w", err)
 			if errors.Is(err, database.ErrDBDuplicatedEntry) {
@@ -51,12 +88,16 @@ func HandleCreate(db *sqlx.DB) web.Handler {
 			return err
 		}
 
+		l.VideoChanged(video)
+
 		return web.Respond(ctx, w, video, http.StatusCreated)
 	}
 }
 
-// HandleUpdate allows administrators to update videos' information.
-func HandleUpdate(db *sqlx.DB) web.Handler {
+// HandleUpdate allows administrators, and the instructor of the course,
+// to update videos' information. Edits are recorded in the revisions of
+// the course.
+func HandleUpdate(db *sqlx.DB, l Listener) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		videoID := web.Param(r, "id")
 
@@ -82,8 +123,34 @@ func HandleUpdate(db *sqlx.DB) web.Handler {
 			return err
 		}
 
-		if vup.CourseID != nil {
+		if err := course.CheckManager(ctx, db, video.CourseID); err != nil {
+			return err
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		before := video
+
+		// Instructors can move videos only between the courses they teach.
+		// Sections belong to a course, so moved videos leave theirs.
+		if vup.CourseID != nil && *vup.CourseID != video.CourseID {
+			if err := course.CheckManager(ctx, db, *vup.CourseID); err != nil {
+				return err
+			}
 			video.CourseID = *vup.CourseID
+			video.SectionID = nil
+		}
+		if vup.SectionID != nil {
+			video.SectionID = nil
+			if *vup.SectionID != "" {
+				if err := checkSection(ctx, db, *vup.SectionID, video.CourseID); err != nil {
+					return err
+				}
+				video.SectionID = vup.SectionID
+			}
 		}
 		if vup.Index != nil {
 			video.Index = *vup.Index
@@ -100,24 +167,286 @@ func HandleUpdate(db *sqlx.DB) web.Handler {
 		if vup.URL != nil {
 			video.URL = *vup.URL
 		}
+		if vup.Provider != nil {
+			video.Provider = *vup.Provider
+		}
 		if vup.ImageURL != nil {
 			video.ImageURL = *vup.ImageURL
 		}
+		if vup.Published != nil {
+			video.Published = *vup.Published
+		}
+		if vup.JobID != nil && *vup.JobID != video.JobID {
+			video.JobID = *vup.JobID
+			video.Status = StatusReady
+			if video.JobID != "" {
+				video.Status = StatusProcessing
+			}
+		}
+
+		if vup.URL != nil || vup.Provider != nil {
+			if err := checkSource(video.Provider, video.URL); err != nil {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+		}
 		video.UpdatedAt = time.Now().UTC()
 
-		if video, err = Update(ctx, db, video); err != nil {
+		rev, changed, err := course.NewRevision(video.CourseID, &video.ID, clm.UserID, metadata(before), metadata(video), video.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if video, err = Update(ctx, tx, video); err != nil {
+				return err
+			}
+
+			if !changed {
+				return nil
+			}
+			return course.CreateRevision(ctx, tx, rev)
+		})
+
+		if err != nil {
 			return fmt.Errorf("updating video[%s]: %w", videoID, err)
 		}
 
+		l.VideoChanged(video)
+
 		return web.Respond(ctx, w, video, http.StatusOK)
 	}
 }
 
-// HandleList returns all the available videos.
+// HandleDelete allows administrators, and the instructor of the course,
+// to remove a video. The videos which follow it in the course are moved
+// back, so that the indexes of the course have no gaps.
+func HandleDelete(db *sqlx.DB, l Listener) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := checkManager(ctx, db, videoID); err != nil {
+			return err
+		}
+
+		var video Video
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			now := time.Now().UTC()
+			var err error
+			if video, err = Delete(ctx, tx, videoID, now); err != nil {
+				return err
+			}
+
+			return CompactIndexes(ctx, tx, video.CourseID, video.Index, now)
+		})
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		l.VideoChanged(video)
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleListDeleted allows administrators to list the deleted videos,
+// the most recently deleted first.
+func HandleListDeleted(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videos, err := FetchDeleted(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, videos, http.StatusOK)
+	}
+}
+
+// HandleRestore allows administrators to restore a deleted video.
+// The video is moved at the end of its course, since its index may
+// have been taken meanwhile.
+func HandleRestore(db *sqlx.DB, l Listener) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var video Video
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			deleted, err := FetchDeletedForUpdate(ctx, tx, videoID)
+			if err != nil {
+				return err
+			}
+
+			videos, err := FetchAllByCourseForUpdate(ctx, tx, deleted.CourseID)
+			if err != nil {
+				return err
+			}
+
+			video, err = Restore(ctx, tx, videoID, len(videos)+1, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		l.VideoChanged(video)
+
+		return web.Respond(ctx, w, video, http.StatusOK)
+	}
+}
+
+// errOrderMismatch is returned when an order doesn't list exactly the
+// videos of a course.
+var errOrderMismatch = errors.New("the order must list every video of the course once")
+
+// HandleReorder allows administrators, and the instructor of the
+// course, to reorder all the videos of a course at once, rather than
+// updating their indexes one by one. The videos of the course are
+// returned in their new order.
+func HandleReorder(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var ord VideosOrder
+		if err := web.Decode(w, r, &ord); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(ord); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := course.CheckManager(ctx, db, courseID); err != nil {
+			return err
+		}
+
+		var videos []Video
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			current, err := FetchAllByCourseForUpdate(ctx, tx, courseID)
+			if err != nil {
+				return err
+			}
+
+			ids := make(map[string]bool, len(current))
+			for _, v := range current {
+				ids[v.ID] = true
+			}
+			for _, id := range ord.VideoIDs {
+				if !ids[id] {
+					return errOrderMismatch
+				}
+				delete(ids, id)
+			}
+			if len(ids) > 0 {
+				return errOrderMismatch
+			}
+
+			if err := Reorder(ctx, tx, courseID, ord.VideoIDs, time.Now().UTC()); err != nil {
+				return err
+			}
+
+			videos, err = FetchAllByCourse(ctx, tx, courseID)
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, errOrderMismatch) {
+				return weberr.NewError(err, errOrderMismatch.Error(), http.StatusUnprocessableEntity)
+			}
+			return fmt.Errorf("reordering videos of course[%s]: %w", courseID, err)
+		}
+
+		return web.Respond(ctx, w, videos, http.StatusOK)
+	}
+}
+
+// HandleReplaceChapters allows administrators, and the instructor of
+// the course, to set the chapters of a video, replacing the current
+// ones. Chapters can't start at the same time.
+func HandleReplaceChapters(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var cup ChaptersUp
+		if err := web.Decode(w, r, &cup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(cup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		chs := cup.Chapters
+		sort.Slice(chs, func(i, j int) bool { return chs[i].Start < chs[j].Start })
+
+		now := time.Now().UTC()
+		for i := range chs {
+			if i > 0 && chs[i].Start == chs[i-1].Start {
+				err := fmt.Errorf("two chapters start at %ds", chs[i].Start)
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+			chs[i].VideoID = videoID
+			chs[i].CreatedAt = now
+		}
+
+		video, err := Fetch(ctx, db, videoID)
+		if err != nil {
+			err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if err := course.CheckManager(ctx, db, video.CourseID); err != nil {
+			return err
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			return ReplaceChapters(ctx, tx, videoID, chs)
+		})
+		if err != nil {
+			return err
+		}
+
+		if chs == nil {
+			chs = []Chapter{}
+		}
+
+		return web.Respond(ctx, w, chs, http.StatusOK)
+	}
+}
+
+// HandleList returns all the available videos, drafts included for
+// administrators only.
 // It doesn't return the actual URL of videos, so it can be safely exposed.
 func HandleList(db *sqlx.DB) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-		videos, err := FetchAll(ctx, db)
+		fetch := FetchAllPublished
+		if claims.IsAdmin(ctx) {
+			fetch = FetchAll
+		}
+
+		videos, err := fetch(ctx, db)
 		if err != nil {
 			return fmt.Errorf("fetching all videos: %w", err)
 		}
@@ -126,7 +455,9 @@ func HandleList(db *sqlx.DB) web.Handler {
 	}
 }
 
-// HandleListByCourse returns all the available videos of a course.
+// HandleListByCourse returns all the available videos of a course,
+// drafts included for administrators and the instructor of the course.
+// With group=section the videos are grouped by section, see Outline.
 // It doesn't return the actual URL of videos, so it can be safely exposed.
 func HandleListByCourse(db *sqlx.DB) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -136,12 +467,43 @@ func HandleListByCourse(db *sqlx.DB) web.Handler {
 			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
 		}
 
-		videos, err := FetchAllByCourse(ctx, db, courseID)
+		group := r.URL.Query().Get("group")
+		if group != "" && group != "section" {
+			return weberr.BadRequest(fmt.Errorf("unknown grouping %q", group))
+		}
+
+		fetch := FetchPublishedByCourse
+		if claims.IsAdmin(ctx) {
+			fetch = FetchAllByCourse
+		} else if claims.IsInstructor(ctx) {
+			crs, err := course.Fetch(ctx, db, courseID)
+			if err != nil && !errors.Is(err, database.ErrDBNotFound) {
+				return err
+			}
+			if err == nil && course.CanManage(ctx, crs) {
+				fetch = FetchAllByCourse
+			}
+		}
+
+		videos, err := fetch(ctx, db, courseID)
 		if err != nil {
 			return fmt.Errorf("fetching all videos by course[%s]: %w", courseID, err)
 		}
 
-		return web.Respond(ctx, w, videos, http.StatusOK)
+		if err := withLock(ctx, db, courseID, videos); err != nil {
+			return err
+		}
+
+		if group == "" {
+			return web.Respond(ctx, w, videos, http.StatusOK)
+		}
+
+		sections, err := FetchSectionsByCourse(ctx, db, courseID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, outline(sections, videos), http.StatusOK)
 	}
 }
 
@@ -155,22 +517,28 @@ func HandleShow(db *sqlx.DB) web.Handler {
 			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
 		}
 
-		video, err := Fetch(ctx, db, videoID)
+		video, err := fetchVisible(ctx, db, videoID)
 		if err != nil {
-			err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
-			if errors.Is(err, database.ErrDBNotFound) {
-				return weberr.NotFound(err)
-			}
 			return err
 		}
 
-		return web.Respond(ctx, w, video, http.StatusOK)
+		vs := []Video{video}
+		if err := withLock(ctx, db, video.CourseID, vs); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, vs[0], http.StatusOK)
 	}
 }
 
+// TrackFetcher returns the caption tracks of a video.
+type TrackFetcher func(ctx context.Context, videoID string) ([]Track, error)
+
 // HandleShowFull returns all data useful for presenting the video to users.
 // This returns the URL also, so only owners of a video are allowed to call this.
-func HandleShowFull(db *sqlx.DB) web.Handler {
+// When prerequisites are enforced, the videos of locked courses can't be
+// played until the prerequisites are completed.
+func HandleShowFull(db *sqlx.DB, tracks TrackFetcher, enforcePrerequisites bool) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		videoID := web.Param(r, "id")
 
@@ -183,33 +551,34 @@ func HandleShowFull(db *sqlx.DB) web.Handler {
 			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
 		}
 
-		video, err := Fetch(ctx, db, videoID)
+		video, crs, err := fetchPlayable(ctx, db, videoID, clm.UserID)
 		if err != nil {
-			err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
-			if errors.Is(err, database.ErrDBNotFound) {
-				return weberr.NotFound(err)
-			}
 			return err
 		}
 
-		var crs course.Course
-		if video.Free {
-			crs, err = course.Fetch(ctx, db, video.CourseID)
-			if err != nil {
-				return fmt.Errorf("fetching course of free video[%s]: %w", video.ID, err)
-			}
-		} else {
-			crs, err = course.FetchOwned(ctx, db, video.CourseID, clm.UserID)
-			if err != nil {
-				err := fmt.Errorf("fetching course[%s] owned by user[%s]: %w", video.CourseID, clm.UserID, err)
-				if errors.Is(err, database.ErrDBNotFound) {
-					return weberr.NewError(err, "access forbidden", http.StatusForbidden)
-				}
-				return err
-			}
+		cs := []course.Course{crs}
+		if err := course.WithLocks(ctx, db, cs); err != nil {
+			return fmt.Errorf("fetching lock of course[%s]: %w", crs.ID, err)
 		}
+		crs = cs[0]
 
-		videos, err := FetchAllByCourse(ctx, db, video.CourseID)
+		if crs.Locked && enforcePrerequisites {
+			err := fmt.Errorf("user[%s] didn't complete the prerequisites of course[%s]", clm.UserID, crs.ID)
+			return weberr.NewError(err, "prerequisites not completed", http.StatusForbidden)
+		}
+		video.Locked = crs.Locked
+
+		wt := Watch{UserID: clm.UserID, VideoID: video.ID, StartedAt: time.Now().UTC()}
+		if err := CreateWatch(ctx, db, wt); err != nil {
+			return err
+		}
+
+		fetch := FetchPublishedByCourse
+		if claims.IsAdmin(ctx) {
+			fetch = FetchAllByCourse
+		}
+
+		videos, err := fetch(ctx, db, video.CourseID)
 		if err != nil {
 			err := fmt.Errorf("fetching all videos of course[%s]: %w", video.CourseID, err)
 			if errors.Is(err, database.ErrDBNotFound) {
@@ -217,24 +586,55 @@ func HandleShowFull(db *sqlx.DB) web.Handler {
 			}
 			return err
 		}
+		for i := range videos {
+			videos[i].Locked = crs.Locked
+		}
 
 		progress, err := FetchUserProgressByCourse(ctx, db, clm.UserID, video.CourseID)
 		if err != nil {
 			return fmt.Errorf("fetching user[%s] progress by course[%s]: %w", clm.UserID, video.CourseID, err)
 		}
 
+		rends, err := FetchRenditions(ctx, db, video.ID)
+		if err != nil {
+			return fmt.Errorf("fetching renditions of video[%s]: %w", video.ID, err)
+		}
+
+		chapters, err := FetchChapters(ctx, db, video.ID)
+		if err != nil {
+			return fmt.Errorf("fetching chapters of video[%s]: %w", video.ID, err)
+		}
+
+		play, err := playback(video.Provider, video.URL)
+		if err != nil {
+			return fmt.Errorf("building playback of video[%s]: %w", video.ID, err)
+		}
+
+		captions, err := tracks(ctx, video.ID)
+		if err != nil {
+			return fmt.Errorf("fetching captions of video[%s]: %w", video.ID, err)
+		}
+
 		fullVideo := struct {
 			Course      course.Course `json:"course"`
 			Video       Video         `json:"video"`
 			AllVideos   []Video       `json:"allVideos"`
 			AllProgress []Progress    `json:"allProgress"`
 			URL         string        `json:"url"`
+			Renditions  []Rendition   `json:"renditions"`
+			Chapters    []Chapter     `json:"chapters"`
+			Playback    Playback      `json:"playback"`
+			Captions    []Track       `json:"captions"`
 		}{
 			Course:      crs,
 			Video:       video,
 			AllVideos:   videos,
 			AllProgress: progress,
 			URL:         video.URL,
+			Renditions:  rends,
+			Chapters:    chapters,
+			Playback:    play,
+			Captions:    captions,
 		}
 
 		return web.Respond(ctx, w, fullVideo, http.StatusOK)
@@ -252,12 +652,8 @@ func HandleShowFree(db *sqlx.DB) web.Handler {
 			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
 		}
 
-		video, err := Fetch(ctx, db, videoID)
+		video, err := fetchVisible(ctx, db, videoID)
 		if err != nil {
-			err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
-			if errors.Is(err, database.ErrDBNotFound) {
-				return weberr.NotFound(err)
-			}
 			return err
 		}
 
@@ -265,19 +661,44 @@ func HandleShowFree(db *sqlx.DB) web.Handler {
 			return weberr.NewError(err, "access forbidden", http.StatusForbidden)
 		}
 
+		if video.Status != StatusReady {
+			return weberr.NewError(fmt.Errorf("video[%s] is %s", video.ID, video.Status), "video is not playable yet", http.StatusConflict)
+		}
+
 		crs, err := course.Fetch(ctx, db, video.CourseID)
 		if err != nil {
 			return fmt.Errorf("fetching course[%s]: %w", video.CourseID, err)
 		}
 
+		rends, err := FetchRenditions(ctx, db, video.ID)
+		if err != nil {
+			return fmt.Errorf("fetching renditions of video[%s]: %w", video.ID, err)
+		}
+
+		chapters, err := FetchChapters(ctx, db, video.ID)
+		if err != nil {
+			return fmt.Errorf("fetching chapters of video[%s]: %w", video.ID, err)
+		}
+
+		play, err := playback(video.Provider, video.URL)
+		if err != nil {
+			return fmt.Errorf("building playback of video[%s]: %w", video.ID, err)
+		}
+
 		freeVideo := struct {
-			Course course.Course `json:"course"`
-			Video  Video         `json:"video"`
-			URL    string        `json:"url"`
+			Course     course.Course `json:"course"`
+			Video      Video         `json:"video"`
+			URL        string        `json:"url"`
+			Renditions []Rendition   `json:"renditions"`
+			Chapters   []Chapter     `json:"chapters"`
+			Playback   Playback      `json:"playback"`
 		}{
-			Course: crs,
-			Video:  video,
-			URL:    video.URL,
+			Course:     crs,
+			Video:      video,
+			URL:        video.URL,
+			Renditions: rends,
+			Chapters:   chapters,
+			Playback:   play,
 		}
 
 		return web.Respond(ctx, w, freeVideo, http.StatusOK)
@@ -285,7 +706,8 @@ func HandleShowFree(db *sqlx.DB) web.Handler {
 }
 
 // HandleUpdateProgress inserts a progress on a video for a specific user.
-func HandleUpdateProgress(db *sqlx.DB) web.Handler {
+// Players report often, so each user is limited by the passed limiter.
+func HandleUpdateProgress(db *sqlx.DB, limiter *rate.Limiter) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 		videoID := web.Param(r, "id")
 
@@ -294,6 +716,11 @@ func HandleUpdateProgress(db *sqlx.DB) web.Handler {
 			return weberr.NotAuthorized(errors.New("user not authenticated"))
 		}
 
+		if !limiter.Check(clm.UserID) {
+			err := errors.New("too many requests")
+			return weberr.NewError(err, err.Error(), http.StatusTooManyRequests)
+		}
+
 		var up ProgressUp
 		if err := web.Decode(w, r, &up); err != nil {
 			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
@@ -303,7 +730,9 @@ func HandleUpdateProgress(db *sqlx.DB) web.Handler {
 			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
 		}
 
-		if err := UpdateProgress(ctx, db, clm.UserID, videoID, up.Progress); err != nil {
+		p := newProgress(videoID, clm.UserID, up, time.Now().UTC())
+
+		if err := UpdateProgress(ctx, db, p); err != nil {
 			return fmt.Errorf("updating video[%s] progress for user[%s]: %w", videoID, clm.UserID, err)
 		}
 
@@ -311,6 +740,127 @@ func HandleUpdateProgress(db *sqlx.DB) web.Handler {
 	}
 }
 
+// HandleUpdateProgressBatch inserts the progress reported by a player
+// since its last report. The reports of the same video are merged
+// before writing, so that a batch costs a single statement.
+// It shares the limiter of HandleUpdateProgress.
+func HandleUpdateProgressBatch(db *sqlx.DB, limiter *rate.Limiter) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if !limiter.Check(clm.UserID) {
+			err := errors.New("too many requests")
+			return weberr.NewError(err, err.Error(), http.StatusTooManyRequests)
+		}
+
+		var batch ProgressBatch
+		if err := web.Decode(w, r, &batch); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(batch); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		for _, rep := range batch.Reports {
+			if err := validate.CheckID(rep.VideoID); err != nil {
+				return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+			}
+		}
+
+		ps := coalesceProgress(clm.UserID, batch.Reports, time.Now().UTC())
+
+		if err := UpdateProgressBatch(ctx, db, ps); err != nil {
+			return fmt.Errorf("updating progress batch for user[%s]: %w", clm.UserID, err)
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// newProgress builds the progress reported by a user at now.
+func newProgress(videoID string, userID string, up ProgressUp, now time.Time) Progress {
+	p := Progress{
+		VideoID:    videoID,
+		UserID:     userID,
+		Progress:   up.Progress,
+		Position:   up.Position,
+		Watched:    up.Watched,
+		ReportedAt: now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	// Timestamps from the future would freeze the position.
+	if up.ReportedAt != nil && up.ReportedAt.Before(now) {
+		p.ReportedAt = up.ReportedAt.UTC()
+	}
+	return p
+}
+
+// coalesceProgress merges the reports of the same video the way
+// UpdateProgress does: the completion and the seconds watched take the
+// highest value, the position the most recent one. Reports with the
+// same timestamp are ordered as they were sent.
+func coalesceProgress(userID string, reports []ProgressReport, now time.Time) []Progress {
+	var ps []Progress
+	index := make(map[string]int, len(reports))
+
+	for _, rep := range reports {
+		p := newProgress(rep.VideoID, userID, rep.ProgressUp, now)
+
+		i, ok := index[p.VideoID]
+		if !ok {
+			index[p.VideoID] = len(ps)
+			ps = append(ps, p)
+			continue
+		}
+
+		cur := &ps[i]
+		cur.Progress = max(cur.Progress, p.Progress)
+		cur.Watched = max(cur.Watched, p.Watched)
+		if !p.ReportedAt.Before(cur.ReportedAt) {
+			cur.Position = p.Position
+			cur.ReportedAt = p.ReportedAt
+		}
+	}
+
+	return ps
+}
+
+// HandleShowResume returns where the user left a video. Videos never
+// watched resume from the start.
+func HandleShowResume(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "id")
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		p, err := FetchProgress(ctx, db, videoID, clm.UserID)
+		if err != nil && !errors.Is(err, database.ErrDBNotFound) {
+			return err
+		}
+
+		res := Resume{
+			VideoID:  videoID,
+			Position: p.Position,
+			Watched:  p.Watched,
+			Progress: p.Progress,
+		}
+
+		return web.Respond(ctx, w, res, http.StatusOK)
+	}
+}
+
 // HandleListProgressByCourse returns all the progress of a user on a specific course.
 func HandleListProgressByCourse(db *sqlx.DB) web.Handler {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -333,3 +883,112 @@ func HandleListProgressByCourse(db *sqlx.DB) web.Handler {
 		return web.Respond(ctx, w, progress, http.StatusOK)
 	}
 }
+
+// fetchPlayable returns the video and its course when the video can be
+// played by the user, i.e. when it's ready and free or its course owned.
+func fetchPlayable(ctx context.Context, db sqlx.ExtContext, videoID string, userID string) (Video, course.Course, error) {
+	video, err := fetchVisible(ctx, db, videoID)
+	if err != nil {
+		return Video{}, course.Course{}, err
+	}
+
+	if video.Status != StatusReady {
+		return Video{}, course.Course{}, weberr.NewError(fmt.Errorf("video[%s] is %s", video.ID, video.Status), "video is not playable yet", http.StatusConflict)
+	}
+
+	if video.Free {
+		crs, err := course.Fetch(ctx, db, video.CourseID)
+		if err != nil {
+			return Video{}, course.Course{}, fmt.Errorf("fetching course of free video[%s]: %w", video.ID, err)
+		}
+		return video, crs, nil
+	}
+
+	crs, err := course.FetchOwned(ctx, db, video.CourseID, userID)
+	if err != nil {
+		err := fmt.Errorf("fetching course[%s] owned by user[%s]: %w", video.CourseID, userID, err)
+		if !errors.Is(err, database.ErrDBNotFound) {
+			return Video{}, course.Course{}, err
+		}
+
+		// Tell users whose rental expired that they can renew it.
+		if _, aerr := course.FetchAccess(ctx, db, video.CourseID, userID); aerr == nil {
+			return Video{}, course.Course{}, weberr.NewError(err, "access expired", http.StatusForbidden)
+		}
+		return Video{}, course.Course{}, weberr.NewError(err, "access forbidden", http.StatusForbidden)
+	}
+
+	return video, crs, nil
+}
+
+// checkManager verifies that the user of the session can manage the
+// course of the video, see course.CheckManager.
+func checkManager(ctx context.Context, db sqlx.ExtContext, videoID string) error {
+	if claims.IsAdmin(ctx) {
+		return nil
+	}
+
+	video, err := Fetch(ctx, db, videoID)
+	if err != nil {
+		err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return weberr.NotFound(err)
+		}
+		return err
+	}
+
+	return course.CheckManager(ctx, db, video.CourseID)
+}
+
+// withLock sets whether the videos of a course are locked for the user
+// of the session, see course.WithLocks.
+func withLock(ctx context.Context, db sqlx.ExtContext, courseID string, videos []Video) error {
+	if len(videos) == 0 {
+		return nil
+	}
+
+	crs, err := course.Fetch(ctx, db, courseID)
+	if err != nil {
+		return fmt.Errorf("fetching course[%s]: %w", courseID, err)
+	}
+
+	cs := []course.Course{crs}
+	if err := course.WithLocks(ctx, db, cs); err != nil {
+		return fmt.Errorf("fetching lock of course[%s]: %w", courseID, err)
+	}
+
+	for i := range videos {
+		videos[i].Locked = cs[0].Locked
+	}
+
+	return nil
+}
+
+// fetchVisible returns a video given its id, drafts being found only by
+// those who can manage its course.
+func fetchVisible(ctx context.Context, db sqlx.ExtContext, videoID string) (Video, error) {
+	video, err := Fetch(ctx, db, videoID)
+	if err != nil {
+		err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Video{}, weberr.NotFound(err)
+		}
+		return Video{}, err
+	}
+
+	if video.Published || claims.IsAdmin(ctx) {
+		return video, nil
+	}
+
+	if claims.IsInstructor(ctx) {
+		crs, err := course.Fetch(ctx, db, video.CourseID)
+		if err != nil && !errors.Is(err, database.ErrDBNotFound) {
+			return Video{}, err
+		}
+		if err == nil && course.CanManage(ctx, crs) {
+			return video, nil
+		}
+	}
+
+	return Video{}, weberr.NotFound(fmt.Errorf("video[%s] is a draft", video.ID))
+}
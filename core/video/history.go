@@ -0,0 +1,44 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jmoiron/sqlx"
+)
+
+// Limits of the pages of the watch history.
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// HandleListHistory returns the videos recently watched by the
+// authenticated user, with their course and the progress made, so that
+// users can continue watching. Each video is listed once, at the last
+// time it was started. Videos are paginated via the page and limit
+// query parameters.
+func HandleListHistory(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		page, err := web.ParsePage(r, defaultHistoryLimit, maxHistoryLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		ws, err := FetchWatched(ctx, db, clm.UserID, page.Limit, page.Offset())
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, ws, http.StatusOK)
+	}
+}
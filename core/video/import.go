@@ -0,0 +1,267 @@
+package video
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxManifestSize is the largest manifest accepted, in bytes.
+const maxManifestSize = 10 << 20
+
+// HandleImport allows administrators to create many videos at once,
+// e.g. when migrating an existing catalog. The manifest is either JSON,
+// see ImportManifest, or CSV when sent as text/csv: its header names
+// the columns after the JSON fields, e.g. courseId,index,name,url,free.
+// Every row is validated and the videos are created in a single
+// transaction only when all of them are valid, otherwise the report
+// tells what is wrong with each row.
+func HandleImport(db *sqlx.DB, l Listener) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var rows []ImportRow
+		var results []ImportResult
+
+		mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mt == "text/csv" {
+			var err error
+			r.Body = http.MaxBytesReader(w, r.Body, maxManifestSize)
+			if rows, results, err = parseCSV(r.Body); err != nil {
+				return weberr.BadRequest(fmt.Errorf("unable to parse manifest: %w", err))
+			}
+		} else {
+			var m ImportManifest
+			if err := web.Decode(w, r, &m); err != nil {
+				return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+			}
+			rows = m.Videos
+			results = make([]ImportResult, len(rows))
+			for i := range results {
+				results[i].Row = i + 1
+			}
+		}
+
+		if len(rows) == 0 {
+			err := errors.New("manifest has no videos")
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+		if len(rows) > 1000 {
+			err := errors.New("manifest has more than 1000 videos")
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		now := time.Now().UTC()
+		videos, err := checkImport(ctx, db, rows, results, now)
+		if err != nil {
+			return err
+		}
+
+		report := ImportReport{Results: results}
+		for _, res := range results {
+			if res.Error != "" {
+				return web.Respond(ctx, w, report, http.StatusUnprocessableEntity)
+			}
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			for _, v := range videos {
+				if err := Create(ctx, tx, v); err != nil {
+					return fmt.Errorf("creating video %q of course[%s]: %w", v.Name, v.CourseID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "videos changed meanwhile, try again", http.StatusConflict)
+			}
+			return err
+		}
+
+		for i, v := range videos {
+			l.VideoChanged(v)
+			report.Results[i].VideoID = v.ID
+		}
+		report.Created = len(videos)
+
+		return web.Respond(ctx, w, report, http.StatusCreated)
+	}
+}
+
+// checkImport validates the rows, which must be neither already parsed
+// with errors nor taking the index of another video, and returns the
+// videos to create. The errors are set on the results.
+func checkImport(ctx context.Context, db sqlx.ExtContext, rows []ImportRow, results []ImportResult, now time.Time) ([]Video, error) {
+	// The indexes taken, by course, nil for the missing courses.
+	taken := make(map[string]map[int]bool)
+
+	videos := make([]Video, len(rows))
+	for i, row := range rows {
+		if results[i].Error != "" {
+			continue
+		}
+
+		v := Video{
+			ID:          validate.GenerateID(),
+			CourseID:    row.CourseID,
+			Index:       row.Index,
+			Name:        row.Name,
+			Description: row.Description,
+			Free:        row.Free,
+			URL:         row.URL,
+			Provider:    row.Provider,
+			ImageURL:    row.ImageURL,
+			Status:      StatusReady,
+			Published:   true,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if row.Published != nil {
+			v.Published = *row.Published
+		}
+		if v.Provider == "" {
+			v.Provider = ProviderNative
+		}
+		videos[i] = v
+
+		if err := validate.Check(row); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if err := validate.CheckID(row.CourseID); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if err := checkSource(v.Provider, v.URL); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		idxs, ok := taken[v.CourseID]
+		if !ok {
+			var err error
+			if idxs, err = courseIndexes(ctx, db, v.CourseID); err != nil {
+				return nil, err
+			}
+			taken[v.CourseID] = idxs
+		}
+
+		switch {
+		case idxs == nil:
+			results[i].Error = fmt.Sprintf("course[%s] not found", v.CourseID)
+		case idxs[v.Index]:
+			results[i].Error = fmt.Sprintf("index %d of course[%s] already taken", v.Index, v.CourseID)
+		default:
+			idxs[v.Index] = true
+		}
+	}
+
+	return videos, nil
+}
+
+// courseIndexes returns the indexes of the videos of a course, nil when
+// the course doesn't exist.
+func courseIndexes(ctx context.Context, db sqlx.ExtContext, courseID string) (map[int]bool, error) {
+	if _, err := course.Fetch(ctx, db, courseID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetching course[%s]: %w", courseID, err)
+	}
+
+	videos, err := FetchAllByCourse(ctx, db, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	idxs := make(map[int]bool, len(videos))
+	for _, v := range videos {
+		idxs[v.Index] = true
+	}
+
+	return idxs, nil
+}
+
+// parseCSV reads the rows of a CSV manifest. Rows whose values can't be
+// parsed are returned with the error set on their results.
+func parseCSV(r io.Reader) ([]ImportRow, []ImportResult, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[name] = i
+	}
+	for _, name := range []string{"courseId", "index", "name"} {
+		if _, ok := cols[name]; !ok {
+			return nil, nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var rows []ImportRow
+	var results []ImportResult
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		get := func(name string) string {
+			if i, ok := cols[name]; ok {
+				return rec[i]
+			}
+			return ""
+		}
+
+		row := ImportRow{
+			CourseID:    get("courseId"),
+			Name:        get("name"),
+			Description: get("description"),
+			URL:         get("url"),
+			Provider:    get("provider"),
+			ImageURL:    get("imageUrl"),
+		}
+		res := ImportResult{Row: len(rows) + 1}
+
+		if row.Index, err = strconv.Atoi(get("index")); err != nil {
+			res.Error = fmt.Sprintf("invalid index %q", get("index"))
+		}
+		if s := get("free"); s != "" && res.Error == "" {
+			if row.Free, err = strconv.ParseBool(s); err != nil {
+				res.Error = fmt.Sprintf("invalid free %q", s)
+			}
+		}
+		if s := get("published"); s != "" && res.Error == "" {
+			published, err := strconv.ParseBool(s)
+			if err != nil {
+				res.Error = fmt.Sprintf("invalid published %q", s)
+			}
+			row.Published = &published
+		}
+
+		rows = append(rows, row)
+		results = append(results, res)
+	}
+
+	return rows, results, nil
+}
@@ -0,0 +1,152 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandlePutPreview allows administrators, and the instructor of the
+// course, to attach a preview clip to a paid video, replacing the previous one. The clip is hosted like the
+// videos, and must be short since anyone can play it.
+func HandlePutPreview(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var pup PreviewUp
+		if err := web.Decode(w, r, &pup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(pup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if pup.Provider == "" {
+			pup.Provider = ProviderNative
+		}
+
+		if err := checkSource(pup.Provider, pup.URL); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		video, err := Fetch(ctx, db, videoID)
+		if err != nil {
+			err := fmt.Errorf("fetching video[%s]: %w", videoID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if err := course.CheckManager(ctx, db, video.CourseID); err != nil {
+			return err
+		}
+
+		if video.Free {
+			err := fmt.Errorf("video[%s] is free", video.ID)
+			return weberr.NewError(err, "free videos can be played without preview", http.StatusUnprocessableEntity)
+		}
+
+		now := time.Now().UTC()
+		p := Preview{
+			VideoID:   video.ID,
+			URL:       pup.URL,
+			Provider:  pup.Provider,
+			Start:     pup.Start,
+			End:       pup.End,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		if err := UpsertPreview(ctx, db, p); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, p, http.StatusOK)
+	}
+}
+
+// HandleDeletePreview allows administrators, and the instructor of the
+// course, to remove the preview of a video. Deleting a missing preview succeeds.
+func HandleDeletePreview(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := checkManager(ctx, db, videoID); err != nil {
+			return err
+		}
+
+		if err := DeletePreview(ctx, db, videoID); err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleShowPreview returns the preview of a video, with its course,
+// so that visitors can sample the video before buying the course.
+func HandleShowPreview(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "id")
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		video, err := fetchVisible(ctx, db, videoID)
+		if err != nil {
+			return err
+		}
+
+		p, err := FetchPreview(ctx, db, video.ID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		crs, err := course.Fetch(ctx, db, video.CourseID)
+		if err != nil {
+			return fmt.Errorf("fetching course[%s]: %w", video.CourseID, err)
+		}
+
+		play, err := playback(p.Provider, p.URL)
+		if err != nil {
+			return fmt.Errorf("building playback of preview of video[%s]: %w", video.ID, err)
+		}
+
+		preview := struct {
+			Course   course.Course `json:"course"`
+			Video    Video         `json:"video"`
+			Preview  Preview       `json:"preview"`
+			Playback Playback      `json:"playback"`
+		}{
+			Course:   crs,
+			Video:    video,
+			Preview:  p,
+			Playback: play,
+		}
+
+		return web.Respond(ctx, w, preview, http.StatusOK)
+	}
+}
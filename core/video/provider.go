@@ -0,0 +1,135 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Hosts of the videos.
+const (
+	ProviderNative  = "native"
+	ProviderMux     = "mux"
+	ProviderVimeo   = "vimeo"
+	ProviderYoutube = "youtube"
+)
+
+// Kinds of playback.
+const (
+	PlaybackStream = "stream"
+	PlaybackEmbed  = "embed"
+)
+
+// Playback tells clients how to play a video: streams are played
+// by the client player, embeds are loaded in an iframe.
+type Playback struct {
+	Provider string `json:"provider"`
+	Kind     string `json:"kind"`
+	URL      string `json:"url"`
+}
+
+// Hosts of the thumbnails of the providers.
+const (
+	muxThumbnails     = "https://image.mux.com/"
+	youtubeThumbnails = "https://i.ytimg.com/vi/"
+)
+
+var (
+	muxID     = regexp.MustCompile(`^/([A-Za-z0-9]+)\.m3u8$`)
+	vimeoID   = regexp.MustCompile(`^/(?:video/)?([0-9]+)(?:/([0-9a-f]+))?$`)
+	youtubeID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+)
+
+// checkSource verifies that the url is valid for the passed provider.
+func checkSource(provider string, raw string) error {
+	_, err := playback(provider, raw)
+	return err
+}
+
+// playback returns how to play the video at raw, hosted by provider.
+func playback(provider string, raw string) (Playback, error) {
+	if provider == ProviderNative {
+		return Playback{Provider: provider, Kind: PlaybackStream, URL: raw}, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" {
+		return Playback{}, fmt.Errorf("%s videos need an https url", provider)
+	}
+	host := strings.TrimPrefix(u.Host, "www.")
+
+	switch provider {
+	case ProviderMux:
+		// https://stream.mux.com/{playback id}.m3u8
+		if host != "stream.mux.com" || !muxID.MatchString(u.Path) {
+			return Playback{}, errors.New("mux videos need a https://stream.mux.com/{id}.m3u8 url")
+		}
+		return Playback{Provider: provider, Kind: PlaybackStream, URL: raw}, nil
+
+	case ProviderVimeo:
+		// https://vimeo.com/{id}, https://player.vimeo.com/video/{id}
+		// and the same for unlisted videos, followed by their hash.
+		m := vimeoID.FindStringSubmatch(u.Path)
+		if (host != "vimeo.com" && host != "player.vimeo.com") || m == nil {
+			return Playback{}, errors.New("vimeo videos need a https://vimeo.com/{id} url")
+		}
+		embed := "https://player.vimeo.com/video/" + m[1]
+		if h := m[2]; h != "" {
+			embed += "?h=" + h
+		} else if h := u.Query().Get("h"); h != "" {
+			embed += "?h=" + url.QueryEscape(h)
+		}
+		return Playback{Provider: provider, Kind: PlaybackEmbed, URL: embed}, nil
+
+	case ProviderYoutube:
+		// https://www.youtube.com/watch?v={id}, https://youtu.be/{id}
+		// and https://www.youtube.com/embed/{id}.
+		id := youtubeVideoID(host, u)
+		if !youtubeID.MatchString(id) {
+			return Playback{}, errors.New("youtube videos need a https://www.youtube.com/watch?v={id} url")
+		}
+		return Playback{Provider: provider, Kind: PlaybackEmbed, URL: "https://www.youtube-nocookie.com/embed/" + id}, nil
+
+	default:
+		return Playback{}, fmt.Errorf("provider %s is not supported", provider)
+	}
+}
+
+// youtubeVideoID returns the id of the youtube video at u.
+func youtubeVideoID(host string, u *url.URL) string {
+	switch {
+	case host == "youtu.be":
+		return strings.TrimPrefix(u.Path, "/")
+	case host == "youtube.com" && u.Path == "/watch":
+		return u.Query().Get("v")
+	case host == "youtube.com" && strings.HasPrefix(u.Path, "/embed/"):
+		return strings.TrimPrefix(u.Path, "/embed/")
+	}
+	return ""
+}
+
+// Thumbnail returns the thumbnail served by the provider for the video
+// at raw, or an empty string for the providers which don't serve one
+// at a known URL.
+func Thumbnail(provider string, raw string) string {
+	if _, err := playback(provider, raw); err != nil {
+		return ""
+	}
+
+	u, _ := url.Parse(raw)
+	switch provider {
+	case ProviderMux:
+		return muxThumbnails + muxID.FindStringSubmatch(u.Path)[1] + "/thumbnail.jpg"
+	case ProviderYoutube:
+		return youtubeThumbnails + youtubeVideoID(strings.TrimPrefix(u.Host, "www."), u) + "/hqdefault.jpg"
+	}
+	return ""
+}
+
+// IsProviderThumbnail reports whether raw is a thumbnail served by
+// a provider.
+func IsProviderThumbnail(raw string) bool {
+	return strings.HasPrefix(raw, muxThumbnails) || strings.HasPrefix(raw, youtubeThumbnails)
+}
@@ -0,0 +1,208 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleCreateSection allows administrators, and the instructor of the
+// course, to add a section to a course.
+func HandleCreateSection(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var sn SectionNew
+		if err := web.Decode(w, r, &sn); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(sn); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		crs, err := course.Fetch(ctx, db, courseID)
+		if err != nil {
+			err := fmt.Errorf("fetching course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if !course.CanManage(ctx, crs) {
+			err := fmt.Errorf("user trying to add a section to course[%s]", courseID)
+			return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+		}
+
+		now := time.Now().UTC()
+		s := Section{
+			ID:        validate.GenerateID(),
+			CourseID:  courseID,
+			Index:     sn.Index,
+			Title:     sn.Title,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Version:   1,
+		}
+
+		if err := CreateSection(ctx, db, s); err != nil {
+			err := fmt.Errorf("creating section of course[%s]: %w", courseID, err)
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "index already taken", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, s, http.StatusCreated)
+	}
+}
+
+// HandleUpdateSection allows administrators, and the instructor of the
+// course, to rename or move a section.
+func HandleUpdateSection(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		sectionID := web.Param(r, "id")
+
+		if err := validate.CheckID(sectionID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var sup SectionUp
+		if err := web.Decode(w, r, &sup); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(sup); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		s, err := FetchSection(ctx, db, sectionID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if err := course.CheckManager(ctx, db, s.CourseID); err != nil {
+			return err
+		}
+
+		if sup.Index != nil {
+			s.Index = *sup.Index
+		}
+		if sup.Title != nil {
+			s.Title = *sup.Title
+		}
+		s.UpdatedAt = time.Now().UTC()
+
+		if s, err = UpdateSection(ctx, db, s); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "index already taken", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, s, http.StatusOK)
+	}
+}
+
+// HandleDeleteSection allows administrators, and the instructor of the
+// course, to remove a section. Its videos are kept in the course, out
+// of any section.
+func HandleDeleteSection(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		sectionID := web.Param(r, "id")
+
+		if err := validate.CheckID(sectionID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		s, err := FetchSection(ctx, db, sectionID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if err := course.CheckManager(ctx, db, s.CourseID); err != nil {
+			return err
+		}
+
+		if _, err := DeleteSection(ctx, db, sectionID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// checkSection verifies that a video can be put in the section, which
+// must belong to the course of the video.
+func checkSection(ctx context.Context, db sqlx.ExtContext, sectionID string, courseID string) error {
+	if err := validate.CheckID(sectionID); err != nil {
+		return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	s, err := FetchSection(ctx, db, sectionID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return weberr.NewError(err, "section not found", http.StatusUnprocessableEntity)
+		}
+		return err
+	}
+
+	if s.CourseID != courseID {
+		err := fmt.Errorf("section[%s] doesn't belong to course[%s]", sectionID, courseID)
+		return weberr.NewError(err, "section not found", http.StatusUnprocessableEntity)
+	}
+
+	return nil
+}
+
+// outline groups the videos, ordered by index, in their sections.
+func outline(sections []Section, videos []Video) Outline {
+	o := Outline{
+		Sections: make([]SectionVideos, len(sections)),
+		Videos:   []Video{},
+	}
+
+	at := make(map[string]int, len(sections))
+	for i, s := range sections {
+		o.Sections[i] = SectionVideos{Section: s, Videos: []Video{}}
+		at[s.ID] = i
+	}
+
+	for _, v := range videos {
+		var i int
+		ok := false
+		if v.SectionID != nil {
+			i, ok = at[*v.SectionID]
+		}
+		if !ok {
+			o.Videos = append(o.Videos, v)
+			continue
+		}
+		o.Sections[i].Videos = append(o.Sections[i].Videos, v)
+	}
+
+	return o
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/jatolentino/tutorialspoint/database"
@@ -13,9 +14,9 @@ import (
 func Create(ctx context.Context, db sqlx.ExtContext, video Video) error {
 	const q = `
 	INSERT INTO videos
-		(video_id, course_id, index, name, description, free, url, image_url, created_at, updated_at)
+		(video_id, course_id, section_id, index, name, description, free, url, provider, image_url, status, job_id, published, created_at, updated_at)
 	VALUES
-	(:video_id, :course_id, :index, :name, :description, :free, :url, :image_url, :created_at, :updated_at)`
+	(:video_id, :course_id, :section_id, :index, :name, :description, :free, :url, :provider, :image_url, :status, :job_id, :published, :created_at, :updated_at)`
 
 	if err := database.NamedExecContext(ctx, db, q, video); err != nil {
 		return fmt.Errorf("inserting video: %w", err)
@@ -31,12 +32,17 @@ func Update(ctx context.Context, db sqlx.ExtContext, video Video) (Video, error)
 	UPDATE videos
 	SET
 		course_id = :course_id,
+		section_id = :section_id,
 		index = :index,
 		name = :name,
 		description = :description,
 		free = :free,
 		url = :url,
+		provider = :provider,
 		image_url = :image_url,
+		status = :status,
+		job_id = :job_id,
+		published = :published,
 		updated_at = :updated_at,
 		version = version + 1
 	WHERE
@@ -60,6 +66,163 @@ func Update(ctx context.Context, db sqlx.ExtContext, video Video) (Video, error)
 	return video, nil
 }
 
+// Delete flags a video as deleted and returns it. Deleted videos are
+// ignored by the other functions, but FetchDeleted and Restore, and
+// the progress of users on them is kept.
+func Delete(ctx context.Context, db sqlx.ExtContext, id string, now time.Time) (Video, error) {
+	in := struct {
+		ID        string    `db:"video_id"`
+		DeletedAt time.Time `db:"deleted_at"`
+	}{
+		ID:        id,
+		DeletedAt: now,
+	}
+
+	const q = `
+	UPDATE videos
+	SET
+		deleted_at = :deleted_at,
+		updated_at = :deleted_at,
+		version = version + 1
+	WHERE
+		video_id = :video_id AND
+		deleted_at IS NULL
+	RETURNING *`
+
+	var video Video
+	if err := database.NamedQueryStruct(ctx, db, q, in, &video); err != nil {
+		return Video{}, fmt.Errorf("deleting video[%s]: %w", id, err)
+	}
+
+	return video, nil
+}
+
+// Restore brings back a deleted video at the passed index and returns it.
+func Restore(ctx context.Context, db sqlx.ExtContext, id string, index int, now time.Time) (Video, error) {
+	in := struct {
+		ID        string    `db:"video_id"`
+		Index     int       `db:"index"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}{
+		ID:        id,
+		Index:     index,
+		UpdatedAt: now,
+	}
+
+	const q = `
+	UPDATE videos
+	SET
+		deleted_at = NULL,
+		index = :index,
+		updated_at = :updated_at,
+		version = version + 1
+	WHERE
+		video_id = :video_id AND
+		deleted_at IS NOT NULL
+	RETURNING *`
+
+	var video Video
+	if err := database.NamedQueryStruct(ctx, db, q, in, &video); err != nil {
+		return Video{}, fmt.Errorf("restoring video[%s]: %w", id, err)
+	}
+
+	return video, nil
+}
+
+// FetchDeletedForUpdate returns a deleted video given its id, locking
+// it until the end of the transaction.
+func FetchDeletedForUpdate(ctx context.Context, db sqlx.ExtContext, id string) (Video, error) {
+	in := struct {
+		ID string `db:"video_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		videos
+	WHERE
+		video_id = :video_id AND
+		deleted_at IS NOT NULL
+	FOR UPDATE`
+
+	var video Video
+	if err := database.NamedQueryStruct(ctx, db, q, in, &video); err != nil {
+		return Video{}, fmt.Errorf("fetching deleted video[%s]: %w", id, err)
+	}
+
+	return video, nil
+}
+
+// FetchDeleted returns all the deleted videos, the most recently
+// deleted first.
+func FetchDeleted(ctx context.Context, db sqlx.ExtContext) ([]Video, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		videos
+	WHERE
+		deleted_at IS NOT NULL
+	ORDER BY
+		deleted_at DESC`
+
+	videos := []Video{}
+	if err := database.NamedQuerySlice(ctx, db, q, struct{}{}, &videos); err != nil {
+		return nil, fmt.Errorf("selecting deleted videos: %w", err)
+	}
+
+	return videos, nil
+}
+
+// CompactIndexes moves back by one the videos of a course which follow
+// index, closing the gap left by a removed video.
+// The videos are moved to negative indexes first, since the uniqueness
+// of the indexes is checked on every row updated.
+func CompactIndexes(ctx context.Context, db sqlx.ExtContext, courseID string, index int, now time.Time) error {
+	in := struct {
+		CourseID  string    `db:"course_id"`
+		Index     int       `db:"index"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}{
+		CourseID:  courseID,
+		Index:     index,
+		UpdatedAt: now,
+	}
+
+	const away = `
+	UPDATE videos
+	SET
+		index = -index
+	WHERE
+		course_id = :course_id AND
+		index > :index AND
+		deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, db, away, in); err != nil {
+		return fmt.Errorf("moving videos of course[%s] after %d: %w", courseID, index, err)
+	}
+
+	const back = `
+	UPDATE videos
+	SET
+		index = -index - 1,
+		updated_at = :updated_at,
+		version = version + 1
+	WHERE
+		course_id = :course_id AND
+		index < 0 AND
+		deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, db, back, in); err != nil {
+		return fmt.Errorf("compacting videos of course[%s] after %d: %w", courseID, index, err)
+	}
+
+	return nil
+}
+
 // Fetch returns a video given its id.
 func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Video, error) {
 	in := struct {
@@ -74,7 +237,8 @@ func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Video, error) {
 	FROM
 		videos
 	WHERE
-		video_id = :video_id`
+		video_id = :video_id AND
+		deleted_at IS NULL`
 
 	var video Video
 	if err := database.NamedQueryStruct(ctx, db, q, in, &video); err != nil {
@@ -84,6 +248,31 @@ func Fetch(ctx context.Context, db sqlx.ExtContext, id string) (Video, error) {
 	return video, nil
 }
 
+// FetchByJobID returns the video processed by the passed transcoding job.
+func FetchByJobID(ctx context.Context, db sqlx.ExtContext, jobID string) (Video, error) {
+	in := struct {
+		JobID string `db:"job_id"`
+	}{
+		JobID: jobID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		videos
+	WHERE
+		job_id = :job_id AND
+		deleted_at IS NULL`
+
+	var video Video
+	if err := database.NamedQueryStruct(ctx, db, q, in, &video); err != nil {
+		return Video{}, fmt.Errorf("fetching video of job[%s]: %w", jobID, err)
+	}
+
+	return video, nil
+}
+
 // FetchAll returns all available videos.
 func FetchAll(ctx context.Context, db sqlx.ExtContext) ([]Video, error) {
 	const q = `
@@ -91,6 +280,8 @@ func FetchAll(ctx context.Context, db sqlx.ExtContext) ([]Video, error) {
 		*
 	FROM
 		videos
+	WHERE
+		deleted_at IS NULL
 	ORDER BY
 		video_id`
 
@@ -102,6 +293,27 @@ func FetchAll(ctx context.Context, db sqlx.ExtContext) ([]Video, error) {
 	return videos, nil
 }
 
+// FetchAllPublished returns all the videos but the drafts.
+func FetchAllPublished(ctx context.Context, db sqlx.ExtContext) ([]Video, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		videos
+	WHERE
+		published AND
+		deleted_at IS NULL
+	ORDER BY
+		video_id`
+
+	videos := []Video{}
+	if err := database.NamedQuerySlice(ctx, db, q, struct{}{}, &videos); err != nil {
+		return nil, fmt.Errorf("selecting published videos: %w", err)
+	}
+
+	return videos, nil
+}
+
 // FetchAllByCourse returns all available videos belonging to a specific course.
 func FetchAllByCourse(ctx context.Context, db sqlx.ExtContext, courseID string) ([]Video, error) {
 	in := struct {
@@ -116,7 +328,8 @@ func FetchAllByCourse(ctx context.Context, db sqlx.ExtContext, courseID string)
 	FROM
 		videos
 	WHERE
-		course_id = :course_id
+		course_id = :course_id AND
+		deleted_at IS NULL
 	ORDER BY
 		index`
 
@@ -128,34 +341,306 @@ func FetchAllByCourse(ctx context.Context, db sqlx.ExtContext, courseID string)
 	return videos, nil
 }
 
-// UpdateProgress upserts user's progress on a video.
-func UpdateProgress(ctx context.Context, db sqlx.ExtContext, userID string, videoID string, value int) error {
+// FetchPublishedByCourse returns the videos of a course but the drafts.
+func FetchPublishedByCourse(ctx context.Context, db sqlx.ExtContext, courseID string) ([]Video, error) {
 	in := struct {
-		VideoID  string `db:"video_id"`
-		UserID   string `db:"user_id"`
-		Progress int    `db:"progress"`
+		ID string `db:"course_id"`
 	}{
-		VideoID:  videoID,
-		UserID:   userID,
-		Progress: value,
+		ID: courseID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		videos
+	WHERE
+		course_id = :course_id AND
+		published AND
+		deleted_at IS NULL
+	ORDER BY
+		index`
+
+	videos := []Video{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &videos); err != nil {
+		return nil, fmt.Errorf("selecting published videos of course[%s]: %w", courseID, err)
+	}
+
+	return videos, nil
+}
+
+// FetchAllByCourseForUpdate returns all the videos of a course, locking
+// them until the end of the transaction.
+func FetchAllByCourseForUpdate(ctx context.Context, db sqlx.ExtContext, courseID string) ([]Video, error) {
+	in := struct {
+		ID string `db:"course_id"`
+	}{
+		ID: courseID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		videos
+	WHERE
+		course_id = :course_id AND
+		deleted_at IS NULL
+	ORDER BY
+		index
+	FOR UPDATE`
+
+	videos := []Video{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &videos); err != nil {
+		return nil, fmt.Errorf("selecting videos of course[%s] for update: %w", courseID, err)
+	}
+
+	return videos, nil
+}
+
+// Reorder sets the indexes of the videos of a course following the
+// passed order, starting from 1. The ids must be all the videos of
+// the course. Like in CompactIndexes, the videos are moved to negative
+// indexes first.
+func Reorder(ctx context.Context, db sqlx.ExtContext, courseID string, videoIDs []string, now time.Time) error {
+	in := struct {
+		CourseID string `db:"course_id"`
+	}{
+		CourseID: courseID,
+	}
+
+	const away = `
+	UPDATE videos
+	SET
+		index = -index - 1
+	WHERE
+		course_id = :course_id AND
+		deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, db, away, in); err != nil {
+		return fmt.Errorf("moving videos of course[%s]: %w", courseID, err)
 	}
 
 	const q = `
+	UPDATE videos
+	SET
+		index = :index,
+		updated_at = :updated_at,
+		version = version + 1
+	WHERE
+		video_id = :video_id AND
+		course_id = :course_id AND
+		deleted_at IS NULL`
+
+	for i, id := range videoIDs {
+		v := struct {
+			ID        string    `db:"video_id"`
+			CourseID  string    `db:"course_id"`
+			Index     int       `db:"index"`
+			UpdatedAt time.Time `db:"updated_at"`
+		}{
+			ID:        id,
+			CourseID:  courseID,
+			Index:     i + 1,
+			UpdatedAt: now,
+		}
+
+		if err := database.NamedExecContext(ctx, db, q, v); err != nil {
+			return fmt.Errorf("moving video[%s] of course[%s] to %d: %w", id, courseID, i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// ReplaceRenditions replaces all the renditions of a video with the passed ones.
+func ReplaceRenditions(ctx context.Context, db sqlx.ExtContext, videoID string, rends []Rendition) error {
+	in := struct {
+		ID string `db:"video_id"`
+	}{
+		ID: videoID,
+	}
+
+	const del = `
+	DELETE FROM
+		video_renditions
+	WHERE
+		video_id = :video_id`
+
+	if err := database.NamedExecContext(ctx, db, del, in); err != nil {
+		return fmt.Errorf("deleting renditions of video[%s]: %w", videoID, err)
+	}
+
+	const ins = `
+	INSERT INTO video_renditions
+		(video_id, name, url, width, height, bitrate, created_at)
+	VALUES
+		(:video_id, :name, :url, :width, :height, :bitrate, :created_at)`
+
+	for _, r := range rends {
+		if err := database.NamedExecContext(ctx, db, ins, r); err != nil {
+			return fmt.Errorf("inserting rendition %s of video[%s]: %w", r.Name, videoID, err)
+		}
+	}
+
+	return nil
+}
+
+// FetchRenditions returns all the renditions of a video, from the smallest.
+func FetchRenditions(ctx context.Context, db sqlx.ExtContext, videoID string) ([]Rendition, error) {
+	in := struct {
+		ID string `db:"video_id"`
+	}{
+		ID: videoID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		video_renditions
+	WHERE
+		video_id = :video_id
+	ORDER BY
+		height, bitrate`
+
+	rends := []Rendition{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &rends); err != nil {
+		return nil, fmt.Errorf("selecting renditions of video[%s]: %w", videoID, err)
+	}
+
+	return rends, nil
+}
+
+// ReplaceChapters replaces all the chapters of a video with the passed ones.
+func ReplaceChapters(ctx context.Context, db sqlx.ExtContext, videoID string, chs []Chapter) error {
+	in := struct {
+		ID string `db:"video_id"`
+	}{
+		ID: videoID,
+	}
+
+	const del = `
+	DELETE FROM
+		video_chapters
+	WHERE
+		video_id = :video_id`
+
+	if err := database.NamedExecContext(ctx, db, del, in); err != nil {
+		return fmt.Errorf("deleting chapters of video[%s]: %w", videoID, err)
+	}
+
+	const ins = `
+	INSERT INTO video_chapters
+		(video_id, start_seconds, title, created_at)
+	VALUES
+		(:video_id, :start_seconds, :title, :created_at)`
+
+	for _, ch := range chs {
+		if err := database.NamedExecContext(ctx, db, ins, ch); err != nil {
+			return fmt.Errorf("inserting chapter at %ds of video[%s]: %w", ch.Start, videoID, err)
+		}
+	}
+
+	return nil
+}
+
+// FetchChapters returns all the chapters of a video, in order.
+func FetchChapters(ctx context.Context, db sqlx.ExtContext, videoID string) ([]Chapter, error) {
+	in := struct {
+		ID string `db:"video_id"`
+	}{
+		ID: videoID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		video_chapters
+	WHERE
+		video_id = :video_id
+	ORDER BY
+		start_seconds`
+
+	chs := []Chapter{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &chs); err != nil {
+		return nil, fmt.Errorf("selecting chapters of video[%s]: %w", videoID, err)
+	}
+
+	return chs, nil
+}
+
+// UpdateProgress upserts user's progress on a video.
+// The completion never goes back, so that a stale client can't undo
+// the progress made elsewhere, while the position follows the most
+// recently reported update.
+func UpdateProgress(ctx context.Context, db sqlx.ExtContext, p Progress) error {
+	if err := database.NamedExecContext(ctx, db, upsertProgress, p); err != nil {
+		return fmt.Errorf("upserting progress: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateProgressBatch upserts many progress in a single statement,
+// following the rules of UpdateProgress. Each video must appear once.
+func UpdateProgressBatch(ctx context.Context, db sqlx.ExtContext, ps []Progress) error {
+	if len(ps) == 0 {
+		return nil
+	}
+
+	if err := database.NamedExecContext(ctx, db, upsertProgress, ps); err != nil {
+		return fmt.Errorf("upserting %d progress: %w", len(ps), err)
+	}
+
+	return nil
+}
+
+// upsertProgress inserts the progress of a user on a video, merging it
+// with the existing one.
+const upsertProgress = `
 	INSERT INTO videos_progress
-		(video_id, user_id, progress, created_at, updated_at)
+		(video_id, user_id, progress, position, watched_seconds, reported_at, created_at, updated_at)
 	VALUES
-		(:video_id, :user_id, :progress, NOW(), NOW())
+		(:video_id, :user_id, :progress, :position, :watched_seconds, :reported_at, :created_at, :updated_at)
 	ON CONFLICT
 		(video_id, user_id)
 	DO UPDATE SET
-		progress = :progress,
-		updated_at = NOW()`
+		progress = GREATEST(videos_progress.progress, EXCLUDED.progress),
+		watched_seconds = GREATEST(videos_progress.watched_seconds, EXCLUDED.watched_seconds),
+		position = CASE
+			WHEN EXCLUDED.reported_at >= videos_progress.reported_at THEN EXCLUDED.position
+			ELSE videos_progress.position
+		END,
+		reported_at = GREATEST(videos_progress.reported_at, EXCLUDED.reported_at),
+		updated_at = EXCLUDED.updated_at`
 
-	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
-		return fmt.Errorf("upserting progress: %w", err)
+// FetchProgress returns the progress of a user on a video.
+func FetchProgress(ctx context.Context, db sqlx.ExtContext, videoID string, userID string) (Progress, error) {
+	in := struct {
+		VideoID string `db:"video_id"`
+		UserID  string `db:"user_id"`
+	}{
+		VideoID: videoID,
+		UserID:  userID,
 	}
 
-	return nil
+	const q = `
+	SELECT
+		*
+	FROM
+		videos_progress
+	WHERE
+		video_id = :video_id AND
+		user_id = :user_id`
+
+	var p Progress
+	if err := database.NamedQueryStruct(ctx, db, q, in, &p); err != nil {
+		return Progress{}, fmt.Errorf("selecting progress of user[%s] on video[%s]: %w", userID, videoID, err)
+	}
+
+	return p, nil
 }
 
 // FetchUserProgressByCourse returns user's progress on videos
@@ -180,7 +665,8 @@ func FetchUserProgressByCourse(ctx context.Context, db sqlx.ExtContext, userID s
 		courses AS c ON c.course_id = v.course_id
 	WHERE
 		c.course_id = :course_id AND
-		p.user_id = :user_id`
+		p.user_id = :user_id AND
+		v.deleted_at IS NULL`
 
 	progress := []Progress{}
 	if err := database.NamedQuerySlice(ctx, db, q, in, &progress); err != nil {
@@ -189,3 +675,351 @@ func FetchUserProgressByCourse(ctx context.Context, db sqlx.ExtContext, userID s
 
 	return progress, nil
 }
+
+// CreateWatch records that a user started watching a video.
+func CreateWatch(ctx context.Context, db sqlx.ExtContext, wt Watch) error {
+	const q = `
+	INSERT INTO watch_history
+		(user_id, video_id, started_at)
+	VALUES
+		(:user_id, :video_id, :started_at)
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, db, q, wt); err != nil {
+		return fmt.Errorf("inserting watch of video[%s] by user[%s]: %w", wt.VideoID, wt.UserID, err)
+	}
+
+	return nil
+}
+
+// FetchWatched returns the videos watched by a user, the most recently
+// watched first.
+func FetchWatched(ctx context.Context, db sqlx.ExtContext, userID string, limit int, offset int) ([]Watched, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+		Limit  int    `db:"limit"`
+		Offset int    `db:"offset"`
+	}{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	const q = `
+	SELECT
+		v.video_id,
+		v.name AS video_name,
+		v.index AS video_index,
+		v.image_url AS video_image_url,
+		c.course_id,
+		c.name AS course_name,
+		c.image_url AS course_image_url,
+		COALESCE(p.progress, 0) AS progress,
+		COALESCE(p.position, 0) AS position,
+		h.watched_at
+	FROM
+		(
+			SELECT
+				video_id,
+				MAX(started_at) AS watched_at
+			FROM
+				watch_history
+			WHERE
+				user_id = :user_id
+			GROUP BY
+				video_id
+		) AS h
+	INNER JOIN
+		videos AS v ON v.video_id = h.video_id
+	INNER JOIN
+		courses AS c ON c.course_id = v.course_id
+	LEFT JOIN
+		videos_progress AS p ON p.video_id = h.video_id AND p.user_id = :user_id
+	WHERE
+		v.deleted_at IS NULL
+	ORDER BY
+		h.watched_at DESC, v.video_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	ws := []Watched{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &ws); err != nil {
+		return nil, fmt.Errorf("selecting videos watched by user[%s]: %w", userID, err)
+	}
+
+	return ws, nil
+}
+
+// CreateUpload inserts a new upload in the database.
+func CreateUpload(ctx context.Context, db sqlx.ExtContext, up Upload) error {
+	const q = `
+	INSERT INTO uploads
+		(upload_id, user_id, course_id, filename, content_type, size, upload_offset, url, created_at, updated_at)
+	VALUES
+		(:upload_id, :user_id, :course_id, :filename, :content_type, :size, :upload_offset, :url, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, up); err != nil {
+		return fmt.Errorf("inserting upload: %w", err)
+	}
+
+	return nil
+}
+
+// FetchUpload returns an upload given its id.
+func FetchUpload(ctx context.Context, db sqlx.ExtContext, id string) (Upload, error) {
+	in := struct {
+		ID string `db:"upload_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		uploads
+	WHERE
+		upload_id = :upload_id`
+
+	var up Upload
+	if err := database.NamedQueryStruct(ctx, db, q, in, &up); err != nil {
+		return Upload{}, fmt.Errorf("selecting upload[%s]: %w", id, err)
+	}
+
+	return up, nil
+}
+
+// FetchUploadForUpdate returns an upload given its id, locking it until
+// the end of the transaction, so that its chunks are written one at
+// a time.
+func FetchUploadForUpdate(ctx context.Context, db sqlx.ExtContext, id string) (Upload, error) {
+	in := struct {
+		ID string `db:"upload_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		uploads
+	WHERE
+		upload_id = :upload_id
+	FOR UPDATE`
+
+	var up Upload
+	if err := database.NamedQueryStruct(ctx, db, q, in, &up); err != nil {
+		return Upload{}, fmt.Errorf("selecting upload[%s]: %w", id, err)
+	}
+
+	return up, nil
+}
+
+// UpdateUpload updates the progress of an upload.
+func UpdateUpload(ctx context.Context, db sqlx.ExtContext, up Upload) error {
+	const q = `
+	UPDATE uploads
+	SET
+		upload_offset = :upload_offset,
+		url = :url,
+		updated_at = :updated_at,
+		completed_at = :completed_at
+	WHERE
+		upload_id = :upload_id`
+
+	if err := database.NamedExecContext(ctx, db, q, up); err != nil {
+		return fmt.Errorf("updating upload[%s]: %w", up.ID, err)
+	}
+
+	return nil
+}
+
+// UpsertPreview stores the preview of a video, replacing the previous
+// one if any.
+func UpsertPreview(ctx context.Context, db sqlx.ExtContext, p Preview) error {
+	const q = `
+	INSERT INTO video_previews
+		(video_id, url, provider, start_seconds, end_seconds, created_at, updated_at)
+	VALUES
+		(:video_id, :url, :provider, :start_seconds, :end_seconds, :created_at, :updated_at)
+	ON CONFLICT
+		(video_id)
+	DO UPDATE SET
+		url = EXCLUDED.url,
+		provider = EXCLUDED.provider,
+		start_seconds = EXCLUDED.start_seconds,
+		end_seconds = EXCLUDED.end_seconds,
+		updated_at = EXCLUDED.updated_at`
+
+	if err := database.NamedExecContext(ctx, db, q, p); err != nil {
+		return fmt.Errorf("upserting preview of video[%s]: %w", p.VideoID, err)
+	}
+
+	return nil
+}
+
+// FetchPreview returns the preview of a video.
+func FetchPreview(ctx context.Context, db sqlx.ExtContext, videoID string) (Preview, error) {
+	in := struct {
+		ID string `db:"video_id"`
+	}{
+		ID: videoID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		video_previews
+	WHERE
+		video_id = :video_id`
+
+	var p Preview
+	if err := database.NamedQueryStruct(ctx, db, q, in, &p); err != nil {
+		return Preview{}, fmt.Errorf("selecting preview of video[%s]: %w", videoID, err)
+	}
+
+	return p, nil
+}
+
+// DeletePreview removes the preview of a video, if any.
+func DeletePreview(ctx context.Context, db sqlx.ExtContext, videoID string) error {
+	in := struct {
+		ID string `db:"video_id"`
+	}{
+		ID: videoID,
+	}
+
+	const q = `
+	DELETE FROM
+		video_previews
+	WHERE
+		video_id = :video_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("deleting preview of video[%s]: %w", videoID, err)
+	}
+
+	return nil
+}
+
+// CreateSection inserts a new section with the passed information.
+func CreateSection(ctx context.Context, db sqlx.ExtContext, s Section) error {
+	const q = `
+	INSERT INTO sections
+		(section_id, course_id, index, title, created_at, updated_at)
+	VALUES
+		(:section_id, :course_id, :index, :title, :created_at, :updated_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, s); err != nil {
+		return fmt.Errorf("inserting section: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSection updates a section with the passed information.
+// It relies on optimistic lock to deal with data races.
+func UpdateSection(ctx context.Context, db sqlx.ExtContext, s Section) (Section, error) {
+	const q = `
+	UPDATE sections
+	SET
+		index = :index,
+		title = :title,
+		updated_at = :updated_at,
+		version = version + 1
+	WHERE
+		section_id = :section_id AND
+		version = :version
+	RETURNING version`
+
+	v := struct {
+		Version int `db:"version"`
+	}{}
+
+	if err := database.NamedQueryStruct(ctx, db, q, s, &v); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Section{}, fmt.Errorf("updating section[%s]: version conflict", s.ID)
+		}
+		return Section{}, fmt.Errorf("updating section[%s]: %w", s.ID, err)
+	}
+
+	s.Version = v.Version
+
+	return s, nil
+}
+
+// DeleteSection removes a section and returns it. Its videos are kept,
+// out of any section.
+func DeleteSection(ctx context.Context, db sqlx.ExtContext, id string) (Section, error) {
+	in := struct {
+		ID string `db:"section_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	DELETE FROM
+		sections
+	WHERE
+		section_id = :section_id
+	RETURNING *`
+
+	var s Section
+	if err := database.NamedQueryStruct(ctx, db, q, in, &s); err != nil {
+		return Section{}, fmt.Errorf("deleting section[%s]: %w", id, err)
+	}
+
+	return s, nil
+}
+
+// FetchSection returns the section with the passed id.
+func FetchSection(ctx context.Context, db sqlx.ExtContext, id string) (Section, error) {
+	in := struct {
+		ID string `db:"section_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sections
+	WHERE
+		section_id = :section_id`
+
+	var s Section
+	if err := database.NamedQueryStruct(ctx, db, q, in, &s); err != nil {
+		return Section{}, fmt.Errorf("selecting section[%s]: %w", id, err)
+	}
+
+	return s, nil
+}
+
+// FetchSectionsByCourse returns the sections of a course, in order.
+func FetchSectionsByCourse(ctx context.Context, db sqlx.ExtContext, courseID string) ([]Section, error) {
+	in := struct {
+		ID string `db:"course_id"`
+	}{
+		ID: courseID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sections
+	WHERE
+		course_id = :course_id
+	ORDER BY
+		index`
+
+	sections := []Section{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &sections); err != nil {
+		return nil, fmt.Errorf("selecting sections of course[%s]: %w", courseID, err)
+	}
+
+	return sections, nil
+}
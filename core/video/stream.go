@@ -0,0 +1,90 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/storage"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// HandleStream serves the files of the videos stored by the platform,
+// e.g. the upload itself or the playlists and segments of its HLS
+// encoding, checking on each request that the user can play the video.
+// Files are looked up next to the file of the video, so the relative
+// URIs of the playlists resolve under /stream/{video_id}/ as well.
+// Ranges are supported and only the part requested is read from the
+// storage.
+func HandleStream(db *sqlx.DB, st storage.Storage) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		videoID := web.Param(r, "video_id")
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if err := validate.CheckID(videoID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		video, _, err := fetchPlayable(ctx, db, videoID, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Videos hosted by the providers are not in the storage.
+		prefix := st.URL("")
+		if !strings.HasPrefix(video.URL, prefix) {
+			return weberr.NotFound(fmt.Errorf("video[%s] is not stored at %s", video.ID, prefix))
+		}
+
+		rel, err := url.PathUnescape(strings.TrimPrefix(video.URL, prefix))
+		if err != nil {
+			return fmt.Errorf("unescaping url of video[%s]: %w", video.ID, err)
+		}
+
+		key := rel
+		if p := web.Param(r, "path"); p != "" {
+			key = path.Dir(rel) + "/" + p
+		}
+
+		obj, err := st.Open(ctx, key)
+		if err != nil {
+			err := fmt.Errorf("opening %s of video[%s]: %w", key, video.ID, err)
+			if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+		defer obj.Close()
+
+		w.Header().Set("Content-Type", obj.ContentType)
+		w.Header().Set("Cache-Control", "private")
+
+		http.ServeContent(&streamWriter{ResponseWriter: w}, r, "", obj.ModTime, obj)
+
+		return nil
+	}
+}
+
+// streamWriter flushes the response once its headers are written, so
+// that the body is streamed to the client instead of being buffered by
+// the middlewares.
+type streamWriter struct {
+	http.ResponseWriter
+}
+
+func (sw *streamWriter) WriteHeader(code int) {
+	sw.ResponseWriter.WriteHeader(code)
+	http.NewResponseController(sw.ResponseWriter).Flush()
+}
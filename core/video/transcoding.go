@@ -0,0 +1,153 @@
+package video
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// SignatureHeader is the header carrying the signature of the callbacks
+// of the transcoding provider, in the form `t=<unix time>,v1=<hex hmac>`.
+// The HMAC-SHA256 is computed on `<unix time>.<body>`, as done by Mux.
+const SignatureHeader = "Transcoding-Signature"
+
+// TranscodingEvent is the payload of the callbacks of the transcoding provider.
+type TranscodingEvent struct {
+	JobID      string      `json:"jobId" validate:"required"`
+	Status     string      `json:"status" validate:"required,oneof=processing ready failed"`
+	Renditions []Rendition `json:"renditions" validate:"dive"`
+	Error      string      `json:"error"`
+}
+
+// Sign returns the signature header of body at the passed time.
+func Sign(body []byte, secret string, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(body, secret, ts))
+}
+
+func mac(body []byte, secret string, ts string) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts + "."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// verify checks that header is a valid signature of body,
+// not older than tolerance.
+func verify(header string, body []byte, secret string, tolerance time.Duration, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("malformed signature timestamp")
+	}
+
+	if now.Sub(time.Unix(unix, 0)) > tolerance {
+		return errors.New("signature too old")
+	}
+
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac(body, secret, ts)) {
+		return errors.New("signature mismatch")
+	}
+
+	return nil
+}
+
+// HandleTranscodingCallback receives the status of transcoding jobs.
+// When a job completes, the renditions of the video are stored and the
+// video becomes playable.
+func HandleTranscodingCallback(db *sqlx.DB, cfg config.Transcoding, l Listener) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		const maxBodyBytes = int64(65536)
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			return weberr.NewError(err, "cannot read payload", http.StatusServiceUnavailable)
+		}
+
+		now := time.Now().UTC()
+		if err := verify(r.Header.Get(SignatureHeader), body, cfg.WebhookSecret, cfg.Tolerance, now); err != nil {
+			return weberr.BadRequest(fmt.Errorf("verifying transcoding callback: %w", err))
+		}
+
+		var ev TranscodingEvent
+		if err := json.Unmarshal(body, &ev); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(ev); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var video Video
+		var changed bool
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			var err error
+			if video, err = FetchByJobID(ctx, tx, ev.JobID); err != nil {
+				return err
+			}
+
+			// Callbacks may arrive more than once or out of order,
+			// but a ready video never goes back.
+			if video.Status == StatusReady || video.Status == ev.Status {
+				return nil
+			}
+
+			video.Status = ev.Status
+			video.UpdatedAt = now
+			if video, err = Update(ctx, tx, video); err != nil {
+				return err
+			}
+			changed = true
+
+			if ev.Status != StatusReady {
+				return nil
+			}
+
+			for i := range ev.Renditions {
+				ev.Renditions[i].VideoID = video.ID
+				ev.Renditions[i].CreatedAt = now
+			}
+			return ReplaceRenditions(ctx, tx, video.ID, ev.Renditions)
+		})
+
+		if err != nil {
+			err := fmt.Errorf("updating video of job[%s] to %s: %w", ev.JobID, ev.Status, err)
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if changed && video.Status == StatusReady {
+			l.VideoReady(video)
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,287 @@
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/storage"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// OffsetHeader carries the offset of the chunks of the uploads, and the
+// bytes received so far in the responses, as done by the tus protocol.
+const OffsetHeader = "Upload-Offset"
+
+// ChunkContentType is the content type of the chunks of the uploads.
+const ChunkContentType = "application/offset+octet-stream"
+
+var (
+	// errOffsetMismatch is returned when a chunk doesn't start where
+	// the upload was left.
+	errOffsetMismatch = errors.New("offset doesn't match the bytes received")
+
+	// errUploadCompleted is returned when sending chunks to an upload
+	// which received all of its bytes already.
+	errUploadCompleted = errors.New("upload already completed")
+
+	// errChunkTooLarge is returned when a chunk exceeds the configured
+	// size or the size of the upload.
+	errChunkTooLarge = errors.New("chunk too large")
+)
+
+// partPath returns where the bytes of an upload in progress are written.
+func partPath(dir string, id string) string {
+	return filepath.Join(dir, id+".part")
+}
+
+// checkUpload verifies that the user of the session can manage the
+// course of the upload. Only administrators handle uploads without one.
+func checkUpload(ctx context.Context, db sqlx.ExtContext, courseID *string) error {
+	if courseID == nil {
+		if claims.IsAdmin(ctx) {
+			return nil
+		}
+		err := errors.New("instructors can only upload files for their courses")
+		return weberr.NewError(err, "access forbidden", http.StatusForbidden)
+	}
+
+	return course.CheckManager(ctx, db, *courseID)
+}
+
+// HandleCreateUpload allows administrators, and instructors for the
+// courses they teach, to start uploading a video or an image, e.g. its
+// thumbnail, which is then sent in chunks.
+func HandleCreateUpload(db *sqlx.DB, cfg config.Uploads) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in UploadNew
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		// The file is stored under its name, so it can't be a path.
+		if strings.ContainsAny(in.Filename, `/\`) || in.Filename == "." || in.Filename == ".." {
+			err := fmt.Errorf("invalid filename %q", in.Filename)
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if in.Size > cfg.MaxSize {
+			err := fmt.Errorf("files can't be larger than %d bytes", cfg.MaxSize)
+			return weberr.NewError(err, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if err := checkUpload(ctx, db, in.CourseID); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		up := Upload{
+			ID:          validate.GenerateID(),
+			UserID:      clm.UserID,
+			CourseID:    in.CourseID,
+			Filename:    in.Filename,
+			ContentType: in.ContentType,
+			Size:        in.Size,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+			return fmt.Errorf("creating uploads directory: %w", err)
+		}
+
+		f, err := os.Create(partPath(cfg.Dir, up.ID))
+		if err != nil {
+			return fmt.Errorf("creating file of upload[%s]: %w", up.ID, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("closing file of upload[%s]: %w", up.ID, err)
+		}
+
+		if err := CreateUpload(ctx, db, up); err != nil {
+			return err
+		}
+
+		w.Header().Set("Location", "/uploads/"+up.ID)
+		w.Header().Set(OffsetHeader, "0")
+
+		return web.Respond(ctx, w, up, http.StatusCreated)
+	}
+}
+
+// HandleShowUpload returns the state of an upload, telling clients
+// where to resume an interrupted upload from.
+func HandleShowUpload(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		uploadID := web.Param(r, "id")
+
+		if err := validate.CheckID(uploadID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		up, err := FetchUpload(ctx, db, uploadID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		if err := checkUpload(ctx, db, up.CourseID); err != nil {
+			return err
+		}
+
+		w.Header().Set(OffsetHeader, strconv.FormatInt(up.Offset, 10))
+
+		return web.Respond(ctx, w, up, http.StatusOK)
+	}
+}
+
+// HandleUploadChunk appends the chunk in the body to an upload. Chunks
+// must be sent in order, each starting at the offset passed via the
+// Upload-Offset header. Once the last chunk is received the file is
+// moved to the storage, and its URL can be used as the URL of a video.
+func HandleUploadChunk(db *sqlx.DB, cfg config.Uploads, st storage.Storage) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		uploadID := web.Param(r, "id")
+
+		if err := validate.CheckID(uploadID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		if ct := r.Header.Get("Content-Type"); ct != ChunkContentType {
+			err := fmt.Errorf("chunks must be sent as %s, got %q", ChunkContentType, ct)
+			return weberr.NewError(err, err.Error(), http.StatusUnsupportedMediaType)
+		}
+
+		offset, err := strconv.ParseInt(r.Header.Get(OffsetHeader), 10, 64)
+		if err != nil || offset < 0 {
+			return weberr.BadRequest(fmt.Errorf("invalid %s header", OffsetHeader))
+		}
+
+		var up Upload
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			var err error
+			if up, err = FetchUploadForUpdate(ctx, tx, uploadID); err != nil {
+				return err
+			}
+
+			if err := checkUpload(ctx, tx, up.CourseID); err != nil {
+				return err
+			}
+
+			if up.CompletedAt != nil {
+				return errUploadCompleted
+			}
+			if offset != up.Offset {
+				return fmt.Errorf("%w: expected %d, got %d", errOffsetMismatch, up.Offset, offset)
+			}
+
+			n, err := writeChunk(partPath(cfg.Dir, up.ID), r.Body, offset, min(up.Size-offset, cfg.ChunkSize))
+			if err != nil {
+				return err
+			}
+
+			now := time.Now().UTC()
+			up.Offset += n
+			up.UpdatedAt = now
+
+			if up.Offset == up.Size {
+				if up.URL, err = store(ctx, cfg.Dir, st, up); err != nil {
+					return err
+				}
+				up.CompletedAt = &now
+			}
+
+			return UpdateUpload(ctx, tx, up)
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, database.ErrDBNotFound):
+				return weberr.NotFound(err)
+			case errors.Is(err, errUploadCompleted), errors.Is(err, errOffsetMismatch):
+				return weberr.NewError(err, err.Error(), http.StatusConflict)
+			case errors.Is(err, errChunkTooLarge):
+				return weberr.NewError(err, err.Error(), http.StatusRequestEntityTooLarge)
+			}
+			return fmt.Errorf("uploading chunk of upload[%s]: %w", uploadID, err)
+		}
+
+		w.Header().Set(OffsetHeader, strconv.FormatInt(up.Offset, 10))
+
+		return web.Respond(ctx, w, up, http.StatusOK)
+	}
+}
+
+// writeChunk writes the chunk read from body into the file at path,
+// starting at offset, and returns its length. Chunks longer than limit
+// are rejected.
+func writeChunk(path string, body io.Reader, offset int64, limit int64) (int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, fmt.Errorf("opening upload file: %w", err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(io.NewOffsetWriter(f, offset), io.LimitReader(body, limit))
+	if err != nil {
+		return 0, fmt.Errorf("writing chunk: %w", err)
+	}
+
+	// The bytes written beyond the offset are overwritten by the
+	// next chunk, as the offset doesn't move.
+	if m, _ := body.Read(make([]byte, 1)); m > 0 {
+		return 0, fmt.Errorf("%w: at most %d bytes are expected", errChunkTooLarge, limit)
+	}
+
+	if err := f.Close(); err != nil {
+		return 0, fmt.Errorf("closing upload file: %w", err)
+	}
+
+	return n, nil
+}
+
+// store moves the file of a completed upload to the storage and
+// returns its URL.
+func store(ctx context.Context, dir string, st storage.Storage, up Upload) (string, error) {
+	path := partPath(dir, up.ID)
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening file of upload[%s]: %w", up.ID, err)
+	}
+	defer f.Close()
+
+	key := "uploads/" + up.ID + "/" + up.Filename
+	if err := st.Put(ctx, key, f, up.Size, up.ContentType); err != nil {
+		return "", fmt.Errorf("storing file of upload[%s]: %w", up.ID, err)
+	}
+
+	// The upload is stored already, the file is only left behind.
+	f.Close()
+	os.Remove(path)
+
+	return st.URL(key), nil
+}
@@ -6,52 +6,358 @@ import "time"
 // A course can contain many videos.
 // A video can be contained by a course only.
 // URL is not marhsalled to JSON to avoid security issues.
+// Provider tells where the video is hosted, see Playback.
+// Videos uploaded to the transcoding provider are processing until
+// the provider calls back, referring to them by JobID.
+// Videos not Published are drafts, shown only to those who can manage
+// their course.
+// Deleted videos have DeletedAt set, until they are restored.
+// A video can be grouped in a Section of its course, SectionID.
+// Locked tells whether the user has not completed the prerequisites of
+// the course of the video yet.
 type Video struct {
-	ID          string    `json:"id" db:"video_id"`
-	CourseID    string    `json:"courseId" db:"course_id"`
-	Index       int       `json:"index" db:"index"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	Free        bool      `json:"free" db:"free"`
-	URL         string    `json:"-" db:"url"`
-	ImageURL    string    `json:"imageUrl" db:"image_url"`
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-	Version     int       `json:"-" db:"version"`
+	ID          string     `json:"id" db:"video_id"`
+	CourseID    string     `json:"courseId" db:"course_id"`
+	SectionID   *string    `json:"sectionId" db:"section_id"`
+	Index       int        `json:"index" db:"index"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	Free        bool       `json:"free" db:"free"`
+	URL         string     `json:"-" db:"url"`
+	Provider    string     `json:"provider" db:"provider"`
+	ImageURL    string     `json:"imageUrl" db:"image_url"`
+	Status      string     `json:"status" db:"status"`
+	JobID       string     `json:"-" db:"job_id"`
+	Published   bool       `json:"published" db:"published"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+	Version     int        `json:"-" db:"version"`
+	Locked      bool       `json:"locked" db:"-"`
+}
+
+// Metadata contains the information of a video tracked by the
+// revisions of its course.
+type Metadata struct {
+	CourseID    string  `json:"courseId"`
+	SectionID   *string `json:"sectionId"`
+	Index       int     `json:"index"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Free        bool    `json:"free"`
+	URL         string  `json:"url"`
+	Provider    string  `json:"provider"`
+	ImageURL    string  `json:"imageUrl"`
+	Published   bool    `json:"published"`
+}
+
+// metadata returns the metadata of a video tracked by revisions.
+func metadata(v Video) Metadata {
+	return Metadata{
+		CourseID:    v.CourseID,
+		SectionID:   v.SectionID,
+		Index:       v.Index,
+		Name:        v.Name,
+		Description: v.Description,
+		Free:        v.Free,
+		URL:         v.URL,
+		Provider:    v.Provider,
+		ImageURL:    v.ImageURL,
+		Published:   v.Published,
+	}
+}
+
+// Processing statuses of a video.
+const (
+	StatusProcessing = "processing"
+	StatusReady      = "ready"
+	StatusFailed     = "failed"
+)
+
+// Rendition models a transcoded version of a video.
+type Rendition struct {
+	VideoID   string    `json:"-" db:"video_id"`
+	Name      string    `json:"name" db:"name" validate:"required"`
+	URL       string    `json:"url" db:"url" validate:"required,url"`
+	Width     int       `json:"width" db:"width" validate:"gte=0"`
+	Height    int       `json:"height" db:"height" validate:"gte=0"`
+	Bitrate   int       `json:"bitrate" db:"bitrate" validate:"gte=0"`
+	CreatedAt time.Time `json:"-" db:"created_at"`
+}
+
+// Chapter models a named section of a video, starting Start seconds
+// after its beginning.
+type Chapter struct {
+	VideoID   string    `json:"-" db:"video_id"`
+	Start     int       `json:"start" db:"start_seconds" validate:"gte=0"`
+	Title     string    `json:"title" db:"title" validate:"required,max=200"`
+	CreatedAt time.Time `json:"-" db:"created_at"`
+}
+
+// ChaptersUp contains all the chapters of a video.
+type ChaptersUp struct {
+	Chapters []Chapter `json:"chapters" validate:"max=100,dive"`
+}
+
+// Section models a group of videos of a course, e.g. a module.
+// Sections are ordered by Index within the course, like videos, and
+// the videos of a section keep the order of their own indexes.
+type Section struct {
+	ID        string    `json:"id" db:"section_id"`
+	CourseID  string    `json:"courseId" db:"course_id"`
+	Index     int       `json:"index" db:"index"`
+	Title     string    `json:"title" db:"title"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	Version   int       `json:"-" db:"version"`
+}
+
+// SectionNew contains the information needed to insert a new section.
+type SectionNew struct {
+	Index int    `json:"index" validate:"required,gte=1"`
+	Title string `json:"title" validate:"required,max=200"`
+}
+
+// SectionUp specifies the data of sections that can be updated.
+type SectionUp struct {
+	Index *int    `json:"index" validate:"omitempty,gte=1"`
+	Title *string `json:"title" validate:"omitempty,min=1,max=200"`
+}
+
+// SectionVideos is a section with its videos.
+type SectionVideos struct {
+	Section
+	Videos []Video `json:"videos"`
+}
+
+// Outline lists the videos of a course grouped by section, followed by
+// the videos out of any section.
+type Outline struct {
+	Sections []SectionVideos `json:"sections"`
+	Videos   []Video         `json:"videos"`
+}
+
+// Preview models a short clip of a paid video, which can be played by
+// anyone. Only the segment from Start to End seconds of the clip is
+// played, until its end when End is 0.
+type Preview struct {
+	VideoID   string    `json:"-" db:"video_id"`
+	URL       string    `json:"-" db:"url"`
+	Provider  string    `json:"provider" db:"provider"`
+	Start     int       `json:"start" db:"start_seconds"`
+	End       int       `json:"end" db:"end_seconds"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// PreviewUp contains the clip previewing a video.
+type PreviewUp struct {
+	URL      string `json:"url" validate:"required,url"`
+	Provider string `json:"provider" validate:"omitempty,oneof=native mux vimeo youtube"`
+	Start    int    `json:"start" validate:"gte=0"`
+	End      int    `json:"end" validate:"omitempty,gtfield=Start"`
+}
+
+// Track is a caption track of a video, in WebVTT.
+type Track struct {
+	Language string `json:"language"`
+	VTT      string `json:"vtt"`
 }
 
 // VideoNew contains all the information needed to insert a new video.
+// Videos are published unless created as drafts, Published false.
+// SectionID, when set, must be a section of the same course.
 type VideoNew struct {
 	CourseID    string `json:"courseId" validate:"required"`
+	SectionID   string `json:"sectionId"`
 	Index       int    `json:"index" validate:"required,gte=0"`
 	Name        string `json:"name" validate:"required"`
 	Description string `json:"description" validate:"required"`
 	Free        bool   `json:"free" validate:"required"`
 	URL         string `json:"url" validate:"omitempty,url"`
-	ImageURL    string `json:"imageUrl" validate:"required"`
+	Provider    string `json:"provider" validate:"omitempty,oneof=native mux vimeo youtube"`
+	ImageURL    string `json:"imageUrl"`
+	JobID       string `json:"jobId"`
+	Published   *bool  `json:"published"`
+}
+
+// ImportRow describes a video of an import manifest. Unlike VideoNew,
+// paid videos are allowed, and the description can be left empty.
+type ImportRow struct {
+	CourseID    string `json:"courseId" validate:"required"`
+	Index       int    `json:"index" validate:"gte=1"`
+	Name        string `json:"name" validate:"required,max=200"`
+	Description string `json:"description"`
+	Free        bool   `json:"free"`
+	URL         string `json:"url" validate:"omitempty,url"`
+	Provider    string `json:"provider" validate:"omitempty,oneof=native mux vimeo youtube"`
+	ImageURL    string `json:"imageUrl"`
+	Published   *bool  `json:"published"`
+}
+
+// ImportManifest contains the videos to import at once.
+type ImportManifest struct {
+	Videos []ImportRow `json:"videos" validate:"required,max=1000"`
+}
+
+// ImportResult tells the outcome of a row of a manifest, numbered from
+// 1: either the id of the video created or why it was rejected.
+type ImportResult struct {
+	Row     int    `json:"row"`
+	VideoID string `json:"videoId,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImportReport contains the results of every row of a manifest.
+// Videos are created only when every row is valid.
+type ImportReport struct {
+	Created int            `json:"created"`
+	Results []ImportResult `json:"results"`
 }
 
 // VideoUp specifies the data of videos that can be updated.
+// An empty SectionID moves the video out of its section.
 type VideoUp struct {
 	CourseID    *string `json:"courseId"`
+	SectionID   *string `json:"sectionId"`
 	Index       *int    `json:"index" validate:"omitempty,gte=0"`
 	Name        *string `json:"name"`
 	Description *string `json:"description"`
 	Free        *bool   `json:"free"`
 	URL         *string `json:"url" validate:"omitempty,url"`
+	Provider    *string `json:"provider" validate:"omitempty,oneof=native mux vimeo youtube"`
 	ImageURL    *string `json:"imageUrl"`
+	JobID       *string `json:"jobId"`
+	Published   *bool   `json:"published"`
+}
+
+// VideosCopy lists the videos of a course to copy into another one,
+// all of them when VideoIDs is empty.
+type VideosCopy struct {
+	FromCourseID string   `json:"fromCourseId" validate:"required"`
+	VideoIDs     []string `json:"videoIds" validate:"max=1000,dive,required"`
+}
+
+// VideosOrder lists all the videos of a course in their new order.
+type VideosOrder struct {
+	VideoIDs []string `json:"videoIds" validate:"required,max=1000,dive,required"`
 }
 
 // Progress models users' progress on videos.
+// Progress is the completion percentage and it never decreases,
+// Position is the last watched second and it moves freely.
+// Watched is the total of seconds watched and it never decreases.
+// ReportedAt is the time, as stated by the client, the progress
+// refers to: it is used to order concurrent updates.
 type Progress struct {
-	VideoID   string    `json:"videoId" db:"video_id"`
-	UserID    string    `json:"userId" db:"user_id"`
-	Progress  int       `json:"progress" db:"progress"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	VideoID    string    `json:"videoId" db:"video_id"`
+	UserID     string    `json:"userId" db:"user_id"`
+	Progress   int       `json:"progress" db:"progress"`
+	Position   int       `json:"position" db:"position"`
+	Watched    int       `json:"watched" db:"watched_seconds"`
+	ReportedAt time.Time `json:"reportedAt" db:"reported_at"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Watch models a user starting the playback of a video.
+type Watch struct {
+	UserID    string    `db:"user_id"`
+	VideoID   string    `db:"video_id"`
+	StartedAt time.Time `db:"started_at"`
+}
+
+// Watched models a video recently watched by a user, with its course
+// and how far the user went.
+type Watched struct {
+	VideoID        string    `json:"videoId" db:"video_id"`
+	VideoName      string    `json:"videoName" db:"video_name"`
+	VideoIndex     int       `json:"videoIndex" db:"video_index"`
+	VideoImageURL  string    `json:"videoImageUrl" db:"video_image_url"`
+	CourseID       string    `json:"courseId" db:"course_id"`
+	CourseName     string    `json:"courseName" db:"course_name"`
+	CourseImageURL string    `json:"courseImageUrl" db:"course_image_url"`
+	Progress       int       `json:"progress" db:"progress"`
+	Position       int       `json:"position" db:"position"`
+	WatchedAt      time.Time `json:"watchedAt" db:"watched_at"`
 }
 
 // ProgressUp contains the data of a progress which can be updated.
+// When ReportedAt is missing the time of the request is used.
 type ProgressUp struct {
-	Progress int `json:"progress" validate:"gte=0,lte=100"`
+	Progress   int        `json:"progress" validate:"gte=0,lte=100"`
+	Position   int        `json:"position" validate:"gte=0"`
+	Watched    int        `json:"watched" validate:"gte=0"`
+	ReportedAt *time.Time `json:"reportedAt"`
+}
+
+// ProgressReport is the progress on a video, as reported in a batch.
+type ProgressReport struct {
+	VideoID string `json:"videoId" validate:"required"`
+	ProgressUp
+}
+
+// ProgressBatch contains the progress reported by a player since its
+// last report, possibly many updates of the same videos.
+type ProgressBatch struct {
+	Reports []ProgressReport `json:"reports" validate:"required,max=100,dive"`
+}
+
+// Resume tells where a user left a video, so that the playback
+// continues from there.
+type Resume struct {
+	VideoID  string `json:"videoId"`
+	Position int    `json:"position"`
+	Watched  int    `json:"watched"`
+	Progress int    `json:"progress"`
+}
+
+// Listener is notified whenever a video is created or updated,
+// and when a video becomes playable after being processed.
+type Listener interface {
+	VideoChanged(Video)
+	VideoReady(Video)
+}
+
+// Listeners notifies all of its listeners.
+type Listeners []Listener
+
+// VideoChanged implements the Listener interface.
+func (ls Listeners) VideoChanged(v Video) {
+	for _, l := range ls {
+		l.VideoChanged(v)
+	}
+}
+
+// VideoReady implements the Listener interface.
+func (ls Listeners) VideoReady(v Video) {
+	for _, l := range ls {
+		l.VideoReady(v)
+	}
+}
+
+// Upload models a file uploaded in chunks for a course, e.g. the source
+// of one of its videos. Offset is the number of bytes received so far, so
+// that interrupted uploads resume from there. Once all the Size bytes
+// are received, the file is stored at URL.
+type Upload struct {
+	ID          string     `json:"id" db:"upload_id"`
+	UserID      string     `json:"userId" db:"user_id"`
+	CourseID    *string    `json:"courseId" db:"course_id"`
+	Filename    string     `json:"filename" db:"filename"`
+	ContentType string     `json:"contentType" db:"content_type"`
+	Size        int64      `json:"size" db:"size"`
+	Offset      int64      `json:"offset" db:"upload_offset"`
+	URL         string     `json:"url" db:"url"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+	CompletedAt *time.Time `json:"completedAt" db:"completed_at"`
+}
+
+// UploadNew contains the information needed to start an upload.
+type UploadNew struct {
+	CourseID    *string `json:"courseId" validate:"omitempty,uuid4"`
+	Filename    string  `json:"filename" validate:"required,max=255"`
+	ContentType string  `json:"contentType" validate:"required,startswith=video/|startswith=image/"`
+	Size        int64   `json:"size" validate:"required,gt=0"`
 }
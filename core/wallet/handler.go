@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// Limits of the pages of wallet entries.
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// HandleShowMine returns the balances of the authenticated user with
+// a page of their changes, the most recent first.
+func HandleShowMine(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		page, err := web.ParsePage(r, defaultPageLimit, maxPageLimit)
+		if err != nil {
+			return weberr.BadRequest(err)
+		}
+
+		bs, err := FetchBalances(ctx, db, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		es, err := FetchEntries(ctx, db, clm.UserID, page.Limit, page.Offset())
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, Wallet{Balances: bs, Entries: es}, http.StatusOK)
+	}
+}
+
+// HandleGrant allows administrators to give credit to a user,
+// e.g. for promotions or gift cards.
+func HandleGrant(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		userID := web.Param(r, "id")
+		if err := validate.CheckID(userID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		var g Grant
+		if err := web.Decode(w, r, &g); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(g); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if g.Amount.Units <= 0 {
+			err := errors.New("amount must be positive")
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if _, err := user.Fetch(ctx, db, userID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		amount := money.New(g.Amount.Units, g.Amount.Currency)
+
+		err := database.Transaction(db, func(tx sqlx.ExtContext) error {
+			return Credit(ctx, tx, userID, amount, g.Reason, nil, g.Note)
+		})
+		if err != nil {
+			return fmt.Errorf("crediting user[%s]: %w", userID, err)
+		}
+
+		bs, err := FetchBalances(ctx, db, userID)
+		if err != nil {
+			return err
+		}
+
+		return web.Respond(ctx, w, bs, http.StatusCreated)
+	}
+}
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jmoiron/sqlx"
+)
+
+// AddFunds increases the balance of a user, creating it if needed.
+func AddFunds(ctx context.Context, db sqlx.ExtContext, userID string, amount money.Amount, now time.Time) error {
+	in := struct {
+		UserID   string    `db:"user_id"`
+		Currency string    `db:"currency"`
+		Units    int64     `db:"units"`
+		Now      time.Time `db:"now"`
+	}{
+		UserID:   userID,
+		Currency: amount.Currency,
+		Units:    amount.Units,
+		Now:      now,
+	}
+
+	const q = `
+	INSERT INTO wallets
+		(user_id, currency, units, updated_at)
+	VALUES
+		(:user_id, :currency, :units, :now)
+	ON CONFLICT (user_id, currency) DO UPDATE
+	SET
+		units = wallets.units + EXCLUDED.units,
+		updated_at = EXCLUDED.updated_at`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("adding funds to user[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// WithdrawFunds decreases the balance of a user. It returns
+// database.ErrDBNotFound when the balance is not enough.
+func WithdrawFunds(ctx context.Context, db sqlx.ExtContext, userID string, amount money.Amount, now time.Time) error {
+	in := struct {
+		UserID   string    `db:"user_id"`
+		Currency string    `db:"currency"`
+		Units    int64     `db:"units"`
+		Now      time.Time `db:"now"`
+	}{
+		UserID:   userID,
+		Currency: amount.Currency,
+		Units:    amount.Units,
+		Now:      now,
+	}
+
+	const q = `
+	UPDATE
+		wallets
+	SET
+		units = units - :units,
+		updated_at = :now
+	WHERE
+		user_id = :user_id AND
+		currency = :currency AND
+		units >= :units
+	RETURNING
+		*`
+
+	var b Balance
+	if err := database.NamedQueryStruct(ctx, db, q, in, &b); err != nil {
+		return fmt.Errorf("withdrawing funds from user[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// CreateEntry records a change of a balance.
+func CreateEntry(ctx context.Context, db sqlx.ExtContext, e Entry) error {
+	const q = `
+	INSERT INTO wallet_entries
+		(entry_id, user_id, amount, reason, order_id, note, created_at)
+	VALUES
+		(:entry_id, :user_id, :amount, :reason, :order_id, :note, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, e); err != nil {
+		return fmt.Errorf("inserting wallet entry: %w", err)
+	}
+
+	return nil
+}
+
+// FetchBalances returns all the balances of a user.
+func FetchBalances(ctx context.Context, db sqlx.ExtContext, userID string) ([]Balance, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		wallets
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		currency`
+
+	bs := []Balance{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &bs); err != nil {
+		return nil, fmt.Errorf("selecting balances of user[%s]: %w", userID, err)
+	}
+
+	return bs, nil
+}
+
+// FetchBalance returns the credit of a user in a currency,
+// which is zero if the user never had any.
+func FetchBalance(ctx context.Context, db sqlx.ExtContext, userID string, currency string) (money.Amount, error) {
+	in := struct {
+		UserID   string `db:"user_id"`
+		Currency string `db:"currency"`
+	}{
+		UserID:   userID,
+		Currency: currency,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		wallets
+	WHERE
+		user_id = :user_id AND
+		currency = :currency`
+
+	var b Balance
+	if err := database.NamedQueryStruct(ctx, db, q, in, &b); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return money.Zero(currency), nil
+		}
+		return money.Amount{}, fmt.Errorf("selecting %s balance of user[%s]: %w", currency, userID, err)
+	}
+
+	return b.Amount(), nil
+}
+
+// FetchEntries returns a page of the changes of the balances of a user,
+// the most recent first.
+func FetchEntries(ctx context.Context, db sqlx.ExtContext, userID string, limit int, offset int) ([]Entry, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+		Limit  int    `db:"limit"`
+		Offset int    `db:"offset"`
+	}{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		wallet_entries
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		created_at DESC, entry_id
+	LIMIT :limit
+	OFFSET :offset`
+
+	es := []Entry{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &es); err != nil {
+		return nil, fmt.Errorf("selecting wallet entries of user[%s]: %w", userID, err)
+	}
+
+	return es, nil
+}
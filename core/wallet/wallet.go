@@ -0,0 +1,115 @@
+// Package wallet manages the store credit of the users, which pays for
+// their purchases before any payment provider.
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/money"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrInsufficientFunds is returned when debiting more than the balance.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// Reasons of the changes of a balance.
+const (
+	ReasonRefund   = "refund"
+	ReasonPromo    = "promo"
+	ReasonGiftCard = "gift_card"
+	ReasonPurchase = "purchase"
+	ReasonRelease  = "release"
+)
+
+// Balance models the credit of a user in a currency.
+type Balance struct {
+	UserID    string    `json:"-" db:"user_id"`
+	Currency  string    `json:"currency" db:"currency"`
+	Units     int64     `json:"units" db:"units"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Amount returns the credit of the balance.
+func (b Balance) Amount() money.Amount {
+	return money.New(b.Units, b.Currency)
+}
+
+// Entry records a change of a balance. Credits are positive, debits
+// negative. OrderID is set for changes due to an order.
+type Entry struct {
+	ID        string       `json:"id" db:"entry_id"`
+	UserID    string       `json:"-" db:"user_id"`
+	Amount    money.Amount `json:"amount" db:"amount"`
+	Reason    string       `json:"reason" db:"reason"`
+	OrderID   *string      `json:"orderId" db:"order_id"`
+	Note      string       `json:"note" db:"note"`
+	CreatedAt time.Time    `json:"createdAt" db:"created_at"`
+}
+
+// Wallet models the balances of a user with their latest changes.
+type Wallet struct {
+	Balances []Balance `json:"balances"`
+	Entries  []Entry   `json:"entries"`
+}
+
+// Grant contains the information needed by administrators to give
+// credit to a user.
+type Grant struct {
+	Amount money.Amount `json:"amount" validate:"required"`
+	Reason string       `json:"reason" validate:"required,oneof=refund promo gift_card"`
+	Note   string       `json:"note" validate:"max=255"`
+}
+
+// Credit adds the amount to the balance of the user, recording why.
+func Credit(ctx context.Context, db sqlx.ExtContext, userID string, amount money.Amount, reason string, orderID *string, note string) error {
+	if amount.Units <= 0 {
+		return fmt.Errorf("crediting %s: amount must be positive", amount)
+	}
+
+	e := Entry{
+		ID:        validate.GenerateID(),
+		UserID:    userID,
+		Amount:    amount,
+		Reason:    reason,
+		OrderID:   orderID,
+		Note:      note,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := AddFunds(ctx, db, userID, amount, e.CreatedAt); err != nil {
+		return err
+	}
+
+	return CreateEntry(ctx, db, e)
+}
+
+// Debit takes the amount from the balance of the user.
+// It returns ErrInsufficientFunds when the balance is not enough.
+func Debit(ctx context.Context, db sqlx.ExtContext, userID string, amount money.Amount, reason string, orderID *string) error {
+	if amount.Units <= 0 {
+		return fmt.Errorf("debiting %s: amount must be positive", amount)
+	}
+
+	e := Entry{
+		ID:        validate.GenerateID(),
+		UserID:    userID,
+		Amount:    money.New(-amount.Units, amount.Currency),
+		Reason:    reason,
+		OrderID:   orderID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := WithdrawFunds(ctx, db, userID, amount, e.CreatedAt); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return fmt.Errorf("%w: debiting %s from user[%s]", ErrInsufficientFunds, amount, userID)
+		}
+		return err
+	}
+
+	return CreateEntry(ctx, db, e)
+}
@@ -0,0 +1,119 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE key types and algorithms supported, see RFC 9053.
+const (
+	coseOKP = 1
+	coseEC2 = 2
+	coseRSA = 3
+
+	coseES256 = -7
+	coseEdDSA = -8
+	coseRS256 = -257
+
+	coseP256    = 1
+	coseEd25519 = 6
+)
+
+// Algorithms lists the COSE algorithms of the credentials accepted, in
+// order of preference.
+var Algorithms = []int{coseES256, coseEdDSA, coseRS256}
+
+// errAlgorithm is returned for the credentials whose public key uses an
+// algorithm not supported.
+var errAlgorithm = errors.New("algorithm not supported")
+
+// publicKey is the public key of a credential, encoded as COSE key.
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parseCOSEKey parses the COSE key at the start of b and returns the
+// bytes left after it.
+func parseCOSEKey(b []byte) (publicKey, []byte, error) {
+	v, rest, err := decodeCBOR(b)
+	if err != nil {
+		return publicKey{}, nil, err
+	}
+
+	m, ok := v.(map[any]any)
+	if !ok {
+		return publicKey{}, nil, fmt.Errorf("%w: cose key is not a map", errCBOR)
+	}
+
+	kty, _ := m[int64(1)].(int64)
+	alg, _ := m[int64(3)].(int64)
+	crv, _ := m[int64(-1)].(int64)
+
+	var pk publicKey
+	pk.alg = alg
+
+	switch {
+	case kty == coseEC2 && alg == coseES256 && crv == coseP256:
+		x, _ := m[int64(-2)].([]byte)
+		y, _ := m[int64(-3)].([]byte)
+		if len(x) != 32 || len(y) != 32 {
+			return publicKey{}, nil, errors.New("invalid ec2 coordinates")
+		}
+
+		k := ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !k.Curve.IsOnCurve(k.X, k.Y) {
+			return publicKey{}, nil, errors.New("ec2 point not on curve")
+		}
+		pk.key = &k
+	case kty == coseOKP && alg == coseEdDSA && crv == coseEd25519:
+		x, _ := m[int64(-2)].([]byte)
+		if len(x) != ed25519.PublicKeySize {
+			return publicKey{}, nil, errors.New("invalid okp key")
+		}
+		pk.key = ed25519.PublicKey(x)
+	case kty == coseRSA && alg == coseRS256:
+		n, _ := m[int64(-1)].([]byte)
+		e, _ := m[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return publicKey{}, nil, errors.New("invalid rsa key")
+		}
+
+		k := rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		pk.key = &k
+	default:
+		return publicKey{}, nil, fmt.Errorf("%w: kty %d, alg %d", errAlgorithm, kty, alg)
+	}
+
+	return pk, rest, nil
+}
+
+// verify checks sig of data against the public key.
+func (pk publicKey) verify(data []byte, sig []byte) bool {
+	switch k := pk.key.(type) {
+	case *ecdsa.PublicKey:
+		h := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(k, h[:], sig)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, data, sig)
+	case *rsa.PublicKey:
+		h := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig) == nil
+	}
+
+	return false
+}
@@ -0,0 +1,244 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/config"
+	"github.com/jatolentino/tutorialspoint/core/auth"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/user"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// Session keys of the challenges of the ceremonies in progress.
+const registerKey = "webauthnRegister"
+const loginKey = "webauthnLogin"
+
+// HandleRegisterOptions starts the registration of a passkey for the
+// authenticated user. It responds with the options to create the
+// credential, whose challenge is kept in the session.
+func HandleRegisterOptions(db *sqlx.DB, session *scs.SessionManager, cfg config.Webauthn) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		u, err := user.Fetch(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", clm.UserID, err)
+		}
+
+		creds, err := FetchByUser(ctx, db, u.ID)
+		if err != nil {
+			return fmt.Errorf("fetching credentials of user[%s]: %w", u.ID, err)
+		}
+
+		challenge, err := NewChallenge()
+		if err != nil {
+			return fmt.Errorf("generating challenge: %w", err)
+		}
+
+		ent := UserEntity{
+			ID:          []byte(u.ID),
+			Name:        u.Email,
+			DisplayName: u.Name,
+		}
+
+		session.Put(ctx, registerKey, base64.RawURLEncoding.EncodeToString(challenge))
+		return web.Respond(ctx, w, NewCreationOptions(cfg, challenge, ent, creds), http.StatusOK)
+	}
+}
+
+// HandleRegister completes the registration of a passkey, verifying the
+// credential created by the authenticator for the challenge issued by
+// HandleRegisterOptions.
+func HandleRegister(db *sqlx.DB, session *scs.SessionManager, cfg config.Webauthn) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Attestation
+			Name string `json:"name" validate:"lte=100"`
+		}
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		challenge, err := base64.RawURLEncoding.DecodeString(session.PopString(ctx, registerKey))
+		if err != nil || len(challenge) == 0 {
+			return weberr.BadRequest(errors.New("no passkey registration in progress"))
+		}
+
+		cred, err := Register(cfg, challenge, in.Attestation)
+		if err != nil {
+			if errors.Is(err, errCeremony) {
+				return weberr.NewError(err, errCeremony.Error(), http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		cred.UserID = clm.UserID
+		cred.Name = in.Name
+		if cred.Name == "" {
+			cred.Name = "Passkey"
+		}
+		cred.CreatedAt = time.Now().UTC()
+
+		if err := Create(ctx, db, cred); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "passkey registered already", http.StatusConflict)
+			}
+			return fmt.Errorf("creating credential of user[%s]: %w", clm.UserID, err)
+		}
+
+		return web.Respond(ctx, w, cred, http.StatusCreated)
+	}
+}
+
+// HandleLoginOptions starts the login with a passkey, responding with
+// the options to get the assertion of any passkey of the site, whose
+// challenge is kept in the session.
+func HandleLoginOptions(session *scs.SessionManager, cfg config.Webauthn) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		challenge, err := NewChallenge()
+		if err != nil {
+			return fmt.Errorf("generating challenge: %w", err)
+		}
+
+		session.Put(ctx, loginKey, base64.RawURLEncoding.EncodeToString(challenge))
+		return web.Respond(ctx, w, NewRequestOptions(cfg, challenge), http.StatusOK)
+	}
+}
+
+// HandleLogin makes a session for the owner of the passkey, if the
+// assertion of the challenge issued by HandleLoginOptions is verified.
+// The same as a login with password, users subject to two-factor
+// authentication are left pending until they verify a code.
+func HandleLogin(db *sqlx.DB, session *scs.SessionManager, cfg config.Webauthn, tf config.TwoFactor) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in Assertion
+
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		challenge, err := base64.RawURLEncoding.DecodeString(session.PopString(ctx, loginKey))
+		if err != nil || len(challenge) == 0 {
+			return weberr.NotAuthorized(errors.New("no passkey login in progress"))
+		}
+
+		cred, err := Fetch(ctx, db, in.ID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotAuthorized(err)
+			}
+			return err
+		}
+
+		if len(in.Response.UserHandle) > 0 && !bytes.Equal(in.Response.UserHandle, []byte(cred.UserID)) {
+			return weberr.NotAuthorized(fmt.Errorf("%w: user handle mismatch", errCeremony))
+		}
+
+		count, err := Login(cfg, challenge, cred, in)
+		if err != nil {
+			if errors.Is(err, errCeremony) {
+				return weberr.NotAuthorized(err)
+			}
+			return err
+		}
+
+		if err := Use(ctx, db, cred.ID, count, time.Now().UTC()); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotAuthorized(fmt.Errorf("%w: signature counter did not grow", errCeremony))
+			}
+			return err
+		}
+
+		u, err := user.Fetch(ctx, db, cred.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching user[%s]: %w", cred.UserID, err)
+		}
+
+		if !u.Active {
+			err := fmt.Errorf("user %s is not active yet", u.Email)
+			return weberr.NewError(err, err.Error(), http.StatusLocked)
+		}
+
+		step, err := auth.StartSession(ctx, db, session, u, tf)
+		if err != nil {
+			return err
+		}
+
+		if step != "" {
+			return web.Respond(ctx, w, auth.Pending{TwoFactor: step}, http.StatusAccepted)
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleList returns the passkeys of the authenticated user.
+func HandleList(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		creds, err := FetchByUser(ctx, db, clm.UserID)
+		if err != nil {
+			return fmt.Errorf("fetching credentials of user[%s]: %w", clm.UserID, err)
+		}
+
+		return web.Respond(ctx, w, creds, http.StatusOK)
+	}
+}
+
+// HandleDelete removes the passkey passed via the id path parameter,
+// base64url encoded, of the authenticated user.
+func HandleDelete(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		id, err := base64.RawURLEncoding.DecodeString(web.Param(r, "id"))
+		if err != nil {
+			return weberr.NewError(err, "invalid passkey id", http.StatusUnprocessableEntity)
+		}
+
+		if err := Delete(ctx, db, clm.UserID, id); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
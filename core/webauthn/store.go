@@ -0,0 +1,134 @@
+package webauthn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// Create inserts a new credential.
+func Create(ctx context.Context, db sqlx.ExtContext, cred Credential) error {
+	const q = `
+	INSERT INTO webauthn_credentials
+		(credential_id, user_id, public_key, sign_count, name, created_at)
+	VALUES
+		(:credential_id, :user_id, :public_key, :sign_count, :name, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, cred); err != nil {
+		return fmt.Errorf("inserting credential: %w", err)
+	}
+
+	return nil
+}
+
+// Fetch returns the credential with the passed id.
+func Fetch(ctx context.Context, db sqlx.ExtContext, id []byte) (Credential, error) {
+	in := struct {
+		ID []byte `db:"credential_id"`
+	}{
+		ID: id,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webauthn_credentials
+	WHERE
+		credential_id = :credential_id`
+
+	var cred Credential
+	if err := database.NamedQueryStruct(ctx, db, q, in, &cred); err != nil {
+		return Credential{}, fmt.Errorf("selecting credential: %w", err)
+	}
+
+	return cred, nil
+}
+
+// FetchByUser returns the credentials of the passed user.
+func FetchByUser(ctx context.Context, db sqlx.ExtContext, userID string) ([]Credential, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webauthn_credentials
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		created_at`
+
+	creds := make([]Credential, 0)
+	if err := database.NamedQuerySlice(ctx, db, q, in, &creds); err != nil {
+		return nil, fmt.Errorf("selecting credentials: %w", err)
+	}
+
+	return creds, nil
+}
+
+// Use records a login with the passed credential, storing the new
+// counter of signatures. It returns database.ErrDBNotFound when a
+// concurrent login stored the same or a greater counter.
+func Use(ctx context.Context, db sqlx.ExtContext, id []byte, signCount int64, now time.Time) error {
+	in := struct {
+		ID        []byte    `db:"credential_id"`
+		SignCount int64     `db:"sign_count"`
+		Now       time.Time `db:"now"`
+	}{
+		ID:        id,
+		SignCount: signCount,
+		Now:       now,
+	}
+
+	const q = `
+	UPDATE webauthn_credentials SET
+		sign_count = :sign_count,
+		last_used_at = :now
+	WHERE
+		credential_id = :credential_id AND
+		(sign_count < :sign_count OR :sign_count = 0)
+	RETURNING
+		*`
+
+	var cred Credential
+	if err := database.NamedQueryStruct(ctx, db, q, in, &cred); err != nil {
+		return fmt.Errorf("updating credential: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the credential with the passed id of the user.
+// It returns database.ErrDBNotFound if the user has no such credential.
+func Delete(ctx context.Context, db sqlx.ExtContext, userID string, id []byte) error {
+	in := struct {
+		UserID string `db:"user_id"`
+		ID     []byte `db:"credential_id"`
+	}{
+		UserID: userID,
+		ID:     id,
+	}
+
+	const q = `
+	DELETE FROM webauthn_credentials
+	WHERE
+		credential_id = :credential_id AND
+		user_id = :user_id
+	RETURNING
+		*`
+
+	var cred Credential
+	if err := database.NamedQueryStruct(ctx, db, q, in, &cred); err != nil {
+		return fmt.Errorf("deleting credential: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,354 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/config"
+)
+
+// Base64 is binary data encoded in JSON as base64url without padding,
+// the same as browsers do for the WebAuthn API.
+type Base64 []byte
+
+// MarshalJSON implements the json.Marshaler interface.
+func (b Base64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (b *Base64) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	v, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+
+	*b = v
+	return nil
+}
+
+// Credential models the passkeys of users, identified by the ID given
+// by the authenticator. PublicKey is encoded as COSE key. SignCount is
+// the counter of the signatures made by the authenticator, if it keeps
+// one, which must grow at each login.
+type Credential struct {
+	ID         Base64     `json:"id" db:"credential_id"`
+	UserID     string     `json:"-" db:"user_id"`
+	PublicKey  []byte     `json:"-" db:"public_key"`
+	SignCount  int64      `json:"-" db:"sign_count"`
+	Name       string     `json:"name" db:"name"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt" db:"last_used_at"`
+}
+
+// RelyingParty describes the site to authenticators.
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity describes the user to authenticators. ID is the user
+// handle returned along with the assertions of discoverable credentials.
+type UserEntity struct {
+	ID          Base64 `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParam is an algorithm of the credentials accepted.
+type CredentialParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// CredentialDescriptor identifies a credential.
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   Base64 `json:"id"`
+}
+
+// AuthenticatorSelection requires discoverable credentials, i.e.
+// passkeys, verifying the user, e.g. via biometrics.
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are the options of navigator.credentials.create to
+// register a passkey.
+type CreationOptions struct {
+	Challenge              Base64                 `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParam      `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options of navigator.credentials.get to login
+// with a passkey. No credentials are listed, so that users pick any of
+// their passkeys.
+type RequestOptions struct {
+	Challenge        Base64 `json:"challenge"`
+	RPID             string `json:"rpId"`
+	Timeout          int64  `json:"timeout"`
+	UserVerification string `json:"userVerification"`
+}
+
+// Attestation is the credential created by the authenticator,
+// registering a passkey.
+type Attestation struct {
+	ID       Base64 `json:"rawId" validate:"required"`
+	Response struct {
+		ClientDataJSON    Base64 `json:"clientDataJSON" validate:"required"`
+		AttestationObject Base64 `json:"attestationObject" validate:"required"`
+	} `json:"response"`
+}
+
+// Assertion is the signature of the challenge made by the
+// authenticator, logging in with a passkey.
+type Assertion struct {
+	ID       Base64 `json:"rawId" validate:"required"`
+	Response struct {
+		ClientDataJSON    Base64 `json:"clientDataJSON" validate:"required"`
+		AuthenticatorData Base64 `json:"authenticatorData" validate:"required"`
+		Signature         Base64 `json:"signature" validate:"required"`
+		UserHandle        Base64 `json:"userHandle"`
+	} `json:"response"`
+}
+
+// Flags of the authenticator data.
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// errCeremony is returned when the credential or the assertion sent by
+// the client can't be verified.
+var errCeremony = errors.New("passkey not verified")
+
+// NewChallenge returns a random challenge to be signed by the
+// authenticator.
+func NewChallenge() (Base64, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// NewCreationOptions returns the options to register a passkey for the
+// passed user, excluding the credentials registered already.
+func NewCreationOptions(cfg config.Webauthn, challenge Base64, user UserEntity, creds []Credential) CreationOptions {
+	opts := CreationOptions{
+		Challenge: challenge,
+		RP: RelyingParty{
+			ID:   cfg.RPID,
+			Name: cfg.RPName,
+		},
+		User:               user,
+		Timeout:            cfg.Timeout.Milliseconds(),
+		ExcludeCredentials: make([]CredentialDescriptor, 0, len(creds)),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}
+
+	for _, alg := range Algorithms {
+		opts.PubKeyCredParams = append(opts.PubKeyCredParams, CredentialParam{Type: "public-key", Alg: alg})
+	}
+
+	for _, c := range creds {
+		opts.ExcludeCredentials = append(opts.ExcludeCredentials, CredentialDescriptor{Type: "public-key", ID: c.ID})
+	}
+
+	return opts
+}
+
+// NewRequestOptions returns the options to login with a passkey.
+func NewRequestOptions(cfg config.Webauthn, challenge Base64) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		RPID:             cfg.RPID,
+		Timeout:          cfg.Timeout.Milliseconds(),
+		UserVerification: "required",
+	}
+}
+
+// Register verifies the credential created by the authenticator for
+// challenge and returns it. Since no attestation is requested, the
+// attestation statement is not verified and the public key is trusted
+// as registered by the authenticated user.
+func Register(cfg config.Webauthn, challenge []byte, att Attestation) (Credential, error) {
+	if err := checkClientData(cfg, att.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return Credential{}, err
+	}
+
+	v, _, err := decodeCBOR(att.Response.AttestationObject)
+	if err != nil {
+		return Credential{}, fmt.Errorf("%w: decoding attestation object: %w", errCeremony, err)
+	}
+
+	obj, _ := v.(map[any]any)
+	raw, ok := obj["authData"].([]byte)
+	if !ok {
+		return Credential{}, fmt.Errorf("%w: authenticator data not found", errCeremony)
+	}
+
+	ad, err := parseAuthData(cfg, raw)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	if ad.credID == nil {
+		return Credential{}, fmt.Errorf("%w: attested credential data not found", errCeremony)
+	}
+
+	if !bytes.Equal(ad.credID, att.ID) {
+		return Credential{}, fmt.Errorf("%w: credential id mismatch", errCeremony)
+	}
+
+	cred := Credential{
+		ID:        ad.credID,
+		PublicKey: ad.rawKey,
+		SignCount: int64(ad.signCount),
+	}
+
+	return cred, nil
+}
+
+// Login verifies the assertion of cred for challenge and returns the
+// new counter of signatures. Counters not growing are refused, since the
+// authenticator could be cloned.
+func Login(cfg config.Webauthn, challenge []byte, cred Credential, asr Assertion) (int64, error) {
+	if err := checkClientData(cfg, asr.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	ad, err := parseAuthData(cfg, asr.Response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	pk, _, err := parseCOSEKey(cred.PublicKey)
+	if err != nil {
+		return 0, fmt.Errorf("parsing public key of credential: %w", err)
+	}
+
+	hash := sha256.Sum256(asr.Response.ClientDataJSON)
+	data := append(bytes.Clone(asr.Response.AuthenticatorData), hash[:]...)
+	if !pk.verify(data, asr.Response.Signature) {
+		return 0, fmt.Errorf("%w: wrong signature", errCeremony)
+	}
+
+	count := int64(ad.signCount)
+	if (count != 0 || cred.SignCount != 0) && count <= cred.SignCount {
+		return 0, fmt.Errorf("%w: signature counter did not grow", errCeremony)
+	}
+
+	return count, nil
+}
+
+// checkClientData checks that the client data was collected by the
+// browser for a ceremony of typ with challenge, on the site.
+func checkClientData(cfg config.Webauthn, raw []byte, typ string, challenge []byte) error {
+	var cd struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Origin    string `json:"origin"`
+	}
+
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return fmt.Errorf("%w: decoding client data: %w", errCeremony, err)
+	}
+
+	if cd.Type != typ {
+		return fmt.Errorf("%w: type %s, expected %s", errCeremony, cd.Type, typ)
+	}
+
+	want := base64.RawURLEncoding.EncodeToString(challenge)
+	if subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(want)) != 1 {
+		return fmt.Errorf("%w: wrong challenge", errCeremony)
+	}
+
+	if cd.Origin != cfg.Origin {
+		return fmt.Errorf("%w: origin %s, expected %s", errCeremony, cd.Origin, cfg.Origin)
+	}
+
+	return nil
+}
+
+// authData is the data of the authenticator. The credential id and key
+// are attested when registering a passkey.
+type authData struct {
+	flags     byte
+	signCount uint32
+	credID    []byte
+	rawKey    []byte
+}
+
+// parseAuthData parses the data of the authenticator, which must be
+// scoped to the site and must have verified the user.
+func parseAuthData(cfg config.Webauthn, b []byte) (authData, error) {
+	if len(b) < 37 {
+		return authData{}, fmt.Errorf("%w: authenticator data too short", errCeremony)
+	}
+
+	rpIDHash := sha256.Sum256([]byte(cfg.RPID))
+	if !bytes.Equal(b[:32], rpIDHash[:]) {
+		return authData{}, fmt.Errorf("%w: wrong relying party", errCeremony)
+	}
+
+	ad := authData{
+		flags:     b[32],
+		signCount: binary.BigEndian.Uint32(b[33:37]),
+	}
+
+	if ad.flags&flagUserPresent == 0 || ad.flags&flagUserVerified == 0 {
+		return authData{}, fmt.Errorf("%w: user not verified", errCeremony)
+	}
+
+	if ad.flags&flagAttested == 0 {
+		return ad, nil
+	}
+
+	// The AAGUID of the authenticator and the length of the credential id.
+	rest := b[37:]
+	if len(rest) < 18 {
+		return authData{}, fmt.Errorf("%w: attested credential data too short", errCeremony)
+	}
+
+	n := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if n == 0 || n > 1023 || len(rest) < n {
+		return authData{}, fmt.Errorf("%w: invalid credential id", errCeremony)
+	}
+	ad.credID, rest = rest[:n], rest[n:]
+
+	_, after, err := parseCOSEKey(rest)
+	if err != nil {
+		return authData{}, fmt.Errorf("%w: %w", errCeremony, err)
+	}
+	ad.rawKey = rest[:len(rest)-len(after)]
+
+	return ad, nil
+}
@@ -0,0 +1,214 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/config"
+)
+
+var cfg = config.Webauthn{
+	RPID:    "example.com",
+	RPName:  "Example",
+	Origin:  "https://example.com",
+	Timeout: time.Minute,
+}
+
+// pair is a key and value of a CBOR map, encoded in order.
+type pair struct {
+	k any
+	v any
+}
+
+// encodeCBOR encodes the few types used by authenticators.
+func encodeCBOR(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 1<<8:
+			return []byte{major<<5 | 24, byte(n)}
+		default:
+			b := []byte{major<<5 | 25, 0, 0}
+			binary.BigEndian.PutUint16(b[1:], uint16(n))
+			return b
+		}
+	}
+
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return head(cborNegInt, uint64(-1-v))
+		}
+		return head(cborUint, uint64(v))
+	case []byte:
+		return append(head(cborBytes, uint64(len(v))), v...)
+	case string:
+		return append(head(cborText, uint64(len(v))), v...)
+	case []pair:
+		b := head(cborMap, uint64(len(v)))
+		for _, p := range v {
+			b = append(b, encodeCBOR(p.k)...)
+			b = append(b, encodeCBOR(p.v)...)
+		}
+		return b
+	}
+
+	panic("type not supported")
+}
+
+// authenticator emulates an authenticator with a P-256 passkey.
+type authenticator struct {
+	key   *ecdsa.PrivateKey
+	id    []byte
+	count uint32
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &authenticator{key: key, id: []byte("credential-id")}
+}
+
+func (a *authenticator) authData(rpID string, flags byte, attested bool) []byte {
+	h := sha256.Sum256([]byte(rpID))
+	b := append(h[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(b[33:], a.count)
+
+	if attested {
+		b = append(b, make([]byte, 16)...)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(a.id)))
+		b = append(b, a.id...)
+		b = append(b, encodeCBOR([]pair{
+			{1, coseEC2},
+			{3, coseES256},
+			{-1, coseP256},
+			{-2, a.key.X.FillBytes(make([]byte, 32))},
+			{-3, a.key.Y.FillBytes(make([]byte, 32))},
+		})...)
+	}
+
+	return b
+}
+
+func clientData(typ string, challenge []byte, origin string) []byte {
+	b, _ := json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    origin,
+	})
+	return b
+}
+
+func (a *authenticator) create(challenge []byte, flags byte) Attestation {
+	var att Attestation
+	att.ID = a.id
+	att.Response.ClientDataJSON = clientData("webauthn.create", challenge, cfg.Origin)
+	att.Response.AttestationObject = encodeCBOR([]pair{
+		{"fmt", "none"},
+		{"attStmt", []pair{}},
+		{"authData", a.authData(cfg.RPID, flags, true)},
+	})
+	return att
+}
+
+func (a *authenticator) get(t *testing.T, challenge []byte) Assertion {
+	a.count++
+
+	var asr Assertion
+	asr.ID = a.id
+	asr.Response.ClientDataJSON = clientData("webauthn.get", challenge, cfg.Origin)
+	asr.Response.AuthenticatorData = a.authData(cfg.RPID, flagUserPresent|flagUserVerified, false)
+
+	h := sha256.Sum256(asr.Response.ClientDataJSON)
+	digest := sha256.Sum256(append(bytes.Clone(asr.Response.AuthenticatorData), h[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	asr.Response.Signature = sig
+
+	return asr
+}
+
+func TestCeremonies(t *testing.T) {
+	a := newAuthenticator(t)
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Register(cfg, challenge, a.create(challenge, flagUserPresent|flagAttested)); !errors.Is(err, errCeremony) {
+		t.Fatalf("registering without user verification should fail, got %v", err)
+	}
+
+	other, _ := NewChallenge()
+	if _, err := Register(cfg, other, a.create(challenge, flagUserPresent|flagUserVerified|flagAttested)); !errors.Is(err, errCeremony) {
+		t.Fatalf("registering with another challenge should fail, got %v", err)
+	}
+
+	cred, err := Register(cfg, challenge, a.create(challenge, flagUserPresent|flagUserVerified|flagAttested))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(cred.ID, a.id) {
+		t.Fatalf("wrong credential id %x", cred.ID)
+	}
+
+	asr := a.get(t, challenge)
+	count, err := Login(cfg, challenge, cred, asr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 1 {
+		t.Fatalf("expected signature counter 1, got %d", count)
+	}
+	cred.SignCount = count
+
+	// Replayed assertions don't grow the counter.
+	if _, err := Login(cfg, challenge, cred, asr); !errors.Is(err, errCeremony) {
+		t.Fatalf("replaying an assertion should fail, got %v", err)
+	}
+
+	asr = a.get(t, challenge)
+	asr.Response.Signature[len(asr.Response.Signature)-1] ^= 0xff
+	if _, err := Login(cfg, challenge, cred, asr); !errors.Is(err, errCeremony) {
+		t.Fatalf("logging in with a wrong signature should fail, got %v", err)
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	b := encodeCBOR([]pair{{"a", -300}, {1, []byte{1, 2}}})
+
+	v, rest, err := decodeCBOR(append(b, 0xff))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := v.(map[any]any)
+	if m["a"] != int64(-300) || !bytes.Equal(m[int64(1)].([]byte), []byte{1, 2}) {
+		t.Fatalf("wrong map decoded: %v", m)
+	}
+
+	if !bytes.Equal(rest, []byte{0xff}) {
+		t.Fatalf("wrong bytes left: %x", rest)
+	}
+
+	if _, _, err := decodeCBOR(b[:len(b)-1]); !errors.Is(err, errCBOR) {
+		t.Fatalf("decoding truncated data should fail, got %v", err)
+	}
+}
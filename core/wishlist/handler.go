@@ -0,0 +1,210 @@
+package wishlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/api/web"
+	"github.com/jatolentino/tutorialspoint/api/weberr"
+	"github.com/jatolentino/tutorialspoint/core/cart"
+	"github.com/jatolentino/tutorialspoint/core/claims"
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jatolentino/tutorialspoint/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// errOwned is returned when wishing for a course owned already.
+var errOwned = errors.New("course already owned")
+
+// owned reports whether the user owns the course.
+func owned(ctx context.Context, db sqlx.ExtContext, userID string, courseID string) (bool, error) {
+	cs, err := course.FetchByOwner(ctx, db, userID)
+	if err != nil {
+		return false, fmt.Errorf("checking if course[%s] is already owned by user[%s]: %w", courseID, userID, err)
+	}
+
+	for _, c := range cs {
+		if c.ID == courseID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// HandleList returns the wishlist of the user, with the prices of its
+// courses and the discount of the sales running on them.
+func HandleList(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		its, err := FetchAll(ctx, db, clm.UserID)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, len(its))
+		for i, it := range its {
+			ids[i] = it.CourseID
+		}
+
+		cs, err := course.FetchByIDs(ctx, db, ids)
+		if err != nil {
+			return err
+		}
+
+		if err := course.WithSales(ctx, db, cs, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		byID := make(map[string]course.Course, len(cs))
+		for _, c := range cs {
+			byID[c.ID] = c
+		}
+
+		for i := range its {
+			c := byID[its[i].CourseID]
+			its[i].Price = c.Price
+			its[i].Sale = c.Sale
+		}
+
+		return web.Respond(ctx, w, its, http.StatusOK)
+	}
+}
+
+// HandleCreate adds a course to the wishlist of the user.
+// Courses owned already can't be added.
+func HandleCreate(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var in ItemNew
+		if err := web.Decode(w, r, &in); err != nil {
+			return weberr.BadRequest(fmt.Errorf("unable to decode payload: %w", err))
+		}
+
+		if err := validate.Check(in); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		if err := validate.CheckID(in.CourseID); err != nil {
+			return weberr.NewError(err, err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		if _, err := course.Fetch(ctx, db, in.CourseID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NewError(err, "course not found", http.StatusUnprocessableEntity)
+			}
+			return err
+		}
+
+		own, err := owned(ctx, db, clm.UserID, in.CourseID)
+		if err != nil {
+			return err
+		}
+		if own {
+			return weberr.NewError(errOwned, errOwned.Error(), http.StatusUnprocessableEntity)
+		}
+
+		it := Item{
+			UserID:    clm.UserID,
+			CourseID:  in.CourseID,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		if err := Create(ctx, db, it); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return weberr.NewError(err, "course already in the wishlist", http.StatusConflict)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, it, http.StatusCreated)
+	}
+}
+
+// HandleDelete drops a course from the wishlist of the user.
+func HandleDelete(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		// Deletion is idempotent.
+		if _, err := Take(ctx, db, clm.UserID, courseID); err != nil && !errors.Is(err, database.ErrDBNotFound) {
+			return err
+		}
+
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+}
+
+// HandleMoveToCart moves a course from the wishlist of the user into
+// the cart. Courses bought in the meantime can't be moved.
+func HandleMoveToCart(db *sqlx.DB) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		courseID := web.Param(r, "course_id")
+
+		if err := validate.CheckID(courseID); err != nil {
+			return weberr.BadRequest(fmt.Errorf("passed id is not valid: %w", err))
+		}
+
+		clm, err := claims.Get(ctx)
+		if err != nil {
+			return weberr.NotAuthorized(errors.New("user not authenticated"))
+		}
+
+		own, err := owned(ctx, db, clm.UserID, courseID)
+		if err != nil {
+			return err
+		}
+		if own {
+			return weberr.NewError(errOwned, errOwned.Error(), http.StatusUnprocessableEntity)
+		}
+
+		now := time.Now().UTC()
+		item := cart.Item{
+			UserID:    clm.UserID,
+			CourseID:  courseID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		err = database.Transaction(db, func(tx sqlx.ExtContext) error {
+			if _, err := Take(ctx, tx, clm.UserID, courseID); err != nil {
+				return err
+			}
+
+			if _, err := cart.Upsert(ctx, tx, clm.UserID); err != nil {
+				return fmt.Errorf("upserting user[%s] cart: %w", clm.UserID, err)
+			}
+
+			return cart.PutItem(ctx, tx, item)
+		})
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return weberr.NotFound(err)
+			}
+			return err
+		}
+
+		return web.Respond(ctx, w, item, http.StatusOK)
+	}
+}
@@ -0,0 +1,62 @@
+package wishlist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// Mailer sends the emails needed by the wishlists.
+type Mailer interface {
+	SendSaleNotice(course string, courseID string, percent int, endsAt time.Time, to string) error
+}
+
+// SaleNotifier tells users when the courses in their wishlist go on
+// sale. Users are notified once for each sale.
+type SaleNotifier struct {
+	DB     *sqlx.DB
+	Mailer Mailer
+	Log    logrus.FieldLogger
+}
+
+// Run notifies the sales started every interval.
+// It blocks until the passed context is canceled.
+func (n *SaleNotifier) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := n.notify(ctx); err != nil {
+				n.Log.WithField("message", err).Error("ERROR")
+			}
+		}
+	}
+}
+
+// notify sends a notice for each sale of a wishlisted course.
+func (n *SaleNotifier) notify(ctx context.Context) error {
+	sns, err := FetchSaleNotices(ctx, n.DB, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("fetching sales of wishlisted courses: %w", err)
+	}
+
+	for _, sn := range sns {
+		if err := n.Mailer.SendSaleNotice(sn.CourseName, sn.CourseID, sn.Percent, sn.EndsAt, sn.Email); err != nil {
+			n.Log.WithField("message", fmt.Errorf("notifying sale of course[%s] to %s: %w", sn.CourseID, sn.Email, err)).Error("ERROR")
+			continue
+		}
+
+		if err := MarkSaleNotified(ctx, n.DB, sn.UserID, sn.CourseID, sn.SaleID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
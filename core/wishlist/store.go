@@ -0,0 +1,154 @@
+package wishlist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/database"
+	"github.com/jmoiron/sqlx"
+)
+
+// FetchAll returns the wishlist of the user, the latest items first.
+func FetchAll(ctx context.Context, db sqlx.ExtContext, userID string) ([]Item, error) {
+	in := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		wishlist_items
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		created_at DESC, course_id`
+
+	its := []Item{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &its); err != nil {
+		return nil, fmt.Errorf("selecting wishlist of user[%s]: %w", userID, err)
+	}
+
+	return its, nil
+}
+
+// Create adds an item to the wishlist of its user.
+func Create(ctx context.Context, db sqlx.ExtContext, it Item) error {
+	const q = `
+	INSERT INTO wishlist_items
+		(user_id, course_id, created_at)
+	VALUES
+	(:user_id, :course_id, :created_at)`
+
+	if err := database.NamedExecContext(ctx, db, q, it); err != nil {
+		return fmt.Errorf("inserting wishlist item: %w", err)
+	}
+
+	return nil
+}
+
+// Take drops the course from the wishlist of the user and returns it.
+func Take(ctx context.Context, db sqlx.ExtContext, userID string, courseID string) (Item, error) {
+	in := struct {
+		UserID   string `db:"user_id"`
+		CourseID string `db:"course_id"`
+	}{
+		UserID:   userID,
+		CourseID: courseID,
+	}
+
+	const q = `
+	DELETE FROM
+		wishlist_items
+	WHERE
+		user_id = :user_id AND course_id = :course_id
+	RETURNING *`
+
+	var it Item
+	if err := database.NamedQueryStruct(ctx, db, q, in, &it); err != nil {
+		return Item{}, fmt.Errorf("deleting wishlist item[%s] of user[%s]: %w", courseID, userID, err)
+	}
+
+	return it, nil
+}
+
+// FetchSaleNotices returns the best sale running at now on each
+// wishlisted course, unless its user has been notified of it already.
+func FetchSaleNotices(ctx context.Context, db sqlx.ExtContext, now time.Time) ([]SaleNotice, error) {
+	in := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now,
+	}
+
+	const q = `
+	SELECT
+		n.user_id,
+		n.email,
+		n.course_id,
+		n.course_name,
+		n.sale_id,
+		n.percent,
+		n.ends_at
+	FROM (
+		SELECT DISTINCT ON (w.user_id, w.course_id)
+			w.user_id,
+			u.email,
+			w.course_id,
+			c.name AS course_name,
+			w.notified_sale_id,
+			s.sale_id,
+			s.percent,
+			s.ends_at
+		FROM
+			wishlist_items AS w
+			JOIN users AS u ON u.user_id = w.user_id
+			JOIN courses AS c ON c.course_id = w.course_id
+			JOIN sale_courses AS sc ON sc.course_id = w.course_id
+			JOIN sales AS s ON s.sale_id = sc.sale_id
+		WHERE
+			s.starts_at <= :now AND
+			s.ends_at > :now
+		ORDER BY
+			w.user_id, w.course_id, s.percent DESC, s.ends_at
+	) AS n
+	WHERE
+		n.notified_sale_id IS DISTINCT FROM n.sale_id`
+
+	sns := []SaleNotice{}
+	if err := database.NamedQuerySlice(ctx, db, q, in, &sns); err != nil {
+		return nil, fmt.Errorf("selecting sales of wishlisted courses: %w", err)
+	}
+
+	return sns, nil
+}
+
+// MarkSaleNotified records that the user has been notified of the sale
+// of the wishlisted course.
+func MarkSaleNotified(ctx context.Context, db sqlx.ExtContext, userID string, courseID string, saleID string) error {
+	in := struct {
+		UserID   string `db:"user_id"`
+		CourseID string `db:"course_id"`
+		SaleID   string `db:"sale_id"`
+	}{
+		UserID:   userID,
+		CourseID: courseID,
+		SaleID:   saleID,
+	}
+
+	const q = `
+	UPDATE wishlist_items
+	SET
+		notified_sale_id = :sale_id
+	WHERE
+		user_id = :user_id AND course_id = :course_id`
+
+	if err := database.NamedExecContext(ctx, db, q, in); err != nil {
+		return fmt.Errorf("marking sale[%s] of course[%s] notified to user[%s]: %w", saleID, courseID, userID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package wishlist
+
+import (
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/core/course"
+	"github.com/jatolentino/tutorialspoint/money"
+)
+
+// Item models a course in the wishlist of a user.
+// Price is the original price of the course, to strike through when
+// the course is on sale.
+type Item struct {
+	UserID         string       `json:"-" db:"user_id"`
+	CourseID       string       `json:"courseId" db:"course_id"`
+	Price          money.Amount `json:"price" db:"-"`
+	Sale           *course.Sale `json:"sale,omitempty" db:"-"`
+	NotifiedSaleID *string      `json:"-" db:"notified_sale_id"`
+	CreatedAt      time.Time    `json:"createdAt" db:"created_at"`
+}
+
+// ItemNew models the data required to add a course to the wishlist.
+type ItemNew struct {
+	CourseID string `json:"courseId" validate:"required"`
+}
+
+// SaleNotice is the sale of a wishlisted course the user has not been
+// notified of yet.
+type SaleNotice struct {
+	UserID     string    `db:"user_id"`
+	Email      string    `db:"email"`
+	CourseID   string    `db:"course_id"`
+	CourseName string    `db:"course_name"`
+	SaleID     string    `db:"sale_id"`
+	Percent    int       `db:"percent"`
+	EndsAt     time.Time `db:"ends_at"`
+}
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS compensations
+(
+	provider_id   TEXT                        NOT NULL,
+	provider      TEXT                        NOT NULL,
+	payment_id    TEXT                        NOT NULL,
+	/* pending, fulfilled, refunded or failed. */
+	status        TEXT                        NOT NULL,
+	attempts      INT                         NOT NULL DEFAULT 0,
+	last_error    TEXT                        NOT NULL DEFAULT '',
+	next_run_at   TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (provider_id),
+	FOREIGN KEY (provider_id) REFERENCES orders(provider_id) ON DELETE CASCADE
+);
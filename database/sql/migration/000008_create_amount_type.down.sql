@@ -0,0 +1,7 @@
+ALTER TABLE order_items
+	ALTER COLUMN price TYPE INT USING ((price).units / 100)::INT;
+
+ALTER TABLE courses
+	ALTER COLUMN price TYPE INT USING ((price).units / 100)::INT;
+
+DROP TYPE IF EXISTS amount;
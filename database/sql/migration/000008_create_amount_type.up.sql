@@ -0,0 +1,13 @@
+/* Monetary values are stored in minor units together with their currency. */
+CREATE TYPE amount AS
+(
+	units         BIGINT,
+	currency      TEXT
+);
+
+/* Prices used to be whole dollars. */
+ALTER TABLE courses
+	ALTER COLUMN price TYPE amount USING ROW(price::BIGINT * 100, 'USD')::amount;
+
+ALTER TABLE order_items
+	ALTER COLUMN price TYPE amount USING ROW(price::BIGINT * 100, 'USD')::amount;
@@ -0,0 +1,7 @@
+ALTER TABLE order_items
+	DROP COLUMN IF EXISTS name,
+	DROP COLUMN IF EXISTS coupon,
+	DROP COLUMN IF EXISTS discount,
+	DROP COLUMN IF EXISTS tax;
+
+DROP TABLE IF EXISTS course_prices;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS course_prices
+(
+	course_id     UUID                        NOT NULL,
+	price         amount                      NOT NULL,
+	/* Empty for prices set before the history was recorded. */
+	changed_by    TEXT                        NOT NULL DEFAULT '',
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS course_prices_course_idx ON course_prices (course_id, created_at);
+
+INSERT INTO course_prices (course_id, price, created_at)
+	SELECT course_id, price, updated_at FROM courses;
+
+/* Order items snapshot everything that concurred to their final price. */
+ALTER TABLE order_items
+	ADD COLUMN name     TEXT   NOT NULL DEFAULT '',
+	ADD COLUMN coupon   TEXT   NOT NULL DEFAULT '',
+	ADD COLUMN discount amount,
+	ADD COLUMN tax      amount;
+
+UPDATE order_items AS i
+SET
+	name = c.name,
+	discount = ROW(0, (i.price).currency)::amount,
+	tax = ROW(0, (i.price).currency)::amount
+FROM
+	courses AS c
+WHERE
+	c.course_id = i.course_id;
+
+ALTER TABLE order_items
+	ALTER COLUMN discount SET NOT NULL,
+	ALTER COLUMN tax SET NOT NULL;
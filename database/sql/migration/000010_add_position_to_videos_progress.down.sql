@@ -0,0 +1,3 @@
+ALTER TABLE videos_progress
+	DROP COLUMN IF EXISTS reported_at,
+	DROP COLUMN IF EXISTS position;
@@ -0,0 +1,5 @@
+ALTER TABLE videos_progress
+	ADD COLUMN position     INT         NOT NULL DEFAULT 0 CHECK (position >= 0),
+	ADD COLUMN reported_at  TIMESTAMP   NOT NULL DEFAULT NOW();
+
+UPDATE videos_progress SET reported_at = updated_at;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS videos_search_idx;
+DROP INDEX IF EXISTS courses_search_idx;
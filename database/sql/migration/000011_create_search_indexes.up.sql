@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS courses_search_idx ON courses USING GIN (to_tsvector('english', name || ' ' || description));
+CREATE INDEX IF NOT EXISTS videos_search_idx ON videos USING GIN (to_tsvector('english', name || ' ' || description));
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS video_renditions;
+DROP INDEX IF EXISTS videos_job_id_idx;
+
+ALTER TABLE videos
+	DROP COLUMN IF EXISTS job_id,
+	DROP COLUMN IF EXISTS status;
@@ -0,0 +1,19 @@
+ALTER TABLE videos
+	ADD COLUMN status    TEXT    NOT NULL DEFAULT 'ready',
+	ADD COLUMN job_id    TEXT    NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS videos_job_id_idx ON videos (job_id) WHERE job_id <> '';
+
+CREATE TABLE IF NOT EXISTS video_renditions
+(
+	video_id      UUID                        NOT NULL,
+	name          TEXT                        NOT NULL,
+	url           TEXT                        NOT NULL,
+	width         INT                         NOT NULL,
+	height        INT                         NOT NULL,
+	bitrate       INT                         NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (video_id, name),
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
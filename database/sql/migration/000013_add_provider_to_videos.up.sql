@@ -0,0 +1,2 @@
+ALTER TABLE videos
+	ADD COLUMN provider TEXT NOT NULL DEFAULT 'native';
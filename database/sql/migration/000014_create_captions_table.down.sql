@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS caption_jobs;
+DROP TABLE IF EXISTS captions;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS captions
+(
+	video_id      UUID                        NOT NULL,
+	language      TEXT                        NOT NULL,
+	vtt           TEXT                        NOT NULL,
+	/* manual or machine. */
+	source        TEXT                        NOT NULL,
+	reviewed      BOOLEAN                     NOT NULL DEFAULT FALSE,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (video_id, language),
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS caption_jobs
+(
+	video_id      UUID                        NOT NULL,
+	url           TEXT                        NOT NULL,
+	/* pending, done or failed. */
+	status        TEXT                        NOT NULL,
+	attempts      INT                         NOT NULL DEFAULT 0,
+	last_error    TEXT                        NOT NULL DEFAULT '',
+	next_run_at   TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (video_id, url),
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+ALTER TABLE order_items
+	DROP COLUMN IF EXISTS expiry_notified,
+	DROP COLUMN IF EXISTS renewal,
+	DROP COLUMN IF EXISTS expires_at;
+
+ALTER TABLE courses
+	DROP COLUMN IF EXISTS renewal_discount,
+	DROP COLUMN IF EXISTS access_days;
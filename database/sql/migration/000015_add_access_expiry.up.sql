@@ -0,0 +1,10 @@
+/* Courses sold with an access duration, 0 means forever. */
+ALTER TABLE courses
+	ADD COLUMN access_days       INT    NOT NULL DEFAULT 0 CHECK (access_days >= 0),
+	ADD COLUMN renewal_discount  INT    NOT NULL DEFAULT 0 CHECK (renewal_discount BETWEEN 0 AND 100);
+
+/* Access bought by an item expires at expires_at, if any. */
+ALTER TABLE order_items
+	ADD COLUMN expires_at        TIMESTAMP,
+	ADD COLUMN renewal           BOOLEAN   NOT NULL DEFAULT FALSE,
+	ADD COLUMN expiry_notified   BOOLEAN   NOT NULL DEFAULT FALSE;
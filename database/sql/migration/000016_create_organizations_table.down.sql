@@ -0,0 +1,10 @@
+ALTER TABLE order_items
+	DROP COLUMN IF EXISTS quantity;
+
+ALTER TABLE orders
+	DROP COLUMN IF EXISTS org_id;
+
+DROP TABLE IF EXISTS seat_assignments;
+DROP TABLE IF EXISTS org_seats;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS organizations;
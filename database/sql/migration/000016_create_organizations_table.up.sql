@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS organizations
+(
+	org_id        UUID                        NOT NULL,
+	name          TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (org_id)
+);
+
+/* Members are invited by email and join the organization later on. */
+CREATE TABLE IF NOT EXISTS org_members
+(
+	org_id        UUID                        NOT NULL,
+	email         TEXT                        NOT NULL,
+	user_id       UUID,
+	/* admin or member. */
+	role          TEXT                        NOT NULL,
+	invited_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	joined_at     TIMESTAMP,
+
+	PRIMARY KEY (org_id, email),
+	UNIQUE (org_id, user_id),
+	FOREIGN KEY (org_id) REFERENCES organizations(org_id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS org_seats
+(
+	org_id        UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+	quantity      INT                         NOT NULL CHECK (quantity >= 0),
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (org_id, course_id),
+	FOREIGN KEY (org_id) REFERENCES organizations(org_id) ON DELETE CASCADE,
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS seat_assignments
+(
+	org_id        UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+	user_id       UUID                        NOT NULL,
+	assigned_at   TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (org_id, course_id, user_id),
+	FOREIGN KEY (org_id, course_id) REFERENCES org_seats(org_id, course_id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS seat_assignments_user_idx ON seat_assignments (user_id, course_id);
+
+/* Orders of organizations buy seats instead of personal access. */
+ALTER TABLE orders
+	ADD COLUMN org_id     UUID    REFERENCES organizations(org_id);
+
+ALTER TABLE order_items
+	ADD COLUMN quantity   INT     NOT NULL DEFAULT 1 CHECK (quantity > 0);
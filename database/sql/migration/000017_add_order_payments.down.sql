@@ -0,0 +1,3 @@
+ALTER TABLE orders
+	DROP COLUMN IF EXISTS payment_id,
+	DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,9 @@
+/* The provider and the payment of an order are needed to refund it. */
+ALTER TABLE orders
+	ADD COLUMN provider      TEXT    NOT NULL DEFAULT '',
+	ADD COLUMN payment_id    TEXT    NOT NULL DEFAULT '';
+
+/* Stripe checkout sessions are the only ids prefixed by cs_. */
+UPDATE orders
+SET
+	provider = CASE WHEN provider_id LIKE 'cs\_%' THEN 'stripe' ELSE 'paypal' END;
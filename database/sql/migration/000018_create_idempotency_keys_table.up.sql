@@ -0,0 +1,13 @@
+/* Responses of checkouts, replayed when clients retry with the same key. */
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+	user_id       UUID                        NOT NULL,
+	idem_key      TEXT                        NOT NULL,
+	provider      TEXT                        NOT NULL,
+	/* NULL while the request is in progress. */
+	response      JSONB,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (user_id, idem_key),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
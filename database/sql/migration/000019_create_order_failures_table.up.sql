@@ -0,0 +1,16 @@
+/* Incidents happened while completing payed orders. */
+CREATE TABLE IF NOT EXISTS order_failures
+(
+	failure_id    UUID                        NOT NULL,
+	provider_id   TEXT                        NOT NULL,
+	order_id      UUID,
+	/* fulfillment, retry or refund. */
+	stage         TEXT                        NOT NULL,
+	error         TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (failure_id),
+	FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS order_failures_order_idx ON order_failures (order_id, created_at);
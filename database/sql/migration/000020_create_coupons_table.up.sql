@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS coupons
+(
+	coupon_id     UUID                        NOT NULL,
+	code          TEXT                        NOT NULL,
+	/* percent or fixed. */
+	kind          TEXT                        NOT NULL,
+	percent       INT                         NOT NULL DEFAULT 0 CHECK (percent >= 0 AND percent <= 100),
+	amount        amount,
+	/* NULL when the coupon applies to all the courses. */
+	course_id     UUID,
+	expires_at    TIMESTAMP,
+	/* NULL when the coupon can be used without limits. */
+	max_uses      INT,
+	uses          INT                         NOT NULL DEFAULT 0 CHECK (uses >= 0),
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (coupon_id),
+	UNIQUE (code),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE
+);
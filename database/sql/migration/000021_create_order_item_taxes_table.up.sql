@@ -0,0 +1,21 @@
+/* Breakdown of the taxes applied to order items, as calculated by the provider. */
+CREATE TABLE IF NOT EXISTS order_item_taxes
+(
+	tax_id        UUID                        NOT NULL,
+	order_id      UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+	/* e.g. VAT or Sales Tax. */
+	name          TEXT                        NOT NULL,
+	jurisdiction  TEXT                        NOT NULL DEFAULT '',
+	country       TEXT                        NOT NULL DEFAULT '',
+	state         TEXT                        NOT NULL DEFAULT '',
+	percentage    NUMERIC(7, 4)               NOT NULL,
+	inclusive     BOOLEAN                     NOT NULL,
+	amount        amount                      NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (tax_id),
+	FOREIGN KEY (order_id, course_id) REFERENCES order_items(order_id, course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS order_item_taxes_order_idx ON order_item_taxes (order_id);
@@ -0,0 +1,14 @@
+/* Invoices are numbered progressively across all the orders. */
+CREATE SEQUENCE IF NOT EXISTS invoice_numbers;
+
+CREATE TABLE IF NOT EXISTS invoices
+(
+	order_id      UUID                        NOT NULL,
+	number        BIGINT                      NOT NULL,
+	pdf           BYTEA                       NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (order_id),
+	UNIQUE (number),
+	FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE
+);
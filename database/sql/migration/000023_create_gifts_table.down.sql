@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS gifts;
+
+ALTER TABLE orders
+	DROP COLUMN IF EXISTS gift;
@@ -0,0 +1,25 @@
+/* Gift orders grant access to the recipients of their gifts, not to the buyer. */
+ALTER TABLE orders
+	ADD COLUMN gift BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS gifts
+(
+	gift_id       UUID                        NOT NULL,
+	order_id      UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+	email         TEXT                        NOT NULL,
+	code          TEXT                        NOT NULL,
+	/* Whether the code has been sent to the recipient. */
+	notified      BOOLEAN                     NOT NULL DEFAULT FALSE,
+	redeemed_by   UUID,
+	redeemed_at   TIMESTAMP,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (gift_id),
+	UNIQUE (code),
+	FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	FOREIGN KEY (redeemed_by) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS gifts_redeemed_by_idx ON gifts (redeemed_by);
@@ -0,0 +1,5 @@
+ALTER TABLE order_items
+	DROP COLUMN IF EXISTS credit;
+
+DROP TABLE IF EXISTS wallet_entries;
+DROP TABLE IF EXISTS wallets;
@@ -0,0 +1,41 @@
+/* Store credit of the users, one balance per currency. */
+CREATE TABLE IF NOT EXISTS wallets
+(
+	user_id       UUID                        NOT NULL,
+	currency      TEXT                        NOT NULL,
+	units         BIGINT                      NOT NULL CHECK (units >= 0),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (user_id, currency),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+/* Every change of a balance: credits are positive, debits negative. */
+CREATE TABLE IF NOT EXISTS wallet_entries
+(
+	entry_id      UUID                        NOT NULL,
+	user_id       UUID                        NOT NULL,
+	amount        amount                      NOT NULL,
+	/* refund, promo, gift_card, purchase or release. */
+	reason        TEXT                        NOT NULL,
+	order_id      UUID,
+	note          TEXT                        NOT NULL DEFAULT '',
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (entry_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+	FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS wallet_entries_user_idx ON wallet_entries (user_id, created_at);
+
+/* Store credit spent on each unit of an order item. */
+ALTER TABLE order_items
+	ADD COLUMN credit amount;
+
+UPDATE order_items
+SET
+	credit = ROW(0, (price).currency)::amount;
+
+ALTER TABLE order_items
+	ALTER COLUMN credit SET NOT NULL;
@@ -0,0 +1,38 @@
+/* Sets of courses sold together at a bundled price. */
+CREATE TABLE IF NOT EXISTS bundles
+(
+	bundle_id     UUID                        NOT NULL,
+	name          TEXT                        NOT NULL,
+	description   TEXT                        NOT NULL DEFAULT '',
+	price         amount                      NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (bundle_id)
+);
+
+CREATE TABLE IF NOT EXISTS bundle_courses
+(
+	bundle_id     UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+
+	PRIMARY KEY (bundle_id, course_id),
+	FOREIGN KEY (bundle_id) REFERENCES bundles(bundle_id) ON DELETE CASCADE,
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS cart_bundles
+(
+	user_id       UUID                        NOT NULL,
+	bundle_id     UUID                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (user_id, bundle_id),
+	FOREIGN KEY (user_id) REFERENCES carts(user_id) ON DELETE CASCADE,
+	FOREIGN KEY (bundle_id) REFERENCES bundles(bundle_id) ON DELETE CASCADE
+);
+
+/* Bundle the course was bought with, if any. */
+ALTER TABLE order_items
+	ADD COLUMN bundle_id UUID REFERENCES bundles(bundle_id) ON DELETE SET NULL;
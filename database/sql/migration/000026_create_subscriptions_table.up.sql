@@ -0,0 +1,20 @@
+/* Subscriptions of the users to the all-access plan. */
+CREATE TABLE IF NOT EXISTS subscriptions
+(
+	subscription_id UUID                      NOT NULL,
+	user_id       UUID                        NOT NULL,
+	provider_id   TEXT                        NOT NULL,
+	customer_id   TEXT                        NOT NULL DEFAULT '',
+	/* active, past_due or canceled. */
+	status        TEXT                        NOT NULL,
+	period_end    TIMESTAMP                   NOT NULL,
+	canceled_at   TIMESTAMP,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (subscription_id),
+	UNIQUE (provider_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS subscriptions_user_idx ON subscriptions (user_id);
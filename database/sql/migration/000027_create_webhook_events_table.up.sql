@@ -0,0 +1,22 @@
+/* Webhook calls received from the payment providers. */
+CREATE TABLE IF NOT EXISTS webhook_events
+(
+	event_id      UUID                        NOT NULL,
+	provider      TEXT                        NOT NULL,
+	type          TEXT                        NOT NULL DEFAULT '',
+	payload       TEXT                        NOT NULL,
+	provider_id   TEXT                        NOT NULL DEFAULT '',
+	payment_id    TEXT                        NOT NULL DEFAULT '',
+	/* The status of the payment notified by the event. */
+	payment_status TEXT                       NOT NULL DEFAULT '',
+	/* received, processed, ignored, compensated or failed. */
+	result        TEXT                        NOT NULL,
+	error         TEXT                        NOT NULL DEFAULT '',
+	attempts      INT                         NOT NULL DEFAULT 0,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_events_result_idx ON webhook_events (result, created_at);
@@ -0,0 +1,3 @@
+/* Items can be refunded one by one, revoking the access to their course only. */
+ALTER TABLE order_items
+	ADD COLUMN refunded_at   TIMESTAMP;
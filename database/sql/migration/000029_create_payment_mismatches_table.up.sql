@@ -0,0 +1,18 @@
+/* Payments which don't match the local orders, found by the reconciliation. */
+CREATE TABLE IF NOT EXISTS payment_mismatches
+(
+	mismatch_id   UUID                        NOT NULL,
+	provider      TEXT                        NOT NULL,
+	provider_id   TEXT                        NOT NULL DEFAULT '',
+	payment_id    TEXT                        NOT NULL DEFAULT '',
+	order_id      UUID,
+	/* paid_not_fulfilled or fulfilled_not_paid. */
+	kind          TEXT                        NOT NULL,
+	detected_at   TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (mismatch_id),
+	UNIQUE (provider, kind, provider_id, payment_id),
+	FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS payment_mismatches_detected_idx ON payment_mismatches (detected_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS regional_prices;
+
+ALTER TABLE users
+	DROP COLUMN IF EXISTS country;
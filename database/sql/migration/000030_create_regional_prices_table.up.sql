@@ -0,0 +1,16 @@
+/* Users are charged the regional price of their country, if any. */
+ALTER TABLE users
+	ADD COLUMN country TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS regional_prices
+(
+	course_id     UUID                        NOT NULL,
+	/* ISO 3166-1 alpha-2 code of the country. */
+	region        TEXT                        NOT NULL,
+	price         amount                      NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (course_id, region),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE
+);
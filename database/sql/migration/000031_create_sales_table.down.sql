@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS sale_courses;
+DROP TABLE IF EXISTS sales;
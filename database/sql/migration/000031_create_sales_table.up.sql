@@ -0,0 +1,27 @@
+/* Time-boxed discounts on courses. */
+CREATE TABLE IF NOT EXISTS sales
+(
+	sale_id       UUID                        NOT NULL,
+	name          TEXT                        NOT NULL,
+	percent       INT                         NOT NULL,
+	starts_at     TIMESTAMP                   NOT NULL,
+	ends_at       TIMESTAMP                   NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (sale_id),
+	CHECK (percent BETWEEN 1 AND 100),
+	CHECK (ends_at > starts_at)
+);
+
+CREATE TABLE IF NOT EXISTS sale_courses
+(
+	sale_id       UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+
+	PRIMARY KEY (sale_id, course_id),
+	FOREIGN KEY (sale_id) REFERENCES sales(sale_id) ON DELETE CASCADE,
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS sale_courses_course_idx ON sale_courses (course_id);
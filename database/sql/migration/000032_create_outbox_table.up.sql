@@ -0,0 +1,16 @@
+/* Events written together with the changes they notify, relayed to the consumers afterwards. */
+CREATE TABLE IF NOT EXISTS outbox
+(
+	event_id      UUID                        NOT NULL,
+	topic         TEXT                        NOT NULL,
+	payload       TEXT                        NOT NULL,
+	attempts      INT                         NOT NULL DEFAULT 0,
+	last_error    TEXT                        NOT NULL DEFAULT '',
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	/* NULL until every consumer received the event. */
+	published_at  TIMESTAMP,
+
+	PRIMARY KEY (event_id)
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (created_at) WHERE published_at IS NULL;
@@ -0,0 +1,9 @@
+/* Events of the outbox already handled by each consumer, to drop the ones delivered twice. */
+CREATE TABLE IF NOT EXISTS consumed_events
+(
+	event_id      UUID                        NOT NULL,
+	consumer      TEXT                        NOT NULL,
+	consumed_at   TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (event_id, consumer)
+);
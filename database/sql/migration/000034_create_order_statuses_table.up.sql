@@ -0,0 +1,15 @@
+/* History of the statuses of the orders. */
+CREATE TABLE IF NOT EXISTS order_statuses
+(
+	order_id      UUID                        NOT NULL,
+	status        TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS order_statuses_order_idx ON order_statuses (order_id, created_at);
+
+/* Orders placed before only know their current status. */
+INSERT INTO order_statuses (order_id, status, created_at)
+	SELECT order_id, status, updated_at FROM orders;
@@ -0,0 +1,22 @@
+/* Refunds asked by the buyers, approved or denied by the administrators. */
+CREATE TABLE IF NOT EXISTS refund_requests
+(
+	request_id    UUID                        NOT NULL,
+	order_id      UUID                        NOT NULL,
+	user_id       UUID                        NOT NULL,
+	reason        TEXT                        NOT NULL,
+	/* pending, approved or denied. */
+	status        TEXT                        NOT NULL,
+	/* Why the request has been denied, if it was. */
+	note          TEXT                        NOT NULL DEFAULT '',
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (request_id),
+	FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+/* Orders can have only one request waiting for a decision. */
+CREATE UNIQUE INDEX IF NOT EXISTS refund_requests_pending_idx ON refund_requests (order_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS refund_requests_status_idx ON refund_requests (status, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE orders
+	DROP COLUMN IF EXISTS commission,
+	DROP COLUMN IF EXISTS affiliate_id;
+
+DROP TABLE IF EXISTS affiliates;
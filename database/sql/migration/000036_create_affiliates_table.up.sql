@@ -0,0 +1,21 @@
+/* Referrers earning a commission on the orders placed with their code. */
+CREATE TABLE IF NOT EXISTS affiliates
+(
+	affiliate_id  UUID                        NOT NULL,
+	user_id       UUID UNIQUE                 NOT NULL,
+	code          TEXT UNIQUE                 NOT NULL,
+	/* Percentage of what the buyers pay. */
+	commission    INT                         NOT NULL CHECK (commission BETWEEN 0 AND 100),
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (affiliate_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+/* Orders placed with a referral code keep the commission of the time. */
+ALTER TABLE orders
+	ADD COLUMN affiliate_id  UUID REFERENCES affiliates(affiliate_id) ON DELETE SET NULL,
+	ADD COLUMN commission    INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS orders_affiliate_idx ON orders (affiliate_id) WHERE affiliate_id IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS cart_reminder_optouts;
+DROP INDEX IF EXISTS carts_updated_idx;
+ALTER TABLE carts
+	DROP COLUMN IF EXISTS reminded_at;
@@ -0,0 +1,15 @@
+/* When the user was last reminded of the cart, if ever. */
+ALTER TABLE carts
+	ADD COLUMN reminded_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS carts_updated_idx ON carts (updated_at);
+
+/* Users who don't want to be reminded of their abandoned carts. */
+CREATE TABLE IF NOT EXISTS cart_reminder_optouts
+(
+	user_id       UUID                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (user_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+/* Courses the users wish to buy, to be notified when they go on sale. */
+CREATE TABLE IF NOT EXISTS wishlist_items
+(
+	user_id           UUID                        NOT NULL,
+	course_id         UUID                        NOT NULL,
+	/* Sale the user was last notified of, if any. */
+	notified_sale_id  UUID,
+	created_at        TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (user_id, course_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	FOREIGN KEY (notified_sale_id) REFERENCES sales(sale_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS wishlist_items_course_idx ON wishlist_items (course_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS media_jobs
+(
+	video_id      UUID                        NOT NULL,
+	source_url    TEXT                        NOT NULL,
+	/* pending, done or failed. */
+	status        TEXT                        NOT NULL,
+	attempts      INT                         NOT NULL DEFAULT 0,
+	last_error    TEXT                        NOT NULL DEFAULT '',
+	manifest_url  TEXT                        NOT NULL DEFAULT '',
+	next_run_at   TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (video_id),
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
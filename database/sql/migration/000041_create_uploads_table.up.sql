@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS uploads
+(
+	upload_id     UUID                        NOT NULL,
+	user_id       UUID                        NOT NULL,
+	filename      TEXT                        NOT NULL,
+	content_type  TEXT                        NOT NULL,
+	size          BIGINT                      NOT NULL,
+	/* Bytes received so far. */
+	upload_offset BIGINT                      NOT NULL DEFAULT 0,
+	/* Set once every byte has been received. */
+	url           TEXT                        NOT NULL DEFAULT '',
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	completed_at  TIMESTAMP,
+
+	PRIMARY KEY (upload_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS video_chapters
+(
+	video_id      UUID                        NOT NULL,
+	/* Offset of the chapter from the start of the video. */
+	start_seconds INT                         NOT NULL,
+	title         TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (video_id, start_seconds),
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS watch_history
+(
+	user_id       UUID                        NOT NULL,
+	video_id      UUID                        NOT NULL,
+	started_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (user_id, started_at, video_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE videos_progress
+	DROP COLUMN IF EXISTS watched_seconds;
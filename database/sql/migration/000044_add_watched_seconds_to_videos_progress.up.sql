@@ -0,0 +1,2 @@
+ALTER TABLE videos_progress
+	ADD COLUMN watched_seconds INT NOT NULL DEFAULT 0 CHECK (watched_seconds >= 0);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS resources
+(
+	resource_id   UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+	/* Set for the resources of a single video of the course. */
+	video_id      UUID,
+	name          TEXT                        NOT NULL,
+	kind          TEXT                        NOT NULL,
+	filename      TEXT                        NOT NULL DEFAULT '',
+	content_type  TEXT                        NOT NULL DEFAULT '',
+	size          BIGINT                      NOT NULL DEFAULT 0,
+	/* Where files are stored, empty for links. */
+	storage_key   TEXT                        NOT NULL DEFAULT '',
+	/* Where links point to, empty for files. */
+	url           TEXT                        NOT NULL DEFAULT '',
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (resource_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS resources_course_id_idx ON resources (course_id);
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS questions
+(
+	question_id   UUID                        NOT NULL,
+	video_id      UUID                        NOT NULL,
+	user_id       UUID                        NOT NULL,
+	title         TEXT                        NOT NULL,
+	body          TEXT                        NOT NULL,
+	resolved      BOOLEAN                     NOT NULL DEFAULT FALSE,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (question_id),
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS questions_video_id_idx ON questions (video_id, created_at);
+
+CREATE TABLE IF NOT EXISTS question_replies
+(
+	reply_id      UUID                        NOT NULL,
+	question_id   UUID                        NOT NULL,
+	user_id       UUID                        NOT NULL,
+	body          TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (reply_id),
+	FOREIGN KEY (question_id) REFERENCES questions(question_id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS question_replies_question_id_idx ON question_replies (question_id, created_at);
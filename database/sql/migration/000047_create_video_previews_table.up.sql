@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS video_previews
+(
+	video_id      UUID                        NOT NULL,
+	url           TEXT                        NOT NULL,
+	provider      TEXT                        NOT NULL,
+	/* Segment of the clip played, until its end when end_seconds is 0. */
+	start_seconds INT                         NOT NULL DEFAULT 0,
+	end_seconds   INT                         NOT NULL DEFAULT 0,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (video_id),
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE videos
+	ADD COLUMN published BOOLEAN NOT NULL DEFAULT TRUE;
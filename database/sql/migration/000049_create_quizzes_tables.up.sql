@@ -0,0 +1,68 @@
+CREATE TABLE IF NOT EXISTS quizzes
+(
+	quiz_id       UUID                        NOT NULL,
+	video_id      UUID                        NOT NULL,
+	title         TEXT                        NOT NULL,
+	/* Percentage of questions to answer correctly to pass. */
+	pass_score    INT                         NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (quiz_id),
+	UNIQUE (video_id),
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS quiz_questions
+(
+	question_id   UUID                        NOT NULL,
+	quiz_id       UUID                        NOT NULL,
+	position      INT                         NOT NULL,
+	text          TEXT                        NOT NULL,
+
+	PRIMARY KEY (question_id),
+	UNIQUE (quiz_id, position),
+	FOREIGN KEY (quiz_id) REFERENCES quizzes(quiz_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS quiz_choices
+(
+	choice_id     UUID                        NOT NULL,
+	question_id   UUID                        NOT NULL,
+	position      INT                         NOT NULL,
+	text          TEXT                        NOT NULL,
+	correct       BOOLEAN                     NOT NULL DEFAULT FALSE,
+
+	PRIMARY KEY (choice_id),
+	UNIQUE (question_id, position),
+	FOREIGN KEY (question_id) REFERENCES quiz_questions(question_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS quiz_attempts
+(
+	attempt_id    UUID                        NOT NULL,
+	quiz_id       UUID                        NOT NULL,
+	user_id       UUID                        NOT NULL,
+	correct       INT                         NOT NULL,
+	total         INT                         NOT NULL,
+	score         INT                         NOT NULL,
+	passed        BOOLEAN                     NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (attempt_id),
+	FOREIGN KEY (quiz_id) REFERENCES quizzes(quiz_id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS quiz_attempts_user_id_idx ON quiz_attempts (quiz_id, user_id, created_at);
+
+/* The choices selected in an attempt. */
+CREATE TABLE IF NOT EXISTS quiz_attempt_answers
+(
+	attempt_id    UUID                        NOT NULL,
+	choice_id     UUID                        NOT NULL,
+
+	PRIMARY KEY (attempt_id, choice_id),
+	FOREIGN KEY (attempt_id) REFERENCES quiz_attempts(attempt_id) ON DELETE CASCADE,
+	FOREIGN KEY (choice_id) REFERENCES quiz_choices(choice_id) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+/* Transcripts are stored as timestamped segments, so that searches point to the moment of the video. */
+CREATE TABLE IF NOT EXISTS transcript_segments
+(
+	video_id      UUID                        NOT NULL,
+	start_seconds INT                         NOT NULL,
+	end_seconds   INT                         NOT NULL,
+	text          TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (video_id, start_seconds),
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS transcript_segments_search_idx ON transcript_segments USING GIN (to_tsvector('english', text));
@@ -0,0 +1,9 @@
+DELETE FROM videos WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS videos_course_id_index_key;
+
+ALTER TABLE videos
+	ADD CONSTRAINT videos_course_id_index_key UNIQUE (course_id, index);
+
+ALTER TABLE videos
+	DROP COLUMN IF EXISTS deleted_at;
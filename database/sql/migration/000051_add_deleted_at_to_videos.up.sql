@@ -0,0 +1,8 @@
+ALTER TABLE videos
+	ADD COLUMN deleted_at TIMESTAMP;
+
+/* Deleted videos keep their index, which the other videos can take. */
+ALTER TABLE videos
+	DROP CONSTRAINT IF EXISTS videos_course_id_index_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS videos_course_id_index_key ON videos (course_id, index) WHERE deleted_at IS NULL;
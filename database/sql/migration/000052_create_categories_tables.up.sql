@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS categories
+(
+	category_id   UUID                        NOT NULL,
+	name          TEXT                        NOT NULL,
+	slug          TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (category_id),
+	UNIQUE (slug)
+);
+
+CREATE TABLE IF NOT EXISTS tags
+(
+	tag_id        UUID                        NOT NULL,
+	name          TEXT                        NOT NULL,
+	slug          TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (tag_id),
+	UNIQUE (slug)
+);
+
+CREATE TABLE IF NOT EXISTS course_categories
+(
+	course_id     UUID                        NOT NULL,
+	category_id   UUID                        NOT NULL,
+
+	PRIMARY KEY (course_id, category_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	FOREIGN KEY (category_id) REFERENCES categories(category_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS course_categories_category_id_idx ON course_categories (category_id);
+
+CREATE TABLE IF NOT EXISTS course_tags
+(
+	course_id     UUID                        NOT NULL,
+	tag_id        UUID                        NOT NULL,
+
+	PRIMARY KEY (course_id, tag_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	FOREIGN KEY (tag_id) REFERENCES tags(tag_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS course_tags_tag_id_idx ON course_tags (tag_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS course_ratings
+(
+	course_id     UUID                        NOT NULL,
+	user_id       UUID                        NOT NULL,
+	stars         SMALLINT                    NOT NULL CHECK (stars BETWEEN 1 AND 5),
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (course_id, user_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS courses_status_idx;
+
+ALTER TABLE courses
+	DROP COLUMN IF EXISTS status;
@@ -0,0 +1,6 @@
+/* Existing courses are already on sale. */
+ALTER TABLE courses
+	ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published'
+	CHECK (status IN ('draft', 'published', 'archived'));
+
+CREATE INDEX IF NOT EXISTS courses_status_idx ON courses (status);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS courses_instructor_id_idx;
+
+ALTER TABLE courses
+	DROP COLUMN IF EXISTS instructor_id;
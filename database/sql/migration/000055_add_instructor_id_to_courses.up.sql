@@ -0,0 +1,4 @@
+ALTER TABLE courses
+	ADD COLUMN IF NOT EXISTS instructor_id UUID REFERENCES users(user_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS courses_instructor_id_idx ON courses (instructor_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS videos_section_id_idx;
+
+ALTER TABLE videos
+	DROP COLUMN IF EXISTS section_id;
+
+DROP TABLE IF EXISTS sections;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS sections
+(
+	section_id    UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+	index         INT                         NOT NULL,
+	title         TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	version       INT                         NOT NULL DEFAULT 1,
+
+	PRIMARY KEY (section_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	UNIQUE (course_id, index)
+);
+
+/* Videos out of any section are listed after the sections of the course. */
+ALTER TABLE videos
+	ADD COLUMN IF NOT EXISTS section_id UUID REFERENCES sections(section_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS videos_section_id_idx ON videos (section_id);
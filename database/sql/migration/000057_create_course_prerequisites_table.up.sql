@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS course_prerequisites
+(
+	course_id       UUID                        NOT NULL,
+	prerequisite_id UUID                        NOT NULL,
+	created_at      TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	CHECK (course_id <> prerequisite_id),
+	PRIMARY KEY (course_id, prerequisite_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	FOREIGN KEY (prerequisite_id) REFERENCES courses(course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS course_prerequisites_prerequisite_id_idx ON course_prerequisites (prerequisite_id);
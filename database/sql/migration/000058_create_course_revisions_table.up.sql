@@ -0,0 +1,22 @@
+/* Edits of the metadata of courses and of their videos. */
+CREATE TABLE IF NOT EXISTS course_revisions
+(
+	revision_id   UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+	/* NULL for the edits of the course itself. */
+	video_id      UUID,
+	/* NULL once the editor is removed. */
+	editor_id     UUID,
+	/* The metadata after the edit. */
+	snapshot      JSONB                       NOT NULL,
+	/* The fields changed by the edit, from and to. */
+	diff          JSONB                       NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (revision_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	FOREIGN KEY (video_id) REFERENCES videos(video_id) ON DELETE CASCADE,
+	FOREIGN KEY (editor_id) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS course_revisions_course_id_idx ON course_revisions (course_id, created_at);
@@ -0,0 +1,25 @@
+/* Accesses to courses granted by administrators without payment. Revoked
+   grants are kept as the audit trail. */
+CREATE TABLE IF NOT EXISTS course_grants
+(
+	grant_id      UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+	user_id       UUID                        NOT NULL,
+	reason        TEXT                        NOT NULL,
+	/* NULL once the administrator is removed. */
+	granted_by    UUID,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	revoked_at    TIMESTAMP,
+	revoked_by    UUID,
+	revoke_reason TEXT                        NOT NULL DEFAULT '',
+
+	PRIMARY KEY (grant_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+	FOREIGN KEY (granted_by) REFERENCES users(user_id) ON DELETE SET NULL,
+	FOREIGN KEY (revoked_by) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+/* A user has at most one active grant of a course. */
+CREATE UNIQUE INDEX IF NOT EXISTS course_grants_active_idx ON course_grants (course_id, user_id) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS course_grants_user_id_idx ON course_grants (user_id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS course_faqs
+(
+	faq_id        UUID                        NOT NULL,
+	course_id     UUID                        NOT NULL,
+	index         INT                         NOT NULL,
+	question      TEXT                        NOT NULL,
+	answer        TEXT                        NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+	version       INT                         NOT NULL DEFAULT 1,
+
+	PRIMARY KEY (faq_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	UNIQUE (course_id, index)
+);
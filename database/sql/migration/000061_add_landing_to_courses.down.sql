@@ -0,0 +1,5 @@
+ALTER TABLE courses
+	DROP COLUMN IF EXISTS objectives,
+	DROP COLUMN IF EXISTS requirements,
+	DROP COLUMN IF EXISTS audience,
+	DROP COLUMN IF EXISTS long_description;
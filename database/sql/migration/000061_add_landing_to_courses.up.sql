@@ -0,0 +1,7 @@
+/* The content of the landing page of a course. Long descriptions are
+   markdown. */
+ALTER TABLE courses
+	ADD COLUMN IF NOT EXISTS objectives TEXT[] NOT NULL DEFAULT '{}',
+	ADD COLUMN IF NOT EXISTS requirements TEXT[] NOT NULL DEFAULT '{}',
+	ADD COLUMN IF NOT EXISTS audience TEXT[] NOT NULL DEFAULT '{}',
+	ADD COLUMN IF NOT EXISTS long_description TEXT NOT NULL DEFAULT '';
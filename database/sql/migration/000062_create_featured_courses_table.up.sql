@@ -0,0 +1,11 @@
+/* The courses curated by administrators for the homepage, by position. */
+CREATE TABLE IF NOT EXISTS featured_courses
+(
+	course_id     UUID                        NOT NULL,
+	position      INT                         NOT NULL,
+	created_at    TIMESTAMP                   NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (course_id),
+	FOREIGN KEY (course_id) REFERENCES courses(course_id) ON DELETE CASCADE,
+	UNIQUE (position)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE courses
+	DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,34 @@
+/* Slugs identify courses in public URLs, e.g. /courses/go-in-practice.
+   Existing courses get theirs from the name, suffixed by the start of
+   their id when taken by an older course. */
+ALTER TABLE courses
+	ADD COLUMN IF NOT EXISTS slug TEXT;
+
+UPDATE courses c
+SET
+	slug = s.slug
+FROM (
+	SELECT
+		course_id,
+		CASE WHEN n = 1 THEN base ELSE base || '-' || LEFT(course_id::TEXT, 8) END AS slug
+	FROM (
+		SELECT
+			course_id,
+			base,
+			ROW_NUMBER() OVER (PARTITION BY base ORDER BY created_at, course_id) AS n
+		FROM (
+			SELECT
+				course_id,
+				created_at,
+				COALESCE(NULLIF(TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(name, '[^a-zA-Z0-9]+', '-', 'g'))), ''), 'course') AS base
+			FROM
+				courses
+		) b
+	) r
+) s
+WHERE
+	c.course_id = s.course_id;
+
+ALTER TABLE courses
+	ALTER COLUMN slug SET NOT NULL,
+	ADD CONSTRAINT courses_slug_key UNIQUE (slug);
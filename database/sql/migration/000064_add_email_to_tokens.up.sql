@@ -0,0 +1,3 @@
+/* The new email of the user, confirmed by email change tokens. */
+ALTER TABLE tokens
+	ADD COLUMN IF NOT EXISTS email TEXT;
@@ -0,0 +1,13 @@
+/* The accounts of external providers users sign in with, e.g. google,
+   identified by the subject the provider assigns to the user. */
+CREATE TABLE IF NOT EXISTS user_identities
+(
+	provider    TEXT         NOT NULL,
+	subject     TEXT         NOT NULL,
+	user_id     UUID         NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+	email       TEXT         NOT NULL,
+	created_at  TIMESTAMP    NOT NULL,
+
+	PRIMARY KEY (provider, subject),
+	UNIQUE (user_id, provider)
+);
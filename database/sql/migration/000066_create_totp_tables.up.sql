@@ -0,0 +1,21 @@
+/* The authenticator app of users having two-factor authentication,
+   enabled once the first code is confirmed. last_counter is the time
+   step of the last code accepted, which can't be used again. */
+CREATE TABLE IF NOT EXISTS user_totps
+(
+	user_id       UUID         PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+	secret        TEXT         NOT NULL,
+	confirmed_at  TIMESTAMP,
+	last_counter  BIGINT       NOT NULL DEFAULT 0,
+	created_at    TIMESTAMP    NOT NULL
+);
+
+/* Single use codes to login when the authenticator app is lost. */
+CREATE TABLE IF NOT EXISTS totp_recovery_codes
+(
+	user_id  UUID         NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+	hash     BYTEA        NOT NULL,
+	used_at  TIMESTAMP,
+
+	PRIMARY KEY (user_id, hash)
+);
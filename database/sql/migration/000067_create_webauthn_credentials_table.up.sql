@@ -0,0 +1,14 @@
+/* The passkeys users login with, identified by the id given by their
+   authenticator. public_key is encoded as COSE key. */
+CREATE TABLE IF NOT EXISTS webauthn_credentials
+(
+	credential_id  BYTEA        PRIMARY KEY,
+	user_id        UUID         NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+	public_key     BYTEA        NOT NULL,
+	sign_count     BIGINT       NOT NULL DEFAULT 0,
+	name           TEXT         NOT NULL,
+	created_at     TIMESTAMP    NOT NULL,
+	last_used_at   TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
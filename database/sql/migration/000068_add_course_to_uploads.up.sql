@@ -0,0 +1,4 @@
+/* The course the file is uploaded for, which instructors must teach.
+   Uploads made by administrators may have none. */
+ALTER TABLE uploads
+	ADD COLUMN IF NOT EXISTS course_id UUID REFERENCES courses(course_id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+/* Hash of the request the key was claimed for, so that the key can't be
+   reused for another one. */
+ALTER TABLE idempotency_keys
+	ADD COLUMN IF NOT EXISTS request_hash BYTEA NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE compensations
+	ADD CONSTRAINT compensations_provider_id_fkey FOREIGN KEY (provider_id) REFERENCES orders(provider_id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+/* Payments are compensated even when no order is bound to them. */
+ALTER TABLE compensations
+	DROP CONSTRAINT IF EXISTS compensations_provider_id_fkey;
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"html/template"
 	"net/smtp"
+	"strings"
+	"time"
 )
 
 //go:embed templates
@@ -22,8 +24,32 @@ type Emailer struct {
 
 // Links contains URLs to be send to customers via email.
 type Links struct {
-	RecoveryURL   string
-	ActivationURL string
+	RecoveryURL    string
+	ActivationURL  string
+	ClaimURL       string
+	CourseURL      string
+	EmailChangeURL string
+	LoginURL       string
+}
+
+// Receipt lists what has been bought with an order.
+// Amounts are formatted with their currency, e.g. "19.99 USD".
+type Receipt struct {
+	OrderID   string
+	Provider  string
+	Reference string
+	Total     string
+	Items     []ReceiptItem
+}
+
+// ReceiptItem is a course listed in a receipt. Owned is false when
+// the course has been bought for others, e.g. as a gift.
+type ReceiptItem struct {
+	CourseID string
+	Course   string
+	Quantity int
+	Paid     string
+	Owned    bool
 }
 
 // New builds and returns a ready-to-use Emailer.
@@ -34,42 +60,206 @@ func New(address string, password string, host string, port string, links Links)
 
 // SendActivationToken attempts to send the passed token to the specified user.
 func (e *Emailer) SendActivationToken(token string, to string) error {
-	t, err := template.New("email").ParseFS(templates, "templates/activation.tmpl")
-	if err != nil {
-		return fmt.Errorf("parsing email template: %w", err)
+	var data struct {
+		Link string
 	}
+	data.Link = e.links.ActivationURL + token
+
+	return e.send("templates/activation.tmpl", "Welcome to Govod!", data, to)
+}
 
+// SendRecoveryToken attempts to send the passed token to the specified user.
+func (e *Emailer) SendRecoveryToken(token string, to string) error {
 	var data struct {
 		Link string
 	}
-	data.Link = e.links.ActivationURL + token
+	data.Link = e.links.RecoveryURL + token
 
-	var body bytes.Buffer
-	err = t.ExecuteTemplate(&body, "html", data)
-	if err != nil {
-		return fmt.Errorf("executing template: %w", err)
+	return e.send("templates/reset-password.tmpl", "Reset your password", data, to)
+}
+
+// SendClaimToken sends the passed token to the guest who bought with
+// the specified email, to claim the account holding the purchase.
+func (e *Emailer) SendClaimToken(token string, to string) error {
+	var data struct {
+		Link string
 	}
+	data.Link = e.links.ClaimURL + token
 
-	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
-	subject := "Subject: Welcome to Govod!\n"
-	src := fmt.Sprintf("From: %s\r\n", e.from)
-	dst := fmt.Sprintf("To: %s\r\n", to)
-	bytes := append([]byte(src+dst+subject+mime), body.Bytes()...)
+	return e.send("templates/claim.tmpl", "Claim your Govod account", data, to)
+}
+
+// SendEmailChangeToken sends the passed token to the new email of a
+// user, to confirm the change.
+func (e *Emailer) SendEmailChangeToken(token string, to string) error {
+	var data struct {
+		Link string
+	}
+	data.Link = e.links.EmailChangeURL + token
 
-	return smtp.SendMail(e.host, e.auth, e.from, []string{to}, bytes)
+	return e.send("templates/email-change.tmpl", "Confirm your new email", data, to)
 }
 
-// SendRecoveryToken attempts to send the passed token to the specified user.
-func (e *Emailer) SendRecoveryToken(token string, to string) error {
-	t, err := template.New("email").ParseFS(templates, "templates/reset-password.tmpl")
-	if err != nil {
-		return fmt.Errorf("parsing email template: %w", err)
+// SendLoginToken sends the link to login without password to the
+// specified user.
+func (e *Emailer) SendLoginToken(token string, to string) error {
+	var data struct {
+		Link string
+	}
+	data.Link = e.links.LoginURL + token
+
+	return e.send("templates/login.tmpl", "Your Govod login link", data, to)
+}
+
+// SendEmailChanged informs the user, at the old address, that the email
+// of the account has been changed to the passed one.
+func (e *Emailer) SendEmailChanged(email string, to string) error {
+	var data struct {
+		Email string
+	}
+	data.Email = email
+
+	return e.send("templates/email-changed.tmpl", "Your Govod email has been changed", data, to)
+}
+
+// SendRefundNotice informs the user that the passed order has been refunded
+// because it could not be completed.
+func (e *Emailer) SendRefundNotice(orderID string, to string) error {
+	var data struct {
+		OrderID string
 	}
+	data.OrderID = orderID
 
+	return e.send("templates/refund.tmpl", "Your order has been refunded", data, to)
+}
+
+// SendFulfillmentAlert warns the administrators that the fulfillment of
+// the passed order failed after the payment.
+func (e *Emailer) SendFulfillmentAlert(orderID string, reason string, to []string) error {
+	var data struct {
+		OrderID string
+		Reason  string
+	}
+	data.OrderID = orderID
+	data.Reason = reason
+
+	return e.send("templates/fulfillment-alert.tmpl", "[ALERT] Order fulfillment failed", data, to...)
+}
+
+// SendCheckoutReminder invites the user to complete an abandoned purchase.
+func (e *Emailer) SendCheckoutReminder(cartURL string, to string) error {
 	var data struct {
 		Link string
 	}
-	data.Link = e.links.RecoveryURL + token
+	data.Link = cartURL
+
+	return e.send("templates/checkout-reminder.tmpl", "Your courses are waiting for you", data, to)
+}
+
+// SendCartReminder invites the user to complete the purchase of the
+// courses left in the cart.
+func (e *Emailer) SendCartReminder(cartURL string, unsubscribeURL string, to string) error {
+	var data struct {
+		Link        string
+		Unsubscribe string
+	}
+	data.Link = cartURL
+	data.Unsubscribe = unsubscribeURL
+
+	return e.send("templates/cart-reminder.tmpl", "You left something in your cart", data, to)
+}
+
+// SendAccessExpiring invites the user to renew the access to a rented course.
+func (e *Emailer) SendAccessExpiring(course string, renewURL string, expiresAt time.Time, to string) error {
+	var data struct {
+		Course    string
+		ExpiresAt string
+		Link      string
+	}
+	data.Course = course
+	data.ExpiresAt = expiresAt.Format("January 2, 2006")
+	data.Link = renewURL
+
+	return e.send("templates/access-expiring.tmpl", "Your access to "+course+" is expiring", data, to)
+}
+
+// SendSaleNotice tells the user that a course of the wishlist is on
+// sale, until endsAt.
+func (e *Emailer) SendSaleNotice(course string, courseID string, percent int, endsAt time.Time, to string) error {
+	var data struct {
+		Course  string
+		Percent int
+		EndsAt  string
+		Link    string
+	}
+	data.Course = course
+	data.Percent = percent
+	data.EndsAt = endsAt.Format("January 2, 2006")
+	data.Link = e.links.CourseURL + courseID
+
+	return e.send("templates/sale-notice.tmpl", course+" is on sale", data, to)
+}
+
+// SendOrgInvitation invites the user to join an organization.
+func (e *Emailer) SendOrgInvitation(org string, joinURL string, to string) error {
+	var data struct {
+		Org  string
+		Link string
+	}
+	data.Org = org
+	data.Link = joinURL
+
+	return e.send("templates/org-invitation.tmpl", "You have been invited to join "+org, data, to)
+}
+
+// SendGift sends the recipient of a gift the link to redeem it.
+func (e *Emailer) SendGift(course string, from string, redeemURL string, to string) error {
+	var data struct {
+		Course string
+		From   string
+		Link   string
+	}
+	data.Course = course
+	data.From = from
+	data.Link = redeemURL
+
+	return e.send("templates/gift.tmpl", from+" gifted you "+course, data, to)
+}
+
+// SendReceipt sends the buyer the receipt of a fulfilled order, with the
+// links to start learning the courses owned.
+func (e *Emailer) SendReceipt(r Receipt, to string) error {
+	type item struct {
+		ReceiptItem
+		Link string
+	}
+
+	var data struct {
+		Receipt
+		Items []item
+	}
+	data.Receipt = r
+	for _, it := range r.Items {
+		i := item{ReceiptItem: it}
+		if it.Owned {
+			i.Link = e.links.CourseURL + it.CourseID
+		}
+		data.Items = append(data.Items, i)
+	}
+
+	return e.send("templates/receipt.tmpl", "Your Govod receipt", data, to)
+}
+
+// send renders the passed template with data and sends it to the recipients.
+func (e *Emailer) send(tmpl string, subject string, data any, to ...string) error {
+	if len(to) == 0 {
+		return nil
+	}
+
+	t, err := template.New("email").ParseFS(templates, tmpl)
+	if err != nil {
+		return fmt.Errorf("parsing email template: %w", err)
+	}
 
 	var body bytes.Buffer
 	err = t.ExecuteTemplate(&body, "html", data)
@@ -78,10 +268,10 @@ func (e *Emailer) SendRecoveryToken(token string, to string) error {
 	}
 
 	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
-	subject := "Subject: Reset your password\n"
+	subj := fmt.Sprintf("Subject: %s\n", subject)
 	src := fmt.Sprintf("From: %s\r\n", e.from)
-	dst := fmt.Sprintf("To: %s\r\n", to)
-	bytes := append([]byte(src+dst+subject+mime), body.Bytes()...)
+	dst := fmt.Sprintf("To: %s\r\n", strings.Join(to, ", "))
+	bytes := append([]byte(src+dst+subj+mime), body.Bytes()...)
 
-	return smtp.SendMail(e.host, e.auth, e.from, []string{to}, bytes)
+	return smtp.SendMail(e.host, e.auth, e.from, to, bytes)
 }
@@ -0,0 +1,193 @@
+// Package money models monetary amounts.
+// Amounts are always expressed in the minor unit of their currency
+// (e.g. cents for USD), so that no precision is lost with floats and
+// every payment provider receives exactly the same value.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when operating on amounts
+// expressed in different currencies.
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// zeroDecimal lists the currencies which have no minor unit.
+var zeroDecimal = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "JPY": true,
+	"KMF": true, "KRW": true, "MGA": true, "PYG": true, "RWF": true,
+	"UGX": true, "VND": true, "VUV": true, "XAF": true, "XOF": true,
+	"XPF": true,
+}
+
+// threeDecimal lists the currencies whose minor unit is a thousandth.
+var threeDecimal = map[string]bool{
+	"BHD": true, "JOD": true, "KWD": true, "OMR": true, "TND": true,
+}
+
+// exponent returns the number of decimals of the minor unit of the
+// currency, 2 unless listed otherwise.
+func exponent(currency string) int {
+	switch {
+	case zeroDecimal[currency]:
+		return 0
+	case threeDecimal[currency]:
+		return 3
+	}
+	return 2
+}
+
+// Amount models a monetary value of a specific currency.
+// Units are expressed in minor units, Currency is an ISO 4217 code.
+//
+// Amounts are stored in the database as the composite type
+// `amount (units BIGINT, currency TEXT)`.
+type Amount struct {
+	Units    int64  `json:"units" validate:"gte=0"`
+	Currency string `json:"currency" validate:"required,iso4217"`
+}
+
+// New returns an amount of the passed minor units of a currency.
+func New(units int64, currency string) Amount {
+	return Amount{Units: units, Currency: strings.ToUpper(currency)}
+}
+
+// Zero returns an empty amount of the passed currency.
+func Zero(currency string) Amount {
+	return New(0, currency)
+}
+
+// IsZero reports whether the amount has no value.
+func (a Amount) IsZero() bool {
+	return a.Units == 0
+}
+
+// Add returns the sum of two amounts of the same currency.
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, fmt.Errorf("adding %s to %s: %w", b.Currency, a.Currency, ErrCurrencyMismatch)
+	}
+	return Amount{Units: a.Units + b.Units, Currency: a.Currency}, nil
+}
+
+// Sub returns the difference of two amounts of the same currency.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if a.Currency != b.Currency {
+		return Amount{}, fmt.Errorf("subtracting %s from %s: %w", b.Currency, a.Currency, ErrCurrencyMismatch)
+	}
+	return Amount{Units: a.Units - b.Units, Currency: a.Currency}, nil
+}
+
+// Mul returns the amount multiplied by n, e.g. the price of n seats.
+func (a Amount) Mul(n int64) Amount {
+	return Amount{Units: a.Units * n, Currency: a.Currency}
+}
+
+// Sum adds up all the passed amounts, which must share the same currency.
+// The sum of no amounts is the zero amount of the passed currency.
+func Sum(currency string, amounts ...Amount) (Amount, error) {
+	tot := Zero(currency)
+	for _, a := range amounts {
+		var err error
+		if tot, err = tot.Add(a); err != nil {
+			return Amount{}, err
+		}
+	}
+	return tot, nil
+}
+
+// Allocate splits the amount into shares proportional to the passed
+// amounts, e.g. to spread the price of a bundle over its courses.
+// Shares add up to the amount exactly: the units left by rounding go
+// to the first non zero amounts, one each. When the amount doesn't
+// exceed the sum of the passed ones, no share exceeds its amount.
+func (a Amount) Allocate(amounts ...Amount) ([]Amount, error) {
+	tot, err := Sum(a.Currency, amounts...)
+	if err != nil {
+		return nil, err
+	}
+	if tot.Units <= 0 {
+		return nil, errors.New("allocating over no value")
+	}
+
+	shares := make([]Amount, len(amounts))
+	left := a.Units
+	for i, b := range amounts {
+		shares[i] = New(b.Units*a.Units/tot.Units, a.Currency)
+		left -= shares[i].Units
+	}
+
+	for i := 0; left > 0; i = (i + 1) % len(amounts) {
+		if amounts[i].Units > 0 {
+			shares[i].Units++
+			left--
+		}
+	}
+
+	return shares, nil
+}
+
+// Decimal formats the amount in major units, e.g. "19.99" for
+// 1999 USD cents or "1.999" for 1999 KWD fils. This is the format
+// expected by paypal.
+func (a Amount) Decimal() string {
+	exp := exponent(a.Currency)
+	if exp == 0 {
+		return strconv.FormatInt(a.Units, 10)
+	}
+
+	pow := int64(1)
+	for i := 0; i < exp; i++ {
+		pow *= 10
+	}
+
+	sign := ""
+	v := a.Units
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, v/pow, exp, v%pow)
+}
+
+// String implements the fmt.Stringer interface.
+func (a Amount) String() string {
+	return a.Decimal() + " " + a.Currency
+}
+
+// Value implements the driver.Valuer interface, encoding the amount
+// as a literal of the composite type.
+func (a Amount) Value() (driver.Value, error) {
+	return fmt.Sprintf("(%d,%s)", a.Units, a.Currency), nil
+}
+
+// Scan implements the sql.Scanner interface, decoding a literal
+// of the composite type, e.g. `(1999,USD)`.
+func (a *Amount) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("cannot scan %T into an amount", src)
+	}
+
+	fields := strings.Split(strings.Trim(s, "()"), ",")
+	if len(fields) != 2 {
+		return fmt.Errorf("malformed amount %q", s)
+	}
+
+	v, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed amount value %q: %w", s, err)
+	}
+
+	*a = New(v, strings.Trim(fields[1], `"`))
+	return nil
+}
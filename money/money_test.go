@@ -0,0 +1,107 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		exp    string
+	}{
+		{New(1999, "USD"), "19.99"},
+		{New(5, "eur"), "0.05"},
+		{New(100, "USD"), "1.00"},
+		{New(0, "USD"), "0.00"},
+		{New(-250, "USD"), "-2.50"},
+		{New(1500, "JPY"), "1500"},
+		{New(1999, "KWD"), "1.999"},
+		{New(50, "bhd"), "0.050"},
+		{New(-1000, "TND"), "-1.000"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.Decimal(); got != tt.exp {
+			t.Errorf("decimal of %d %s: got %q, expected %q", tt.amount.Units, tt.amount.Currency, got, tt.exp)
+		}
+	}
+}
+
+func TestSum(t *testing.T) {
+	tot, err := Sum("USD", New(1000, "USD"), New(999, "USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tot != New(1999, "USD") {
+		t.Fatalf("wrong sum: got %s", tot)
+	}
+
+	if _, err := Sum("USD", New(1000, "USD"), New(999, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("summing different currencies should fail, got: %v", err)
+	}
+}
+
+func TestMul(t *testing.T) {
+	if got := New(1999, "USD").Mul(3); got != New(5997, "USD") {
+		t.Fatalf("wrong product: got %s", got)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		amount  Amount
+		amounts []Amount
+		exp     []Amount
+	}{
+		{New(3000, "USD"), []Amount{New(2000, "USD"), New(2000, "USD")}, []Amount{New(1500, "USD"), New(1500, "USD")}},
+		{New(1000, "USD"), []Amount{New(1000, "USD"), New(1000, "USD"), New(1000, "USD")}, []Amount{New(334, "USD"), New(333, "USD"), New(333, "USD")}},
+		{New(5, "USD"), []Amount{New(0, "USD"), New(3, "USD"), New(3, "USD")}, []Amount{New(0, "USD"), New(3, "USD"), New(2, "USD")}},
+		{New(2499, "USD"), []Amount{New(1999, "USD"), New(999, "USD")}, []Amount{New(1667, "USD"), New(832, "USD")}},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.amount.Allocate(tt.amounts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range tt.exp {
+			if got[i] != tt.exp[i] {
+				t.Errorf("allocating %s: got %v, expected %v", tt.amount, got, tt.exp)
+				break
+			}
+		}
+	}
+
+	if _, err := New(1000, "USD").Allocate(New(1000, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("allocating over different currencies should fail, got: %v", err)
+	}
+
+	if _, err := New(1000, "USD").Allocate(New(0, "USD")); err == nil {
+		t.Fatal("allocating over no value should fail")
+	}
+}
+
+func TestScan(t *testing.T) {
+	a := New(1999, "USD")
+
+	v, err := a.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Amount
+	if err := got.Scan([]byte(v.(string))); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != a {
+		t.Fatalf("scanned amount %s differs from %s", got, a)
+	}
+
+	if err := got.Scan("(19.99,USD)"); err == nil {
+		t.Fatal("scanning a malformed amount should fail")
+	}
+}
@@ -0,0 +1,136 @@
+// Package pdf writes simple text documents in the PDF format.
+// Documents are A4 pages of lines written with the standard Helvetica
+// fonts, which every PDF reader provides, so no font is embedded.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page sizes and margins, in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+	Margin     = 50
+)
+
+// text is a string drawn on a page.
+type text struct {
+	x, y float64
+	size float64
+	bold bool
+	s    string
+}
+
+// Document is a PDF document being written line by line.
+// Texts are drawn on the current line, starting from the top of the
+// first page. Pages are added when the current one is full.
+type Document struct {
+	pages [][]text
+	y     float64
+}
+
+// New returns an empty document of one page.
+func New() *Document {
+	return &Document{pages: [][]text{nil}, y: PageHeight - Margin}
+}
+
+// Text draws s on the current line, x points from the left margin.
+func (d *Document) Text(x float64, size float64, s string) {
+	d.draw(x, size, false, s)
+}
+
+// Bold draws s in bold on the current line, x points from the left margin.
+func (d *Document) Bold(x float64, size float64, s string) {
+	d.draw(x, size, true, s)
+}
+
+// Ln moves to the next line, h points below the current one.
+func (d *Document) Ln(h float64) {
+	d.y -= h
+	if d.y < Margin {
+		d.pages = append(d.pages, nil)
+		d.y = PageHeight - Margin
+	}
+}
+
+// draw adds a text to the current page.
+func (d *Document) draw(x float64, size float64, bold bool, s string) {
+	p := len(d.pages) - 1
+	d.pages[p] = append(d.pages[p], text{x: Margin + x, y: d.y - size, size: size, bold: bold, s: s})
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() []byte {
+	var b bytes.Buffer
+	var offsets []int
+
+	// Objects are numbered from 1 in the order they are written:
+	// the catalog, the page tree, the fonts, then each page followed
+	// by its content stream.
+	obj := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	const firstPage = 5
+
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+
+	b.WriteString("%PDF-1.4\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, texts := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, firstPage+2*i+1))
+
+		var c bytes.Buffer
+		for _, t := range texts {
+			font := "F1"
+			if t.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&c, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, t.size, t.x, t.y, escape(t.s))
+		}
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", c.Len(), c.Bytes()))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return b.Bytes()
+}
+
+// escape encodes s as the content of a PDF literal string.
+// Characters missing from the WinAnsi encoding are replaced by '?'.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '€':
+			b.WriteString(`\200`)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, `\%03o`, r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp string
+	}{
+		{"Invoice 42", "Invoice 42"},
+		{`a (b) \c`, `a \(b\) \\c`},
+		{"10 €", `10 \200`},
+		{"Città", `Citt\340`},
+		{"日本", "??"},
+	}
+
+	for _, tt := range tests {
+		if got := escape(tt.s); got != tt.exp {
+			t.Errorf("escaping %q: got %q, expected %q", tt.s, got, tt.exp)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	d := New()
+	d.Bold(0, 18, "Invoice")
+	d.Ln(24)
+
+	// Fill more than a page.
+	for i := 0; i < 100; i++ {
+		d.Text(0, 10, "line "+strconv.Itoa(i))
+		d.Ln(14)
+	}
+
+	b := d.Bytes()
+
+	if !bytes.HasPrefix(b, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(b, []byte("%%EOF\n")) {
+		t.Fatalf("malformed document:\n%s", b)
+	}
+
+	if !bytes.Contains(b, []byte("/Count 2")) {
+		t.Fatalf("expected two pages:\n%s", b)
+	}
+
+	// Every object must be where the cross-reference table says.
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(b, -1)
+	if len(offsets) != 8 {
+		t.Fatalf("expected 8 objects, got %d", len(offsets))
+	}
+	for i, o := range offsets {
+		off, _ := strconv.Atoi(string(o[1]))
+		exp := strconv.Itoa(i+1) + " 0 obj"
+		if !bytes.HasPrefix(b[off:], []byte(exp)) {
+			t.Fatalf("object %d not found at offset %d", i+1, off)
+		}
+	}
+}
@@ -17,8 +17,16 @@ import (
 	"github.com/jatolentino/tutorialspoint/api/background"
 	"github.com/jatolentino/tutorialspoint/config"
 	"github.com/jatolentino/tutorialspoint/core/auth"
+	"github.com/jatolentino/tutorialspoint/core/caption"
+	"github.com/jatolentino/tutorialspoint/core/cart"
+	"github.com/jatolentino/tutorialspoint/core/media"
+	"github.com/jatolentino/tutorialspoint/core/order"
+	"github.com/jatolentino/tutorialspoint/core/search"
+	"github.com/jatolentino/tutorialspoint/core/video"
+	"github.com/jatolentino/tutorialspoint/core/wishlist"
 	"github.com/jatolentino/tutorialspoint/database"
 	"github.com/jatolentino/tutorialspoint/email"
+	"github.com/jatolentino/tutorialspoint/storage"
 	"github.com/sirupsen/logrus"
 	stripecl "github.com/stripe/stripe-go/v74/client"
 )
@@ -62,8 +70,12 @@ func Run(logger *logrus.Logger) error {
 
 	// Build a mailer.
 	links := email.Links{
-		ActivationURL: cfg.Email.ActivationURL,
-		RecoveryURL:   cfg.Email.RecoveryURL,
+		ActivationURL:  cfg.Email.ActivationURL,
+		RecoveryURL:    cfg.Email.RecoveryURL,
+		ClaimURL:       cfg.Email.ClaimURL,
+		CourseURL:      cfg.Email.CourseURL,
+		EmailChangeURL: cfg.Email.EmailChangeURL,
+		LoginURL:       cfg.Email.LoginURL,
 	}
 	mail := email.New(cfg.Email.Address, cfg.Email.Password, cfg.Email.Host, cfg.Email.Port, links)
 
@@ -89,17 +101,216 @@ func Run(logger *logrus.Logger) error {
 	strp := &stripecl.API{}
 	strp.Init(cfg.Stripe.APISecret, nil)
 
-	// Instantiate known oauth providers.
+	// Instantiate the oauth providers configured.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Oauth.DiscoveryTimeout)
 	defer cancel()
-	google := cfg.Oauth.Google
-	oauthProvs, err := auth.MakeProviders(ctx, []auth.ProviderConfig{
-		{Name: "google", Client: google.Client, Secret: google.Secret, URL: google.URL, RedirectURL: google.RedirectURL},
-	})
+	var oauthCfgs []auth.ProviderConfig
+	if google := cfg.Oauth.Google; google.Client != "" {
+		oauthCfgs = append(oauthCfgs, auth.ProviderConfig{Name: "google", Kind: auth.KindOIDC, Client: google.Client, Secret: google.Secret, URL: google.URL, RedirectURL: google.RedirectURL})
+	}
+	if github := cfg.Oauth.Github; github.Client != "" {
+		oauthCfgs = append(oauthCfgs, auth.ProviderConfig{Name: "github", Kind: auth.KindGithub, Client: github.Client, Secret: github.Secret, URL: github.URL, APIURL: github.APIURL, RedirectURL: github.RedirectURL})
+	}
+	if apple := cfg.Oauth.Apple; apple.Client != "" {
+		oauthCfgs = append(oauthCfgs, auth.ProviderConfig{Name: "apple", Kind: auth.KindApple, Client: apple.Client, Secret: apple.Key, URL: apple.URL, TeamID: apple.TeamID, KeyID: apple.KeyID, RedirectURL: apple.RedirectURL})
+	}
+	oauthProvs, err := auth.MakeProviders(ctx, oauthCfgs)
 	if err != nil {
 		return fmt.Errorf("failed to discover oauth providers: %w", err)
 	}
 
+	// Build the engine serving searches.
+	engine, err := search.NewEngine(cfg.Search, db)
+	if err != nil {
+		return fmt.Errorf("failed to build the search engine: %w", err)
+	}
+
+	// Build the storage of the files served to the users.
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to build the storage: %w", err)
+	}
+
+	// Retry the fulfillment of payed orders in background, refunding
+	// users whose orders can't be fulfilled at all.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	provs := order.NewProviders(pp, strp, cfg.Stripe, cfg.Razorpay, cfg.Coinbase, cfg.Mollie)
+
+	// Alert the operators about critical payment failures.
+	var alerters order.Alerters
+	if cfg.Alerts.Email {
+		alerters = append(alerters, &order.MailAlerter{DB: db, Mailer: mail})
+	}
+	if cfg.Alerts.SlackURL != "" {
+		alerters = append(alerters, order.NewSlack(cfg.Alerts.SlackURL))
+	}
+	if cfg.Alerts.PagerDutyKey != "" {
+		alerters = append(alerters, order.NewPagerDuty(cfg.Alerts.PagerDutyKey))
+	}
+
+	comp := &order.Compensator{
+		DB:          db,
+		Providers:   provs,
+		Mailer:      mail,
+		Alerter:     alerters,
+		Log:         logger,
+		MaxAttempts: cfg.Compensation.MaxAttempts,
+		Backoff:     cfg.Compensation.Backoff,
+		MaxBackoff:  cfg.Compensation.MaxBackoff,
+	}
+	bg.Add(func() error {
+		return comp.Run(workerCtx, cfg.Compensation.Interval)
+	})
+
+	// Close the orders whose payment never completed.
+	stale := &order.Expirer{
+		DB:        db,
+		Providers: provs,
+		Log:       logger,
+		TTL:       cfg.StaleOrders.TTL,
+	}
+	bg.Add(func() error {
+		return stale.Run(workerCtx, cfg.StaleOrders.Interval)
+	})
+
+	// Invite users to renew rented courses before they expire.
+	exp := &order.ExpiryNotifier{
+		DB:       db,
+		Mailer:   mail,
+		Log:      logger,
+		Before:   cfg.Rental.NoticeBefore,
+		RenewURL: cfg.Rental.RenewURL,
+	}
+	bg.Add(func() error {
+		return exp.Run(workerCtx, cfg.Rental.Interval)
+	})
+
+	// Send their codes to the recipients of payed gifts.
+	gifts := &order.GiftNotifier{
+		DB:        db,
+		Mailer:    mail,
+		Log:       logger,
+		RedeemURL: cfg.Gifts.RedeemURL,
+	}
+	bg.Add(func() error {
+		return gifts.Run(workerCtx, cfg.Gifts.Interval)
+	})
+
+	// Send the guests who bought without an account the link to claim it.
+	guests := &order.ClaimNotifier{
+		DB:     db,
+		Mailer: mail,
+		Log:    logger,
+		TTL:    cfg.Guests.ClaimTTL,
+	}
+	bg.Add(func() error {
+		return guests.Run(workerCtx, cfg.Guests.Interval)
+	})
+
+	// Report the payments which don't match the orders.
+	recon := &order.Reconciler{
+		DB:        db,
+		Providers: provs,
+		Log:       logger,
+		Window:    cfg.Reconciliation.Window,
+		Grace:     cfg.Reconciliation.Grace,
+	}
+	bg.Add(func() error {
+		return recon.Run(workerCtx, cfg.Reconciliation.Interval)
+	})
+
+	// Remind the users of the courses left in their carts, if enabled.
+	if cfg.AbandonedCarts.Secret != "" {
+		carts := &cart.Reminder{
+			DB:             db,
+			Mailer:         mail,
+			Log:            logger,
+			IdleAfter:      cfg.AbandonedCarts.IdleAfter,
+			CartURL:        cfg.AbandonedCarts.CartURL,
+			UnsubscribeURL: cfg.AbandonedCarts.UnsubscribeURL,
+			Secret:         cfg.AbandonedCarts.Secret,
+		}
+		bg.Add(func() error {
+			return carts.Run(workerCtx, cfg.AbandonedCarts.Interval)
+		})
+	}
+
+	// Tell the users about the sales of the courses they wish for.
+	wishes := &wishlist.SaleNotifier{
+		DB:     db,
+		Mailer: mail,
+		Log:    logger,
+	}
+	bg.Add(func() error {
+		return wishes.Run(workerCtx, cfg.Wishlist.Interval)
+	})
+
+	// Publish the events of the outbox, e.g. the orders fulfilled,
+	// sending their receipts to the buyers.
+	consumers := order.Consumers{
+		&order.ReceiptMailer{DB: db, Mailer: mail},
+	}
+	if cfg.Outbox.WebhookURL != "" {
+		consumers = append(consumers, order.NewWebhook(cfg.Outbox.WebhookURL))
+	}
+	relay := &order.Relay{
+		DB:        db,
+		Consumers: consumers,
+		Log:       logger,
+		Batch:     cfg.Outbox.Batch,
+	}
+	bg.Add(func() error {
+		return relay.Run(workerCtx, cfg.Outbox.Interval)
+	})
+
+	// Generate the captions of the videos, if enabled.
+	var videoListeners []video.Listener
+	if cfg.Captions.Enabled {
+		capt := &caption.Captioner{
+			DB:          db,
+			STT:         caption.NewWhisper(cfg.Captions.URL, cfg.Captions.APIKey, cfg.Captions.Model),
+			BG:          bg,
+			Log:         logger,
+			Language:    cfg.Captions.Language,
+			MaxAttempts: cfg.Captions.MaxAttempts,
+			Backoff:     cfg.Captions.Backoff,
+		}
+		bg.Add(func() error {
+			return capt.Run(workerCtx, cfg.Captions.Interval)
+		})
+		videoListeners = append(videoListeners, capt)
+	}
+
+	// Encode the videos to HLS, if enabled.
+	if cfg.Media.Enabled {
+		tc := &media.Transcoder{
+			DB:          db,
+			Encoder:     media.NewFFmpeg(cfg.Media.FFmpeg, store),
+			BG:          bg,
+			Log:         logger,
+			MaxAttempts: cfg.Media.MaxAttempts,
+			Backoff:     cfg.Media.Backoff,
+		}
+		bg.Add(func() error {
+			return tc.Run(workerCtx, cfg.Media.Interval)
+		})
+		videoListeners = append(videoListeners, tc)
+	}
+
+	// Fill the missing images of the videos with their thumbnails,
+	// extracted from the native videos when encoding is enabled.
+	thumbs := &media.Thumbnailer{
+		DB:      db,
+		Storage: store,
+		BG:      bg,
+	}
+	if cfg.Media.Enabled {
+		thumbs.Framer = media.NewFFmpeg(cfg.Media.FFmpeg, store)
+	}
+	videoListeners = append(videoListeners, thumbs)
+
 	// Construct the mux for the API calls.
 	mux := api.APIMux(api.APIConfig{
 		CorsOrigin:         cfg.Cors.Origin,
@@ -112,9 +323,25 @@ func Run(logger *logrus.Logger) error {
 		Paypal:             pp,
 		Stripe:             strp,
 		StripeCfg:          cfg.Stripe,
+		RazorpayCfg:        cfg.Razorpay,
+		CoinbaseCfg:        cfg.Coinbase,
+		MollieCfg:          cfg.Mollie,
+		TranscodingCfg:     cfg.Transcoding,
+		RefundsCfg:         cfg.Refunds,
+		UploadsCfg:         cfg.Uploads,
+		ResourcesCfg:       cfg.Resources,
+		ProgressCfg:        cfg.Progress,
+		PrerequisitesCfg:   cfg.Prerequisites,
+		AbandonedCartsCfg:  cfg.AbandonedCarts,
 		Providers:          oauthProvs,
 		LoginRedirectURL:   cfg.Oauth.LoginRedirectURL,
+		OrgJoinURL:         cfg.Org.JoinURL,
 		ActivationRequired: cfg.Auth.ActivationRequired,
+		TwoFactorCfg:       cfg.TwoFactor,
+		WebauthnCfg:        cfg.Webauthn,
+		Search:             engine,
+		Storage:            store,
+		VideoListeners:     videoListeners,
 	})
 
 	// Construct a server to service the requests against the mux.
@@ -155,6 +382,7 @@ func Run(logger *logrus.Logger) error {
 			return fmt.Errorf("could not stop server gracefully: %w", err)
 		}
 
+		stopWorkers()
 		if err := bg.Shutdown(ctx); err != nil {
 			return fmt.Errorf("could not complete all background tasks: %w", err)
 		}
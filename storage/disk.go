@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Disk stores the files into a directory of the local disk, which is
+// expected to be served at baseURL.
+type Disk struct {
+	dir     string
+	baseURL string
+}
+
+// NewDisk returns a storage writing into dir.
+func NewDisk(dir string, baseURL string) *Disk {
+	return &Disk{dir: dir, baseURL: baseURL}
+}
+
+// Put implements the Storage interface. The file is written aside and
+// then moved in place, so that it's never served partially.
+func (d *Disk) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	path := filepath.Join(d.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating directory of %s: %w", key, err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".put-")
+	if err != nil {
+		return fmt.Errorf("creating file of %s: %w", key, err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", key, err)
+	}
+	if n != size {
+		return fmt.Errorf("writing %s: expected %d bytes, got %d", key, size, n)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing %s: %w", key, err)
+	}
+
+	// CreateTemp creates the file private to its owner.
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return fmt.Errorf("opening %s: %w", key, err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("moving %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Delete implements the Storage interface.
+// Deleting missing files succeeds.
+func (d *Disk) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(d.dir, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("deleting %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// URL implements the Storage interface.
+func (d *Disk) URL(key string) string {
+	return join(d.baseURL, key)
+}
+
+// SignedURL implements the Storage interface. The files on disk are
+// served as they are, so the URL doesn't expire.
+func (d *Disk) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return d.URL(key), nil
+}
+
+// Open implements the Storage interface.
+func (d *Disk) Open(ctx context.Context, key string) (*Object, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(d.dir, filepath.FromSlash(key)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("opening %s: %w", key, ErrNotFound)
+		}
+		return nil, fmt.Errorf("opening %s: %w", key, err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading size of %s: %w", key, err)
+	}
+	if fi.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("opening %s: %w", key, ErrNotFound)
+	}
+
+	return &Object{
+		ReadSeekCloser: f,
+		Size:           fi.Size(),
+		ContentType:    contentType(key),
+		ModTime:        fi.ModTime(),
+	}, nil
+}
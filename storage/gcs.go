@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/jwt"
+)
+
+// gcsScope allows reading and writing the objects of the buckets.
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// GCS stores the files into a Google Cloud Storage bucket, authorized
+// as a service account. Files are served at baseURL, the bucket itself
+// when empty.
+type GCS struct {
+	apiURL  string
+	bucket  string
+	baseURL string
+	email   string
+	key     *rsa.PrivateKey
+	client  *http.Client
+}
+
+// NewGCS returns a storage writing into bucket, authorized with the
+// JSON key of a service account.
+func NewGCS(credentials []byte, bucket string, baseURL string) (*GCS, error) {
+	var key struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(credentials, &key); err != nil {
+		return nil, fmt.Errorf("decoding gcs credentials: %w", err)
+	}
+
+	cfg := &jwt.Config{
+		Email:      key.ClientEmail,
+		PrivateKey: []byte(key.PrivateKey),
+		Scopes:     []string{gcsScope},
+		TokenURL:   key.TokenURI,
+	}
+
+	pk, err := parseRSAKey(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding gcs private key: %w", err)
+	}
+
+	if baseURL == "" {
+		baseURL = "https://storage.googleapis.com/" + bucket
+	}
+
+	return &GCS{
+		apiURL:  "https://storage.googleapis.com",
+		bucket:  bucket,
+		baseURL: baseURL,
+		email:   key.ClientEmail,
+		key:     pk,
+		client:  cfg.Client(context.Background()),
+	}, nil
+}
+
+// parseRSAKey decodes the PEM encoded private key of a service account.
+func parseRSAKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rk, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not a rsa key")
+	}
+	return rk, nil
+}
+
+// Put implements the Storage interface.
+func (g *GCS) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	q.Set("uploadType", "media")
+	q.Set("name", key)
+	u := g.apiURL + "/upload/storage/v1/b/" + url.PathEscape(g.bucket) + "/o?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, r)
+	if err != nil {
+		return fmt.Errorf("building gcs request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	return g.do(req, key)
+}
+
+// Delete implements the Storage interface.
+// Deleting missing files succeeds.
+func (g *GCS) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	u := g.apiURL + "/storage/v1/b/" + url.PathEscape(g.bucket) + "/o/" + url.PathEscape(key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return fmt.Errorf("building gcs request: %w", err)
+	}
+
+	return g.do(req, key)
+}
+
+// URL implements the Storage interface.
+func (g *GCS) URL(key string) string {
+	return join(g.baseURL, key)
+}
+
+// SignedURL implements the Storage interface, signing the download of
+// the object as the service account. GCS accepts at most 7 days.
+func (g *GCS) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(g.apiURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing gcs url: %w", err)
+	}
+
+	segs := strings.Split(key, "/")
+	for i, seg := range segs {
+		segs[i] = awsEscape(seg)
+	}
+	path := "/" + awsEscape(g.bucket) + "/" + strings.Join(segs, "/")
+
+	now := time.Now().UTC()
+	date := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/auto/storage/goog4_request"
+
+	q := url.Values{}
+	q.Set("X-Goog-Algorithm", "GOOG4-RSA-SHA256")
+	q.Set("X-Goog-Credential", g.email+"/"+scope)
+	q.Set("X-Goog-Date", date)
+	q.Set("X-Goog-Expires", strconv.Itoa(int(ttl.Seconds())))
+	q.Set("X-Goog-SignedHeaders", "host")
+	query := canonicalQuery(q)
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		path,
+		query,
+		"host:" + u.Host,
+		"",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := sha256.Sum256([]byte("GOOG4-RSA-SHA256\n" + date + "\n" + scope + "\n" + hex.EncodeToString(hash[:])))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, g.key, crypto.SHA256, toSign[:])
+	if err != nil {
+		return "", fmt.Errorf("signing url of %s: %w", key, err)
+	}
+
+	return u.Scheme + "://" + u.Host + path + "?" + query + "&X-Goog-Signature=" + hex.EncodeToString(sig), nil
+}
+
+// Open implements the Storage interface. The metadata of the object is
+// fetched first, and then its media with ranged requests.
+func (g *GCS) Open(ctx context.Context, key string) (*Object, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	u := g.apiURL + "/storage/v1/b/" + url.PathEscape(g.bucket) + "/o/" + url.PathEscape(key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building gcs request: %w", err)
+	}
+
+	resp, err := g.send(req, key)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var meta struct {
+		Size        string    `json:"size"`
+		ContentType string    `json:"contentType"`
+		Updated     time.Time `json:"updated"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("decoding gcs metadata of %s: %w", key, err)
+	}
+
+	size, err := strconv.ParseInt(meta.Size, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("decoding gcs size of %s: %w", key, err)
+	}
+
+	obj := Object{
+		Size:        size,
+		ContentType: meta.ContentType,
+		ModTime:     meta.Updated,
+	}
+	if obj.ContentType == "" {
+		obj.ContentType = contentType(key)
+	}
+
+	get := func(ctx context.Context, off int64) (io.ReadCloser, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+"?alt=media", nil)
+		if err != nil {
+			return nil, fmt.Errorf("building gcs request: %w", err)
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+
+		resp, err := g.send(req, key)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	obj.ReadSeekCloser = &rangeReader{ctx: ctx, get: get, size: size}
+
+	return &obj, nil
+}
+
+// do sends the request, decoding the error returned by GCS.
+func (g *GCS) do(req *http.Request, key string) error {
+	resp, err := g.send(req, key)
+	if err != nil {
+		if req.Method == http.MethodDelete && errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// send sends the request, returning the response when it succeeds and
+// the error returned by GCS otherwise.
+func (g *GCS) send(req *http.Request, key string) (*http.Response, error) {
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling gcs for %s: %w", key, err)
+	}
+
+	if resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("gcs %s of %s: %w", req.Method, key, ErrNotFound)
+	}
+
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error.Message == "" {
+		return nil, fmt.Errorf("gcs %s of %s: status code %d", req.Method, key, resp.StatusCode)
+	}
+	return nil, fmt.Errorf("gcs %s of %s: %s", req.Method, key, e.Error.Message)
+}
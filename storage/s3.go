@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unsignedPayload tells S3 that the body is not part of the signature,
+// so that files are streamed without being read twice.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3 stores the files into an S3 bucket, or the bucket of any service
+// compatible with its API, e.g. MinIO or Cloudflare R2. Buckets are
+// addressed by path, which all of them support.
+// Files are served at baseURL, the bucket itself when empty.
+type S3 struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	baseURL   string
+	client    *http.Client
+}
+
+// NewS3 returns a storage writing into the bucket at endpoint.
+func NewS3(endpoint string, region string, bucket string, accessKey string, secretKey string, baseURL string) *S3 {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if baseURL == "" {
+		baseURL = endpoint + "/" + bucket
+	}
+
+	return &S3{
+		endpoint:  endpoint,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		baseURL:   baseURL,
+		client:    &http.Client{},
+	}
+}
+
+// Put implements the Storage interface.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	req, err := s.request(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	return s.do(req, key)
+}
+
+// Delete implements the Storage interface.
+// S3 succeeds when deleting missing files.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.do(req, key)
+}
+
+// URL implements the Storage interface.
+func (s *S3) URL(key string) string {
+	return join(s.baseURL, key)
+}
+
+// SignedURL implements the Storage interface, presigning the download
+// of the object from the bucket itself. S3 accepts at most 7 days.
+func (s *S3) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+
+	req, err := s.request(context.Background(), http.MethodGet, key, nil)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+
+	q := url.Values{}
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+	query := canonicalQuery(q)
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		req.URL.EscapedPath(),
+		query,
+		"host:" + req.URL.Host,
+		"",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	sig := hex.EncodeToString(hmacSHA256(s.signingKey(day), toSign))
+
+	return req.URL.Scheme + "://" + req.URL.Host + req.URL.EscapedPath() + "?" + query + "&X-Amz-Signature=" + sig, nil
+}
+
+// Open implements the Storage interface. The object is described by
+// a HEAD request, and then read with ranged GET requests.
+func (s *S3) Open(ctx context.Context, key string) (*Object, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := s.request(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.send(req, key)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	obj := Object{
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if obj.ContentType == "" {
+		obj.ContentType = contentType(key)
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.ModTime = t
+	}
+
+	get := func(ctx context.Context, off int64) (io.ReadCloser, error) {
+		req, err := s.request(ctx, http.MethodGet, key, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+
+		resp, err := s.send(req, key)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	obj.ReadSeekCloser = &rangeReader{ctx: ctx, get: get, size: obj.Size}
+
+	return &obj, nil
+}
+
+// request returns the request of the passed method on the object at key.
+func (s *S3) request(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing s3 endpoint: %w", err)
+	}
+
+	segs := strings.Split(key, "/")
+	escaped := make([]string, len(segs))
+	for i, seg := range segs {
+		escaped[i] = awsEscape(seg)
+	}
+	u.Path = "/" + s.bucket + "/" + key
+	u.RawPath = "/" + awsEscape(s.bucket) + "/" + strings.Join(escaped, "/")
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("building s3 request: %w", err)
+	}
+
+	return req, nil
+}
+
+// do signs and sends the request, decoding the error returned by S3.
+func (s *S3) do(req *http.Request, key string) error {
+	resp, err := s.send(req, key)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// send signs and sends the request, returning the response when it
+// succeeds and the error returned by S3 otherwise.
+func (s *S3) send(req *http.Request, key string) (*http.Response, error) {
+	s.sign(req, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling s3 for %s: %w", key, err)
+	}
+
+	if resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		return nil, fmt.Errorf("s3 %s of %s: %w", req.Method, key, ErrNotFound)
+	}
+
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&e); err != nil || e.Code == "" {
+		return nil, fmt.Errorf("s3 %s of %s: status code %d", req.Method, key, resp.StatusCode)
+	}
+	return nil, fmt.Errorf("s3 %s of %s: %s: %s", req.Method, key, e.Code, e.Message)
+}
+
+// sign adds to the request the AWS signature version 4 at now.
+func (s *S3) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	const signed = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + unsignedPayload,
+		"x-amz-date:" + amzDate,
+		"",
+		signed,
+		unsignedPayload,
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signed, hex.EncodeToString(hmacSHA256(s.signingKey(day), toSign)),
+	))
+}
+
+// signingKey returns the key signing the requests of the passed day.
+func (s *S3) signingKey(day string) []byte {
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsEscape escapes every byte of s but the unreserved characters,
+// as required by the AWS signature.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,188 @@
+// Package storage stores the files served to the users, e.g. videos
+// and images, on the local disk or in a cloud bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jatolentino/tutorialspoint/config"
+)
+
+// Available drivers.
+const (
+	DriverDisk = "disk"
+	DriverS3   = "s3"
+	DriverGCS  = "gcs"
+)
+
+// ErrInvalidKey is returned for keys which are not a relative path.
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// ErrNotFound is returned when opening a missing file.
+var ErrNotFound = errors.New("file not found")
+
+// Storage stores files under keys, slash separated paths such as
+// `uploads/{id}/lesson.mp4`, and tells where they are served.
+// SignedURL returns a URL granting access to a file for ttl, for the
+// files which are not meant to be public.
+// Open returns a file to be served by the API itself. Only the parts
+// read are fetched from the cloud buckets, after seeking to them.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+	SignedURL(key string, ttl time.Duration) (string, error)
+	Open(ctx context.Context, key string) (*Object, error)
+}
+
+// Object is a file opened from the storage.
+type Object struct {
+	io.ReadSeekCloser
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// New returns the storage of the driver selected by cfg.
+func New(cfg config.Storage) (Storage, error) {
+	switch cfg.Driver {
+	case DriverDisk:
+		return NewDisk(cfg.Disk.Dir, cfg.BaseURL), nil
+	case DriverS3:
+		return NewS3(cfg.S3.Endpoint, cfg.S3.Region, cfg.S3.Bucket, cfg.S3.AccessKey, cfg.S3.SecretKey, cfg.BaseURL), nil
+	case DriverGCS:
+		creds, err := os.ReadFile(cfg.GCS.Credentials)
+		if err != nil {
+			return nil, fmt.Errorf("reading gcs credentials: %w", err)
+		}
+		return NewGCS(creds, cfg.GCS.Bucket, cfg.BaseURL)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
+// checkKey verifies that key is a relative path not escaping the root.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." || strings.Contains(seg, `\`) {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+// escapeKey escapes each segment of key to be used in a URL path.
+func escapeKey(key string) string {
+	segs := strings.Split(key, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+// canonicalQuery returns the query sorted by key, escaped as required
+// by the signatures of S3 and GCS.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, awsEscape(k)+"="+awsEscape(q.Get(k)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// join returns the URL of the key under base.
+func join(base string, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + escapeKey(key)
+}
+
+// contentType guesses the type of the file at key from its extension.
+// The types of the files of HLS encodings are not known to mime.
+func contentType(key string) string {
+	ext := path.Ext(key)
+	switch ext {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".ts":
+		return "video/mp2t"
+	case ".mp4":
+		return "video/mp4"
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// rangeReader reads a remote file of size from its offset, requesting
+// the rest of the file from there with get on the first read following
+// a seek.
+type rangeReader struct {
+	ctx  context.Context
+	get  func(ctx context.Context, off int64) (io.ReadCloser, error)
+	size int64
+	off  int64
+	body io.ReadCloser
+}
+
+func (rr *rangeReader) Read(p []byte) (int, error) {
+	if rr.off >= rr.size {
+		return 0, io.EOF
+	}
+
+	if rr.body == nil {
+		body, err := rr.get(rr.ctx, rr.off)
+		if err != nil {
+			return 0, err
+		}
+		rr.body = body
+	}
+
+	n, err := rr.body.Read(p)
+	rr.off += int64(n)
+	return n, err
+}
+
+func (rr *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += rr.off
+	case io.SeekEnd:
+		offset += rr.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seeking before the start of the file")
+	}
+
+	if offset != rr.off {
+		rr.Close()
+		rr.off = offset
+	}
+	return offset, nil
+}
+
+func (rr *rangeReader) Close() error {
+	if rr.body == nil {
+		return nil
+	}
+	err := rr.body.Close()
+	rr.body = nil
+	return err
+}
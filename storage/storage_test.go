@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDisk(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	d := NewDisk(dir, "https://cdn.example.com/")
+
+	if err := d.Put(ctx, "uploads/1/my lesson.mp4", strings.NewReader("video"), 5, "video/mp4"); err != nil {
+		t.Fatalf("putting file: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "uploads", "1", "my lesson.mp4"))
+	if err != nil || string(b) != "video" {
+		t.Fatalf("expected the file stored, got %q: %v", b, err)
+	}
+
+	if got, exp := d.URL("uploads/1/my lesson.mp4"), "https://cdn.example.com/uploads/1/my%20lesson.mp4"; got != exp {
+		t.Fatalf("expected url %s, got %s", exp, got)
+	}
+
+	// Files shorter than expected are not stored.
+	if err := d.Put(ctx, "uploads/2/short.mp4", strings.NewReader("vid"), 5, "video/mp4"); err == nil {
+		t.Fatal("expected an error putting a truncated file")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "uploads", "2", "short.mp4")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no truncated file, got %v", err)
+	}
+
+	obj, err := d.Open(ctx, "uploads/1/my lesson.mp4")
+	if err != nil {
+		t.Fatalf("opening file: %v", err)
+	}
+	b, err = io.ReadAll(obj)
+	obj.Close()
+	if err != nil || string(b) != "video" || obj.Size != 5 || obj.ContentType != "video/mp4" {
+		t.Fatalf("expected the file opened, got %q of %+v: %v", b, obj, err)
+	}
+
+	if err := d.Delete(ctx, "uploads/1/my lesson.mp4"); err != nil {
+		t.Fatalf("deleting file: %v", err)
+	}
+	if _, err := d.Open(ctx, "uploads/1/my lesson.mp4"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("opening missing file: expected ErrNotFound, got %v", err)
+	}
+	if err := d.Delete(ctx, "uploads/1/my lesson.mp4"); err != nil {
+		t.Fatalf("deleting missing file: %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "uploads//x", `uploads\x`} {
+		if err := d.Put(ctx, key, strings.NewReader(""), 0, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("key %q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
+
+func TestS3(t *testing.T) {
+	var got *http.Request
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewS3(srv.URL, "eu-west-1", "courses", "key", "secret", "")
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "uploads/1/my lesson+1.mp4", strings.NewReader("video"), 5, "video/mp4"); err != nil {
+		t.Fatalf("putting file: %v", err)
+	}
+
+	if got.Method != http.MethodPut || got.URL.EscapedPath() != "/courses/uploads/1/my%20lesson%2B1.mp4" || body != "video" {
+		t.Fatalf("unexpected request %s %s with body %q", got.Method, got.URL.EscapedPath(), body)
+	}
+	if got.ContentLength != 5 || got.Header.Get("Content-Type") != "video/mp4" {
+		t.Fatalf("unexpected headers %v", got.Header)
+	}
+
+	auth := got.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=key/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+		t.Fatalf("unexpected authorization %s", auth)
+	}
+
+	if err := s.Delete(ctx, "uploads/1/my lesson+1.mp4"); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("expected the error of s3, got %v", err)
+	}
+
+	if got, exp := s.URL("uploads/1/a.mp4"), srv.URL+"/courses/uploads/1/a.mp4"; got != exp {
+		t.Fatalf("expected url %s, got %s", exp, got)
+	}
+
+	signed, err := s.SignedURL("resources/1/notes v2.pdf", time.Hour)
+	if err != nil {
+		t.Fatalf("signing url: %v", err)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parsing signed url: %v", err)
+	}
+
+	q := u.Query()
+	if u.EscapedPath() != "/courses/resources/1/notes%20v2.pdf" || q.Get("X-Amz-Expires") != "3600" || len(q.Get("X-Amz-Signature")) != 64 {
+		t.Fatalf("unexpected signed url %s", signed)
+	}
+	if !strings.HasPrefix(q.Get("X-Amz-Credential"), "key/") || q.Get("X-Amz-SignedHeaders") != "host" {
+		t.Fatalf("unexpected signed url %s", signed)
+	}
+}
+
+func TestS3Open(t *testing.T) {
+	const content = "0123456789"
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/courses/media/1/seg.ts" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "video/mp2t")
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			return
+		}
+
+		ranges = append(ranges, r.Header.Get("Range"))
+		var off int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &off)
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, content[off:])
+	}))
+	defer srv.Close()
+
+	s := NewS3(srv.URL, "eu-west-1", "courses", "key", "secret", "")
+	ctx := context.Background()
+
+	if _, err := s.Open(ctx, "media/1/missing.ts"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("opening missing file: expected ErrNotFound, got %v", err)
+	}
+
+	obj, err := s.Open(ctx, "media/1/seg.ts")
+	if err != nil {
+		t.Fatalf("opening file: %v", err)
+	}
+	defer obj.Close()
+
+	if obj.Size != 10 || obj.ContentType != "video/mp2t" || len(ranges) != 0 {
+		t.Fatalf("expected the file described only, got %+v with ranges %v", obj, ranges)
+	}
+
+	if _, err := obj.Seek(6, io.SeekStart); err != nil {
+		t.Fatalf("seeking: %v", err)
+	}
+	b := make([]byte, 2)
+	if _, err := io.ReadFull(obj, b); err != nil || string(b) != "67" {
+		t.Fatalf("expected the bytes at 6, got %q: %v", b, err)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=6-" {
+		t.Fatalf("expected a ranged request, got %v", ranges)
+	}
+}